@@ -0,0 +1,257 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	gethrpc "github.com/ethereum/go-ethereum/rpc"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// Operation commands talk to the administrative APIs of running services.
+
+const adminRequestTimeout = 10 * time.Second
+
+var (
+	adminOpt struct {
+		nodeUrl  string // node manager RPC URL
+		adminUrl string // administrative RPC URL of RPC service
+		group    string
+		space    string
+		reason   string // reason of node maintenance
+	}
+
+	nodesCmd = &cobra.Command{
+		Use:   "nodes",
+		Short: "Manage full nodes via node management service",
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Help()
+		},
+	}
+
+	nodesListCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List full nodes of group",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			var urls []string
+			mustCallAdmin(adminOpt.nodeUrl, &urls, "node_list", adminOpt.group)
+
+			for _, url := range urls {
+				fmt.Println(url)
+			}
+		},
+	}
+
+	nodesAddCmd = &cobra.Command{
+		Use:   "add <url>",
+		Short: "Add full node into group",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			mustCallAdmin(adminOpt.nodeUrl, nil, "node_add", adminOpt.group, args[0])
+		},
+	}
+
+	nodesRemoveCmd = &cobra.Command{
+		Use:   "remove <url>",
+		Short: "Remove full node from group",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			mustCallAdmin(adminOpt.nodeUrl, nil, "node_remove", adminOpt.group, args[0])
+		},
+	}
+
+	nodesDrainCmd = &cobra.Command{
+		Use:   "drain <url>",
+		Short: "Stop routing requests to full node, but keep it monitored",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var ok bool
+			if mustCallAdmin(adminOpt.nodeUrl, &ok, "node_drain", adminOpt.group, args[0]); !ok {
+				logrus.Fatal("Node not found")
+			}
+		},
+	}
+
+	nodesUndrainCmd = &cobra.Command{
+		Use:   "undrain <url>",
+		Short: "Resume routing requests to drained full node",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var ok bool
+			if mustCallAdmin(adminOpt.nodeUrl, &ok, "node_undrain", adminOpt.group, args[0]); !ok {
+				logrus.Fatal("Node not found or not drained")
+			}
+		},
+	}
+
+	nodesMaintainCmd = &cobra.Command{
+		Use:   "maintain <url>",
+		Short: "Put full node under maintenance in all groups, which is excluded from routing but kept monitored",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var groups []string
+			if mustCallAdmin(adminOpt.nodeUrl, &groups, "node_setMaintenance", args[0], adminOpt.reason); len(groups) == 0 {
+				logrus.Fatal("Node not found")
+			}
+
+			fmt.Printf("Node under maintenance in groups: %v\n", groups)
+		},
+	}
+
+	nodesUnmaintainCmd = &cobra.Command{
+		Use:   "unmaintain <url>",
+		Short: "Resume routing requests to full node under maintenance in all groups",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var groups []string
+			if mustCallAdmin(adminOpt.nodeUrl, &groups, "node_clearMaintenance", args[0]); len(groups) == 0 {
+				logrus.Fatal("Node not found or not under maintenance")
+			}
+
+			fmt.Printf("Node maintenance finished in groups: %v\n", groups)
+		},
+	}
+
+	nodesMaintenanceCmd = &cobra.Command{
+		Use:   "maintenance",
+		Short: "Show full nodes under maintenance of all groups",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			var result json.RawMessage
+			mustCallAdmin(adminOpt.nodeUrl, &result, "node_maintenance")
+			printJson(result)
+		},
+	}
+
+	nodesStatusCmd = &cobra.Command{
+		Use:   "status [url]",
+		Short: "Show health status of full nodes",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var url *string
+			if len(args) > 0 {
+				url = &args[0]
+			}
+
+			var result json.RawMessage
+			mustCallAdmin(adminOpt.nodeUrl, &result, "node_status", adminOpt.group, url)
+			printJson(result)
+		},
+	}
+
+	nodesPartitionsCmd = &cobra.Command{
+		Use:   "partitions",
+		Short: "Show key space ownership of full nodes in hash ring",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			var result json.RawMessage
+			mustCallAdmin(adminOpt.nodeUrl, &result, "node_partitions", adminOpt.group)
+			printJson(result)
+		},
+	}
+
+	ratelimitCmd = &cobra.Command{
+		Use:   "ratelimit",
+		Short: "Inspect rate limit settings and revoke limit keys of RPC service",
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Help()
+		},
+	}
+
+	ratelimitShowCmd = &cobra.Command{
+		Use:   "show",
+		Short: "Show rate limit strategies in use",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			var result json.RawMessage
+			mustCallAdmin(adminOpt.adminUrl, &result, "admin_rateLimitStrategies", adminOpt.space)
+			printJson(result)
+		},
+	}
+
+	ratelimitRevokeCmd = &cobra.Command{
+		Use:   "revoke <key>...",
+		Short: "Revoke limit keys from cache immediately",
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var result json.RawMessage
+			mustCallAdmin(adminOpt.adminUrl, &result, "admin_revokeKeys", args)
+			printJson(result)
+		},
+	}
+
+	cacheCmd = &cobra.Command{
+		Use:   "cache",
+		Short: "Manage memory caches of RPC service",
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Help()
+		},
+	}
+
+	cachePurgeCmd = &cobra.Command{
+		Use:   "purge",
+		Short: "Purge all cached RPC responses",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			mustCallAdmin(adminOpt.adminUrl, nil, "admin_purgeCache")
+		},
+	}
+)
+
+func init() {
+	nodesCmd.PersistentFlags().StringVar(
+		&adminOpt.nodeUrl, "url", "http://127.0.0.1:28530", "node management RPC URL",
+	)
+	nodesCmd.PersistentFlags().StringVarP(
+		&adminOpt.group, "group", "g", "ethhttp", "node group",
+	)
+	nodesMaintainCmd.Flags().StringVar(&adminOpt.reason, "reason", "", "reason of maintenance, e.g. upgrade")
+	nodesCmd.AddCommand(
+		nodesListCmd, nodesAddCmd, nodesRemoveCmd, nodesDrainCmd, nodesUndrainCmd, nodesStatusCmd, nodesPartitionsCmd,
+		nodesMaintainCmd, nodesUnmaintainCmd, nodesMaintenanceCmd,
+	)
+
+	ratelimitCmd.PersistentFlags().StringVar(
+		&adminOpt.adminUrl, "url", "http://127.0.0.1:22540", "administrative RPC URL of RPC service",
+	)
+	ratelimitShowCmd.Flags().StringVarP(
+		&adminOpt.space, "space", "s", "eth", "space name, cfx or eth",
+	)
+	ratelimitCmd.AddCommand(ratelimitShowCmd, ratelimitRevokeCmd)
+
+	cacheCmd.PersistentFlags().StringVar(
+		&adminOpt.adminUrl, "url", "http://127.0.0.1:22540", "administrative RPC URL of RPC service",
+	)
+	cacheCmd.AddCommand(cachePurgeCmd)
+
+	rootCmd.AddCommand(nodesCmd, ratelimitCmd, cacheCmd)
+}
+
+// mustCallAdmin calls administrative RPC method, or exits if failed.
+func mustCallAdmin(url string, result interface{}, method string, args ...interface{}) {
+	ctx, cancel := context.WithTimeout(context.Background(), adminRequestTimeout)
+	defer cancel()
+
+	logger := logrus.WithFields(logrus.Fields{"url": url, "method": method})
+
+	client, err := gethrpc.DialContext(ctx, url)
+	if err != nil {
+		logger.WithError(err).Fatal("Failed to connect to administrative RPC")
+	}
+	defer client.Close()
+
+	if err := client.CallContext(ctx, result, method, args...); err != nil {
+		logger.WithError(err).Fatal("Failed to call administrative RPC")
+	}
+}
+
+func printJson(data json.RawMessage) {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(data)
+}
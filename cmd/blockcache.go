@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"github.com/scroll-tech/rpc-gateway/store"
+	"github.com/scroll-tech/rpc-gateway/store/blockcache"
+	"github.com/scroll-tech/rpc-gateway/util/rpc"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// block cache build options
+	blockCacheOpt struct {
+		nodeURL  string
+		from, to uint64
+		out      string
+		useBatch bool
+		// number of confirmations to regard block as finalized
+		confirmations uint64
+	}
+
+	blockCacheCmd = &cobra.Command{
+		Use:   "blockcache",
+		Short: "Manage memory mapped block cache files of finalized blocks and receipts",
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Help()
+		},
+	}
+
+	blockCacheBuildCmd = &cobra.Command{
+		Use:   "build",
+		Short: "Generate block cache file offline from full node for the specified finalized block range",
+		Run:   buildBlockCache,
+	}
+)
+
+func init() {
+	blockCacheBuildCmd.Flags().StringVarP(&blockCacheOpt.nodeURL, "url", "u", "", "full node RPC URL")
+	blockCacheBuildCmd.Flags().Uint64VarP(&blockCacheOpt.from, "from", "f", 0, "first block number (inclusive)")
+	blockCacheBuildCmd.Flags().Uint64VarP(&blockCacheOpt.to, "to", "t", 0, "last block number (inclusive)")
+	blockCacheBuildCmd.Flags().StringVarP(&blockCacheOpt.out, "out", "o", "", "block cache file to generate")
+	blockCacheBuildCmd.Flags().BoolVar(&blockCacheOpt.useBatch, "batch", true, "query block receipts in batch")
+	blockCacheBuildCmd.Flags().Uint64Var(
+		&blockCacheOpt.confirmations, "confirmations", 1000, "number of confirmations to regard block as finalized",
+	)
+
+	blockCacheBuildCmd.MarkFlagRequired("url")
+	blockCacheBuildCmd.MarkFlagRequired("to")
+	blockCacheBuildCmd.MarkFlagRequired("out")
+
+	blockCacheCmd.AddCommand(blockCacheBuildCmd)
+	rootCmd.AddCommand(blockCacheCmd)
+}
+
+func buildBlockCache(*cobra.Command, []string) {
+	opt := blockCacheOpt
+	logger := logrus.WithFields(logrus.Fields{
+		"url": opt.nodeURL, "from": opt.from, "to": opt.to, "out": opt.out,
+	})
+
+	if opt.from > opt.to {
+		logger.Fatal("Invalid block range")
+	}
+
+	w3c, err := rpc.NewEthClient(opt.nodeURL)
+	if err != nil {
+		logger.WithError(err).Fatal("Failed to create eth client")
+	}
+
+	chainId, err := w3c.Eth.ChainId()
+	if err != nil || chainId == nil {
+		logger.WithError(err).Fatal("Failed to get chain id")
+	}
+
+	// only finalized blocks are immutable to cache
+	latest, err := w3c.Eth.BlockNumber()
+	if err != nil || latest == nil {
+		logger.WithError(err).Fatal("Failed to get latest block number")
+	}
+
+	if latest.Uint64() < opt.to+opt.confirmations {
+		logger.WithField("latest", latest).Fatal("Block range not finalized yet")
+	}
+
+	builder, err := blockcache.NewBuilder(opt.out, *chainId, opt.from)
+	if err != nil {
+		logger.WithError(err).Fatal("Failed to create block cache builder")
+	}
+
+	var prev *store.EthData
+	for bn := opt.from; bn <= opt.to; bn++ {
+		data, err := store.QueryEthData(w3c, bn, opt.useBatch)
+		if err == nil && prev != nil {
+			if ok, desc := data.IsContinuousTo(prev); !ok {
+				err = store.ErrChainReorged
+				logger = logger.WithField("desc", desc)
+			}
+		}
+
+		if err == nil {
+			err = builder.Add(data)
+		}
+
+		if err != nil {
+			builder.Abort()
+			logger.WithError(err).WithField("block", bn).Fatal("Failed to add block into block cache")
+		}
+
+		if (bn-opt.from+1)%1000 == 0 {
+			logger.WithField("block", bn).Info("Block cache build in progress")
+		}
+
+		prev = data
+	}
+
+	if err := builder.Finish(); err != nil {
+		logger.WithError(err).Fatal("Failed to finish block cache file")
+	}
+
+	logger.Info("Block cache file generated")
+}
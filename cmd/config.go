@@ -0,0 +1,212 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	viperutil "github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/pkg/errors"
+	"github.com/scroll-tech/rpc-gateway/node"
+	"github.com/scroll-tech/rpc-gateway/rpc"
+	"github.com/scroll-tech/rpc-gateway/store/mysql"
+	"github.com/scroll-tech/rpc-gateway/util/rate"
+	"github.com/scroll-tech/rpc-gateway/util/rpc/middlewares"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// sanityCheckConfig configurations of sanity checks at startup.
+type sanityCheckConfig struct {
+	// whether to run sanity checks at startup, and fail fast if any check failed
+	Enabled bool
+	// whether to probe reachability of nodes and external services
+	Probe bool `default:"true"`
+	// timeout to probe each node or external service
+	Timeout time.Duration `default:"5s"`
+}
+
+// sanityCheck checks some aspect of configurations, and returns an error for each failure.
+type sanityCheck struct {
+	name  string
+	check func() []error
+}
+
+var (
+	validateOpt sanityCheckConfig
+
+	configCmd = &cobra.Command{
+		Use:   "config",
+		Short: "Configuration utilities",
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Help()
+		},
+	}
+
+	configValidateCmd = &cobra.Command{
+		Use:   "validate",
+		Short: "Validate configurations, including node reachability, node groups, rate limit rules and Web3Pay",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			// configurations are validated by components once loaded, and it fails fast otherwise
+			storeCtx := mustInitStoreContext()
+			defer storeCtx.Close()
+
+			if failures := runSanityChecks(&validateOpt, storeCtx); failures > 0 {
+				fmt.Printf("%v check(s) failed\n", failures)
+				os.Exit(1)
+			}
+
+			fmt.Println("Configurations validated")
+		},
+	}
+)
+
+func init() {
+	configValidateCmd.Flags().BoolVar(
+		&validateOpt.Probe, "probe", true, "whether to probe reachability of nodes and external services",
+	)
+
+	configValidateCmd.Flags().DurationVar(
+		&validateOpt.Timeout, "timeout", 5*time.Second, "timeout to probe each node or external service",
+	)
+
+	configCmd.AddCommand(configValidateCmd)
+	rootCmd.AddCommand(configCmd)
+}
+
+// mustSanityCheck runs sanity checks at startup if enabled, and exits if any check failed.
+func mustSanityCheck(storeCtx storeContext) {
+	var conf sanityCheckConfig
+	viperutil.MustUnmarshalKey("sanityCheck", &conf)
+
+	if !conf.Enabled {
+		return
+	}
+
+	if failures := runSanityChecks(&conf, storeCtx); failures > 0 {
+		logrus.WithField("failures", failures).Fatal("Sanity checks failed, please fix configurations and restart")
+	}
+}
+
+// runSanityChecks runs all sanity checks, prints failures along with actionable messages, and
+// returns the number of failed checks.
+func runSanityChecks(conf *sanityCheckConfig, storeCtx storeContext) int {
+	checks := []sanityCheck{
+		{"node groups", node.CheckGroups},
+		{"rate limit rules", func() []error { return checkRateLimitRules(storeCtx) }},
+		{"web3pay", func() []error { return checkWeb3Pay(conf) }},
+	}
+
+	if conf.Probe {
+		checks = append(checks, sanityCheck{"node reachability", func() []error {
+			return node.ProbeNodes(conf.Timeout)
+		}})
+	}
+
+	var failures int
+
+	for _, c := range checks {
+		errs := c.check()
+		if len(errs) == 0 {
+			fmt.Printf("[OK]   %v\n", c.name)
+			continue
+		}
+
+		for _, err := range errs {
+			fmt.Printf("[FAIL] %v: %v\n", c.name, err)
+		}
+
+		failures += len(errs)
+	}
+
+	return failures
+}
+
+// checkRateLimitRules checks rate limit strategies in database if any, and the strategies
+// referenced by configurations.
+func checkRateLimitRules(storeCtx storeContext) []error {
+	strategies := make(map[string]bool)
+
+	dbs := []struct {
+		space string
+		db    *mysql.MysqlStore
+	}{{"cfx", storeCtx.cfxDB}, {"eth", storeCtx.ethDB}}
+
+	var errs []error
+	for _, v := range dbs {
+		if v.db == nil {
+			continue
+		}
+
+		conf := v.db.LoadRateLimitConfigs()
+		if conf == nil {
+			errs = append(errs, errors.Errorf("failed to load rate limit strategies of %v space from database", v.space))
+			continue
+		}
+
+		for _, err := range rate.ValidateStrategies(conf.Strategies) {
+			errs = append(errs, errors.WithMessagef(err, "%v space", v.space))
+		}
+
+		for _, s := range conf.Strategies {
+			strategies[s.Name] = true
+		}
+	}
+
+	// strategies are unknown without database
+	if len(strategies) == 0 {
+		return errs
+	}
+
+	var quota rpc.SubscriptionQuotaConfig
+	viperutil.MustUnmarshalKey("rpc.subscriptionQuota", &quota)
+
+	for _, tier := range quota.Tiers {
+		if !strategies[tier.Strategy] {
+			errs = append(errs, errors.Errorf(
+				"rate limit strategy %v of subscription quota tier not found, please fix `rpc.subscriptionQuota.tiers`",
+				tier.Strategy,
+			))
+		}
+	}
+
+	return errs
+}
+
+// checkWeb3Pay checks Web3Pay client configurations and gateway reachability if enabled.
+func checkWeb3Pay(conf *sanityCheckConfig) []error {
+	var web3pay struct {
+		Enabled    bool
+		Gateway    string
+		BillingKey string
+	}
+	viperutil.MustUnmarshalKey("web3pay", &web3pay)
+
+	if !web3pay.Enabled {
+		return nil
+	}
+
+	if len(web3pay.Gateway) == 0 || len(web3pay.BillingKey) == 0 {
+		return []error{errors.New("`web3pay.gateway` and `web3pay.billingKey` are required when billing enabled")}
+	}
+
+	if _, _, err := middlewares.NewWeb3PayClient(); err != nil {
+		return []error{errors.WithMessage(err, "failed to create Web3Pay client, please check `web3pay`")}
+	}
+
+	if !conf.Probe {
+		return nil
+	}
+
+	client := http.Client{Timeout: conf.Timeout}
+
+	resp, err := client.Get(web3pay.Gateway)
+	if err != nil {
+		return []error{errors.WithMessagef(err, "Web3Pay gateway %v unreachable", web3pay.Gateway)}
+	}
+	resp.Body.Close()
+
+	return nil
+}
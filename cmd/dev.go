@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	cmdutil "github.com/scroll-tech/rpc-gateway/cmd/util"
+	"github.com/scroll-tech/rpc-gateway/node"
+	"github.com/scroll-tech/rpc-gateway/util/mock"
+	rpcutil "github.com/scroll-tech/rpc-gateway/util/rpc"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// dev boot options
+	devOpt struct {
+		mock.ChainConfig
+		nodeEndpoint string
+	}
+
+	devCmd = &cobra.Command{
+		Use:   "dev",
+		Short: "Start evm space RPC server against deterministic mock chain for local development",
+		Args:  cobra.NoArgs,
+		Run:   startDevService,
+	}
+)
+
+func init() {
+	devCmd.Flags().Uint64Var(&devOpt.ChainId, "chainId", 1337, "chain ID of mock chain")
+	devCmd.Flags().Uint64Var(&devOpt.Height, "height", 100, "initial height of mock chain")
+	devCmd.Flags().DurationVar(
+		&devOpt.BlockTime, "blockTime", 2*time.Second, "interval to produce a new block, and 0 means never",
+	)
+	devCmd.Flags().StringVar(
+		&devOpt.nodeEndpoint, "nodeEndpoint", "127.0.0.1:28546", "served HTTP endpoint of mock node",
+	)
+
+	rootCmd.AddCommand(devCmd)
+}
+
+// devRouter routes requests of all node groups to the mock node.
+type devRouter string
+
+func (r devRouter) Route(group node.Group, key []byte) string {
+	return string(r)
+}
+
+func startDevService(*cobra.Command, []string) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+
+	chain := mock.NewChain(devOpt.ChainConfig)
+	nodeUrl := mustServeMockNode(ctx, &wg, chain, devOpt.nodeEndpoint)
+
+	logrus.WithFields(logrus.Fields{
+		"chainId": devOpt.ChainId,
+		"node":    nodeUrl,
+	}).Info("Mock node started")
+
+	// the full middleware pipeline without database and full nodes
+	startEvmSpaceRpcServer(ctx, &wg, storeContext{}, devRouter(nodeUrl))
+
+	cmdutil.GracefulShutdown(&wg, cancel)
+}
+
+// mustServeMockNode serves JSON-RPC of mock chain over HTTP until context done, and returns
+// the node URL.
+func mustServeMockNode(ctx context.Context, wg *sync.WaitGroup, chain *mock.Chain, endpoint string) string {
+	handler, err := mock.NewHandler(chain)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to create mock node")
+	}
+
+	// listen before RPC server started, which requests chain ID at startup
+	listener, err := net.Listen("tcp", endpoint)
+	if err != nil {
+		logrus.WithError(err).WithField("endpoint", endpoint).Fatal("Failed to listen mock node endpoint")
+	}
+
+	server := &http.Server{Handler: handler}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		<-ctx.Done()
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), rpcutil.DefaultShutdownTimeout)
+		defer cancel()
+
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			logrus.WithError(err).Warn("Failed to shutdown mock node")
+		}
+	}()
+
+	go func() {
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			logrus.WithError(err).Fatal("Failed to serve mock node")
+		}
+	}()
+
+	return "http://" + listener.Addr().String()
+}
@@ -6,9 +6,11 @@ import (
 
 	"github.com/scroll-tech/rpc-gateway/cmd/util"
 	"github.com/scroll-tech/rpc-gateway/node"
+	"github.com/scroll-tech/rpc-gateway/util/reload"
 	"github.com/scroll-tech/rpc-gateway/util/rpc"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
 )
 
 var (
@@ -44,9 +46,15 @@ func startNodeManagerService(*cobra.Command, []string) {
 		logrus.Fatal("No node mananger server specified")
 	}
 
+	// fail fast if any sanity check failed, where rate limit rules are not applicable
+	mustSanityCheck(storeContext{})
+
 	ctx, cancel := context.WithCancel(context.Background())
 	var wg sync.WaitGroup
 
+	// reload configurations at runtime
+	go reload.WatchFromViper(ctx)
+
 	if nmOpt.cfxEnabled {
 		startNativeSpaceNodeServer(ctx, &wg)
 	}
@@ -59,11 +67,33 @@ func startNodeManagerService(*cobra.Command, []string) {
 }
 
 func startNativeSpaceNodeServer(ctx context.Context, wg *sync.WaitGroup) {
-	server, endpoint := node.Factory().CreatRpcServer()
-	go server.MustServeGraceful(ctx, wg, endpoint, rpc.ProtocolHttp)
+	f := node.Factory()
+	startNodeServer(ctx, wg, f.CreatRpcServer, f.CreateGrpcServer)
 }
 
 func startEvmSpaceNodeServer(ctx context.Context, wg *sync.WaitGroup) {
-	server, endpoint := node.EthFactory().CreatRpcServer()
+	f := node.EthFactory()
+	startNodeServer(ctx, wg, f.CreatRpcServer, f.CreateGrpcServer)
+
+	// canonical head for lag detector
+	node.StartHeadBroadcaster(ctx)
+
+	// node managers for extra evm chains
+	for _, chain := range node.Chains() {
+		f, _ := node.ChainFactory(chain)
+		startNodeServer(ctx, wg, f.CreatRpcServer, f.CreateGrpcServer)
+	}
+}
+
+// startNodeServer starts node manager RPC server, and gRPC server if configured.
+func startNodeServer(
+	ctx context.Context, wg *sync.WaitGroup,
+	createRpcServer func() (*rpc.Server, string), createGrpcServer func() (*grpc.Server, string),
+) {
+	server, endpoint := createRpcServer()
 	go server.MustServeGraceful(ctx, wg, endpoint, rpc.ProtocolHttp)
+
+	if grpcServer, grpcEndpoint := createGrpcServer(); grpcServer != nil {
+		go node.MustServeGrpcGraceful(ctx, wg, grpcServer, grpcEndpoint)
+	}
 }
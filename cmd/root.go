@@ -9,6 +9,8 @@ import (
 	"github.com/scroll-tech/rpc-gateway/cmd/test"
 	"github.com/scroll-tech/rpc-gateway/cmd/util"
 	"github.com/scroll-tech/rpc-gateway/config"
+	"github.com/scroll-tech/rpc-gateway/util/lifecycle"
+	"github.com/scroll-tech/rpc-gateway/util/reload"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
@@ -67,6 +69,12 @@ func start(cmd *cobra.Command, args []string) {
 	storeCtx := mustInitStoreContext()
 	defer storeCtx.Close()
 
+	// fail fast if any sanity check failed
+	mustSanityCheck(storeCtx)
+
+	// reload configurations at runtime
+	go reload.WatchFromViper(ctx)
+
 	if syncServerEnabled { // start sync
 		syncCtx := mustInitSyncContext(storeCtx)
 		defer syncCtx.Close()
@@ -75,10 +83,10 @@ func start(cmd *cobra.Command, args []string) {
 	}
 
 	if rpcServerEnabled { // start RPC
-		startNativeSpaceRpcServer(ctx, wg, storeCtx)
-		startEvmSpaceRpcServer(ctx, wg, storeCtx)
-		startNativeSpaceBridgeRpcServer(ctx, wg)
-		startDebugSpaceRpcServer(ctx, wg)
+		rpcOpt.cfxEnabled, rpcOpt.ethEnabled = true, true
+		rpcOpt.cfxBridgeEnabled, rpcOpt.debugEnabled = true, true
+
+		registerRpcSubsystems(ctx, wg, storeCtx)
 	}
 
 	if nodeServerEnabled { // start node management
@@ -86,7 +94,13 @@ func start(cmd *cobra.Command, args []string) {
 		startEvmSpaceNodeServer(ctx, wg)
 	}
 
+	if err := lifecycle.Start(ctx); err != nil {
+		logrus.WithError(err).WithField("subsystems", lifecycle.Default.Status()).Fatal("Failed to start services")
+	}
+
 	util.GracefulShutdown(wg, cancel)
+
+	lifecycle.Shutdown()
 }
 
 // Execute is the command line entrypoint.
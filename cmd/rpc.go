@@ -10,20 +10,29 @@ import (
 	"sync"
 	"time"
 
+	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
 	viperutil "github.com/Conflux-Chain/go-conflux-util/viper"
 	gethrpc "github.com/ethereum/go-ethereum/rpc"
+	goredis "github.com/go-redis/redis/v8"
 	cmdutil "github.com/scroll-tech/rpc-gateway/cmd/util"
+	"github.com/scroll-tech/rpc-gateway/config"
 	"github.com/scroll-tech/rpc-gateway/node"
 	"github.com/scroll-tech/rpc-gateway/rpc"
 	"github.com/scroll-tech/rpc-gateway/rpc/handler"
 	"github.com/scroll-tech/rpc-gateway/store/redis"
+	"github.com/scroll-tech/rpc-gateway/util/apikey"
+	"github.com/scroll-tech/rpc-gateway/util/feature"
+	"github.com/scroll-tech/rpc-gateway/util/lifecycle"
 	"github.com/scroll-tech/rpc-gateway/util/rate"
 	"github.com/scroll-tech/rpc-gateway/util/relay"
+	"github.com/scroll-tech/rpc-gateway/util/reload"
 	rpcutil "github.com/scroll-tech/rpc-gateway/util/rpc"
+	"github.com/scroll-tech/rpc-gateway/util/rpc/middlewares"
+	"github.com/scroll-tech/rpc-gateway/util/usage"
 	"github.com/scroll-tech/rpc-gateway/util/whitelist"
 )
 
@@ -64,6 +73,10 @@ func init() {
 	)
 
 	rootCmd.AddCommand(rpcCmd)
+
+	rpc.SetBinaryInfoProvider(func() interface{} {
+		return config.GetBuildInfo()
+	})
 }
 
 func startRpcService(*cobra.Command, []string) {
@@ -77,28 +90,419 @@ func startRpcService(*cobra.Command, []string) {
 	storeCtx := mustInitStoreContext()
 	defer storeCtx.Close()
 
+	// fail fast if any sanity check failed
+	mustSanityCheck(storeCtx)
+
+	// reload configurations at runtime
+	go reload.WatchFromViper(ctx)
+
+	registerRpcSubsystems(ctx, &wg, storeCtx)
+
+	// initialize subsystems in dependency order, and non-critical failures are tolerated
+	// in degraded mode
+	if err := lifecycle.Start(ctx); err != nil {
+		logrus.WithError(err).WithField("subsystems", lifecycle.Default.Status()).Fatal("Failed to start RPC service")
+	}
+
+	logrus.WithField("subsystems", lifecycle.Default.Status()).Info("RPC service started")
+
+	cmdutil.GracefulShutdown(&wg, cancel)
+
+	lifecycle.Shutdown()
+}
+
+// registerRpcSubsystems registers subsystems of RPC service to lifecycle manager.
+func registerRpcSubsystems(ctx context.Context, wg *sync.WaitGroup, storeCtx storeContext) {
+	mustRegister := func(s lifecycle.Subsystem) {
+		if err := lifecycle.Register(s); err != nil {
+			logrus.WithError(err).WithField("subsystem", s.Name).Fatal("Failed to register subsystem")
+		}
+	}
+
+	// limit key cache and revocation push
+	var revocationClient *goredis.Client
+	mustRegister(lifecycle.Subsystem{
+		Name: "keyRevocation",
+		Init: func(ctx context.Context) (err error) {
+			revocationClient, err = startKeyRevocation(ctx)
+			return err
+		},
+		Health: func(ctx context.Context) error {
+			if revocationClient == nil {
+				return nil
+			}
+
+			return revocationClient.Ping(ctx).Err()
+		},
+		Shutdown: func() {
+			if revocationClient != nil {
+				revocationClient.Close()
+			}
+		},
+	})
+
+	// remote feature flags
+	var featureClient *goredis.Client
+	mustRegister(lifecycle.Subsystem{
+		Name: "featureFlags",
+		Init: func(ctx context.Context) (err error) {
+			featureClient, err = startRemoteFeatureFlags(ctx)
+			return err
+		},
+		Shutdown: func() {
+			if featureClient != nil {
+				featureClient.Close()
+			}
+		},
+	})
+
+	// web3pay billing, requests are rejected if enabled but unavailable
+	mustRegister(lifecycle.Subsystem{
+		Name: "web3pay",
+		Init: func(ctx context.Context) error {
+			client, ok, err := middlewares.NewWeb3PayClient()
+			if err != nil {
+				return err
+			}
+
+			if ok {
+				middlewares.SetBillingClient(client)
+				logrus.Info("Web3Pay billing RPC middleware enabled")
+			}
+
+			return nil
+		},
+		Ready: func(ctx context.Context) error {
+			return middlewares.BillingReady()
+		},
+	})
+
+	// built-in API key store, requests are rejected if keys required but store unavailable
+	var apiKeyClient *goredis.Client
+	mustRegister(lifecycle.Subsystem{
+		Name: "apiKeys",
+		Init: func(ctx context.Context) (err error) {
+			apiKeyClient, err = startApiKeyStore(ctx, storeCtx)
+			return err
+		},
+		Health: func(ctx context.Context) error {
+			if apiKeyClient == nil {
+				return nil
+			}
+
+			return apiKeyClient.Ping(ctx).Err()
+		},
+		Ready: func(ctx context.Context) error {
+			return middlewares.ApiKeyReady()
+		},
+		Shutdown: func() {
+			if apiKeyClient != nil {
+				apiKeyClient.Close()
+			}
+		},
+	})
+
+	// per API key usage analytics in daily rollups
+	mustRegister(lifecycle.Subsystem{
+		Name: "usage",
+		Init: func(ctx context.Context) error {
+			return startUsageAnalytics(ctx, storeCtx)
+		},
+		Shutdown: func() {
+			if aggregator, ok := usage.Default(); ok {
+				if err := aggregator.Flush(); err != nil {
+					logrus.WithError(err).Warn("Failed to flush usages on shutdown")
+				}
+			}
+		},
+	})
+
+	// upstream quota budgeting across gateway replicas
+	var upstreamQuotaClient *goredis.Client
+	mustRegister(lifecycle.Subsystem{
+		Name: "upstreamQuota",
+		Init: func(ctx context.Context) (err error) {
+			upstreamQuotaClient, err = startUpstreamQuota()
+			return err
+		},
+		Health: func(ctx context.Context) error {
+			if upstreamQuotaClient == nil {
+				return nil
+			}
+
+			return upstreamQuotaClient.Ping(ctx).Err()
+		},
+		Shutdown: func() {
+			if upstreamQuotaClient != nil {
+				upstreamQuotaClient.Close()
+			}
+		},
+	})
+
 	if rpcOpt.cfxEnabled { // start core space RPC
-		startNativeSpaceRpcServer(ctx, &wg, storeCtx)
+		var router node.Router
+		mustRegister(lifecycle.Subsystem{
+			Name:     "cfxRouter",
+			Critical: true,
+			Init: func(ctx context.Context) (err error) {
+				router, err = node.Factory().NewRouter()
+				return err
+			},
+			// any node available for each node group with configured nodes
+			Ready: func(ctx context.Context) error {
+				return node.NewCfxClientProvider(router).Ready()
+			},
+		})
+
+		mustRegister(lifecycle.Subsystem{
+			Name:      "cfxRpc",
+			DependsOn: []string{"cfxRouter"},
+			Critical:  true,
+			Init: func(ctx context.Context) error {
+				startNativeSpaceRpcServer(ctx, wg, storeCtx, router)
+				return nil
+			},
+		})
 	}
 
 	if rpcOpt.ethEnabled { // start evm space RPC
-		startEvmSpaceRpcServer(ctx, &wg, storeCtx)
+		var router node.Router
+		mustRegister(lifecycle.Subsystem{
+			Name:     "ethRouter",
+			Critical: true,
+			Init: func(ctx context.Context) (err error) {
+				router, err = node.EthFactory().NewRouter()
+				return err
+			},
+			// any node available for each node group with configured nodes
+			Ready: func(ctx context.Context) error {
+				return node.NewEthClientProvider(router).Ready()
+			},
+		})
+
+		mustRegister(lifecycle.Subsystem{
+			Name:      "ethRpc",
+			DependsOn: []string{"ethRouter"},
+			Critical:  true,
+			Init: func(ctx context.Context) error {
+				startEvmSpaceRpcServer(ctx, wg, storeCtx, router)
+				return nil
+			},
+		})
+
+		// extra evm chains are served in degraded mode if failed
+		// list could only be unmarshalled as field, but not directly by key
+		var ethrpc struct {
+			Chains []evmChainRpcConfig
+		}
+		viperutil.MustUnmarshalKey("ethrpc", &ethrpc)
+
+		for i := range ethrpc.Chains {
+			c := ethrpc.Chains[i]
+			mustRegister(lifecycle.Subsystem{
+				Name: "ethChain/" + c.Name,
+				Init: func(ctx context.Context) error {
+					return startEvmChainRpcServer(ctx, wg, c)
+				},
+			})
+		}
 	}
 
 	if rpcOpt.cfxBridgeEnabled { // start core space bridge RPC
-		startNativeSpaceBridgeRpcServer(ctx, &wg)
+		mustRegister(lifecycle.Subsystem{
+			Name:     "cfxBridgeRpc",
+			Critical: true,
+			Init: func(ctx context.Context) error {
+				startNativeSpaceBridgeRpcServer(ctx, wg)
+				return nil
+			},
+		})
 	}
 
 	if rpcOpt.debugEnabled { // start debug space RPC
-		startDebugSpaceRpcServer(ctx, &wg)
+		mustRegister(lifecycle.Subsystem{
+			Name:     "debugRpc",
+			Critical: true,
+			Init: func(ctx context.Context) error {
+				go startDebugSpaceRpcServer(ctx, wg)
+				return nil
+			},
+		})
 	}
 
-	cmdutil.GracefulShutdown(&wg, cancel)
+	// start administrative RPC if configured
+	if adminEndpoint := viper.GetString("rpc.adminEndpoint"); len(adminEndpoint) > 0 {
+		mustRegister(lifecycle.Subsystem{
+			Name: "adminRpc",
+			Init: func(ctx context.Context) error {
+				server := rpc.MustNewAdminServer()
+				go server.MustServeGraceful(ctx, wg, adminEndpoint, rpcutil.ProtocolHttp)
+				return nil
+			},
+		})
+	}
+}
+
+// startKeyRevocation configures limit key cache, and subscribes revoked keys if configured.
+func startKeyRevocation(ctx context.Context) (*goredis.Client, error) {
+	var conf rate.RevocationConfig
+	viperutil.MustUnmarshalKey("rpc.keyRevocation", &conf)
+
+	rate.DefaultRegistryCfx.SetKeyCacheTTL(conf.KeyCacheTTL)
+	rate.DefaultRegistryEth.SetKeyCacheTTL(conf.KeyCacheTTL)
+
+	if len(conf.RedisUrl) == 0 {
+		return nil, nil
+	}
+
+	client, err := redis.NewRedisClient(conf.RedisUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	go rate.SubscribeRevocation(ctx, client, conf.Channel, rate.DefaultRegistryCfx, rate.DefaultRegistryEth)
+
+	return client, nil
+}
+
+// startRemoteFeatureFlags loads feature flags from remote provider and polls changes if configured.
+func startRemoteFeatureFlags(ctx context.Context) (*goredis.Client, error) {
+	var conf feature.RemoteConfig
+	viperutil.MustUnmarshalKey("features.remote", &conf)
+
+	if len(conf.RedisUrl) == 0 {
+		return nil, nil
+	}
+
+	client, err := redis.NewRedisClient(conf.RedisUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := feature.LoadRemote(ctx, client, conf.Key); err != nil {
+		client.Close()
+		return nil, err
+	}
+
+	go feature.PollRemote(ctx, client, conf.Key, conf.Interval)
+
+	return client, nil
+}
+
+// startUpstreamQuota enables upstream quota budgeting with shared token buckets in Redis if configured.
+func startUpstreamQuota() (*goredis.Client, error) {
+	var conf rpc.UpstreamQuotasConfig
+	viperutil.MustUnmarshalKey("rpc.upstreamQuota", &conf)
+
+	if !conf.Enabled {
+		return nil, nil
+	}
+
+	client, err := redis.NewRedisClient(conf.RedisUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := rpc.SetUpstreamQuota(&conf, client); err != nil {
+		client.Close()
+		return nil, err
+	}
+
+	logrus.WithField("providers", len(conf.Providers)).Info("Upstream quota budgeting enabled")
+
+	return client, nil
+}
+
+// startUsageAnalytics initializes per API key usage analytics if enabled.
+func startUsageAnalytics(ctx context.Context, storeCtx storeContext) error {
+	var conf usage.Config
+	viperutil.MustUnmarshalKey("usage", &conf)
+
+	if !conf.Enabled {
+		return nil
+	}
+
+	// prefer evm space database
+	db := storeCtx.ethDB
+	if db == nil {
+		db = storeCtx.cfxDB
+	}
+
+	if db == nil {
+		return errors.New("mysql database not enabled for usage analytics")
+	}
+
+	if err := db.MigrateKeyUsages(); err != nil {
+		return errors.WithMessage(err, "failed to migrate key usage table")
+	}
+
+	aggregator := usage.NewAggregator(conf, db.KeyUsageStore)
+	usage.SetDefault(aggregator)
+
+	go aggregator.Run(ctx)
+
+	logrus.Info("Usage analytics RPC middleware enabled")
+
+	return nil
+}
+
+// startApiKeyStore initializes the built-in API key store if enabled, and returns the redis
+// client for `redis` backend.
+func startApiKeyStore(ctx context.Context, storeCtx storeContext) (*goredis.Client, error) {
+	var conf apikey.Config
+	viperutil.MustUnmarshalKey("apiKeys", &conf)
+
+	if !conf.Enabled {
+		return nil, nil
+	}
+
+	middlewares.SetApiKeyRequired(conf.Required)
+
+	var store apikey.Store
+	var client *goredis.Client
+
+	switch conf.Backend {
+	case "redis":
+		var err error
+		if client, err = redis.NewRedisClient(conf.RedisUrl); err != nil {
+			return nil, err
+		}
+
+		store = apikey.NewRedisStore(ctx, client, conf.RedisKeyPrefix)
+	case "mysql":
+		// prefer evm space database
+		db := storeCtx.ethDB
+		if db == nil {
+			db = storeCtx.cfxDB
+		}
+
+		if db == nil {
+			return nil, errors.New("mysql database not enabled for api key store")
+		}
+
+		if err := db.MigrateApiKeys(); err != nil {
+			return nil, errors.WithMessage(err, "failed to migrate api key table")
+		}
+
+		store = db.ApiKeyStore
+	default:
+		return nil, errors.Errorf("invalid api key store backend %v", conf.Backend)
+	}
+
+	manager := apikey.NewManager(store, conf.CacheSize, conf.CacheTTL)
+	manager.SetLifecycle(conf.Lifecycle)
+	apikey.SetDefault(manager)
+
+	// rotation reminders
+	go manager.RunLifecycle(ctx)
+
+	logrus.WithField("backend", conf.Backend).Info("API key RPC middleware enabled")
+
+	return client, nil
 }
 
 // startNativeSpaceRpcServer starts core space RPC server
-func startNativeSpaceRpcServer(ctx context.Context, wg *sync.WaitGroup, storeCtx storeContext) {
-	router := node.Factory().CreateRouter()
+func startNativeSpaceRpcServer(ctx context.Context, wg *sync.WaitGroup, storeCtx storeContext, router node.Router) {
 	option := rpc.CfxAPIOption{
 		Relayer: relay.MustNewTxnRelayerFromViper(),
 	}
@@ -151,9 +555,14 @@ func startNativeSpaceRpcServer(ctx context.Context, wg *sync.WaitGroup, storeCtx
 }
 
 // startEvmSpaceRpcServer starts evm space RPC server
-func startEvmSpaceRpcServer(ctx context.Context, wg *sync.WaitGroup, storeCtx storeContext) {
-	var option rpc.EthAPIOption
-	router := node.EthFactory().CreateRouter()
+func startEvmSpaceRpcServer(ctx context.Context, wg *sync.WaitGroup, storeCtx storeContext, router node.Router) {
+	var privateRelayConf relay.SequencerConfig
+	viperutil.MustUnmarshalKey("ethrpc.privateRelay", &privateRelayConf)
+
+	option := rpc.EthAPIOption{
+		Sequencer:    relay.MustNewSequencerRouterFromViper(),
+		PrivateRelay: relay.MustNewSequencerRouter(&privateRelayConf),
+	}
 
 	if storeCtx.ethDB != nil {
 		// initialize store handler
@@ -167,6 +576,20 @@ func startEvmSpaceRpcServer(ctx context.Context, wg *sync.WaitGroup, storeCtx st
 		)
 	}
 
+	// resolve `safe` and `finalized` block tags from L1 batch finalization
+	rpc.StartFinalityTracker(ctx)
+
+	// watch batches committed on L1 for batch status query
+	rpc.StartL1Watcher(ctx)
+
+	// forward to peer gateways in other regions if local node group unavailable
+	rpc.StartPeering(ctx)
+
+	// share the canonical head among subsystems
+	if node.StartHeadBroadcaster(ctx) {
+		go rpc.PurgeCacheOnNewHeads(ctx)
+	}
+
 	// initialize RPC server
 	exposedModules := viper.GetStringSlice("ethrpc.exposedModules")
 	server := rpc.MustNewEvmSpaceServer(router, exposedModules, option)
@@ -179,6 +602,58 @@ func startEvmSpaceRpcServer(ctx context.Context, wg *sync.WaitGroup, storeCtx st
 	if wsEndpoint := viper.GetString("ethrpc.wsEndpoint"); len(wsEndpoint) > 0 {
 		go server.MustServeGraceful(ctx, wg, wsEndpoint, rpcutil.ProtocolWS)
 	}
+
+	// serve gRPC endpoint
+	var grpcConf rpc.GrpcConfig
+	viperutil.MustUnmarshalKey("ethrpc.grpc", &grpcConf)
+
+	if len(grpcConf.Endpoint) > 0 {
+		grpcServer := rpc.NewEvmSpaceGrpcServer(server, &grpcConf)
+		go rpc.MustServeGrpcGraceful(ctx, wg, grpcServer, grpcConf.Endpoint)
+	}
+}
+
+// evmChainRpcConfig RPC server configurations for extra evm chain.
+type evmChainRpcConfig struct {
+	// chain name, which should be configured in node chains as well
+	Name           string
+	Endpoint       string
+	WSEndpoint     string
+	ExposedModules []string
+	Sequencer      relay.SequencerConfig
+	PrivateRelay   relay.SequencerConfig
+}
+
+// startEvmChainRpcServer starts RPC server for extra evm chain on different ports
+func startEvmChainRpcServer(ctx context.Context, wg *sync.WaitGroup, c evmChainRpcConfig) error {
+	factory, ok := node.ChainFactory(c.Name)
+	if !ok {
+		return errors.Errorf("chain %v not configured for node management", c.Name)
+	}
+
+	router, err := factory.NewRouter()
+	if err != nil {
+		return err
+	}
+
+	option := rpc.EthAPIOption{
+		Sequencer:    relay.MustNewSequencerRouter(&c.Sequencer),
+		PrivateRelay: relay.MustNewSequencerRouter(&c.PrivateRelay),
+	}
+
+	server := rpc.MustNewEvmChainServer(c.Name, router, c.ExposedModules, option)
+
+	// serve HTTP endpoint
+	go server.MustServeGraceful(ctx, wg, c.Endpoint, rpcutil.ProtocolHttp)
+
+	// serve Websocket endpoint
+	if len(c.WSEndpoint) > 0 {
+		go server.MustServeGraceful(ctx, wg, c.WSEndpoint, rpcutil.ProtocolWS)
+	}
+
+	logrus.WithField("chain", c.Name).Info("Evm chain RPC server started")
+
+	return nil
 }
 
 // startNativeSpaceBridgeRpcServer starts core space bridge RPC server
@@ -0,0 +1,53 @@
+package test
+
+import (
+	"github.com/scroll-tech/rpc-gateway/rpc/capture"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	fixtureCaptureFile string
+	fixtureOutDir      string
+	fixtureOpts        capture.FixtureOptions
+
+	fixtureCmd = &cobra.Command{
+		Use:   "fixture",
+		Short: "generate anonymized routing test fixtures from sampled capture log",
+		Long: "Generate anonymized routing test fixtures from sampled capture log, and then update golden files by\n" +
+			"`go test ./rpc -run TestRoutingFixtures -update`",
+		Run: generateFixtures,
+	}
+)
+
+func init() {
+	fixtureCmd.Flags().StringVarP(&fixtureCaptureFile, "capture", "c", "capture.jsonl", "capture log file")
+	fixtureCmd.Flags().StringVarP(&fixtureOutDir, "out", "o", "rpc/testdata/routing", "directory to write fixtures")
+	fixtureCmd.Flags().StringVarP(&fixtureOpts.Salt, "salt", "s", "", "secret salt to derive pseudonyms")
+	fixtureCmd.Flags().IntVarP(&fixtureOpts.MaxPerMethod, "max-per-method", "m", 20, "max number of fixtures per method")
+	fixtureCmd.MarkFlagRequired("salt")
+
+	Cmd.AddCommand(fixtureCmd)
+}
+
+func generateFixtures(cmd *cobra.Command, args []string) {
+	records, err := capture.ReadRecords(fixtureCaptureFile)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to read capture log")
+	}
+
+	method2Fixtures, err := capture.GenerateFixtures(records, fixtureOpts)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to generate fixtures")
+	}
+
+	if err := capture.WriteFixtures(fixtureOutDir, method2Fixtures); err != nil {
+		logrus.WithError(err).Fatal("Failed to write fixtures")
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"records": len(records),
+		"methods": len(method2Fixtures),
+		"out":     fixtureOutDir,
+	}).Info("Routing test fixtures generated")
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/scroll-tech/rpc-gateway/util/alert"
 	"github.com/scroll-tech/rpc-gateway/util/metrics"
+	"github.com/scroll-tech/rpc-gateway/util/secret"
 	"github.com/sirupsen/logrus"
 
 	// For go-ethereum v1.0.15, node pkg imports internal/debug pkg which will inits log root
@@ -25,6 +26,8 @@ func init() {
 	viper.MustInit(viperEnvPrefix)
 	// init logger
 	initLogger()
+	// load secrets from secret files or vault
+	secret.MustInit()
 	// init metrics
 	metrics.Init()
 	// init alert
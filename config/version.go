@@ -1,9 +1,14 @@
 package config
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
 	"runtime"
+	"time"
 
 	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
 )
 
 var (
@@ -13,6 +18,9 @@ var (
 	BuildDate string
 	BuildOS   string
 	BuildArch string
+
+	// time when the process started
+	StartedAt = time.Now()
 )
 
 func init() {
@@ -28,4 +36,42 @@ func DumpVersionInfo() {
 	logrus.Infof(strFormat, "Build OS:", BuildOS)
 	logrus.Infof(strFormat, "Build Arch:", BuildArch)
 	logrus.Infof(strFormat, "Build Date:", BuildDate)
+	logrus.Infof(strFormat, "Go Version:", runtime.Version())
+	logrus.Infof(strFormat, "Config Hash:", ConfigHash())
+}
+
+// BuildInfo is the build, version and runtime info of the running binary.
+type BuildInfo struct {
+	Version    string    `json:"version"`
+	GitCommit  string    `json:"gitCommit"`
+	BuildDate  string    `json:"buildDate"`
+	BuildOS    string    `json:"buildOS"`
+	BuildArch  string    `json:"buildArch"`
+	GoVersion  string    `json:"goVersion"`
+	StartedAt  time.Time `json:"startedAt"`
+	ConfigHash string    `json:"configHash"`
+}
+
+// GetBuildInfo returns the build, version and runtime info of the running binary.
+func GetBuildInfo() BuildInfo {
+	return BuildInfo{
+		Version:    Version,
+		GitCommit:  GitCommit,
+		BuildDate:  BuildDate,
+		BuildOS:    BuildOS,
+		BuildArch:  BuildArch,
+		GoVersion:  runtime.Version(),
+		StartedAt:  StartedAt,
+		ConfigHash: ConfigHash(),
+	}
+}
+
+// ConfigHash returns the SHA256 hash of effective configurations, including environment
+// overrides, so that config drift of the fleet could be audited without exposing secrets.
+func ConfigHash() string {
+	// map keys are printed in sorted order, which is deterministic
+	settings := fmt.Sprintf("%v", viper.AllSettings())
+	hash := sha256.Sum256([]byte(settings))
+
+	return hex.EncodeToString(hash[:])
 }
@@ -6,6 +6,7 @@ require (
 	github.com/Conflux-Chain/go-conflux-sdk v1.4.2
 	github.com/Conflux-Chain/go-conflux-util v0.0.0-20220907035343-2d1233bccd70
 	github.com/Conflux-Chain/web3pay-service v0.0.0-20220915034912-b5c10ef3163a
+	github.com/andybalholm/brotli v1.0.4
 	github.com/buraksezer/consistent v0.9.0
 	github.com/cespare/xxhash v1.1.0
 	github.com/ethereum/go-ethereum v1.10.15
@@ -25,6 +26,8 @@ require (
 	github.com/zealws/golang-ring v0.0.0-20210116075443-7c86fdb43134
 	go.uber.org/multierr v1.6.0
 	golang.org/x/time v0.0.0-20220411224347-583f2d630306
+	google.golang.org/grpc v1.42.0
+	google.golang.org/protobuf v1.27.1
 	gorm.io/driver/mysql v1.3.6
 	gorm.io/gorm v1.23.8
 )
@@ -2,6 +2,8 @@ package node
 
 import (
 	"context"
+	"fmt"
+	"math/rand"
 
 	sdk "github.com/Conflux-Chain/go-conflux-sdk"
 	"github.com/scroll-tech/rpc-gateway/util/rpc"
@@ -19,23 +21,33 @@ func NewCfxClientProvider(router Router) *CfxClientProvider {
 		}),
 	}
 
-	for grp := range urlCfg {
-		cp.registerGroup(grp)
-	}
+	cp.registerGroups(urlCfg)
 
 	return cp
 }
 
-// GetClientByIP gets client of normal HTTP group by remote IP address.
+// GetClientByIP gets client of normal HTTP group by route key (remote IP address by default).
 func (p *CfxClientProvider) GetClientByIP(ctx context.Context) (sdk.ClientOperator, error) {
 	return p.GetClientByIPGroup(ctx, GroupCfxHttp)
 }
 
-// GetClientByIPGroup gets client of specific group by remote IP address.
+// GetClientRandomByGroup gets client of specific group randomly.
+func (p *CfxClientProvider) GetClientRandomByGroup(group Group) (sdk.ClientOperator, error) {
+	key := fmt.Sprintf("random_key_%v", rand.Int())
+
+	client, err := p.getClient(key, group)
+	if err != nil {
+		return nil, err
+	}
+
+	return client.(sdk.ClientOperator), nil
+}
+
+// GetClientByIPGroup gets client of specific group by route key (remote IP address by default).
 func (p *CfxClientProvider) GetClientByIPGroup(ctx context.Context, group Group) (sdk.ClientOperator, error) {
-	remoteAddr := remoteAddrFromContext(ctx)
+	key := routeKeyFromContext(ctx)
 
-	client, err := p.getClient(remoteAddr, group)
+	client, err := p.getClient(key, group)
 	if err != nil {
 		return nil, err
 	}
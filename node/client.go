@@ -1,16 +1,17 @@
 package node
 
 import (
-	"context"
 	"sync"
 
 	"github.com/pkg/errors"
 	"github.com/scroll-tech/rpc-gateway/util"
 	"github.com/scroll-tech/rpc-gateway/util/rpc"
-	"github.com/scroll-tech/rpc-gateway/util/rpc/handlers"
 	"github.com/sirupsen/logrus"
 )
 
+// maxRandomRouteAttempts is the max number of attempts to route randomly to a different node.
+const maxRandomRouteAttempts = 8
+
 var (
 	ErrClientUnavailable = errors.New("no full node available")
 )
@@ -25,9 +26,13 @@ type clientProvider struct {
 	router  Router
 	factory clientFactory
 	mutex   sync.Mutex
+	chain   string // chain name to qualify node groups, empty for the default chain
 
 	// group => node name => RPC client
 	clients map[Group]*util.ConcurrentMap
+
+	// node groups with configured nodes, which are checked for readiness
+	configuredGroups []Group
 }
 
 func newClientProvider(router Router, factory clientFactory) *clientProvider {
@@ -52,8 +57,39 @@ func (p *clientProvider) registerGroup(group Group) *util.ConcurrentMap {
 	return p.clients[group]
 }
 
+// registerGroups registers node groups of the specified URL configurations, which are
+// already qualified with chain name if any.
+func (p *clientProvider) registerGroups(groupConf map[Group]UrlConfig) {
+	for grp, conf := range groupConf {
+		p.registerGroup(grp)
+
+		if len(conf.Nodes) > 0 {
+			p.configuredGroups = append(p.configuredGroups, grp)
+		}
+	}
+}
+
+// Chain returns the chain name of provided clients, and empty for the default chain.
+func (p *clientProvider) Chain() string {
+	return p.chain
+}
+
+// Ready checks if any node available to route for each node group with configured nodes.
+// Note, node groups without any configured node, e.g. optional websocket group, are ignored.
+func (p *clientProvider) Ready() error {
+	for _, group := range p.configuredGroups {
+		if len(p.router.Route(group, []byte("readiness"))) == 0 {
+			return errors.WithMessagef(ErrClientUnavailable, "group %v", group)
+		}
+	}
+
+	return nil
+}
+
 // getClient gets client based on keyword and node group type.
 func (p *clientProvider) getClient(key string, group Group) (interface{}, error) {
+	group = group.WithChain(p.chain)
+
 	clients, ok := p.clients[group]
 	if !ok {
 		return nil, errors.Errorf("Unknown node group %v", group)
@@ -61,6 +97,53 @@ func (p *clientProvider) getClient(key string, group Group) (interface{}, error)
 
 	url := p.router.Route(group, []byte(key))
 
+	return p.connect(clients, key, group, url)
+}
+
+// getClientByHeight gets client based on keyword and node group type, whose synced height
+// covers the specified height. Note, nil height stands for the latest height.
+func (p *clientProvider) getClientByHeight(key string, group Group, height *uint64) (interface{}, error) {
+	hr, ok := p.router.(HeightRouter)
+	if !ok { // height aware routing not supported
+		return p.getClient(key, group)
+	}
+
+	group = group.WithChain(p.chain)
+
+	clients, ok := p.clients[group]
+	if !ok {
+		return nil, errors.Errorf("Unknown node group %v", group)
+	}
+
+	url := hr.RouteByHeight(group, []byte(key), height)
+
+	return p.connect(clients, key, group, url)
+}
+
+// getClientByNodeName gets client of the specified node in group, which should be one of
+// the node URLs of group, e.g. to pin requests to some node for debugging.
+func (p *clientProvider) getClientByNodeName(group Group, urls []string, nodeName string) (interface{}, error) {
+	group = group.WithChain(p.chain)
+
+	clients, ok := p.clients[group]
+	if !ok {
+		return nil, errors.Errorf("Unknown node group %v", group)
+	}
+
+	nodeName = rpc.Url2NodeName(nodeName)
+	for _, url := range urls {
+		if rpc.Url2NodeName(url) == nodeName {
+			return p.connect(clients, nodeName, group, url)
+		}
+	}
+
+	return nil, errors.Errorf("Unknown node %v in group %v", nodeName, group)
+}
+
+// connect gets or creates client of the routed full node URL.
+func (p *clientProvider) connect(
+	clients *util.ConcurrentMap, key string, group Group, url string,
+) (interface{}, error) {
 	logger := logrus.WithFields(logrus.Fields{
 		"key":   key,
 		"group": group,
@@ -99,11 +182,3 @@ func (p *clientProvider) getClient(key string, group Group) (interface{}, error)
 
 	return client, nil
 }
-
-func remoteAddrFromContext(ctx context.Context) string {
-	if ip, ok := handlers.GetIPAddressFromContext(ctx); ok {
-		return ip
-	}
-
-	return "unknown_ip"
-}
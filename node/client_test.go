@@ -0,0 +1,42 @@
+package node
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// groupRouter routes requests of each group to the fixed node URL if any.
+type groupRouter map[Group]string
+
+func (r groupRouter) Route(group Group, key []byte) string {
+	return r[group]
+}
+
+func TestClientProviderReady(t *testing.T) {
+	groupConf := map[Group]UrlConfig{
+		GroupEthHttp:   {Nodes: []string{"http://127.0.0.1:8545"}},
+		GroupEthWs:     {}, // no node configured
+		GroupDebugHttp: {},
+	}
+
+	tests := []struct {
+		router Router
+		ready  bool
+	}{
+		{groupRouter{GroupEthHttp: "http://127.0.0.1:8545"}, true},
+		{groupRouter{}, false},
+	}
+
+	for _, tt := range tests {
+		p := newEthClientProvider(tt.router, "", groupConf)
+		assert.Equal(t, tt.ready, p.Ready() == nil)
+	}
+
+	// groups of extra chain are already qualified
+	chainGroup := Group(GroupEthHttp).WithChain("l2")
+	p := newEthClientProvider(groupRouter{chainGroup: "http://127.0.0.1:8545"}, "l2", map[Group]UrlConfig{
+		chainGroup: {Nodes: []string{"http://127.0.0.1:8545"}},
+	})
+	assert.Nil(t, p.Ready())
+}
@@ -1,10 +1,14 @@
 package node
 
 import (
+	"strings"
 	"time"
 
 	"github.com/Conflux-Chain/go-conflux-util/viper"
 	"github.com/buraksezer/consistent"
+	"github.com/pkg/errors"
+	"github.com/scroll-tech/rpc-gateway/util/reload"
+	"github.com/scroll-tech/rpc-gateway/util/rpc"
 	"github.com/sirupsen/logrus"
 )
 
@@ -13,47 +17,293 @@ import (
 var cfg config
 var urlCfg map[Group]UrlConfig
 var ethUrlCfg map[Group]UrlConfig
+var chainUrlCfgs map[string]map[Group]UrlConfig // chain name => group => URL config
 
 func init() {
 	viper.MustUnmarshalKey("node", &cfg)
 	logrus.WithField("config", cfg).Debug("Node manager configurations loaded.")
 
-	urlCfg = map[Group]UrlConfig{
+	if err := validateGroupConfigs(cfg.Groups); err != nil {
+		logrus.WithError(err).Fatal("Invalid node group configurations")
+	}
+
+	for grp, conf := range cfg.HashRing.Groups {
+		if err := conf.validate(); err != nil {
+			logrus.WithError(err).WithField("group", grp).Fatal("Invalid hash ring configurations")
+		}
+	}
+
+	for i := range cfg.Maintenance.Windows {
+		if err := cfg.Maintenance.Windows[i].init(); err != nil {
+			logrus.WithError(err).WithField("url", cfg.Maintenance.Windows[i].URL).Fatal(
+				"Invalid node maintenance window configurations",
+			)
+		}
+	}
+
+	if err := initCanaryNodes(cfg.Canary); err != nil {
+		logrus.WithError(err).Fatal("Invalid canary node configurations")
+	}
+
+	customGroups = cfg.Groups
+	urlCfg, ethUrlCfg = newUrlConfig(&cfg)
+
+	if err := validateFallbackGroups(urlCfg, ethUrlCfg); err != nil {
+		logrus.WithError(err).Fatal("Invalid node fallback group configurations")
+	}
+
+	if err := validateNodeProtocols(urlCfg, ethUrlCfg); err != nil {
+		logrus.WithError(err).Fatal("Invalid node URL configurations")
+	}
+
+	if err := initTiers(cfg.Tiers); err != nil {
+		logrus.WithError(err).Fatal("Invalid node tier configurations")
+	}
+
+	if err := resetUpstreamTls(&cfg, ethUrlCfg); err != nil {
+		logrus.WithError(err).Fatal("Invalid upstream TLS configurations")
+	}
+
+	// reloaded apart from node URLs, so that invalid TLS configurations will not reject
+	// reloading of node URLs
+	reload.Register("node_tls", reloadUpstreamTls)
+
+	var err error
+	if chainUrlCfgs, err = newChainUrlConfig(&cfg); err != nil {
+		logrus.WithError(err).Fatal("Invalid chain configurations")
+	}
+}
+
+// newUrlConfig builds node URL configurations of all groups for both core space
+// and evm space.
+func newUrlConfig(c *config) (map[Group]UrlConfig, map[Group]UrlConfig) {
+	cfxConf := map[Group]UrlConfig{
 		GroupCfxHttp: {
-			Nodes:    cfg.URLs,
-			Failover: cfg.Router.ChainedFailover.URL,
+			Nodes:    c.URLs,
+			Spares:   c.Spare.URLs[GroupCfxHttp],
+			Failover: c.Router.ChainedFailover.URL,
+			Fallback: Group(c.Router.FallbackGroups[GroupCfxHttp]),
 		},
 		GroupCfxWs: {
-			Nodes:    cfg.WSURLs,
-			Failover: cfg.Router.ChainedFailover.WSURL,
+			Nodes:    c.WSURLs,
+			Spares:   c.Spare.URLs[GroupCfxWs],
+			Failover: c.Router.ChainedFailover.WSURL,
+			Fallback: Group(c.Router.FallbackGroups[GroupCfxWs]),
 		},
 		GroupCfxArchives: {
-			Nodes: cfg.ArchiveNodes,
+			Nodes:    c.ArchiveNodes,
+			Spares:   c.Spare.URLs[GroupCfxArchives],
+			Fallback: Group(c.Router.FallbackGroups[GroupCfxArchives]),
 		},
 		GroupCfxLogs: {
-			Nodes: cfg.LogNodes,
+			Nodes:    c.LogNodes,
+			Spares:   c.Spare.URLs[GroupCfxLogs],
+			Fallback: Group(c.Router.FallbackGroups[GroupCfxLogs]),
 		},
 	}
 
-	ethUrlCfg = map[Group]UrlConfig{
+	ethConf := map[Group]UrlConfig{
 		GroupEthHttp: {
-			Nodes:    cfg.EthURLs,
-			Failover: cfg.Router.ChainedFailover.EthURL,
+			Nodes:    c.EthURLs,
+			Spares:   c.Spare.URLs[GroupEthHttp],
+			Failover: c.Router.ChainedFailover.EthURL,
+			Fallback: Group(c.Router.FallbackGroups[GroupEthHttp]),
 		},
 		GroupEthWs: {
-			Nodes:    cfg.EthWSURLs,
-			Failover: cfg.Router.ChainedFailover.EthWSURL,
+			Nodes:    c.EthWSURLs,
+			Spares:   c.Spare.URLs[GroupEthWs],
+			Failover: c.Router.ChainedFailover.EthWSURL,
+			Fallback: Group(c.Router.FallbackGroups[GroupEthWs]),
 		},
 		GroupEthLogs: {
-			Nodes: cfg.EthLogNodes,
+			Nodes:    c.EthLogNodes,
+			Spares:   c.Spare.URLs[GroupEthLogs],
+			Fallback: Group(c.Router.FallbackGroups[GroupEthLogs]),
 		},
 		GroupDebugHttp: {
-			Nodes: cfg.DebugURLs,
+			Nodes:    c.DebugURLs,
+			Spares:   c.Spare.URLs[GroupDebugHttp],
+			Fallback: Group(c.Router.FallbackGroups[GroupDebugHttp]),
 		},
 	}
 
+	// config-driven node groups
+	for _, grp := range c.Groups {
+		conf := UrlConfig{
+			Nodes: grp.URLs, Spares: grp.Spares, Failover: grp.Failover, Fallback: Group(grp.Fallback),
+		}
+
+		if grp.Space == "eth" {
+			ethConf[Group(grp.Name)] = conf
+		} else {
+			cfxConf[Group(grp.Name)] = conf
+		}
+	}
+
+	return cfxConf, ethConf
+}
+
+// newChainUrlConfig builds node URL configurations of all groups for extra evm chains,
+// which are qualified with chain name.
+func newChainUrlConfig(c *config) (map[string]map[Group]UrlConfig, error) {
+	chainConfs := make(map[string]map[Group]UrlConfig)
+
+	for _, chain := range c.Chains {
+		if len(chain.Name) == 0 || strings.Contains(chain.Name, chainSeparator) {
+			return nil, errors.Errorf("invalid chain name %q", chain.Name)
+		}
+
+		if _, ok := chainConfs[chain.Name]; ok {
+			return nil, errors.Errorf("duplicate chain name %q", chain.Name)
+		}
+
+		chainConf := map[Group]UrlConfig{
+			Group(GroupEthHttp).WithChain(chain.Name): {Nodes: chain.URLs},
+			Group(GroupEthWs).WithChain(chain.Name):   {Nodes: chain.WSURLs},
+			Group(GroupEthLogs).WithChain(chain.Name): {Nodes: chain.LogNodes},
+		}
+
+		// fallback groups are qualified with the same chain name
+		for grp, conf := range chainConf {
+			if fallback, ok := c.Router.FallbackGroups[string(grp.Base())]; ok {
+				conf.Fallback = Group(fallback).WithChain(chain.Name)
+				chainConf[grp] = conf
+			}
+		}
+
+		if err := validateNodeProtocols(chainConf); err != nil {
+			return nil, errors.WithMessagef(err, "invalid node url of chain %v", chain.Name)
+		}
+
+		chainConfs[chain.Name] = chainConf
+	}
+
+	return chainConfs, nil
+}
+
+// loadUrlConfig loads the latest node URL configurations from viper, which is
+// used to reload node clusters at runtime.
+func loadUrlConfig() (map[Group]UrlConfig, map[Group]UrlConfig, error) {
+	var c config
+	if err := viper.UnmarshalKey("node", &c); err != nil {
+		return nil, nil, err
+	}
+
+	if err := validateGroupConfigs(c.Groups); err != nil {
+		return nil, nil, err
+	}
+
+	cfxConf, ethConf := newUrlConfig(&c)
+	if err := validateFallbackGroups(cfxConf, ethConf); err != nil {
+		return nil, nil, err
+	}
+
+	if err := validateNodeProtocols(cfxConf, ethConf); err != nil {
+		return nil, nil, err
+	}
+
+	return cfxConf, ethConf, nil
+}
+
+// validateFallbackGroups validates that fallback groups exist in the same space.
+func validateFallbackGroups(spaceConfs ...map[Group]UrlConfig) error {
+	for _, confs := range spaceConfs {
+		for grp, conf := range confs {
+			if len(conf.Fallback) == 0 {
+				continue
+			}
+
+			if conf.Fallback == grp {
+				return errors.Errorf("group %v falls back to itself", grp)
+			}
+
+			if _, ok := confs[conf.Fallback]; !ok {
+				return errors.Errorf("fallback group %v of group %v not found in the same space", conf.Fallback, grp)
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateNodeProtocols validates that node URLs are over HTTP(S), WS(S) or unix IPC, and
+// nodes of websocket groups are over persistent connections to serve subscriptions.
+func validateNodeProtocols(spaceConfs ...map[Group]UrlConfig) error {
+	for _, confs := range spaceConfs {
+		for grp, conf := range confs {
+			for _, url := range conf.urls() {
+				protocol, err := rpc.NodeProtocol(url)
+				if err != nil {
+					return errors.WithMessagef(err, "invalid node of group %v", grp)
+				}
+
+				if base := grp.Base(); (base == GroupCfxWs || base == GroupEthWs) && !rpc.IsPersistentProtocol(protocol) {
+					return errors.Errorf("node %v of group %v should be over WS or IPC", url, grp)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// resetUpstreamTls resets TLS configurations of upstream nodes by node group, which is only
+// available for evm space.
+func resetUpstreamTls(c *config, ethConf map[Group]UrlConfig) error {
+	groupConfs := make(map[Group]rpc.TlsClientConfig)
+	for name, conf := range c.Tls {
+		groupConfs[Group(name)] = conf
+	}
+
+	for _, grp := range c.Groups {
+		if grp.Tls != nil {
+			groupConfs[Group(grp.Name)] = *grp.Tls
+		}
+	}
+
+	urlConfs := make(map[string]*rpc.TlsClientConfig)
+
+	for grp, conf := range groupConfs {
+		urlConf, ok := ethConf[grp]
+		if !ok {
+			return errors.Errorf("upstream TLS not supported for group %v, which is not in evm space", grp)
+		}
+
+		conf := conf
+		for _, url := range urlConf.urls() {
+			urlConfs[url] = &conf
+		}
+	}
+
+	return rpc.SetUpstreamTls(urlConfs)
 }
 
+// reloadUpstreamTls reloads TLS configurations of upstream nodes from viper.
+func reloadUpstreamTls() error {
+	var c config
+	if err := viper.UnmarshalKey("node", &c); err != nil {
+		return err
+	}
+
+	_, ethConf := newUrlConfig(&c)
+
+	return resetUpstreamTls(&c, ethConf)
+}
+
+// loadChainUrlConfig loads the latest node URL configurations of extra evm chains from
+// viper, which is used to reload node clusters at runtime.
+func loadChainUrlConfig() (map[string]map[Group]UrlConfig, error) {
+	var c config
+	if err := viper.UnmarshalKey("node", &c); err != nil {
+		return nil, err
+	}
+
+	return newChainUrlConfig(&c)
+}
+
+// urlConfigLoader loads the latest node URL configurations of some space.
+type urlConfigLoader func() (map[Group]UrlConfig, error)
+
 type config struct {
 	Endpoint     string `default:":22530"`
 	EthEndpoint  string `default:":28530"`
@@ -65,10 +315,59 @@ type config struct {
 	LogNodes     []string
 	EthLogNodes  []string
 	ArchiveNodes []string
-	HashRing     struct {
+	Chains       []ChainConfig
+	Groups       []GroupConfig
+	// built-in group => TLS configurations to connect upstream nodes over HTTPS
+	Tls map[string]rpc.TlsClientConfig
+	// warm spare nodes which are monitored but excluded from hash ring until activated
+	Spare struct {
+		// built-in group => spare node URLs
+		URLs map[string][]string
+		// activate spare nodes if the number of primary nodes in hash ring drops below threshold
+		MinCapacity int `default:"1"`
+		// interval to check group capacity, with 0 means never activated
+		Interval time.Duration `default:"5s"`
+	}
+	// hot/cold tiers of evm space groups by block age
+	Tiers []TierConfig
+	// canary nodes that receive only a percentage of traffic regardless of hash ring share
+	Canary []CanaryConfig
+	// shares the canonical head of the default evm chain among subsystems
+	HeadBroadcaster HeadBroadcasterConfig
+	// scheduled maintenance windows, during which nodes are excluded from routing
+	Maintenance struct {
+		// interval to check maintenance windows
+		Interval time.Duration `default:"10s"`
+		Windows  []MaintenanceWindow
+	}
+	// nodes failed to construct are quarantined and retried in the background
+	Quarantine struct {
+		// interval to retry quarantined nodes, with 0 means never retried
+		RetryInterval time.Duration `default:"30s"`
+	}
+	HashRing struct {
 		PartitionCount    int     `default:"15739"`
 		ReplicationFactor int     `default:"51"`
 		Load              float64 `default:"1.25"`
+		// persist hash ring snapshots to survive restarts, empty file means disabled
+		Snapshot struct {
+			File     string
+			Interval time.Duration `default:"1m"`
+		}
+		// built-in group => hash ring overrides, which apply to the same group of extra chains
+		Groups map[string]HashRingConfig
+		// alert when partition ownership skewed, and optionally rebalance automatically
+		Skew struct {
+			Enabled  bool
+			Interval time.Duration `default:"1m"`
+			// ratio of partitions owned by a node to the mean, beyond which (or below the
+			// reciprocal) the hash ring is considered skewed
+			Threshold float64 `default:"2"`
+			// whether to rebuild hash ring with doubled replication factor to rebalance
+			AutoRebalance bool
+			// max replication factor to rebalance
+			MaxReplicationFactor int `default:"400"`
+		}
 	}
 	Monitor struct {
 		Interval time.Duration `default:"1s"`
@@ -82,11 +381,30 @@ type config struct {
 			RemindInterval time.Duration `default:"5m"`
 			SuccessCounter uint64        `default:"60"`
 		}
+		// remove nodes lagging behind the group head from hash ring
+		Lag struct {
+			Enabled         bool
+			Interval        time.Duration `default:"5s"`
+			RemoveThreshold uint64        `default:"50"`
+			RejoinThreshold uint64        `default:"10"`
+		}
 	}
 	Router struct {
-		RedisURL        string
-		NodeRPCURL      string
-		EthNodeRPCURL   string
+		RedisURL      string
+		NodeRPCURL    string
+		EthNodeRPCURL string
+		// max number of blocks lagging behind the group head to route latest requests
+		MaxLatestLag uint64 `default:"5"`
+		// route key extractor, `ip` or `apiKey`
+		RouteKey string `default:"ip"`
+		// built-in group => fallback group if none node available in the group
+		FallbackGroups map[string]string
+		// sticky routes to keep key routed to the same node when hash ring repartitioned
+		Repartition struct {
+			Enabled    bool
+			TTL        time.Duration `default:"10m"`
+			MaxEntries int           `default:"100000"`
+		}
 		ChainedFailover struct {
 			URL      string
 			WSURL    string
@@ -94,18 +412,100 @@ type config struct {
 			EthWSURL string
 		}
 	}
+	// node manager gRPC endpoints, empty means disabled
+	Grpc struct {
+		Endpoint    string
+		EthEndpoint string
+	}
 }
 
-func (c *config) HashRingRaw() consistent.Config {
-	return consistent.Config{
+// HashRingRaw returns hash ring configurations of the specified group, which could be
+// overridden per group.
+func (c *config) HashRingRaw(group Group) consistent.Config {
+	conf := consistent.Config{
 		PartitionCount:    c.HashRing.PartitionCount,
 		ReplicationFactor: c.HashRing.ReplicationFactor,
 		Load:              c.HashRing.Load,
 		Hasher:            &hasher{},
 	}
+
+	override, ok := c.HashRing.Groups[string(group.Base())]
+	for _, grp := range c.Groups {
+		if Group(grp.Name) == group.Base() {
+			override, ok = grp.HashRing, true
+		}
+	}
+
+	if !ok {
+		return conf
+	}
+
+	if override.PartitionCount > 0 {
+		conf.PartitionCount = override.PartitionCount
+	}
+
+	if override.ReplicationFactor > 0 {
+		conf.ReplicationFactor = override.ReplicationFactor
+	}
+
+	if override.Load > 0 {
+		conf.Load = override.Load
+	}
+
+	return conf
+}
+
+// HashRingConfig overrides hash ring configurations of node group, e.g. fewer partitions for
+// small archive groups, and zero value means not overridden.
+type HashRingConfig struct {
+	PartitionCount    int
+	ReplicationFactor int
+	Load              float64
+}
+
+func (c *HashRingConfig) validate() error {
+	if c.PartitionCount < 0 || c.ReplicationFactor < 0 {
+		return errors.New("partition count and replication factor should not be negative")
+	}
+
+	// partitions could not be distributed if load factor is less than 1
+	if c.Load != 0 && c.Load < 1 {
+		return errors.Errorf("load factor should not be less than 1, but got %v", c.Load)
+	}
+
+	return nil
+}
+
+// ChainConfig node configurations for extra evm chain served by the same gateway process,
+// e.g. Scroll Sepolia besides mainnet.
+type ChainConfig struct {
+	// unique chain name, which is used to qualify node groups
+	Name string
+	// node manager RPC endpoint
+	Endpoint string
+	// node manager gRPC endpoint, empty means disabled
+	GrpcEndpoint string
+	URLs         []string
+	WSURLs       []string
+	LogNodes     []string
+	NodeRPCURL   string
 }
 
 type UrlConfig struct {
-	Nodes    []string
+	Nodes []string
+	// warm spare nodes excluded from hash ring until activated
+	Spares   []string
 	Failover string
+	// group to route requests if none node available in this group
+	Fallback Group
+}
+
+// urls returns all node URLs of the group, including spare and failover nodes.
+func (c *UrlConfig) urls() []string {
+	urls := append(append([]string{}, c.Nodes...), c.Spares...)
+	if len(c.Failover) > 0 {
+		urls = append(urls, c.Failover)
+	}
+
+	return urls
 }
@@ -21,6 +21,15 @@ type EthClientProvider struct {
 }
 
 func NewEthClientProvider(router Router) *EthClientProvider {
+	return newEthClientProvider(router, "", ethUrlCfg)
+}
+
+// NewChainEthClientProvider creates client provider for the specified extra evm chain.
+func NewChainEthClientProvider(router Router, chain string) *EthClientProvider {
+	return newEthClientProvider(router, chain, chainUrlCfgs[chain])
+}
+
+func newEthClientProvider(router Router, chain string, groupConf map[Group]UrlConfig) *EthClientProvider {
 	cp := &EthClientProvider{
 		clientProvider: newClientProvider(router, func(url string) (interface{}, error) {
 			client, err := rpc.NewEthClient(url, rpc.WithClientHookMetrics(true))
@@ -32,33 +41,95 @@ func NewEthClientProvider(router Router) *EthClientProvider {
 		}),
 	}
 
-	for grp := range ethUrlCfg {
-		cp.registerGroup(grp)
-	}
+	cp.chain = chain
+
+	cp.registerGroups(groupConf)
 
 	return cp
 }
 
-// GetClientByIP gets client of normal HTTP group by remote IP address.
+// GetClientByIP gets client of normal HTTP group by route key (remote IP address by default).
 func (p *EthClientProvider) GetClientByIP(ctx context.Context) (*Web3goClient, error) {
 	return p.GetClientByIPGroup(ctx, GroupEthHttp)
 }
 
-// GetClientByIPGroup gets client of specific group by remote IP address.
+// GetClientByIPGroup gets client of specific group by route key (remote IP address by default).
 func (p *EthClientProvider) GetClientByIPGroup(ctx context.Context, group Group) (*Web3goClient, error) {
-	remoteAddr := remoteAddrFromContext(ctx)
-	client, err := p.getClient(remoteAddr, group)
+	key := routeKeyFromContext(ctx)
 
-	return client.(*Web3goClient), err
+	client, err := p.getClient(key, group)
+	if err != nil {
+		return nil, err
+	}
+
+	return client.(*Web3goClient), nil
+}
+
+// GetClientByIPGroupHeight gets client of specific group by route key (remote IP address by default),
+// whose synced height covers the specified height. Note, nil height stands for the latest height.
+func (p *EthClientProvider) GetClientByIPGroupHeight(
+	ctx context.Context, group Group, height *uint64,
+) (*Web3goClient, error) {
+	key := routeKeyFromContext(ctx)
+	client, err := p.getClientByHeight(key, group, height)
+	if err != nil {
+		return nil, err
+	}
+
+	return client.(*Web3goClient), nil
+}
+
+// GetClientByNodeName gets client of specific group by node name, which is the node URL
+// without scheme, e.g. `10.0.0.1:8545`.
+func (p *EthClientProvider) GetClientByNodeName(group Group, nodeName string) (*Web3goClient, error) {
+	confs := ethUrlCfg
+	if len(p.chain) > 0 {
+		confs = chainUrlCfgs[p.chain]
+	}
+
+	conf := confs[group]
+
+	client, err := p.getClientByNodeName(group, conf.urls(), nodeName)
+	if err != nil {
+		return nil, err
+	}
+
+	return client.(*Web3goClient), nil
 }
 
 func (p *EthClientProvider) GetClientRandom() (*Web3goClient, error) {
-	return p.GetClientRandomByGroup(GroupEthHttp);
+	return p.GetClientRandomByGroup(GroupEthHttp)
 }
 
 func (p *EthClientProvider) GetClientRandomByGroup(group Group) (*Web3goClient, error) {
 	key := fmt.Sprintf("random_key_%v", rand.Int())
+
 	client, err := p.getClient(key, group)
+	if err != nil {
+		return nil, err
+	}
+
+	return client.(*Web3goClient), nil
+}
+
+// GetClientRandomByGroupExcept gets client of specific group randomly, which is different
+// from the excluded node URLs, e.g. to hedge request to another node.
+func (p *EthClientProvider) GetClientRandomByGroupExcept(group Group, excludedURLs ...string) (*Web3goClient, error) {
+	excluded := make(map[string]bool, len(excludedURLs))
+	for _, url := range excludedURLs {
+		excluded[rpc.Url2NodeName(url)] = true
+	}
+
+	for i := 0; i < maxRandomRouteAttempts; i++ {
+		client, err := p.GetClientRandomByGroup(group)
+		if err != nil {
+			return nil, err
+		}
+
+		if !excluded[rpc.Url2NodeName(client.URL)] {
+			return client, nil
+		}
+	}
 
-	return client.(*Web3goClient), err
+	return nil, ErrClientUnavailable
 }
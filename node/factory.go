@@ -4,6 +4,7 @@ import (
 	"sync"
 
 	"github.com/scroll-tech/rpc-gateway/util/rpc"
+	"google.golang.org/grpc"
 )
 
 var (
@@ -12,6 +13,9 @@ var (
 
 	ethFactory *factory
 	ethOnce    sync.Once
+
+	chainFactories map[string]*factory // chain name => factory
+	chainOnce      sync.Once
 )
 
 // Factory returns core space instance factory
@@ -19,9 +23,18 @@ func Factory() *factory {
 	cfxOnce.Do(func() {
 		cfxFactory = newFactory(
 			func(group Group, name, url string, hm HealthMonitor) (Node, error) {
-				return NewCfxNode(group, name, url, hm), nil
+				n, err := NewCfxNode(group, name, url, hm)
+				if err != nil {
+					return nil, err
+				}
+
+				return n, nil
+			},
+			cfg.Endpoint, cfg.Grpc.Endpoint, urlCfg, cfg.Router.NodeRPCURL,
+			func() (map[Group]UrlConfig, error) {
+				conf, _, err := loadUrlConfig()
+				return conf, err
 			},
-			cfg.Endpoint, urlCfg, cfg.Router.NodeRPCURL,
 		)
 	})
 
@@ -32,39 +45,118 @@ func Factory() *factory {
 func EthFactory() *factory {
 	ethOnce.Do(func() {
 		ethFactory = newFactory(
-			func(group Group, name, url string, hm HealthMonitor) (Node, error) {
-				return NewEthNode(group, name, url, hm), nil
+			newEthNode, cfg.EthEndpoint, cfg.Grpc.EthEndpoint, ethUrlCfg, cfg.Router.EthNodeRPCURL,
+			func() (map[Group]UrlConfig, error) {
+				_, conf, err := loadUrlConfig()
+				return conf, err
 			},
-			cfg.EthEndpoint, ethUrlCfg, cfg.Router.EthNodeRPCURL,
 		)
 	})
 
 	return ethFactory
 }
 
+// ChainFactory returns instance factory of the specified extra evm chain if configured.
+func ChainFactory(chain string) (*factory, bool) {
+	chainOnce.Do(func() {
+		chainFactories = make(map[string]*factory)
+
+		for _, c := range cfg.Chains {
+			chain := c.Name
+
+			chainFactories[chain] = newFactory(
+				newEthNode, c.Endpoint, c.GrpcEndpoint, chainUrlCfgs[chain], c.NodeRPCURL,
+				func() (map[Group]UrlConfig, error) {
+					confs, err := loadChainUrlConfig()
+					if err != nil {
+						return nil, err
+					}
+
+					return confs[chain], nil
+				},
+			)
+		}
+	})
+
+	f, ok := chainFactories[chain]
+	return f, ok
+}
+
+// Chains returns the names of all configured extra evm chains.
+func Chains() []string {
+	var chains []string
+	for _, c := range cfg.Chains {
+		chains = append(chains, c.Name)
+	}
+
+	return chains
+}
+
+func newEthNode(group Group, name, url string, hm HealthMonitor) (Node, error) {
+	n, err := NewEthNode(group, name, url, hm)
+	if err != nil {
+		return nil, err
+	}
+
+	return n, nil
+}
+
 // factory creates router and RPC server.
 type factory struct {
-	nodeRpcUrl     string
-	rpcSrvEndpoint string
-	groupConf      map[Group]UrlConfig
-	nodeFactory    nodeFactory
+	nodeRpcUrl      string
+	rpcSrvEndpoint  string
+	grpcSrvEndpoint string
+	groupConf       map[Group]UrlConfig
+	groupConfLoad   urlConfigLoader // to reload group config at runtime
+	nodeFactory     nodeFactory
+
+	api     *api // shared by RPC and gRPC servers
+	apiOnce sync.Once
 }
 
-func newFactory(nf nodeFactory, rpcSrvEndpoint string, groupConf map[Group]UrlConfig, nodeRpcUrl string) *factory {
+func newFactory(
+	nf nodeFactory, rpcSrvEndpoint, grpcSrvEndpoint string,
+	groupConf map[Group]UrlConfig, nodeRpcUrl string, loader urlConfigLoader,
+) *factory {
 	return &factory{
-		nodeRpcUrl:     nodeRpcUrl,
-		nodeFactory:    nf,
-		rpcSrvEndpoint: rpcSrvEndpoint,
-		groupConf:      groupConf,
+		nodeRpcUrl:      nodeRpcUrl,
+		nodeFactory:     nf,
+		rpcSrvEndpoint:  rpcSrvEndpoint,
+		grpcSrvEndpoint: grpcSrvEndpoint,
+		groupConf:       groupConf,
+		groupConfLoad:   loader,
 	}
 }
 
+func (f *factory) getApi() *api {
+	f.apiOnce.Do(func() {
+		f.api = newApi(f.nodeFactory, f.groupConf, f.groupConfLoad)
+	})
+
+	return f.api
+}
+
 // CreatRpcServer creates node manager RPC server
 func (f *factory) CreatRpcServer() (*rpc.Server, string) {
-	return NewServer(f.nodeFactory, f.groupConf), f.rpcSrvEndpoint
+	return newServer(f.getApi()), f.rpcSrvEndpoint
+}
+
+// CreateGrpcServer creates node manager gRPC server, which shares node managers with RPC
+// server. Note, nil server returned if gRPC endpoint not configured.
+func (f *factory) CreateGrpcServer() (*grpc.Server, string) {
+	if len(f.grpcSrvEndpoint) == 0 {
+		return nil, ""
+	}
+
+	return newGrpcServer(f.getApi()), f.grpcSrvEndpoint
 }
 
 // CreateRouter creates node router
 func (f *factory) CreateRouter() Router {
-	return MustNewRouter(cfg.Router.RedisURL, f.nodeRpcUrl, f.groupConf)
+	return MustNewRouter(cfg.Router.RedisURL, f.nodeRpcUrl, f.groupConf, f.groupConfLoad)
+}
+
+// NewRouter creates node router, and returns error instead of exit on failure.
+func (f *factory) NewRouter() (Router, error) {
+	return NewRouter(cfg.Router.RedisURL, f.nodeRpcUrl, f.groupConf, f.groupConfLoad)
 }
@@ -0,0 +1,121 @@
+package node
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/scroll-tech/rpc-gateway/util/rpc"
+)
+
+const (
+	// routing policies for config-driven node groups
+	RoutingConsistentHashing = "consistentHashing"
+	RoutingRandom            = "random"
+)
+
+// GroupConfig config-driven node group, so that new upstream class (e.g. trace, light)
+// could be added without code change.
+type GroupConfig struct {
+	// unique group name
+	Name string
+	// space name, `cfx` or `eth`
+	Space string
+	URLs  []string
+	// warm spare nodes excluded from hash ring until group capacity drops below threshold
+	Spares   []string
+	Failover string
+	// group to route requests if none node available in this group
+	Fallback string
+	// RPC methods served by the group, which supports `*` suffix as wildcard, e.g. `trace_*`
+	Methods []string
+	// routing policy, `consistentHashing` (default) or `random`
+	Routing string
+	// TLS configurations to connect upstream nodes over HTTPS, only available for evm space
+	Tls *rpc.TlsClientConfig
+	// hash ring overrides of the group
+	HashRing HashRingConfig
+}
+
+// customGroups config-driven node groups in configured order.
+var customGroups []GroupConfig
+
+// validateGroupConfigs validates the config-driven node groups and fills default values.
+func validateGroupConfigs(confs []GroupConfig) error {
+	builtins := map[Group]bool{
+		GroupCfxHttp: true, GroupCfxWs: true, GroupCfxLogs: true, GroupCfxArchives: true,
+		GroupEthHttp: true, GroupEthWs: true, GroupEthLogs: true, GroupDebugHttp: true,
+	}
+
+	names := make(map[string]bool)
+
+	for i := range confs {
+		conf := &confs[i]
+
+		if len(conf.Name) == 0 || strings.Contains(conf.Name, chainSeparator) {
+			return errors.Errorf("invalid group name %q", conf.Name)
+		}
+
+		if builtins[Group(conf.Name)] || names[conf.Name] {
+			return errors.Errorf("duplicate group name %q", conf.Name)
+		}
+
+		names[conf.Name] = true
+
+		if conf.Space != "cfx" && conf.Space != "eth" {
+			return errors.Errorf("invalid space %q for group %v", conf.Space, conf.Name)
+		}
+
+		switch conf.Routing {
+		case "":
+			conf.Routing = RoutingConsistentHashing
+		case RoutingConsistentHashing, RoutingRandom:
+		default:
+			return errors.Errorf("invalid routing policy %q for group %v", conf.Routing, conf.Name)
+		}
+
+		if err := conf.HashRing.validate(); err != nil {
+			return errors.WithMessagef(err, "invalid hash ring for group %v", conf.Name)
+		}
+	}
+
+	return nil
+}
+
+// customGroupSpace returns the space of config-driven node group if any.
+func customGroupSpace(g Group) (string, bool) {
+	for _, conf := range customGroups {
+		if Group(conf.Name) == g {
+			return conf.Space, true
+		}
+	}
+
+	return "", false
+}
+
+// MatchGroup returns the config-driven node group and routing policy to serve the specified
+// RPC method of some space. Groups are matched in configured order.
+func MatchGroup(space, method string) (Group, string, bool) {
+	for _, conf := range customGroups {
+		if conf.Space != space {
+			continue
+		}
+
+		for _, pattern := range conf.Methods {
+			if MatchMethod(pattern, method) {
+				return Group(conf.Name), conf.Routing, true
+			}
+		}
+	}
+
+	return "", "", false
+}
+
+// MatchMethod checks if RPC method matches the specified pattern, which supports `*`
+// suffix as wildcard.
+func MatchMethod(pattern, method string) bool {
+	if strings.HasSuffix(pattern, "*") {
+		return strings.HasPrefix(method, strings.TrimSuffix(pattern, "*"))
+	}
+
+	return pattern == method
+}
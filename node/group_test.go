@@ -0,0 +1,100 @@
+package node
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchMethod(t *testing.T) {
+	assert.True(t, MatchMethod("trace_*", "trace_block"))
+	assert.True(t, MatchMethod("debug_traceTransaction", "debug_traceTransaction"))
+	assert.False(t, MatchMethod("debug_traceTransaction", "debug_traceCall"))
+	assert.False(t, MatchMethod("trace_*", "eth_call"))
+}
+
+func TestValidateGroupConfigs(t *testing.T) {
+	confs := []GroupConfig{{Name: "ethtrace", Space: "eth"}}
+	assert.Nil(t, validateGroupConfigs(confs))
+	assert.Equal(t, RoutingConsistentHashing, confs[0].Routing)
+
+	assert.NotNil(t, validateGroupConfigs([]GroupConfig{{Name: GroupEthHttp, Space: "eth"}}))
+	assert.NotNil(t, validateGroupConfigs([]GroupConfig{{Name: "a.b", Space: "eth"}}))
+	assert.NotNil(t, validateGroupConfigs([]GroupConfig{{Name: "light", Space: "btc"}}))
+	assert.NotNil(t, validateGroupConfigs([]GroupConfig{{Name: "light", Space: "eth", Routing: "foo"}}))
+}
+
+func TestChainedRouterFallback(t *testing.T) {
+	groupConf := map[Group]UrlConfig{
+		GroupCfxHttp:     {Fallback: GroupCfxArchives, Failover: "http://failover"},
+		GroupCfxArchives: {Fallback: GroupCfxHttp},
+	}
+
+	router := NewChainedRouter(groupConf, NewLocalRouter(map[Group][]string{
+		GroupCfxArchives: {"http://archive"},
+	}))
+
+	assert.Equal(t, "http://archive", router.Route(GroupCfxHttp, []byte("key")))
+
+	// cyclic fallback terminated with failover
+	router = NewChainedRouter(groupConf, NewLocalRouter(nil))
+	assert.Equal(t, "http://failover", router.Route(GroupCfxHttp, []byte("key")))
+}
+
+// healthRouter routes to healthy nodes only, e.g. node RPC router.
+type healthRouter map[Group]string
+
+func (r healthRouter) Route(group Group, key []byte) string {
+	return r[group]
+}
+
+func TestChainedRouterFallbackUnhealthy(t *testing.T) {
+	groupConf := map[Group]UrlConfig{
+		GroupCfxHttp:     {Nodes: []string{"http://http"}, Fallback: GroupCfxArchives},
+		GroupCfxArchives: {Nodes: []string{"http://archive"}},
+	}
+
+	local := NewLocalRouter(map[Group][]string{
+		GroupCfxHttp:     {"http://http"},
+		GroupCfxArchives: {"http://archive"},
+	})
+
+	// all nodes of group unhealthy
+	router := NewChainedRouter(groupConf, healthRouter{GroupCfxArchives: "http://archive"}, local)
+	assert.Equal(t, "http://archive", router.Route(GroupCfxHttp, []byte("key")))
+	assert.Equal(t, "http://archive", router.(HeightRouter).RouteByHeight(GroupCfxHttp, []byte("key"), nil))
+
+	// healthy nodes in group
+	router = NewChainedRouter(groupConf, healthRouter{GroupCfxHttp: "http://http"}, local)
+	assert.Equal(t, "http://http", router.Route(GroupCfxHttp, []byte("key")))
+
+	// health aware routers unavailable, e.g. node RPC down
+	router = NewChainedRouter(groupConf, healthRouter{}, local)
+	assert.Equal(t, "http://http", router.Route(GroupCfxHttp, []byte("key")))
+}
+
+func TestValidateFallbackGroups(t *testing.T) {
+	assert.Nil(t, validateFallbackGroups(map[Group]UrlConfig{
+		GroupEthLogs: {Fallback: GroupEthHttp}, GroupEthHttp: {},
+	}))
+	assert.NotNil(t, validateFallbackGroups(map[Group]UrlConfig{GroupEthHttp: {Fallback: GroupEthHttp}}))
+	assert.NotNil(t, validateFallbackGroups(map[Group]UrlConfig{GroupEthHttp: {Fallback: GroupCfxHttp}}))
+}
+
+func TestHashRingOverrides(t *testing.T) {
+	c := config{Groups: []GroupConfig{{Name: "ethtrace", Space: "eth", HashRing: HashRingConfig{Load: 1.5}}}}
+	c.HashRing.PartitionCount, c.HashRing.ReplicationFactor, c.HashRing.Load = 15739, 51, 1.25
+	c.HashRing.Groups = map[string]HashRingConfig{GroupCfxArchives: {PartitionCount: 271}}
+
+	conf := c.HashRingRaw(GroupCfxArchives)
+	assert.Equal(t, 271, conf.PartitionCount)
+	assert.Equal(t, 51, conf.ReplicationFactor)
+
+	conf = c.HashRingRaw(Group("ethtrace").WithChain("sepolia"))
+	assert.Equal(t, 15739, conf.PartitionCount)
+	assert.Equal(t, 1.5, conf.Load)
+
+	assert.Equal(t, 1.25, c.HashRingRaw(GroupEthHttp).Load)
+
+	assert.NotNil(t, validateGroupConfigs([]GroupConfig{{Name: "light", Space: "eth", HashRing: HashRingConfig{Load: 0.5}}}))
+}
@@ -0,0 +1,254 @@
+package node
+
+import (
+	"context"
+	"net"
+	"sort"
+	"sync"
+
+	"github.com/scroll-tech/rpc-gateway/util/rpc/grpcwire"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const grpcServiceName = "gateway.node.v1.NodeManagement"
+
+// grpcServer implements the NodeManagement gRPC service defined in management.proto.
+type grpcServer struct {
+	api *api
+}
+
+func newGrpcServer(api *api) *grpc.Server {
+	server := grpc.NewServer(grpc.ForceServerCodec(grpcwire.Codec{}))
+	server.RegisterService(&grpcServiceDesc, &grpcServer{api})
+
+	return server
+}
+
+// MustServeGrpcGraceful serves gRPC server until graceful shutdown.
+func MustServeGrpcGraceful(ctx context.Context, wg *sync.WaitGroup, server *grpc.Server, endpoint string) {
+	logger := logrus.WithField("endpoint", endpoint)
+
+	listener, err := net.Listen("tcp", endpoint)
+	if err != nil {
+		logger.WithError(err).Fatal("Failed to listen to gRPC endpoint")
+	}
+
+	wg.Add(1)
+	defer wg.Done()
+
+	go server.Serve(listener)
+	logger.Info("Node management gRPC server started")
+
+	<-ctx.Done()
+
+	server.GracefulStop()
+	logger.Info("Node management gRPC server shutdown")
+}
+
+func (s *grpcServer) manager(group string) (*Manager, error) {
+	if m, ok := s.api.managers[Group(group)]; ok {
+		return m, nil
+	}
+
+	return nil, status.Errorf(codes.NotFound, "node group %v not found", group)
+}
+
+func (s *grpcServer) ListNodes(ctx context.Context, req *pbGroupRequest) (*pbNodeList, error) {
+	if _, err := s.manager(req.Group); err != nil {
+		return nil, err
+	}
+
+	urls := s.api.List(Group(req.Group))
+	sort.Strings(urls)
+
+	return &pbNodeList{Urls: urls}, nil
+}
+
+func (s *grpcServer) AddNode(ctx context.Context, req *pbNodeRequest) (*grpcwire.Empty, error) {
+	m, err := s.manager(req.Group)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.Add(req.Url); err != nil {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+
+	return &grpcwire.Empty{}, nil
+}
+
+func (s *grpcServer) RemoveNode(ctx context.Context, req *pbNodeRequest) (*grpcwire.Empty, error) {
+	m, err := s.manager(req.Group)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := m.Remove(req.Url); err == errNodeNotFound {
+		return nil, status.Errorf(codes.NotFound, "node %v not found", req.Url)
+	} else if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return &grpcwire.Empty{}, nil
+}
+
+func (s *grpcServer) DrainNode(ctx context.Context, req *pbNodeRequest) (*grpcwire.Empty, error) {
+	m, err := s.manager(req.Group)
+	if err != nil {
+		return nil, err
+	}
+
+	if !m.Drain(req.Url) {
+		return nil, status.Errorf(codes.NotFound, "node %v not found", req.Url)
+	}
+
+	return &grpcwire.Empty{}, nil
+}
+
+func (s *grpcServer) UndrainNode(ctx context.Context, req *pbNodeRequest) (*grpcwire.Empty, error) {
+	m, err := s.manager(req.Group)
+	if err != nil {
+		return nil, err
+	}
+
+	if !m.Undrain(req.Url) {
+		return nil, status.Errorf(codes.FailedPrecondition, "node %v not found or not drained", req.Url)
+	}
+
+	return &grpcwire.Empty{}, nil
+}
+
+func (s *grpcServer) GetHealth(ctx context.Context, req *pbGroupRequest) (*pbNodeHealthList, error) {
+	m, err := s.manager(req.Group)
+	if err != nil {
+		return nil, err
+	}
+
+	var result pbNodeHealthList
+
+	for _, n := range m.List() {
+		st := n.Status()
+
+		result.Nodes = append(result.Nodes, &pbNodeHealth{
+			Url:            n.Url(),
+			Healthy:        !st.unhealthy,
+			Drained:        m.IsDrained(n.Url()),
+			LatestEpoch:    st.latestStateEpoch,
+			FailureCounter: st.failureCounter,
+		})
+	}
+
+	sort.Slice(result.Nodes, func(i, j int) bool {
+		return result.Nodes[i].Url < result.Nodes[j].Url
+	})
+
+	return &result, nil
+}
+
+func (s *grpcServer) DumpRoutingTable(ctx context.Context, req *pbGroupRequest) (*pbRoutingTable, error) {
+	m, err := s.manager(req.Group)
+	if err != nil {
+		return nil, err
+	}
+
+	var result pbRoutingTable
+
+	for url, partitions := range m.RoutingTable() {
+		result.Entries = append(result.Entries, &pbRoutingEntry{Url: url, Partitions: uint32(partitions)})
+	}
+
+	sort.Slice(result.Entries, func(i, j int) bool {
+		return result.Entries[i].Url < result.Entries[j].Url
+	})
+
+	return &result, nil
+}
+
+func (s *grpcServer) FlushCaches(ctx context.Context, req *pbGroupRequest) (*grpcwire.Empty, error) {
+	if len(req.Group) == 0 {
+		s.api.FlushCaches(nil)
+		return &grpcwire.Empty{}, nil
+	}
+
+	if _, err := s.manager(req.Group); err != nil {
+		return nil, err
+	}
+
+	group := Group(req.Group)
+	s.api.FlushCaches(&group)
+
+	return &grpcwire.Empty{}, nil
+}
+
+// grpcUnaryHandler adapts typed gRPC method to generic unary handler.
+func grpcUnaryHandler(
+	method string,
+	newReq func() grpcwire.Unmarshaler,
+	call func(s *grpcServer, ctx context.Context, req interface{}) (interface{}, error),
+) grpc.MethodDesc {
+	return grpc.MethodDesc{
+		MethodName: method,
+		Handler: func(
+			srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor,
+		) (interface{}, error) {
+			req := newReq()
+			if err := dec(req); err != nil {
+				return nil, err
+			}
+
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				return call(srv.(*grpcServer), ctx, req)
+			}
+
+			if interceptor == nil {
+				return handler(ctx, req)
+			}
+
+			info := &grpc.UnaryServerInfo{
+				Server:     srv,
+				FullMethod: "/" + grpcServiceName + "/" + method,
+			}
+
+			return interceptor(ctx, req, info, handler)
+		},
+	}
+}
+
+func newGroupRequest() grpcwire.Unmarshaler { return &pbGroupRequest{} }
+func newNodeRequest() grpcwire.Unmarshaler  { return &pbNodeRequest{} }
+
+var grpcServiceDesc = grpc.ServiceDesc{
+	ServiceName: grpcServiceName,
+	HandlerType: (*interface{})(nil),
+	Methods: []grpc.MethodDesc{
+		grpcUnaryHandler("ListNodes", newGroupRequest, func(s *grpcServer, ctx context.Context, req interface{}) (interface{}, error) {
+			return s.ListNodes(ctx, req.(*pbGroupRequest))
+		}),
+		grpcUnaryHandler("AddNode", newNodeRequest, func(s *grpcServer, ctx context.Context, req interface{}) (interface{}, error) {
+			return s.AddNode(ctx, req.(*pbNodeRequest))
+		}),
+		grpcUnaryHandler("RemoveNode", newNodeRequest, func(s *grpcServer, ctx context.Context, req interface{}) (interface{}, error) {
+			return s.RemoveNode(ctx, req.(*pbNodeRequest))
+		}),
+		grpcUnaryHandler("DrainNode", newNodeRequest, func(s *grpcServer, ctx context.Context, req interface{}) (interface{}, error) {
+			return s.DrainNode(ctx, req.(*pbNodeRequest))
+		}),
+		grpcUnaryHandler("UndrainNode", newNodeRequest, func(s *grpcServer, ctx context.Context, req interface{}) (interface{}, error) {
+			return s.UndrainNode(ctx, req.(*pbNodeRequest))
+		}),
+		grpcUnaryHandler("GetHealth", newGroupRequest, func(s *grpcServer, ctx context.Context, req interface{}) (interface{}, error) {
+			return s.GetHealth(ctx, req.(*pbGroupRequest))
+		}),
+		grpcUnaryHandler("DumpRoutingTable", newGroupRequest, func(s *grpcServer, ctx context.Context, req interface{}) (interface{}, error) {
+			return s.DumpRoutingTable(ctx, req.(*pbGroupRequest))
+		}),
+		grpcUnaryHandler("FlushCaches", newGroupRequest, func(s *grpcServer, ctx context.Context, req interface{}) (interface{}, error) {
+			return s.FlushCaches(ctx, req.(*pbGroupRequest))
+		}),
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "node/management.proto",
+}
@@ -0,0 +1,104 @@
+package node
+
+import (
+	"github.com/scroll-tech/rpc-gateway/util/rpc/grpcwire"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Protobuf messages defined in management.proto, which are encoded in wire format manually
+// to avoid code generation for a handful of simple messages.
+
+type pbGroupRequest struct {
+	Group string
+}
+
+func (m *pbGroupRequest) UnmarshalWire(b []byte) error {
+	return grpcwire.ConsumeFields(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		if num == 1 {
+			return grpcwire.ConsumeString(typ, b, &m.Group)
+		}
+
+		return 0, nil
+	})
+}
+
+type pbNodeRequest struct {
+	Group string
+	Url   string
+}
+
+func (m *pbNodeRequest) UnmarshalWire(b []byte) error {
+	return grpcwire.ConsumeFields(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		switch num {
+		case 1:
+			return grpcwire.ConsumeString(typ, b, &m.Group)
+		case 2:
+			return grpcwire.ConsumeString(typ, b, &m.Url)
+		}
+
+		return 0, nil
+	})
+}
+
+type pbNodeList struct {
+	Urls []string
+}
+
+func (m *pbNodeList) MarshalWire(b []byte) []byte {
+	for _, url := range m.Urls {
+		b = protowire.AppendTag(b, 1, protowire.BytesType)
+		b = protowire.AppendString(b, url)
+	}
+
+	return b
+}
+
+type pbNodeHealth struct {
+	Url            string
+	Healthy        bool
+	Drained        bool
+	LatestEpoch    uint64
+	FailureCounter uint64
+}
+
+func (m *pbNodeHealth) MarshalWire(b []byte) []byte {
+	b = grpcwire.AppendString(b, 1, m.Url)
+	b = grpcwire.AppendBool(b, 2, m.Healthy)
+	b = grpcwire.AppendBool(b, 3, m.Drained)
+	b = grpcwire.AppendVarint(b, 4, m.LatestEpoch)
+	return grpcwire.AppendVarint(b, 5, m.FailureCounter)
+}
+
+type pbNodeHealthList struct {
+	Nodes []*pbNodeHealth
+}
+
+func (m *pbNodeHealthList) MarshalWire(b []byte) []byte {
+	for _, n := range m.Nodes {
+		b = grpcwire.AppendMessage(b, 1, n)
+	}
+
+	return b
+}
+
+type pbRoutingEntry struct {
+	Url        string
+	Partitions uint32
+}
+
+func (m *pbRoutingEntry) MarshalWire(b []byte) []byte {
+	b = grpcwire.AppendString(b, 1, m.Url)
+	return grpcwire.AppendVarint(b, 2, uint64(m.Partitions))
+}
+
+type pbRoutingTable struct {
+	Entries []*pbRoutingEntry
+}
+
+func (m *pbRoutingTable) MarshalWire(b []byte) []byte {
+	for _, e := range m.Entries {
+		b = grpcwire.AppendMessage(b, 1, e)
+	}
+
+	return b
+}
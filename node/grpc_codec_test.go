@@ -0,0 +1,40 @@
+package node
+
+import (
+	"testing"
+
+	"github.com/scroll-tech/rpc-gateway/util/rpc/grpcwire"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func TestWireCodecUnmarshal(t *testing.T) {
+	var b []byte
+	b = grpcwire.AppendString(b, 1, "ethhttp")
+	b = grpcwire.AppendVarint(b, 9, 100) // unknown field
+	b = grpcwire.AppendString(b, 2, "http://127.0.0.1:8545")
+
+	var req pbNodeRequest
+	assert.Nil(t, grpcwire.Codec{}.Unmarshal(b, &req))
+	assert.Equal(t, "ethhttp", req.Group)
+	assert.Equal(t, "http://127.0.0.1:8545", req.Url)
+
+	// invalid wire type
+	b = protowire.AppendTag(nil, 1, protowire.VarintType)
+	b = protowire.AppendVarint(b, 1)
+	assert.NotNil(t, grpcwire.Codec{}.Unmarshal(b, &req))
+}
+
+func TestWireCodecMarshal(t *testing.T) {
+	table := pbRoutingTable{Entries: []*pbRoutingEntry{{Url: "a", Partitions: 2}}}
+
+	data, err := grpcwire.Codec{}.Marshal(&table)
+	assert.Nil(t, err)
+
+	entry := []byte{0x0a, 0x01, 'a', 0x10, 0x02}
+	expected := append([]byte{0x0a, byte(len(entry))}, entry...)
+	assert.Equal(t, expected, data)
+
+	_, err = grpcwire.Codec{}.Marshal(&pbGroupRequest{})
+	assert.NotNil(t, err)
+}
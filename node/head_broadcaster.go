@@ -0,0 +1,299 @@
+package node
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/openweb3/web3go/types"
+	"github.com/pkg/errors"
+	"github.com/scroll-tech/rpc-gateway/util/metrics"
+	"github.com/scroll-tech/rpc-gateway/util/rpc"
+	"github.com/sirupsen/logrus"
+)
+
+// HeadBroadcasterConfig configurations of chain head broadcaster, which subscribes newHeads
+// from multiple nodes of the default evm chain, and shares the reconciled canonical head with
+// all subsystems, e.g. cache invalidator, lag detector and WebSocket fan-out.
+type HeadBroadcasterConfig struct {
+	Enabled bool
+	// WebSocket node URLs to subscribe newHeads, empty means nodes of `ethws` group
+	URLs []string
+	// min number of nodes agreed on the canonical head, which is capped by the number of
+	// connected nodes
+	Quorum int `default:"1"`
+	// buffer size of each subscriber, beyond which heads are dropped for the subscriber
+	BufferSize int `default:"64"`
+}
+
+// Head is the canonical chain head reconciled by head broadcaster.
+type Head struct {
+	Number     uint64
+	Hash       common.Hash
+	ParentHash common.Hash
+	// raw header reported by node
+	Header *types.Header
+	// whether the head replaces the previous one rather than extends it
+	Reorg bool
+}
+
+// newHead parses the header of newHeads subscription.
+func newHead(header *types.Header) (*Head, error) {
+	data, err := json.Marshal(header)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields struct {
+		Number     *hexutil.Uint64 `json:"number"`
+		Hash       common.Hash     `json:"hash"`
+		ParentHash common.Hash     `json:"parentHash"`
+	}
+
+	if err = json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	if fields.Number == nil {
+		return nil, errors.New("block number missed")
+	}
+
+	return &Head{
+		Number:     uint64(*fields.Number),
+		Hash:       fields.Hash,
+		ParentHash: fields.ParentHash,
+		Header:     header,
+	}, nil
+}
+
+// HeadBroadcaster reconciles the canonical head from multiple nodes, and broadcasts to
+// subscribers once changed.
+type HeadBroadcaster struct {
+	mu        sync.Mutex
+	conf      HeadBroadcasterConfig
+	started   bool
+	sources   map[string]*Head // node name => latest head
+	canonical *Head
+	subs      map[chan *Head]struct{}
+}
+
+func newHeadBroadcaster(conf HeadBroadcasterConfig) *HeadBroadcaster {
+	return &HeadBroadcaster{
+		conf:    conf,
+		sources: make(map[string]*Head),
+		subs:    make(map[chan *Head]struct{}),
+	}
+}
+
+var (
+	headBroadcaster     *HeadBroadcaster
+	headBroadcasterOnce sync.Once
+)
+
+// Heads returns the head broadcaster of the default evm chain.
+func Heads() *HeadBroadcaster {
+	headBroadcasterOnce.Do(func() {
+		headBroadcaster = newHeadBroadcaster(cfg.HeadBroadcaster)
+	})
+
+	return headBroadcaster
+}
+
+// StartHeadBroadcaster starts to subscribe newHeads from nodes if enabled, and returns false
+// if disabled. Note, it is started only once even if called multiple times.
+func StartHeadBroadcaster(ctx context.Context) bool {
+	b := Heads()
+
+	if !b.conf.Enabled {
+		return false
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.started {
+		return true
+	}
+
+	urls := b.conf.URLs
+	if len(urls) == 0 {
+		urls = ethUrlCfg[GroupEthWs].Nodes
+	}
+
+	for _, url := range urls {
+		go b.subscribe(ctx, url)
+	}
+
+	b.started = true
+
+	logrus.WithField("urls", urls).Info("Head broadcaster started")
+
+	return true
+}
+
+// Started checks if head broadcaster is started.
+func (b *HeadBroadcaster) Started() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.started
+}
+
+// Head returns the canonical head if any.
+func (b *HeadBroadcaster) Head() (*Head, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.canonical, b.canonical != nil
+}
+
+// Subscribe subscribes canonical heads, and returns a function to unsubscribe. Note, heads
+// are dropped for slow subscriber once buffer is full.
+func (b *HeadBroadcaster) Subscribe() (<-chan *Head, func()) {
+	ch := make(chan *Head, b.conf.BufferSize)
+
+	b.mu.Lock()
+	b.subs[ch] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+
+	return ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subs, ch)
+			b.mu.Unlock()
+		})
+	}
+}
+
+// subscribe subscribes newHeads from node, and resubscribes if any error.
+func (b *HeadBroadcaster) subscribe(ctx context.Context, url string) {
+	nodeName := rpc.Url2NodeName(url)
+	logger := logrus.WithField("node", nodeName)
+
+	for {
+		if err := b.subscribeOnce(ctx, nodeName, url); err != nil {
+			logger.WithError(err).Info("Head broadcaster failed to subscribe newHeads")
+		}
+
+		// heads of disconnected node are not taken into account
+		b.mu.Lock()
+		delete(b.sources, nodeName)
+		b.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+func (b *HeadBroadcaster) subscribeOnce(ctx context.Context, nodeName, url string) error {
+	client, err := rpc.NewEthClient(url)
+	if err != nil {
+		return err
+	}
+	defer client.Provider().Close()
+
+	headersCh := make(chan *types.Header, b.conf.BufferSize)
+
+	sub, err := client.Eth.SubscribeNewHead(headersCh)
+	if err != nil {
+		return err
+	}
+	defer sub.Unsubscribe()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-sub.Err():
+			return err
+		case header := <-headersCh:
+			head, err := newHead(header)
+			if err != nil {
+				logrus.WithError(err).WithField("node", nodeName).Info("Head broadcaster received invalid header")
+				continue
+			}
+
+			b.update(nodeName, head)
+		}
+	}
+}
+
+// update updates the latest head of node, and broadcasts if canonical head changed.
+func (b *HeadBroadcaster) update(nodeName string, head *Head) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.sources[nodeName] = head
+
+	canonical, ok := b.reconcile()
+	if !ok {
+		return
+	}
+
+	if prev := b.canonical; prev != nil {
+		// never go backwards, e.g. the leading node disconnected
+		if canonical.Number < prev.Number || canonical.Hash == prev.Hash {
+			return
+		}
+
+		canonical.Reorg = canonical.Number == prev.Number ||
+			(canonical.Number == prev.Number+1 && canonical.ParentHash != prev.Hash)
+	}
+
+	b.canonical = canonical
+
+	metrics.Registry.Nodes.Head().Update(int64(canonical.Number))
+	if canonical.Reorg {
+		metrics.Registry.Nodes.HeadReorg().Mark(1)
+	}
+
+	for ch := range b.subs {
+		select {
+		case ch <- canonical:
+		default:
+			metrics.Registry.Nodes.HeadDropped().Mark(1)
+		}
+	}
+}
+
+// reconcile returns the highest head agreed by quorum nodes.
+func (b *HeadBroadcaster) reconcile() (*Head, bool) {
+	quorum := b.conf.Quorum
+	if quorum > len(b.sources) {
+		quorum = len(b.sources)
+	}
+
+	votes := make(map[common.Hash]int)
+	for _, head := range b.sources {
+		votes[head.Hash]++
+	}
+
+	var canonical *Head
+	for _, head := range b.sources {
+		if votes[head.Hash] < quorum || canonical != nil && head.Number < canonical.Number {
+			continue
+		}
+
+		// more votes for forks at the same height
+		if canonical == nil || head.Number > canonical.Number || votes[head.Hash] > votes[canonical.Hash] {
+			canonical = head
+		}
+	}
+
+	if canonical == nil {
+		return nil, false
+	}
+
+	// copy to mark reorg
+	result := *canonical
+
+	return &result, true
+}
@@ -0,0 +1,47 @@
+package node
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHeadBroadcasterReconcile(t *testing.T) {
+	b := newHeadBroadcaster(HeadBroadcasterConfig{Quorum: 2, BufferSize: 8})
+	heads, unsubscribe := b.Subscribe()
+	defer unsubscribe()
+
+	h1 := &Head{Number: 1, Hash: common.HexToHash("0x1")}
+	h2 := &Head{Number: 2, Hash: common.HexToHash("0x2"), ParentHash: h1.Hash}
+	h2Fork := &Head{Number: 2, Hash: common.HexToHash("0x2f"), ParentHash: h1.Hash}
+
+	// quorum capped by the number of nodes
+	b.update("n1", h1)
+	assert.Equal(t, uint64(1), (<-heads).Number)
+
+	// not agreed by quorum nodes
+	b.update("n2", h1)
+	b.update("n1", h2)
+	head, _ := b.Head()
+	assert.Equal(t, h1.Hash, head.Hash)
+
+	b.update("n2", h2)
+	head = <-heads
+	assert.Equal(t, h2.Hash, head.Hash)
+	assert.False(t, head.Reorg)
+
+	// reorg at the same height
+	b.update("n1", h2Fork)
+	b.update("n2", h2Fork)
+	head = <-heads
+	assert.Equal(t, h2Fork.Hash, head.Hash)
+	assert.True(t, head.Reorg)
+
+	// never go backwards
+	delete(b.sources, "n1")
+	b.update("n2", h1)
+	head, _ = b.Head()
+	assert.Equal(t, h2Fork.Hash, head.Hash)
+	assert.Equal(t, 0, len(heads))
+}
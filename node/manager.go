@@ -1,13 +1,17 @@
 package node
 
 import (
+	"context"
 	"strings"
 	"sync"
+	"sync/atomic"
 
 	"github.com/buraksezer/consistent"
 	"github.com/cespare/xxhash"
+	"github.com/pkg/errors"
 	"github.com/scroll-tech/rpc-gateway/util/metrics"
 	"github.com/scroll-tech/rpc-gateway/util/rpc"
+	"github.com/sirupsen/logrus"
 )
 
 // nodeFactory factory method to create node instance
@@ -27,62 +31,281 @@ type Manager struct {
 	nodeFactory     nodeFactory       // factory method to create node instance
 	nodeName2Epochs map[string]uint64 // node name => epoch
 	midEpoch        uint64            // middle epoch of managed full nodes.
+	maxEpoch        uint64            // max epoch of managed full nodes, a.k.a group head.
+	laggingNodes    map[string]bool   // nodes removed from hash ring due to lagging behind
+	drainedNodes    map[string]bool   // nodes removed from hash ring by administrator
+	spareNodes      map[string]bool   // warm spare node name => activated
+
+	maintainedNodes map[string]*Maintenance // nodes under maintenance, still monitored
+
+	quarantinedNodes map[string]*QuarantinedNode // nodes failed to construct
+
+	replicationFactor int // replication factor adjusted to rebalance, 0 means not adjusted
+
+	cancel context.CancelFunc // stops background reconciliation
+	wg     sync.WaitGroup     // waits for background reconciliation to stop
 }
 
 func NewManager(group Group, nf nodeFactory, urls []string) *Manager {
-	return NewManagerWithRepartition(group, nf, urls, &noopRepartitionResolver{})
+	return NewManagerWithRepartition(group, nf, urls, newRepartitionResolver())
 }
 
+// TryNewManager creates node manager, and returns error if any node failed to construct,
+// in which case nodes are quarantined and retried in the background.
+func TryNewManager(group Group, nf nodeFactory, urls []string) (*Manager, error) {
+	return newManager(group, nf, urls, newRepartitionResolver())
+}
+
+// newRepartitionResolver creates the repartition resolver from configurations.
+func newRepartitionResolver() RepartitionResolver {
+	conf := cfg.Router.Repartition
+	if !conf.Enabled {
+		return &noopRepartitionResolver{}
+	}
+
+	return NewSimpleRepartitionResolver(conf.TTL, conf.MaxEntries)
+}
+
+// NewManagerWithRepartition creates node manager with the specified repartition resolver. Note,
+// nodes failed to construct are quarantined and retried in the background.
 func NewManagerWithRepartition(group Group, nf nodeFactory, urls []string, resolver RepartitionResolver) *Manager {
+	manager, err := newManager(group, nf, urls, resolver)
+	if err != nil {
+		logrus.WithError(err).WithField("group", group).Warn("Failed to create some nodes")
+	}
+
+	return manager
+}
+
+func newManager(group Group, nf nodeFactory, urls []string, resolver RepartitionResolver) (*Manager, error) {
 	manager := Manager{
 		group:           group,
 		nodeFactory:     nf,
 		nodes:           make(map[string]Node),
 		resolver:        resolver,
 		nodeName2Epochs: make(map[string]uint64),
+		laggingNodes:    make(map[string]bool),
+		drainedNodes:    make(map[string]bool),
+		spareNodes:      make(map[string]bool),
+
+		maintainedNodes:  make(map[string]*Maintenance),
+		quarantinedNodes: make(map[string]*QuarantinedNode),
 	}
 
 	var members []consistent.Member
+	var errs []error
 
 	for _, url := range urls {
 		nodeName := rpc.Url2NodeName(url)
-		if _, ok := manager.nodes[nodeName]; !ok {
-			node, _ := nf(group, nodeName, url, &manager)
-			manager.nodes[nodeName] = node
+		if _, ok := manager.nodes[nodeName]; ok {
+			continue
+		}
+
+		node, err := manager.newNode(nodeName, url)
+		if err != nil {
+			errs = append(errs, manager.quarantine(nodeName, url, false, err))
+			continue
+		}
+
+		manager.nodes[nodeName] = node
+
+		if !isCanary(nodeName) {
 			members = append(members, node)
 		}
 	}
 
-	manager.hashRing = consistent.New(members, cfg.HashRingRaw())
+	manager.hashRing = consistent.New(members, manager.hashRingConfig())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	manager.cancel = cancel
+
+	if cfg.Monitor.Lag.Enabled {
+		manager.goReconcile(ctx, manager.reconcileLagging)
+	}
+
+	if cfg.HashRing.Skew.Enabled {
+		manager.goReconcile(ctx, manager.reconcileSkew)
+	}
+
+	if len(cfg.Maintenance.Windows) > 0 {
+		manager.goReconcile(ctx, manager.reconcileMaintenance)
+	}
+
+	if cfg.Spare.Interval > 0 {
+		manager.goReconcile(ctx, manager.reconcileSpares)
+	}
+
+	if cfg.Quarantine.RetryInterval > 0 {
+		manager.goReconcile(ctx, manager.reconcileQuarantine)
+	}
 
-	return &manager
+	return &manager, quarantineError(errs)
 }
 
-// Add adds fullnode to monitor
-func (m *Manager) Add(url string) {
+// goReconcile runs the reconciliation loop in a separate goroutine until manager closed.
+func (m *Manager) goReconcile(ctx context.Context, reconcile func(ctx context.Context)) {
+	m.wg.Add(1)
+
+	go func() {
+		defer m.wg.Done()
+		reconcile(ctx)
+	}()
+}
+
+// Close stops background reconciliation, and then tears down all managed nodes. Note, nodes
+// failed to tear down are still removed, and the last error is returned if any.
+func (m *Manager) Close() error {
+	m.cancel()
+	m.wg.Wait()
+
 	m.mu.Lock()
-	defer m.mu.Unlock()
+	var nodes []Node
+	for nodeName := range m.nodes {
+		node, _ := m.remove(nodeName)
+		nodes = append(nodes, node)
+	}
+	m.mu.Unlock()
 
-	nodeName := rpc.Url2NodeName(url)
-	if _, ok := m.nodes[nodeName]; !ok {
-		node, _ := m.nodeFactory(m.group, nodeName, url, m)
-		m.nodes[nodeName] = node
-		m.hashRing.Add(node)
+	// tear down without lock held, see Remove for details
+	var lastErr error
+	for _, node := range nodes {
+		if _, err := closeNode(node); err != nil {
+			lastErr = err
+		}
 	}
+
+	return lastErr
 }
 
-// Remove removes monitored fullnode
-func (m *Manager) Remove(url string) {
+// Add adds fullnode to monitor. If failed to construct, node is quarantined and retried in
+// the background.
+func (m *Manager) Add(url string) error {
+	nodeName := rpc.Url2NodeName(url)
+	if m.Get(url) != nil {
+		return nil
+	}
+
+	// create node without lock held, which may dial the full node
+	node, err := m.newNode(nodeName, url)
+
 	m.mu.Lock()
-	defer m.mu.Unlock()
+	admitted, err := m.admit(nodeName, url, false, node, err)
+	m.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	// added by others in the meantime
+	if !admitted {
+		discard([]Node{node})
+	}
 
+	return nil
+}
+
+// Remove removes monitored fullnode, and returns the teardown report. Note, node is removed
+// even if failed to tear down, in which case both report and error returned.
+func (m *Manager) Remove(url string) (*TeardownReport, error) {
 	nodeName := rpc.Url2NodeName(url)
-	if node, ok := m.nodes[nodeName]; ok {
-		node.Close()
-		delete(m.nodes, nodeName)
-		delete(m.nodeName2Epochs, nodeName)
-		m.hashRing.Remove(nodeName)
+
+	m.mu.Lock()
+	node, ok := m.remove(nodeName)
+	unquarantined := m.unquarantine(nodeName)
+	m.mu.Unlock()
+
+	if unquarantined && !ok {
+		return &TeardownReport{Node: nodeName}, nil
+	}
+
+	if !ok {
+		return nil, errNodeNotFound
+	}
+
+	// tear down without lock held, since health monitor may acquire lock to report node
+	// status, and teardown waits for health monitor to stop.
+	return closeNode(node)
+}
+
+// closeNode closes the node, and wraps error with node name if any.
+func closeNode(node Node) (*TeardownReport, error) {
+	report, err := node.Close()
+	if err != nil {
+		return report, errors.WithMessagef(err, "failed to tear down node %v", node.Name())
 	}
+
+	return report, nil
+}
+
+// remove removes the specified node from management, which should be called with lock held.
+// Note, the removed node is not closed yet.
+func (m *Manager) remove(nodeName string) (Node, bool) {
+	node, ok := m.nodes[nodeName]
+	if !ok {
+		return nil, false
+	}
+
+	delete(m.nodes, nodeName)
+	delete(m.nodeName2Epochs, nodeName)
+	delete(m.laggingNodes, nodeName)
+	delete(m.drainedNodes, nodeName)
+	delete(m.maintainedNodes, nodeName)
+	delete(m.spareNodes, nodeName)
+	m.hashRing.Remove(nodeName)
+
+	// invalidate sticky routes to the removed node
+	if invalidator, ok := m.resolver.(repartitionInvalidator); ok {
+		invalidator.Invalidate(nodeName)
+	}
+
+	return node, true
+}
+
+// Sync synchronizes monitored fullnodes with the specified URLs, by which new
+// fullnodes will be added and stale ones will be removed. Note, warm spare nodes
+// are synchronized separately.
+func (m *Manager) Sync(urls []string) (added, removed []string) {
+	nodeName2Urls := make(map[string]string)
+	for _, url := range urls {
+		nodeName2Urls[rpc.Url2NodeName(url)] = url
+	}
+
+	spares := m.Spares()
+
+	for _, n := range m.List() {
+		if _, ok := spares[n.Url()]; ok {
+			continue
+		}
+
+		if _, ok := nodeName2Urls[n.Name()]; !ok {
+			if _, err := m.Remove(n.Url()); err != nil {
+				logrus.WithError(err).WithField("url", n.Url()).Warn("Failed to remove node")
+			}
+
+			removed = append(removed, n.Url())
+		}
+	}
+
+	// drop quarantined nodes no longer configured
+	m.mu.Lock()
+	for nodeName, q := range m.quarantinedNodes {
+		if _, ok := nodeName2Urls[nodeName]; !ok && !q.Spare {
+			m.unquarantine(nodeName)
+		}
+	}
+	m.mu.Unlock()
+
+	for _, url := range nodeName2Urls {
+		if m.Get(url) == nil {
+			if err := m.Add(url); err != nil {
+				continue
+			}
+
+			added = append(added, url)
+		}
+	}
+
+	return added, removed
 }
 
 // Get gets monitored fullnode from url
@@ -108,6 +331,19 @@ func (m *Manager) List() []Node {
 	return nodes
 }
 
+// ListHealthy lists all healthy fullnodes, which are those in the hash ring.
+func (m *Manager) ListHealthy() []Node {
+	var nodes []Node
+
+	for _, member := range m.hashRing.GetMembers() {
+		if n, ok := member.(Node); ok {
+			nodes = append(nodes, n)
+		}
+	}
+
+	return nodes
+}
+
 // String implements stringer interface
 func (m *Manager) String() string {
 	m.mu.RLock()
@@ -129,8 +365,12 @@ func (m *Manager) Distribute(key []byte) Node {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
+	if node, ok := m.distributeCanary(k); ok {
+		return node
+	}
+
 	// Use repartition resolver to distribute if configured.
-	if name, ok := m.resolver.Get(k); ok {
+	if name, ok := m.resolver.Get(k); ok && m.isRoutable(name) {
 		return m.nodes[name]
 	}
 
@@ -145,9 +385,81 @@ func (m *Manager) Distribute(key []byte) Node {
 	return node
 }
 
+// DistributeByHeight distributes a full node by specified key, whose synced height covers
+// the specified height. If height is nil, which stands for the latest height, full nodes
+// lagging behind the group head too much will be avoided. Note, it falls back to distribute
+// by key only if none full node is qualified.
+func (m *Manager) DistributeByHeight(key []byte, height *uint64) Node {
+	if node, ok := m.distributeByHeight(key, height); ok {
+		return node
+	}
+
+	return m.Distribute(key)
+}
+
+func (m *Manager) distributeByHeight(key []byte, height *uint64) (Node, bool) {
+	k := xxhash.Sum64(key)
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if node, ok := m.distributeCanary(k); ok && m.coversHeight(node.Name(), height) {
+		return node, true
+	}
+
+	// Use repartition resolver to distribute if qualified.
+	if name, ok := m.resolver.Get(k); ok && m.isRoutable(name) && m.coversHeight(name, height) {
+		return m.nodes[name], true
+	}
+
+	// Otherwise, walk through the hash ring from the key located node.
+	count := len(m.hashRing.GetMembers())
+	if count == 0 {
+		return nil, false
+	}
+
+	members, err := m.hashRing.GetClosestN(key, count)
+	if err != nil {
+		return nil, false
+	}
+
+	for _, member := range members {
+		if node := member.(Node); m.coversHeight(node.Name(), height) {
+			return node, true
+		}
+	}
+
+	return nil, false
+}
+
+// coversHeight checks if the synced height of the specified node covers the height,
+// which should be called with lock held.
+func (m *Manager) coversHeight(nodeName string, height *uint64) bool {
+	epoch, ok := m.nodeName2Epochs[nodeName]
+	if !ok { // no epoch reported yet
+		return false
+	}
+
+	if height == nil {
+		return epoch+cfg.Router.MaxLatestLag >= atomic.LoadUint64(&m.maxEpoch)
+	}
+
+	return epoch >= *height
+}
+
 // Route implements the Router interface.
 func (m *Manager) Route(key []byte) string {
-	if n := m.Distribute(key); n != nil {
+	return m.route(m.Distribute(key))
+}
+
+// RouteByHeight routes the specified key to some node whose synced height covers
+// the specified height.
+func (m *Manager) RouteByHeight(key []byte, height *uint64) string {
+	return m.route(m.DistributeByHeight(key, height))
+}
+
+func (m *Manager) route(n Node) string {
+	if n != nil {
 		// metrics overall route QPS
 		metrics.Registry.Nodes.Routes(m.group.Space(), m.group.String(), "overall").Mark(1)
 		// metrics per node route QPS
@@ -0,0 +1,203 @@
+package node
+
+import (
+	"sort"
+
+	"github.com/scroll-tech/rpc-gateway/util/rpc"
+	"github.com/sirupsen/logrus"
+)
+
+// Administrative operations of node manager.
+
+// Drain removes the specified node from hash ring so that no more requests will be routed
+// to it, but still keeps the node monitored. It returns false if node not found.
+func (m *Manager) Drain(url string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	nodeName := rpc.Url2NodeName(url)
+	if _, ok := m.nodes[nodeName]; !ok {
+		return false
+	}
+
+	m.drainedNodes[nodeName] = true
+	m.hashRing.Remove(nodeName)
+
+	logrus.WithFields(logrus.Fields{"group": m.group, "node": nodeName}).Info("Node drained")
+
+	return true
+}
+
+// Undrain adds the drained node into hash ring again if healthy. It returns false if node
+// not found or not drained.
+func (m *Manager) Undrain(url string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	nodeName := rpc.Url2NodeName(url)
+	node, ok := m.nodes[nodeName]
+	if !ok || !m.drainedNodes[nodeName] {
+		return false
+	}
+
+	delete(m.drainedNodes, nodeName)
+
+	// unhealthy or lagging node will be added into hash ring once recovered, and spare
+	// node will be added once activated
+	if status := node.Status(); !status.unhealthy && !m.isExcluded(nodeName) {
+		m.hashRing.Add(node)
+	}
+
+	logrus.WithFields(logrus.Fields{"group": m.group, "node": nodeName}).Info("Node undrained")
+
+	return true
+}
+
+// IsDrained checks if the specified node is drained.
+func (m *Manager) IsDrained(url string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.drainedNodes[rpc.Url2NodeName(url)]
+}
+
+// RoutingTable returns the number of hash ring partitions owned by each node.
+func (m *Manager) RoutingTable() map[string]int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	table := make(map[string]int)
+	partitions := m.hashRingConfig().PartitionCount
+
+	for i := 0; i < partitions; i++ {
+		member := m.hashRing.GetPartitionOwner(i)
+		if member == nil { // empty hash ring
+			break
+		}
+
+		if node, ok := m.nodes[member.String()]; ok {
+			table[node.Url()]++
+		}
+	}
+
+	return table
+}
+
+// NodePartitions is the key space ownership of node in hash ring.
+type NodePartitions struct {
+	URL        string `json:"url"`
+	Partitions int    `json:"partitions"`
+	// percentage of key space owned
+	Share float64 `json:"share"`
+	// percentage of deviation from the mean partitions per node
+	Deviation float64 `json:"deviation"`
+}
+
+// RingPartitions is the key space ownership of all nodes in hash ring.
+type RingPartitions struct {
+	PartitionCount int `json:"partitionCount"`
+	Members        int `json:"members"`
+	// max number of partitions allowed per node, namely the bounded load
+	AverageLoad float64          `json:"averageLoad"`
+	Nodes       []NodePartitions `json:"nodes"`
+}
+
+// Partitions returns the key space ownership of nodes in hash ring, so as to verify balance
+// after nodes added or removed.
+func (m *Manager) Partitions() *RingPartitions {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.partitions()
+}
+
+// partitions returns the key space ownership of nodes in hash ring, which should be called
+// with lock held.
+func (m *Manager) partitions() *RingPartitions {
+	members := m.hashRing.GetMembers()
+
+	result := RingPartitions{
+		PartitionCount: m.hashRingConfig().PartitionCount,
+		Members:        len(members),
+		Nodes:          []NodePartitions{},
+	}
+
+	if len(members) == 0 {
+		return &result
+	}
+
+	result.AverageLoad = m.hashRing.AverageLoad()
+
+	counts := make(map[string]int)
+	for i := 0; i < result.PartitionCount; i++ {
+		if member := m.hashRing.GetPartitionOwner(i); member != nil {
+			counts[member.String()]++
+		}
+	}
+
+	mean := float64(result.PartitionCount) / float64(len(members))
+
+	for _, member := range members {
+		url := member.String()
+		if node, ok := m.nodes[url]; ok {
+			url = node.Url()
+		}
+
+		n := counts[member.String()]
+
+		result.Nodes = append(result.Nodes, NodePartitions{
+			URL:        url,
+			Partitions: n,
+			Share:      100 * float64(n) / float64(result.PartitionCount),
+			Deviation:  100 * (float64(n) - mean) / mean,
+		})
+	}
+
+	sort.Slice(result.Nodes, func(i, j int) bool {
+		return result.Nodes[i].URL < result.Nodes[j].URL
+	})
+
+	return &result
+}
+
+// cacheFlusher is implemented by repartition resolver that supports to flush cache.
+type cacheFlusher interface {
+	Flush()
+}
+
+// FlushCache flushes the cached routes of repartition resolver if supported.
+func (m *Manager) FlushCache() bool {
+	flusher, ok := m.resolver.(cacheFlusher)
+	if ok {
+		flusher.Flush()
+	}
+
+	return ok
+}
+
+// isRoutable checks if the specified node is still managed and not excluded from hash ring,
+// e.g. stale routes from shared repartition resolver, which should be called with lock held.
+func (m *Manager) isRoutable(nodeName string) bool {
+	_, ok := m.nodes[nodeName]
+	return ok && !m.isExcluded(nodeName)
+}
+
+// isExcluded checks if the specified node is excluded from hash ring on purpose, e.g. canary,
+// inactive spare or suspended, which should be called with lock held.
+func (m *Manager) isExcluded(nodeName string) bool {
+	if activated, ok := m.spareNodes[nodeName]; ok && !activated {
+		return true
+	}
+
+	return isCanary(nodeName) || m.isSuspended(nodeName)
+}
+
+// isSuspended checks if the specified node is suspended from routing, e.g. drained, under
+// maintenance or lagging behind too much, which should be called with lock held.
+func (m *Manager) isSuspended(nodeName string) bool {
+	if _, ok := m.maintainedNodes[nodeName]; ok {
+		return true
+	}
+
+	return m.drainedNodes[nodeName] || m.laggingNodes[nodeName]
+}
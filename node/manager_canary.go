@@ -0,0 +1,92 @@
+package node
+
+import (
+	"github.com/pkg/errors"
+	"github.com/scroll-tech/rpc-gateway/util/rpc"
+)
+
+// canaryBuckets is the number of buckets to split traffic for canary nodes, so the precision
+// of canary percentage is 0.01%.
+const canaryBuckets = 10000
+
+// CanaryConfig is the canary node that receives only a percentage of traffic regardless of
+// hash ring share, e.g. node of new version to validate gradually.
+type CanaryConfig struct {
+	URL string
+	// percentage of traffic routed to canary node, e.g. 5 means 5%
+	Percentage float64
+
+	nodeName string
+}
+
+func (c *CanaryConfig) init() error {
+	if c.Percentage <= 0 || c.Percentage > 100 {
+		return errors.Errorf("percentage should be in range (0, 100], got %v", c.Percentage)
+	}
+
+	c.nodeName = rpc.Url2NodeName(c.URL)
+
+	return nil
+}
+
+// canaryNodes is the set of canary node names.
+var canaryNodes = make(map[string]bool)
+
+// initCanaryNodes validates canary configurations, and registers canary nodes so as to
+// collect error rates separately.
+func initCanaryNodes(confs []CanaryConfig) error {
+	var urls []string
+	var total float64
+
+	for i := range confs {
+		if err := confs[i].init(); err != nil {
+			return errors.WithMessagef(err, "invalid canary node %v", confs[i].URL)
+		}
+
+		canaryNodes[confs[i].nodeName] = true
+		urls = append(urls, confs[i].URL)
+		total += confs[i].Percentage
+	}
+
+	// canary nodes beyond 100% would never receive their share
+	if total > 100 {
+		return errors.Errorf("total percentage of canary nodes should be at most 100, got %v", total)
+	}
+
+	rpc.SetCanaryNodes(urls)
+
+	return nil
+}
+
+// isCanary checks if the specified node is canary, which is excluded from hash ring.
+func isCanary(nodeName string) bool {
+	return canaryNodes[nodeName]
+}
+
+// distributeCanary distributes the hashed key to canary node if falls into the traffic share
+// of any canary node, which should be called with lock held. Note, it returns false if the
+// canary node is not routable, in which case key is distributed by hash ring.
+func (m *Manager) distributeCanary(k uint64) (Node, bool) {
+	// use high bits to be independent of hash ring
+	bucket := float64((k >> 32) % canaryBuckets)
+
+	var upper float64
+	for _, c := range cfg.Canary {
+		node, ok := m.nodes[c.nodeName]
+		if !ok {
+			continue
+		}
+
+		if upper += c.Percentage * canaryBuckets / 100; bucket >= upper {
+			continue
+		}
+
+		if node.Status().unhealthy || m.isSuspended(c.nodeName) {
+			return nil, false
+		}
+
+		return node, true
+	}
+
+	return nil, false
+}
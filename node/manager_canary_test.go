@@ -0,0 +1,69 @@
+package node
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/cespare/xxhash"
+	"github.com/scroll-tech/rpc-gateway/util/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanaryConfig(t *testing.T) {
+	conf := CanaryConfig{URL: "http://127.0.0.1:8545", Percentage: 5}
+	assert.Nil(t, conf.init())
+	assert.Equal(t, "127.0.0.1:8545", conf.nodeName)
+
+	for _, percentage := range []float64{0, -1, 100.1} {
+		conf := CanaryConfig{URL: "http://127.0.0.1:8545", Percentage: percentage}
+		assert.NotNil(t, conf.init())
+	}
+}
+
+func TestInitCanaryNodesOverflow(t *testing.T) {
+	defer func(nodes map[string]bool) { canaryNodes = nodes }(canaryNodes)
+	canaryNodes = make(map[string]bool)
+
+	err := initCanaryNodes([]CanaryConfig{
+		{URL: "http://127.0.0.1:8545", Percentage: 60},
+		{URL: "http://127.0.0.2:8545", Percentage: 50},
+	})
+	assert.NotNil(t, err)
+}
+
+func TestDistributeCanary(t *testing.T) {
+	defer func(confs []CanaryConfig, nodes map[string]bool) {
+		cfg.Canary, canaryNodes = confs, nodes
+	}(cfg.Canary, canaryNodes)
+
+	canaryNodes = make(map[string]bool)
+	confs := []CanaryConfig{
+		{URL: "http://127.0.0.1:8545", Percentage: 5},
+		{URL: "http://127.0.0.2:8545", Percentage: 20},
+	}
+	assert.Nil(t, initCanaryNodes(confs))
+	cfg.Canary = confs
+
+	nf := MockNodeFactory(mock.NewChain(mock.ChainConfig{ChainId: 1337, Height: 100}))
+	m := NewManager(GroupEthHttp, nf, []string{
+		"http://127.0.0.1:8545", "http://127.0.0.2:8545", "http://127.0.0.3:8545",
+	})
+	defer m.Close()
+
+	const keys = 100000
+	routed := make(map[string]int)
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for i := 0; i < keys; i++ {
+		k := xxhash.Sum64([]byte(fmt.Sprintf("key-%v", i)))
+		if node, ok := m.distributeCanary(k); ok {
+			routed[node.Name()]++
+		}
+	}
+
+	assert.InDelta(t, 0.05, float64(routed["127.0.0.1:8545"])/keys, 0.005)
+	assert.InDelta(t, 0.20, float64(routed["127.0.0.2:8545"])/keys, 0.005)
+	assert.Zero(t, routed["127.0.0.3:8545"])
+}
@@ -0,0 +1,82 @@
+package node
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// reconcileLagging periodically compares the head height of each managed node with
+// the highest head among healthy managed nodes, and removes nodes lagging too much from
+// the hash ring, except the last routable one. Removed nodes will be re-added once caught
+// up. Note, different thresholds are used to remove and re-add nodes to avoid flapping.
+func (m *Manager) reconcileLagging(ctx context.Context) {
+	ticker := time.NewTicker(cfg.Monitor.Lag.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.reconcileLaggingOnce()
+		}
+	}
+}
+
+func (m *Manager) reconcileLaggingOnce() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	name2Epochs := make(map[string]uint64)
+	var maxEpoch uint64
+
+	for name, n := range m.nodes {
+		status := n.Status()
+		if status.unhealthy { // handled by health monitor
+			continue
+		}
+
+		name2Epochs[name] = status.latestStateEpoch
+		if status.latestStateEpoch > maxEpoch {
+			maxEpoch = status.latestStateEpoch
+		}
+	}
+
+	// nodes in hash ring, which are routable
+	routable := make(map[string]bool)
+	for _, member := range m.hashRing.GetMembers() {
+		routable[member.String()] = true
+	}
+
+	for name, epoch := range name2Epochs {
+		lag := maxEpoch - epoch
+		logger := logrus.WithFields(logrus.Fields{
+			"group": m.group, "node": name, "lag": lag, "maxEpoch": maxEpoch,
+		})
+
+		if m.laggingNodes[name] {
+			if lag <= cfg.Monitor.Lag.RejoinThreshold {
+				delete(m.laggingNodes, name)
+				if !m.isExcluded(name) {
+					m.hashRing.Add(m.nodes[name])
+					routable[name] = true
+				}
+
+				logger.Warn("Lagging node caught up and added into hash ring again")
+			}
+		} else if lag > cfg.Monitor.Lag.RemoveThreshold {
+			// lagging node still serves better than none node available
+			if routable[name] && len(routable) == 1 {
+				logger.Error("Node lagging behind too much but kept as the last routable node")
+				continue
+			}
+
+			m.laggingNodes[name] = true
+			m.hashRing.Remove(name)
+			delete(routable, name)
+			logger.Error("Node lagging behind too much and removed from hash ring")
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package node
+
+import (
+	"testing"
+
+	"github.com/scroll-tech/rpc-gateway/util/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReconcileLagging(t *testing.T) {
+	nf := MockNodeFactory(mock.NewChain(mock.ChainConfig{ChainId: 1337, Height: 100}))
+	m := NewManager(GroupEthHttp, nf, []string{
+		"http://127.0.0.1:8545", "http://127.0.0.2:8545", "http://127.0.0.3:8545",
+	})
+	defer m.Close()
+
+	setStatus := func(nodeName string, epoch uint64, unhealthy bool) {
+		n := m.nodes[nodeName].(*MockNode)
+		status := n.Status()
+		status.latestStateEpoch = epoch
+		status.unhealthy = unhealthy
+		n.atomicStatus.Store(status)
+	}
+
+	routable := func() []string {
+		var names []string
+		for _, n := range m.ListHealthy() {
+			names = append(names, n.Name())
+		}
+
+		return names
+	}
+
+	lag := cfg.Monitor.Lag.RemoveThreshold + 1
+
+	// unhealthy node is not the reference even with the highest head
+	setStatus("127.0.0.1:8545", 1000, false)
+	setStatus("127.0.0.2:8545", 1000-lag, false)
+	setStatus("127.0.0.3:8545", 1000+lag, true)
+	m.reconcileLaggingOnce()
+	assert.ElementsMatch(t, []string{"127.0.0.1:8545", "127.0.0.3:8545"}, routable())
+
+	// caught up and rejoined
+	setStatus("127.0.0.2:8545", 1000-cfg.Monitor.Lag.RejoinThreshold, false)
+	m.reconcileLaggingOnce()
+	assert.ElementsMatch(t, []string{"127.0.0.1:8545", "127.0.0.2:8545", "127.0.0.3:8545"}, routable())
+
+	// last routable node is never evicted, even though all nodes in hash ring lagging
+	m.hashRing.Remove("127.0.0.3:8545")
+	setStatus("127.0.0.1:8545", 1000-lag, false)
+	setStatus("127.0.0.2:8545", 1000-lag, false)
+	setStatus("127.0.0.3:8545", 1000, false)
+	m.reconcileLaggingOnce()
+	assert.Len(t, routable(), 1)
+}
@@ -0,0 +1,195 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/scroll-tech/rpc-gateway/util/cron"
+	"github.com/scroll-tech/rpc-gateway/util/rpc"
+	"github.com/sirupsen/logrus"
+)
+
+// Maintenance is the maintenance state of node set by administrator, e.g. for upgrades.
+type Maintenance struct {
+	Reason string    `json:"reason,omitempty"`
+	Since  time.Time `json:"since"`
+	// whether under maintenance due to scheduled window, which ends automatically
+	Scheduled bool `json:"scheduled,omitempty"`
+}
+
+// MaintenanceWindow is the scheduled maintenance window of node, e.g. routine nightly restart.
+type MaintenanceWindow struct {
+	URL string
+	// cron expression when window starts, e.g. `0 3 * * *`
+	Schedule string
+	Duration time.Duration
+	// IANA time zone of schedule, empty means local time zone
+	Timezone string
+
+	schedule *cron.Schedule
+	location *time.Location
+}
+
+// init parses the schedule and time zone of maintenance window.
+func (w *MaintenanceWindow) init() (err error) {
+	if w.Duration <= 0 {
+		return errors.New("duration should be positive")
+	}
+
+	if w.schedule, err = cron.Parse(w.Schedule); err != nil {
+		return errors.WithMessage(err, "invalid schedule")
+	}
+
+	w.location = time.Local
+	if len(w.Timezone) > 0 {
+		if w.location, err = time.LoadLocation(w.Timezone); err != nil {
+			return errors.WithMessage(err, "invalid timezone")
+		}
+	}
+
+	return nil
+}
+
+// within checks if the specified time is within the maintenance window.
+func (w *MaintenanceWindow) within(t time.Time) bool {
+	return w.schedule.Within(t.In(w.location), w.Duration)
+}
+
+// SetMaintenance puts the specified node under maintenance, so that it is excluded from
+// routing but still registered and monitored. It returns false if node not found.
+func (m *Manager) SetMaintenance(url, reason string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	nodeName := rpc.Url2NodeName(url)
+	if _, ok := m.nodes[nodeName]; !ok {
+		return false
+	}
+
+	state, ok := m.maintainedNodes[nodeName]
+	if !ok {
+		state = &Maintenance{Since: time.Now()}
+		m.maintainedNodes[nodeName] = state
+	}
+
+	// taken over by administrator if under scheduled maintenance
+	state.Reason, state.Scheduled = reason, false
+
+	m.hashRing.Remove(nodeName)
+
+	logrus.WithFields(logrus.Fields{
+		"group": m.group, "node": nodeName, "reason": reason,
+	}).Info("Node under maintenance")
+
+	return true
+}
+
+// ClearMaintenance brings the node under maintenance back to hash ring if healthy. It returns
+// false if node not found or not under maintenance.
+func (m *Manager) ClearMaintenance(url string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	nodeName := rpc.Url2NodeName(url)
+	node, ok := m.nodes[nodeName]
+	if !ok {
+		return false
+	}
+
+	if _, ok := m.maintainedNodes[nodeName]; !ok {
+		return false
+	}
+
+	delete(m.maintainedNodes, nodeName)
+
+	if status := node.Status(); !status.unhealthy && !m.isExcluded(nodeName) {
+		m.hashRing.Add(node)
+	}
+
+	logrus.WithFields(logrus.Fields{"group": m.group, "node": nodeName}).Info("Node maintenance finished")
+
+	return true
+}
+
+// Maintenance returns the maintenance states of nodes under maintenance, keyed by node URL.
+func (m *Manager) Maintenance() map[string]Maintenance {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	result := make(map[string]Maintenance, len(m.maintainedNodes))
+
+	for nodeName, state := range m.maintainedNodes {
+		if n, ok := m.nodes[nodeName]; ok {
+			result[n.Url()] = *state
+		}
+	}
+
+	return result
+}
+
+// reconcileMaintenance periodically puts nodes under maintenance during scheduled windows,
+// and brings them back to routing afterwards.
+func (m *Manager) reconcileMaintenance(ctx context.Context) {
+	ticker := time.NewTicker(cfg.Maintenance.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.reconcileMaintenanceOnce(time.Now())
+		}
+	}
+}
+
+func (m *Manager) reconcileMaintenanceOnce(now time.Time) {
+	// node name => matched window, nil means not within any window
+	windows := make(map[string]*MaintenanceWindow)
+
+	for i := range cfg.Maintenance.Windows {
+		w := &cfg.Maintenance.Windows[i]
+		nodeName := rpc.Url2NodeName(w.URL)
+
+		if windows[nodeName] == nil && w.within(now) {
+			windows[nodeName] = w
+		} else if _, ok := windows[nodeName]; !ok {
+			windows[nodeName] = nil
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for nodeName, w := range windows {
+		node, ok := m.nodes[nodeName]
+		if !ok {
+			continue
+		}
+
+		state, maintained := m.maintainedNodes[nodeName]
+		logger := logrus.WithFields(logrus.Fields{"group": m.group, "node": nodeName})
+
+		switch {
+		case w != nil && !maintained:
+			m.maintainedNodes[nodeName] = &Maintenance{
+				Reason:    fmt.Sprintf("scheduled window `%v` for %v", w.Schedule, w.Duration),
+				Since:     now,
+				Scheduled: true,
+			}
+			m.hashRing.Remove(nodeName)
+
+			logger.Info("Node under scheduled maintenance")
+		case w == nil && maintained && state.Scheduled:
+			delete(m.maintainedNodes, nodeName)
+
+			if !node.Status().unhealthy && !m.isExcluded(nodeName) {
+				m.hashRing.Add(node)
+			}
+
+			logger.Info("Node scheduled maintenance finished")
+		}
+	}
+}
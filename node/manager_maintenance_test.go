@@ -0,0 +1,78 @@
+package node
+
+import (
+	"testing"
+
+	"github.com/scroll-tech/rpc-gateway/util/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManagerMaintenance(t *testing.T) {
+	nf := MockNodeFactory(mock.NewChain(mock.ChainConfig{ChainId: 1337, Height: 100}))
+	m := NewManager(GroupEthHttp, nf, []string{"http://127.0.0.1:8545", "http://127.0.0.2:8545"})
+	defer m.Close()
+
+	routable := func() []string {
+		var names []string
+		for _, n := range m.ListHealthy() {
+			names = append(names, n.Name())
+		}
+
+		return names
+	}
+
+	tests := []struct {
+		op         string // set, clear or healthy
+		url        string
+		ok         bool
+		routable   []string
+		maintained []string
+	}{
+		{"set", "http://127.0.0.1:8545", true, []string{"127.0.0.2:8545"}, []string{"http://127.0.0.1:8545"}},
+		// still excluded from routing once reported healthy
+		{"healthy", "http://127.0.0.1:8545", true, []string{"127.0.0.2:8545"}, []string{"http://127.0.0.1:8545"}},
+		// reason updated
+		{"set", "http://127.0.0.1:8545", true, []string{"127.0.0.2:8545"}, []string{"http://127.0.0.1:8545"}},
+		// node not found
+		{"set", "http://127.0.0.3:8545", false, []string{"127.0.0.2:8545"}, []string{"http://127.0.0.1:8545"}},
+		{"clear", "http://127.0.0.3:8545", false, []string{"127.0.0.2:8545"}, []string{"http://127.0.0.1:8545"}},
+		// not under maintenance
+		{"clear", "http://127.0.0.2:8545", false, []string{"127.0.0.2:8545"}, []string{"http://127.0.0.1:8545"}},
+		{"clear", "http://127.0.0.1:8545", true, []string{"127.0.0.1:8545", "127.0.0.2:8545"}, nil},
+		{"healthy", "http://127.0.0.1:8545", true, []string{"127.0.0.1:8545", "127.0.0.2:8545"}, nil},
+	}
+
+	for i, tt := range tests {
+		switch tt.op {
+		case "set":
+			assert.Equal(t, tt.ok, m.SetMaintenance(tt.url, "upgrade"), i)
+		case "clear":
+			assert.Equal(t, tt.ok, m.ClearMaintenance(tt.url), i)
+		case "healthy":
+			m.ReportHealthy(m.Get(tt.url).Name())
+		}
+
+		assert.ElementsMatch(t, tt.routable, routable(), i)
+
+		var maintained []string
+		for url, state := range m.Maintenance() {
+			assert.Equal(t, "upgrade", state.Reason)
+			maintained = append(maintained, url)
+		}
+
+		assert.ElementsMatch(t, tt.maintained, maintained, i)
+
+		// still registered and monitored
+		assert.Equal(t, 2, len(m.List()), i)
+	}
+
+	// maintenance state restored from snapshot
+	m.SetMaintenance("http://127.0.0.2:8545", "upgrade")
+	snapshot := m.SnapshotRing()
+	assert.Contains(t, snapshot.Maintenance, "http://127.0.0.2:8545")
+
+	m.ClearMaintenance("http://127.0.0.2:8545")
+	assert.NoError(t, m.RestoreRing(snapshot))
+	assert.Contains(t, m.Maintenance(), "http://127.0.0.2:8545")
+	assert.ElementsMatch(t, []string{"127.0.0.1:8545"}, routable())
+}
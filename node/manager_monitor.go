@@ -23,6 +23,7 @@ func (m *Manager) ReportEpoch(nodeName string, epoch uint64) {
 	m.nodeName2Epochs[nodeName] = epoch
 	if len(m.nodeName2Epochs) == 1 {
 		atomic.StoreUint64(&m.midEpoch, epoch)
+		atomic.StoreUint64(&m.maxEpoch, epoch)
 		return
 	}
 
@@ -33,6 +34,7 @@ func (m *Manager) ReportEpoch(nodeName string, epoch uint64) {
 
 	sort.Ints(epochs)
 
+	atomic.StoreUint64(&m.maxEpoch, uint64(epochs[len(epochs)-1]))
 	atomic.StoreUint64(&m.midEpoch, uint64(epochs[len(epochs)/2]))
 }
 
@@ -58,6 +60,16 @@ func (m *Manager) ReportHealthy(nodeName string) {
 	// alert
 	logrus.WithField("node", nodeName).Warn("Node became healthy now")
 
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	// drained, maintained or lagging node should not be added into hash ring
+	if m.isExcluded(nodeName) {
+		return
+	}
+
 	// add recovered node into hash ring again
-	m.hashRing.Add(m.nodes[nodeName])
+	if n, ok := m.nodes[nodeName]; ok {
+		m.hashRing.Add(n)
+	}
 }
@@ -0,0 +1,57 @@
+package node
+
+import (
+	"testing"
+
+	"github.com/scroll-tech/rpc-gateway/util/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManagerPartitions(t *testing.T) {
+	nf := MockNodeFactory(mock.NewChain(mock.ChainConfig{ChainId: 1337, Height: 100}))
+
+	tests := []struct {
+		urls []string
+	}{
+		{nil},
+		{[]string{"http://127.0.0.1:8545"}},
+		{[]string{"http://127.0.0.3:8545", "http://127.0.0.1:8545", "http://127.0.0.2:8545"}},
+	}
+
+	for _, tt := range tests {
+		m := NewManager(GroupEthHttp, nf, tt.urls)
+		partitions := m.Partitions()
+		m.Close()
+
+		assert.Equal(t, cfg.HashRingRaw(GroupEthHttp).PartitionCount, partitions.PartitionCount)
+		assert.Equal(t, len(tt.urls), partitions.Members)
+		assert.Equal(t, len(tt.urls), len(partitions.Nodes))
+
+		if len(tt.urls) == 0 {
+			assert.Zero(t, partitions.AverageLoad)
+			continue
+		}
+
+		var total int
+		var share float64
+		for i, n := range partitions.Nodes {
+			// sorted by node URL
+			if i > 0 {
+				assert.True(t, partitions.Nodes[i-1].URL < n.URL)
+			}
+
+			assert.Contains(t, tt.urls, n.URL)
+			assert.LessOrEqual(t, float64(n.Partitions), partitions.AverageLoad)
+
+			total += n.Partitions
+			share += n.Share
+		}
+
+		// all partitions owned
+		assert.Equal(t, partitions.PartitionCount, total)
+		assert.InDelta(t, 100, share, 0.001)
+	}
+
+	// unknown group
+	assert.Nil(t, (&api{managers: map[Group]*Manager{}}).Partitions(GroupEthWs))
+}
@@ -0,0 +1,188 @@
+package node
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/scroll-tech/rpc-gateway/util/metrics"
+	"github.com/sirupsen/logrus"
+)
+
+// Nodes failed to construct, e.g. invalid URL or unreachable websocket endpoint, are put into
+// quarantine rather than silently discarded, and retried in the background until constructed
+// or removed from configurations.
+
+// QuarantinedNode is a node failed to construct.
+type QuarantinedNode struct {
+	Url       string    `json:"url"`
+	Spare     bool      `json:"spare"`
+	Error     string    `json:"error"`
+	Attempts  int       `json:"attempts"`
+	Since     time.Time `json:"since"`
+	LastRetry time.Time `json:"lastRetry"`
+}
+
+// newNode creates node by node factory, which should be called without lock held, since node
+// factory may dial the full node.
+func (m *Manager) newNode(nodeName, url string) (Node, error) {
+	node, err := m.nodeFactory(m.group, nodeName, url, m)
+	if err == nil && node == nil {
+		err = errors.New("nil node created")
+	}
+
+	return node, err
+}
+
+// admit puts the created node under management, or into quarantine if failed to create, which
+// should be called with lock held. Note, it returns false if node already managed, in which case
+// the created node should be closed without lock held.
+func (m *Manager) admit(nodeName, url string, spare bool, node Node, err error) (bool, error) {
+	if err != nil {
+		return false, m.quarantine(nodeName, url, spare, err)
+	}
+
+	if _, ok := m.nodes[nodeName]; ok {
+		return false, nil
+	}
+
+	if m.unquarantine(nodeName) {
+		logrus.WithFields(logrus.Fields{
+			"group": m.group, "url": url,
+		}).Info("Node created and released from quarantine")
+	}
+
+	m.nodes[nodeName] = node
+
+	if spare {
+		m.spareNodes[nodeName] = false
+	} else if !isCanary(nodeName) {
+		m.hashRing.Add(node)
+	}
+
+	return true, nil
+}
+
+// quarantine puts node failed to construct into quarantine, which should be called with lock held.
+func (m *Manager) quarantine(nodeName, url string, spare bool, err error) error {
+	now := time.Now()
+
+	q, ok := m.quarantinedNodes[nodeName]
+	if !ok {
+		q = &QuarantinedNode{Url: url, Since: now}
+		m.quarantinedNodes[nodeName] = q
+
+		logrus.WithError(err).WithFields(logrus.Fields{
+			"group": m.group, "url": url,
+		}).Warn("Failed to create node, put into quarantine")
+	}
+
+	q.Spare = spare
+	q.Error = err.Error()
+	q.Attempts++
+	q.LastRetry = now
+
+	m.updateQuarantineMetrics()
+
+	return errors.WithMessagef(err, "failed to create node %v", url)
+}
+
+// discard closes nodes created but not admitted due to concurrent changes, which should be
+// called without lock held.
+func discard(nodes []Node) {
+	for _, node := range nodes {
+		if _, err := closeNode(node); err != nil {
+			logrus.WithError(err).Warn("Failed to discard node")
+		}
+	}
+}
+
+// unquarantine removes node from quarantine, which should be called with lock held.
+func (m *Manager) unquarantine(nodeName string) bool {
+	if _, ok := m.quarantinedNodes[nodeName]; !ok {
+		return false
+	}
+
+	delete(m.quarantinedNodes, nodeName)
+	m.updateQuarantineMetrics()
+
+	return true
+}
+
+func (m *Manager) updateQuarantineMetrics() {
+	metrics.Registry.Nodes.Quarantined(string(m.group)).Update(int64(len(m.quarantinedNodes)))
+}
+
+// Quarantined returns all nodes failed to construct.
+func (m *Manager) Quarantined() []QuarantinedNode {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var nodes []QuarantinedNode
+	for _, q := range m.quarantinedNodes {
+		nodes = append(nodes, *q)
+	}
+
+	return nodes
+}
+
+// quarantineError aggregates errors of nodes failed to construct.
+func quarantineError(errs []error) error {
+	if len(errs) == 0 {
+		return nil
+	}
+
+	var msgs []string
+	for _, err := range errs {
+		msgs = append(msgs, err.Error())
+	}
+
+	return errors.Errorf("%v node(s) quarantined: %v", len(errs), strings.Join(msgs, "; "))
+}
+
+func (m *Manager) reconcileQuarantine(ctx context.Context) {
+	ticker := time.NewTicker(cfg.Quarantine.RetryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.reconcileQuarantineOnce()
+		}
+	}
+}
+
+// reconcileQuarantineOnce retries to create quarantined nodes.
+func (m *Manager) reconcileQuarantineOnce() {
+	m.mu.RLock()
+	quarantined := make(map[string]QuarantinedNode)
+	for nodeName, q := range m.quarantinedNodes {
+		quarantined[nodeName] = *q
+	}
+	m.mu.RUnlock()
+
+	var discarded []Node
+
+	for nodeName, q := range quarantined {
+		// create node without lock held, which may dial the full node
+		node, err := m.newNode(nodeName, q.Url)
+
+		m.mu.Lock()
+
+		admitted := false
+		if _, ok := m.quarantinedNodes[nodeName]; ok { // not removed in the meantime
+			admitted, _ = m.admit(nodeName, q.Url, q.Spare, node, err)
+		}
+
+		m.mu.Unlock()
+
+		if err == nil && !admitted {
+			discarded = append(discarded, node)
+		}
+	}
+
+	discard(discarded)
+}
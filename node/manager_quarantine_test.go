@@ -0,0 +1,113 @@
+package node
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/scroll-tech/rpc-gateway/util/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReconcileQuarantine(t *testing.T) {
+	chain := mock.NewChain(mock.ChainConfig{ChainId: 1337, Height: 100})
+
+	// urls failed to construct
+	failing := map[string]bool{"http://127.0.0.2:8545": true, "http://127.0.0.3:8545": true}
+	nf := func(group Group, name, url string, hm HealthMonitor) (Node, error) {
+		if failing[url] {
+			return nil, errors.New("dial failed")
+		}
+
+		return NewMockNode(group, name, url, hm, chain), nil
+	}
+
+	m, err := TryNewManager(GroupEthHttp, nf, []string{
+		"http://127.0.0.1:8545", "http://127.0.0.2:8545", "http://127.0.0.3:8545",
+	})
+	defer m.Close()
+	assert.Error(t, err)
+
+	names := func() []string {
+		var names []string
+		for _, n := range m.List() {
+			names = append(names, n.Name())
+		}
+
+		return names
+	}
+
+	quarantined := func() map[string]int {
+		attempts := make(map[string]int)
+		for _, q := range m.Quarantined() {
+			attempts[q.Url] = q.Attempts
+		}
+
+		return attempts
+	}
+
+	tests := []struct {
+		failing     []string
+		nodes       []string
+		quarantined map[string]int // url => attempts
+	}{
+		// still failed
+		{[]string{"http://127.0.0.2:8545", "http://127.0.0.3:8545"}, []string{"127.0.0.1:8545"}, map[string]int{
+			"http://127.0.0.2:8545": 2, "http://127.0.0.3:8545": 2,
+		}},
+		// partially recovered
+		{[]string{"http://127.0.0.3:8545"}, []string{"127.0.0.1:8545", "127.0.0.2:8545"}, map[string]int{
+			"http://127.0.0.3:8545": 3,
+		}},
+		// all recovered
+		{nil, []string{"127.0.0.1:8545", "127.0.0.2:8545", "127.0.0.3:8545"}, map[string]int{}},
+	}
+
+	for _, tt := range tests {
+		failing = make(map[string]bool)
+		for _, url := range tt.failing {
+			failing[url] = true
+		}
+
+		m.reconcileQuarantineOnce()
+
+		assert.ElementsMatch(t, tt.nodes, names())
+		assert.Equal(t, tt.quarantined, quarantined())
+		assert.Equal(t, len(tt.nodes), len(m.ListHealthy()))
+	}
+}
+
+func TestManagerAddQuarantined(t *testing.T) {
+	chain := mock.NewChain(mock.ChainConfig{ChainId: 1337, Height: 100})
+	nf := func(group Group, name, url string, hm HealthMonitor) (Node, error) {
+		if url == "http://127.0.0.2:8545" {
+			return nil, errors.New("dial failed")
+		}
+
+		return NewMockNode(group, name, url, hm, chain), nil
+	}
+
+	m := NewManager(GroupEthHttp, nf, []string{"http://127.0.0.1:8545"})
+	defer m.Close()
+
+	assert.Error(t, m.Add("http://127.0.0.2:8545"))
+	assert.Nil(t, m.Get("http://127.0.0.2:8545"))
+	assert.Equal(t, 1, len(m.Quarantined()))
+
+	// dropped once no longer configured
+	added, removed := m.Sync([]string{"http://127.0.0.1:8545"})
+	assert.Empty(t, added)
+	assert.Empty(t, removed)
+	assert.Empty(t, m.Quarantined())
+
+	// quarantined node could be removed by administrator
+	assert.Error(t, m.Add("http://127.0.0.2:8545"))
+
+	report, err := m.Remove("http://127.0.0.2:8545")
+	assert.NoError(t, err)
+	assert.Equal(t, "127.0.0.2:8545", report.Node)
+	assert.Empty(t, m.Quarantined())
+
+	_, err = m.Remove("http://127.0.0.2:8545")
+	assert.Equal(t, errNodeNotFound, err)
+	assert.Equal(t, 1, len(m.List()))
+}
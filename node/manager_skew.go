@@ -0,0 +1,107 @@
+package node
+
+import (
+	"context"
+	"time"
+
+	"github.com/buraksezer/consistent"
+	"github.com/scroll-tech/rpc-gateway/util/metrics"
+	"github.com/sirupsen/logrus"
+)
+
+// hashRingConfig returns hash ring configurations in use, of which replication factor could
+// be adjusted at runtime to rebalance.
+func (m *Manager) hashRingConfig() consistent.Config {
+	conf := cfg.HashRingRaw(m.group)
+	if m.replicationFactor > 0 {
+		conf.ReplicationFactor = m.replicationFactor
+	}
+
+	return conf
+}
+
+// reconcileSkew periodically checks partition ownership of hash ring, and warns if skewed
+// beyond threshold. If auto rebalance enabled, hash ring is rebuilt with doubled replication
+// factor, which reshuffles keys and only applies to this node manager.
+func (m *Manager) reconcileSkew(ctx context.Context) {
+	ticker := time.NewTicker(cfg.HashRing.Skew.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.reconcileSkewOnce()
+		}
+	}
+}
+
+func (m *Manager) reconcileSkewOnce() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	partitions := m.partitions()
+	if len(partitions.Nodes) < 2 {
+		return
+	}
+
+	skew := partitionSkew(partitions)
+	metrics.Registry.Nodes.RingSkew(m.group.Space(), m.group.String()).Update(skew)
+
+	threshold := cfg.HashRing.Skew.Threshold
+	if skew <= threshold {
+		return
+	}
+
+	conf := m.hashRingConfig()
+	logger := logrus.WithFields(logrus.Fields{
+		"group":             m.group,
+		"skew":              skew,
+		"threshold":         threshold,
+		"replicationFactor": conf.ReplicationFactor,
+		"partitions":        partitions.Nodes,
+	})
+
+	if !cfg.HashRing.Skew.AutoRebalance || conf.ReplicationFactor >= cfg.HashRing.Skew.MaxReplicationFactor {
+		logger.Warn("Hash ring partition ownership skewed")
+		return
+	}
+
+	conf.ReplicationFactor *= 2
+	if conf.ReplicationFactor > cfg.HashRing.Skew.MaxReplicationFactor {
+		conf.ReplicationFactor = cfg.HashRing.Skew.MaxReplicationFactor
+	}
+
+	m.replicationFactor = conf.ReplicationFactor
+	m.hashRing = consistent.New(m.hashRing.GetMembers(), conf)
+
+	metrics.Registry.Nodes.RingRebalanced(m.group.Space(), m.group.String()).Mark(1)
+	logger.WithField("newReplicationFactor", conf.ReplicationFactor).Warn(
+		"Hash ring partition ownership skewed and rebalanced with increased replication factor",
+	)
+}
+
+// partitionSkew returns the max ratio of partitions owned by a node to the mean, or the mean
+// to partitions owned by a node, whichever is larger.
+func partitionSkew(partitions *RingPartitions) float64 {
+	mean := float64(partitions.PartitionCount) / float64(len(partitions.Nodes))
+
+	var skew float64
+	for _, node := range partitions.Nodes {
+		if node.Partitions == 0 {
+			return float64(partitions.PartitionCount)
+		}
+
+		ratio := float64(node.Partitions) / mean
+		if ratio < 1 {
+			ratio = 1 / ratio
+		}
+
+		if ratio > skew {
+			skew = ratio
+		}
+	}
+
+	return skew
+}
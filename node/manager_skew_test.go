@@ -0,0 +1,27 @@
+package node
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPartitionSkew(t *testing.T) {
+	balanced := RingPartitions{
+		PartitionCount: 100,
+		Nodes:          []NodePartitions{{Partitions: 50}, {Partitions: 50}},
+	}
+	assert.Equal(t, 1.0, partitionSkew(&balanced))
+
+	overloaded := RingPartitions{
+		PartitionCount: 100,
+		Nodes:          []NodePartitions{{Partitions: 75}, {Partitions: 15}, {Partitions: 10}},
+	}
+	assert.InDelta(t, 3.33, partitionSkew(&overloaded), 0.01)
+
+	starved := RingPartitions{
+		PartitionCount: 100,
+		Nodes:          []NodePartitions{{Partitions: 100}, {Partitions: 0}},
+	}
+	assert.Equal(t, 100.0, partitionSkew(&starved))
+}
@@ -0,0 +1,190 @@
+package node
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/scroll-tech/rpc-gateway/util/rpc"
+	"github.com/sirupsen/logrus"
+)
+
+// RingSnapshot is the persisted partition layout of hash ring, which could be restored across
+// restarts or copied between gateway replicas to avoid re-shuffle of sticky traffic.
+//
+// Note, partitions are deterministically located by hash ring members, so only the states that
+// changed the members at runtime (e.g. drained nodes) and sticky routes are restored.
+type RingSnapshot struct {
+	Group Group `json:"group"`
+	// URLs of nodes in the hash ring, which determine the partition layout
+	Members []string `json:"members"`
+	// URLs of nodes drained by administrator
+	Drained []string `json:"drained,omitempty"`
+	// nodes under maintenance, node URL => maintenance state
+	Maintenance map[string]Maintenance `json:"maintenance,omitempty"`
+	// sticky routes of repartition resolver, hashed key => node name
+	Routes map[uint64]string `json:"routes,omitempty"`
+}
+
+// routesExporter is implemented by repartition resolver that supports to export and import
+// the sticky routes.
+type routesExporter interface {
+	Export() map[uint64]string
+	Import(routes map[uint64]string)
+}
+
+// SnapshotRing returns the current partition layout of hash ring.
+func (m *Manager) SnapshotRing() *RingSnapshot {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	snapshot := RingSnapshot{Group: m.group}
+
+	for _, member := range m.hashRing.GetMembers() {
+		if n, ok := member.(Node); ok {
+			snapshot.Members = append(snapshot.Members, n.Url())
+		}
+	}
+
+	for nodeName := range m.drainedNodes {
+		if n, ok := m.nodes[nodeName]; ok {
+			snapshot.Drained = append(snapshot.Drained, n.Url())
+		}
+	}
+
+	for nodeName, state := range m.maintainedNodes {
+		if n, ok := m.nodes[nodeName]; ok {
+			if snapshot.Maintenance == nil {
+				snapshot.Maintenance = make(map[string]Maintenance)
+			}
+
+			snapshot.Maintenance[n.Url()] = *state
+		}
+	}
+
+	if exporter, ok := m.resolver.(routesExporter); ok {
+		snapshot.Routes = exporter.Export()
+	}
+
+	return &snapshot
+}
+
+// RestoreRing restores the partition layout of hash ring from the specified snapshot. Note,
+// nodes not managed any more are ignored.
+func (m *Manager) RestoreRing(snapshot *RingSnapshot) error {
+	if snapshot.Group != m.group {
+		return errors.Errorf("group mismatch, expected %v, got %v", m.group, snapshot.Group)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, url := range snapshot.Drained {
+		nodeName := rpc.Url2NodeName(url)
+		if _, ok := m.nodes[nodeName]; ok {
+			m.drainedNodes[nodeName] = true
+			m.hashRing.Remove(nodeName)
+		}
+	}
+
+	for url, state := range snapshot.Maintenance {
+		nodeName := rpc.Url2NodeName(url)
+		if _, ok := m.nodes[nodeName]; ok {
+			state := state
+			m.maintainedNodes[nodeName] = &state
+			m.hashRing.Remove(nodeName)
+		}
+	}
+
+	if exporter, ok := m.resolver.(routesExporter); ok {
+		routes := make(map[uint64]string)
+		for k, nodeName := range snapshot.Routes {
+			if _, ok := m.nodes[nodeName]; ok {
+				routes[k] = nodeName
+			}
+		}
+
+		exporter.Import(routes)
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"group": m.group, "members": len(snapshot.Members), "routes": len(snapshot.Routes),
+	}).Info("Hash ring restored from snapshot")
+
+	return nil
+}
+
+// LoadRingSnapshots loads the hash ring snapshots of all groups from the specified file.
+func LoadRingSnapshots(file string) (map[Group]*RingSnapshot, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshots map[Group]*RingSnapshot
+	if err := json.Unmarshal(data, &snapshots); err != nil {
+		return nil, errors.WithMessage(err, "invalid snapshot file")
+	}
+
+	return snapshots, nil
+}
+
+// SaveRingSnapshots saves the hash ring snapshots of all groups into the specified file atomically.
+func SaveRingSnapshots(file string, snapshots map[Group]*RingSnapshot) error {
+	data, err := json.Marshal(snapshots)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(file), filepath.Base(file)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), file)
+}
+
+// persistRingSnapshots restores hash ring of all managers from the snapshot file if any, and then
+// saves the snapshots periodically so as to survive restarts.
+func persistRingSnapshots(managers map[Group]*Manager, file string, interval time.Duration) {
+	logger := logrus.WithField("file", file)
+
+	if snapshots, err := LoadRingSnapshots(file); err == nil {
+		for grp, snapshot := range snapshots {
+			if m, ok := managers[grp]; ok {
+				if err := m.RestoreRing(snapshot); err != nil {
+					logger.WithError(err).WithField("group", grp).Warn("Failed to restore hash ring")
+				}
+			}
+		}
+	} else if !os.IsNotExist(err) {
+		logger.WithError(err).Warn("Failed to load hash ring snapshots")
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		snapshots := make(map[Group]*RingSnapshot)
+		for grp, m := range managers {
+			snapshots[grp] = m.SnapshotRing()
+		}
+
+		if err := SaveRingSnapshots(file, snapshots); err != nil {
+			logger.WithError(err).Warn("Failed to save hash ring snapshots")
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package node
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/scroll-tech/rpc-gateway/util/rpc"
+	"github.com/sirupsen/logrus"
+)
+
+// Warm spare nodes are health-checked and kept connected, but excluded from hash ring until
+// activated automatically when the group capacity drops below threshold.
+
+// SyncSpares synchronizes warm spare nodes with the specified URLs, by which new spare nodes
+// will be added and stale ones will be removed. Note, nodes already managed as primary nodes
+// are ignored.
+func (m *Manager) SyncSpares(urls []string) (added, removed []string) {
+	nodeName2Urls := make(map[string]string)
+	for _, url := range urls {
+		nodeName2Urls[rpc.Url2NodeName(url)] = url
+	}
+
+	m.mu.Lock()
+
+	var stale []Node
+
+	for nodeName, q := range m.quarantinedNodes {
+		if _, ok := nodeName2Urls[nodeName]; !ok && q.Spare {
+			m.unquarantine(nodeName)
+		}
+	}
+
+	for nodeName := range m.spareNodes {
+		if _, ok := nodeName2Urls[nodeName]; !ok {
+			node, _ := m.remove(nodeName)
+			stale = append(stale, node)
+			removed = append(removed, node.Url())
+		}
+	}
+
+	pending := make(map[string]string)
+	for nodeName, url := range nodeName2Urls {
+		if _, ok := m.nodes[nodeName]; !ok {
+			pending[nodeName] = url
+		}
+	}
+
+	m.mu.Unlock()
+
+	// tear down without lock held, see Remove for details
+	for _, node := range stale {
+		if _, err := closeNode(node); err != nil {
+			logrus.WithError(err).Warn("Failed to remove spare node")
+		}
+	}
+
+	var discarded []Node
+
+	for nodeName, url := range pending {
+		// create node without lock held, which may dial the full node
+		node, err := m.newNode(nodeName, url)
+
+		m.mu.Lock()
+		admitted, err := m.admit(nodeName, url, true, node, err)
+		m.mu.Unlock()
+
+		if admitted {
+			added = append(added, url)
+		} else if err == nil {
+			discarded = append(discarded, node)
+		}
+	}
+
+	discard(discarded)
+
+	return added, removed
+}
+
+// Spares returns the URLs of all warm spare nodes and whether activated.
+func (m *Manager) Spares() map[string]bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	spares := make(map[string]bool)
+	for nodeName, activated := range m.spareNodes {
+		spares[m.nodes[nodeName].Url()] = activated
+	}
+
+	return spares
+}
+
+// isSpare checks if the specified node is a warm spare node, which should be called with lock held.
+func (m *Manager) isSpare(nodeName string) bool {
+	_, ok := m.spareNodes[nodeName]
+	return ok
+}
+
+// reconcileSpares periodically activates warm spare nodes if the number of primary nodes in
+// hash ring drops below threshold, and deactivates them once recovered.
+func (m *Manager) reconcileSpares(ctx context.Context) {
+	ticker := time.NewTicker(cfg.Spare.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.reconcileSparesOnce()
+		}
+	}
+}
+
+func (m *Manager) reconcileSparesOnce() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.spareNodes) == 0 {
+		return
+	}
+
+	var capacity int
+	for _, member := range m.hashRing.GetMembers() {
+		if !m.isSpare(member.String()) {
+			capacity++
+		}
+	}
+
+	// activate spare nodes in deterministic order to avoid unnecessary repartition
+	var names []string
+	for nodeName := range m.spareNodes {
+		names = append(names, nodeName)
+	}
+
+	sort.Strings(names)
+
+	need := cfg.Spare.MinCapacity - capacity
+
+	for _, nodeName := range names {
+		node := m.nodes[nodeName]
+		eligible := !node.Status().unhealthy && !m.isSuspended(nodeName)
+		activate := eligible && need > 0
+
+		if activate {
+			need--
+		}
+
+		if activate == m.spareNodes[nodeName] {
+			continue
+		}
+
+		m.spareNodes[nodeName] = activate
+
+		logger := logrus.WithFields(logrus.Fields{
+			"group": m.group, "node": nodeName, "capacity": capacity,
+		})
+
+		if activate {
+			m.hashRing.Add(node)
+			logger.Warn("Group capacity dropped below threshold and spare node activated")
+		} else {
+			m.hashRing.Remove(nodeName)
+			logger.Info("Spare node deactivated")
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package node
+
+import (
+	"testing"
+
+	"github.com/scroll-tech/rpc-gateway/util/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReconcileSpares(t *testing.T) {
+	defer func(minCapacity int) { cfg.Spare.MinCapacity = minCapacity }(cfg.Spare.MinCapacity)
+
+	nf := MockNodeFactory(mock.NewChain(mock.ChainConfig{ChainId: 1337, Height: 100}))
+	m := NewManager(GroupEthHttp, nf, []string{"http://127.0.0.1:8545", "http://127.0.0.2:8545"})
+	defer m.Close()
+
+	added, _ := m.SyncSpares([]string{"http://127.0.0.4:8545", "http://127.0.0.3:8545", "http://127.0.0.1:8545"})
+	assert.ElementsMatch(t, []string{"http://127.0.0.3:8545", "http://127.0.0.4:8545"}, added)
+
+	routable := func() []string {
+		var names []string
+		for _, n := range m.ListHealthy() {
+			names = append(names, n.Name())
+		}
+
+		return names
+	}
+
+	tests := []struct {
+		minCapacity int
+		removed     []string // primary nodes removed from hash ring
+		routable    []string
+		spares      map[string]bool
+	}{
+		// enough capacity
+		{2, nil, []string{"127.0.0.1:8545", "127.0.0.2:8545"}, map[string]bool{
+			"http://127.0.0.3:8545": false, "http://127.0.0.4:8545": false,
+		}},
+		// activated in order
+		{2, []string{"127.0.0.2:8545"}, []string{"127.0.0.1:8545", "127.0.0.3:8545"}, map[string]bool{
+			"http://127.0.0.3:8545": true, "http://127.0.0.4:8545": false,
+		}},
+		{2, []string{"127.0.0.1:8545", "127.0.0.2:8545"}, []string{"127.0.0.3:8545", "127.0.0.4:8545"}, map[string]bool{
+			"http://127.0.0.3:8545": true, "http://127.0.0.4:8545": true,
+		}},
+		// deactivated once recovered
+		{2, nil, []string{"127.0.0.1:8545", "127.0.0.2:8545"}, map[string]bool{
+			"http://127.0.0.3:8545": false, "http://127.0.0.4:8545": false,
+		}},
+		// never activated with zero capacity threshold
+		{0, []string{"127.0.0.1:8545", "127.0.0.2:8545"}, nil, map[string]bool{
+			"http://127.0.0.3:8545": false, "http://127.0.0.4:8545": false,
+		}},
+	}
+
+	for _, tt := range tests {
+		cfg.Spare.MinCapacity = tt.minCapacity
+
+		m.hashRing.Add(m.nodes["127.0.0.1:8545"])
+		m.hashRing.Add(m.nodes["127.0.0.2:8545"])
+
+		for _, nodeName := range tt.removed {
+			m.hashRing.Remove(nodeName)
+		}
+
+		m.reconcileSparesOnce()
+
+		assert.ElementsMatch(t, tt.routable, routable())
+		assert.Equal(t, tt.spares, m.Spares())
+	}
+}
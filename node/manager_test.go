@@ -0,0 +1,22 @@
+package node
+
+import (
+	"testing"
+	"time"
+
+	"github.com/scroll-tech/rpc-gateway/util/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManagerClose(t *testing.T) {
+	defer func(interval time.Duration) { cfg.Spare.Interval = interval }(cfg.Spare.Interval)
+	cfg.Spare.Interval = time.Millisecond
+
+	nf := MockNodeFactory(mock.NewChain(mock.ChainConfig{ChainId: 1337, Height: 100}))
+	m := NewManager(GroupEthHttp, nf, []string{"http://127.0.0.1:8545", "http://127.0.0.2:8545"})
+	assert.Equal(t, 2, len(m.List()))
+
+	// reconciliation stopped and nodes torn down
+	assert.NoError(t, m.Close())
+	assert.Empty(t, m.List())
+}
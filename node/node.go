@@ -17,6 +17,7 @@ import (
 var (
 	_ Node = (*CfxNode)(nil)
 	_ Node = (*EthNode)(nil)
+	_ Node = (*MockNode)(nil)
 )
 
 // Node represents a full node with friendly name and health status.
@@ -29,7 +30,9 @@ type Node interface {
 
 	LatestEpochNumber() (uint64, error)
 
-	Close()
+	// Close synchronously tears down health monitor and client connections, and reports
+	// resources freed. Error returned if any resource failed to free in time.
+	Close() (*TeardownReport, error)
 }
 
 type baseNode struct {
@@ -37,11 +40,13 @@ type baseNode struct {
 	url          string
 	cancel       context.CancelFunc
 	atomicStatus atomic.Value
+	done         chan struct{} // closed once health monitor stopped
+	pending      int64         // number of pending heartbeat requests
 }
 
 func newBaseNode(name, url string, cancel context.CancelFunc) *baseNode {
 	return &baseNode{
-		name: name, url: url, cancel: cancel,
+		name: name, url: url, cancel: cancel, done: make(chan struct{}),
 	}
 }
 
@@ -65,6 +70,7 @@ func (n *baseNode) String() string {
 func (n *baseNode) monitor(ctx context.Context, node Node, hm HealthMonitor) {
 	ticker := time.NewTicker(cfg.Monitor.Interval)
 	defer ticker.Stop()
+	defer close(n.done)
 
 	for {
 		select {
@@ -72,17 +78,43 @@ func (n *baseNode) monitor(ctx context.Context, node Node, hm HealthMonitor) {
 			logrus.WithField("name", n.name).Info("Complete to monitor node")
 			return
 		case <-ticker.C:
+			atomic.AddInt64(&n.pending, 1)
 			status := n.atomicStatus.Load().(Status)
 			status.Update(node, hm)
 			n.atomicStatus.Store(status)
+			atomic.AddInt64(&n.pending, -1)
 		}
 	}
 }
 
-func (n *baseNode) Close() {
+// stopMonitor stops the health monitor, closes client connections to abort pending heartbeat
+// if any, and waits for health monitor to stop.
+func (n *baseNode) stopMonitor(report *TeardownReport, closeConns func()) error {
+	start := time.Now()
+	report.Node = n.name
+
 	n.cancel()
+
+	report.PendingAborted = atomic.LoadInt64(&n.pending)
+	closeConns()
+	report.ConnsClosed = true
+
+	select {
+	case <-n.done:
+		report.MonitorStopped = true
+	case <-time.After(teardownTimeout):
+	}
+
+	// unregister metrics after health monitor stopped, which may register again otherwise
 	status := n.Status()
-	status.Close()
+	report.MetricsFreed = status.Close()
+	report.Elapsed = time.Since(start)
+
+	if !report.MonitorStopped {
+		return errors.Errorf("health monitor not stopped in %v", teardownTimeout)
+	}
+
+	return nil
 }
 
 // EthNode represents an evm space node with friendly name and health status.
@@ -93,19 +125,30 @@ type EthNode struct {
 
 // NewEthNode creates an instance of evm space node and start to monitor
 // node health in a separate goroutine until node closed.
-func NewEthNode(group Group, name, url string, hm HealthMonitor) *EthNode {
+func NewEthNode(group Group, name, url string, hm HealthMonitor) (*EthNode, error) {
+	client, err := rpc.NewEthClient(url)
+	if err != nil {
+		return nil, err
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 
 	n := &EthNode{
 		baseNode: newBaseNode(name, url, cancel),
-		Client:   rpc.MustNewEthClient(url),
+		Client:   client,
 	}
 
 	n.atomicStatus.Store(NewStatus(group, name))
 
 	go n.monitor(ctx, n, hm)
 
-	return n
+	return n, nil
+}
+
+func (n *EthNode) Close() (*TeardownReport, error) {
+	var report TeardownReport
+	err := n.stopMonitor(&report, n.Provider().Close)
+	return &report, err
 }
 
 // LatestEpochNumber returns the latest block height of the evm space fullnode
@@ -131,19 +174,24 @@ type CfxNode struct {
 
 // NewCfxNode creates an instance of core space fullnode and start to monitor
 // node health in a separate goroutine until node closed.
-func NewCfxNode(group Group, name, url string, hm HealthMonitor) *CfxNode {
+func NewCfxNode(group Group, name, url string, hm HealthMonitor) (*CfxNode, error) {
+	client, err := rpc.NewCfxClient(url)
+	if err != nil {
+		return nil, err
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 
 	n := &CfxNode{
 		baseNode:       newBaseNode(name, url, cancel),
-		ClientOperator: rpc.MustNewCfxClient(url),
+		ClientOperator: client,
 	}
 
 	n.atomicStatus.Store(NewStatus(group, name))
 
 	go n.monitor(ctx, n, hm)
 
-	return n
+	return n, nil
 }
 
 // LatestEpochNumber returns the latest epoch height of the core space fullnode
@@ -161,7 +209,8 @@ func (n *CfxNode) LatestEpochNumber() (uint64, error) {
 	return epoch.ToInt().Uint64(), nil
 }
 
-func (n *CfxNode) Close() {
-	n.baseNode.Close()
-	n.ClientOperator.Close()
+func (n *CfxNode) Close() (*TeardownReport, error) {
+	var report TeardownReport
+	err := n.stopMonitor(&report, n.ClientOperator.Close)
+	return &report, err
 }
@@ -0,0 +1,50 @@
+package node
+
+import (
+	"context"
+
+	"github.com/scroll-tech/rpc-gateway/util/mock"
+)
+
+// MockNode represents a node of deterministic mock chain for tests and local development,
+// which never requests any full node.
+type MockNode struct {
+	*baseNode
+	chain *mock.Chain
+}
+
+// NewMockNode creates an instance of mock node and start to monitor node health in a
+// separate goroutine until node closed.
+func NewMockNode(group Group, name, url string, hm HealthMonitor, chain *mock.Chain) *MockNode {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	n := &MockNode{
+		baseNode: newBaseNode(name, url, cancel),
+		chain:    chain,
+	}
+
+	n.atomicStatus.Store(NewStatus(group, name))
+
+	go n.monitor(ctx, n, hm)
+
+	return n
+}
+
+// MockNodeFactory returns the factory method to create mock nodes of the specified chain, e.g.
+// to create node manager in tests.
+func MockNodeFactory(chain *mock.Chain) nodeFactory {
+	return func(group Group, name, url string, hm HealthMonitor) (Node, error) {
+		return NewMockNode(group, name, url, hm, chain), nil
+	}
+}
+
+// LatestEpochNumber returns the head of mock chain.
+func (n *MockNode) LatestEpochNumber() (uint64, error) {
+	return n.chain.Head(), nil
+}
+
+func (n *MockNode) Close() (*TeardownReport, error) {
+	var report TeardownReport
+	err := n.stopMonitor(&report, func() {})
+	return &report, err
+}
@@ -183,8 +183,9 @@ func (s *Status) checkHealth(targetEpoch uint64) error {
 	return nil
 }
 
-func (s *Status) Close() {
-	s.metric.unregisterAll()
+// Close unregisters status metrics, and returns the number of metrics unregistered.
+func (s *Status) Close() int {
+	return s.metric.unregisterAll()
 }
 
 type statusMetrics struct {
@@ -207,7 +208,13 @@ func (sm *statusMetrics) update(start time.Time, err error) {
 	metrics.GetOrRegisterTimeWindowPercentageDefault(sm.availability).Mark(err == nil)
 }
 
-func (sm *statusMetrics) unregisterAll() {
-	metrics.InfuraRegistry.Unregister(sm.latency)
-	metrics.InfuraRegistry.Unregister(sm.availability)
+func (sm *statusMetrics) unregisterAll() (freed int) {
+	for _, name := range []string{sm.latency, sm.availability} {
+		if metrics.InfuraRegistry.Get(name) != nil {
+			metrics.InfuraRegistry.Unregister(name)
+			freed++
+		}
+	}
+
+	return freed
 }
@@ -13,6 +13,12 @@ type RepartitionResolver interface {
 	Put(key uint64, value string)
 }
 
+// repartitionInvalidator is implemented by repartition resolver that supports to invalidate
+// all the cached routes to some node, e.g. when node removed from the hash ring.
+type repartitionInvalidator interface {
+	Invalidate(node string) int
+}
+
 type noopRepartitionResolver struct{}
 
 func (r *noopRepartitionResolver) Get(key uint64) (string, bool) { return "", false }
@@ -24,17 +30,21 @@ type partitionInfo struct {
 	deadline time.Time
 }
 
+// SimpleRepartitionResolver is an in-memory repartition resolver, which evicts the cached routes
+// when expired or the least recently used ones when the max number of entries reached.
 type SimpleRepartitionResolver struct {
-	key2Items sync.Map
-	items     *list.List
-	ttl       time.Duration
-	mu        sync.Mutex
+	key2Items  sync.Map
+	items      *list.List
+	ttl        time.Duration
+	maxEntries int // 0 means unlimited
+	mu         sync.Mutex
 }
 
-func NewSimpleRepartitionResolver(ttl time.Duration) *SimpleRepartitionResolver {
+func NewSimpleRepartitionResolver(ttl time.Duration, maxEntries int) *SimpleRepartitionResolver {
 	return &SimpleRepartitionResolver{
-		items: list.New(),
-		ttl:   ttl,
+		items:      list.New(),
+		ttl:        ttl,
+		maxEntries: maxEntries,
 	}
 }
 
@@ -84,12 +94,88 @@ func (r *SimpleRepartitionResolver) Put(key uint64, value string) {
 		item.Value = info
 		r.items.MoveToBack(item)
 	} else {
+		// evict the least recently used items if capacity reached
+		for r.maxEntries > 0 && r.items.Len() >= r.maxEntries {
+			r.remove(r.items.Front())
+		}
+
 		// add new item
 		item := r.items.PushBack(info)
 		r.key2Items.Store(key, item)
 	}
 }
 
+// Invalidate removes all the cached items routed to the specified node, and returns the
+// number of removed items.
+func (r *SimpleRepartitionResolver) Invalidate(node string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var removed int
+
+	for item := r.items.Front(); item != nil; {
+		next := item.Next()
+
+		if item.Value.(partitionInfo).node == node {
+			r.remove(item)
+			removed++
+		}
+
+		item = next
+	}
+
+	return removed
+}
+
+// Export returns all the unexpired cached items.
+func (r *SimpleRepartitionResolver) Export() map[uint64]string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.gc()
+
+	routes := make(map[uint64]string)
+	for item := r.items.Front(); item != nil; item = item.Next() {
+		info := item.Value.(partitionInfo)
+		routes[info.key] = info.node
+	}
+
+	return routes
+}
+
+// Import adds the specified items into cache, which will not overwrite the existing ones.
+func (r *SimpleRepartitionResolver) Import(routes map[uint64]string) {
+	for key, node := range routes {
+		if _, ok := r.key2Items.Load(key); !ok {
+			r.Put(key, node)
+		}
+	}
+}
+
+// Len returns the number of cached items, including the expired ones not collected yet.
+func (r *SimpleRepartitionResolver) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.items.Len()
+}
+
+// Flush removes all the cached items.
+func (r *SimpleRepartitionResolver) Flush() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for front := r.items.Front(); front != nil; front = r.items.Front() {
+		r.remove(front)
+	}
+}
+
+// remove removes the specified item, which should be called with lock held.
+func (r *SimpleRepartitionResolver) remove(item *list.Element) {
+	r.items.Remove(item)
+	r.key2Items.Delete(item.Value.(partitionInfo).key)
+}
+
 // gc removes the expired items.
 func (r *SimpleRepartitionResolver) gc() {
 	now := time.Now()
@@ -105,7 +191,6 @@ func (r *SimpleRepartitionResolver) gc() {
 			break
 		}
 
-		r.items.Remove(front)
-		r.key2Items.Delete(info.key)
+		r.remove(front)
 	}
 }
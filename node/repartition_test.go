@@ -0,0 +1,77 @@
+package node
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSimpleRepartitionResolverCapacity(t *testing.T) {
+	r := NewSimpleRepartitionResolver(time.Minute, 2)
+
+	r.Put(1, "n1")
+	r.Put(2, "n2")
+
+	// touch key 1 so that key 2 is the least recently used
+	_, ok := r.Get(1)
+	assert.True(t, ok)
+
+	r.Put(3, "n3")
+	assert.Equal(t, 2, r.Len())
+
+	_, ok = r.Get(2)
+	assert.False(t, ok)
+
+	node, ok := r.Get(1)
+	assert.True(t, ok)
+	assert.Equal(t, "n1", node)
+}
+
+func TestSimpleRepartitionResolverExpiry(t *testing.T) {
+	r := NewSimpleRepartitionResolver(10*time.Millisecond, 0)
+
+	r.Put(1, "n1")
+	time.Sleep(20 * time.Millisecond)
+
+	_, ok := r.Get(1)
+	assert.False(t, ok)
+}
+
+func TestSimpleRepartitionResolverInvalidate(t *testing.T) {
+	r := NewSimpleRepartitionResolver(time.Minute, 0)
+
+	r.Put(1, "n1")
+	r.Put(2, "n2")
+	r.Put(3, "n1")
+
+	assert.Equal(t, 2, r.Invalidate("n1"))
+	assert.Equal(t, 1, r.Len())
+
+	_, ok := r.Get(1)
+	assert.False(t, ok)
+
+	node, ok := r.Get(2)
+	assert.True(t, ok)
+	assert.Equal(t, "n2", node)
+}
+
+func TestSimpleRepartitionResolverExportImport(t *testing.T) {
+	r := NewSimpleRepartitionResolver(time.Minute, 0)
+	r.Put(1, "n1")
+	r.Put(2, "n2")
+
+	routes := r.Export()
+	assert.Equal(t, map[uint64]string{1: "n1", 2: "n2"}, routes)
+
+	r2 := NewSimpleRepartitionResolver(time.Minute, 0)
+	r2.Put(1, "n3")
+	r2.Import(routes)
+
+	// existing routes not overwritten
+	node, _ := r2.Get(1)
+	assert.Equal(t, "n3", node)
+
+	node, _ = r2.Get(2)
+	assert.Equal(t, "n2", node)
+}
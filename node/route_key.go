@@ -0,0 +1,85 @@
+package node
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/pkg/errors"
+	"github.com/scroll-tech/rpc-gateway/util/reload"
+	"github.com/scroll-tech/rpc-gateway/util/rpc/handlers"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// Built-in route key extractors.
+const (
+	RouteKeyIP     = "ip"     // route by client IP address
+	RouteKeyApiKey = "apiKey" // route by API key, and fall back to client IP if absent
+)
+
+// RouteKeyExtractor extracts key from request context to route RPC request, so that
+// requests of the same key consistently land on the same node to improve upstream node
+// cache locality.
+type RouteKeyExtractor func(ctx context.Context) string
+
+var (
+	routeKeyExtractors = map[string]RouteKeyExtractor{
+		RouteKeyIP:     ipRouteKey,
+		RouteKeyApiKey: apiKeyRouteKey,
+	}
+
+	// routeKeyExtractor is the route key extractor in use, which could be changed at runtime.
+	routeKeyExtractor atomic.Value
+)
+
+func init() {
+	if err := SetRouteKeyExtractorByName(cfg.Router.RouteKey); err != nil {
+		logrus.WithError(err).Fatal("Invalid route key extractor")
+	}
+
+	reload.Register("node_route_key", func() error {
+		return SetRouteKeyExtractorByName(viper.GetString("node.router.routeKey"))
+	})
+}
+
+// SetRouteKeyExtractor sets a customized route key extractor.
+func SetRouteKeyExtractor(extractor RouteKeyExtractor) {
+	routeKeyExtractor.Store(extractor)
+}
+
+// SetRouteKeyExtractorByName sets route key extractor by built-in name, and empty name
+// stands for client IP address.
+func SetRouteKeyExtractorByName(name string) error {
+	if len(name) == 0 {
+		name = RouteKeyIP
+	}
+
+	extractor, ok := routeKeyExtractors[name]
+	if !ok {
+		return errors.Errorf("unknown route key extractor %v", name)
+	}
+
+	SetRouteKeyExtractor(extractor)
+	return nil
+}
+
+// routeKeyFromContext extracts key from request context to route RPC request.
+func routeKeyFromContext(ctx context.Context) string {
+	return routeKeyExtractor.Load().(RouteKeyExtractor)(ctx)
+}
+
+func ipRouteKey(ctx context.Context) string {
+	if ip, ok := handlers.GetIPAddressFromContext(ctx); ok {
+		return ip
+	}
+
+	return "unknown_ip"
+}
+
+func apiKeyRouteKey(ctx context.Context) string {
+	if key, ok := handlers.GetAccessTokenFromContext(ctx); ok && len(key) > 0 {
+		return "key:" + key
+	}
+
+	return ipRouteKey(ctx)
+}
@@ -0,0 +1,55 @@
+package node
+
+import (
+	"context"
+	"testing"
+
+	"github.com/scroll-tech/rpc-gateway/util/rpc/handlers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouteKeyFromContext(t *testing.T) {
+	defer SetRouteKeyExtractor(routeKeyExtractor.Load().(RouteKeyExtractor))
+
+	newCtx := func(ip, key string) context.Context {
+		ctx := context.Background()
+
+		if len(ip) > 0 {
+			ctx = context.WithValue(ctx, handlers.CtxKeyRealIP, ip)
+		}
+
+		if len(key) > 0 {
+			ctx = context.WithValue(ctx, handlers.CtxAccessToken, key)
+		}
+
+		return ctx
+	}
+
+	tests := []struct {
+		extractor string
+		ip        string
+		key       string
+		routeKey  string
+	}{
+		{"", "10.0.0.1", "abc", "10.0.0.1"},
+		{RouteKeyIP, "10.0.0.1", "abc", "10.0.0.1"},
+		{RouteKeyIP, "", "abc", "unknown_ip"},
+		{RouteKeyApiKey, "10.0.0.1", "abc", "key:abc"},
+		// fall back to client IP if API key absent
+		{RouteKeyApiKey, "10.0.0.1", "", "10.0.0.1"},
+		{RouteKeyApiKey, "", "", "unknown_ip"},
+	}
+
+	for _, tt := range tests {
+		assert.NoError(t, SetRouteKeyExtractorByName(tt.extractor))
+		assert.Equal(t, tt.routeKey, routeKeyFromContext(newCtx(tt.ip, tt.key)))
+	}
+
+	// unknown extractor never changes the one in use
+	assert.Error(t, SetRouteKeyExtractorByName("header"))
+	assert.Equal(t, "key:abc", routeKeyFromContext(newCtx("10.0.0.1", "abc")))
+
+	// customized extractor
+	SetRouteKeyExtractor(func(ctx context.Context) string { return "tenant" })
+	assert.Equal(t, "tenant", routeKeyFromContext(newCtx("10.0.0.1", "abc")))
+}
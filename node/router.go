@@ -3,6 +3,7 @@ package node
 import (
 	"context"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/buraksezer/consistent"
@@ -10,6 +11,8 @@ import (
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/go-redis/redis/v8"
+	"github.com/pkg/errors"
+	"github.com/scroll-tech/rpc-gateway/util/reload"
 	rpcutil "github.com/scroll-tech/rpc-gateway/util/rpc"
 	"github.com/sirupsen/logrus"
 )
@@ -35,9 +38,45 @@ const (
 	GroupDebugHttp = "debughttp"
 )
 
+// chainSeparator separates chain name and group name, e.g. `sepolia.ethhttp`.
+const chainSeparator = "."
+
+// WithChain qualifies group with the specified chain name, so that node groups of different
+// chains could be managed independently. Note, empty chain stands for the default chain, in
+// which case the group name remains unchanged.
+func (g Group) WithChain(chain string) Group {
+	if len(chain) == 0 {
+		return g
+	}
+
+	return Group(chain + chainSeparator + string(g))
+}
+
+// Chain parses chain name from group name, and returns empty for the default chain.
+func (g Group) Chain() string {
+	if idx := strings.Index(string(g), chainSeparator); idx >= 0 {
+		return string(g)[:idx]
+	}
+
+	return ""
+}
+
+// Base returns the group name without chain name.
+func (g Group) Base() Group {
+	if idx := strings.Index(string(g), chainSeparator); idx >= 0 {
+		return g[idx+len(chainSeparator):]
+	}
+
+	return g
+}
+
 // Space parses space from group name
 func (g Group) Space() string {
-	if strings.HasPrefix(string(g), "eth") {
+	if space, ok := customGroupSpace(g.Base()); ok {
+		return space
+	}
+
+	if strings.HasPrefix(string(g.Base()), "eth") {
 		return "eth"
 	}
 
@@ -54,8 +93,31 @@ type Router interface {
 	Route(group Group, key []byte) string
 }
 
+// HeightRouter is implemented by any router that supports to route RPC requests
+// with the requested block height taken into account.
+type HeightRouter interface {
+	// RouteByHeight returns the full node URL for specified group and key, whose synced
+	// height covers the specified height. Note, nil height stands for the latest height,
+	// in which case full nodes lagging behind too much should be avoided.
+	RouteByHeight(group Group, key []byte, height *uint64) string
+}
+
 // MustNewRouter creates an instance of Router.
-func MustNewRouter(redisURL string, nodeRPCURL string, groupConf map[Group]UrlConfig) Router {
+func MustNewRouter(
+	redisURL string, nodeRPCURL string, groupConf map[Group]UrlConfig, loader urlConfigLoader,
+) Router {
+	router, err := NewRouter(redisURL, nodeRPCURL, groupConf, loader)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to create router")
+	}
+
+	return router
+}
+
+// NewRouter creates an instance of Router.
+func NewRouter(
+	redisURL string, nodeRPCURL string, groupConf map[Group]UrlConfig, loader urlConfigLoader,
+) (Router, error) {
 	var routers []Router
 
 	// Add redis router if configured
@@ -63,7 +125,7 @@ func MustNewRouter(redisURL string, nodeRPCURL string, groupConf map[Group]UrlCo
 		// redis://<user>:<password>@<host>:<port>/<db_number>
 		opt, err := redis.ParseURL(redisURL)
 		if err != nil {
-			logrus.WithError(err).Fatal("Failed to parse redis URL")
+			return nil, errors.WithMessage(err, "failed to parse redis URL")
 		}
 
 		client := redis.NewClient(opt)
@@ -75,7 +137,7 @@ func MustNewRouter(redisURL string, nodeRPCURL string, groupConf map[Group]UrlCo
 		// http://127.0.0.1:22530
 		client, err := rpc.DialHTTP(nodeRPCURL)
 		if err != nil {
-			logrus.WithError(err).Fatal("Failed to create rpc client")
+			return nil, errors.WithMessage(err, "failed to create rpc client")
 		}
 
 		routers = append(routers, NewNodeRpcRouter(client))
@@ -83,7 +145,7 @@ func MustNewRouter(redisURL string, nodeRPCURL string, groupConf map[Group]UrlCo
 		// Also add local router in case node rpc temporary unavailable
 		localRouter, err := NewLocalRouterFromNodeRPC(client, groupConf)
 		if err != nil {
-			logrus.WithError(err).Fatal("Failed to new local router with node rpc")
+			return nil, errors.WithMessage(err, "failed to new local router with node rpc")
 		}
 		routers = append(routers, localRouter)
 	}
@@ -95,10 +157,23 @@ func MustNewRouter(redisURL string, nodeRPCURL string, groupConf map[Group]UrlCo
 			group2Urls[k] = v.Nodes
 		}
 
-		routers = append(routers, NewLocalRouter(group2Urls))
+		localRouter := NewLocalRouter(group2Urls)
+		routers = append(routers, localRouter)
+
+		if loader != nil {
+			reload.Register("local_router", func() error {
+				groupConf, err := loader()
+				if err != nil {
+					return err
+				}
+
+				localRouter.reload(groupConf)
+				return nil
+			})
+		}
 	}
 
-	return NewChainedRouter(groupConf, routers...)
+	return NewChainedRouter(groupConf, routers...), nil
 }
 
 // chainedRouter routes RPC requests in chained responsibility pattern.
@@ -115,9 +190,36 @@ func NewChainedRouter(groupConf map[Group]UrlConfig, routers ...Router) Router {
 }
 
 func (r *chainedRouter) Route(group Group, key []byte) string {
-	for _, r := range r.routers {
-		if val := r.Route(group, key); len(val) > 0 {
-			return val
+	return r.route(group, key, nil, false)
+}
+
+// RouteByHeight implements the HeightRouter interface. It routes by height aware routers
+// at first, and then falls back to route by key only.
+func (r *chainedRouter) RouteByHeight(group Group, key []byte, height *uint64) string {
+	return r.route(group, key, height, true)
+}
+
+// route routes RPC requests of the specified group, and cascades to the fallback groups in
+// order if none node available, e.g. all nodes in the group are unhealthy.
+//
+// Note, local routers hold all configured nodes regardless of health status, so they are only
+// consulted once health aware routers (redis or node RPC) failed to route in all cascaded groups.
+func (r *chainedRouter) route(group Group, key []byte, height *uint64, byHeight bool) string {
+	for _, static := range []bool{false, true} {
+		visited := make(map[Group]bool)
+
+		for grp := group; len(grp) > 0 && !visited[grp]; grp = r.groupConf[grp].Fallback {
+			visited[grp] = true
+
+			if val := r.routeGroup(grp, key, height, byHeight, static); len(val) > 0 {
+				if grp != group {
+					logrus.WithFields(logrus.Fields{
+						"group": group, "fallback": grp, "key": string(key),
+					}).Debug("No node available in group, cascaded to fallback group")
+				}
+
+				return val
+			}
 		}
 	}
 
@@ -135,6 +237,35 @@ func (r *chainedRouter) Route(group Group, key []byte) string {
 	return config.Failover
 }
 
+// routeGroup routes RPC requests of the specified group only by chained routers, which are
+// either local routers (static) or health aware routers.
+func (r *chainedRouter) routeGroup(group Group, key []byte, height *uint64, byHeight, static bool) string {
+	var routers []Router
+	for _, r := range r.routers {
+		if _, ok := r.(*LocalRouter); ok == static {
+			routers = append(routers, r)
+		}
+	}
+
+	if byHeight {
+		for _, r := range routers {
+			if hr, ok := r.(HeightRouter); ok {
+				if val := hr.RouteByHeight(group, key, height); len(val) > 0 {
+					return val
+				}
+			}
+		}
+	}
+
+	for _, r := range routers {
+		if val := r.Route(group, key); len(val) > 0 {
+			return val
+		}
+	}
+
+	return ""
+}
+
 // RedisRouter routes RPC requests via redis.
 // It should be used together with RedisRepartitionResolver.
 type RedisRouter struct {
@@ -185,6 +316,18 @@ func (r *NodeRpcRouter) Route(group Group, key []byte) string {
 	return result
 }
 
+func (r *NodeRpcRouter) RouteByHeight(group Group, key []byte, height *uint64) string {
+	var result string
+	if err := r.client.Call(
+		&result, "node_routeByHeight", group, hexutil.Bytes(key), (*hexutil.Uint64)(height),
+	); err != nil {
+		logrus.WithError(err).Error("Failed to route key by height from node RPC")
+		return ""
+	}
+
+	return result
+}
+
 type localNode string
 
 func (n localNode) String() string { return string(n) }
@@ -200,7 +343,7 @@ type localNodeGroup struct {
 	hashRing *consistent.Consistent
 }
 
-func newLocalNodeGroup(urls []string) *localNodeGroup {
+func newLocalNodeGroup(group Group, urls []string) *localNodeGroup {
 	item := localNodeGroup{
 		nodes: make(map[string]localNode),
 	}
@@ -215,7 +358,7 @@ func newLocalNodeGroup(urls []string) *localNodeGroup {
 		}
 	}
 
-	item.hashRing = consistent.New(members, cfg.HashRingRaw())
+	item.hashRing = consistent.New(members, cfg.HashRingRaw(group))
 
 	return &item
 }
@@ -223,16 +366,17 @@ func newLocalNodeGroup(urls []string) *localNodeGroup {
 // LocalRouter routes RPC requests based on local hash ring.
 type LocalRouter struct {
 	groups map[Group]*localNodeGroup
+	mu     sync.Mutex // to serialize node groups update
 }
 
 func NewLocalRouter(group2Urls map[Group][]string) *LocalRouter {
 	groups := make(map[Group]*localNodeGroup)
 
 	for k, v := range group2Urls {
-		groups[k] = newLocalNodeGroup(v)
+		groups[k] = newLocalNodeGroup(k, v)
 	}
 
-	return &LocalRouter{groups}
+	return &LocalRouter{groups: groups}
 }
 
 func (r *LocalRouter) Route(group Group, key []byte) string {
@@ -286,7 +430,19 @@ func (r *LocalRouter) update(client *rpc.Client) {
 	}
 }
 
+// reload updates node groups with reloaded configurations.
+func (r *LocalRouter) reload(groupConf map[Group]UrlConfig) {
+	for grp := range r.groups {
+		if conf, ok := groupConf[grp]; ok {
+			r.updateOnce(conf.Nodes, grp)
+		}
+	}
+}
+
 func (r *LocalRouter) updateOnce(urls []string, group Group) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
 	fnNodes, hashRing := r.groups[group].nodes, r.groups[group].hashRing
 
 	// detect new added
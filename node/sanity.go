@@ -0,0 +1,150 @@
+package node
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/pkg/errors"
+	"github.com/scroll-tech/rpc-gateway/util/rpc"
+)
+
+// Sanity checks of node configurations, which are used to fail fast at startup or validate
+// configurations offline.
+
+// CheckGroups checks node groups that should have nodes, and returns an error for each
+// empty group with actionable message.
+func CheckGroups() []error {
+	var errs []error
+
+	if len(ethUrlCfg[GroupEthHttp].Nodes) == 0 {
+		errs = append(errs, errors.Errorf("group %v has no node, please configure `node.ethurls`", GroupEthHttp))
+	}
+
+	for _, grp := range cfg.Groups {
+		if len(grp.URLs) == 0 && len(grp.Spares) == 0 {
+			errs = append(errs, errors.Errorf(
+				"config-driven group %v has no node, please configure `urls` of the group or remove it", grp.Name,
+			))
+		}
+	}
+
+	for _, chain := range cfg.Chains {
+		if len(chain.URLs) == 0 {
+			errs = append(errs, errors.Errorf("chain %v has no node, please configure `urls` of the chain", chain.Name))
+		}
+	}
+
+	spaceConfs := []map[Group]UrlConfig{urlCfg, ethUrlCfg}
+	for _, confs := range chainUrlCfgs {
+		spaceConfs = append(spaceConfs, confs)
+	}
+
+	var fallbackErrs []error
+	for _, confs := range spaceConfs {
+		for grp, conf := range confs {
+			if len(conf.Fallback) > 0 && len(conf.Nodes) > 0 && len(confs[conf.Fallback].Nodes) == 0 {
+				fallbackErrs = append(fallbackErrs, errors.Errorf(
+					"fallback group %v of group %v has no node, please configure nodes or remove `node.router.fallbackGroups.%v`",
+					conf.Fallback, grp, grp.Base(),
+				))
+			}
+		}
+	}
+
+	// deterministic order regardless of map iteration
+	sort.Slice(fallbackErrs, func(i, j int) bool {
+		return fallbackErrs[i].Error() < fallbackErrs[j].Error()
+	})
+
+	return append(errs, fallbackErrs...)
+}
+
+// ProbeNodes checks if all configured nodes are reachable by querying the latest block
+// number concurrently, and returns an error for each unreachable node.
+func ProbeNodes(timeout time.Duration) []error {
+	type probe struct {
+		url   string
+		group Group
+		eth   bool
+	}
+
+	var probes []probe
+	visited := make(map[string]bool)
+
+	addProbes := func(confs map[Group]UrlConfig, eth bool) {
+		for grp, conf := range confs {
+			for _, url := range conf.urls() {
+				if key := fmt.Sprintf("%v/%v", eth, url); !visited[key] {
+					visited[key] = true
+					probes = append(probes, probe{url, grp, eth})
+				}
+			}
+		}
+	}
+
+	addProbes(urlCfg, false)
+	addProbes(ethUrlCfg, true)
+
+	for _, confs := range chainUrlCfgs {
+		addProbes(confs, true)
+	}
+
+	errs := make([]error, len(probes))
+
+	var wg sync.WaitGroup
+	for i := range probes {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			p := probes[i]
+			if err := probeNode(p.url, p.eth, timeout); err != nil {
+				errs[i] = errors.WithMessagef(err, "node %v of group %v unreachable", p.url, p.group)
+			}
+		}(i)
+	}
+
+	wg.Wait()
+
+	var result []error
+	for _, err := range errs {
+		if err != nil {
+			result = append(result, err)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Error() < result[j].Error()
+	})
+
+	return result
+}
+
+// probeNode queries the latest block number of node without retry.
+func probeNode(url string, eth bool, timeout time.Duration) error {
+	options := []rpc.ClientOption{rpc.WithClientRequestTimeout(timeout), rpc.WithClientRetryCount(0)}
+
+	if eth {
+		client, err := rpc.NewEthClient(url, options...)
+		if err != nil {
+			return err
+		}
+		defer client.Provider().Close()
+
+		_, err = client.Eth.BlockNumber()
+		return err
+	}
+
+	client, err := rpc.NewCfxClient(url, options...)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	_, err = client.GetEpochNumber(types.EpochLatestMined)
+	return err
+}
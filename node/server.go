@@ -2,20 +2,65 @@ package node
 
 import (
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/pkg/errors"
 	"github.com/scroll-tech/rpc-gateway/util"
+	"github.com/scroll-tech/rpc-gateway/util/reload"
 	"github.com/scroll-tech/rpc-gateway/util/rpc"
+	"github.com/sirupsen/logrus"
 )
 
 // NewServer creates node management RPC server
-func NewServer(nf nodeFactory, groupConf map[Group]UrlConfig) *rpc.Server {
+func NewServer(nf nodeFactory, groupConf map[Group]UrlConfig, loader urlConfigLoader) *rpc.Server {
+	return newServer(newApi(nf, groupConf, loader))
+}
+
+func newServer(api *api) *rpc.Server {
+	return rpc.MustNewServer("node", map[string]interface{}{
+		"node": api,
+	}, newHealthyEndpointsMiddleware(api.managers))
+}
+
+// newApi creates node management APIs with node managers of all groups.
+func newApi(nf nodeFactory, groupConf map[Group]UrlConfig, loader urlConfigLoader) *api {
 	managers := make(map[Group]*Manager)
 	for k, v := range groupConf {
-		managers[k] = NewManager(k, nf, v.Nodes)
+		m, err := TryNewManager(k, nf, v.Nodes)
+		if err != nil {
+			logrus.WithError(err).WithField("group", k).Warn("Failed to create some nodes of group")
+		}
+
+		managers[k] = m
+		managers[k].SyncSpares(v.Spares)
 	}
 
-	return rpc.MustNewServer("node", map[string]interface{}{
-		"node": &api{managers},
-	})
+	if conf := cfg.HashRing.Snapshot; len(conf.File) > 0 {
+		go persistRingSnapshots(managers, conf.File, conf.Interval)
+	}
+
+	if loader != nil {
+		// synchronize managed nodes with the latest configurations on reload
+		reload.Register("node_manager", func() error {
+			groupConf, err := loader()
+			if err != nil {
+				return err
+			}
+
+			for grp, m := range managers {
+				if conf, ok := groupConf[grp]; ok {
+					added, removed := m.Sync(conf.Nodes)
+					sparesAdded, sparesRemoved := m.SyncSpares(conf.Spares)
+					logrus.WithFields(logrus.Fields{
+						"group": grp, "added": added, "removed": removed,
+						"sparesAdded": sparesAdded, "sparesRemoved": sparesRemoved,
+					}).Info("Node manager synchronized with reloaded configurations")
+				}
+			}
+
+			return nil
+		})
+	}
+
+	return &api{managers}
 }
 
 // api node management RPC APIs.
@@ -23,16 +68,25 @@ type api struct {
 	managers map[Group]*Manager
 }
 
-func (api *api) Add(group Group, url string) {
-	if m, ok := api.managers[group]; ok {
-		m.Add(url)
+// Add adds node to group, and returns error if failed to construct, in which case the node
+// is quarantined and retried in the background.
+func (api *api) Add(group Group, url string) error {
+	m, ok := api.managers[group]
+	if !ok {
+		return errors.Errorf("invalid group %v", group)
 	}
+
+	return m.Add(url)
 }
 
-func (api *api) Remove(group Group, url string) {
-	if m, ok := api.managers[group]; ok {
-		m.Remove(url)
+// Remove removes the node from group, and returns the teardown report.
+func (api *api) Remove(group Group, url string) (*TeardownReport, error) {
+	m, ok := api.managers[group]
+	if !ok {
+		return nil, errors.Errorf("invalid group %v", group)
 	}
+
+	return m.Remove(url)
 }
 
 // List returns the URL list of all nodes.
@@ -51,6 +105,17 @@ func (api *api) List(group Group) []string {
 	return nodes
 }
 
+// HealthyList returns the URL list of all healthy nodes, which could be used for
+// client side load balancing and failover.
+func (api *api) HealthyList(group Group) []string {
+	m, ok := api.managers[group]
+	if !ok {
+		return nil
+	}
+
+	return healthyUrls(m)
+}
+
 func (api *api) Status(group Group, url *string) (res []Status) {
 	mgr := api.managers[group]
 	if mgr == nil { // no group found
@@ -93,3 +158,139 @@ func (api *api) Route(group Group, key hexutil.Bytes) string {
 
 	return ""
 }
+
+// RouteByHeight routes the specified key to any node whose synced height covers the
+// specified height, and return the node URL. Note, nil height stands for the latest
+// height, in which case nodes lagging behind too much will be avoided.
+func (api *api) RouteByHeight(group Group, key hexutil.Bytes, height *hexutil.Uint64) string {
+	if m, ok := api.managers[group]; ok {
+		return m.RouteByHeight(key, (*uint64)(height))
+	}
+
+	return ""
+}
+
+// Quarantined returns nodes of group failed to construct, which are retried in the background.
+func (api *api) Quarantined(group Group) []QuarantinedNode {
+	if m, ok := api.managers[group]; ok {
+		return m.Quarantined()
+	}
+
+	return nil
+}
+
+// Spares returns the URLs of warm spare nodes and whether activated.
+func (api *api) Spares(group Group) map[string]bool {
+	if m, ok := api.managers[group]; ok {
+		return m.Spares()
+	}
+
+	return nil
+}
+
+// Drain removes the specified node from hash ring, but still keeps it monitored.
+func (api *api) Drain(group Group, url string) bool {
+	if m, ok := api.managers[group]; ok {
+		return m.Drain(url)
+	}
+
+	return false
+}
+
+// Undrain adds the drained node into hash ring again.
+func (api *api) Undrain(group Group, url string) bool {
+	if m, ok := api.managers[group]; ok {
+		return m.Undrain(url)
+	}
+
+	return false
+}
+
+// SetMaintenance puts the specified node under maintenance in all groups, so that it is
+// excluded from routing but still monitored. It returns the groups that the node belongs to.
+func (api *api) SetMaintenance(url, reason string) []Group {
+	var groups []Group
+
+	for grp, m := range api.managers {
+		if m.SetMaintenance(url, reason) {
+			groups = append(groups, grp)
+		}
+	}
+
+	return groups
+}
+
+// ClearMaintenance brings the node under maintenance back to routing in all groups. It
+// returns the groups that the node was under maintenance.
+func (api *api) ClearMaintenance(url string) []Group {
+	var groups []Group
+
+	for grp, m := range api.managers {
+		if m.ClearMaintenance(url) {
+			groups = append(groups, grp)
+		}
+	}
+
+	return groups
+}
+
+// Maintenance returns nodes under maintenance of all groups.
+func (api *api) Maintenance() map[Group]map[string]Maintenance {
+	result := make(map[Group]map[string]Maintenance)
+
+	for grp, m := range api.managers {
+		if nodes := m.Maintenance(); len(nodes) > 0 {
+			result[grp] = nodes
+		}
+	}
+
+	return result
+}
+
+// RoutingTable returns the number of hash ring partitions owned by each node.
+func (api *api) RoutingTable(group Group) map[string]int {
+	if m, ok := api.managers[group]; ok {
+		return m.RoutingTable()
+	}
+
+	return nil
+}
+
+// Partitions returns the key space ownership of nodes in hash ring, e.g. partition counts
+// and average load.
+func (api *api) Partitions(group Group) *RingPartitions {
+	if m, ok := api.managers[group]; ok {
+		return m.Partitions()
+	}
+
+	return nil
+}
+
+// FlushCaches flushes the cached routes of the specified group, or all groups if not specified.
+func (api *api) FlushCaches(group *Group) {
+	for grp, m := range api.managers {
+		if group == nil || *group == grp {
+			m.FlushCache()
+		}
+	}
+}
+
+// SnapshotRing returns the partition layout of hash ring of the specified group.
+func (api *api) SnapshotRing(group Group) *RingSnapshot {
+	if m, ok := api.managers[group]; ok {
+		return m.SnapshotRing()
+	}
+
+	return nil
+}
+
+// RestoreRing restores the partition layout of hash ring from the specified snapshot,
+// e.g. copied from another gateway replica.
+func (api *api) RestoreRing(snapshot RingSnapshot) error {
+	m, ok := api.managers[snapshot.Group]
+	if !ok {
+		return errors.Errorf("group %v not found", snapshot.Group)
+	}
+
+	return m.RestoreRing(&snapshot)
+}
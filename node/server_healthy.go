@@ -0,0 +1,74 @@
+package node
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/scroll-tech/rpc-gateway/util/rpc/handlers"
+)
+
+// healthyEndpointsPath is the HTTP path to query healthy node endpoints, e.g.
+// `GET /healthy?group=ethhttp&format=text`.
+const healthyEndpointsPath = "/healthy"
+
+// newHealthyEndpointsMiddleware creates a middleware to serve healthy node endpoints
+// in plain HTTP, so that internal consumers could do simple client side failover
+// without a full load balancer.
+func newHealthyEndpointsMiddleware(managers map[Group]*Manager) handlers.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet || r.URL.Path != healthyEndpointsPath {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			serveHealthyEndpoints(w, r, managers)
+		})
+	}
+}
+
+func serveHealthyEndpoints(w http.ResponseWriter, r *http.Request, managers map[Group]*Manager) {
+	query := r.URL.Query()
+
+	group := Group(query.Get("group"))
+	m, ok := managers[group]
+	if !ok {
+		http.Error(w, "unknown node group", http.StatusNotFound)
+		return
+	}
+
+	urls := healthyUrls(m)
+
+	// response should always be fresh for failover
+	w.Header().Set("Cache-Control", "no-store")
+
+	var body []byte
+	if query.Get("format") == "text" {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		body = []byte(strings.Join(urls, "\n"))
+	} else {
+		w.Header().Set("Content-Type", "application/json")
+		body, _ = json.Marshal(urls)
+	}
+
+	if len(urls) == 0 {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+
+	w.Write(body)
+}
+
+// healthyUrls returns the sorted URL list of all healthy nodes of the specified manager.
+func healthyUrls(m *Manager) []string {
+	urls := []string{}
+
+	for _, n := range m.ListHealthy() {
+		urls = append(urls, n.Url())
+	}
+
+	sort.Strings(urls)
+
+	return urls
+}
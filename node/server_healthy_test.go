@@ -0,0 +1,61 @@
+package node
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/scroll-tech/rpc-gateway/util/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHealthyEndpointsMiddleware(t *testing.T) {
+	nf := MockNodeFactory(mock.NewChain(mock.ChainConfig{ChainId: 1337, Height: 100}))
+
+	m := NewManager(GroupEthHttp, nf, []string{"http://127.0.0.2:8545", "http://127.0.0.1:8545"})
+	defer m.Close()
+
+	empty := NewManager(GroupEthWs, nf, nil)
+	defer empty.Close()
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+	handler := newHealthyEndpointsMiddleware(map[Group]*Manager{
+		GroupEthHttp: m,
+		GroupEthWs:   empty,
+	})(next)
+
+	tests := []struct {
+		method      string
+		target      string
+		status      int
+		contentType string
+		body        string
+	}{
+		{http.MethodGet, "/healthy?group=ethhttp", http.StatusOK, "application/json",
+			`["http://127.0.0.1:8545","http://127.0.0.2:8545"]`},
+		{http.MethodGet, "/healthy?group=ethhttp&format=text", http.StatusOK, "text/plain; charset=utf-8",
+			"http://127.0.0.1:8545\nhttp://127.0.0.2:8545"},
+		// none healthy node
+		{http.MethodGet, "/healthy?group=ethws", http.StatusServiceUnavailable, "application/json", `[]`},
+		{http.MethodGet, "/healthy?group=cfxhttp", http.StatusNotFound, "text/plain; charset=utf-8",
+			"unknown node group\n"},
+		// delegated to next handler
+		{http.MethodPost, "/healthy?group=ethhttp", http.StatusTeapot, "", ""},
+		{http.MethodGet, "/", http.StatusTeapot, "", ""},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(tt.method, tt.target, nil))
+
+		assert.Equal(t, tt.status, w.Code, tt.target)
+		assert.Equal(t, tt.contentType, w.Header().Get("Content-Type"), tt.target)
+		assert.Equal(t, tt.body, w.Body.String(), tt.target)
+	}
+
+	// unhealthy node removed from hash ring
+	m.hashRing.Remove("127.0.0.2:8545")
+	assert.Equal(t, []string{"http://127.0.0.1:8545"}, healthyUrls(m))
+}
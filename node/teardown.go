@@ -0,0 +1,27 @@
+package node
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// teardownTimeout is the max duration to wait for health monitor to stop when node closed.
+const teardownTimeout = 10 * time.Second
+
+var errNodeNotFound = errors.New("node not found")
+
+// TeardownReport reports resources freed and pending requests aborted when node closed.
+type TeardownReport struct {
+	Node string `json:"node"`
+	// whether health monitor stopped in time
+	MonitorStopped bool `json:"monitorStopped"`
+	// number of status metrics unregistered
+	MetricsFreed int `json:"metricsFreed"`
+	// whether client connections closed
+	ConnsClosed bool `json:"connsClosed"`
+	// number of pending heartbeat requests aborted
+	PendingAborted int64 `json:"pendingAborted"`
+	// time elapsed to tear down
+	Elapsed time.Duration `json:"elapsed"`
+}
@@ -0,0 +1,49 @@
+package node
+
+import (
+	"testing"
+
+	"github.com/scroll-tech/rpc-gateway/util/mock"
+	"github.com/scroll-tech/rpc-gateway/util/rpc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManagerRemove(t *testing.T) {
+	nf := MockNodeFactory(mock.NewChain(mock.ChainConfig{ChainId: 1337, Height: 100}))
+	m := NewManager(GroupEthHttp, nf, []string{"http://127.0.0.1:8545", "http://127.0.0.2:8545"})
+	defer m.Close()
+
+	tests := []struct {
+		url       string
+		err       error
+		remaining []string
+	}{
+		{"http://127.0.0.1:8545", nil, []string{"127.0.0.2:8545"}},
+		// already removed
+		{"http://127.0.0.1:8545", errNodeNotFound, []string{"127.0.0.2:8545"}},
+		{"http://127.0.0.3:8545", errNodeNotFound, []string{"127.0.0.2:8545"}},
+		{"http://127.0.0.2:8545", nil, nil},
+	}
+
+	for _, tt := range tests {
+		report, err := m.Remove(tt.url)
+		assert.Equal(t, tt.err, err, tt.url)
+
+		if tt.err == nil {
+			// torn down synchronously
+			assert.Equal(t, rpc.Url2NodeName(tt.url), report.Node)
+			assert.True(t, report.MonitorStopped)
+			assert.True(t, report.ConnsClosed)
+		} else {
+			assert.Nil(t, report)
+		}
+
+		var remaining []string
+		for _, n := range m.List() {
+			remaining = append(remaining, n.Name())
+		}
+
+		assert.ElementsMatch(t, tt.remaining, remaining, tt.url)
+		assert.Equal(t, len(tt.remaining), len(m.ListHealthy()), tt.url)
+	}
+}
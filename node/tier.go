@@ -0,0 +1,47 @@
+package node
+
+import (
+	"github.com/pkg/errors"
+)
+
+// TierConfig splits evm space node group into hot and cold tiers by block age, where the group
+// only has recent nodes (e.g. pruned and fast), and requests of blocks older than the pruning
+// horizon are routed to the full-history group.
+type TierConfig struct {
+	// group of recent nodes, e.g. `ethhttp`
+	Group string
+	// group of full-history nodes, e.g. a config-driven group without methods
+	History string
+	// number of recent blocks kept by recent nodes
+	Horizon uint64
+}
+
+// tiers is the hot/cold tiers of the default evm chain, keyed by group of recent nodes.
+var tiers = make(map[Group]TierConfig)
+
+// initTiers validates tier configurations, which requires URL configurations initialized.
+func initTiers(confs []TierConfig) error {
+	for _, c := range confs {
+		if c.Horizon == 0 {
+			return errors.Errorf("pruning horizon of group %v should be positive", c.Group)
+		}
+
+		if _, ok := ethUrlCfg[Group(c.Group)]; !ok {
+			return errors.Errorf("evm space group %v not found", c.Group)
+		}
+
+		if _, ok := ethUrlCfg[Group(c.History)]; !ok || c.History == c.Group {
+			return errors.Errorf("invalid full-history group %v of group %v", c.History, c.Group)
+		}
+
+		tiers[Group(c.Group)] = c
+	}
+
+	return nil
+}
+
+// TierOf returns the hot/cold tiers of the specified evm space group if any.
+func TierOf(group Group) (TierConfig, bool) {
+	conf, ok := tiers[group]
+	return conf, ok
+}
@@ -0,0 +1,152 @@
+package rpc
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/scroll-tech/rpc-gateway/rpc/cache"
+	"github.com/scroll-tech/rpc-gateway/rpc/slowlog"
+	"github.com/scroll-tech/rpc-gateway/util/apikey"
+	"github.com/scroll-tech/rpc-gateway/util/rate"
+	"github.com/scroll-tech/rpc-gateway/util/rpc"
+	"github.com/scroll-tech/rpc-gateway/util/usage"
+)
+
+const adminRpcServerName = "admin_rpc"
+
+// MustNewAdminServer creates administrative RPC server, which should only be exposed to
+// operators, e.g. listen on localhost.
+func MustNewAdminServer() *rpc.Server {
+	return rpc.MustNewServer(adminRpcServerName, map[string]interface{}{
+		"admin": &adminAPI{},
+	})
+}
+
+// adminAPI provides administrative RPC APIs for operations.
+type adminAPI struct{}
+
+// PurgeCache expires all the cached RPC responses in memory.
+func (api *adminAPI) PurgeCache() {
+	cache.PurgeAll()
+}
+
+// RateLimitStrategies returns all the available rate limit strategies of the specified
+// space, `cfx` or `eth`.
+func (api *adminAPI) RateLimitStrategies(space string) ([]*rate.Strategy, error) {
+	switch space {
+	case "cfx":
+		return rate.DefaultRegistryCfx.Strategies(), nil
+	case "eth":
+		return rate.DefaultRegistryEth.Strategies(), nil
+	default:
+		return nil, errors.Errorf("invalid space %v", space)
+	}
+}
+
+// RevokeKeys evicts the specified limit keys from cache of both spaces, so that revoked keys
+// stop working immediately. It could be used as revocation webhook.
+func (api *adminAPI) RevokeKeys(keys []string) int {
+	return rate.DefaultRegistryCfx.Revoke(keys...) + rate.DefaultRegistryEth.Revoke(keys...)
+}
+
+var errApiKeyDisabled = errors.New("api key store not enabled")
+
+// CreateApiKey issues a new API key bound to the specified rate limit strategy.
+func (api *adminAPI) CreateApiKey(name string, sid uint32) (*apikey.Key, error) {
+	m, ok := apikey.Default()
+	if !ok {
+		return nil, errApiKeyDisabled
+	}
+
+	return m.Create(name, sid)
+}
+
+// RotateApiKey issues a new API key with the same attributes, and expires the old one after
+// the grace period (in seconds) if specified, otherwise immediately.
+func (api *adminAPI) RotateApiKey(key string, graceSecs *uint64) (*apikey.Key, error) {
+	m, ok := apikey.Default()
+	if !ok {
+		return nil, errApiKeyDisabled
+	}
+
+	var grace time.Duration
+	if graceSecs != nil {
+		grace = time.Duration(*graceSecs) * time.Second
+	}
+
+	k, err := m.Rotate(key, grace)
+	if err != nil {
+		return nil, err
+	}
+
+	if grace == 0 {
+		api.RevokeKeys([]string{key})
+	}
+
+	return k, nil
+}
+
+// RevokeApiKey revokes the API key, and evicts it from limit key cache of both spaces.
+func (api *adminAPI) RevokeApiKey(key string) error {
+	m, ok := apikey.Default()
+	if !ok {
+		return errApiKeyDisabled
+	}
+
+	if err := m.Revoke(key); err != nil {
+		return err
+	}
+
+	api.RevokeKeys([]string{key})
+
+	return nil
+}
+
+// KillSwitches returns all the active kill switches, including those from config.
+func (api *adminAPI) KillSwitches() []KillSwitchConfig {
+	return defaultKillSwitches.list()
+}
+
+// SetKillSwitch turns on the kill switch to disable RPC methods gateway-wide or for specific
+// node groups instantly, which overrides the configured one of the same name.
+func (api *adminAPI) SetKillSwitch(conf KillSwitchConfig) error {
+	return defaultKillSwitches.set(conf)
+}
+
+// ClearKillSwitch turns off the kill switch set by administrative RPC. Note, configured kill
+// switches could only be turned off by config reload.
+func (api *adminAPI) ClearKillSwitch(name string) bool {
+	return defaultKillSwitches.clear(name)
+}
+
+// SlowQueries returns the top offenders of heavy requests by method and node within the
+// window, which defaults to 1 hour, ordered by total duration.
+func (api *adminAPI) SlowQueries(windowSecs *uint64, limit *int) []*slowlog.Offender {
+	window := time.Hour
+	if windowSecs != nil {
+		window = time.Duration(*windowSecs) * time.Second
+	}
+
+	top := 20
+	if limit != nil {
+		top = *limit
+	}
+
+	return slowLog.Top(window, top)
+}
+
+// SubscriptionUsage returns the current websocket connections and subscriptions along with
+// quotas of all API keys, or the specified one if any.
+func (api *adminAPI) SubscriptionUsage(key *string) []SubscriptionUsage {
+	if key == nil {
+		return defaultSubscriptionQuotas.snapshot("")
+	}
+
+	return defaultSubscriptionQuotas.snapshot(*key)
+}
+
+// KeyUsage returns the daily usages of API key within the day range inclusively, e.g.
+// `2022-10-01`, which defaults to today if not specified.
+func (api *adminAPI) KeyUsage(key string, dayFrom, dayTo *string) ([]*usage.DailyUsage, error) {
+	return queryUsage(key, dayFrom, dayTo)
+}
@@ -0,0 +1,347 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/pkg/errors"
+	"github.com/scroll-tech/rpc-gateway/util/metrics"
+	"github.com/scroll-tech/rpc-gateway/util/reload"
+	"github.com/scroll-tech/rpc-gateway/util/rpc/handlers"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/time/rate"
+)
+
+// maxAnomalyClients is the max number of clients tracked for anomaly detection.
+const maxAnomalyClients = 65536
+
+// number of idle windows before client traffic untracked
+const anomalyIdleWindows = 10
+
+// kinds of anomalous traffic
+const (
+	anomalySpike     = "spike"     // sudden traffic spike against baseline
+	anomalyMethodMix = "methodMix" // sudden flood of sensitive methods, e.g. `debug_*`
+)
+
+// AnomalyConfig configurations of traffic anomaly detection, which flags sudden per API key
+// or per IP traffic spikes and unusual method mixes, and applies temporary stricter limits.
+type AnomalyConfig struct {
+	Enabled bool
+	// window to count traffic of clients and analyze periodically
+	Window time.Duration `default:"1m"`
+	// smoothing factor of traffic baseline in exponential moving average, in range (0, 1]
+	Smoothing float64 `default:"0.2"`
+	// min number of calls within window to evaluate traffic spike
+	MinCalls int64 `default:"600"`
+	// calls within window exceeding baseline by the factor regarded as spike, while clients
+	// without baseline are compared with min calls instead
+	SpikeFactor float64 `default:"5"`
+	// methods regarded as sensitive, which supports `*` suffix as wildcard
+	SensitiveMethods []string `default:"[debug_*,trace_*]"`
+	// min number of sensitive method calls within window to evaluate method mix
+	MinSensitiveCalls int64 `default:"60"`
+	// ratio of sensitive method calls within window regarded as unusual method mix, unless
+	// the baseline ratio of client is already beyond
+	SensitiveRatio float64 `default:"0.5"`
+	// temporary stricter limits applied to anomalous clients
+	Penalty struct {
+		// max number of calls per second
+		Rate  float64 `default:"5"`
+		Burst int     `default:"10"`
+		// duration of stricter limits
+		Duration time.Duration `default:"10m"`
+	}
+}
+
+// clientTraffic is the traffic of client within the current window along with baselines.
+type clientTraffic struct {
+	calls, sensitive int64
+
+	baseline      float64 // calls per window
+	baselineRatio float64 // ratio of sensitive method calls
+	hasBaseline   bool
+	idle          int // number of idle windows
+
+	throttle       *rate.Limiter
+	throttledUntil time.Time
+	throttledFor   string // anomaly kind
+}
+
+// anomalyThrottledError is returned for requests throttled by temporary stricter limits.
+type anomalyThrottledError struct {
+	kind       string
+	retryAfter time.Duration
+}
+
+func (e *anomalyThrottledError) Error() string {
+	return fmt.Sprintf("too many requests, throttled due to anomalous traffic (%v)", e.kind)
+}
+
+func (e *anomalyThrottledError) ErrorData() interface{} {
+	return map[string]interface{}{
+		"anomaly":    e.kind,
+		"retryAfter": e.retryAfter.Round(time.Second).String(),
+	}
+}
+
+type anomalyDetector struct {
+	AnomalyConfig
+
+	mu      sync.Mutex
+	clients map[string]*clientTraffic // client => traffic
+}
+
+func newAnomalyDetector(conf AnomalyConfig) *anomalyDetector {
+	return &anomalyDetector{
+		AnomalyConfig: conf,
+		clients:       make(map[string]*clientTraffic),
+	}
+}
+
+// observe counts the RPC call of client, and returns error if throttled by stricter limits.
+func (d *anomalyDetector) observe(client, method string, now time.Time) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	t, ok := d.clients[client]
+	if !ok {
+		if len(d.clients) >= maxAnomalyClients {
+			return nil
+		}
+
+		t = &clientTraffic{}
+		d.clients[client] = t
+	}
+
+	t.calls++
+	if matchMethods(d.SensitiveMethods, method) {
+		t.sensitive++
+	}
+
+	if t.throttle == nil {
+		return nil
+	}
+
+	if now.After(t.throttledUntil) {
+		d.release(client, t)
+		return nil
+	}
+
+	if t.throttle.AllowN(now, 1) {
+		return nil
+	}
+
+	return &anomalyThrottledError{kind: t.throttledFor, retryAfter: t.throttledUntil.Sub(now)}
+}
+
+// detect returns the anomaly kind of client traffic within the current window if any.
+func (d *anomalyDetector) detect(t *clientTraffic) (string, bool) {
+	if t.calls >= d.MinCalls {
+		threshold := d.SpikeFactor * float64(d.MinCalls)
+		if t.hasBaseline {
+			threshold = d.SpikeFactor * t.baseline
+		}
+
+		if float64(t.calls) > threshold {
+			return anomalySpike, true
+		}
+	}
+
+	if t.sensitive >= d.MinSensitiveCalls {
+		ratio := float64(t.sensitive) / float64(t.calls)
+		if ratio >= d.SensitiveRatio && (!t.hasBaseline || t.baselineRatio < d.SensitiveRatio) {
+			return anomalyMethodMix, true
+		}
+	}
+
+	return "", false
+}
+
+// analyze detects anomalous traffic of all clients within the current window, and then starts
+// a new window.
+func (d *anomalyDetector) analyze(now time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for client, t := range d.clients {
+		if kind, ok := d.detect(t); ok {
+			d.punish(client, t, kind, now)
+		} else {
+			if t.throttle != nil && now.After(t.throttledUntil) {
+				d.release(client, t)
+			}
+
+			// anomalous traffic excluded from baseline
+			if t.calls > 0 {
+				d.updateBaseline(t)
+			}
+		}
+
+		if t.calls > 0 {
+			t.idle = 0
+		} else if t.idle++; t.idle >= anomalyIdleWindows && t.throttle == nil {
+			delete(d.clients, client)
+		}
+
+		t.calls, t.sensitive = 0, 0
+	}
+
+	d.updateMetrics()
+}
+
+func (d *anomalyDetector) updateBaseline(t *clientTraffic) {
+	ratio := float64(t.sensitive) / float64(t.calls)
+
+	if t.hasBaseline {
+		t.baseline += d.Smoothing * (float64(t.calls) - t.baseline)
+		t.baselineRatio += d.Smoothing * (ratio - t.baselineRatio)
+	} else {
+		t.baseline, t.baselineRatio, t.hasBaseline = float64(t.calls), ratio, true
+	}
+}
+
+// punish applies temporary stricter limits to client, which should be called with lock held.
+func (d *anomalyDetector) punish(client string, t *clientTraffic, kind string, now time.Time) {
+	metrics.Registry.RPC.Anomaly(kind).Mark(1)
+
+	logrus.WithFields(logrus.Fields{
+		"client":        client,
+		"anomaly":       kind,
+		"calls":         t.calls,
+		"sensitive":     t.sensitive,
+		"baseline":      t.baseline,
+		"baselineRatio": t.baselineRatio,
+		"penalty":       d.Penalty.Duration,
+	}).Warn("Anomalous traffic detected, client throttled temporarily")
+
+	if t.throttle == nil {
+		t.throttle = rate.NewLimiter(rate.Limit(d.Penalty.Rate), d.Penalty.Burst)
+	}
+
+	t.throttledUntil = now.Add(d.Penalty.Duration)
+	t.throttledFor = kind
+}
+
+// release removes temporary stricter limits of client, which should be called with lock held.
+func (d *anomalyDetector) release(client string, t *clientTraffic) {
+	t.throttle = nil
+	t.throttledFor = ""
+
+	logrus.WithField("client", client).Info("Client released from anomaly throttling")
+}
+
+func (d *anomalyDetector) updateMetrics() {
+	var throttled int64
+	for _, t := range d.clients {
+		if t.throttle != nil {
+			throttled++
+		}
+	}
+
+	metrics.Registry.RPC.AnomalyThrottled().Update(throttled)
+}
+
+// anomalyDetection is the anomaly detector in use, which could be changed at runtime.
+var anomalyDetection atomic.Value
+
+func init() {
+	detector, err := loadAnomalyDetector()
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to load anomaly detection config")
+	}
+
+	anomalyDetection.Store(detector)
+
+	reload.Register("rpc_anomaly", func() error {
+		detector, err := loadAnomalyDetector()
+		if err != nil {
+			return err
+		}
+
+		anomalyDetection.Store(detector)
+		return nil
+	})
+
+	go analyzeAnomaliesPeriodically()
+}
+
+func loadAnomalyDetector() (*anomalyDetector, error) {
+	var conf AnomalyConfig
+	if err := viper.UnmarshalKey("rpc.anomaly", &conf); err != nil {
+		return nil, err
+	}
+
+	if !conf.Enabled {
+		return newAnomalyDetector(conf), nil
+	}
+
+	if conf.Window <= 0 || conf.Penalty.Duration <= 0 {
+		return nil, errors.New("anomaly window and penalty duration should be positive")
+	}
+
+	if conf.Smoothing <= 0 || conf.Smoothing > 1 {
+		return nil, errors.New("anomaly smoothing factor should be in range (0, 1]")
+	}
+
+	return newAnomalyDetector(conf), nil
+}
+
+// analyzeAnomaliesPeriodically analyzes traffic of the anomaly detector in use per window.
+func analyzeAnomaliesPeriodically() {
+	for {
+		detector := anomalyDetection.Load().(*anomalyDetector)
+
+		window := detector.Window
+		if window <= 0 {
+			window = time.Minute
+		}
+
+		time.Sleep(window)
+
+		if detector.Enabled {
+			detector.analyze(time.Now())
+		}
+	}
+}
+
+// anomalyClient returns the client of RPC request, which is identified by API key if any,
+// otherwise by IP address.
+func anomalyClient(ctx context.Context) (string, bool) {
+	if token, ok := handlers.GetAccessTokenFromContext(ctx); ok {
+		return "key:" + token, true
+	}
+
+	if ip, ok := handlers.GetIPAddressFromContext(ctx); ok {
+		return "ip:" + handlers.ClientIPKey(ip), true
+	}
+
+	return "", false
+}
+
+// anomalyMiddleware counts traffic of clients for anomaly detection, and throttles clients
+// with anomalous traffic detected by temporary stricter limits.
+func anomalyMiddleware(next rpc.HandleCallMsgFunc) rpc.HandleCallMsgFunc {
+	return func(ctx context.Context, msg *rpc.JsonRpcMessage) *rpc.JsonRpcMessage {
+		detector := anomalyDetection.Load().(*anomalyDetector)
+		if !detector.Enabled {
+			return next(ctx, msg)
+		}
+
+		client, ok := anomalyClient(ctx)
+		if !ok {
+			return next(ctx, msg)
+		}
+
+		if err := detector.observe(client, msg.Method, time.Now()); err != nil {
+			return msg.ErrorResponse(err)
+		}
+
+		return next(ctx, msg)
+	}
+}
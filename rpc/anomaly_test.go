@@ -0,0 +1,69 @@
+package rpc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestAnomalyDetector() *anomalyDetector {
+	conf := AnomalyConfig{
+		Enabled:           true,
+		Window:            time.Minute,
+		Smoothing:         0.5,
+		MinCalls:          10,
+		SpikeFactor:       3,
+		SensitiveMethods:  []string{"debug_*"},
+		MinSensitiveCalls: 5,
+		SensitiveRatio:    0.5,
+	}
+	conf.Penalty.Rate = 1
+	conf.Penalty.Burst = 1
+	conf.Penalty.Duration = time.Minute
+
+	return newAnomalyDetector(conf)
+}
+
+func TestAnomalyDetectorSpike(t *testing.T) {
+	detector := newTestAnomalyDetector()
+	now := time.Now()
+
+	// baseline established
+	for i := 0; i < 20; i++ {
+		assert.Nil(t, detector.observe("ip:127.0.0.1", "eth_call", now))
+	}
+	detector.analyze(now)
+
+	// spike
+	for i := 0; i < 70; i++ {
+		assert.Nil(t, detector.observe("ip:127.0.0.1", "eth_call", now))
+	}
+	detector.analyze(now)
+
+	// throttled by stricter limits
+	assert.Nil(t, detector.observe("ip:127.0.0.1", "eth_call", now))
+	assert.NotNil(t, detector.observe("ip:127.0.0.1", "eth_call", now))
+
+	// other clients not affected
+	assert.Nil(t, detector.observe("ip:127.0.0.2", "eth_call", now))
+	assert.Nil(t, detector.observe("ip:127.0.0.2", "eth_call", now))
+
+	// released once penalty expired
+	assert.Nil(t, detector.observe("ip:127.0.0.1", "eth_call", now.Add(2*time.Minute)))
+	assert.Nil(t, detector.observe("ip:127.0.0.1", "eth_call", now.Add(2*time.Minute)))
+}
+
+func TestAnomalyDetectorMethodMix(t *testing.T) {
+	detector := newTestAnomalyDetector()
+	now := time.Now()
+
+	for i := 0; i < 8; i++ {
+		detector.observe("key:abc", "eth_call", now)
+		detector.observe("key:abc", "debug_traceTransaction", now)
+	}
+
+	kind, ok := detector.detect(detector.clients["key:abc"])
+	assert.True(t, ok)
+	assert.Equal(t, anomalyMethodMix, kind)
+}
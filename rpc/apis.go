@@ -5,6 +5,7 @@ import (
 	"github.com/openweb3/web3go"
 	"github.com/pkg/errors"
 	"github.com/scroll-tech/rpc-gateway/node"
+	"github.com/scroll-tech/rpc-gateway/rpc/cache"
 	"github.com/scroll-tech/rpc-gateway/rpc/cfxbridge"
 	"github.com/scroll-tech/rpc-gateway/rpc/handler"
 	"github.com/scroll-tech/rpc-gateway/util/metrics/service"
@@ -95,21 +96,29 @@ func nativeSpaceApis(
 
 // evmSpaceApis returns the collection of built-in RPC APIs for EVM space.
 func evmSpaceApis(clientProvider *node.EthClientProvider, option ...EthAPIOption) ([]API, error) {
+	ethCache := cache.Eth(clientProvider.Chain())
+	eth := mustNewEthAPI(clientProvider, option...)
+
 	return []API{
 		{
 			Namespace: "eth",
 			Version:   "1.0",
-			Service:   mustNewEthAPI(clientProvider, option...),
+			Service:   eth,
+			Public:    true,
+		}, {
+			Namespace: "gateway",
+			Version:   "1.0",
+			Service:   newGatewayAPI(eth),
 			Public:    true,
 		}, {
 			Namespace: "web3",
 			Version:   "1.0",
-			Service:   &web3API{},
+			Service:   &web3API{ethCache},
 			Public:    true,
 		}, {
 			Namespace: "net",
 			Version:   "1.0",
-			Service:   &netAPI{},
+			Service:   &netAPI{ethCache},
 			Public:    true,
 		}, {
 			Namespace: "trace",
@@ -0,0 +1,128 @@
+package rpc
+
+import (
+	"context"
+	"sort"
+	"sync/atomic"
+
+	"github.com/scroll-tech/rpc-gateway/util/feature"
+	"github.com/scroll-tech/rpc-gateway/util/lifecycle"
+	"github.com/scroll-tech/rpc-gateway/util/rpc/handlers"
+)
+
+// BuildInfo is the self-describing build, version and runtime info of gateway, so that fleet
+// drift could be audited programmatically via `/buildinfo` or `gateway_version`.
+type BuildInfo struct {
+	// build, version and runtime info of the running binary
+	Binary interface{} `json:"binary"`
+	// load balancer mode of evm space
+	LoadBalancerMode string `json:"loadBalancerMode"`
+	// configurable middlewares and whether enabled
+	Middlewares map[string]bool `json:"middlewares"`
+	// enabled feature flags
+	Features []string `json:"features"`
+	// subsystems managed by lifecycle manager
+	Subsystems []string `json:"subsystems"`
+}
+
+// binaryInfoProvider provides the build info of the running binary, which is set by command.
+var binaryInfoProvider atomic.Value // func() interface{}
+
+func init() {
+	handlers.SetBuildInfoProvider(func() interface{} {
+		return currentBuildInfo()
+	})
+}
+
+// SetBinaryInfoProvider sets the provider of build, version and runtime info of the running binary.
+func SetBinaryInfoProvider(provider func() interface{}) {
+	binaryInfoProvider.Store(provider)
+}
+
+func currentBuildInfo() *BuildInfo {
+	info := BuildInfo{
+		LoadBalancerMode: loadBalancerMode.Load().(string),
+		Middlewares:      middlewareStates(),
+		Features:         []string{},
+		Subsystems:       []string{},
+	}
+
+	if provider, ok := binaryInfoProvider.Load().(func() interface{}); ok {
+		info.Binary = provider()
+	}
+
+	for _, flag := range feature.Flags() {
+		if flag.Enabled {
+			info.Features = append(info.Features, flag.Name)
+		}
+	}
+
+	for name := range lifecycle.Default.Status() {
+		info.Subsystems = append(info.Subsystems, name)
+	}
+
+	sort.Strings(info.Features)
+	sort.Strings(info.Subsystems)
+
+	return &info
+}
+
+// middlewareStates returns whether the configurable middlewares are enabled.
+func middlewareStates() map[string]bool {
+	states := make(map[string]bool)
+
+	if p, ok := concurrency.Load().(*concurrencyPolicy); ok {
+		states["concurrency"] = p.Enabled
+	}
+
+	if p, ok := nodeConcurrency.Load().(*nodeConcurrencyPolicy); ok {
+		states["nodeConcurrency"] = p.Enabled
+	}
+
+	if conf, ok := responseSize.Load().(*ResponseSizeConfig); ok {
+		states["responseSize"] = conf.Enabled
+	}
+
+	if conf, ok := timeouts.Load().(*TimeoutConfig); ok {
+		states["timeout"] = conf.Enabled
+	}
+
+	if p, ok := upstreamQuota.Load().(*upstreamQuotaPolicy); ok {
+		states["upstreamQuota"] = p.Enabled
+	} else {
+		states["upstreamQuota"] = false
+	}
+
+	if p, ok := logSampling.Load().(*logSampler); ok {
+		states["logSampling"] = p.Enabled
+	}
+
+	if p, ok := debugAnnotation.Load().(*debugAnnotationPolicy); ok {
+		states["debugAnnotation"] = p.Enabled
+	}
+
+	if p, ok := hedging.Load().(*hedgingPolicy); ok {
+		states["hedging"] = p.Enabled
+	}
+
+	if p, ok := quorum.Load().(*quorumPolicy); ok {
+		states["quorum"] = p.Enabled
+	}
+
+	if p, ok := shadow.Load().(*shadowPolicy); ok {
+		states["shadow"] = p.Enabled
+	}
+
+	exps, _ := experiments.Load().([]*experiment)
+	states["experiment"] = len(exps) > 0
+
+	states["killSwitch"] = len(defaultKillSwitches.list()) > 0
+	states["slowLog"] = slowLog != nil && slowLog.Enabled()
+
+	return states
+}
+
+// Version returns the build, version and runtime info of gateway.
+func (api *gatewayAPI) Version(ctx context.Context) (*BuildInfo, error) {
+	return currentBuildInfo(), nil
+}
@@ -0,0 +1,51 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/scroll-tech/rpc-gateway/util/rpc/handlers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildInfo(t *testing.T) {
+	tests := []struct {
+		binary interface{} // nil means provider not set
+	}{
+		{nil},
+		{map[string]interface{}{"version": "v1.0.0"}},
+	}
+
+	handler := handlers.BuildInfo(&handlers.BuildInfoConfig{Enabled: true, Path: "/buildinfo"})(http.NotFoundHandler())
+
+	for _, tt := range tests {
+		if tt.binary != nil {
+			binary := tt.binary
+			SetBinaryInfoProvider(func() interface{} { return binary })
+		}
+
+		info, err := (&gatewayAPI{}).Version(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, tt.binary, info.Binary)
+		assert.Equal(t, loadBalancerMode.Load().(string), info.LoadBalancerMode)
+		assert.NotNil(t, info.Features)
+		assert.NotNil(t, info.Subsystems)
+
+		for _, name := range []string{"killSwitch", "slowLog", "experiment", "upstreamQuota"} {
+			assert.Contains(t, info.Middlewares, name)
+		}
+
+		// served by build info endpoint
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/buildinfo", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var served BuildInfo
+		assert.NoError(t, json.NewDecoder(w.Body).Decode(&served))
+		assert.Equal(t, tt.binary, served.Binary)
+		assert.Equal(t, info.Middlewares, served.Middlewares)
+	}
+}
@@ -49,3 +49,10 @@ func (cache *CfxCache) GetClientVersion(cfx sdk.ClientOperator) (string, error)
 
 	return val.(string), nil
 }
+
+// Purge expires all cached values immediately.
+func (cache *CfxCache) Purge() {
+	cache.priceCache.purge()
+	cache.versionCache.purge()
+	cache.StatusCache.inner.purge()
+}
@@ -2,6 +2,7 @@ package cache
 
 import (
 	"math/big"
+	"sync"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common/hexutil"
@@ -12,6 +13,35 @@ import (
 
 var EthDefault = NewEth()
 
+var (
+	chainEthCaches   = make(map[string]*EthCache) // chain name => cache
+	chainEthCachesMu sync.Mutex
+)
+
+// Eth returns memory cache of the specified evm chain, so that RPC responses of different
+// chains will not be mixed up. Note, empty chain stands for the default chain.
+func Eth(chain string) *EthCache {
+	if len(chain) == 0 {
+		return EthDefault
+	}
+
+	chainEthCachesMu.Lock()
+	defer chainEthCachesMu.Unlock()
+
+	if c, ok := chainEthCaches[chain]; ok {
+		return c
+	}
+
+	c := NewEth()
+	if cfg := loadedConfig.Load(); cfg != nil {
+		c.applyConfig(cfg.(*config))
+	}
+
+	chainEthCaches[chain] = c
+
+	return c
+}
+
 // EthCache memory cache for some evm space RPC methods
 type EthCache struct {
 	netVersionCache    *expiryCache
@@ -92,3 +122,31 @@ func (cache *EthCache) GetBlockNumber(client *node.Web3goClient) (*hexutil.Big,
 
 	return (*hexutil.Big)(val.(*big.Int)), nil
 }
+
+// PurgeBlockNumber expires the cached block numbers of all nodes immediately, e.g. once new
+// head received.
+func (cache *EthCache) PurgeBlockNumber() {
+	cache.blockNumberCache.purge()
+}
+
+// Purge expires all cached values immediately.
+func (cache *EthCache) Purge() {
+	cache.netVersionCache.purge()
+	cache.clientVersionCache.purge()
+	cache.chainIdCache.purge()
+	cache.priceCache.purge()
+	cache.blockNumberCache.purge()
+}
+
+// PurgeAll expires all cached values of all evm chains immediately.
+func PurgeAll() {
+	EthDefault.Purge()
+	CfxDefault.Purge()
+
+	chainEthCachesMu.Lock()
+	defer chainEthCachesMu.Unlock()
+
+	for _, c := range chainEthCaches {
+		c.Purge()
+	}
+}
@@ -0,0 +1,65 @@
+package cache
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/scroll-tech/rpc-gateway/util/reload"
+	"github.com/sirupsen/logrus"
+)
+
+// config expiration durations of memory caches for RPC methods
+type config struct {
+	NetVersion    time.Duration `default:"1m"`
+	ClientVersion time.Duration `default:"1m"`
+	GasPrice      time.Duration `default:"3s"`
+	BlockNumber   time.Duration `default:"1s"`
+	Status        time.Duration `default:"1s"`
+}
+
+func init() {
+	var cfg config
+	viper.MustUnmarshalKey("rpc.cache", &cfg)
+	applyConfig(&cfg)
+
+	// cache TTLs could be changed at runtime
+	reload.Register("rpc_cache", func() error {
+		var cfg config
+		if err := viper.UnmarshalKey("rpc.cache", &cfg); err != nil {
+			return err
+		}
+
+		applyConfig(&cfg)
+		return nil
+	})
+}
+
+// loadedConfig is the latest applied configurations, which is used to initialize caches
+// created later.
+var loadedConfig atomic.Value
+
+func applyConfig(cfg *config) {
+	loadedConfig.Store(cfg)
+
+	EthDefault.applyConfig(cfg)
+
+	chainEthCachesMu.Lock()
+	for _, c := range chainEthCaches {
+		c.applyConfig(cfg)
+	}
+	chainEthCachesMu.Unlock()
+
+	CfxDefault.versionCache.setTimeout(cfg.ClientVersion)
+	CfxDefault.priceCache.setTimeout(cfg.GasPrice)
+	CfxDefault.StatusCache.inner.setTimeout(cfg.Status)
+
+	logrus.WithField("config", cfg).Debug("RPC cache configurations applied")
+}
+
+func (cache *EthCache) applyConfig(cfg *config) {
+	cache.netVersionCache.setTimeout(cfg.NetVersion)
+	cache.clientVersionCache.setTimeout(cfg.ClientVersion)
+	cache.priceCache.setTimeout(cfg.GasPrice)
+	cache.blockNumberCache.setTimeout(cfg.BlockNumber)
+}
@@ -17,16 +17,30 @@ type cacheValue struct {
 // expiryCache is used to cache value with specified expiration time.
 type expiryCache struct {
 	value   atomic.Value
-	timeout time.Duration
+	timeout int64 // expiration duration, which could be updated at runtime
 	mu      sync.Mutex
 }
 
 func newExpiryCache(timeout time.Duration) *expiryCache {
 	return &expiryCache{
-		timeout: timeout,
+		timeout: int64(timeout),
 	}
 }
 
+// setTimeout updates expiration duration, which takes effect for the next cache update.
+func (cache *expiryCache) setTimeout(timeout time.Duration) {
+	atomic.StoreInt64(&cache.timeout, int64(timeout))
+}
+
+func (cache *expiryCache) getTimeout() time.Duration {
+	return time.Duration(atomic.LoadInt64(&cache.timeout))
+}
+
+// purge expires the cached value immediately.
+func (cache *expiryCache) purge() {
+	cache.value.Store(cacheValue{})
+}
+
 func (cache *expiryCache) get() (interface{}, bool) {
 	return cache.getAt(time.Now())
 }
@@ -72,7 +86,7 @@ func (cache *expiryCache) getOrUpdateAt(time time.Time, updateFunc func() (inter
 	// update cache
 	cache.value.Store(cacheValue{
 		value:    val,
-		expireAt: time.Add(cache.timeout),
+		expireAt: time.Add(cache.getTimeout()),
 	})
 
 	return val, nil
@@ -81,19 +95,37 @@ func (cache *expiryCache) getOrUpdateAt(time time.Time, updateFunc func() (inter
 // nodeExpiryCaches is used for multiple nodes to cache data respectively.
 type nodeExpiryCaches struct {
 	node2Caches util.ConcurrentMap // node name => expiryCache
-	timeout     time.Duration
+	timeout     int64              // expiration duration, which could be updated at runtime
 }
 
 func newNodeExpiryCaches(timeout time.Duration) *nodeExpiryCaches {
 	return &nodeExpiryCaches{
-		timeout: timeout,
+		timeout: int64(timeout),
 	}
 }
 
+// setTimeout updates expiration duration for all nodes.
+func (caches *nodeExpiryCaches) setTimeout(timeout time.Duration) {
+	atomic.StoreInt64(&caches.timeout, int64(timeout))
+
+	caches.node2Caches.Range(func(key, value interface{}) bool {
+		value.(*expiryCache).setTimeout(timeout)
+		return true
+	})
+}
+
 func (caches *nodeExpiryCaches) getOrUpdate(node string, updateFunc func() (interface{}, error)) (interface{}, error) {
 	val, _ := caches.node2Caches.LoadOrStoreFn(node, func(interface{}) interface{} {
-		return newExpiryCache(caches.timeout)
+		return newExpiryCache(time.Duration(atomic.LoadInt64(&caches.timeout)))
 	})
 
 	return val.(*expiryCache).getOrUpdate(updateFunc)
 }
+
+// purge expires the cached values of all nodes immediately.
+func (caches *nodeExpiryCaches) purge() {
+	caches.node2Caches.Range(func(key, value interface{}) bool {
+		value.(*expiryCache).purge()
+		return true
+	})
+}
@@ -0,0 +1,61 @@
+package rpc
+
+import (
+	"context"
+	"time"
+
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/scroll-tech/rpc-gateway/node"
+	"github.com/scroll-tech/rpc-gateway/rpc/capture"
+	"github.com/scroll-tech/rpc-gateway/util/rpc/handlers"
+	"github.com/sirupsen/logrus"
+)
+
+// captureWriter samples RPC traffic into capture log, which could be converted into test
+// fixtures by the `test fixture` command.
+var captureWriter *capture.Writer
+
+func init() {
+	var conf capture.Config
+	viper.MustUnmarshalKey("rpc.capture", &conf)
+
+	var err error
+	if captureWriter, err = capture.NewWriter(conf); err != nil {
+		logrus.WithError(err).Fatal("Failed to init traffic capture")
+	}
+}
+
+func captureMiddleware(next rpc.HandleCallMsgFunc) rpc.HandleCallMsgFunc {
+	return func(ctx context.Context, msg *rpc.JsonRpcMessage) *rpc.JsonRpcMessage {
+		if !captureWriter.Sampled() {
+			return next(ctx, msg)
+		}
+
+		record := capture.Record{
+			Time:   time.Now(),
+			Method: msg.Method,
+			Params: msg.Params,
+		}
+
+		switch ctx.Value(ctxKeyClientProvider).(type) {
+		case *node.CfxClientProvider:
+			record.Space = "cfx"
+		case *node.EthClientProvider:
+			record.Space = "eth"
+		}
+
+		record.Chain, _ = ctx.Value(handlers.CtxKeyChain).(string)
+		record.ApiKey, _ = handlers.GetAccessTokenFromContext(ctx)
+		record.IP, _ = handlers.GetIPAddressFromContext(ctx)
+
+		resp := next(ctx, msg)
+		if resp != nil && resp.Error != nil {
+			record.ErrorCode = resp.Error.Code
+		}
+
+		captureWriter.Write(&record)
+
+		return resp
+	}
+}
@@ -0,0 +1,134 @@
+// Package capture samples production RPC traffic into capture logs, and converts capture
+// logs into anonymized test fixtures for the routing and middleware pipeline.
+package capture
+
+import (
+	"bufio"
+	"encoding/json"
+	"math/rand"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// Config configurations to sample RPC traffic into capture log.
+type Config struct {
+	Enabled bool
+	// capture log file, which is appended in JSON lines
+	Path string `default:"capture.jsonl"`
+	// percentage of traffic to sample, in range [0, 100]
+	Percentage float64 `default:"1"`
+	// max number of records pending to write, and more will be dropped
+	BufferSize int `default:"1024"`
+}
+
+// Record is a sampled RPC request in capture log.
+type Record struct {
+	Time      time.Time       `json:"time"`
+	Space     string          `json:"space"`
+	Chain     string          `json:"chain,omitempty"`
+	ApiKey    string          `json:"apiKey,omitempty"`
+	IP        string          `json:"ip,omitempty"`
+	Method    string          `json:"method"`
+	Params    json.RawMessage `json:"params,omitempty"`
+	ErrorCode int             `json:"errorCode,omitempty"`
+}
+
+// Writer samples records and writes into capture log asynchronously.
+type Writer struct {
+	conf    Config
+	records chan *Record
+	once    sync.Once
+}
+
+func NewWriter(conf Config) (*Writer, error) {
+	if conf.Percentage < 0 || conf.Percentage > 100 {
+		return nil, errors.Errorf("invalid capture percentage %v", conf.Percentage)
+	}
+
+	if conf.BufferSize <= 0 {
+		return nil, errors.Errorf("invalid capture buffer size %v", conf.BufferSize)
+	}
+
+	return &Writer{
+		conf:    conf,
+		records: make(chan *Record, conf.BufferSize),
+	}, nil
+}
+
+// Sampled checks if the next request should be captured.
+func (w *Writer) Sampled() bool {
+	return w.conf.Enabled && rand.Float64()*100 < w.conf.Percentage
+}
+
+// Write writes the record into capture log, and drops it if too many records pending.
+func (w *Writer) Write(record *Record) {
+	// file opened lazily upon the first sampled request
+	w.once.Do(func() {
+		go w.loop()
+	})
+
+	select {
+	case w.records <- record:
+	default:
+		logrus.WithField("method", record.Method).Debug("Capture record dropped due to too many pending")
+	}
+}
+
+func (w *Writer) loop() {
+	file, err := os.OpenFile(w.conf.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		logrus.WithError(err).WithField("path", w.conf.Path).Error("Failed to open capture log")
+		return
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	encoder := json.NewEncoder(writer)
+
+	for record := range w.records {
+		if err := encoder.Encode(record); err != nil {
+			logrus.WithError(err).Debug("Failed to encode capture record")
+			continue
+		}
+
+		// flush once no more records pending
+		if len(w.records) == 0 {
+			if err := writer.Flush(); err != nil {
+				logrus.WithError(err).Warn("Failed to flush capture log")
+			}
+		}
+	}
+}
+
+// ReadRecords reads all records from the capture log file.
+func ReadRecords(path string) ([]*Record, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to open capture log")
+	}
+	defer file.Close()
+
+	var records []*Record
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var record Record
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, errors.WithMessagef(err, "invalid capture record at line %v", line)
+		}
+
+		records = append(records, &record)
+	}
+
+	return records, scanner.Err()
+}
@@ -0,0 +1,240 @@
+package capture
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// fixture file suffix, e.g. eth_call.json
+	fixtureSuffix = ".json"
+	// golden file suffix, e.g. eth_call.golden.json
+	GoldenSuffix = ".golden.json"
+)
+
+// hex values longer than a 64 bits quantity (e.g. address, hash and raw tx) are pseudonymized,
+// while block numbers are reserved for routing.
+var sensitiveHexPattern = regexp.MustCompile(`^0x[0-9a-fA-F]{17,}$`)
+
+// Fixture is an anonymized RPC request to test the routing and middleware pipeline.
+type Fixture struct {
+	Name   string          `json:"name"`
+	Space  string          `json:"space"`
+	Chain  string          `json:"chain,omitempty"`
+	ApiKey string          `json:"apiKey,omitempty"`
+	IP     string          `json:"ip,omitempty"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+// FixtureOptions options to generate fixtures from capture log.
+type FixtureOptions struct {
+	// secret salt to derive pseudonyms, so that fixtures could not be reversed by brute force
+	Salt string
+	// max number of fixtures per method
+	MaxPerMethod int
+}
+
+// Anonymizer replaces API keys, IP addresses and sensitive hex values with stable pseudonyms.
+type Anonymizer struct {
+	salt string
+}
+
+func NewAnonymizer(salt string) *Anonymizer {
+	return &Anonymizer{salt: salt}
+}
+
+func (a *Anonymizer) digest(kind, value string) []byte {
+	sum := sha256.Sum256([]byte(a.salt + "|" + kind + "|" + value))
+	return sum[:]
+}
+
+// pseudoHex derives a hex value of the same length.
+func (a *Anonymizer) pseudoHex(value string) string {
+	digits := len(value) - 2
+
+	var sb strings.Builder
+	sb.WriteString("0x")
+
+	for seed := value; sb.Len() < digits+2; {
+		sum := a.digest("hex", seed)
+		seed = hex.EncodeToString(sum)
+		sb.WriteString(seed)
+	}
+
+	return sb.String()[:digits+2]
+}
+
+// pseudoIP derives an IP address in the benchmarking range 198.18.0.0/15.
+func (a *Anonymizer) pseudoIP(ip string) string {
+	sum := a.digest("ip", ip)
+	return net.IPv4(198, 18|(sum[0]&1), sum[1], sum[2]).String()
+}
+
+func (a *Anonymizer) pseudoApiKey(key string) string {
+	return "key-" + hex.EncodeToString(a.digest("apikey", key)[:8])
+}
+
+func (a *Anonymizer) anonymizeValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case string:
+		if sensitiveHexPattern.MatchString(val) {
+			return a.pseudoHex(val)
+		}
+	case []interface{}:
+		for i := range val {
+			val[i] = a.anonymizeValue(val[i])
+		}
+	case map[string]interface{}:
+		for k := range val {
+			val[k] = a.anonymizeValue(val[k])
+		}
+	}
+
+	return v
+}
+
+// Anonymize converts the capture record into an anonymized fixture.
+func (a *Anonymizer) Anonymize(record *Record) (*Fixture, error) {
+	fixture := Fixture{
+		Space:  record.Space,
+		Chain:  record.Chain,
+		Method: record.Method,
+	}
+
+	if len(record.ApiKey) > 0 {
+		fixture.ApiKey = a.pseudoApiKey(record.ApiKey)
+	}
+
+	if len(record.IP) > 0 {
+		fixture.IP = a.pseudoIP(record.IP)
+	}
+
+	if len(record.Params) > 0 {
+		decoder := json.NewDecoder(bytes.NewReader(record.Params))
+		decoder.UseNumber()
+
+		var params interface{}
+		if err := decoder.Decode(&params); err != nil {
+			return nil, errors.WithMessagef(err, "invalid params of method %v", record.Method)
+		}
+
+		data, err := json.Marshal(a.anonymizeValue(params))
+		if err != nil {
+			return nil, err
+		}
+
+		fixture.Params = data
+	}
+
+	return &fixture, nil
+}
+
+// GenerateFixtures anonymizes capture records into fixtures grouped by method, with duplicated
+// requests removed.
+func GenerateFixtures(records []*Record, opts FixtureOptions) (map[string][]*Fixture, error) {
+	anonymizer := NewAnonymizer(opts.Salt)
+	method2Fixtures := make(map[string][]*Fixture)
+	seen := make(map[string]bool)
+
+	for _, record := range records {
+		if len(record.Method) == 0 || strings.ContainsAny(record.Method, `/\.`) {
+			continue
+		}
+
+		fixtures := method2Fixtures[record.Method]
+		if opts.MaxPerMethod > 0 && len(fixtures) >= opts.MaxPerMethod {
+			continue
+		}
+
+		fixture, err := anonymizer.Anonymize(record)
+		if err != nil {
+			return nil, err
+		}
+
+		// client identity excluded to dedup, since routing mostly depends on request only
+		key := fmt.Sprintf("%v|%v|%v|%s", fixture.Space, fixture.Chain, fixture.Method, fixture.Params)
+		if seen[key] {
+			continue
+		}
+
+		seen[key] = true
+		fixture.Name = fmt.Sprintf("%v#%v", record.Method, len(fixtures))
+		method2Fixtures[record.Method] = append(fixtures, fixture)
+	}
+
+	return method2Fixtures, nil
+}
+
+// WriteFixtures writes fixtures into directory, one file per method.
+func WriteFixtures(dir string, method2Fixtures map[string][]*Fixture) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	for method, fixtures := range method2Fixtures {
+		if err := WriteJSONFile(filepath.Join(dir, method+fixtureSuffix), fixtures); err != nil {
+			return errors.WithMessagef(err, "failed to write fixtures of method %v", method)
+		}
+	}
+
+	return nil
+}
+
+// LoadFixtures loads fixtures from directory, with fixture file path as key.
+func LoadFixtures(dir string) (map[string][]*Fixture, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*"+fixtureSuffix))
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(files)
+
+	file2Fixtures := make(map[string][]*Fixture)
+	for _, file := range files {
+		if strings.HasSuffix(file, GoldenSuffix) {
+			continue
+		}
+
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		var fixtures []*Fixture
+		if err := json.Unmarshal(data, &fixtures); err != nil {
+			return nil, errors.WithMessagef(err, "invalid fixture file %v", file)
+		}
+
+		file2Fixtures[file] = fixtures
+	}
+
+	return file2Fixtures, nil
+}
+
+// GoldenFile returns the golden file path of the specified fixture file.
+func GoldenFile(fixtureFile string) string {
+	return strings.TrimSuffix(fixtureFile, fixtureSuffix) + GoldenSuffix
+}
+
+// WriteJSONFile writes value into file in indented JSON.
+func WriteJSONFile(file string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(file, append(data, '\n'), 0644)
+}
@@ -0,0 +1,53 @@
+package capture
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnonymize(t *testing.T) {
+	record := Record{
+		Space:  "eth",
+		ApiKey: "secret-key",
+		IP:     "1.2.3.4",
+		Method: "eth_getBalance",
+		Params: json.RawMessage(`["0x000000000000000000000000000000000000dEaD", "0x10"]`),
+	}
+
+	anonymizer := NewAnonymizer("salt")
+	fixture, err := anonymizer.Anonymize(&record)
+	assert.Nil(t, err)
+
+	assert.NotEqual(t, record.ApiKey, fixture.ApiKey)
+	assert.NotEqual(t, record.IP, fixture.IP)
+	assert.Contains(t, fixture.IP, "198.")
+
+	var params []string
+	assert.Nil(t, json.Unmarshal(fixture.Params, &params))
+	assert.Len(t, params[0], 42)
+	assert.NotEqual(t, "0x000000000000000000000000000000000000dEaD", params[0])
+	assert.Equal(t, "0x10", params[1])
+
+	// pseudonyms are stable
+	again, _ := anonymizer.Anonymize(&record)
+	assert.Equal(t, fixture, again)
+}
+
+func TestGenerateFixtures(t *testing.T) {
+	records := []*Record{
+		{Space: "eth", Method: "eth_blockNumber", IP: "1.1.1.1"},
+		{Space: "eth", Method: "eth_blockNumber", IP: "2.2.2.2"},
+		{Space: "eth", Method: "eth_getBlockByNumber", Params: json.RawMessage(`["0x1", false]`)},
+		{Space: "eth", Method: "eth_getBlockByNumber", Params: json.RawMessage(`["0x2", false]`)},
+		{Space: "eth", Method: "../eth_call"},
+	}
+
+	fixtures, err := GenerateFixtures(records, FixtureOptions{Salt: "salt", MaxPerMethod: 1})
+	assert.Nil(t, err)
+	assert.Len(t, fixtures, 2)
+	assert.Len(t, fixtures["eth_blockNumber"], 1)
+	assert.Len(t, fixtures["eth_getBlockByNumber"], 1)
+	assert.Equal(t, "eth_getBlockByNumber#0", fixtures["eth_getBlockByNumber"][0].Name)
+}
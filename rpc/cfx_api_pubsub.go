@@ -31,6 +31,11 @@ func (api *cfxAPI) NewHeads(ctx context.Context) (*rpc.Subscription, error) {
 		return &rpc.Subscription{}, errSubscriptionProxyError
 	}
 
+	release, err := acquireSubscriptionQuota(ctx)
+	if err != nil {
+		return &rpc.Subscription{}, err
+	}
+
 	rpcSub := psCtx.notifier.CreateSubscription()
 
 	headersCh := make(chan *types.BlockHeader, pubsubChannelBufferSize)
@@ -39,6 +44,7 @@ func (api *cfxAPI) NewHeads(ctx context.Context) (*rpc.Subscription, error) {
 	dSub, err := dClient.delegateSubscribeNewHeads(rpcSub.ID, headersCh)
 	if err != nil {
 		logrus.WithError(err).Error("Failed to delegate pubsub NewHeads")
+		release()
 		return &rpc.Subscription{}, errSubscriptionProxyError
 	}
 
@@ -49,6 +55,7 @@ func (api *cfxAPI) NewHeads(ctx context.Context) (*rpc.Subscription, error) {
 	counter.Inc(1)
 
 	go func() {
+		defer release()
 		defer dSub.unsubscribe()
 		defer counter.Dec(1)
 
@@ -56,7 +63,7 @@ func (api *cfxAPI) NewHeads(ctx context.Context) (*rpc.Subscription, error) {
 			select {
 			case blockHeader := <-headersCh:
 				logger.WithField("blockHeader", blockHeader).Debug("Received new block header from pubsub delegate")
-				psCtx.notifier.Notify(rpcSub.ID, blockHeader)
+				notifyWs("cfx", psCtx.notifier, psCtx.rpcClient, rpcSub.ID, blockHeader)
 
 			case err = <-dSub.err: // delegate subscription error
 				logger.WithError(err).Debug("Received error from newHeads pubsub delegate")
@@ -98,6 +105,11 @@ func (api *cfxAPI) Epochs(ctx context.Context, subEpoch *types.Epoch) (*rpc.Subs
 		return &rpc.Subscription{}, errSubscriptionProxyError
 	}
 
+	release, err := acquireSubscriptionQuota(ctx)
+	if err != nil {
+		return &rpc.Subscription{}, err
+	}
+
 	rpcSub := psCtx.notifier.CreateSubscription()
 
 	epochsCh := make(chan *types.WebsocketEpochResponse, pubsubChannelBufferSize)
@@ -106,6 +118,7 @@ func (api *cfxAPI) Epochs(ctx context.Context, subEpoch *types.Epoch) (*rpc.Subs
 	dSub, err := dClient.delegateSubscribeEpochs(rpcSub.ID, epochsCh, *subEpoch)
 	if err != nil {
 		logrus.WithError(err).Errorf("Failed to delegate pubsub epochs subscription (%v)", subEpoch)
+		release()
 		return &rpc.Subscription{}, errSubscriptionProxyError
 	}
 
@@ -116,6 +129,7 @@ func (api *cfxAPI) Epochs(ctx context.Context, subEpoch *types.Epoch) (*rpc.Subs
 	counter.Inc(1)
 
 	go func() {
+		defer release()
 		defer dSub.unsubscribe()
 		defer counter.Dec(1)
 
@@ -123,7 +137,7 @@ func (api *cfxAPI) Epochs(ctx context.Context, subEpoch *types.Epoch) (*rpc.Subs
 			select {
 			case epoch := <-epochsCh:
 				logger.WithField("epoch", epoch).Debugf("Received new epoch from pubsub delegate (%v)", subEpoch)
-				psCtx.notifier.Notify(rpcSub.ID, epoch)
+				notifyWs("cfx", psCtx.notifier, psCtx.rpcClient, rpcSub.ID, epoch)
 
 			case err = <-dSub.err: // delegate subscription error
 				logger.WithError(err).Debugf("Received error from epochs pubsub delegate (%v)", subEpoch)
@@ -159,6 +173,11 @@ func (api *cfxAPI) Logs(ctx context.Context, filter types.LogFilter) (*rpc.Subsc
 		return &rpc.Subscription{}, errSubscriptionProxyError
 	}
 
+	release, err := acquireSubscriptionQuota(ctx)
+	if err != nil {
+		return &rpc.Subscription{}, err
+	}
+
 	rpcSub := psCtx.notifier.CreateSubscription()
 
 	logsCh := make(chan *types.SubscriptionLog, pubsubChannelBufferSize)
@@ -167,6 +186,7 @@ func (api *cfxAPI) Logs(ctx context.Context, filter types.LogFilter) (*rpc.Subsc
 	dSub, err := dClient.delegateSubscribeLogs(rpcSub.ID, logsCh, filter)
 	if err != nil {
 		logrus.WithField("filter", filter).WithError(err).Error("Failed to delegate pubsub logs subscription")
+		release()
 		return &rpc.Subscription{}, errSubscriptionProxyError
 	}
 
@@ -177,6 +197,7 @@ func (api *cfxAPI) Logs(ctx context.Context, filter types.LogFilter) (*rpc.Subsc
 	counter.Inc(1)
 
 	go func() {
+		defer release()
 		defer dSub.unsubscribe()
 		defer counter.Dec(1)
 
@@ -184,7 +205,7 @@ func (api *cfxAPI) Logs(ctx context.Context, filter types.LogFilter) (*rpc.Subsc
 			select {
 			case log := <-logsCh:
 				logger.WithField("log", log).Debug("Received new log from pubsub delegate")
-				psCtx.notifier.Notify(rpcSub.ID, log)
+				notifyWs("cfx", psCtx.notifier, psCtx.rpcClient, rpcSub.ID, log)
 
 			case err = <-dSub.err: // delegate subscription error
 				logger.WithError(err).Debug("Received error from logs pubsub delegate")
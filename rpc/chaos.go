@@ -0,0 +1,225 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/pkg/errors"
+	"github.com/scroll-tech/rpc-gateway/util/metrics"
+	"github.com/scroll-tech/rpc-gateway/util/reload"
+	"github.com/scroll-tech/rpc-gateway/util/rpc/handlers"
+	"github.com/sirupsen/logrus"
+)
+
+// errCodeChaos is the default JSON-RPC error code of injected errors.
+const errCodeChaos = -32603
+
+const ctxKeyChaosDrop = handlers.CtxKey("Infura-RPC-Chaos-Drop")
+
+// ChaosRuleConfig rule to inject faults into RPC requests, of which rates are in range [0, 1].
+type ChaosRuleConfig struct {
+	// RPC methods to inject faults, which supports `*` suffix as wildcard, and empty means any
+	Methods []string
+	// upstream node names to inject faults, e.g. `10.0.0.1:8545`, and empty means any
+	Nodes []string
+	// artificial latency injected at rate
+	Latency     time.Duration
+	LatencyRate float64
+	// error response injected at rate, of which code defaults to -32603
+	ErrorRate    float64
+	ErrorCode    int
+	ErrorMessage string
+	// connection dropped at rate without response, which is only available over HTTP
+	DropRate float64
+}
+
+// ChaosConfig configurations to inject artificial latency, error responses or dropped connections
+// for resilience tests of downstream clients and gateway retry logic in staging, which should never
+// be enabled in production. Note, the first matched rule applies.
+type ChaosConfig struct {
+	Enabled bool
+	Rules   []ChaosRuleConfig
+}
+
+// chaos is the chaos config in use, which could be changed at runtime.
+var chaos atomic.Value
+
+func init() {
+	conf, err := loadChaosConfig()
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to load chaos config")
+	}
+
+	chaos.Store(conf)
+
+	reload.Register("rpc_chaos", func() error {
+		conf, err := loadChaosConfig()
+		if err != nil {
+			return err
+		}
+
+		chaos.Store(conf)
+		return nil
+	})
+}
+
+func loadChaosConfig() (*ChaosConfig, error) {
+	var conf ChaosConfig
+	if err := viper.UnmarshalKey("rpc.chaos", &conf); err != nil {
+		return nil, err
+	}
+
+	for i, rule := range conf.Rules {
+		for _, rate := range []float64{rule.LatencyRate, rule.ErrorRate, rule.DropRate} {
+			if rate < 0 || rate > 1 {
+				return nil, errors.Errorf("invalid rate of chaos rule #%v, which should be in range [0, 1]", i)
+			}
+		}
+	}
+
+	if conf.Enabled {
+		logrus.WithField("rules", len(conf.Rules)).Warn(
+			"Chaos injection enabled, which should never be enabled in production",
+		)
+	}
+
+	return &conf, nil
+}
+
+// match returns the first rule that matches the RPC method and upstream node.
+func (conf *ChaosConfig) match(method, nodeName string) (*ChaosRuleConfig, bool) {
+	for i := range conf.Rules {
+		rule := &conf.Rules[i]
+
+		if len(rule.Methods) > 0 && !matchMethods(rule.Methods, method) {
+			continue
+		}
+
+		if len(rule.Nodes) > 0 && !containsString(rule.Nodes, nodeName) {
+			continue
+		}
+
+		return rule, true
+	}
+
+	return nil, false
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// chaosError is the error injected by chaos rule.
+type chaosError struct {
+	code    int
+	message string
+}
+
+func (e *chaosError) Error() string  { return e.message }
+func (e *chaosError) ErrorCode() int { return e.code }
+
+// chaosResponseWriter drops the connection without response once requested by any RPC request.
+type chaosResponseWriter struct {
+	http.ResponseWriter
+	dropped int32
+}
+
+func (w *chaosResponseWriter) WriteHeader(statusCode int) {
+	w.abortIfDropped()
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *chaosResponseWriter) Write(data []byte) (int, error) {
+	w.abortIfDropped()
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *chaosResponseWriter) abortIfDropped() {
+	if atomic.LoadInt32(&w.dropped) == 1 {
+		// aborts the connection without response, which is recovered by HTTP server
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// withChaosDrop wraps the HTTP response writer so that connection could be dropped by chaos
+// rules if enabled, which doesn't apply to websocket.
+func withChaosDrop(ctx context.Context, w http.ResponseWriter, r *http.Request) (context.Context, http.ResponseWriter) {
+	if !chaos.Load().(*ChaosConfig).Enabled || len(r.Header.Get("Upgrade")) > 0 {
+		return ctx, w
+	}
+
+	cw := &chaosResponseWriter{ResponseWriter: w}
+
+	return context.WithValue(ctx, ctxKeyChaosDrop, cw), cw
+}
+
+// chaosMiddleware injects faults into RPC requests by the matched chaos rule, which should be
+// hooked after the client middleware so that faults could be injected per upstream node.
+func chaosMiddleware(next rpc.HandleCallMsgFunc) rpc.HandleCallMsgFunc {
+	return func(ctx context.Context, msg *rpc.JsonRpcMessage) *rpc.JsonRpcMessage {
+		conf := chaos.Load().(*ChaosConfig)
+		if !conf.Enabled {
+			return next(ctx, msg)
+		}
+
+		nodeName, _ := servingNodeName(ctx)
+
+		rule, ok := conf.match(msg.Method, nodeName)
+		if !ok {
+			return next(ctx, msg)
+		}
+
+		logger := logrus.WithFields(logrus.Fields{"method": msg.Method, "node": nodeName})
+
+		if rule.Latency > 0 && rand.Float64() < rule.LatencyRate {
+			metrics.Registry.RPC.ChaosInjected(msg.Method, "latency").Mark(1)
+			logger.WithField("latency", rule.Latency).Debug("Chaos latency injected")
+
+			select {
+			case <-time.After(rule.Latency):
+			case <-ctx.Done():
+				return msg.ErrorResponse(ctx.Err())
+			}
+		}
+
+		if cw, ok := ctx.Value(ctxKeyChaosDrop).(*chaosResponseWriter); ok && rand.Float64() < rule.DropRate {
+			metrics.Registry.RPC.ChaosInjected(msg.Method, "drop").Mark(1)
+			logger.Debug("Chaos connection drop injected")
+
+			atomic.StoreInt32(&cw.dropped, 1)
+
+			return msg.ErrorResponse(errors.New("connection dropped by chaos injection"))
+		}
+
+		if rand.Float64() < rule.ErrorRate {
+			metrics.Registry.RPC.ChaosInjected(msg.Method, "error").Mark(1)
+			logger.Debug("Chaos error injected")
+
+			message := rule.ErrorMessage
+			if len(message) == 0 {
+				message = fmt.Sprintf("chaos injected error of method %v", msg.Method)
+			}
+
+			code := rule.ErrorCode
+			if code == 0 {
+				code = errCodeChaos
+			}
+
+			return msg.ErrorResponse(&chaosError{code: code, message: message})
+		}
+
+		return next(ctx, msg)
+	}
+}
@@ -0,0 +1,67 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChaosConfigMatch(t *testing.T) {
+	conf := ChaosConfig{Rules: []ChaosRuleConfig{
+		{Methods: []string{"eth_getLogs"}, Nodes: []string{"10.0.0.1:8545"}, ErrorRate: 1},
+		{Methods: []string{"trace_*"}, DropRate: 1},
+		{Nodes: []string{"10.0.0.2:8545"}, LatencyRate: 1},
+	}}
+
+	rule, ok := conf.match("eth_getLogs", "10.0.0.1:8545")
+	assert.True(t, ok)
+	assert.Equal(t, float64(1), rule.ErrorRate)
+
+	_, ok = conf.match("eth_getLogs", "10.0.0.3:8545")
+	assert.False(t, ok)
+
+	rule, ok = conf.match("trace_block", "10.0.0.3:8545")
+	assert.True(t, ok)
+	assert.Equal(t, float64(1), rule.DropRate)
+
+	rule, ok = conf.match("eth_call", "10.0.0.2:8545")
+	assert.True(t, ok)
+	assert.Equal(t, float64(1), rule.LatencyRate)
+}
+
+func TestChaosMiddleware(t *testing.T) {
+	defer chaos.Store(chaos.Load())
+
+	chaos.Store(&ChaosConfig{Enabled: true, Rules: []ChaosRuleConfig{
+		{Methods: []string{"eth_call"}, ErrorRate: 1, ErrorCode: -32000, ErrorMessage: "injected"},
+		{Methods: []string{"eth_getLogs"}, DropRate: 1},
+	}})
+
+	handler := chaosMiddleware(func(ctx context.Context, msg *rpc.JsonRpcMessage) *rpc.JsonRpcMessage {
+		return &rpc.JsonRpcMessage{Version: msg.Version, ID: msg.ID, Result: json.RawMessage(`"0x1"`)}
+	})
+
+	newMsg := func(method string) *rpc.JsonRpcMessage {
+		return &rpc.JsonRpcMessage{Version: "2.0", ID: json.RawMessage("1"), Method: method}
+	}
+
+	// error injected
+	resp := handler(context.Background(), newMsg("eth_call"))
+	assert.NotNil(t, resp.Error)
+	assert.Equal(t, -32000, resp.Error.Code)
+
+	// not matched
+	resp = handler(context.Background(), newMsg("eth_blockNumber"))
+	assert.Nil(t, resp.Error)
+
+	// connection dropped once response written
+	r := httptest.NewRequest("POST", "/", nil)
+	ctx, w := withChaosDrop(context.Background(), httptest.NewRecorder(), r)
+	handler(ctx, newMsg("eth_getLogs"))
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() { w.Write([]byte("{}")) })
+}
@@ -0,0 +1,160 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/pkg/errors"
+	"github.com/scroll-tech/rpc-gateway/util/metrics"
+	"github.com/scroll-tech/rpc-gateway/util/reload"
+	"github.com/sirupsen/logrus"
+)
+
+// MethodConcurrencyConfig concurrency limit shared by a set of RPC methods.
+type MethodConcurrencyConfig struct {
+	// RPC methods sharing the limit, which supports `*` suffix as wildcard, e.g. `debug_*`
+	Methods []string
+	// max number of concurrent requests
+	Max int
+}
+
+// ConcurrencyConfig configurations of gateway-wide and per method concurrency limits, which
+// protect both the gateway and upstreams from expensive-call storms.
+type ConcurrencyConfig struct {
+	Enabled bool
+	// max number of concurrent requests gateway-wide, and 0 means unlimited
+	Global int
+	// per method limits, of which the first matched applies
+	Methods []MethodConcurrencyConfig
+	// max number of requests waiting for each limit
+	MaxQueue int32 `default:"128"`
+	// max duration to wait, and 0 to reject immediately once limit reached
+	QueueTimeout time.Duration `default:"100ms"`
+}
+
+type concurrencyPolicy struct {
+	ConcurrencyConfig
+	global  *inflightLimiter   // nil if unlimited
+	methods []*inflightLimiter // in the same order of method limits
+}
+
+// concurrency is the concurrency policy in use, which could be changed at runtime. Note,
+// in-flight requests are not counted by the new policy.
+var concurrency atomic.Value
+
+func init() {
+	policy, err := loadConcurrencyPolicy()
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to load concurrency limits config")
+	}
+
+	concurrency.Store(policy)
+
+	reload.Register("rpc_concurrency", func() error {
+		policy, err := loadConcurrencyPolicy()
+		if err != nil {
+			return err
+		}
+
+		concurrency.Store(policy)
+		return nil
+	})
+}
+
+func loadConcurrencyPolicy() (*concurrencyPolicy, error) {
+	var conf ConcurrencyConfig
+	if err := viper.UnmarshalKey("rpc.concurrency", &conf); err != nil {
+		return nil, err
+	}
+
+	policy := concurrencyPolicy{ConcurrencyConfig: conf}
+
+	if conf.Global < 0 {
+		return nil, errors.Errorf("invalid global concurrency limit %v", conf.Global)
+	}
+
+	if conf.Global > 0 {
+		policy.global = newInflightLimiter(conf.Global)
+	}
+
+	for _, mc := range conf.Methods {
+		if mc.Max <= 0 || len(mc.Methods) == 0 {
+			return nil, errors.Errorf("invalid concurrency limit %v of methods %v", mc.Max, mc.Methods)
+		}
+
+		policy.methods = append(policy.methods, newInflightLimiter(mc.Max))
+	}
+
+	return &policy, nil
+}
+
+// methodLimiter returns the limiter of the first matched method limit if any.
+func (p *concurrencyPolicy) methodLimiter(method string) (*inflightLimiter, bool) {
+	for i, mc := range p.Methods {
+		if matchMethods(mc.Methods, method) {
+			return p.methods[i], true
+		}
+	}
+
+	return nil, false
+}
+
+// acquire acquires both the per method and global in-flight slots, and returns the function
+// to release them.
+func (p *concurrencyPolicy) acquire(ctx context.Context, method string) (func(), error) {
+	var acquired []*inflightLimiter
+	release := func() {
+		for _, l := range acquired {
+			l.release()
+		}
+	}
+
+	if l, ok := p.methodLimiter(method); ok {
+		if !l.acquire(ctx, p.MaxQueue, p.QueueTimeout) {
+			metrics.Registry.RPC.ConcurrencyRejected("method", method).Mark(1)
+			return nil, &busyError{
+				message:    fmt.Sprintf("too many concurrent %v requests, please try again later", method),
+				retryAfter: p.QueueTimeout,
+			}
+		}
+
+		acquired = append(acquired, l)
+	}
+
+	if p.global != nil {
+		if !p.global.acquire(ctx, p.MaxQueue, p.QueueTimeout) {
+			release()
+			metrics.Registry.RPC.ConcurrencyRejected("global", method).Mark(1)
+			return nil, &busyError{
+				message:    "gateway busy, please try again later",
+				retryAfter: p.QueueTimeout,
+			}
+		}
+
+		acquired = append(acquired, p.global)
+	}
+
+	return release, nil
+}
+
+// concurrencyMiddleware limits concurrent requests gateway-wide and per method.
+func concurrencyMiddleware(next rpc.HandleCallMsgFunc) rpc.HandleCallMsgFunc {
+	return func(ctx context.Context, msg *rpc.JsonRpcMessage) *rpc.JsonRpcMessage {
+		policy := concurrency.Load().(*concurrencyPolicy)
+		if !policy.Enabled {
+			return next(ctx, msg)
+		}
+
+		release, err := policy.acquire(ctx, msg.Method)
+		if err != nil {
+			return msg.ErrorResponse(err)
+		}
+		defer release()
+
+		return next(ctx, msg)
+	}
+}
@@ -0,0 +1,62 @@
+package rpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInflightLimiter(t *testing.T) {
+	limiter := newInflightLimiter(1)
+	ctx := context.Background()
+
+	assert.True(t, limiter.acquire(ctx, 1, time.Millisecond))
+
+	// wait in queue until timeout
+	assert.False(t, limiter.acquire(ctx, 1, 10*time.Millisecond))
+
+	// acquired once released while waiting
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		limiter.release()
+	}()
+	assert.True(t, limiter.acquire(ctx, 1, time.Second))
+
+	// queue full
+	limiter.queued = 1
+	assert.False(t, limiter.acquire(ctx, 1, time.Second))
+	assert.False(t, limiter.tryAcquire())
+}
+
+func TestConcurrencyPolicy(t *testing.T) {
+	policy := concurrencyPolicy{
+		ConcurrencyConfig: ConcurrencyConfig{
+			Methods: []MethodConcurrencyConfig{{Methods: []string{"debug_*"}, Max: 1}},
+		},
+		global:  newInflightLimiter(2),
+		methods: []*inflightLimiter{newInflightLimiter(1)},
+	}
+	ctx := context.Background()
+
+	release1, err := policy.acquire(ctx, "debug_traceBlockByNumber")
+	assert.Nil(t, err)
+
+	// per method limit reached
+	_, err = policy.acquire(ctx, "debug_traceTransaction")
+	assert.NotNil(t, err)
+
+	// global limit reached
+	release2, err := policy.acquire(ctx, "eth_call")
+	assert.Nil(t, err)
+	_, err = policy.acquire(ctx, "eth_call")
+	assert.NotNil(t, err)
+
+	release1()
+	release2()
+
+	release3, err := policy.acquire(ctx, "debug_traceTransaction")
+	assert.Nil(t, err)
+	release3()
+}
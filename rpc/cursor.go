@@ -0,0 +1,184 @@
+package rpc
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	errInvalidCursor = errors.New("invalid cursor")
+	errCursorExpired = errors.New("cursor expired, please query from the beginning again")
+)
+
+// CursorConfig configurations of opaque pagination cursors.
+type CursorConfig struct {
+	// hex encoded secret to sign cursors, which should be shared among gateway instances
+	// behind load balancer. If empty, a random one is generated at startup.
+	Secret string
+	// cursor expiration duration
+	Expiry time.Duration `default:"1h"`
+}
+
+// cursorPayload is the content of an opaque cursor.
+type cursorPayload struct {
+	Method    string `json:"m"`           // RPC method that issues the cursor
+	Digest    string `json:"d"`           // digest of query parameters bound to the cursor
+	Position  uint64 `json:"p"`           // position to continue query from
+	Offset    uint64 `json:"o,omitempty"` // number of items to skip at position
+	ExpiresAt int64  `json:"e"`           // unix time in seconds
+}
+
+// CursorCodec encodes/decodes opaque signed cursors for gateway extension APIs that may
+// return unbounded data, so that query could be continued from where it stopped. Cursor is
+// bound to the issuing RPC method and query parameters to prevent from misuse.
+type CursorCodec struct {
+	secret []byte
+	expiry time.Duration
+}
+
+var (
+	sharedCursorCodec     *CursorCodec
+	sharedCursorCodecOnce sync.Once
+)
+
+// sharedCursors returns the cursor codec shared by RPC APIs and middlewares, so that cursors
+// issued by middlewares could be continued with gateway extension APIs.
+func sharedCursors() *CursorCodec {
+	sharedCursorCodecOnce.Do(func() {
+		sharedCursorCodec = MustNewCursorCodecFromViper()
+	})
+
+	return sharedCursorCodec
+}
+
+func MustNewCursorCodecFromViper() *CursorCodec {
+	var conf CursorConfig
+	viper.MustUnmarshalKey("rpc.cursor", &conf)
+
+	codec, err := NewCursorCodec(&conf)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to create pagination cursor codec")
+	}
+
+	return codec
+}
+
+func NewCursorCodec(conf *CursorConfig) (*CursorCodec, error) {
+	if len(conf.Secret) == 0 {
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, errors.WithMessage(err, "failed to generate random secret")
+		}
+
+		logrus.Warn("Pagination cursor secret not configured, cursors are not portable among gateway instances")
+
+		return &CursorCodec{secret: secret, expiry: conf.Expiry}, nil
+	}
+
+	secret, err := hex.DecodeString(strings.TrimPrefix(conf.Secret, "0x"))
+	if err != nil {
+		return nil, errors.WithMessage(err, "invalid hex secret")
+	}
+
+	return &CursorCodec{secret: secret, expiry: conf.Expiry}, nil
+}
+
+// Encode issues a cursor for the specified RPC method and query parameters to continue
+// query from the given position.
+func (c *CursorCodec) Encode(method string, query interface{}, position uint64) (string, error) {
+	return c.EncodeOffset(method, query, position, 0)
+}
+
+// EncodeOffset issues a cursor to continue query from the given position, of which the first
+// offset items are skipped, e.g. logs already returned within a block.
+func (c *CursorCodec) EncodeOffset(method string, query interface{}, position, offset uint64) (string, error) {
+	digest, err := cursorDigest(query)
+	if err != nil {
+		return "", err
+	}
+
+	payload, err := json.Marshal(&cursorPayload{
+		Method:    method,
+		Digest:    digest,
+		Position:  position,
+		Offset:    offset,
+		ExpiresAt: time.Now().Add(c.expiry).Unix(),
+	})
+	if err != nil {
+		return "", errors.WithMessage(err, "failed to marshal cursor")
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+
+	return encoded + "." + c.sign(encoded), nil
+}
+
+// Decode verifies the cursor against the specified RPC method and query parameters, and
+// returns the position to continue query from.
+func (c *CursorCodec) Decode(cursor, method string, query interface{}) (uint64, error) {
+	position, _, err := c.DecodeOffset(cursor, method, query)
+	return position, err
+}
+
+// DecodeOffset verifies the cursor against the specified RPC method and query parameters, and
+// returns the position to continue query from along with the number of items to skip.
+func (c *CursorCodec) DecodeOffset(cursor, method string, query interface{}) (uint64, uint64, error) {
+	parts := strings.Split(cursor, ".")
+	if len(parts) != 2 || !hmac.Equal([]byte(c.sign(parts[0])), []byte(parts[1])) {
+		return 0, 0, errInvalidCursor
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return 0, 0, errInvalidCursor
+	}
+
+	var payload cursorPayload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return 0, 0, errInvalidCursor
+	}
+
+	digest, err := cursorDigest(query)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if payload.Method != method || payload.Digest != digest {
+		return 0, 0, errors.WithMessage(errInvalidCursor, "query parameters mismatch")
+	}
+
+	if time.Now().Unix() > payload.ExpiresAt {
+		return 0, 0, errCursorExpired
+	}
+
+	return payload.Position, payload.Offset, nil
+}
+
+func (c *CursorCodec) sign(encoded string) string {
+	mac := hmac.New(sha256.New, c.secret)
+	mac.Write([]byte(encoded))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// cursorDigest returns the digest of query parameters bound to cursor.
+func cursorDigest(query interface{}) (string, error) {
+	data, err := json.Marshal(query)
+	if err != nil {
+		return "", errors.WithMessage(err, "failed to marshal query parameters")
+	}
+
+	hash := sha256.Sum256(data)
+
+	return hex.EncodeToString(hash[:8]), nil
+}
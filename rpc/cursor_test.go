@@ -0,0 +1,46 @@
+package rpc
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCursorCodec(t *testing.T) {
+	codec, err := NewCursorCodec(&CursorConfig{Secret: "0x" + strings.Repeat("ab", 32), Expiry: time.Hour})
+	assert.Nil(t, err)
+
+	query := []interface{}{"0x1", "0x100"}
+
+	cursor, err := codec.Encode("gateway_getBlocks", query, 66)
+	assert.Nil(t, err)
+
+	position, err := codec.Decode(cursor, "gateway_getBlocks", query)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(66), position)
+
+	// bound to method and query parameters
+	_, err = codec.Decode(cursor, "gateway_getLogs", query)
+	assert.Error(t, err)
+
+	_, err = codec.Decode(cursor, "gateway_getBlocks", []interface{}{"0x1", "0x200"})
+	assert.Error(t, err)
+
+	// tampered
+	parts := strings.Split(cursor, ".")
+	_, err = codec.Decode(parts[0]+"x."+parts[1], "gateway_getBlocks", query)
+	assert.Equal(t, errInvalidCursor, err)
+}
+
+func TestCursorCodecExpired(t *testing.T) {
+	codec, err := NewCursorCodec(&CursorConfig{Expiry: -time.Minute})
+	assert.Nil(t, err)
+
+	cursor, err := codec.Encode("gateway_getLogs", nil, 1)
+	assert.Nil(t, err)
+
+	_, err = codec.Decode(cursor, "gateway_getLogs", nil)
+	assert.Equal(t, errCursorExpired, err)
+}
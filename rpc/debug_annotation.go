@@ -0,0 +1,343 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	sdk "github.com/Conflux-Chain/go-conflux-sdk"
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/scroll-tech/rpc-gateway/node"
+	"github.com/scroll-tech/rpc-gateway/util/reload"
+	"github.com/scroll-tech/rpc-gateway/util/rpc/handlers"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	ctxKeyServingCollector = handlers.CtxKey("Infura-RPC-Serving-Collector")
+	ctxKeyServingEntry     = handlers.CtxKey("Infura-RPC-Serving-Entry")
+
+	// servingExtensionField is the extension field appended to JSON-RPC response for debugging.
+	servingExtensionField = "x-serving"
+)
+
+// DebugAnnotationConfig configurations to annotate responses with serving metadata for debugging.
+type DebugAnnotationConfig struct {
+	// annotate responses of all requests
+	Enabled bool
+	// trusted request header to annotate responses on demand
+	TrustedHeader string `default:"X-Debug-Token"`
+	// tokens accepted in the trusted request header
+	Tokens []string
+}
+
+type debugAnnotationPolicy struct {
+	DebugAnnotationConfig
+	tokens map[string]bool
+}
+
+// debugAnnotation is the debug annotation policy in use, which could be changed at runtime.
+var debugAnnotation atomic.Value
+
+func init() {
+	policy, err := loadDebugAnnotationPolicy()
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to load debug annotation config")
+	}
+
+	debugAnnotation.Store(policy)
+
+	reload.Register("rpc_debug_annotation", func() error {
+		policy, err := loadDebugAnnotationPolicy()
+		if err != nil {
+			return err
+		}
+
+		debugAnnotation.Store(policy)
+		return nil
+	})
+}
+
+func loadDebugAnnotationPolicy() (*debugAnnotationPolicy, error) {
+	var conf DebugAnnotationConfig
+	if err := viper.UnmarshalKey("rpc.debugAnnotation", &conf); err != nil {
+		return nil, err
+	}
+
+	policy := debugAnnotationPolicy{DebugAnnotationConfig: conf, tokens: make(map[string]bool)}
+	for _, token := range conf.Tokens {
+		if len(token) > 0 {
+			policy.tokens[token] = true
+		}
+	}
+
+	return &policy, nil
+}
+
+// shouldAnnotate checks if response of the specified HTTP request should be annotated.
+func shouldAnnotate(r *http.Request) bool {
+	policy := debugAnnotation.Load().(*debugAnnotationPolicy)
+	if policy.Enabled {
+		return true
+	}
+
+	return len(policy.TrustedHeader) > 0 && policy.tokens[r.Header.Get(policy.TrustedHeader)]
+}
+
+// ServingLatency is the latency breakdown to serve a request.
+type ServingLatency struct {
+	Total string `json:"total"`
+	// node name => upstream latency, including the RPC handler
+	Upstream map[string]string `json:"upstream,omitempty"`
+}
+
+// ServingMetadata is the metadata to serve a request, which is appended to response for debugging.
+type ServingMetadata struct {
+	// upstream nodes requested in order, e.g. hedged or quorum reads
+	Nodes   []string `json:"nodes,omitempty"`
+	Retries int      `json:"retries"`
+	// cache status, e.g. `store:hit` or `store:miss`
+	Cache   string         `json:"cache,omitempty"`
+	Latency ServingLatency `json:"latency"`
+}
+
+// servingEntry is the serving metadata of a JSON-RPC request, which could be updated concurrently,
+// e.g. hedged requests.
+type servingEntry struct {
+	mu sync.Mutex
+	md ServingMetadata
+}
+
+func (e *servingEntry) update(fn func(md *ServingMetadata)) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	fn(&e.md)
+}
+
+// servingCollector collects serving metadata of requests in the same HTTP request, keyed by
+// JSON-RPC message ID.
+type servingCollector struct {
+	mu    sync.Mutex
+	items map[string]*servingEntry
+}
+
+func newServingCollector() *servingCollector {
+	return &servingCollector{items: make(map[string]*servingEntry)}
+}
+
+func (c *servingCollector) add(id json.RawMessage) *servingEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := &servingEntry{
+		md: ServingMetadata{Latency: ServingLatency{Upstream: make(map[string]string)}},
+	}
+	c.items[normalizeJsonRpcID(id)] = entry
+
+	return entry
+}
+
+func (c *servingCollector) get(id json.RawMessage) (*ServingMetadata, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.items[normalizeJsonRpcID(id)]
+	if !ok {
+		return nil, false
+	}
+
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	md := entry.md
+	return &md, true
+}
+
+func normalizeJsonRpcID(id json.RawMessage) string {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, id); err != nil {
+		return string(id)
+	}
+
+	return buf.String()
+}
+
+// annotateServing updates the serving metadata of the RPC request if debug annotation requested.
+func annotateServing(ctx context.Context, fn func(md *ServingMetadata)) {
+	if entry, ok := ctx.Value(ctxKeyServingEntry).(*servingEntry); ok {
+		entry.update(fn)
+	}
+}
+
+// annotateCacheStatus annotates the cache status of the RPC request if debug annotation requested.
+func annotateCacheStatus(ctx context.Context, status string) {
+	annotateServing(ctx, func(md *ServingMetadata) {
+		md.Cache = status
+	})
+}
+
+// servingTotalMiddleware measures the total latency to serve RPC request, which should be hooked
+// in front of other middlewares.
+func servingTotalMiddleware(next rpc.HandleCallMsgFunc) rpc.HandleCallMsgFunc {
+	return func(ctx context.Context, msg *rpc.JsonRpcMessage) *rpc.JsonRpcMessage {
+		collector, ok := ctx.Value(ctxKeyServingCollector).(*servingCollector)
+		if !ok {
+			return next(ctx, msg)
+		}
+
+		entry := collector.add(msg.ID)
+		ctx = context.WithValue(ctx, ctxKeyServingEntry, entry)
+
+		start := time.Now()
+		resp := next(ctx, msg)
+
+		entry.update(func(md *ServingMetadata) {
+			md.Latency.Total = time.Since(start).String()
+		})
+
+		return resp
+	}
+}
+
+// servingUpstreamMiddleware records the upstream node and latency to serve RPC request, which
+// should be hooked right after the client middleware.
+func servingUpstreamMiddleware(next rpc.HandleCallMsgFunc) rpc.HandleCallMsgFunc {
+	return func(ctx context.Context, msg *rpc.JsonRpcMessage) *rpc.JsonRpcMessage {
+		if _, ok := ctx.Value(ctxKeyServingEntry).(*servingEntry); !ok {
+			return next(ctx, msg)
+		}
+
+		start := time.Now()
+		resp := next(ctx, msg)
+		elapsed := time.Since(start)
+
+		if nodeName, ok := servingNodeName(ctx); ok {
+			annotateServing(ctx, func(md *ServingMetadata) {
+				md.Nodes = append(md.Nodes, nodeName)
+				md.Retries = len(md.Nodes) - 1
+				md.Latency.Upstream[nodeName] = elapsed.String()
+			})
+		}
+
+		return resp
+	}
+}
+
+// servingNodeName returns the name of upstream node in context, without any credential in URL.
+func servingNodeName(ctx context.Context) (string, bool) {
+	var rawUrl string
+
+	switch client := ctx.Value(ctxKeyClient).(type) {
+	case *node.Web3goClient:
+		rawUrl = client.URL
+	case sdk.ClientOperator:
+		rawUrl = client.GetNodeURL()
+	default:
+		return "", false
+	}
+
+	if u, err := url.Parse(rawUrl); err == nil && len(u.Host) > 0 {
+		return u.Host, true
+	}
+
+	return "", false
+}
+
+// annotationResponseWriter buffers the HTTP response so as to append serving metadata.
+type annotationResponseWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *annotationResponseWriter) WriteHeader(status int) { w.status = status }
+func (w *annotationResponseWriter) Write(b []byte) (int, error) {
+	return w.body.Write(b)
+}
+
+// annotateResponse appends serving metadata to the buffered JSON-RPC response, which could be
+// either a single response or a batch.
+func annotateResponse(body []byte, collector *servingCollector) []byte {
+	annotate := func(raw json.RawMessage) json.RawMessage {
+		var resp map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &resp); err != nil {
+			return raw
+		}
+
+		md, ok := collector.get(resp["id"])
+		if !ok {
+			return raw
+		}
+
+		annotation, err := json.Marshal(md)
+		if err != nil {
+			return raw
+		}
+
+		resp[servingExtensionField] = annotation
+
+		if annotated, err := json.Marshal(resp); err == nil {
+			return annotated
+		}
+
+		return raw
+	}
+
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) == 0 {
+		return body
+	}
+
+	if trimmed[0] != '[' {
+		return annotate(trimmed)
+	}
+
+	var batch []json.RawMessage
+	if err := json.Unmarshal(trimmed, &batch); err != nil {
+		return body
+	}
+
+	for i := range batch {
+		batch[i] = annotate(batch[i])
+	}
+
+	if annotated, err := json.Marshal(batch); err == nil {
+		return annotated
+	}
+
+	return body
+}
+
+// debugAnnotationMiddleware annotates JSON-RPC responses with serving metadata if requested.
+// Note, websocket requests are not supported, and annotated responses are never compressed.
+func debugAnnotationMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !shouldAnnotate(r) || r.Header.Get("Upgrade") != "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		collector := newServingCollector()
+		ctx := context.WithValue(r.Context(), ctxKeyServingCollector, collector)
+
+		// response body compressed by inner handlers could not be annotated
+		r = r.Clone(ctx)
+		r.Header.Del("Accept-Encoding")
+
+		aw := annotationResponseWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(&aw, r)
+
+		body := annotateResponse(aw.body.Bytes(), collector)
+
+		w.Header().Del("Content-Length")
+		w.WriteHeader(aw.status)
+		w.Write(body)
+	})
+}
@@ -0,0 +1,70 @@
+package rpc
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/scroll-tech/rpc-gateway/util/rpc/handlers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnnotateResponse(t *testing.T) {
+	collector := newServingCollector()
+	collector.add(json.RawMessage(`1`)).update(func(md *ServingMetadata) {
+		md.Nodes = []string{"node1:8545"}
+		md.Cache = "store:hit"
+	})
+
+	body := annotateResponse([]byte(`{"jsonrpc":"2.0","id":1,"result":"0x1"}`), collector)
+
+	var resp map[string]json.RawMessage
+	assert.NoError(t, json.Unmarshal(body, &resp))
+	assert.Equal(t, `"0x1"`, string(resp["result"]))
+
+	var md ServingMetadata
+	assert.NoError(t, json.Unmarshal(resp[servingExtensionField], &md))
+	assert.Equal(t, []string{"node1:8545"}, md.Nodes)
+	assert.Equal(t, "store:hit", md.Cache)
+
+	// batch response with unknown id untouched
+	body = annotateResponse([]byte(`[{"jsonrpc":"2.0","id":1,"result":"0x1"},{"jsonrpc":"2.0","id":2,"result":"0x2"}]`), collector)
+
+	var batch []map[string]json.RawMessage
+	assert.NoError(t, json.Unmarshal(body, &batch))
+	assert.Contains(t, batch[0], servingExtensionField)
+	assert.NotContains(t, batch[1], servingExtensionField)
+}
+
+func TestDebugAnnotationMiddlewareCompressed(t *testing.T) {
+	defer debugAnnotation.Store(debugAnnotation.Load())
+
+	debugAnnotation.Store(&debugAnnotationPolicy{DebugAnnotationConfig: DebugAnnotationConfig{Enabled: true}})
+
+	rpcHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if collector, ok := r.Context().Value(ctxKeyServingCollector).(*servingCollector); ok {
+			collector.add(json.RawMessage(`1`))
+		}
+
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":"0x1"}`))
+	})
+
+	compression := handlers.Compression(&handlers.CompressionConfig{
+		Enabled: true, GzipLevel: 6, Encodings: []string{"gzip"},
+	})
+	handler := debugAnnotationMiddleware(compression(rpcHandler))
+
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"eth_chainId"}`))
+	r.Header.Set("Accept-Encoding", "gzip")
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+
+	var resp map[string]json.RawMessage
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Contains(t, resp, servingExtensionField)
+}
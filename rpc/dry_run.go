@@ -0,0 +1,192 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/pkg/errors"
+	"github.com/scroll-tech/rpc-gateway/node"
+	"github.com/scroll-tech/rpc-gateway/util/metrics"
+	"github.com/scroll-tech/rpc-gateway/util/reload"
+	"github.com/scroll-tech/rpc-gateway/util/rpc/handlers"
+	"github.com/sirupsen/logrus"
+)
+
+const ctxKeyDryRun = handlers.CtxKey("Infura-RPC-Dry-Run")
+
+// DryRunResponseConfig canned response of RPC method in dry-run mode.
+type DryRunResponseConfig struct {
+	Method string
+	// JSON encoded result, e.g. `"0x1"` or `{"number":"0x1"}`
+	Result string
+}
+
+// DryRunConfig configurations of dry-run mode, in which requests are validated, routed, billed
+// in sandbox and logged as usual, but answered with canned responses without touching upstream
+// nodes, so that clients could integration test their pipelines against gateway safely.
+type DryRunConfig struct {
+	Enabled bool
+	// whether to dry run all requests, e.g. for staging gateway
+	All bool
+	// API keys of which requests are always dry run
+	Keys []string
+	// request header to opt in dry-run mode per request, empty means not allowed
+	Header string `default:"X-Dry-Run"`
+	// canned responses, and others are answered with `null` except raw transaction submissions
+	Responses []DryRunResponseConfig
+}
+
+type dryRunPolicy struct {
+	DryRunConfig
+	keys    map[string]bool
+	results map[string]json.RawMessage // method => result
+}
+
+// dryRun is the dry-run policy in use, which could be changed at runtime.
+var dryRun atomic.Value
+
+func init() {
+	policy, err := loadDryRunPolicy()
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to load dry-run config")
+	}
+
+	dryRun.Store(policy)
+
+	reload.Register("rpc_dry_run", func() error {
+		policy, err := loadDryRunPolicy()
+		if err != nil {
+			return err
+		}
+
+		dryRun.Store(policy)
+		return nil
+	})
+}
+
+func loadDryRunPolicy() (*dryRunPolicy, error) {
+	var conf DryRunConfig
+	if err := viper.UnmarshalKey("rpc.dryRun", &conf); err != nil {
+		return nil, err
+	}
+
+	policy := dryRunPolicy{
+		DryRunConfig: conf,
+		keys:         make(map[string]bool),
+		results:      make(map[string]json.RawMessage),
+	}
+
+	for _, key := range conf.Keys {
+		if len(key) > 0 {
+			policy.keys[key] = true
+		}
+	}
+
+	for _, cr := range conf.Responses {
+		if len(cr.Method) == 0 || !json.Valid([]byte(cr.Result)) {
+			return nil, errors.Errorf("invalid dry-run response %+v", cr)
+		}
+
+		policy.results[cr.Method] = json.RawMessage(cr.Result)
+	}
+
+	return &policy, nil
+}
+
+// withDryRun marks the HTTP request in dry-run mode if requested.
+func withDryRun(ctx context.Context, r *http.Request) context.Context {
+	policy := dryRun.Load().(*dryRunPolicy)
+	if !policy.Enabled {
+		return ctx
+	}
+
+	enabled := policy.All || policy.keys[handlers.GetAccessToken(r)]
+
+	if v := r.Header.Get(policy.Header); !enabled && len(policy.Header) > 0 && len(v) > 0 {
+		enabled, _ = strconv.ParseBool(v)
+	}
+
+	if enabled {
+		ctx = context.WithValue(ctx, ctxKeyDryRun, true)
+	}
+
+	return ctx
+}
+
+// isDryRun checks if the RPC request is in dry-run mode.
+func isDryRun(ctx context.Context) bool {
+	enabled, _ := ctx.Value(ctxKeyDryRun).(bool)
+	return enabled
+}
+
+// cannedResult returns the canned result of RPC request in dry-run mode.
+func (policy *dryRunPolicy) cannedResult(msg *rpc.JsonRpcMessage) json.RawMessage {
+	if result, ok := policy.results[msg.Method]; ok {
+		return result
+	}
+
+	switch msg.Method {
+	case "eth_sendRawTransaction", "cfx_sendRawTransaction":
+		// hash of the raw transaction, so that clients could track submissions
+		var params []hexutil.Bytes
+		if err := json.Unmarshal(msg.Params, &params); err == nil && len(params) > 0 {
+			if result, err := json.Marshal(crypto.Keccak256Hash(params[0])); err == nil {
+				return result
+			}
+		}
+	}
+
+	return json.RawMessage("null")
+}
+
+// sandboxBilling bypasses the billing middleware for requests in dry-run mode, which are never
+// charged but still aggregated in usage analytics.
+func sandboxBilling(billing rpc.HandleCallMsgMiddleware) rpc.HandleCallMsgMiddleware {
+	return func(next rpc.HandleCallMsgFunc) rpc.HandleCallMsgFunc {
+		billed := billing(next)
+
+		return func(ctx context.Context, msg *rpc.JsonRpcMessage) *rpc.JsonRpcMessage {
+			if isDryRun(ctx) {
+				return next(ctx, msg)
+			}
+
+			return billed(ctx, msg)
+		}
+	}
+}
+
+// dryRunMiddleware answers requests in dry-run mode with canned responses once routed, which
+// should be hooked right after the client middleware.
+func dryRunMiddleware(next rpc.HandleCallMsgFunc) rpc.HandleCallMsgFunc {
+	return func(ctx context.Context, msg *rpc.JsonRpcMessage) *rpc.JsonRpcMessage {
+		policy := dryRun.Load().(*dryRunPolicy)
+		if !policy.Enabled {
+			return next(ctx, msg)
+		}
+
+		dry := isDryRun(ctx)
+		metrics.Registry.RPC.Percentage(msg.Method, "dryRun").Mark(dry)
+
+		if !dry {
+			return next(ctx, msg)
+		}
+
+		group, _ := ctx.Value(ctxKeyGroup).(node.Group)
+		nodeName, _ := servingNodeName(ctx)
+
+		logrus.WithFields(logrus.Fields{
+			"method": msg.Method,
+			"group":  group,
+			"node":   nodeName,
+		}).Debug("RPC answered with canned response in dry-run mode")
+
+		return &rpc.JsonRpcMessage{Version: msg.Version, ID: msg.ID, Result: policy.cannedResult(msg)}
+	}
+}
@@ -0,0 +1,55 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDryRunMiddleware(t *testing.T) {
+	defer dryRun.Store(dryRun.Load())
+
+	dryRun.Store(&dryRunPolicy{
+		DryRunConfig: DryRunConfig{Enabled: true, Header: "X-Dry-Run"},
+		keys:         map[string]bool{"sandbox": true},
+		results:      map[string]json.RawMessage{"eth_blockNumber": json.RawMessage(`"0x1"`)},
+	})
+
+	var called bool
+	handler := dryRunMiddleware(func(ctx context.Context, msg *rpc.JsonRpcMessage) *rpc.JsonRpcMessage {
+		called = true
+		return &rpc.JsonRpcMessage{Version: msg.Version, ID: msg.ID, Result: json.RawMessage(`"0x10"`)}
+	})
+
+	call := func(ctx context.Context, method, params string) *rpc.JsonRpcMessage {
+		called = false
+		return handler(ctx, &rpc.JsonRpcMessage{
+			Version: "2.0", ID: json.RawMessage("1"), Method: method, Params: json.RawMessage(params),
+		})
+	}
+
+	// API key in dry-run mode
+	ctx := withDryRun(context.Background(), httptest.NewRequest("POST", "/sandbox", nil))
+	assert.Equal(t, json.RawMessage(`"0x1"`), call(ctx, "eth_blockNumber", "[]").Result)
+	assert.Equal(t, json.RawMessage("null"), call(ctx, "eth_getBalance", `["0x0000000000000000000000000000000000000000"]`).Result)
+	assert.Equal(t,
+		json.RawMessage(`"0xbc36789e7a1e281436464229828f817d6612f7b477d66591ff96a9e064bcc98a"`),
+		call(ctx, "eth_sendRawTransaction", `["0x00"]`).Result,
+	)
+	assert.False(t, called)
+
+	// opt in by request header
+	r := httptest.NewRequest("POST", "/normal", nil)
+	r.Header.Set("X-Dry-Run", "true")
+	call(withDryRun(context.Background(), r), "eth_blockNumber", "[]")
+	assert.False(t, called)
+
+	// not in dry-run mode
+	ctx = withDryRun(context.Background(), httptest.NewRequest("POST", "/normal", nil))
+	assert.Equal(t, json.RawMessage(`"0x10"`), call(ctx, "eth_blockNumber", "[]").Result)
+	assert.True(t, called)
+}
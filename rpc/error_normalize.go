@@ -0,0 +1,261 @@
+package rpc
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"sync/atomic"
+
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/pkg/errors"
+	"github.com/scroll-tech/rpc-gateway/util/metrics"
+	"github.com/scroll-tech/rpc-gateway/util/reload"
+	"github.com/sirupsen/logrus"
+)
+
+// errCodeReverted is the JSON-RPC error code of reverted calls, which conforms to geth.
+const errCodeReverted = 3
+
+// ErrorMappingRule maps upstream errors matched by code and message into normalized ones.
+type ErrorMappingRule struct {
+	// RPC methods to apply, which supports `*` suffix as wildcard, and empty means all methods
+	Methods []string
+	// upstream error code to match, and 0 means any code
+	Code int
+	// regular expression to match upstream error message, and empty means any message
+	Message string
+	// normalized error code, and 0 means unchanged
+	NewCode int
+	// normalized error message, which could reference submatches of `message`, e.g. `$1`, and
+	// empty means unchanged
+	NewMessage string
+
+	pattern *regexp.Regexp
+}
+
+func (rule *ErrorMappingRule) init() (err error) {
+	if rule.NewCode == 0 && len(rule.NewMessage) == 0 {
+		return errors.New("either new code or new message required")
+	}
+
+	if len(rule.Message) > 0 {
+		if rule.pattern, err = regexp.Compile(rule.Message); err != nil {
+			return errors.WithMessage(err, "invalid message pattern")
+		}
+	}
+
+	return nil
+}
+
+// apply normalizes the upstream error if matched, and returns false otherwise.
+func (rule *ErrorMappingRule) apply(method string, code int, message string) (int, string, bool) {
+	if len(rule.Methods) > 0 && !matchMethods(rule.Methods, method) {
+		return 0, "", false
+	}
+
+	if rule.Code != 0 && rule.Code != code {
+		return 0, "", false
+	}
+
+	if rule.pattern != nil && !rule.pattern.MatchString(message) {
+		return 0, "", false
+	}
+
+	if rule.NewCode != 0 {
+		code = rule.NewCode
+	}
+
+	if len(rule.NewMessage) > 0 {
+		if rule.pattern != nil {
+			message = rule.pattern.ReplaceAllString(message, rule.NewMessage)
+		} else {
+			message = rule.NewMessage
+		}
+	}
+
+	return code, message, true
+}
+
+// builtinErrorMappingRules normalizes well known divergences of execution clients, e.g. geth,
+// erigon, nethermind and besu, into geth conformant errors.
+var builtinErrorMappingRules = []ErrorMappingRule{
+	{
+		Methods:    []string{"eth_sendRawTransaction"},
+		Message:    `(?i)^.*(nonce too low|oldnonce).*$`,
+		NewCode:    -32000,
+		NewMessage: "nonce too low",
+	},
+	{
+		Methods:    []string{"eth_sendRawTransaction", "eth_call", "eth_estimateGas"},
+		Message:    `(?i)^.*(insufficient funds|upfront cost exceeds account balance).*$`,
+		NewCode:    -32000,
+		NewMessage: "insufficient funds for gas * price + value",
+	},
+	{
+		Methods:    []string{"eth_sendRawTransaction"},
+		Message:    `(?i)^.*(already known|alreadyknown|known transaction).*$`,
+		NewCode:    -32000,
+		NewMessage: "already known",
+	},
+	{
+		Methods:    []string{"eth_sendRawTransaction"},
+		Message:    `(?i)^.*(replacement transaction underpriced|replacementunderpriced).*$`,
+		NewCode:    -32000,
+		NewMessage: "replacement transaction underpriced",
+	},
+}
+
+func init() {
+	for i := range builtinErrorMappingRules {
+		if err := builtinErrorMappingRules[i].init(); err != nil {
+			logrus.WithError(err).Fatal("Invalid built-in error mapping rule")
+		}
+	}
+}
+
+// ErrorNormalizationConfig configurations to normalize upstream errors into consistent JSON-RPC
+// codes, messages and data regardless of which execution client served the request.
+type ErrorNormalizationConfig struct {
+	Enabled bool
+	// whether to normalize reverted calls and apply built-in rules after the configured ones
+	Builtin bool `default:"true"`
+	// rules to normalize upstream errors, of which the first matched applies
+	Rules []ErrorMappingRule
+}
+
+// errorNormalization is the error normalization config in use, which could be changed at runtime.
+var errorNormalization atomic.Value
+
+func init() {
+	conf, err := loadErrorNormalizationConfig()
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to load error normalization config")
+	}
+
+	errorNormalization.Store(conf)
+
+	reload.Register("rpc_error_normalization", func() error {
+		conf, err := loadErrorNormalizationConfig()
+		if err != nil {
+			return err
+		}
+
+		errorNormalization.Store(conf)
+		return nil
+	})
+}
+
+func loadErrorNormalizationConfig() (*ErrorNormalizationConfig, error) {
+	var conf ErrorNormalizationConfig
+	if err := viper.UnmarshalKey("rpc.errorNormalization", &conf); err != nil {
+		return nil, err
+	}
+
+	for i := range conf.Rules {
+		if err := conf.Rules[i].init(); err != nil {
+			return nil, errors.WithMessagef(err, "invalid error mapping rule #%v", i)
+		}
+	}
+
+	return &conf, nil
+}
+
+var (
+	// messages of reverted calls, e.g. `execution reverted: reason`, `Reverted 0x...` or
+	// `VM execution error.`, of which the revert reason is optional
+	revertedMessagePattern = regexp.MustCompile(`(?i)^(?:execution reverted|reverted|vm execution error\.?)(?::\s*(.+))?`)
+	// revert data in hex, e.g. `0x08c379a0...` or `Reverted 0x08c379a0...`
+	revertDataPattern = regexp.MustCompile(`0x[0-9a-fA-F]*`)
+)
+
+// normalizeReverted normalizes the error of reverted call into geth conformant error, namely
+// code 3 along with message `execution reverted: reason` and revert data in hex.
+func normalizeReverted(method string, code int, message string, data interface{}) (int, string, interface{}, bool) {
+	if method != "eth_call" && method != "eth_estimateGas" {
+		return 0, "", nil, false
+	}
+
+	matches := revertedMessagePattern.FindStringSubmatch(strings.TrimSpace(message))
+	if matches == nil {
+		return 0, "", nil, false
+	}
+
+	message = "execution reverted"
+	if reason := strings.TrimSpace(matches[1]); len(reason) > 0 && !strings.HasPrefix(reason, "0x") {
+		message += ": " + reason
+	}
+
+	if str, ok := data.(string); ok {
+		if hex := revertDataPattern.FindString(str); len(hex) > 0 {
+			data = hex
+		} else {
+			// revert reason only, e.g. `revert: reason`
+			data = nil
+		}
+	}
+
+	return errCodeReverted, message, data, true
+}
+
+// normalize normalizes the upstream error of response, and returns false if not matched.
+func (conf *ErrorNormalizationConfig) normalize(method string, resp *rpc.JsonRpcMessage) bool {
+	rpcErr := resp.Error
+
+	for i := range conf.Rules {
+		if code, message, ok := conf.Rules[i].apply(method, rpcErr.Code, rpcErr.Message); ok {
+			rpcErr.Code, rpcErr.Message = code, message
+			return true
+		}
+	}
+
+	if !conf.Builtin {
+		return false
+	}
+
+	if code, message, data, ok := normalizeReverted(method, rpcErr.Code, rpcErr.Message, rpcErr.Data); ok {
+		rpcErr.Code, rpcErr.Message, rpcErr.Data = code, message, data
+		return true
+	}
+
+	for i := range builtinErrorMappingRules {
+		if code, message, ok := builtinErrorMappingRules[i].apply(method, rpcErr.Code, rpcErr.Message); ok {
+			rpcErr.Code, rpcErr.Message = code, message
+			return true
+		}
+	}
+
+	return false
+}
+
+// errorNormalizationMiddleware normalizes upstream errors into consistent JSON-RPC errors.
+func errorNormalizationMiddleware(next rpc.HandleCallMsgFunc) rpc.HandleCallMsgFunc {
+	return func(ctx context.Context, msg *rpc.JsonRpcMessage) *rpc.JsonRpcMessage {
+		conf := errorNormalization.Load().(*ErrorNormalizationConfig)
+		if !conf.Enabled {
+			return next(ctx, msg)
+		}
+
+		resp := next(ctx, msg)
+		if resp == nil || resp.Error == nil {
+			return resp
+		}
+
+		code, message := resp.Error.Code, resp.Error.Message
+		if !conf.normalize(msg.Method, resp) {
+			return resp
+		}
+
+		metrics.Registry.RPC.ErrorNormalized(msg.Method).Mark(1)
+
+		logrus.WithFields(logrus.Fields{
+			"method":     msg.Method,
+			"code":       code,
+			"message":    message,
+			"newCode":    resp.Error.Code,
+			"newMessage": resp.Error.Message,
+		}).Debug("RPC upstream error normalized")
+
+		return resp
+	}
+}
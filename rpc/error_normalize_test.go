@@ -0,0 +1,67 @@
+package rpc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeReverted(t *testing.T) {
+	// geth
+	code, message, data, ok := normalizeReverted("eth_call", 3, "execution reverted: not owner", "0x08c379a0")
+	assert.True(t, ok)
+	assert.Equal(t, errCodeReverted, code)
+	assert.Equal(t, "execution reverted: not owner", message)
+	assert.Equal(t, "0x08c379a0", data)
+
+	// nethermind
+	code, message, data, ok = normalizeReverted("eth_estimateGas", -32015, "VM execution error.", "Reverted 0x08c379a0")
+	assert.True(t, ok)
+	assert.Equal(t, errCodeReverted, code)
+	assert.Equal(t, "execution reverted", message)
+	assert.Equal(t, "0x08c379a0", data)
+
+	// besu
+	_, message, data, ok = normalizeReverted("eth_call", -32000, "Execution reverted", "0x")
+	assert.True(t, ok)
+	assert.Equal(t, "execution reverted", message)
+	assert.Equal(t, "0x", data)
+
+	_, _, _, ok = normalizeReverted("eth_sendRawTransaction", 3, "execution reverted", nil)
+	assert.False(t, ok)
+
+	_, _, _, ok = normalizeReverted("eth_call", -32000, "header not found", nil)
+	assert.False(t, ok)
+}
+
+func TestErrorMappingRule(t *testing.T) {
+	rule := ErrorMappingRule{
+		Methods:    []string{"eth_send*"},
+		Code:       -32010,
+		Message:    `(?i)^gas price too low: (\d+)$`,
+		NewCode:    -32000,
+		NewMessage: "transaction underpriced: $1",
+	}
+	assert.Nil(t, rule.init())
+
+	code, message, ok := rule.apply("eth_sendRawTransaction", -32010, "Gas price too low: 100")
+	assert.True(t, ok)
+	assert.Equal(t, -32000, code)
+	assert.Equal(t, "transaction underpriced: 100", message)
+
+	_, _, ok = rule.apply("eth_call", -32010, "Gas price too low: 100")
+	assert.False(t, ok)
+
+	_, _, ok = rule.apply("eth_sendRawTransaction", -32000, "Gas price too low: 100")
+	assert.False(t, ok)
+
+	for _, msg := range []string{"nonce too low", "OldNonce", "Nonce too low: next nonce 5"} {
+		code, message, ok := builtinErrorMappingRules[0].apply("eth_sendRawTransaction", -32010, msg)
+		assert.True(t, ok, msg)
+		assert.Equal(t, -32000, code)
+		assert.Equal(t, "nonce too low", message)
+	}
+
+	assert.NotNil(t, (&ErrorMappingRule{Message: "nonce"}).init())
+	assert.NotNil(t, (&ErrorMappingRule{NewCode: -32000, Message: "("}).init())
+}
@@ -5,6 +5,7 @@ import (
 	"math/big"
 	"math/bits"
 
+	"github.com/Conflux-Chain/go-conflux-util/viper"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/rpc"
@@ -16,8 +17,11 @@ import (
 	"github.com/scroll-tech/rpc-gateway/rpc/cache"
 	"github.com/scroll-tech/rpc-gateway/rpc/handler"
 	"github.com/scroll-tech/rpc-gateway/store"
+	"github.com/scroll-tech/rpc-gateway/store/blockcache"
 	"github.com/scroll-tech/rpc-gateway/util"
+	"github.com/scroll-tech/rpc-gateway/util/feature"
 	"github.com/scroll-tech/rpc-gateway/util/metrics"
+	"github.com/scroll-tech/rpc-gateway/util/relay"
 	"github.com/sirupsen/logrus"
 )
 
@@ -34,10 +38,18 @@ var (
 type EthAPIOption struct {
 	StoreHandler  *handler.EthStoreHandler
 	LogApiHandler *handler.EthLogsApiHandler
+	Sequencer     *relay.SequencerRouter // route raw transactions to rollup sequencer(s) if enabled
+	PrivateRelay  *relay.SequencerRouter // route raw transactions of opted-in tenants to private relay(s) if enabled
 }
 
-func updateEthStoreHitRatio(method string, hit bool) {
+func updateEthStoreHitRatio(ctx context.Context, method string, hit bool) {
 	metrics.Registry.RPC.StoreHit(method, "store").Mark(hit)
+
+	if hit {
+		annotateCacheStatus(ctx, "store:hit")
+	} else {
+		annotateCacheStatus(ctx, "store:miss")
+	}
 }
 
 // ethAPI provides Ethereum relative API within evm space according to:
@@ -46,7 +58,16 @@ type ethAPI struct {
 	EthAPIOption
 
 	provider         *node.EthClientProvider
+	cache            *cache.EthCache
 	inputBlockMetric metrics.InputBlockMetric
+	txInclusion      *txInclusionTracker
+	txBroadcast      TxBroadcastConfig
+	txDedup          *txDedupCache
+	txReplay         *txReplayGuard
+	txValidator      *txValidator
+	blockCache       blockcache.Caches
+	callCache        *ethCallCache // nil if disabled
+	logsPage         LogsPageConfig
 
 	hardforkBlockNumber *rpc.BlockNumber // return default value before eSpace hardfork
 }
@@ -76,11 +97,46 @@ func mustNewEthAPI(provider *node.EthClientProvider, option ...EthAPIOption) *et
 		opt = option[0]
 	}
 
-	return &ethAPI{
+	space := "eth"
+	if chain := provider.Chain(); len(chain) > 0 {
+		space = chain
+	}
+
+	api := ethAPI{
 		EthAPIOption:        opt,
 		provider:            provider,
+		cache:               cache.Eth(provider.Chain()),
+		txInclusion:         newTxInclusionTracker(space),
 		hardforkBlockNumber: hardforkBlockNumber,
 	}
+
+	viper.MustUnmarshalKey("ethrpc.txBroadcast", &api.txBroadcast)
+
+	var dedupConf TxDedupConfig
+	viper.MustUnmarshalKey("ethrpc.txDedup", &dedupConf)
+	api.txDedup = newTxDedupCache(dedupConf)
+
+	var replayConf TxReplayConfig
+	viper.MustUnmarshalKey("ethrpc.txReplay", &replayConf)
+	api.txReplay = newTxReplayGuard(replayConf)
+
+	var validationConf TxValidationConfig
+	viper.MustUnmarshalKey("ethrpc.txValidation", &validationConf)
+	api.txValidator = newTxValidator(validationConf, *chainId)
+
+	var blockCacheConf blockcache.Config
+	viper.MustUnmarshalKey("ethrpc.blockCache", &blockCacheConf)
+	if api.blockCache, err = blockcache.OpenCaches(blockCacheConf.Paths, *chainId); err != nil {
+		logrus.WithError(err).Fatal("Failed to mount block cache files")
+	}
+
+	var callCacheConf EthCallCacheConfig
+	viper.MustUnmarshalKey("ethrpc.callCache", &callCacheConf)
+	api.callCache = newEthCallCache(callCacheConf)
+
+	viper.MustUnmarshalKey("ethrpc.logsPage", &api.logsPage)
+
+	return &api
 }
 
 // GetBlockByHash returns the requested block. When fullTx is true all transactions in
@@ -94,9 +150,13 @@ func (api *ethAPI) GetBlockByHash(
 		"blockHash": blockHash.Hex(), "includeTxs": fullTx,
 	})
 
-	if !store.EthStoreConfig().IsChainBlockDisabled() && !util.IsInterfaceValNil(api.StoreHandler) {
+	if block, ok := api.cachedBlockByHash(ctx, blockHash, fullTx); ok {
+		return block, nil
+	}
+
+	if !store.EthStoreConfig().IsChainBlockDisabled() && !util.IsInterfaceValNil(api.StoreHandler) && !cacheBypassed(ctx) {
 		block, err := api.StoreHandler.GetBlockByHash(ctx, blockHash, fullTx)
-		updateEthStoreHitRatio("eth_getBlockByHash", err == nil)
+		updateEthStoreHitRatio(ctx, "eth_getBlockByHash", err == nil)
 		if err == nil {
 			logger.Debug("Loading eth data for eth_getBlockByHash hit in the store")
 			return block, nil
@@ -113,13 +173,19 @@ func (api *ethAPI) GetBlockByHash(
 // ChainId returns the chainID value for transaction replay protection.
 func (api *ethAPI) ChainId(ctx context.Context) (*hexutil.Uint64, error) {
 	w3c := GetEthClientFromContext(ctx)
-	return cache.EthDefault.GetChainId(w3c.Client)
+	return api.cache.GetChainId(w3c.Client)
 }
 
 // BlockNumber returns the block number of the chain head.
 func (api *ethAPI) BlockNumber(ctx context.Context) (*hexutil.Big, error) {
 	w3c := GetEthClientFromContext(ctx)
-	return cache.EthDefault.GetBlockNumber(w3c)
+
+	if cacheBypassed(ctx) {
+		bn, err := w3c.Eth.BlockNumber()
+		return (*hexutil.Big)(bn), err
+	}
+
+	return api.cache.GetBlockNumber(w3c)
 }
 
 // GetBalance returns the amount of wei for the given address in the state of the
@@ -150,9 +216,13 @@ func (api *ethAPI) GetBlockByNumber(
 	w3c := GetEthClientFromContext(ctx)
 	api.inputBlockMetric.Update1(&blockNum, "eth_getBlockByNumber", w3c.Eth)
 
-	if !store.EthStoreConfig().IsChainBlockDisabled() && !util.IsInterfaceValNil(api.StoreHandler) {
+	if block, ok := api.cachedBlockByNumber(ctx, blockNum, fullTx); ok {
+		return block, nil
+	}
+
+	if !store.EthStoreConfig().IsChainBlockDisabled() && !util.IsInterfaceValNil(api.StoreHandler) && !cacheBypassed(ctx) {
 		block, err := api.StoreHandler.GetBlockByNumber(ctx, &blockNum, fullTx)
-		updateEthStoreHitRatio("eth_getBlockByNumber", err == nil)
+		updateEthStoreHitRatio(ctx, "eth_getBlockByNumber", err == nil)
 		if err == nil {
 			logger.Debug("Loading eth data for eth_getBlockByNumber hit in the store")
 			return block, nil
@@ -193,7 +263,14 @@ func (api *ethAPI) ProtocolVersion(ctx context.Context) (string, error) {
 // GasPrice returns the current gas price in wei.
 func (api *ethAPI) GasPrice(ctx context.Context) (*hexutil.Big, error) {
 	w3c := GetEthClientFromContext(ctx)
-	return cache.EthDefault.GetGasPrice(w3c.Client)
+
+	// gas price is cached by gas oracle across nodes
+	if gasOracleEnabled() || cacheBypassed(ctx) {
+		price, err := w3c.Eth.GasPrice()
+		return (*hexutil.Big)(price), err
+	}
+
+	return api.cache.GetGasPrice(w3c.Client)
 }
 
 // GetStorageAt returns the value from a storage position at a given address.
@@ -231,14 +308,64 @@ func (api *ethAPI) GetTransactionCount(
 // If the transaction was a contract creation use the TransactionReceipt method to get the
 // contract address after the transaction has been mined.
 func (api *ethAPI) SendRawTransaction(ctx context.Context, signedTx hexutil.Bytes) (common.Hash, error) {
-	w3c := GetEthClientFromContext(ctx)
-	return w3c.Eth.SendRawTransaction(signedTx)
+	send := func(w3c *node.Web3goClient) (common.Hash, error) {
+		return w3c.Eth.SendRawTransaction(signedTx)
+	}
+
+	return api.sendRawTransaction(ctx, "eth_sendRawTransaction", signedTx, send)
 }
 
 // SubmitTransaction is an alias of `SendRawTransaction` method.
 func (api *ethAPI) SubmitTransaction(ctx context.Context, signedTx hexutil.Bytes) (common.Hash, error) {
-	w3c := GetEthClientFromContext(ctx)
-	return w3c.Eth.SubmitTransaction(signedTx)
+	send := func(w3c *node.Web3goClient) (common.Hash, error) {
+		return w3c.Eth.SubmitTransaction(signedTx)
+	}
+
+	return api.sendRawTransaction(ctx, "eth_submitTransaction", signedTx, send)
+}
+
+// sendRawTransaction sends raw transaction to private relay for opted-in tenants or sequencer if
+// enabled, otherwise to the fullnode(s) by the specified send function, and tracks the transaction
+// for inclusion latency. Note, duplicate submissions within the dedup window are answered from cache.
+func (api *ethAPI) sendRawTransaction(
+	ctx context.Context, method string, signedTx hexutil.Bytes,
+	send func(w3c *node.Web3goClient) (common.Hash, error),
+) (common.Hash, error) {
+	if err := api.txValidator.check(method, signedTx); err != nil {
+		return common.Hash{}, err
+	}
+
+	if err := api.txReplay.check(ctx, method, signedTx); err != nil {
+		return common.Hash{}, err
+	}
+
+	if txHash, ok := api.txDedup.get(method, signedTx); ok {
+		return txHash, nil
+	}
+
+	var url string
+	var txHash common.Hash
+	var err error
+
+	private := api.PrivateRelay.Enabled() && feature.OptedIn(ctx, featurePrivateRelay)
+	metrics.Registry.RPC.Percentage(method, "private").Mark(private)
+
+	if private {
+		// never fall back to public nodes to avoid exposure in public mempool
+		txHash, url, err = api.PrivateRelay.SendRawTransaction(signedTx)
+	} else if api.Sequencer.Enabled() {
+		txHash, url, err = api.Sequencer.SendRawTransaction(signedTx)
+	} else {
+		txHash, url, err = broadcastTx(api.txBroadcast, api.provider, GetEthClientFromContext(ctx), send)
+	}
+
+	if err == nil {
+		api.txDedup.add(signedTx, txHash)
+		api.txReplay.add(ctx, signedTx)
+		api.txInclusion.onBroadcast(txHash, url)
+	}
+
+	return txHash, err
 }
 
 // Call executes a new message call immediately without creating a transaction on the block chain.
@@ -247,7 +374,16 @@ func (api *ethAPI) Call(
 ) (hexutil.Bytes, error) {
 	w3c := GetEthClientFromContext(ctx)
 	api.inputBlockMetric.Update2(blockNumOrHash, "eth_call", w3c.Eth)
-	return w3c.Eth.Call(request, blockNumOrHash)
+
+	result, err := api.cachedCall(ctx, w3c, "eth_call", &request, blockNumOrHash, func() (interface{}, error) {
+		data, err := w3c.Eth.Call(request, blockNumOrHash)
+		return hexutil.Bytes(data), err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result.(hexutil.Bytes), nil
 }
 
 // EstimateGas generates and returns an estimate of how much gas is necessary to allow the transaction
@@ -259,17 +395,29 @@ func (api *ethAPI) EstimateGas(
 ) (*hexutil.Big, error) {
 	w3c := GetEthClientFromContext(ctx)
 	api.inputBlockMetric.Update2(blockNumOrHash, "eth_estimateGas", w3c.Eth)
-	gas, err := w3c.Eth.EstimateGas(request, blockNumOrHash)
-	return (*hexutil.Big)(gas), err
+
+	gas, err := api.cachedCall(ctx, w3c, "eth_estimateGas", &request, blockNumOrHash, func() (interface{}, error) {
+		return w3c.Eth.EstimateGas(request, blockNumOrHash)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// copy to avoid cached value modified
+	return (*hexutil.Big)(new(big.Int).Set(gas.(*big.Int))), nil
 }
 
 // TransactionByHash returns the transaction with the given hash.
 func (api *ethAPI) GetTransactionByHash(ctx context.Context, hash common.Hash) (*web3Types.TransactionDetail, error) {
 	logger := logrus.WithField("txHash", hash.Hex())
 
-	if !store.EthStoreConfig().IsChainTxnDisabled() && !util.IsInterfaceValNil(api.StoreHandler) {
+	if tx, ok := api.cachedTransactionByHash(ctx, hash); ok {
+		return tx, nil
+	}
+
+	if !store.EthStoreConfig().IsChainTxnDisabled() && !util.IsInterfaceValNil(api.StoreHandler) && !cacheBypassed(ctx) {
 		tx, err := api.StoreHandler.GetTransactionByHash(ctx, hash)
-		updateEthStoreHitRatio("eth_getTransactionByHash", err == nil)
+		updateEthStoreHitRatio(ctx, "eth_getTransactionByHash", err == nil)
 		if err == nil {
 			logger.Debug("Loading eth data for eth_getTransactionByHash hit in the store")
 			return tx, nil
@@ -289,9 +437,13 @@ func (api *ethAPI) GetTransactionByHash(ctx context.Context, hash common.Hash) (
 func (api *ethAPI) GetTransactionReceipt(ctx context.Context, txHash common.Hash) (*web3Types.Receipt, error) {
 	logger := logrus.WithField("txHash", txHash.Hex())
 
-	if !store.EthStoreConfig().IsChainReceiptDisabled() && !util.IsInterfaceValNil(api.StoreHandler) {
+	if receipt, ok := api.cachedTransactionReceipt(ctx, txHash); ok {
+		return receipt, nil
+	}
+
+	if !store.EthStoreConfig().IsChainReceiptDisabled() && !util.IsInterfaceValNil(api.StoreHandler) && !cacheBypassed(ctx) {
 		tx, err := api.StoreHandler.GetTransactionReceipt(ctx, txHash)
-		updateEthStoreHitRatio("eth_getTransactionReceipt", err == nil)
+		updateEthStoreHitRatio(ctx, "eth_getTransactionReceipt", err == nil)
 		if err == nil {
 			logger.Debug("Loading eth data for eth_getTransactionReceipt hit in the ethstore")
 			return tx, nil
@@ -306,38 +458,65 @@ func (api *ethAPI) GetTransactionReceipt(ctx context.Context, txHash common.Hash
 	receipt, err := w3c.Eth.TransactionReceipt(txHash)
 	if err != nil {
 		metrics.Registry.RPC.Percentage("eth_getTransactionReceipt", "notfound").Mark(receipt == nil)
+	} else if receipt != nil {
+		api.txInclusion.onReceipt(txHash, w3c.URL)
 	}
 
 	return receipt, err
 }
 
-// GetLogs returns an array of all logs matching a given filter object.
-func (api *ethAPI) GetLogs(ctx context.Context, filter web3Types.FilterQuery) ([]web3Types.Log, error) {
+// GetLogs returns an array of all logs matching a given filter object. As a gateway specific
+// extension, logs are returned page by page along with cursor if page option specified.
+func (api *ethAPI) GetLogs(
+	ctx context.Context, filter web3Types.FilterQuery, page *LogsPageOption,
+) (interface{}, error) {
+	if page != nil {
+		return api.getLogsPage(ctx, filter, page)
+	}
+
 	w3c := GetEthClientFromContext(ctx)
-	api.metricLogFilter(w3c.Eth, &filter)
 
-	flag, ok := store.ParseEthLogFilterType(&filter)
+	if ok, err := api.prepareLogFilter(w3c, &filter); !ok {
+		return ethEmptyLogs, err
+	}
+
+	return api.getLogs(ctx, w3c, filter)
+}
+
+// prepareLogFilter normalizes and validates the log filter, and returns false if no need
+// to query event logs any more.
+func (api *ethAPI) prepareLogFilter(w3c *node.Web3goClient, filter *web3Types.FilterQuery) (bool, error) {
+	api.metricLogFilter(w3c.Eth, filter)
+
+	flag, ok := store.ParseEthLogFilterType(filter)
 	if !ok {
-		return ethEmptyLogs, errInvalidEthLogFilter
+		return false, errInvalidEthLogFilter
 	}
 
-	if err := api.normalizeLogFilter(w3c.Client, flag, &filter); err != nil {
-		return ethEmptyLogs, err
+	if err := api.normalizeLogFilter(w3c.Client, flag, filter); err != nil {
+		return false, err
 	}
 
-	if err := api.validateLogFilter(flag, &filter); err != nil {
-		api.filterLogger(&filter).
+	if err := api.validateLogFilter(flag, filter); err != nil {
+		api.filterLogger(filter).
 			WithError(err).
 			Debug("Invalid log filter parameter for eth_getLogs rpc request")
 
-		return ethEmptyLogs, err
+		return false, err
 	}
 
 	// return empty directly if filter block range before eSpace hardfork
 	if filter.ToBlock != nil && *filter.ToBlock <= *api.hardforkBlockNumber {
-		return ethEmptyLogs, nil
+		return false, nil
 	}
 
+	return true, nil
+}
+
+// getLogs queries event logs from log api handler if configured, otherwise from fullnode.
+func (api *ethAPI) getLogs(
+	ctx context.Context, w3c *node.Web3goClient, filter web3Types.FilterQuery,
+) ([]web3Types.Log, error) {
 	if api.LogApiHandler != nil {
 		logs, hitStore, err := api.LogApiHandler.GetLogs(ctx, w3c.Client.Eth, &filter)
 
@@ -345,7 +524,7 @@ func (api *ethAPI) GetLogs(ctx context.Context, filter web3Types.FilterQuery) ([
 			WithError(err).
 			Debug("Delegated `eth_getLogs` to log api handler")
 
-		updateEthStoreHitRatio("eth_getLogs", hitStore)
+		updateEthStoreHitRatio(ctx, "eth_getLogs", hitStore)
 
 		if logs == nil { // uniform empty logs
 			logs = ethEmptyLogs
@@ -358,7 +537,11 @@ func (api *ethAPI) GetLogs(ctx context.Context, filter web3Types.FilterQuery) ([
 
 	api.filterLogger(&filter).
 		Debug("Fail over `eth_getLogs` to fullnode due to no API handler configured")
-	return w3c.Eth.Logs(filter)
+
+	// pass request context along, so that oversized response could be aborted while reading
+	var logs []web3Types.Log
+	err := w3c.Eth.CallContext(ctx, &logs, "eth_getLogs", filter)
+	return logs, err
 }
 
 // GetBlockTransactionCountByHash returns the total number of transactions in the given block.
@@ -0,0 +1,72 @@
+package rpc
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/common"
+	web3Types "github.com/openweb3/web3go/types"
+	"github.com/pkg/errors"
+	"github.com/scroll-tech/rpc-gateway/store"
+	"github.com/scroll-tech/rpc-gateway/util/metrics"
+	"github.com/sirupsen/logrus"
+)
+
+// Finalized historical blocks and receipts are served from memory mapped block cache files
+// if mounted, before the store and full nodes.
+
+func (api *ethAPI) onBlockCacheLookup(ctx context.Context, method string, err error) bool {
+	hit := err == nil
+	metrics.Registry.RPC.StoreHit(method, "blockcache").Mark(hit)
+
+	if hit {
+		annotateCacheStatus(ctx, "blockcache:hit")
+	} else if !errors.Is(err, store.ErrNotFound) {
+		logrus.WithError(err).WithField("method", method).Warn("Failed to lookup block cache")
+	}
+
+	return hit
+}
+
+func (api *ethAPI) cachedBlockByHash(
+	ctx context.Context, hash common.Hash, fullTx bool,
+) (*web3Types.Block, bool) {
+	if len(api.blockCache) == 0 || cacheBypassed(ctx) {
+		return nil, false
+	}
+
+	block, err := api.blockCache.BlockByHash(hash, fullTx)
+	return block, api.onBlockCacheLookup(ctx, "eth_getBlockByHash", err)
+}
+
+func (api *ethAPI) cachedBlockByNumber(
+	ctx context.Context, blockNum web3Types.BlockNumber, fullTx bool,
+) (*web3Types.Block, bool) {
+	if len(api.blockCache) == 0 || blockNum < 0 || cacheBypassed(ctx) {
+		return nil, false
+	}
+
+	block, err := api.blockCache.BlockByNumber(uint64(blockNum), fullTx)
+	return block, api.onBlockCacheLookup(ctx, "eth_getBlockByNumber", err)
+}
+
+func (api *ethAPI) cachedTransactionByHash(
+	ctx context.Context, hash common.Hash,
+) (*web3Types.TransactionDetail, bool) {
+	if len(api.blockCache) == 0 || cacheBypassed(ctx) {
+		return nil, false
+	}
+
+	tx, err := api.blockCache.TransactionByHash(hash)
+	return tx, api.onBlockCacheLookup(ctx, "eth_getTransactionByHash", err)
+}
+
+func (api *ethAPI) cachedTransactionReceipt(
+	ctx context.Context, hash common.Hash,
+) (*web3Types.Receipt, bool) {
+	if len(api.blockCache) == 0 || cacheBypassed(ctx) {
+		return nil, false
+	}
+
+	receipt, err := api.blockCache.TransactionReceipt(hash)
+	return receipt, api.onBlockCacheLookup(ctx, "eth_getTransactionReceipt", err)
+}
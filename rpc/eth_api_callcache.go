@@ -0,0 +1,139 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/ethereum/go-ethereum/common"
+	lru "github.com/hashicorp/golang-lru"
+	web3Types "github.com/openweb3/web3go/types"
+	"github.com/scroll-tech/rpc-gateway/node"
+	"github.com/scroll-tech/rpc-gateway/util/metrics"
+	"github.com/sirupsen/logrus"
+)
+
+// EthCallCacheConfig configurations to cache `eth_call` and `eth_estimateGas` results of
+// historical blocks, which are immutable and keyed by hash of the requested block.
+type EthCallCacheConfig struct {
+	// max number of cached results, and 0 to disable
+	Size int
+	// number of blocks behind the latest one regarded as immutable if requested by number,
+	// while blocks requested by hash are always immutable
+	Confirmations uint64 `default:"64"`
+}
+
+// ethCallCache caches call results by block hash, so that indexers re-querying view functions
+// at fixed heights could be served without full nodes. Note, state root is not used as key,
+// since blocks may share the same state root, e.g. consecutive empty blocks, while calls may
+// depend on block context, e.g. block number or timestamp.
+type ethCallCache struct {
+	EthCallCacheConfig
+	results *lru.Cache // method, block hash and call request => result
+	hashes  *lru.Cache // block number => block hash
+}
+
+func newEthCallCache(conf EthCallCacheConfig) *ethCallCache {
+	if conf.Size <= 0 {
+		return nil
+	}
+
+	results, _ := lru.New(conf.Size)
+	hashes, _ := lru.New(conf.Size)
+
+	return &ethCallCache{
+		EthCallCacheConfig: conf,
+		results:            results,
+		hashes:             hashes,
+	}
+}
+
+// blockHash returns the hash of the requested block if immutable.
+func (c *ethCallCache) blockHash(
+	api *ethAPI, w3c *node.Web3goClient, blockNumOrHash *web3Types.BlockNumberOrHash,
+) (common.Hash, bool) {
+	if blockNumOrHash == nil {
+		return common.Hash{}, false
+	}
+
+	if blockNumOrHash.BlockHash != nil {
+		return *blockNumOrHash.BlockHash, true
+	}
+
+	// block tags, e.g. latest or pending
+	if blockNumOrHash.BlockNumber == nil || *blockNumOrHash.BlockNumber < 0 {
+		return common.Hash{}, false
+	}
+
+	bn := uint64(*blockNumOrHash.BlockNumber)
+	if hash, ok := c.hashes.Get(bn); ok {
+		return hash.(common.Hash), true
+	}
+
+	// blocks finalized on L1 are immutable regardless of confirmations
+	if len(api.provider.Chain()) > 0 || !isFinalizedBlock(bn) {
+		latest, err := api.cache.GetBlockNumber(w3c)
+		if err != nil || latest.ToInt().Uint64() < bn+c.Confirmations {
+			return common.Hash{}, false
+		}
+	}
+
+	block, err := w3c.Eth.BlockByNumber(*blockNumOrHash.BlockNumber, false)
+	if err != nil || block == nil {
+		return common.Hash{}, false
+	}
+
+	c.hashes.Add(bn, block.Hash)
+
+	return block.Hash, true
+}
+
+// resultKey returns the cache key of call result, or false if the requested block is mutable.
+func (c *ethCallCache) resultKey(
+	api *ethAPI, w3c *node.Web3goClient, method string,
+	request *web3Types.CallRequest, blockNumOrHash *web3Types.BlockNumberOrHash,
+) (string, bool) {
+	hash, ok := c.blockHash(api, w3c, blockNumOrHash)
+	if !ok {
+		return "", false
+	}
+
+	data, err := json.Marshal(request)
+	if err != nil {
+		logrus.WithError(err).Debug("Failed to marshal call request for call cache")
+		return "", false
+	}
+
+	return method + hash.Hex() + string(data), true
+}
+
+// cachedCall returns the cached result of `eth_call` or `eth_estimateGas`, or calls the full
+// node and caches the result if the requested block is immutable.
+func (api *ethAPI) cachedCall(
+	ctx context.Context, w3c *node.Web3goClient, method string,
+	request *web3Types.CallRequest, blockNumOrHash *web3Types.BlockNumberOrHash,
+	call func() (interface{}, error),
+) (interface{}, error) {
+	if api.callCache == nil || cacheBypassed(ctx) {
+		return call()
+	}
+
+	key, ok := api.callCache.resultKey(api, w3c, method, request, blockNumOrHash)
+	if !ok {
+		return call()
+	}
+
+	if result, ok := api.callCache.results.Get(key); ok {
+		metrics.Registry.RPC.StoreHit(method, "callcache").Mark(true)
+		annotateCacheStatus(ctx, "callcache:hit")
+		return result, nil
+	}
+
+	metrics.Registry.RPC.StoreHit(method, "callcache").Mark(false)
+
+	result, err := call()
+	if err == nil {
+		api.callCache.results.Add(key, result)
+	}
+
+	return result, err
+}
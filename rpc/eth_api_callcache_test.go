@@ -0,0 +1,55 @@
+package rpc
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	web3Types "github.com/openweb3/web3go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEthCallCacheResultKey(t *testing.T) {
+	cache := newEthCallCache(EthCallCacheConfig{Size: 16, Confirmations: 64})
+
+	to := common.HexToAddress("0x0000000000000000000000000000000000000001")
+	request := &web3Types.CallRequest{To: &to}
+
+	// consecutive empty blocks sharing the same state root
+	hash100 := common.HexToHash("0x01")
+	hash101 := common.HexToHash("0x02")
+	cache.hashes.Add(uint64(100), hash100)
+	cache.hashes.Add(uint64(101), hash101)
+
+	byHash := func(hash common.Hash) *web3Types.BlockNumberOrHash {
+		bnh := web3Types.BlockNumberOrHashWithHash(hash, false)
+		return &bnh
+	}
+
+	byNumber := func(bn web3Types.BlockNumber) *web3Types.BlockNumberOrHash {
+		bnh := web3Types.BlockNumberOrHashWithNumber(bn)
+		return &bnh
+	}
+
+	tests := []struct {
+		blockNumOrHash *web3Types.BlockNumberOrHash
+		key            string
+		ok             bool
+	}{
+		{byHash(hash100), "eth_call" + hash100.Hex(), true},
+		{byHash(hash101), "eth_call" + hash101.Hex(), true},
+		{byNumber(100), "eth_call" + hash100.Hex(), true},
+		{byNumber(101), "eth_call" + hash101.Hex(), true},
+		{byNumber(web3Types.LatestBlockNumber), "", false},
+		{byNumber(web3Types.PendingBlockNumber), "", false},
+		{nil, "", false},
+	}
+
+	for _, tt := range tests {
+		key, ok := cache.resultKey(nil, nil, "eth_call", request, tt.blockNumOrHash)
+		assert.Equal(t, tt.ok, ok)
+
+		if tt.ok {
+			assert.Equal(t, tt.key+`{"to":"0x0000000000000000000000000000000000000001"}`, key)
+		}
+	}
+}
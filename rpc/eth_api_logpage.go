@@ -0,0 +1,154 @@
+package rpc
+
+import (
+	"context"
+
+	web3Types "github.com/openweb3/web3go/types"
+	"github.com/pkg/errors"
+	"github.com/scroll-tech/rpc-gateway/util/metrics"
+)
+
+// LogsPageConfig configures the pagination extension of `eth_getLogs`.
+type LogsPageConfig struct {
+	// max number of logs returned in a page
+	MaxLimit uint64 `default:"10000"`
+	// number of blocks to query in each chunk
+	ChunkSize uint64 `default:"1000"`
+}
+
+// LogsPageOption is the gateway specific extra parameter of `eth_getLogs` to page through
+// huge log result sets, e.g. `eth_getLogs(filter, {"limit": 1000, "cursor": "..."})`.
+type LogsPageOption struct {
+	// max number of logs returned in a page
+	Limit uint64 `json:"limit"`
+	// opaque cursor returned along with the previous page
+	Cursor string `json:"cursor,omitempty"`
+}
+
+// LogsPage is the result of `eth_getLogs` with pagination extension.
+type LogsPage struct {
+	Logs []web3Types.Log `json:"logs"`
+	// opaque cursor to query the next page with, only available if more logs available
+	Cursor string `json:"cursor,omitempty"`
+}
+
+// getLogsPage queries the block range chunk by chunk until the page is full, and returns the
+// cursor positioned at the first log of the next page, so that pages are deterministic as long
+// as the block range is finalized.
+func (api *ethAPI) getLogsPage(
+	ctx context.Context, filter web3Types.FilterQuery, opt *LogsPageOption,
+) (*LogsPage, error) {
+	if opt.Limit == 0 || opt.Limit > api.logsPage.MaxLimit {
+		return nil, errors.Errorf("invalid page limit, should be in range [1, %v]", api.logsPage.MaxLimit)
+	}
+
+	w3c := GetEthClientFromContext(ctx)
+
+	// cursor is bound to the original filter before normalization
+	query := filter
+
+	var position, offset uint64
+	if len(opt.Cursor) > 0 {
+		var err error
+		if position, offset, err = sharedCursors().DecodeOffset(opt.Cursor, "eth_getLogs", &query); err != nil {
+			return nil, err
+		}
+	}
+
+	metrics.Registry.RPC.Percentage("eth_getLogs", "page/cursor").Mark(len(opt.Cursor) > 0)
+
+	if ok, err := api.prepareLogFilter(w3c, &filter); !ok {
+		return &LogsPage{Logs: ethEmptyLogs}, err
+	}
+
+	// block hash filter is not splittable
+	if filter.FromBlock == nil || filter.ToBlock == nil {
+		logs, err := api.getLogs(ctx, w3c, filter)
+		if err != nil {
+			return nil, err
+		}
+
+		if offset > uint64(len(logs)) {
+			offset = uint64(len(logs))
+		}
+
+		page := LogsPage{Logs: logs[offset:]}
+		if uint64(len(page.Logs)) > opt.Limit {
+			page.Logs = page.Logs[:opt.Limit]
+			if page.Cursor, err = sharedCursors().EncodeOffset("eth_getLogs", &query, 0, offset+opt.Limit); err != nil {
+				return nil, err
+			}
+		}
+
+		return &page, nil
+	}
+
+	fromBlock, toBlock := uint64(*filter.FromBlock), uint64(*filter.ToBlock)
+	if position >= fromBlock {
+		fromBlock = position
+	} else {
+		// offset only applies to the block of cursor position
+		offset = 0
+	}
+
+	page := LogsPage{Logs: []web3Types.Log{}}
+
+	for from := fromBlock; from <= toBlock; from += api.logsPage.ChunkSize {
+		to := from + api.logsPage.ChunkSize - 1
+		if to > toBlock {
+			to = toBlock
+		}
+
+		chunkFilter := filter
+		chunkFrom, chunkTo := web3Types.BlockNumber(from), web3Types.BlockNumber(to)
+		chunkFilter.FromBlock, chunkFilter.ToBlock = &chunkFrom, &chunkTo
+
+		logs, err := api.getLogs(ctx, w3c, chunkFilter)
+		if err != nil {
+			return nil, err
+		}
+
+		// skip logs already returned within the block of cursor position
+		if from == fromBlock {
+			logs = skipBlockLogs(logs, fromBlock, offset)
+		}
+
+		page.Logs = append(page.Logs, logs...)
+
+		// one more log queried to position the next page
+		if uint64(len(page.Logs)) > opt.Limit {
+			next := page.Logs[opt.Limit].BlockNumber
+
+			var nextOffset uint64
+			if next == fromBlock {
+				nextOffset = offset
+			}
+
+			for _, log := range page.Logs[:opt.Limit] {
+				if log.BlockNumber == next {
+					nextOffset++
+				}
+			}
+
+			page.Logs = page.Logs[:opt.Limit]
+			if page.Cursor, err = sharedCursors().EncodeOffset("eth_getLogs", &query, next, nextOffset); err != nil {
+				return nil, err
+			}
+
+			break
+		}
+	}
+
+	return &page, nil
+}
+
+// skipBlockLogs skips the first n logs of the specified block, which are sorted by block
+// number and log index.
+func skipBlockLogs(logs []web3Types.Log, blockNumber, n uint64) []web3Types.Log {
+	var skipped uint64
+	for skipped < n && skipped < uint64(len(logs)) && logs[skipped].BlockNumber == blockNumber {
+		skipped++
+	}
+
+	return logs[skipped:]
+}
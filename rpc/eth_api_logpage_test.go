@@ -0,0 +1,21 @@
+package rpc
+
+import (
+	"testing"
+
+	web3Types "github.com/openweb3/web3go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSkipBlockLogs(t *testing.T) {
+	logs := []web3Types.Log{
+		{BlockNumber: 5, Index: 0},
+		{BlockNumber: 5, Index: 1},
+		{BlockNumber: 6, Index: 0},
+	}
+
+	assert.Equal(t, logs, skipBlockLogs(logs, 5, 0))
+	assert.Equal(t, logs[1:], skipBlockLogs(logs, 5, 1))
+	assert.Equal(t, logs[2:], skipBlockLogs(logs, 5, 3))
+	assert.Equal(t, logs, skipBlockLogs(logs, 4, 2))
+}
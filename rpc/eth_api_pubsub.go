@@ -32,14 +32,26 @@ func (api *ethAPI) NewHeads(ctx context.Context) (*rpc.Subscription, error) {
 		return &rpc.Subscription{}, errSubscriptionProxyError
 	}
 
+	release, err := acquireSubscriptionQuota(ctx)
+	if err != nil {
+		return &rpc.Subscription{}, err
+	}
+
 	rpcSub := psCtx.notifier.CreateSubscription()
 
+	// fan out the canonical heads shared among subsystems if available
+	if len(api.provider.Chain()) == 0 && node.Heads().Started() {
+		go fanOutHeads(psCtx, rpcSub, release)
+		return rpcSub, nil
+	}
+
 	headersCh := make(chan *types.Header, pubsubChannelBufferSize)
 	dClient := getOrNewEthDelegateClient(psCtx.eth)
 
 	dSub, err := dClient.delegateSubscribeNewHeads(rpcSub.ID, headersCh)
 	if err != nil {
 		logrus.WithError(err).Error("Failed to delegate pubsub NewHeads")
+		release()
 		return &rpc.Subscription{}, errSubscriptionProxyError
 	}
 
@@ -50,6 +62,7 @@ func (api *ethAPI) NewHeads(ctx context.Context) (*rpc.Subscription, error) {
 	counter.Inc(1)
 
 	go func() {
+		defer release()
 		defer dSub.unsubscribe()
 		defer counter.Dec(1)
 
@@ -57,7 +70,7 @@ func (api *ethAPI) NewHeads(ctx context.Context) (*rpc.Subscription, error) {
 			select {
 			case blockHeader := <-headersCh:
 				logger.WithField("blockHeader", blockHeader).Debug("Received new block header from pubsub delegate")
-				psCtx.notifier.Notify(rpcSub.ID, blockHeader)
+				notifyWs("eth", psCtx.notifier, psCtx.rpcClient, rpcSub.ID, blockHeader)
 
 			case err = <-dSub.err: // delegate subscription error
 				logger.WithError(err).Debug("Received error from newHeads pubsub delegate")
@@ -78,6 +91,35 @@ func (api *ethAPI) NewHeads(ctx context.Context) (*rpc.Subscription, error) {
 	return rpcSub, nil
 }
 
+// fanOutHeads notifies canonical heads of head broadcaster until subscription closed.
+func fanOutHeads(psCtx *epubsubContext, rpcSub *rpc.Subscription, release func()) {
+	heads, unsubscribe := node.Heads().Subscribe()
+	logger := logrus.WithField("rpcSubID", rpcSub.ID)
+
+	counter := metrics.Registry.PubSub.Sessions("eth", "new_heads", "broadcaster")
+	counter.Inc(1)
+
+	defer release()
+	defer unsubscribe()
+	defer counter.Dec(1)
+
+	for {
+		select {
+		case head := <-heads:
+			logger.WithField("head", head.Number).Debug("Received new head from head broadcaster")
+			notifyWs("eth", psCtx.notifier, psCtx.rpcClient, rpcSub.ID, head.Header)
+
+		case err := <-rpcSub.Err(): // client connection closed or error
+			logger.WithError(err).Debug("NewHeads pubsub subscription error")
+			return
+
+		case <-psCtx.notifier.Closed():
+			logger.Debug("NewHeads pubsub connection closed")
+			return
+		}
+	}
+}
+
 // Logs creates a subscription that fires for all new log that match the given filter criteria.
 func (api *ethAPI) Logs(ctx context.Context, filter types.FilterQuery) (*rpc.Subscription, error) {
 	metrics.Registry.PubSub.InputLogFilter("eth").Mark(!isEmptyEthLogFilter(filter))
@@ -93,6 +135,11 @@ func (api *ethAPI) Logs(ctx context.Context, filter types.FilterQuery) (*rpc.Sub
 		return &rpc.Subscription{}, errSubscriptionProxyError
 	}
 
+	release, err := acquireSubscriptionQuota(ctx)
+	if err != nil {
+		return &rpc.Subscription{}, err
+	}
+
 	rpcSub := psCtx.notifier.CreateSubscription()
 
 	logsCh := make(chan *types.Log, pubsubChannelBufferSize)
@@ -101,6 +148,7 @@ func (api *ethAPI) Logs(ctx context.Context, filter types.FilterQuery) (*rpc.Sub
 	dSub, err := dClient.delegateSubscribeLogs(rpcSub.ID, logsCh, filter)
 	if err != nil {
 		logrus.WithField("filter", filter).WithError(err).Error("Failed to delegate pubsub logs subscription")
+		release()
 		return &rpc.Subscription{}, errSubscriptionProxyError
 	}
 
@@ -111,6 +159,7 @@ func (api *ethAPI) Logs(ctx context.Context, filter types.FilterQuery) (*rpc.Sub
 	counter.Inc(1)
 
 	go func() {
+		defer release()
 		defer dSub.unsubscribe()
 		defer counter.Dec(1)
 
@@ -118,7 +167,7 @@ func (api *ethAPI) Logs(ctx context.Context, filter types.FilterQuery) (*rpc.Sub
 			select {
 			case log := <-logsCh:
 				logger.WithField("log", log).Debug("Received new log from pubsub delegate")
-				psCtx.notifier.Notify(rpcSub.ID, log)
+				notifyWs("eth", psCtx.notifier, psCtx.rpcClient, rpcSub.ID, log)
 
 			case err = <-dSub.err: // delegate subscription error
 				logger.WithError(err).Debug("Received error from logs pubsub delegate")
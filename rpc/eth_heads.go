@@ -0,0 +1,31 @@
+package rpc
+
+import (
+	"context"
+
+	"github.com/scroll-tech/rpc-gateway/node"
+	"github.com/scroll-tech/rpc-gateway/rpc/cache"
+	"github.com/sirupsen/logrus"
+)
+
+// PurgeCacheOnNewHeads expires cached block numbers of the default evm chain once canonical
+// head changed, so that clients never observe a block number behind the shared head.
+func PurgeCacheOnNewHeads(ctx context.Context) {
+	heads, unsubscribe := node.Heads().Subscribe()
+	defer unsubscribe()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case head := <-heads:
+			cache.EthDefault.PurgeBlockNumber()
+
+			if head.Reorg {
+				logrus.WithFields(logrus.Fields{
+					"number": head.Number, "hash": head.Hash,
+				}).Info("Chain reorg detected by head broadcaster")
+			}
+		}
+	}
+}
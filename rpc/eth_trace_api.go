@@ -7,15 +7,20 @@ import (
 	"github.com/openweb3/web3go/types"
 )
 
-// ethTraceAPI provides evm space trace RPC proxy API.
+// ethTraceAPI provides evm space trace RPC proxy API. Note, request context is passed along to
+// full node, so that oversized traces could be aborted while reading.
 type ethTraceAPI struct{}
 
 func (api *ethTraceAPI) Block(ctx context.Context, blockNumOrHash types.BlockNumberOrHash) ([]types.LocalizedTrace, error) {
-	return GetEthClientFromContext(ctx).Trace.Blocks(blockNumOrHash)
+	var traces []types.LocalizedTrace
+	err := GetEthClientFromContext(ctx).Trace.CallContext(ctx, &traces, "trace_block", blockNumOrHash)
+	return traces, err
 }
 
 func (api *ethTraceAPI) Filter(ctx context.Context, filter types.TraceFilter) ([]types.LocalizedTrace, error) {
-	return GetEthClientFromContext(ctx).Trace.Filter(filter)
+	var traces []types.LocalizedTrace
+	err := GetEthClientFromContext(ctx).Trace.CallContext(ctx, &traces, "trace_filter", filter)
+	return traces, err
 }
 
 func (api *ethTraceAPI) Transaction(ctx context.Context, txHash common.Hash) ([]types.LocalizedTrace, error) {
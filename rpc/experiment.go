@@ -0,0 +1,216 @@
+package rpc
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/pkg/errors"
+	"github.com/scroll-tech/rpc-gateway/node"
+	"github.com/scroll-tech/rpc-gateway/util/feature"
+	"github.com/scroll-tech/rpc-gateway/util/metrics"
+	"github.com/scroll-tech/rpc-gateway/util/reload"
+	"github.com/scroll-tech/rpc-gateway/util/rpc/handlers"
+	"github.com/sirupsen/logrus"
+)
+
+const ctxKeyExperimentArm = handlers.CtxKey("Infura-RPC-Experiment-Arm")
+
+// ExperimentArmConfig configurations of experiment arm, which overrides routing behaviors.
+type ExperimentArmConfig struct {
+	Name string
+	// percentage of traffic in range [0, 100] assigned to this arm
+	Weight float64
+	// load balancer mode, `consistentHashing` or `random`, and empty means unchanged
+	LoadBalancerMode string
+	// routing behaviors disabled for this arm, e.g. `hedging`, `quorum` or `shadow`
+	Disable []string
+}
+
+// ExperimentConfig configurations of routing experiment, of which traffic is assigned to arms
+// by API key or client IP, and metrics are segmented by arm. Traffic not assigned to any arm
+// is not part of the experiment.
+type ExperimentConfig struct {
+	Name    string
+	Enabled bool
+	// RPC methods in experiment, which supports `*` suffix as wildcard, and empty means all
+	Methods []string
+	Arms    []ExperimentArmConfig
+}
+
+// experimentArm is the experiment arm assigned to RPC request.
+type experimentArm struct {
+	experiment string
+	ExperimentArmConfig
+	disabled map[string]bool
+}
+
+// allows checks if the specified routing behavior is allowed in this arm.
+func (arm *experimentArm) allows(behavior string) bool {
+	return arm == nil || !arm.disabled[behavior]
+}
+
+// loadBalancerMode returns the load balancer mode for this arm.
+func (arm *experimentArm) loadBalancerMode() string {
+	if arm != nil && len(arm.LoadBalancerMode) > 0 {
+		return arm.LoadBalancerMode
+	}
+
+	return loadBalancerMode.Load().(string)
+}
+
+type experiment struct {
+	ExperimentConfig
+	arms []*experimentArm
+}
+
+// experiments are the routing experiments in use, which could be changed at runtime.
+var experiments atomic.Value
+
+func init() {
+	exps, err := loadExperiments()
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to load routing experiments")
+	}
+
+	experiments.Store(exps)
+
+	reload.Register("rpc_experiments", func() error {
+		exps, err := loadExperiments()
+		if err != nil {
+			return err
+		}
+
+		experiments.Store(exps)
+		return nil
+	})
+}
+
+func loadExperiments() ([]*experiment, error) {
+	// list could only be unmarshalled as field, but not directly by key
+	var ethrpc struct {
+		Experiments []ExperimentConfig
+	}
+
+	if err := viper.UnmarshalKey("ethrpc", &ethrpc); err != nil {
+		return nil, err
+	}
+
+	var exps []*experiment
+
+	for _, conf := range ethrpc.Experiments {
+		var total float64
+		exp := experiment{ExperimentConfig: conf}
+
+		for _, armConf := range conf.Arms {
+			total += armConf.Weight
+
+			switch armConf.LoadBalancerMode {
+			case "", "consistentHashing", "random":
+			default:
+				return nil, errors.Errorf(
+					"invalid load balancer mode %q of experiment %v", armConf.LoadBalancerMode, conf.Name,
+				)
+			}
+
+			arm := experimentArm{
+				experiment:          conf.Name,
+				ExperimentArmConfig: armConf,
+				disabled:            make(map[string]bool),
+			}
+
+			for _, behavior := range armConf.Disable {
+				arm.disabled[behavior] = true
+			}
+
+			exp.arms = append(exp.arms, &arm)
+		}
+
+		if total > 100 {
+			return nil, errors.Errorf("total weight of experiment %v exceeds 100", conf.Name)
+		}
+
+		exps = append(exps, &exp)
+	}
+
+	return exps, nil
+}
+
+// assign assigns the RPC request to some arm of the experiment if any.
+func (exp *experiment) assign(method, key string) (*experimentArm, bool) {
+	if !exp.Enabled {
+		return nil, false
+	}
+
+	if len(exp.Methods) > 0 && !matchMethods(exp.Methods, method) {
+		return nil, false
+	}
+
+	bucket := float64(feature.Bucket(exp.Name, key))
+
+	var upper float64
+	for _, arm := range exp.arms {
+		upper += arm.Weight * 100
+		if bucket < upper {
+			return arm, true
+		}
+	}
+
+	return nil, false
+}
+
+func matchMethods(patterns []string, method string) bool {
+	for _, pattern := range patterns {
+		if node.MatchMethod(pattern, method) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// assignExperimentArm assigns the RPC request to arm of the first matched experiment.
+func assignExperimentArm(ctx context.Context, method string) (*experimentArm, bool) {
+	exps, _ := experiments.Load().([]*experiment)
+	if len(exps) == 0 {
+		return nil, false
+	}
+
+	key := feature.BucketKey(ctx)
+
+	for _, exp := range exps {
+		if arm, ok := exp.assign(method, key); ok {
+			return arm, true
+		}
+	}
+
+	return nil, false
+}
+
+// experimentArmFromContext returns the experiment arm assigned to RPC request, and nil if not
+// in any experiment.
+func experimentArmFromContext(ctx context.Context) *experimentArm {
+	arm, _ := ctx.Value(ctxKeyExperimentArm).(*experimentArm)
+	return arm
+}
+
+// Experiment middleware assigns RPC request to experiment arm, and collects metrics segmented
+// by experiment arm.
+func experimentMiddleware(next rpc.HandleCallMsgFunc) rpc.HandleCallMsgFunc {
+	return func(ctx context.Context, msg *rpc.JsonRpcMessage) *rpc.JsonRpcMessage {
+		arm, ok := assignExperimentArm(ctx, msg.Method)
+		if !ok {
+			return next(ctx, msg)
+		}
+
+		start := time.Now()
+		resp := next(context.WithValue(ctx, ctxKeyExperimentArm, arm), msg)
+
+		metrics.Registry.RPC.ExperimentDuration(arm.experiment, arm.Name, msg.Method).UpdateSince(start)
+		metrics.Registry.RPC.ExperimentSuccess(arm.experiment, arm.Name, msg.Method).Mark(resp.Error == nil)
+
+		return resp
+	}
+}
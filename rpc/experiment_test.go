@@ -0,0 +1,47 @@
+package rpc
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExperimentAssign(t *testing.T) {
+	exp := experiment{
+		ExperimentConfig: ExperimentConfig{Name: "exp", Enabled: true, Methods: []string{"eth_call"}},
+		arms: []*experimentArm{
+			{ExperimentArmConfig: ExperimentArmConfig{Name: "a", Weight: 10}},
+			{ExperimentArmConfig: ExperimentArmConfig{Name: "b", Weight: 20}},
+		},
+	}
+
+	counts := make(map[string]int)
+	for i := 0; i < 10000; i++ {
+		if arm, ok := exp.assign("eth_call", fmt.Sprintf("key-%v", i)); ok {
+			counts[arm.Name]++
+		}
+	}
+
+	assert.InDelta(t, 1000, counts["a"], 200)
+	assert.InDelta(t, 2000, counts["b"], 300)
+
+	// method not in experiment
+	_, ok := exp.assign("eth_getLogs", "key-1")
+	assert.False(t, ok)
+
+	// assignment is sticky
+	arm1, ok1 := exp.assign("eth_call", "key-1")
+	arm2, ok2 := exp.assign("eth_call", "key-1")
+	assert.Equal(t, ok1, ok2)
+	assert.Equal(t, arm1, arm2)
+}
+
+func TestExperimentArmAllows(t *testing.T) {
+	var arm *experimentArm
+	assert.True(t, arm.allows(featureHedging))
+
+	arm = &experimentArm{disabled: map[string]bool{featureHedging: true}}
+	assert.False(t, arm.allows(featureHedging))
+	assert.True(t, arm.allows(featureQuorum))
+}
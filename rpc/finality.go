@@ -0,0 +1,161 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/scroll-tech/rpc-gateway/node"
+	"github.com/scroll-tech/rpc-gateway/util/metrics"
+	"github.com/scroll-tech/rpc-gateway/util/rollup"
+	"github.com/scroll-tech/rpc-gateway/util/rpc/handlers"
+	"github.com/sirupsen/logrus"
+)
+
+// finalityTracker tracks L1 batch finalization of the default evm chain, nil if disabled.
+var finalityTracker *rollup.FinalityTracker
+
+// StartFinalityTracker starts to track L1 batch finalization if enabled, so as to resolve `safe`
+// and `finalized` block tags centrally. Note, it should be started before RPC server served.
+func StartFinalityTracker(ctx context.Context) {
+	var conf rollup.FinalityConfig
+	viper.MustUnmarshalKey("ethrpc.finality", &conf)
+
+	if !conf.Enabled {
+		return
+	}
+
+	finalityTracker = rollup.NewFinalityTracker(conf)
+	go finalityTracker.Run(ctx)
+
+	logrus.WithField("url", conf.URL).Info("L1 batch finality tracker started")
+}
+
+// isFinalizedBlock checks if the block of default evm chain is finalized on L1, which is
+// immutable and could be cached indefinitely.
+func isFinalizedBlock(bn uint64) bool {
+	if finalityTracker == nil {
+		return false
+	}
+
+	finality, ok := finalityTracker.Finality()
+	return ok && bn <= finality.Finalized
+}
+
+// resolveFinalityTag resolves `safe` or `finalized` block tag into block number.
+func resolveFinalityTag(tag string) (string, bool) {
+	if finalityTracker == nil {
+		return "", false
+	}
+
+	finality, ok := finalityTracker.Finality()
+	if !ok {
+		return "", false
+	}
+
+	switch strings.ToLower(tag) {
+	case "safe":
+		return hexutil.EncodeUint64(finality.Safe), true
+	case "finalized":
+		return hexutil.EncodeUint64(finality.Finalized), true
+	default:
+		return "", false
+	}
+}
+
+// resolveFinalityParam resolves block tag of block parameter, which is either block number
+// or EIP-1898 block parameter object, e.g. `{"blockNumber": "finalized"}`.
+func resolveFinalityParam(param json.RawMessage, field string) (json.RawMessage, bool) {
+	var tag string
+	if err := json.Unmarshal(param, &tag); err == nil {
+		bn, ok := resolveFinalityTag(tag)
+		if !ok {
+			return nil, false
+		}
+
+		result, _ := json.Marshal(bn)
+		return result, true
+	}
+
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(param, &obj); err != nil || obj[field] == nil {
+		return nil, false
+	}
+
+	if err := json.Unmarshal(obj[field], &tag); err != nil {
+		return nil, false
+	}
+
+	bn, ok := resolveFinalityTag(tag)
+	if !ok {
+		return nil, false
+	}
+
+	obj[field], _ = json.Marshal(bn)
+
+	result, err := json.Marshal(obj)
+	return result, err == nil
+}
+
+// resolveFinalityParams resolves `safe` and `finalized` block tags in RPC params, and returns
+// false if nothing resolved.
+func resolveFinalityParams(method string, rawParams json.RawMessage) (json.RawMessage, bool) {
+	var params []json.RawMessage
+	if err := json.Unmarshal(rawParams, &params); err != nil {
+		return nil, false
+	}
+
+	var resolved bool
+
+	if index, ok := ethBlockParamIndexes[method]; ok && index < len(params) {
+		if param, ok := resolveFinalityParam(params[index], "blockNumber"); ok {
+			params[index], resolved = param, true
+		}
+	}
+
+	// block range of log filter
+	if method == "eth_getLogs" && len(params) > 0 {
+		for _, field := range []string{"fromBlock", "toBlock"} {
+			if param, ok := resolveFinalityParam(params[0], field); ok {
+				params[0], resolved = param, true
+			}
+		}
+	}
+
+	if !resolved {
+		return nil, false
+	}
+
+	result, err := json.Marshal(params)
+	return result, err == nil
+}
+
+// finalityTagMiddleware resolves `safe` and `finalized` block tags from L1 batch finalization
+// data, so that requests against finalized state could be routed to any node by height, and
+// cached indefinitely.
+func finalityTagMiddleware(next rpc.HandleCallMsgFunc) rpc.HandleCallMsgFunc {
+	return func(ctx context.Context, msg *rpc.JsonRpcMessage) *rpc.JsonRpcMessage {
+		if finalityTracker == nil {
+			return next(ctx, msg)
+		}
+
+		// only applies to the default evm chain
+		if _, ok := ctx.Value(ctxKeyClientProvider).(*node.EthClientProvider); !ok {
+			return next(ctx, msg)
+		}
+
+		if chain, _ := ctx.Value(handlers.CtxKeyChain).(string); len(chain) > 0 {
+			return next(ctx, msg)
+		}
+
+		if params, ok := resolveFinalityParams(msg.Method, msg.Params); ok {
+			metrics.Registry.RPC.FinalityResolved(msg.Method).Mark(1)
+			msg.Params = params
+		}
+
+		return next(ctx, msg)
+	}
+}
@@ -0,0 +1,365 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"math/big"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/pkg/errors"
+	"github.com/scroll-tech/rpc-gateway/node"
+	"github.com/scroll-tech/rpc-gateway/util/metrics"
+	"github.com/scroll-tech/rpc-gateway/util/reload"
+	"github.com/sirupsen/logrus"
+)
+
+// gasOracleMethods are fee estimate methods aggregated by gas oracle.
+var gasOracleMethods = map[string]bool{
+	"eth_gasPrice":             true,
+	"eth_maxPriorityFeePerGas": true,
+	"eth_feeHistory":           true,
+}
+
+// GasOracleConfig configurations of gateway-level gas oracle, which samples fee estimates from
+// multiple nodes, rejects outliers, smooths and caches the result briefly, so that clients get
+// stable fee estimates even if individual nodes report noisy values.
+type GasOracleConfig struct {
+	Enabled bool
+	// number of nodes to sample
+	Nodes int `default:"3"`
+	// duration to cache the aggregated result
+	TTL time.Duration `default:"3s"`
+	// max relative deviation from the median to accept sample, e.g. 0.5 means ±50%
+	MaxDeviation float64 `default:"0.5"`
+	// weight of the new value for exponential moving average in range (0, 1], and 1 means
+	// no smoothing, which only applies to `eth_gasPrice` and `eth_maxPriorityFeePerGas`
+	Smoothing float64 `default:"0.3"`
+}
+
+// gasOracleConf is the gas oracle config in use, which could be changed at runtime.
+var gasOracleConf atomic.Value
+
+func init() {
+	conf, err := loadGasOracleConfig()
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to load gas oracle config")
+	}
+
+	gasOracleConf.Store(conf)
+
+	reload.Register("rpc_gas_oracle", func() error {
+		conf, err := loadGasOracleConfig()
+		if err != nil {
+			return err
+		}
+
+		gasOracleConf.Store(conf)
+		return nil
+	})
+}
+
+func loadGasOracleConfig() (*GasOracleConfig, error) {
+	var conf GasOracleConfig
+	if err := viper.UnmarshalKey("ethrpc.gasOracle", &conf); err != nil {
+		return nil, err
+	}
+
+	if conf.Enabled && (conf.Nodes < 1 || conf.MaxDeviation <= 0 || conf.Smoothing <= 0 || conf.Smoothing > 1) {
+		return nil, errors.New("gas oracle requires positive nodes and max deviation, and smoothing in range (0, 1]")
+	}
+
+	return &conf, nil
+}
+
+// gasOracleEnabled checks if gas oracle is enabled, in which case fee estimates are cached by
+// gas oracle rather than per node.
+func gasOracleEnabled() bool {
+	return gasOracleConf.Load().(*GasOracleConfig).Enabled
+}
+
+// shouldAggregateGas checks if the RPC request should be aggregated by gas oracle.
+func shouldAggregateGas(msg *rpc.JsonRpcMessage) (*GasOracleConfig, bool) {
+	conf := gasOracleConf.Load().(*GasOracleConfig)
+	return conf, conf.Enabled && gasOracleMethods[msg.Method]
+}
+
+// gasOracleEntry is the cached response of gas oracle.
+type gasOracleEntry struct {
+	resp     *rpc.JsonRpcMessage
+	cachedAt time.Time
+}
+
+// gasOracle aggregates fee estimates of a node group.
+type gasOracle struct {
+	mu      sync.Mutex
+	entries map[string]*gasOracleEntry // method/params => cached response
+	ema     map[string]*big.Int        // method => smoothed value
+}
+
+// gasOracles holds gas oracles of node groups.
+var gasOracles sync.Map // node group => *gasOracle
+
+func gasOracleOf(group node.Group) *gasOracle {
+	v, _ := gasOracles.LoadOrStore(group, &gasOracle{
+		entries: make(map[string]*gasOracleEntry),
+		ema:     make(map[string]*big.Int),
+	})
+
+	return v.(*gasOracle)
+}
+
+// gasOracleCall answers fee estimate from cache if fresh, otherwise samples the primary client
+// and other nodes of the same group, and aggregates the responses.
+func gasOracleCall(
+	ctx context.Context,
+	msg *rpc.JsonRpcMessage,
+	next rpc.HandleCallMsgFunc,
+	conf *GasOracleConfig,
+	provider *node.EthClientProvider,
+	group node.Group,
+	primary *node.Web3goClient,
+) *rpc.JsonRpcMessage {
+	oracle := gasOracleOf(group)
+	key := msg.Method + "/" + string(msg.Params)
+
+	// concurrent requests are answered once sampled
+	oracle.mu.Lock()
+	defer oracle.mu.Unlock()
+
+	if entry, ok := oracle.entries[key]; ok && time.Since(entry.cachedAt) < conf.TTL {
+		metrics.Registry.RPC.Percentage(msg.Method, "gasOracle/cached").Mark(true)
+
+		resp := *entry.resp
+		resp.ID = msg.ID
+
+		return &resp
+	}
+
+	metrics.Registry.RPC.Percentage(msg.Method, "gasOracle/cached").Mark(false)
+
+	clients := []*node.Web3goClient{primary}
+	urls := []string{primary.URL}
+
+	for len(clients) < conf.Nodes {
+		client, err := provider.GetClientRandomByGroupExcept(group, urls...)
+		if err != nil { // not enough nodes
+			break
+		}
+
+		clients = append(clients, client)
+		urls = append(urls, client.URL)
+	}
+
+	resps := make([]*rpc.JsonRpcMessage, len(clients))
+
+	var wg sync.WaitGroup
+	for i := range clients {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+			resps[i] = next(context.WithValue(ctx, ctxKeyClient, clients[i]), msg)
+		}(i)
+	}
+
+	wg.Wait()
+
+	var resp *rpc.JsonRpcMessage
+	var err error
+
+	if msg.Method == "eth_feeHistory" {
+		resp, err = aggregateFeeHistory(resps, conf)
+	} else {
+		resp, err = oracle.aggregateFee(msg.Method, resps, conf)
+	}
+
+	if err != nil { // none node succeeded
+		return resps[0]
+	}
+
+	oracle.entries[key] = &gasOracleEntry{resp: resp, cachedAt: time.Now()}
+
+	result := *resp
+	result.ID = msg.ID
+
+	return &result
+}
+
+// aggregateFee aggregates fee value of successful responses, and then smooths by exponential
+// moving average.
+func (oracle *gasOracle) aggregateFee(
+	method string, resps []*rpc.JsonRpcMessage, conf *GasOracleConfig,
+) (*rpc.JsonRpcMessage, error) {
+	var first *rpc.JsonRpcMessage
+	var samples []*big.Int
+
+	for _, resp := range resps {
+		var value hexutil.Big
+		if resp == nil || resp.Error != nil || json.Unmarshal(resp.Result, &value) != nil {
+			continue
+		}
+
+		if first == nil {
+			first = resp
+		}
+
+		samples = append(samples, value.ToInt())
+	}
+
+	if len(samples) == 0 {
+		return nil, errors.New("no fee sampled")
+	}
+
+	inliers := rejectFeeOutliers(method, samples, conf.MaxDeviation)
+
+	value := new(big.Int)
+	for _, v := range inliers {
+		value.Add(value, v)
+	}
+	value.Div(value, big.NewInt(int64(len(inliers))))
+
+	if prev, ok := oracle.ema[method]; ok {
+		value = smoothFee(prev, value, conf.Smoothing)
+	}
+
+	oracle.ema[method] = value
+
+	result, err := json.Marshal((*hexutil.Big)(value))
+	if err != nil {
+		return nil, err
+	}
+
+	resp := *first
+	resp.Result = result
+
+	return &resp, nil
+}
+
+// rejectFeeOutliers returns samples within the max relative deviation from the median.
+func rejectFeeOutliers(method string, samples []*big.Int, maxDeviation float64) []*big.Int {
+	median := medianFee(samples)
+	if median.Sign() == 0 {
+		return samples
+	}
+
+	fmedian := new(big.Float).SetInt(median)
+
+	var inliers []*big.Int
+	for _, v := range samples {
+		diff := new(big.Float).SetInt(new(big.Int).Sub(v, median))
+		deviation, _ := new(big.Float).Quo(diff.Abs(diff), fmedian).Float64()
+
+		outlier := deviation > maxDeviation
+		metrics.Registry.RPC.Percentage(method, "gasOracle/outlier").Mark(outlier)
+
+		if !outlier {
+			inliers = append(inliers, v)
+		}
+	}
+
+	// median is always inlier
+	return inliers
+}
+
+// medianFee returns the median of samples, or the lower one of the middle two.
+func medianFee(samples []*big.Int) *big.Int {
+	sorted := make([]*big.Int, len(samples))
+	copy(sorted, samples)
+
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Cmp(sorted[j]) < 0
+	})
+
+	return sorted[(len(sorted)-1)/2]
+}
+
+// smoothFee returns the exponential moving average `prev + weight * (value - prev)`.
+func smoothFee(prev, value *big.Int, weight float64) *big.Int {
+	// weight in basis points to avoid float rounding errors
+	bps := big.NewInt(int64(math.Round(weight * 10000)))
+
+	delta := new(big.Int).Sub(value, prev)
+	delta.Mul(delta, bps).Quo(delta, big.NewInt(10000))
+
+	return delta.Add(delta, prev)
+}
+
+// feeHistoryResult is the result of `eth_feeHistory`.
+type feeHistoryResult struct {
+	OldestBlock  *hexutil.Big     `json:"oldestBlock"`
+	Reward       [][]*hexutil.Big `json:"reward,omitempty"`
+	BaseFee      []*hexutil.Big   `json:"baseFeePerGas,omitempty"`
+	GasUsedRatio []float64        `json:"gasUsedRatio"`
+}
+
+// aggregateFeeHistory aggregates fee histories of the same block range reported by most nodes,
+// of which rewards are the median among nodes, so as to reject outliers.
+func aggregateFeeHistory(resps []*rpc.JsonRpcMessage, conf *GasOracleConfig) (*rpc.JsonRpcMessage, error) {
+	type history struct {
+		resp   *rpc.JsonRpcMessage
+		result feeHistoryResult
+	}
+
+	// group by block range
+	var ranges []string
+	groups := make(map[string][]history)
+
+	for _, resp := range resps {
+		var result feeHistoryResult
+		if resp == nil || resp.Error != nil || json.Unmarshal(resp.Result, &result) != nil || result.OldestBlock == nil {
+			continue
+		}
+
+		key := result.OldestBlock.String() + "/" + hexutil.EncodeUint64(uint64(len(result.GasUsedRatio)))
+		if _, ok := groups[key]; !ok {
+			ranges = append(ranges, key)
+		}
+
+		groups[key] = append(groups[key], history{resp, result})
+	}
+
+	if len(ranges) == 0 {
+		return nil, errors.New("no fee history sampled")
+	}
+
+	var majority []history
+	for _, key := range ranges {
+		if len(groups[key]) > len(majority) {
+			majority = groups[key]
+		}
+	}
+
+	aggregated := majority[0].result
+
+	for i := range aggregated.Reward {
+		for j := range aggregated.Reward[i] {
+			var samples []*big.Int
+			for _, h := range majority {
+				if i < len(h.result.Reward) && j < len(h.result.Reward[i]) && h.result.Reward[i][j] != nil {
+					samples = append(samples, h.result.Reward[i][j].ToInt())
+				}
+			}
+
+			if len(samples) > 0 {
+				aggregated.Reward[i][j] = (*hexutil.Big)(medianFee(samples))
+			}
+		}
+	}
+
+	result, err := json.Marshal(&aggregated)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := *majority[0].resp
+	resp.Result = result
+
+	return &resp, nil
+}
@@ -0,0 +1,23 @@
+package rpc
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRejectFeeOutliers(t *testing.T) {
+	samples := []*big.Int{big.NewInt(100), big.NewInt(1000), big.NewInt(110), big.NewInt(90)}
+
+	assert.Equal(t, big.NewInt(100), medianFee(samples))
+
+	inliers := rejectFeeOutliers("eth_gasPrice", samples, 0.5)
+	assert.Equal(t, []*big.Int{big.NewInt(100), big.NewInt(110), big.NewInt(90)}, inliers)
+}
+
+func TestSmoothFee(t *testing.T) {
+	assert.Equal(t, big.NewInt(130), smoothFee(big.NewInt(100), big.NewInt(200), 0.3))
+	assert.Equal(t, big.NewInt(70), smoothFee(big.NewInt(100), big.NewInt(0), 0.3))
+	assert.Equal(t, big.NewInt(200), smoothFee(big.NewInt(100), big.NewInt(200), 1))
+}
@@ -0,0 +1,283 @@
+package rpc
+
+import (
+	"context"
+	"time"
+
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	web3Types "github.com/openweb3/web3go/types"
+	"github.com/pkg/errors"
+	"github.com/scroll-tech/rpc-gateway/store"
+	"github.com/scroll-tech/rpc-gateway/util"
+	"github.com/scroll-tech/rpc-gateway/util/metrics"
+	"github.com/scroll-tech/rpc-gateway/util/rollup"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// defaultPartialLogsChunkSize is used if chunk size not configured.
+	defaultPartialLogsChunkSize = 1000
+	// defaultBulkBlocksPageSize is used if page size not configured.
+	defaultBulkBlocksPageSize = 100
+)
+
+// PartialLogsConfig configures the watchdog of long-running chunked event logs query.
+type PartialLogsConfig struct {
+	// deadline after which partial results returned with continuation cursor
+	Deadline time.Duration `default:"3s"`
+	// number of blocks to query in each chunk
+	ChunkSize uint64 `default:"1000"`
+}
+
+// BulkBlocksConfig configures the pagination of bulk blocks query.
+type BulkBlocksConfig struct {
+	// max number of blocks returned in a page
+	PageSize uint64 `default:"100"`
+}
+
+// PartialLogs is the result of `gateway_getLogs`, which contains the continuation cursor
+// if the query not completed before deadline.
+type PartialLogs struct {
+	Logs []web3Types.Log `json:"logs"`
+	// whether only partial logs returned
+	Partial bool `json:"partial"`
+	// opaque cursor to continue query with, only available for partial results
+	Cursor string `json:"cursor,omitempty"`
+}
+
+// BlockPage is the result of `gateway_getBlocks`, which contains the continuation cursor
+// if more blocks available.
+type BlockPage struct {
+	Blocks []*web3Types.Block `json:"blocks"`
+	// opaque cursor to query the next page with, only available if more blocks available
+	Cursor string `json:"cursor,omitempty"`
+}
+
+// gatewayAPI provides gateway specific extension RPC methods for evm space.
+type gatewayAPI struct {
+	eth         *ethAPI
+	cursors     *CursorCodec
+	partialLogs PartialLogsConfig
+	bulkBlocks  BulkBlocksConfig
+	streaming   StreamingConfig
+	txStatus    TxStatusConfig
+	bridge      *rollup.Bridge // nil if disabled
+}
+
+func newGatewayAPI(eth *ethAPI) *gatewayAPI {
+	api := gatewayAPI{eth: eth, cursors: sharedCursors()}
+
+	viper.MustUnmarshalKey("ethrpc.partialLogs", &api.partialLogs)
+	viper.MustUnmarshalKey("ethrpc.bulkBlocks", &api.bulkBlocks)
+	viper.MustUnmarshalKey("ethrpc.streaming", &api.streaming)
+	viper.MustUnmarshalKey("ethrpc.txStatus", &api.txStatus)
+
+	// bridge messages only available for the default evm chain
+	var bridgeConf rollup.BridgeConfig
+	viper.MustUnmarshalKey("ethrpc.bridge", &bridgeConf)
+
+	if bridgeConf.Enabled && len(eth.provider.Chain()) == 0 {
+		bridge, err := rollup.NewBridge(bridgeConf)
+		if err != nil {
+			logrus.WithError(err).Fatal("Failed to create bridge for message status query")
+		}
+
+		api.bridge = bridge
+	}
+
+	if api.partialLogs.ChunkSize == 0 {
+		api.partialLogs.ChunkSize = defaultPartialLogsChunkSize
+	}
+
+	if api.bulkBlocks.PageSize == 0 {
+		api.bulkBlocks.PageSize = defaultBulkBlocksPageSize
+	}
+
+	if api.streaming.FrameSize <= 0 {
+		api.streaming.FrameSize = defaultStreamFrameSize
+	}
+
+	return &api
+}
+
+// GetLogs returns event logs matching the given filter object. Different from `eth_getLogs`,
+// the block range is queried chunk by chunk, and partial results returned along with
+// the continuation cursor if deadline exceeded rather than failing the entire request.
+//
+// To continue query, call again with the same filter and the returned cursor.
+func (api *gatewayAPI) GetLogs(
+	ctx context.Context, filter web3Types.FilterQuery, cursor *string,
+) (*PartialLogs, error) {
+	w3c := GetEthClientFromContext(ctx)
+
+	// cursor is bound to the original filter before normalization
+	query := filter
+
+	var position uint64
+	if cursor != nil && len(*cursor) > 0 {
+		var err error
+		if position, err = api.cursors.Decode(*cursor, "gateway_getLogs", &query); err != nil {
+			return nil, err
+		}
+	}
+
+	if ok, err := api.eth.prepareLogFilter(w3c, &filter); !ok {
+		return &PartialLogs{Logs: ethEmptyLogs}, err
+	}
+
+	// block hash filter is not splittable
+	if filter.FromBlock == nil || filter.ToBlock == nil {
+		logs, err := api.eth.getLogs(ctx, w3c, filter)
+		if err != nil {
+			return nil, err
+		}
+
+		return &PartialLogs{Logs: logs}, nil
+	}
+
+	// watchdog to abort the long-running chunk query
+	deadlineCtx, cancel := context.WithTimeout(ctx, api.partialLogs.Deadline)
+	defer cancel()
+
+	fromBlock, toBlock := uint64(*filter.FromBlock), uint64(*filter.ToBlock)
+	if position > fromBlock {
+		fromBlock = position
+	}
+
+	getLogs := func(ctx context.Context, filter web3Types.FilterQuery) ([]web3Types.Log, error) {
+		return api.eth.getLogs(ctx, w3c, filter)
+	}
+
+	logs, next, err := getLogsByChunk(deadlineCtx, filter, fromBlock, toBlock, api.partialLogs.ChunkSize, getLogs)
+	if err != nil {
+		return nil, err
+	}
+
+	result := PartialLogs{Logs: logs}
+
+	metrics.Registry.RPC.Percentage("gateway_getLogs", "partial").Mark(next > 0)
+
+	if next > 0 {
+		nextCursor, err := api.cursors.Encode("gateway_getLogs", &query, next)
+		if err != nil {
+			return nil, err
+		}
+
+		result.Partial, result.Cursor = true, nextCursor
+	}
+
+	return &result, nil
+}
+
+// GetBlocks returns blocks within the given block range page by page. To query the next
+// page, call again with the same block range and the returned cursor.
+func (api *gatewayAPI) GetBlocks(
+	ctx context.Context, fromBlock, toBlock web3Types.BlockNumber, fullTx bool, cursor *string,
+) (*BlockPage, error) {
+	w3c := GetEthClientFromContext(ctx)
+
+	// cursor is bound to the original block range before normalization
+	query := []interface{}{fromBlock, toBlock, fullTx}
+
+	var position uint64
+	if cursor != nil && len(*cursor) > 0 {
+		var err error
+		if position, err = api.cursors.Decode(*cursor, "gateway_getBlocks", query); err != nil {
+			return nil, err
+		}
+	}
+
+	from, err := util.NormalizeEthBlockNumber(w3c.Client, &fromBlock, *api.eth.hardforkBlockNumber)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to normalize from block")
+	}
+
+	to, err := util.NormalizeEthBlockNumber(w3c.Client, &toBlock, *api.eth.hardforkBlockNumber)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to normalize to block")
+	}
+
+	if *from > *to {
+		return nil, errInvalidLogFilterBlockRange
+	}
+
+	start, end := uint64(*from), uint64(*to)
+	if position > start {
+		start = position
+	}
+
+	page := BlockPage{Blocks: []*web3Types.Block{}}
+
+	for bn := start; bn <= end; bn++ {
+		if bn-start >= api.bulkBlocks.PageSize {
+			if page.Cursor, err = api.cursors.Encode("gateway_getBlocks", query, bn); err != nil {
+				return nil, err
+			}
+
+			break
+		}
+
+		block, err := api.eth.GetBlockByNumber(ctx, web3Types.BlockNumber(bn), fullTx)
+		if err != nil {
+			return nil, err
+		}
+
+		page.Blocks = append(page.Blocks, block)
+	}
+
+	return &page, nil
+}
+
+// getLogsByChunk queries event logs of the block range chunk by chunk until deadline of the
+// context, and returns the block number to continue query from if only partial logs queried.
+func getLogsByChunk(
+	ctx context.Context,
+	filter web3Types.FilterQuery,
+	fromBlock, toBlock, chunkSize uint64,
+	getLogs func(ctx context.Context, filter web3Types.FilterQuery) ([]web3Types.Log, error),
+) ([]web3Types.Log, uint64, error) {
+	result := []web3Types.Log{}
+
+	for from := fromBlock; from <= toBlock; from += chunkSize {
+		// at least one chunk is queried to ensure progress
+		if from > fromBlock && ctx.Err() != nil {
+			return result, from, nil
+		}
+
+		to := from + chunkSize - 1
+		if to > toBlock {
+			to = toBlock
+		}
+
+		chunkFilter := filter
+		chunkFrom, chunkTo := web3Types.BlockNumber(from), web3Types.BlockNumber(to)
+		chunkFilter.FromBlock, chunkFilter.ToBlock = &chunkFrom, &chunkTo
+
+		logs, err := getLogs(ctx, chunkFilter)
+		if err != nil {
+			// return partial results if any chunk already completed
+			if from > fromBlock && isPartialLogsRecoverable(err) {
+				return result, from, nil
+			}
+
+			return nil, 0, err
+		}
+
+		// return partial results rather than failure if too many logs
+		if from > fromBlock && len(result)+len(logs) > int(store.MaxLogLimit) {
+			return result, from, nil
+		}
+
+		result = append(result, logs...)
+	}
+
+	return result, 0, nil
+}
+
+// isPartialLogsRecoverable checks if the error of a chunk query is caused by the watchdog,
+// in which case partial results could be returned.
+func isPartialLogsRecoverable(err error) bool {
+	return err == store.ErrGetLogsTimeout ||
+		err == store.ErrGetLogsResultSetTooLarge ||
+		errors.Is(err, context.DeadlineExceeded)
+}
@@ -0,0 +1,100 @@
+package rpc
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/pkg/errors"
+	"github.com/scroll-tech/rpc-gateway/util/rollup"
+	"github.com/sirupsen/logrus"
+)
+
+// l1Watcher watches batches of the default evm chain committed on L1, nil if disabled.
+var l1Watcher *rollup.L1Watcher
+
+// StartL1Watcher starts to watch batch events of rollup contract on L1 if enabled. Note, it
+// should be started before RPC server served.
+func StartL1Watcher(ctx context.Context) {
+	var conf rollup.L1WatcherConfig
+	viper.MustUnmarshalKey("ethrpc.l1Watcher", &conf)
+
+	if !conf.Enabled {
+		return
+	}
+
+	watcher, err := rollup.NewL1Watcher(conf)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to create L1 watcher")
+	}
+
+	l1Watcher = watcher
+	go l1Watcher.Run(ctx)
+
+	logrus.WithField("contract", conf.RollupContract).Info("L1 watcher started")
+}
+
+// BatchStatus is the result of `gateway_getBatchStatus`.
+type BatchStatus struct {
+	BlockNumber hexutil.Uint64 `json:"blockNumber"`
+	// `pending`, `committed` or `finalized`
+	Status string `json:"status"`
+	// only available if committed
+	Batch *rollup.Batch `json:"batch,omitempty"`
+}
+
+// GetBatchStatus returns whether the batch containing the specified transaction hash or block
+// number has been committed or finalized on L1. Returns nil if transaction not mined, or block
+// not within batches watched by gateway.
+func (api *gatewayAPI) GetBatchStatus(ctx context.Context, txHashOrBlockNumber string) (*BatchStatus, error) {
+	if l1Watcher == nil || len(api.eth.provider.Chain()) > 0 {
+		return nil, errors.New("batch status query not enabled")
+	}
+
+	bn, err := api.parseBatchQuery(ctx, txHashOrBlockNumber)
+	if err != nil || bn == nil {
+		return nil, err
+	}
+
+	batch, status, ok := l1Watcher.BatchOf(*bn)
+	if !ok {
+		return nil, nil
+	}
+
+	return &BatchStatus{
+		BlockNumber: hexutil.Uint64(*bn),
+		Status:      status,
+		Batch:       batch,
+	}, nil
+}
+
+// parseBatchQuery returns the block number of transaction hash, or block number in decimal or
+// hex. Returns nil if transaction not mined.
+func (api *gatewayAPI) parseBatchQuery(ctx context.Context, txHashOrBlockNumber string) (*uint64, error) {
+	if isHashParam(txHashOrBlockNumber) {
+		receipt, err := api.eth.GetTransactionReceipt(ctx, common.HexToHash(txHashOrBlockNumber))
+		if err != nil || receipt == nil {
+			return nil, err
+		}
+
+		return &receipt.BlockNumber, nil
+	}
+
+	var bn uint64
+	var err error
+
+	if strings.HasPrefix(txHashOrBlockNumber, "0x") {
+		bn, err = hexutil.DecodeUint64(txHashOrBlockNumber)
+	} else {
+		bn, err = strconv.ParseUint(txHashOrBlockNumber, 10, 64)
+	}
+
+	if err != nil {
+		return nil, errors.Errorf("invalid transaction hash or block number %v", txHashOrBlockNumber)
+	}
+
+	return &bn, nil
+}
@@ -0,0 +1,98 @@
+package rpc
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+	"github.com/scroll-tech/rpc-gateway/util/rollup"
+)
+
+// status of messages between L1 and L2
+const (
+	MessageStatusPending   = "pending"   // not relayed on L2 yet, or not claimable on L1 yet
+	MessageStatusRelayed   = "relayed"   // L1 message executed on L2
+	MessageStatusClaimable = "claimable" // L2 message finalized on L1, but not claimed yet
+	MessageStatusClaimed   = "claimed"   // L2 message claimed on L1
+)
+
+var errBridgeDisabled = errors.New("bridge message query not enabled")
+
+// BridgeMessage is the result of `gateway_getL1MessageStatus` and `gateway_getL2MessageStatus`.
+type BridgeMessage struct {
+	*rollup.Message
+	Status string `json:"status"`
+}
+
+// GetL1MessageStatus returns the status of L1 -> L2 messages sent by L1 transaction, which is
+// `relayed` once executed on L2, or `pending` otherwise. Returns nil if transaction not mined.
+func (api *gatewayAPI) GetL1MessageStatus(ctx context.Context, txHash common.Hash) ([]BridgeMessage, error) {
+	if api.bridge == nil {
+		return nil, errBridgeDisabled
+	}
+
+	messages, ok, err := api.bridge.L1Messages(txHash)
+	if err != nil || !ok {
+		return nil, err
+	}
+
+	w3c := GetEthClientFromContext(ctx)
+	result := make([]BridgeMessage, 0, len(messages))
+
+	for _, msg := range messages {
+		relayed, err := api.bridge.IsL1MessageExecuted(w3c.Client, msg.Hash)
+		if err != nil {
+			return nil, errors.WithMessage(err, "failed to check L1 message execution on L2")
+		}
+
+		status := MessageStatusPending
+		if relayed {
+			status = MessageStatusRelayed
+		}
+
+		result = append(result, BridgeMessage{msg, status})
+	}
+
+	return result, nil
+}
+
+// GetL2MessageStatus returns the status of L2 -> L1 messages sent by L2 transaction, which is
+// `claimed` once executed on L1, `claimable` once the containing block finalized on L1, or
+// `pending` otherwise. Note, `claimable` requires L1 batch finality tracking enabled. Returns nil
+// if transaction not mined.
+func (api *gatewayAPI) GetL2MessageStatus(ctx context.Context, txHash common.Hash) ([]BridgeMessage, error) {
+	if api.bridge == nil {
+		return nil, errBridgeDisabled
+	}
+
+	receipt, err := api.eth.GetTransactionReceipt(ctx, txHash)
+	if err != nil || receipt == nil {
+		return nil, err
+	}
+
+	messages, err := rollup.ParseSentMessages(receipt.Logs, api.bridge.L2Messenger())
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]BridgeMessage, 0, len(messages))
+
+	for _, msg := range messages {
+		claimed, err := api.bridge.IsL2MessageExecuted(msg.Hash)
+		if err != nil {
+			return nil, errors.WithMessage(err, "failed to check L2 message execution on L1")
+		}
+
+		status := MessageStatusPending
+		switch {
+		case claimed:
+			status = MessageStatusClaimed
+		case isFinalizedBlock(receipt.BlockNumber):
+			status = MessageStatusClaimable
+		}
+
+		result = append(result, BridgeMessage{msg, status})
+	}
+
+	return result, nil
+}
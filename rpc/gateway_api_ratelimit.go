@@ -0,0 +1,39 @@
+package rpc
+
+import (
+	"context"
+
+	"github.com/scroll-tech/rpc-gateway/util/rpc/handlers"
+)
+
+// RateLimitBudget is the rate limit budget of client for a rate limit rule.
+type RateLimitBudget struct {
+	Rule      string `json:"rule"`
+	Limit     int    `json:"limit"`
+	Remaining int    `json:"remaining"`
+	// seconds until fully replenished
+	Reset int64 `json:"reset"`
+}
+
+// RateLimitStatus returns the current rate limit budgets of client, including the overall one
+// and the one of specified RPC method if any. Rules not limited are omitted.
+func (api *gatewayAPI) RateLimitStatus(ctx context.Context, method *string) ([]RateLimitBudget, error) {
+	rules := []string{"rpc_all"}
+	if method != nil && len(*method) > 0 {
+		rules = append(rules, *method)
+	}
+
+	budgets := []RateLimitBudget{}
+	for _, rule := range rules {
+		if status, ok := handlers.RateLimitStatus(ctx, rule); ok {
+			budgets = append(budgets, RateLimitBudget{
+				Rule:      rule,
+				Limit:     status.Limit,
+				Remaining: status.Remaining,
+				Reset:     status.ResetSeconds(),
+			})
+		}
+	}
+
+	return budgets, nil
+}
@@ -0,0 +1,197 @@
+package rpc
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/openweb3/go-rpc-provider"
+	web3Types "github.com/openweb3/web3go/types"
+	"github.com/pkg/errors"
+	"github.com/scroll-tech/rpc-gateway/node"
+	"github.com/scroll-tech/rpc-gateway/util/metrics"
+	"github.com/sirupsen/logrus"
+)
+
+// defaultStreamFrameSize is used if stream frame size not configured.
+const defaultStreamFrameSize = 500
+
+// StreamingConfig configures result streaming over websocket for large queries.
+type StreamingConfig struct {
+	// max number of items in a notification frame
+	FrameSize int `default:"500"`
+}
+
+// StreamFrame is the notification frame of streamed query results, all of which are
+// tied to the subscription ID of the query. The last frame is marked as done, with
+// error message if query failed halfway.
+type StreamFrame struct {
+	Seq   uint64      `json:"seq"`
+	Data  interface{} `json:"data,omitempty"`
+	Done  bool        `json:"done"`
+	Error string      `json:"error,omitempty"`
+}
+
+// streamEmitter emits a frame of results, and returns false if the stream is closed.
+type streamEmitter func(data interface{}) bool
+
+// StreamLogs delivers event logs matching the given filter as a stream of websocket
+// notifications, so as to avoid huge single JSON frame for bulk logs.
+func (api *gatewayAPI) StreamLogs(ctx context.Context, filter web3Types.FilterQuery) (*rpc.Subscription, error) {
+	w3c := GetEthClientFromContext(ctx)
+
+	ok, err := api.eth.prepareLogFilter(w3c, &filter)
+	if err != nil {
+		return &rpc.Subscription{}, err
+	}
+
+	return api.stream(ctx, "logs", w3c, func(ctx context.Context, emit streamEmitter) error {
+		if !ok {
+			return nil
+		}
+
+		// block hash filter is not splittable
+		if filter.FromBlock == nil || filter.ToBlock == nil {
+			logs, err := api.eth.getLogs(ctx, w3c, filter)
+			if err != nil {
+				return err
+			}
+
+			emitFrames(logs, api.streaming.FrameSize, emit)
+			return nil
+		}
+
+		fromBlock, toBlock := uint64(*filter.FromBlock), uint64(*filter.ToBlock)
+
+		for from := fromBlock; from <= toBlock; from += api.partialLogs.ChunkSize {
+			to := from + api.partialLogs.ChunkSize - 1
+			if to > toBlock {
+				to = toBlock
+			}
+
+			chunkFilter := filter
+			chunkFrom, chunkTo := web3Types.BlockNumber(from), web3Types.BlockNumber(to)
+			chunkFilter.FromBlock, chunkFilter.ToBlock = &chunkFrom, &chunkTo
+
+			logs, err := api.eth.getLogs(ctx, w3c, chunkFilter)
+			if err != nil {
+				return err
+			}
+
+			if !emitFrames(logs, api.streaming.FrameSize, emit) {
+				return nil
+			}
+		}
+
+		return nil
+	})
+}
+
+// StreamTraces delivers traces matching the given filter as a stream of websocket
+// notifications, so as to avoid huge single JSON frame for bulk traces.
+func (api *gatewayAPI) StreamTraces(ctx context.Context, filter web3Types.TraceFilter) (*rpc.Subscription, error) {
+	w3c := GetEthClientFromContext(ctx)
+
+	return api.stream(ctx, "traces", w3c, func(ctx context.Context, emit streamEmitter) error {
+		traces, err := w3c.Trace.Filter(filter)
+		if err != nil {
+			return err
+		}
+
+		emitFrames(traces, api.streaming.FrameSize, emit)
+		return nil
+	})
+}
+
+// stream creates subscription for the query, and notifies the results frame by frame
+// in a separate goroutine.
+func (api *gatewayAPI) stream(
+	ctx context.Context,
+	topic string,
+	w3c *node.Web3goClient,
+	query func(ctx context.Context, emit streamEmitter) error,
+) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+
+	release, err := acquireSubscriptionQuota(ctx)
+	if err != nil {
+		return &rpc.Subscription{}, err
+	}
+
+	rpcSub := notifier.CreateSubscription()
+	logger := logrus.WithFields(logrus.Fields{"rpcSubID": rpcSub.ID, "topic": topic})
+
+	counter := metrics.Registry.PubSub.Sessions("eth", "stream_"+topic, "gateway")
+	counter.Inc(1)
+
+	// query context is canceled once subscription terminated by client
+	queryCtx, cancel := context.WithCancel(context.WithValue(context.Background(), ctxKeyClient, w3c))
+
+	go func() {
+		defer cancel()
+
+		select {
+		case err := <-rpcSub.Err():
+			logger.WithError(err).Debug("Stream subscription terminated")
+		case <-notifier.Closed():
+			logger.Debug("Stream connection closed")
+		case <-queryCtx.Done():
+		}
+	}()
+
+	go func() {
+		defer release()
+		defer counter.Dec(1)
+		defer cancel()
+
+		var seq uint64
+		notify := func(frame *StreamFrame) bool {
+			frame.Seq, seq = seq, seq+1
+
+			if err := notifier.Notify(rpcSub.ID, frame); err != nil {
+				logger.WithError(err).Debug("Failed to notify stream frame")
+				return false
+			}
+
+			return queryCtx.Err() == nil
+		}
+
+		err := query(queryCtx, func(data interface{}) bool {
+			return notify(&StreamFrame{Data: data})
+		})
+
+		if queryCtx.Err() != nil { // terminated by client
+			return
+		}
+
+		frame := StreamFrame{Done: true}
+		if err != nil {
+			logger.WithError(err).Debug("Failed to query streamed results")
+			frame.Error = errors.Cause(err).Error()
+		}
+
+		notify(&frame)
+	}()
+
+	return rpcSub, nil
+}
+
+// emitFrames emits slice items frame by frame, and returns false if the stream is closed.
+func emitFrames(items interface{}, frameSize int, emit streamEmitter) bool {
+	val := reflect.ValueOf(items)
+
+	for start := 0; start < val.Len(); start += frameSize {
+		end := start + frameSize
+		if end > val.Len() {
+			end = val.Len()
+		}
+
+		if !emit(val.Slice(start, end).Interface()) {
+			return false
+		}
+	}
+
+	return true
+}
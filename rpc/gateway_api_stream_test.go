@@ -0,0 +1,118 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+// streamTestService streams the specified items, and fails with the specified error if any.
+type streamTestService struct {
+	api   *gatewayAPI
+	items []int
+	err   error
+}
+
+func (s *streamTestService) Items(ctx context.Context) (*rpc.Subscription, error) {
+	return s.api.stream(ctx, "items", nil, func(ctx context.Context, emit streamEmitter) error {
+		emitFrames(s.items, s.api.streaming.FrameSize, emit)
+		return s.err
+	})
+}
+
+func TestEmitFrames(t *testing.T) {
+	tests := []struct {
+		items  []int
+		closed int // number of frames emitted before stream closed, 0 means never closed
+		frames [][]int
+		ok     bool
+	}{
+		{nil, 0, nil, true},
+		{[]int{1}, 0, [][]int{{1}}, true},
+		{[]int{1, 2, 3, 4, 5}, 0, [][]int{{1, 2}, {3, 4}, {5}}, true},
+		{[]int{1, 2, 3, 4, 5}, 1, [][]int{{1, 2}}, false},
+	}
+
+	for _, tt := range tests {
+		var frames [][]int
+		ok := emitFrames(tt.items, 2, func(data interface{}) bool {
+			frames = append(frames, data.([]int))
+			return tt.closed == 0 || len(frames) < tt.closed
+		})
+
+		assert.Equal(t, tt.ok, ok)
+		assert.Equal(t, tt.frames, frames)
+	}
+}
+
+func TestStream(t *testing.T) {
+	tests := []struct {
+		frameSize int // configured frame size
+		items     []int
+		err       error
+		frames    int // number of data frames
+		errMsg    string
+	}{
+		{2, []int{1, 2, 3, 4, 5}, nil, 3, ""},
+		{2, nil, nil, 0, ""},
+		// failed halfway
+		{2, []int{1, 2, 3}, errors.New("upstream failure"), 2, "upstream failure"},
+		// misconfigured frame size falls back to default
+		{0, []int{1, 2, 3, 4, 5}, nil, 1, ""},
+		{-1, []int{1, 2, 3, 4, 5}, nil, 1, ""},
+	}
+
+	defer viper.Set("ethrpc.streaming", nil)
+
+	for _, tt := range tests {
+		viper.Set("ethrpc.streaming", map[string]interface{}{"frameSize": tt.frameSize})
+
+		api := newGatewayAPI(nil)
+		if tt.frameSize <= 0 {
+			assert.Equal(t, defaultStreamFrameSize, api.streaming.FrameSize)
+		}
+
+		server := rpc.NewServer()
+		assert.NoError(t, server.RegisterName("gateway", &streamTestService{api, tt.items, tt.err}))
+
+		client := rpc.DialInProc(server)
+
+		ch := make(chan StreamFrame)
+		sub, err := client.Subscribe(context.Background(), "gateway", ch, "items")
+		assert.NoError(t, err)
+
+		var frames []StreamFrame
+		for done := false; !done; {
+			select {
+			case frame := <-ch:
+				frames = append(frames, frame)
+				done = frame.Done
+			case err := <-sub.Err():
+				t.Fatal("Stream subscription terminated", err)
+			case <-time.After(5 * time.Second):
+				t.Fatal("Stream timed out")
+			}
+		}
+
+		sub.Unsubscribe()
+		client.Close()
+		server.Stop()
+
+		// data frames followed by the done frame
+		assert.Equal(t, tt.frames+1, len(frames))
+		for i, frame := range frames {
+			assert.Equal(t, uint64(i), frame.Seq)
+		}
+
+		assert.Equal(t, tt.errMsg, frames[len(frames)-1].Error)
+	}
+
+	// notifications unsupported, e.g. over HTTP
+	_, err := (&gatewayAPI{}).stream(context.Background(), "items", nil, nil)
+	assert.Equal(t, rpc.ErrNotificationsUnsupported, err)
+}
@@ -0,0 +1,70 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	web3Types "github.com/openweb3/web3go/types"
+	"github.com/scroll-tech/rpc-gateway/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetLogsByChunk(t *testing.T) {
+	errUpstream := errors.New("upstream failure")
+
+	// chunk query result, keyed by the from block of chunk
+	type chunk struct {
+		logs  int
+		err   error
+		delay time.Duration
+	}
+
+	tests := []struct {
+		chunks   map[uint64]chunk
+		logs     int
+		next     uint64 // 0 means completed
+		err      error
+		queried  []uint64
+		deadline time.Duration
+	}{
+		// completed
+		{map[uint64]chunk{}, 0, 0, nil, []uint64{1, 11, 21}, time.Minute},
+		{map[uint64]chunk{1: {logs: 2}, 21: {logs: 1}}, 3, 0, nil, []uint64{1, 11, 21}, time.Minute},
+		// deadline exceeded after the first chunk
+		{map[uint64]chunk{1: {logs: 2, delay: 20 * time.Millisecond}}, 2, 11, nil, []uint64{1}, 10 * time.Millisecond},
+		// recoverable failure after the first chunk
+		{map[uint64]chunk{1: {logs: 2}, 11: {err: store.ErrGetLogsTimeout}}, 2, 11, nil, []uint64{1, 11}, time.Minute},
+		{map[uint64]chunk{1: {logs: 2}, 11: {err: store.ErrGetLogsResultSetTooLarge}}, 2, 11, nil, []uint64{1, 11}, time.Minute},
+		// too many logs after the first chunk
+		{map[uint64]chunk{1: {logs: 2}, 11: {logs: int(store.MaxLogLimit)}}, 2, 11, nil, []uint64{1, 11}, time.Minute},
+		// first chunk always fails the request
+		{map[uint64]chunk{1: {err: store.ErrGetLogsTimeout}}, 0, 0, store.ErrGetLogsTimeout, []uint64{1}, time.Minute},
+		// unrecoverable failure
+		{map[uint64]chunk{11: {err: errUpstream}}, 0, 0, errUpstream, []uint64{1, 11}, time.Minute},
+	}
+
+	for _, tt := range tests {
+		var queried []uint64
+
+		getLogs := func(ctx context.Context, filter web3Types.FilterQuery) ([]web3Types.Log, error) {
+			from := uint64(*filter.FromBlock)
+			queried = append(queried, from)
+
+			c := tt.chunks[from]
+			time.Sleep(c.delay)
+
+			return make([]web3Types.Log, c.logs), c.err
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), tt.deadline)
+		logs, next, err := getLogsByChunk(ctx, web3Types.FilterQuery{}, 1, 25, 10, getLogs)
+		cancel()
+
+		assert.Equal(t, tt.err, err)
+		assert.Equal(t, tt.logs, len(logs))
+		assert.Equal(t, tt.next, next)
+		assert.Equal(t, tt.queried, queried)
+	}
+}
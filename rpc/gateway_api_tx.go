@@ -0,0 +1,80 @@
+package rpc
+
+import (
+	"context"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	web3Types "github.com/openweb3/web3go/types"
+)
+
+// transaction status tracked by gateway
+const (
+	TxStatusUnknown   = "unknown"   // not submitted through gateway or untracked
+	TxStatusSubmitted = "submitted" // accepted by upstream, but not found in txpool yet
+	TxStatusPending   = "pending"   // found in txpool
+	TxStatusMined     = "mined"     // receipt available
+	TxStatusDropped   = "dropped"   // neither mined nor pending for a long time
+)
+
+// TxStatusConfig configurations to track transactions submitted through gateway.
+type TxStatusConfig struct {
+	// duration after submission to regard transaction as dropped if neither mined nor pending
+	DropTimeout time.Duration `default:"10m"`
+}
+
+// TxStatus is the result of `gateway_getTxStatus`.
+type TxStatus struct {
+	Hash   common.Hash `json:"hash"`
+	Status string      `json:"status"`
+	// unix timestamp in seconds when submitted through gateway
+	SubmittedAt hexutil.Uint64 `json:"submittedAt,omitempty"`
+	// node name of the sequencer or fullnode which accepted the transaction
+	Endpoint string `json:"endpoint,omitempty"`
+	// only available if mined
+	Receipt *web3Types.Receipt `json:"receipt,omitempty"`
+}
+
+// GetTxStatus returns the status of transaction submitted through gateway, which is determined
+// by polling upstream receipt and txpool. Note, transactions are tracked for a limited duration,
+// after which `unknown` returned.
+func (api *gatewayAPI) GetTxStatus(ctx context.Context, txHash common.Hash) (*TxStatus, error) {
+	broadcastAt, endpoint, ok := api.eth.txInclusion.lookup(txHash)
+	if !ok {
+		return &TxStatus{Hash: txHash, Status: TxStatusUnknown}, nil
+	}
+
+	status := TxStatus{
+		Hash:        txHash,
+		SubmittedAt: hexutil.Uint64(broadcastAt.Unix()),
+		Endpoint:    endpoint,
+	}
+
+	receipt, err := api.eth.GetTransactionReceipt(ctx, txHash)
+	if err != nil {
+		return nil, err
+	}
+
+	if receipt != nil {
+		status.Status = TxStatusMined
+		status.Receipt = receipt
+		return &status, nil
+	}
+
+	tx, err := api.eth.GetTransactionByHash(ctx, txHash)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case tx != nil:
+		status.Status = TxStatusPending
+	case time.Since(broadcastAt) > api.txStatus.DropTimeout:
+		status.Status = TxStatusDropped
+	default:
+		status.Status = TxStatusSubmitted
+	}
+
+	return &status, nil
+}
@@ -0,0 +1,367 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	viperutil "github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/pkg/errors"
+	"github.com/scroll-tech/rpc-gateway/node"
+	"github.com/scroll-tech/rpc-gateway/util/metrics"
+	"github.com/scroll-tech/rpc-gateway/util/rpc/handlers"
+	"github.com/scroll-tech/rpc-gateway/util/rpc/middlewares"
+	"github.com/sirupsen/logrus"
+)
+
+// graphqlMethod is the pseudo method name of GraphQL queries for rate limit, kill switch
+// and metrics.
+const graphqlMethod = "graphql"
+
+// graphqlProbeQuery is the GraphQL query to check health of node GraphQL endpoint.
+const graphqlProbeQuery = `{"query":"{ block { number } }"}`
+
+// GraphQLConfig configurations to proxy GraphQL queries to the GraphQL endpoint of full nodes,
+// e.g. `/graphql` of geth, with the same routing, auth and rate limiting as JSON-RPC.
+type GraphQLConfig struct {
+	Enabled bool
+	// path of GraphQL endpoint served by gateway
+	Path string `default:"/graphql"`
+	// path of GraphQL endpoint of full nodes, which shares the host with node RPC URL
+	NodePath string `default:"/graphql"`
+	// timeout to proxy GraphQL query to full node
+	Timeout time.Duration `default:"30s"`
+	// max size of GraphQL request body
+	MaxBodySize int64 `default:"1048576"`
+	// health check of node GraphQL endpoints
+	HealthCheck struct {
+		// interval to probe node GraphQL endpoints
+		Interval time.Duration `default:"10s"`
+		// number of consecutive failures to regard node GraphQL endpoint as unhealthy
+		Failures int `default:"3"`
+		// node GraphQL endpoints not routed within the duration are no longer probed
+		Expiry time.Duration `default:"10m"`
+	}
+}
+
+// graphqlNodeHealth is the health status of node GraphQL endpoint.
+type graphqlNodeHealth struct {
+	failures   int
+	healthy    bool
+	lastRouted time.Time
+}
+
+// graphqlProxy proxies GraphQL queries to full nodes routed by consistent hashing, and skips
+// nodes of which GraphQL endpoint is unhealthy.
+type graphqlProxy struct {
+	conf     *GraphQLConfig
+	provider *node.EthClientProvider
+	client   *http.Client
+
+	mu    sync.Mutex
+	nodes map[string]*graphqlNodeHealth // node URL => GraphQL health
+}
+
+func newGraphqlProxy(conf *GraphQLConfig, provider *node.EthClientProvider) *graphqlProxy {
+	return &graphqlProxy{
+		conf:     conf,
+		provider: provider,
+		client:   &http.Client{Timeout: conf.Timeout},
+		nodes:    make(map[string]*graphqlNodeHealth),
+	}
+}
+
+// graphqlMiddleware serves GraphQL queries on the configured path, optionally prefixed with access
+// token, and delegates other requests to the next handler. Note, it should be applied after HTTP
+// middleware to inject context values.
+func graphqlMiddleware(provider *node.EthClientProvider) handlers.Middleware {
+	var conf GraphQLConfig
+	viperutil.MustUnmarshalKey("rpc.graphql", &conf)
+
+	return func(next http.Handler) http.Handler {
+		if !conf.Enabled {
+			return next
+		}
+
+		proxy := newGraphqlProxy(&conf, provider)
+		go proxy.monitor()
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if len(r.Header.Get("Upgrade")) > 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if req, path, ok := matchPathPrefix(r, conf.Path); ok && len(path) == 0 {
+				proxy.ServeHTTP(w, req)
+			} else {
+				next.ServeHTTP(w, r)
+			}
+		})
+	}
+}
+
+func (p *graphqlProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		writeGraphqlError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, p.conf.MaxBodySize+1))
+	if err != nil {
+		writeGraphqlError(w, http.StatusBadRequest, errors.WithMessage(err, "failed to read request"))
+		return
+	}
+
+	if int64(len(body)) > p.conf.MaxBodySize {
+		writeGraphqlError(w, http.StatusRequestEntityTooLarge, errors.New("request body too large"))
+		return
+	}
+
+	if err := middlewares.ValidateApiKey(ctx); err != nil {
+		writeGraphqlError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	if err := defaultKillSwitches.check(graphqlMethod, ""); err != nil {
+		writeGraphqlError(w, http.StatusServiceUnavailable, err)
+		return
+	}
+
+	if err := middlewares.CheckRateLimit(ctx, graphqlMethod); err != nil {
+		writeGraphqlError(w, http.StatusTooManyRequests, err)
+		return
+	}
+
+	client, err := p.route(ctx)
+	if err != nil {
+		writeGraphqlError(w, http.StatusServiceUnavailable, err)
+		return
+	}
+
+	// per group kill switch
+	if err := defaultKillSwitches.check(graphqlMethod, node.GroupEthHttp); err != nil {
+		writeGraphqlError(w, http.StatusServiceUnavailable, err)
+		return
+	}
+
+	start := time.Now()
+	resp, err := p.forward(ctx, r, client.URL, body)
+	metrics.Registry.RPC.UpdateDuration(graphqlMethod, err, start)
+	p.report(client.URL, err == nil && resp.StatusCode < http.StatusInternalServerError)
+
+	if err != nil {
+		logrus.WithError(err).WithField("node", client.URL).Debug("Failed to proxy GraphQL query")
+		writeGraphqlError(w, http.StatusBadGateway, errors.New("failed to request full node"))
+		return
+	}
+
+	defer resp.Body.Close()
+
+	if contentType := resp.Header.Get("Content-Type"); len(contentType) > 0 {
+		w.Header().Set("Content-Type", contentType)
+	}
+
+	w.WriteHeader(resp.StatusCode)
+	io.Copy(w, resp.Body)
+}
+
+// route routes GraphQL query by consistent hashing as JSON-RPC, and reroutes to another node
+// if GraphQL endpoint of the routed node is unhealthy.
+func (p *graphqlProxy) route(ctx context.Context) (*node.Web3goClient, error) {
+	client, err := p.provider.GetClientByIPGroup(ctx, node.GroupEthHttp)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.routed(client.URL) {
+		return client, nil
+	}
+
+	client, err = p.provider.GetClientRandomByGroupExcept(node.GroupEthHttp, p.unhealthyNodes()...)
+	if err != nil {
+		return nil, err
+	}
+
+	p.routed(client.URL)
+
+	return client, nil
+}
+
+func (p *graphqlProxy) forward(
+	ctx context.Context, r *http.Request, nodeUrl string, body []byte,
+) (*http.Response, error) {
+	endpoint, err := graphqlEndpoint(nodeUrl, p.conf.NodePath)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(r.URL.RawQuery) > 0 {
+		endpoint += "?" + r.URL.RawQuery
+	}
+
+	req, err := http.NewRequestWithContext(ctx, r.Method, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to create request")
+	}
+
+	for _, header := range []string{"Content-Type", "Accept"} {
+		if v := r.Header.Get(header); len(v) > 0 {
+			req.Header.Set(header, v)
+		}
+	}
+
+	return p.client.Do(req)
+}
+
+// graphqlEndpoint returns the GraphQL endpoint of full node, which shares the host with node
+// RPC URL, e.g. `ws://127.0.0.1:8546` => `http://127.0.0.1:8546/graphql`.
+func graphqlEndpoint(nodeUrl, path string) (string, error) {
+	u, err := url.Parse(nodeUrl)
+	if err != nil {
+		return "", errors.WithMessage(err, "invalid node URL")
+	}
+
+	switch u.Scheme {
+	case "ws":
+		u.Scheme = "http"
+	case "wss":
+		u.Scheme = "https"
+	}
+
+	u.Path, u.RawPath, u.RawQuery, u.Fragment = path, "", "", ""
+
+	return u.String(), nil
+}
+
+// routed marks the node as routed, and returns whether its GraphQL endpoint is healthy.
+func (p *graphqlProxy) routed(nodeUrl string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	health, ok := p.nodes[nodeUrl]
+	if !ok {
+		health = &graphqlNodeHealth{healthy: true}
+		p.nodes[nodeUrl] = health
+	}
+
+	health.lastRouted = time.Now()
+
+	return health.healthy
+}
+
+func (p *graphqlProxy) unhealthyNodes() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var urls []string
+	for nodeUrl, health := range p.nodes {
+		if !health.healthy {
+			urls = append(urls, nodeUrl)
+		}
+	}
+
+	return urls
+}
+
+// report updates health status of node GraphQL endpoint.
+func (p *graphqlProxy) report(nodeUrl string, success bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	health, ok := p.nodes[nodeUrl]
+	if !ok {
+		return
+	}
+
+	if success {
+		if !health.healthy {
+			logrus.WithField("node", nodeUrl).Info("Node GraphQL endpoint becomes healthy")
+		}
+
+		health.failures, health.healthy = 0, true
+		return
+	}
+
+	health.failures++
+
+	if health.healthy && health.failures >= p.conf.HealthCheck.Failures {
+		health.healthy = false
+		logrus.WithFields(logrus.Fields{
+			"node": nodeUrl, "failures": health.failures,
+		}).Warn("Node GraphQL endpoint becomes unhealthy")
+	}
+}
+
+// monitor probes GraphQL endpoints of routed nodes periodically.
+func (p *graphqlProxy) monitor() {
+	ticker := time.NewTicker(p.conf.HealthCheck.Interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		for _, nodeUrl := range p.expire() {
+			p.report(nodeUrl, p.probe(nodeUrl) == nil)
+		}
+	}
+}
+
+// expire removes nodes not routed for a long time, and returns the remaining ones.
+func (p *graphqlProxy) expire() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var urls []string
+	for nodeUrl, health := range p.nodes {
+		if time.Since(health.lastRouted) > p.conf.HealthCheck.Expiry {
+			delete(p.nodes, nodeUrl)
+		} else {
+			urls = append(urls, nodeUrl)
+		}
+	}
+
+	return urls
+}
+
+func (p *graphqlProxy) probe(nodeUrl string) error {
+	endpoint, err := graphqlEndpoint(nodeUrl, p.conf.NodePath)
+	if err != nil {
+		return err
+	}
+
+	resp, err := p.client.Post(endpoint, "application/json", bytes.NewReader([]byte(graphqlProbeQuery)))
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("unexpected status code %v", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// writeGraphqlError writes error in GraphQL response format, along with error data if any.
+func writeGraphqlError(w http.ResponseWriter, status int, err error) {
+	gqlErr := map[string]interface{}{"message": err.Error()}
+
+	if de, ok := err.(interface{ ErrorData() interface{} }); ok {
+		gqlErr["extensions"] = de.ErrorData()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"errors": []interface{}{gqlErr},
+	})
+}
@@ -0,0 +1,428 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	rpcutil "github.com/scroll-tech/rpc-gateway/util/rpc"
+	"github.com/scroll-tech/rpc-gateway/util/rpc/grpcwire"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	grpcServiceName = "gateway.rpc.v1.Gateway"
+
+	// gRPC metadata key of API key
+	grpcMetadataApiKey = "x-api-key"
+)
+
+// GrpcConfig configurations of JSON-RPC over gRPC transport for high-throughput internal
+// consumers, which shares the same routing, caching and limiting pipeline.
+type GrpcConfig struct {
+	// served gRPC endpoint, and empty to disable
+	Endpoint string
+	// max size of received message in bytes
+	MaxRecvMsgSize int `default:"4194304"`
+}
+
+// grpcGateway implements the Gateway gRPC service defined in gateway.proto, which invokes
+// JSON-RPC in process by the HTTP handler of RPC server.
+type grpcGateway struct {
+	handler http.Handler
+}
+
+// NewEvmSpaceGrpcServer creates the gRPC server of evm space upon the RPC server.
+func NewEvmSpaceGrpcServer(server *rpcutil.Server, conf *GrpcConfig) *grpc.Server {
+	gs := grpc.NewServer(grpc.ForceServerCodec(grpcwire.Codec{}), grpc.MaxRecvMsgSize(conf.MaxRecvMsgSize))
+	gs.RegisterService(&grpcGatewayServiceDesc, &grpcGateway{server.HttpHandler()})
+
+	return gs
+}
+
+// MustServeGrpcGraceful serves gRPC server until graceful shutdown.
+func MustServeGrpcGraceful(ctx context.Context, wg *sync.WaitGroup, server *grpc.Server, endpoint string) {
+	logger := logrus.WithField("endpoint", endpoint)
+
+	listener, err := net.Listen("tcp", endpoint)
+	if err != nil {
+		logger.WithError(err).Fatal("Failed to listen to gRPC endpoint")
+	}
+
+	wg.Add(1)
+	defer wg.Done()
+
+	go server.Serve(listener)
+	logger.Info("JSON-RPC gRPC server started")
+
+	<-ctx.Done()
+
+	server.GracefulStop()
+	logger.Info("JSON-RPC gRPC server shutdown")
+}
+
+// invoke invokes JSON-RPC in process, along with API key and client address of gRPC request.
+func (g *grpcGateway) invoke(ctx context.Context, method string, params interface{}) (json.RawMessage, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	// access token path pattern
+	path := "/"
+	if keys := md.Get(grpcMetadataApiKey); len(keys) > 0 {
+		path += url.PathEscape(keys[0])
+	}
+
+	req, err := newJsonRpcRequest(ctx, path, method, params)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	if v := md.Get("x-forwarded-for"); len(v) > 0 {
+		req.Header.Set("X-Forwarded-For", strings.Join(v, ","))
+	}
+
+	if v := md.Get("user-agent"); len(v) > 0 {
+		req.Header.Set("User-Agent", v[0])
+	}
+
+	if p, ok := peer.FromContext(ctx); ok {
+		req.RemoteAddr = p.Addr.String()
+	}
+
+	resp, recorder := invokeJsonRpc(g.handler, req)
+	if resp == nil { // rejected by HTTP middlewares
+		return nil, status.Error(grpcCodeOfHttpStatus(recorder.status), strings.TrimSpace(recorder.body.String()))
+	}
+
+	if resp.Error != nil {
+		code := grpcCodeOfJsonRpcError(resp.Error)
+		if len(recorder.header.Get("Retry-After")) > 0 { // rate limited
+			code = codes.ResourceExhausted
+		}
+
+		return nil, status.Errorf(code, "%v (code %v)", resp.Error.Message, resp.Error.Code)
+	}
+
+	return resp.Result, nil
+}
+
+// call invokes JSON-RPC and decodes the result, which should not be null.
+func (g *grpcGateway) call(ctx context.Context, result interface{}, method string, params ...interface{}) error {
+	if params == nil {
+		params = []interface{}{}
+	}
+
+	data, err := g.invoke(ctx, method, params)
+	if err != nil {
+		return err
+	}
+
+	if len(data) == 0 || string(data) == "null" {
+		return status.Errorf(codes.NotFound, "%v not found", method)
+	}
+
+	if raw, ok := result.(*json.RawMessage); ok {
+		*raw = data
+		return nil
+	}
+
+	if err := json.Unmarshal(data, result); err != nil {
+		return status.Errorf(codes.Internal, "invalid result of %v: %v", method, err)
+	}
+
+	return nil
+}
+
+func (g *grpcGateway) callUint64(ctx context.Context, method string, params ...interface{}) (*pbUint64Value, error) {
+	var result hexutil.Uint64
+	if err := g.call(ctx, &result, method, params...); err != nil {
+		return nil, err
+	}
+
+	return &pbUint64Value{Value: uint64(result)}, nil
+}
+
+func (g *grpcGateway) callBig(ctx context.Context, method string, params ...interface{}) (*pbBytesValue, error) {
+	var result hexutil.Big
+	if err := g.call(ctx, &result, method, params...); err != nil {
+		return nil, err
+	}
+
+	return &pbBytesValue{Value: result.ToInt().Bytes()}, nil
+}
+
+func (g *grpcGateway) callBytes(ctx context.Context, method string, params ...interface{}) (*pbBytesValue, error) {
+	var result hexutil.Bytes
+	if err := g.call(ctx, &result, method, params...); err != nil {
+		return nil, err
+	}
+
+	return &pbBytesValue{Value: result}, nil
+}
+
+func (g *grpcGateway) callJson(ctx context.Context, method string, params ...interface{}) (*pbJsonValue, error) {
+	var result json.RawMessage
+	if err := g.call(ctx, &result, method, params...); err != nil {
+		return nil, err
+	}
+
+	return &pbJsonValue{Json: result}, nil
+}
+
+func (g *grpcGateway) Call(ctx context.Context, req *pbCallRequest) (*pbJsonValue, error) {
+	if len(req.Method) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "method required")
+	}
+
+	var params interface{} = []interface{}{}
+	if len(req.Params) > 0 {
+		if !json.Valid(req.Params) {
+			return nil, status.Error(codes.InvalidArgument, "invalid params in JSON")
+		}
+
+		params = json.RawMessage(req.Params)
+	}
+
+	result, err := g.invoke(ctx, req.Method, params)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pbJsonValue{Json: result}, nil
+}
+
+func (g *grpcGateway) ChainId(ctx context.Context, req *grpcwire.Empty) (*pbUint64Value, error) {
+	return g.callUint64(ctx, "eth_chainId")
+}
+
+func (g *grpcGateway) BlockNumber(ctx context.Context, req *grpcwire.Empty) (*pbUint64Value, error) {
+	return g.callUint64(ctx, "eth_blockNumber")
+}
+
+func (g *grpcGateway) GasPrice(ctx context.Context, req *grpcwire.Empty) (*pbBytesValue, error) {
+	return g.callBig(ctx, "eth_gasPrice")
+}
+
+func (g *grpcGateway) GetBalance(ctx context.Context, req *pbAccountRequest) (*pbBytesValue, error) {
+	addr, block, err := grpcAccountParams(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return g.callBig(ctx, "eth_getBalance", addr, block)
+}
+
+func (g *grpcGateway) GetTransactionCount(ctx context.Context, req *pbAccountRequest) (*pbUint64Value, error) {
+	addr, block, err := grpcAccountParams(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return g.callUint64(ctx, "eth_getTransactionCount", addr, block)
+}
+
+func (g *grpcGateway) GetCode(ctx context.Context, req *pbAccountRequest) (*pbBytesValue, error) {
+	addr, block, err := grpcAccountParams(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return g.callBytes(ctx, "eth_getCode", addr, block)
+}
+
+func (g *grpcGateway) GetBlock(ctx context.Context, req *pbBlockRequest) (*pbJsonValue, error) {
+	if isHashParam(req.Block) {
+		return g.callJson(ctx, "eth_getBlockByHash", req.Block, req.FullTransactions)
+	}
+
+	block, err := grpcBlockParam(req.Block)
+	if err != nil {
+		return nil, err
+	}
+
+	return g.callJson(ctx, "eth_getBlockByNumber", block, req.FullTransactions)
+}
+
+func (g *grpcGateway) GetTransaction(ctx context.Context, req *pbHashRequest) (*pbJsonValue, error) {
+	hash, err := grpcHashParam(req.Hash)
+	if err != nil {
+		return nil, err
+	}
+
+	return g.callJson(ctx, "eth_getTransactionByHash", hash)
+}
+
+func (g *grpcGateway) GetTransactionReceipt(ctx context.Context, req *pbHashRequest) (*pbJsonValue, error) {
+	hash, err := grpcHashParam(req.Hash)
+	if err != nil {
+		return nil, err
+	}
+
+	return g.callJson(ctx, "eth_getTransactionReceipt", hash)
+}
+
+func (g *grpcGateway) SendRawTransaction(ctx context.Context, req *pbBytesValue) (*pbBytesValue, error) {
+	if len(req.Value) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "raw transaction required")
+	}
+
+	return g.callBytes(ctx, "eth_sendRawTransaction", hexutil.Encode(req.Value))
+}
+
+// grpcBlockParam returns the block param of JSON-RPC, which defaults to `latest`.
+func grpcBlockParam(block string) (string, error) {
+	if len(block) == 0 {
+		return "latest", nil
+	}
+
+	if isHashParam(block) {
+		return block, nil
+	}
+
+	block, err := parseBlockParam(block)
+	if err != nil {
+		return "", status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	return block, nil
+}
+
+func grpcAccountParams(req *pbAccountRequest) (string, string, error) {
+	if len(req.Address) != common.AddressLength {
+		return "", "", status.Error(codes.InvalidArgument, "invalid address length")
+	}
+
+	block, err := grpcBlockParam(req.Block)
+	if err != nil {
+		return "", "", err
+	}
+
+	return common.BytesToAddress(req.Address).Hex(), block, nil
+}
+
+func grpcHashParam(hash []byte) (string, error) {
+	if len(hash) != common.HashLength {
+		return "", status.Error(codes.InvalidArgument, "invalid hash length")
+	}
+
+	return common.BytesToHash(hash).Hex(), nil
+}
+
+func grpcCodeOfJsonRpcError(err *jsonRpcError) codes.Code {
+	switch err.Code {
+	case -32600, -32602:
+		return codes.InvalidArgument
+	case -32601:
+		return codes.Unimplemented
+	default:
+		return codes.Unknown
+	}
+}
+
+func grpcCodeOfHttpStatus(httpStatus int) codes.Code {
+	switch httpStatus {
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case http.StatusBadRequest, http.StatusRequestEntityTooLarge:
+		return codes.InvalidArgument
+	default:
+		return codes.Unavailable
+	}
+}
+
+// grpcGatewayUnaryHandler adapts typed gRPC method to generic unary handler.
+func grpcGatewayUnaryHandler(
+	method string,
+	newReq func() grpcwire.Unmarshaler,
+	call func(g *grpcGateway, ctx context.Context, req interface{}) (interface{}, error),
+) grpc.MethodDesc {
+	return grpc.MethodDesc{
+		MethodName: method,
+		Handler: func(
+			srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor,
+		) (interface{}, error) {
+			req := newReq()
+			if err := dec(req); err != nil {
+				return nil, err
+			}
+
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				return call(srv.(*grpcGateway), ctx, req)
+			}
+
+			if interceptor == nil {
+				return handler(ctx, req)
+			}
+
+			info := &grpc.UnaryServerInfo{
+				Server:     srv,
+				FullMethod: "/" + grpcServiceName + "/" + method,
+			}
+
+			return interceptor(ctx, req, info, handler)
+		},
+	}
+}
+
+func newPbEmpty() grpcwire.Unmarshaler          { return &grpcwire.Empty{} }
+func newPbCallRequest() grpcwire.Unmarshaler    { return &pbCallRequest{} }
+func newPbAccountRequest() grpcwire.Unmarshaler { return &pbAccountRequest{} }
+func newPbBlockRequest() grpcwire.Unmarshaler   { return &pbBlockRequest{} }
+func newPbHashRequest() grpcwire.Unmarshaler    { return &pbHashRequest{} }
+func newPbBytesValue() grpcwire.Unmarshaler     { return &pbBytesValue{} }
+
+var grpcGatewayServiceDesc = grpc.ServiceDesc{
+	ServiceName: grpcServiceName,
+	HandlerType: (*interface{})(nil),
+	Methods: []grpc.MethodDesc{
+		grpcGatewayUnaryHandler("Call", newPbCallRequest, func(g *grpcGateway, ctx context.Context, req interface{}) (interface{}, error) {
+			return g.Call(ctx, req.(*pbCallRequest))
+		}),
+		grpcGatewayUnaryHandler("ChainId", newPbEmpty, func(g *grpcGateway, ctx context.Context, req interface{}) (interface{}, error) {
+			return g.ChainId(ctx, req.(*grpcwire.Empty))
+		}),
+		grpcGatewayUnaryHandler("BlockNumber", newPbEmpty, func(g *grpcGateway, ctx context.Context, req interface{}) (interface{}, error) {
+			return g.BlockNumber(ctx, req.(*grpcwire.Empty))
+		}),
+		grpcGatewayUnaryHandler("GasPrice", newPbEmpty, func(g *grpcGateway, ctx context.Context, req interface{}) (interface{}, error) {
+			return g.GasPrice(ctx, req.(*grpcwire.Empty))
+		}),
+		grpcGatewayUnaryHandler("GetBalance", newPbAccountRequest, func(g *grpcGateway, ctx context.Context, req interface{}) (interface{}, error) {
+			return g.GetBalance(ctx, req.(*pbAccountRequest))
+		}),
+		grpcGatewayUnaryHandler("GetTransactionCount", newPbAccountRequest, func(g *grpcGateway, ctx context.Context, req interface{}) (interface{}, error) {
+			return g.GetTransactionCount(ctx, req.(*pbAccountRequest))
+		}),
+		grpcGatewayUnaryHandler("GetCode", newPbAccountRequest, func(g *grpcGateway, ctx context.Context, req interface{}) (interface{}, error) {
+			return g.GetCode(ctx, req.(*pbAccountRequest))
+		}),
+		grpcGatewayUnaryHandler("GetBlock", newPbBlockRequest, func(g *grpcGateway, ctx context.Context, req interface{}) (interface{}, error) {
+			return g.GetBlock(ctx, req.(*pbBlockRequest))
+		}),
+		grpcGatewayUnaryHandler("GetTransaction", newPbHashRequest, func(g *grpcGateway, ctx context.Context, req interface{}) (interface{}, error) {
+			return g.GetTransaction(ctx, req.(*pbHashRequest))
+		}),
+		grpcGatewayUnaryHandler("GetTransactionReceipt", newPbHashRequest, func(g *grpcGateway, ctx context.Context, req interface{}) (interface{}, error) {
+			return g.GetTransactionReceipt(ctx, req.(*pbHashRequest))
+		}),
+		grpcGatewayUnaryHandler("SendRawTransaction", newPbBytesValue, func(g *grpcGateway, ctx context.Context, req interface{}) (interface{}, error) {
+			return g.SendRawTransaction(ctx, req.(*pbBytesValue))
+		}),
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "rpc/gateway.proto",
+}
@@ -0,0 +1,111 @@
+package rpc
+
+import (
+	"github.com/scroll-tech/rpc-gateway/util/rpc/grpcwire"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Protobuf messages defined in gateway.proto, which are encoded in wire format manually to
+// avoid code generation, the same as node management gRPC service.
+
+type pbCallRequest struct {
+	Method string
+	Params []byte
+}
+
+func (m *pbCallRequest) UnmarshalWire(b []byte) error {
+	return grpcwire.ConsumeFields(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		switch num {
+		case 1:
+			return grpcwire.ConsumeString(typ, b, &m.Method)
+		case 2:
+			return grpcwire.ConsumeBytes(typ, b, &m.Params)
+		}
+
+		return 0, nil
+	})
+}
+
+type pbJsonValue struct {
+	Json []byte
+}
+
+func (m *pbJsonValue) MarshalWire(b []byte) []byte {
+	return grpcwire.AppendBytes(b, 1, m.Json)
+}
+
+type pbUint64Value struct {
+	Value uint64
+}
+
+func (m *pbUint64Value) MarshalWire(b []byte) []byte {
+	return grpcwire.AppendVarint(b, 1, m.Value)
+}
+
+type pbBytesValue struct {
+	Value []byte
+}
+
+func (m *pbBytesValue) MarshalWire(b []byte) []byte {
+	return grpcwire.AppendBytes(b, 1, m.Value)
+}
+
+func (m *pbBytesValue) UnmarshalWire(b []byte) error {
+	return grpcwire.ConsumeFields(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		if num == 1 {
+			return grpcwire.ConsumeBytes(typ, b, &m.Value)
+		}
+
+		return 0, nil
+	})
+}
+
+type pbAccountRequest struct {
+	Address []byte
+	Block   string
+}
+
+func (m *pbAccountRequest) UnmarshalWire(b []byte) error {
+	return grpcwire.ConsumeFields(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		switch num {
+		case 1:
+			return grpcwire.ConsumeBytes(typ, b, &m.Address)
+		case 2:
+			return grpcwire.ConsumeString(typ, b, &m.Block)
+		}
+
+		return 0, nil
+	})
+}
+
+type pbBlockRequest struct {
+	Block            string
+	FullTransactions bool
+}
+
+func (m *pbBlockRequest) UnmarshalWire(b []byte) error {
+	return grpcwire.ConsumeFields(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		switch num {
+		case 1:
+			return grpcwire.ConsumeString(typ, b, &m.Block)
+		case 2:
+			return grpcwire.ConsumeBool(typ, b, &m.FullTransactions)
+		}
+
+		return 0, nil
+	})
+}
+
+type pbHashRequest struct {
+	Hash []byte
+}
+
+func (m *pbHashRequest) UnmarshalWire(b []byte) error {
+	return grpcwire.ConsumeFields(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		if num == 1 {
+			return grpcwire.ConsumeBytes(typ, b, &m.Hash)
+		}
+
+		return 0, nil
+	})
+}
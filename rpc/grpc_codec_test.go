@@ -0,0 +1,35 @@
+package rpc
+
+import (
+	"testing"
+
+	"github.com/scroll-tech/rpc-gateway/util/rpc/grpcwire"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func TestWireCodecUnmarshal(t *testing.T) {
+	var b []byte
+	b = grpcwire.AppendBytes(b, 1, []byte("latest"))
+	b = grpcwire.AppendVarint(b, 9, 100) // unknown field
+	b = grpcwire.AppendVarint(b, 2, protowire.EncodeBool(true))
+
+	var req pbBlockRequest
+	assert.Nil(t, grpcwire.Codec{}.Unmarshal(b, &req))
+	assert.Equal(t, "latest", req.Block)
+	assert.True(t, req.FullTransactions)
+
+	// invalid wire type
+	b = protowire.AppendTag(nil, 1, protowire.VarintType)
+	b = protowire.AppendVarint(b, 1)
+	assert.NotNil(t, grpcwire.Codec{}.Unmarshal(b, &req))
+}
+
+func TestWireCodecMarshal(t *testing.T) {
+	data, err := grpcwire.Codec{}.Marshal(&pbUint64Value{Value: 300})
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{0x08, 0xac, 0x02}, data)
+
+	_, err = grpcwire.Codec{}.Marshal(&pbHashRequest{})
+	assert.NotNil(t, err)
+}
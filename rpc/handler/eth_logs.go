@@ -107,8 +107,9 @@ func (handler *EthLogsApiHandler) getLogsReorgGuard(
 			return nil, false, err
 		}
 
-		fnLogs, err := eth.Logs(*fnFilter)
-		if err != nil {
+		// pass request context along, so that oversized response could be aborted while reading
+		var fnLogs []types.Log
+		if err := eth.CallContext(ctx, &fnLogs, "eth_getLogs", *fnFilter); err != nil {
 			return nil, false, err
 		}
 
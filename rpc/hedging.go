@@ -0,0 +1,165 @@
+package rpc
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/scroll-tech/rpc-gateway/node"
+	"github.com/scroll-tech/rpc-gateway/util/metrics"
+	"github.com/scroll-tech/rpc-gateway/util/reload"
+	"github.com/scroll-tech/rpc-gateway/util/rpc/handlers"
+	"github.com/sirupsen/logrus"
+)
+
+// hedgingUnsafeMethods are methods with side effects, which should never be hedged.
+var hedgingUnsafeMethods = map[string]bool{
+	"eth_sendRawTransaction": true,
+	"eth_submitTransaction":  true,
+	"eth_sendTransaction":    true,
+}
+
+// HedgingConfig configurations of request hedging for slow nodes.
+type HedgingConfig struct {
+	Enabled bool
+	// fixed delay before hedging request to another node
+	Delay time.Duration `default:"200ms"`
+	// percentile (e.g. 0.95) of method latency used as hedging delay if samples available,
+	// and 0 to always use the fixed delay
+	Percentile float64
+	// read-only methods opted in for hedging
+	Methods []string
+}
+
+type hedgingPolicy struct {
+	HedgingConfig
+	methods map[string]bool
+}
+
+// hedging is the hedging policy in use, which could be changed at runtime.
+var hedging atomic.Value
+
+func init() {
+	policy, err := loadHedgingPolicy()
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to load request hedging config")
+	}
+
+	hedging.Store(policy)
+
+	reload.Register("rpc_hedging", func() error {
+		policy, err := loadHedgingPolicy()
+		if err != nil {
+			return err
+		}
+
+		hedging.Store(policy)
+		return nil
+	})
+}
+
+func loadHedgingPolicy() (*hedgingPolicy, error) {
+	var conf HedgingConfig
+	if err := viper.UnmarshalKey("ethrpc.hedging", &conf); err != nil {
+		return nil, err
+	}
+
+	return newHedgingPolicy(conf), nil
+}
+
+func newHedgingPolicy(conf HedgingConfig) *hedgingPolicy {
+	policy := hedgingPolicy{HedgingConfig: conf, methods: make(map[string]bool)}
+
+	for _, m := range conf.Methods {
+		if hedgingUnsafeMethods[m] {
+			logrus.WithField("method", m).Warn("Method with side effects is not allowed for request hedging")
+			continue
+		}
+
+		policy.methods[m] = true
+	}
+
+	return &policy
+}
+
+// delay returns the hedging delay for the specified method.
+func (p *hedgingPolicy) delay(method string) time.Duration {
+	if p.Percentile <= 0 {
+		return p.Delay
+	}
+
+	timer := metrics.Registry.RPC.Duration(method)
+	if timer.Count() == 0 {
+		return p.Delay
+	}
+
+	return time.Duration(timer.Percentile(p.Percentile))
+}
+
+// shouldHedge checks if request hedging is enabled for the specified method.
+func shouldHedge(method string) (*hedgingPolicy, bool) {
+	policy := hedging.Load().(*hedgingPolicy)
+	return policy, policy.Enabled && policy.methods[method]
+}
+
+// hedgingClientProvider provides another node of the same group to hedge request.
+type hedgingClientProvider interface {
+	GetClientRandomByGroupExcept(group node.Group, excludedURLs ...string) (*node.Web3goClient, error)
+}
+
+// hedgeCall handles RPC call with the primary client, and issues a duplicate request to another
+// node of the same group if the primary one hasn't responded within hedging delay. Whichever
+// answers first is returned, unless it fails while the other one is still pending.
+func hedgeCall(
+	ctx context.Context,
+	msg *rpc.JsonRpcMessage,
+	next rpc.HandleCallMsgFunc,
+	policy *hedgingPolicy,
+	provider hedgingClientProvider,
+	group node.Group,
+	primary *node.Web3goClient,
+) *rpc.JsonRpcMessage {
+	// cancel the slower request once any responded
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	respCh := make(chan *rpc.JsonRpcMessage, 2)
+	call := func(client *node.Web3goClient) {
+		// each attempt with its own message, which may be mutated by middlewares
+		clone := *msg
+		respCh <- next(context.WithValue(ctx, ctxKeyClient, client), &clone)
+	}
+
+	go call(primary)
+
+	method := msg.Method
+	if chain, ok := ctx.Value(handlers.CtxKeyChain).(string); ok && len(chain) > 0 {
+		method = chain + "/" + method
+	}
+
+	timer := time.NewTimer(policy.delay(method))
+	defer timer.Stop()
+
+	select {
+	case resp := <-respCh:
+		metrics.Registry.RPC.Percentage(method, "hedged").Mark(false)
+		return resp
+	case <-timer.C:
+	}
+
+	secondary, err := provider.GetClientRandomByGroupExcept(group, primary.URL)
+	metrics.Registry.RPC.Percentage(method, "hedged").Mark(err == nil)
+	if err != nil { // no other node available to hedge
+		return <-respCh
+	}
+
+	go call(secondary)
+
+	if resp := <-respCh; resp.Error == nil {
+		return resp
+	}
+
+	return <-respCh
+}
@@ -0,0 +1,126 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/scroll-tech/rpc-gateway/node"
+	"github.com/stretchr/testify/assert"
+)
+
+// hedgingNodes provides the secondary node to hedge request, and counts the hedged requests.
+type hedgingNodes struct {
+	secondary *node.Web3goClient
+	hedged    int32
+}
+
+func (n *hedgingNodes) GetClientRandomByGroupExcept(
+	group node.Group, excludedURLs ...string,
+) (*node.Web3goClient, error) {
+	atomic.AddInt32(&n.hedged, 1)
+
+	if n.secondary == nil {
+		return nil, node.ErrClientUnavailable
+	}
+
+	return n.secondary, nil
+}
+
+func TestHedgeCall(t *testing.T) {
+	policy := newHedgingPolicy(HedgingConfig{Enabled: true, Delay: 20 * time.Millisecond})
+
+	primary := &node.Web3goClient{URL: "http://127.0.0.1:8545"}
+	secondary := &node.Web3goClient{URL: "http://127.0.0.2:8545"}
+
+	// latency and error of nodes
+	type reply struct {
+		latency time.Duration
+		failed  bool
+	}
+
+	call := func(replies map[string]reply, nodes *hedgingNodes) *rpc.JsonRpcMessage {
+		next := func(ctx context.Context, msg *rpc.JsonRpcMessage) *rpc.JsonRpcMessage {
+			url := GetEthClientFromContext(ctx).URL
+			r := replies[url]
+
+			select {
+			case <-ctx.Done():
+			case <-time.After(r.latency):
+			}
+
+			if r.failed {
+				return msg.ErrorResponse(errors.New("upstream failure"))
+			}
+
+			result, _ := json.Marshal(url)
+			return &rpc.JsonRpcMessage{Version: msg.Version, ID: msg.ID, Result: result}
+		}
+
+		msg := &rpc.JsonRpcMessage{Version: "2.0", ID: json.RawMessage("1"), Method: "eth_getBalance"}
+		return hedgeCall(context.Background(), msg, next, policy, nodes, node.GroupEthHttp, primary)
+	}
+
+	// primary responded within delay, and not hedged
+	nodes := &hedgingNodes{secondary: secondary}
+	resp := call(map[string]reply{primary.URL: {latency: time.Millisecond}}, nodes)
+	assert.Equal(t, json.RawMessage(`"http://127.0.0.1:8545"`), resp.Result)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&nodes.hedged))
+
+	// hedged after delay, and the first reply wins
+	nodes = &hedgingNodes{secondary: secondary}
+	start := time.Now()
+	resp = call(map[string]reply{
+		primary.URL:   {latency: time.Second},
+		secondary.URL: {latency: time.Millisecond},
+	}, nodes)
+	assert.Equal(t, json.RawMessage(`"http://127.0.0.2:8545"`), resp.Result)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&nodes.hedged))
+	assert.True(t, time.Since(start) >= policy.Delay)
+	assert.True(t, time.Since(start) < time.Second)
+
+	// hedged request failed, and wait for the primary
+	nodes = &hedgingNodes{secondary: secondary}
+	resp = call(map[string]reply{
+		primary.URL:   {latency: 50 * time.Millisecond},
+		secondary.URL: {failed: true},
+	}, nodes)
+	assert.Equal(t, json.RawMessage(`"http://127.0.0.1:8545"`), resp.Result)
+
+	// no other node to hedge
+	nodes = &hedgingNodes{}
+	resp = call(map[string]reply{primary.URL: {latency: 50 * time.Millisecond}}, nodes)
+	assert.Equal(t, json.RawMessage(`"http://127.0.0.1:8545"`), resp.Result)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&nodes.hedged))
+}
+
+func TestHedgingPolicy(t *testing.T) {
+	defer hedging.Store(hedging.Load())
+
+	hedging.Store(newHedgingPolicy(HedgingConfig{
+		Enabled: true,
+		Methods: []string{"eth_getBalance", "eth_sendRawTransaction", "eth_sendTransaction"},
+	}))
+
+	_, ok := shouldHedge("eth_getBalance")
+	assert.True(t, ok)
+
+	// methods with side effects are never hedged
+	_, ok = shouldHedge("eth_sendRawTransaction")
+	assert.False(t, ok)
+	_, ok = shouldHedge("eth_sendTransaction")
+	assert.False(t, ok)
+
+	// not opted in
+	_, ok = shouldHedge("eth_call")
+	assert.False(t, ok)
+
+	// disabled
+	hedging.Store(newHedgingPolicy(HedgingConfig{Methods: []string{"eth_getBalance"}}))
+	_, ok = shouldHedge("eth_getBalance")
+	assert.False(t, ok)
+}
@@ -0,0 +1,83 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+// jsonRpcError is the error of JSON-RPC response.
+type jsonRpcError struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+func (e *jsonRpcError) Error() string {
+	return e.Message
+}
+
+// jsonRpcResponse is the response of JSON-RPC call invoked in process.
+type jsonRpcResponse struct {
+	Result json.RawMessage `json:"result"`
+	Error  *jsonRpcError   `json:"error"`
+}
+
+func (resp *jsonRpcResponse) isNull() bool {
+	return len(resp.Result) == 0 || string(resp.Result) == "null"
+}
+
+// newJsonRpcRequest creates the HTTP request of JSON-RPC call to invoke in process, e.g. from
+// REST façade or gRPC transport.
+func newJsonRpcRequest(ctx context.Context, path, method string, params interface{}) (*http.Request, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0", "id": 1, "method": method, "params": params,
+	})
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to marshal JSON-RPC request")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, path, bytes.NewReader(body))
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to create JSON-RPC request")
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	return req, nil
+}
+
+// invokeJsonRpc handles the JSON-RPC request by HTTP handler in process, so as to share the same
+// middlewares, e.g. auth, rate limiting, routing and caching. Note, nil response returned if
+// the recorded response is not a valid JSON-RPC response, e.g. rejected by HTTP middlewares.
+func invokeJsonRpc(handler http.Handler, req *http.Request) (*jsonRpcResponse, *jsonRpcRecorder) {
+	recorder := newJsonRpcRecorder()
+	handler.ServeHTTP(recorder, req)
+
+	var resp jsonRpcResponse
+	if err := json.Unmarshal(recorder.body.Bytes(), &resp); err != nil {
+		return nil, recorder
+	}
+
+	return &resp, recorder
+}
+
+// jsonRpcRecorder records the response of JSON-RPC call invoked in process.
+type jsonRpcRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func newJsonRpcRecorder() *jsonRpcRecorder {
+	return &jsonRpcRecorder{header: make(http.Header), status: http.StatusOK}
+}
+
+func (r *jsonRpcRecorder) Header() http.Header { return r.header }
+
+func (r *jsonRpcRecorder) WriteHeader(status int) { r.status = status }
+
+func (r *jsonRpcRecorder) Write(b []byte) (int, error) { return r.body.Write(b) }
@@ -0,0 +1,247 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/pkg/errors"
+	"github.com/scroll-tech/rpc-gateway/node"
+	"github.com/scroll-tech/rpc-gateway/util/reload"
+	"github.com/sirupsen/logrus"
+)
+
+// errCodeMaintenance is the JSON-RPC error code for requests disabled by kill switch.
+const errCodeMaintenance = -32099
+
+// KillSwitchConfig configurations of kill switch to disable RPC methods during upstream incidents.
+type KillSwitchConfig struct {
+	// unique kill switch name
+	Name string `json:"name"`
+	// RPC methods to disable, which supports `*` suffix as wildcard, e.g. `debug_*` for namespace
+	Methods []string `json:"methods"`
+	// node groups to disable methods for, and empty means gateway-wide
+	Groups []string `json:"groups,omitempty"`
+	// maintenance message returned to clients
+	Message string `json:"message,omitempty"`
+}
+
+// matches checks if the RPC method routed to the specified group is disabled. Note, empty group
+// only matches gateway-wide kill switch.
+func (conf *KillSwitchConfig) matches(method string, group node.Group) bool {
+	if !matchMethods(conf.Methods, method) {
+		return false
+	}
+
+	if len(conf.Groups) == 0 {
+		return true
+	}
+
+	for _, g := range conf.Groups {
+		if len(group) > 0 && node.Group(g) == group.Base() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// maintenanceError is the structured error returned for requests disabled by kill switch.
+type maintenanceError struct {
+	method string
+	group  node.Group
+	conf   *KillSwitchConfig
+}
+
+func (e *maintenanceError) Error() string {
+	if len(e.conf.Message) > 0 {
+		return fmt.Sprintf("method %v under maintenance: %v", e.method, e.conf.Message)
+	}
+
+	return fmt.Sprintf("method %v under maintenance", e.method)
+}
+
+func (e *maintenanceError) ErrorCode() int { return errCodeMaintenance }
+
+func (e *maintenanceError) ErrorData() interface{} {
+	data := map[string]interface{}{
+		"killSwitch": e.conf.Name,
+		"method":     e.method,
+	}
+
+	if len(e.group) > 0 {
+		data["group"] = e.group
+	}
+
+	return data
+}
+
+// killSwitches manages kill switches from both config, which could be reloaded at runtime, and
+// administrative RPC, which take precedence over config with the same name.
+type killSwitches struct {
+	mu         sync.Mutex
+	configured []KillSwitchConfig
+	runtime    map[string]KillSwitchConfig
+	active     atomic.Value // []*KillSwitchConfig
+}
+
+var defaultKillSwitches = killSwitches{runtime: make(map[string]KillSwitchConfig)}
+
+func init() {
+	confs, err := loadKillSwitches()
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to load kill switch config")
+	}
+
+	defaultKillSwitches.setConfigured(confs)
+
+	reload.Register("rpc_kill_switches", func() error {
+		confs, err := loadKillSwitches()
+		if err != nil {
+			return err
+		}
+
+		defaultKillSwitches.setConfigured(confs)
+		return nil
+	})
+}
+
+func loadKillSwitches() ([]KillSwitchConfig, error) {
+	// list could only be unmarshalled as field, but not directly by key
+	var rpcConf struct {
+		KillSwitches []KillSwitchConfig
+	}
+
+	if err := viper.UnmarshalKey("rpc", &rpcConf); err != nil {
+		return nil, err
+	}
+
+	for _, conf := range rpcConf.KillSwitches {
+		if err := validateKillSwitch(&conf); err != nil {
+			return nil, err
+		}
+	}
+
+	return rpcConf.KillSwitches, nil
+}
+
+func validateKillSwitch(conf *KillSwitchConfig) error {
+	if len(conf.Name) == 0 {
+		return errors.New("kill switch name required")
+	}
+
+	if len(conf.Methods) == 0 {
+		return errors.Errorf("methods required for kill switch %v", conf.Name)
+	}
+
+	return nil
+}
+
+func (ks *killSwitches) setConfigured(confs []KillSwitchConfig) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	ks.configured = confs
+	ks.refresh()
+}
+
+// set turns on the kill switch at runtime.
+func (ks *killSwitches) set(conf KillSwitchConfig) error {
+	if err := validateKillSwitch(&conf); err != nil {
+		return err
+	}
+
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	ks.runtime[conf.Name] = conf
+	ks.refresh()
+
+	logrus.WithField("killSwitch", conf).Warn("Kill switch turned on")
+
+	return nil
+}
+
+// clear turns off the kill switch set at runtime, and returns false if not found.
+func (ks *killSwitches) clear(name string) bool {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	if _, ok := ks.runtime[name]; !ok {
+		return false
+	}
+
+	delete(ks.runtime, name)
+	ks.refresh()
+
+	logrus.WithField("name", name).Warn("Kill switch turned off")
+
+	return true
+}
+
+// refresh rebuilds the active kill switches, which should be called with lock held.
+func (ks *killSwitches) refresh() {
+	var active []*KillSwitchConfig
+
+	for i := range ks.configured {
+		if _, ok := ks.runtime[ks.configured[i].Name]; !ok {
+			active = append(active, &ks.configured[i])
+		}
+	}
+
+	var names []string
+	for name := range ks.runtime {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		conf := ks.runtime[name]
+		active = append(active, &conf)
+	}
+
+	ks.active.Store(active)
+}
+
+// list returns all the active kill switches.
+func (ks *killSwitches) list() []KillSwitchConfig {
+	active, _ := ks.active.Load().([]*KillSwitchConfig)
+
+	result := make([]KillSwitchConfig, 0, len(active))
+	for _, conf := range active {
+		result = append(result, *conf)
+	}
+
+	return result
+}
+
+// check returns the maintenance error if the RPC method routed to the specified group is
+// disabled. Note, empty group only checks gateway-wide kill switches.
+func (ks *killSwitches) check(method string, group node.Group) error {
+	active, _ := ks.active.Load().([]*KillSwitchConfig)
+
+	for _, conf := range active {
+		if conf.matches(method, group) {
+			return &maintenanceError{method: method, group: group, conf: conf}
+		}
+	}
+
+	return nil
+}
+
+// killSwitchMiddleware rejects RPC requests disabled by gateway-wide kill switches, while
+// per group kill switches are checked once request routed.
+func killSwitchMiddleware(next rpc.HandleCallMsgFunc) rpc.HandleCallMsgFunc {
+	return func(ctx context.Context, msg *rpc.JsonRpcMessage) *rpc.JsonRpcMessage {
+		if err := defaultKillSwitches.check(msg.Method, ""); err != nil {
+			return msg.ErrorResponse(err)
+		}
+
+		return next(ctx, msg)
+	}
+}
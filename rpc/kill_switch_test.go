@@ -0,0 +1,39 @@
+package rpc
+
+import (
+	"testing"
+
+	"github.com/scroll-tech/rpc-gateway/node"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKillSwitches(t *testing.T) {
+	ks := killSwitches{runtime: make(map[string]KillSwitchConfig)}
+	ks.setConfigured([]KillSwitchConfig{
+		{Name: "debug", Methods: []string{"debug_*"}},
+		{Name: "logs", Methods: []string{"eth_getLogs"}, Groups: []string{string(node.GroupEthLogs)}},
+	})
+
+	// gateway-wide
+	assert.NotNil(t, ks.check("debug_traceTransaction", ""))
+	assert.Nil(t, ks.check("eth_blockNumber", ""))
+
+	// per group
+	assert.Nil(t, ks.check("eth_getLogs", ""))
+	assert.Nil(t, ks.check("eth_getLogs", node.GroupEthHttp))
+	assert.NotNil(t, ks.check("eth_getLogs", node.GroupEthLogs))
+	assert.NotNil(t, ks.check("eth_getLogs", node.Group(node.GroupEthLogs).WithChain("sepolia")))
+
+	// runtime kill switch overrides configured one
+	assert.Nil(t, ks.set(KillSwitchConfig{Name: "debug", Methods: []string{"debug_traceCall"}}))
+	assert.Nil(t, ks.check("debug_traceTransaction", ""))
+	assert.NotNil(t, ks.check("debug_traceCall", ""))
+
+	assert.True(t, ks.clear("debug"))
+	assert.NotNil(t, ks.check("debug_traceTransaction", ""))
+	assert.False(t, ks.clear("logs"))
+
+	err := ks.check("debug_traceTransaction", "").(*maintenanceError)
+	assert.Equal(t, errCodeMaintenance, err.ErrorCode())
+	assert.Equal(t, "debug", err.ErrorData().(map[string]interface{})["killSwitch"])
+}
@@ -0,0 +1,259 @@
+package rpc
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/pkg/errors"
+	"github.com/scroll-tech/rpc-gateway/util/reload"
+	"github.com/sirupsen/logrus"
+)
+
+// maxLogSamplingKeys is the max number of methods and nodes tracked for log sampling.
+const maxLogSamplingKeys = 4096
+
+// error classes of RPC responses
+const (
+	errClassNone     = ""
+	errClassClient   = "client"   // invalid request, e.g. method not found or invalid params
+	errClassUpstream = "upstream" // failed to serve by gateway or full node
+)
+
+// LogSamplingConfig configurations of adaptive log sampling, which automatically raises
+// verbosity for methods or nodes exhibiting elevated upstream errors, and lowers it once
+// healthy again, so as to bound log volume while preserving diagnostic detail in incidents.
+type LogSamplingConfig struct {
+	Enabled bool
+	// sampling rate of requests when healthy, in range [0, 1]
+	BaseRate float64 `default:"0.001"`
+	// sampling rate of requests when elevated, in range [0, 1]
+	ElevatedRate float64 `default:"1"`
+	// upstream error rate to regard method or node as elevated
+	ErrorRate float64 `default:"0.05"`
+	// min number of requests within window to evaluate error rate
+	MinRequests int64 `default:"20"`
+	// window to evaluate error rate
+	Window time.Duration `default:"1m"`
+	// max number of sampled logs per second
+	MaxPerSecond int64 `default:"100"`
+}
+
+// samplingStats counts requests and upstream errors in a sliding window of two buckets.
+type samplingStats struct {
+	bucketStart     time.Time
+	total, errors   int64
+	pTotal, pErrors int64 // previous bucket
+	elevated        bool
+}
+
+func (s *samplingStats) roll(now time.Time, window time.Duration) {
+	elapsed := now.Sub(s.bucketStart)
+	if elapsed < window {
+		return
+	}
+
+	if elapsed < 2*window {
+		s.pTotal, s.pErrors = s.total, s.errors
+	} else {
+		s.pTotal, s.pErrors = 0, 0
+	}
+
+	s.total, s.errors = 0, 0
+	s.bucketStart = now
+}
+
+// errorRate estimates error rate in the sliding window, with the previous bucket weighted by
+// the overlapping part.
+func (s *samplingStats) errorRate(now time.Time, window time.Duration) (int64, float64) {
+	weight := 1 - float64(now.Sub(s.bucketStart))/float64(window)
+	total := float64(s.total) + float64(s.pTotal)*weight
+	if total <= 0 {
+		return 0, 0
+	}
+
+	return int64(total), (float64(s.errors) + float64(s.pErrors)*weight) / total
+}
+
+type logSampler struct {
+	LogSamplingConfig
+
+	mu    sync.Mutex
+	stats map[string]*samplingStats // method or node => stats
+
+	second int64 // unix second of the budget
+	budget int64 // sampled logs in the second
+}
+
+func newLogSampler(conf LogSamplingConfig) *logSampler {
+	return &logSampler{
+		LogSamplingConfig: conf,
+		stats:             make(map[string]*samplingStats),
+	}
+}
+
+// observe records the request outcome of key, and returns whether key is elevated.
+func (s *logSampler) observe(key string, failed bool, now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats, ok := s.stats[key]
+	if !ok {
+		if len(s.stats) >= maxLogSamplingKeys {
+			return false
+		}
+
+		stats = &samplingStats{bucketStart: now}
+		s.stats[key] = stats
+	}
+
+	stats.roll(now, s.Window)
+	stats.total++
+	if failed {
+		stats.errors++
+	}
+
+	total, rate := stats.errorRate(now, s.Window)
+	elevated := total >= s.MinRequests && rate >= s.ErrorRate
+
+	if elevated != stats.elevated {
+		stats.elevated = elevated
+
+		logger := logrus.WithFields(logrus.Fields{"key": key, "errorRate": rate, "requests": total})
+		if elevated {
+			logger.Warn("Log sampling raised due to elevated upstream errors")
+		} else {
+			logger.Info("Log sampling lowered since errors recovered")
+		}
+	}
+
+	return elevated
+}
+
+// sample decides whether to log the request, bounded by the max number of logs per second.
+func (s *logSampler) sample(elevated bool, now time.Time) bool {
+	rate := s.BaseRate
+	if elevated {
+		rate = s.ElevatedRate
+	}
+
+	if rand.Float64() >= rate {
+		return false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if sec := now.Unix(); sec != s.second {
+		s.second, s.budget = sec, 0
+	}
+
+	if s.budget >= s.MaxPerSecond {
+		return false
+	}
+
+	s.budget++
+
+	return true
+}
+
+// logSampling is the log sampler in use, which could be changed at runtime.
+var logSampling atomic.Value
+
+func init() {
+	sampler, err := loadLogSampler()
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to load log sampling config")
+	}
+
+	logSampling.Store(sampler)
+
+	reload.Register("rpc_log_sampling", func() error {
+		sampler, err := loadLogSampler()
+		if err != nil {
+			return err
+		}
+
+		logSampling.Store(sampler)
+		return nil
+	})
+}
+
+func loadLogSampler() (*logSampler, error) {
+	var conf LogSamplingConfig
+	if err := viper.UnmarshalKey("rpc.logSampling", &conf); err != nil {
+		return nil, err
+	}
+
+	if conf.Enabled && (conf.Window <= 0 || conf.MaxPerSecond <= 0) {
+		return nil, errors.New("log sampling window and max per second should be positive")
+	}
+
+	return newLogSampler(conf), nil
+}
+
+// classifyError classifies the error of RPC response.
+func classifyError(resp *rpc.JsonRpcMessage) string {
+	if resp == nil || resp.Error == nil {
+		return errClassNone
+	}
+
+	switch resp.Error.Code {
+	case -32700, -32600, -32601, -32602:
+		return errClassClient
+	default:
+		return errClassUpstream
+	}
+}
+
+// logSamplingMiddleware logs sampled requests along with the upstream node, of which sampling
+// rate is raised for methods or nodes exhibiting elevated upstream errors.
+func logSamplingMiddleware(next rpc.HandleCallMsgFunc) rpc.HandleCallMsgFunc {
+	return func(ctx context.Context, msg *rpc.JsonRpcMessage) *rpc.JsonRpcMessage {
+		sampler := logSampling.Load().(*logSampler)
+		if !sampler.Enabled {
+			return next(ctx, msg)
+		}
+
+		start := time.Now()
+		resp := next(ctx, msg)
+		now := time.Now()
+
+		errClass := classifyError(resp)
+		failed := errClass == errClassUpstream
+
+		elevated := sampler.observe("method:"+msg.Method, failed, now)
+
+		nodeName, hasNode := servingNodeName(ctx)
+		if hasNode && sampler.observe("node:"+nodeName, failed, now) {
+			elevated = true
+		}
+
+		if !sampler.sample(elevated, now) {
+			return resp
+		}
+
+		logger := logrus.WithFields(logrus.Fields{
+			"method":   msg.Method,
+			"input":    string(msg.Params),
+			"elapsed":  now.Sub(start),
+			"elevated": elevated,
+		})
+
+		if hasNode {
+			logger = logger.WithField("node", nodeName)
+		}
+
+		if errClass != errClassNone {
+			logger.WithField("errClass", errClass).WithError(resp.Error).Info("RPC sampled with error")
+		} else {
+			logger.Info("RPC sampled")
+		}
+
+		return resp
+	}
+}
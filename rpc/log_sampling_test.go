@@ -0,0 +1,41 @@
+package rpc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogSamplerElevated(t *testing.T) {
+	sampler := newLogSampler(LogSamplingConfig{
+		ErrorRate:    0.5,
+		MinRequests:  4,
+		Window:       time.Minute,
+		MaxPerSecond: 2,
+		ElevatedRate: 1,
+	})
+
+	now := time.Now()
+
+	// not enough requests
+	assert.False(t, sampler.observe("method:eth_call", true, now))
+	assert.False(t, sampler.observe("method:eth_call", true, now))
+	assert.False(t, sampler.observe("method:eth_call", false, now))
+	assert.True(t, sampler.observe("method:eth_call", true, now))
+
+	// healthy method not affected
+	assert.False(t, sampler.observe("method:eth_chainId", false, now))
+
+	// lowered once errors expired
+	later := now.Add(3 * time.Minute)
+	for i := 0; i < 4; i++ {
+		assert.False(t, sampler.observe("method:eth_call", false, later))
+	}
+
+	// bounded by max logs per second
+	assert.True(t, sampler.sample(true, now))
+	assert.True(t, sampler.sample(true, now))
+	assert.False(t, sampler.sample(true, now))
+	assert.False(t, sampler.sample(false, later))
+}
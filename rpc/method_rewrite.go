@@ -0,0 +1,122 @@
+package rpc
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/pkg/errors"
+	"github.com/scroll-tech/rpc-gateway/node"
+	"github.com/scroll-tech/rpc-gateway/util/reload"
+	"github.com/sirupsen/logrus"
+)
+
+// MethodRewriteRule rewrites the matched RPC method, which supports `*` suffix as wildcard to
+// rewrite namespace, e.g. `scroll_*` => `eth_*`.
+type MethodRewriteRule struct {
+	From string
+	To   string
+}
+
+// MethodRewriteConfig configurations of method rewrite table for evm space, so that clients
+// with legacy aliases or non-standard namespaces could be translated to standard methods.
+type MethodRewriteConfig struct {
+	Enabled bool
+	// rewrite rules, of which the first matched applies
+	Rules []MethodRewriteRule
+}
+
+// rewrite returns the rewritten method of the first matched rule if any.
+func (conf *MethodRewriteConfig) rewrite(method string) (string, bool) {
+	for _, rule := range conf.Rules {
+		if !strings.HasSuffix(rule.From, "*") {
+			if method == rule.From {
+				return rule.To, true
+			}
+
+			continue
+		}
+
+		prefix := strings.TrimSuffix(rule.From, "*")
+		if !strings.HasPrefix(method, prefix) {
+			continue
+		}
+
+		if strings.HasSuffix(rule.To, "*") {
+			return strings.TrimSuffix(rule.To, "*") + method[len(prefix):], true
+		}
+
+		return rule.To, true
+	}
+
+	return "", false
+}
+
+// methodRewrite is the method rewrite table in use, which could be changed at runtime.
+var methodRewrite atomic.Value
+
+func init() {
+	conf, err := loadMethodRewriteConfig()
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to load method rewrite config")
+	}
+
+	methodRewrite.Store(conf)
+
+	reload.Register("rpc_method_rewrite", func() error {
+		conf, err := loadMethodRewriteConfig()
+		if err != nil {
+			return err
+		}
+
+		methodRewrite.Store(conf)
+		return nil
+	})
+}
+
+func loadMethodRewriteConfig() (*MethodRewriteConfig, error) {
+	var conf MethodRewriteConfig
+	if err := viper.UnmarshalKey("ethrpc.methodRewrite", &conf); err != nil {
+		return nil, err
+	}
+
+	for _, rule := range conf.Rules {
+		if len(rule.From) == 0 || len(rule.To) == 0 {
+			return nil, errors.Errorf("invalid method rewrite rule %v => %v", rule.From, rule.To)
+		}
+
+		if strings.HasSuffix(rule.To, "*") && !strings.HasSuffix(rule.From, "*") {
+			return nil, errors.Errorf("wildcard method rewrite rule %v => %v mismatch", rule.From, rule.To)
+		}
+	}
+
+	return &conf, nil
+}
+
+// methodRewriteMiddleware rewrites RPC methods of evm space requests by the rewrite table, so
+// that the subsequent middlewares and handlers only deal with standard methods.
+func methodRewriteMiddleware(next rpc.HandleCallMsgFunc) rpc.HandleCallMsgFunc {
+	return func(ctx context.Context, msg *rpc.JsonRpcMessage) *rpc.JsonRpcMessage {
+		conf := methodRewrite.Load().(*MethodRewriteConfig)
+		if !conf.Enabled {
+			return next(ctx, msg)
+		}
+
+		if _, ok := ctx.Value(ctxKeyClientProvider).(*node.EthClientProvider); !ok {
+			return next(ctx, msg)
+		}
+
+		if method, ok := conf.rewrite(msg.Method); ok && method != msg.Method {
+			logrus.WithFields(logrus.Fields{
+				"from": msg.Method,
+				"to":   method,
+			}).Debug("RPC method rewritten")
+
+			msg.Method = method
+		}
+
+		return next(ctx, msg)
+	}
+}
@@ -0,0 +1,35 @@
+package rpc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMethodRewrite(t *testing.T) {
+	conf := MethodRewriteConfig{
+		Rules: []MethodRewriteRule{
+			{From: "cfx_epochNumber", To: "eth_blockNumber"},
+			{From: "scroll_*", To: "eth_*"},
+			{From: "legacy_*", To: "eth_chainId"},
+		},
+	}
+
+	method, ok := conf.rewrite("cfx_epochNumber")
+	assert.True(t, ok)
+	assert.Equal(t, "eth_blockNumber", method)
+
+	method, ok = conf.rewrite("scroll_getBalance")
+	assert.True(t, ok)
+	assert.Equal(t, "eth_getBalance", method)
+
+	method, ok = conf.rewrite("legacy_chainId")
+	assert.True(t, ok)
+	assert.Equal(t, "eth_chainId", method)
+
+	_, ok = conf.rewrite("cfx_getBalance")
+	assert.False(t, ok)
+
+	_, ok = conf.rewrite("eth_blockNumber")
+	assert.False(t, ok)
+}
@@ -7,10 +7,12 @@ import (
 )
 
 // netAPI provides evm space net RPC proxy API.
-type netAPI struct{}
+type netAPI struct {
+	cache *cache.EthCache
+}
 
 // Version returns the current network id.
 func (api *netAPI) Version(ctx context.Context) (string, error) {
 	w3c := GetEthClientFromContext(ctx)
-	return cache.EthDefault.GetNetVersion(w3c.Client)
+	return api.cache.GetNetVersion(w3c.Client)
 }
@@ -0,0 +1,259 @@
+package rpc
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/pkg/errors"
+	"github.com/scroll-tech/rpc-gateway/node"
+	"github.com/scroll-tech/rpc-gateway/util/metrics"
+	"github.com/scroll-tech/rpc-gateway/util/reload"
+	"github.com/scroll-tech/rpc-gateway/util/rpc/handlers"
+	"github.com/sirupsen/logrus"
+)
+
+// errCodeBusy is the JSON-RPC error code for requests rejected due to concurrency limits, which
+// is retriable.
+const errCodeBusy = -32005
+
+const ctxKeyGroup = handlers.CtxKey("Infura-RPC-Group")
+
+// NodeConcurrencyConfig configurations of per node in-flight request caps, so that a slow node
+// could not absorb unbounded goroutines.
+type NodeConcurrencyConfig struct {
+	Enabled bool
+	// max number of in-flight requests per node
+	MaxInflight int `default:"256"`
+	// max in-flight requests of specific nodes by node name, which overrides the default one
+	Nodes map[string]int
+	// max number of requests waiting for in-flight slot per node
+	MaxQueue int32 `default:"512"`
+	// max duration to wait for in-flight slot
+	QueueTimeout time.Duration `default:"500ms"`
+	// whether to reroute overflow requests to another node of the same group
+	Reroute bool `default:"true"`
+}
+
+// busyError is the retriable error returned for requests overflowed.
+type busyError struct {
+	message    string
+	retryAfter time.Duration
+}
+
+func (e *busyError) Error() string {
+	return e.message
+}
+
+func (e *busyError) ErrorCode() int { return errCodeBusy }
+
+func (e *busyError) ErrorData() interface{} {
+	return map[string]interface{}{
+		"retriable":  true,
+		"retryAfter": e.retryAfter.String(),
+	}
+}
+
+// inflightLimiter limits in-flight requests with a bounded wait queue.
+type inflightLimiter struct {
+	slots  chan struct{}
+	queued int32
+}
+
+func newInflightLimiter(maxInflight int) *inflightLimiter {
+	return &inflightLimiter{slots: make(chan struct{}, maxInflight)}
+}
+
+// tryAcquire acquires in-flight slot without waiting.
+func (l *inflightLimiter) tryAcquire() bool {
+	select {
+	case l.slots <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+// acquire acquires in-flight slot, and waits in queue if full until timeout.
+func (l *inflightLimiter) acquire(ctx context.Context, maxQueue int32, timeout time.Duration) bool {
+	if l.tryAcquire() {
+		return true
+	}
+
+	if atomic.AddInt32(&l.queued, 1) > maxQueue {
+		atomic.AddInt32(&l.queued, -1)
+		return false
+	}
+	defer atomic.AddInt32(&l.queued, -1)
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case l.slots <- struct{}{}:
+		return true
+	case <-timer.C:
+		return false
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (l *inflightLimiter) release() {
+	<-l.slots
+}
+
+type nodeConcurrencyPolicy struct {
+	NodeConcurrencyConfig
+	limiters sync.Map // node name => *inflightLimiter
+}
+
+func (p *nodeConcurrencyPolicy) limiter(nodeName string) *inflightLimiter {
+	if l, ok := p.limiters.Load(nodeName); ok {
+		return l.(*inflightLimiter)
+	}
+
+	maxInflight := p.MaxInflight
+	if v, ok := p.Nodes[nodeName]; ok {
+		maxInflight = v
+	}
+
+	l, _ := p.limiters.LoadOrStore(nodeName, newInflightLimiter(maxInflight))
+	return l.(*inflightLimiter)
+}
+
+// nodeConcurrency is the per node concurrency policy in use, which could be changed at runtime.
+// Note, in-flight requests are not counted by the new policy.
+var nodeConcurrency atomic.Value
+
+func init() {
+	policy, err := loadNodeConcurrencyPolicy()
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to load node concurrency config")
+	}
+
+	nodeConcurrency.Store(policy)
+
+	reload.Register("rpc_node_concurrency", func() error {
+		policy, err := loadNodeConcurrencyPolicy()
+		if err != nil {
+			return err
+		}
+
+		nodeConcurrency.Store(policy)
+		return nil
+	})
+}
+
+func loadNodeConcurrencyPolicy() (*nodeConcurrencyPolicy, error) {
+	var conf NodeConcurrencyConfig
+	if err := viper.UnmarshalKey("rpc.nodeConcurrency", &conf); err != nil {
+		return nil, err
+	}
+
+	if conf.MaxInflight <= 0 {
+		return nil, errors.Errorf("invalid node max in-flight requests %v", conf.MaxInflight)
+	}
+
+	for name, v := range conf.Nodes {
+		if v <= 0 {
+			return nil, errors.Errorf("invalid max in-flight requests %v of node %v", v, name)
+		}
+	}
+
+	return &nodeConcurrencyPolicy{NodeConcurrencyConfig: conf}, nil
+}
+
+// nodeConcurrencyMiddleware caps in-flight requests per upstream node. Overflow requests are
+// rerouted to another node of the same group if any available, otherwise rejected with a
+// retriable error.
+func nodeConcurrencyMiddleware(next rpc.HandleCallMsgFunc) rpc.HandleCallMsgFunc {
+	return func(ctx context.Context, msg *rpc.JsonRpcMessage) *rpc.JsonRpcMessage {
+		policy := nodeConcurrency.Load().(*nodeConcurrencyPolicy)
+		if !policy.Enabled {
+			return next(ctx, msg)
+		}
+
+		nodeName, ok := servingNodeName(ctx)
+		if !ok {
+			return next(ctx, msg)
+		}
+
+		limiter := policy.limiter(nodeName)
+		if limiter.acquire(ctx, policy.MaxQueue, policy.QueueTimeout) {
+			defer limiter.release()
+			return next(ctx, msg)
+		}
+
+		// reroute to another node without waiting
+		if rerouted, ok := rerouteOverflow(ctx, policy, nodeName); ok {
+			metrics.Registry.RPC.NodeOverflow(nodeName, "rerouted").Mark(1)
+			defer rerouted.release()
+			return next(rerouted.ctx, msg)
+		}
+
+		metrics.Registry.RPC.NodeOverflow(nodeName, "rejected").Mark(1)
+		logrus.WithFields(logrus.Fields{
+			"method": msg.Method,
+			"node":   nodeName,
+		}).Debug("Request rejected due to upstream node busy")
+
+		return msg.ErrorResponse(&busyError{
+			message:    "upstream node busy, please try again later",
+			retryAfter: policy.QueueTimeout,
+		})
+	}
+}
+
+type reroutedCall struct {
+	ctx     context.Context
+	limiter *inflightLimiter
+}
+
+func (c *reroutedCall) release() {
+	c.limiter.release()
+}
+
+// rerouteOverflow selects another evm space node of the same group with in-flight slot available.
+func rerouteOverflow(ctx context.Context, policy *nodeConcurrencyPolicy, nodeName string) (*reroutedCall, bool) {
+	if !policy.Reroute {
+		return nil, false
+	}
+
+	provider, ok := ctx.Value(ctxKeyClientProvider).(*node.EthClientProvider)
+	if !ok {
+		return nil, false
+	}
+
+	group, ok := ctx.Value(ctxKeyGroup).(node.Group)
+	if !ok {
+		return nil, false
+	}
+
+	primary, ok := ctx.Value(ctxKeyClient).(*node.Web3goClient)
+	if !ok {
+		return nil, false
+	}
+
+	client, err := provider.GetClientRandomByGroupExcept(group, primary.URL)
+	if err != nil {
+		return nil, false
+	}
+
+	ctx = context.WithValue(ctx, ctxKeyClient, client)
+
+	altName, ok := servingNodeName(ctx)
+	if !ok || altName == nodeName {
+		return nil, false
+	}
+
+	limiter := policy.limiter(altName)
+	if !limiter.tryAcquire() {
+		return nil, false
+	}
+
+	return &reroutedCall{ctx, limiter}, true
+}
@@ -0,0 +1,270 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/pkg/errors"
+	"github.com/scroll-tech/rpc-gateway/util/metrics"
+	"github.com/scroll-tech/rpc-gateway/util/rpc/handlers"
+	"github.com/sirupsen/logrus"
+)
+
+// peerViaHeader is the HTTP header of regions that the request has been forwarded through,
+// which is used to prevent forwarding loop between peer gateways.
+const peerViaHeader = "X-Gateway-Via"
+
+const ctxKeyPeerVia = handlers.CtxKey("Infura-RPC-Peer-Via")
+
+// PeerConfig peer gateway in another region.
+type PeerConfig struct {
+	Region string
+	// evm space RPC endpoint of peer gateway
+	URL string
+}
+
+// PeeringConfig configurations to forward evm space requests to peer gateways in other regions
+// when local node group unavailable, e.g. all nodes unhealthy. Note, it only applies to the
+// default evm chain.
+type PeeringConfig struct {
+	Enabled bool
+	// region of this gateway, which should be unique among peers
+	Region string
+	Peers  []PeerConfig
+	// max number of gateways that a request could be forwarded through
+	MaxHops int `default:"1"`
+	// interval to probe latency of peers
+	ProbeInterval time.Duration `default:"10s"`
+	// timeout to probe or forward to peer
+	Timeout time.Duration `default:"5s"`
+}
+
+// peerGateway is a peer gateway with probed latency.
+type peerGateway struct {
+	PeerConfig
+	latency time.Duration // smoothed latency, 0 means not probed yet
+	healthy bool
+}
+
+// peerRouter selects peer gateway by latency to forward requests.
+type peerRouter struct {
+	conf   PeeringConfig
+	client *http.Client
+	mu     sync.RWMutex
+	peers  []*peerGateway
+}
+
+// peering forwards requests to peer gateways, nil if disabled.
+var peering *peerRouter
+
+// StartPeering starts to probe peer gateways if enabled. Note, it should be started before RPC
+// server served.
+func StartPeering(ctx context.Context) {
+	var conf PeeringConfig
+	viper.MustUnmarshalKey("rpc.peering", &conf)
+
+	if !conf.Enabled {
+		return
+	}
+
+	if len(conf.Region) == 0 || conf.MaxHops < 1 {
+		logrus.Fatal("Peering requires region and positive max hops")
+	}
+
+	router := &peerRouter{conf: conf, client: &http.Client{Timeout: conf.Timeout}}
+	for _, pc := range conf.Peers {
+		if pc.Region == conf.Region {
+			logrus.WithField("region", pc.Region).Fatal("Peer gateway in the same region")
+		}
+
+		// healthy until probed
+		router.peers = append(router.peers, &peerGateway{PeerConfig: pc, healthy: true})
+	}
+
+	peering = router
+	go router.probe(ctx)
+
+	logrus.WithFields(logrus.Fields{
+		"region": conf.Region, "peers": len(conf.Peers),
+	}).Info("Gateway peering started")
+}
+
+// probe periodically probes latency of peers.
+func (r *peerRouter) probe(ctx context.Context) {
+	ticker := time.NewTicker(r.conf.ProbeInterval)
+	defer ticker.Stop()
+
+	probeMsg := &rpc.JsonRpcMessage{
+		Version: "2.0", ID: json.RawMessage("1"), Method: "eth_blockNumber", Params: json.RawMessage("[]"),
+	}
+
+	for {
+		for _, p := range r.peers {
+			start := time.Now()
+			resp, err := r.forwardTo(ctx, p, probeMsg, nil)
+			elapsed := time.Since(start)
+
+			healthy := err == nil && resp.Error == nil
+			metrics.Registry.RPC.PeerLatency(p.Region).Update(elapsed.Milliseconds())
+
+			r.mu.Lock()
+			if healthy && p.latency > 0 {
+				p.latency = (p.latency*7 + elapsed) / 8
+			} else if healthy {
+				p.latency = elapsed
+			}
+
+			if p.healthy != healthy {
+				logrus.WithError(err).WithFields(logrus.Fields{
+					"region": p.Region, "healthy": healthy,
+				}).Warn("Peer gateway health changed")
+			}
+
+			p.healthy = healthy
+			r.mu.Unlock()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// candidates returns healthy peers not visited by request in ascending order of latency.
+func (r *peerRouter) candidates(via []string) []*peerGateway {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	visited := make(map[string]bool, len(via))
+	for _, region := range via {
+		visited[region] = true
+	}
+
+	var result []*peerGateway
+	for _, p := range r.peers {
+		if p.healthy && !visited[p.Region] {
+			result = append(result, p)
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].latency < result[j].latency
+	})
+
+	return result
+}
+
+// forward forwards request to peers in order of latency until any peer responds. Returns
+// false if request should not be forwarded, e.g. max hops exceeded.
+func (r *peerRouter) forward(ctx context.Context, msg *rpc.JsonRpcMessage) (*rpc.JsonRpcMessage, bool) {
+	via := parsePeerVia(ctx)
+	if len(via) >= r.conf.MaxHops {
+		return nil, false
+	}
+
+	var lastErr error
+	for _, p := range r.candidates(via) {
+		resp, err := r.forwardTo(ctx, p, msg, via)
+		metrics.Registry.RPC.PeerForwarded(p.Region).Mark(err == nil)
+
+		if err == nil {
+			logrus.WithFields(logrus.Fields{
+				"method": msg.Method, "peer": p.Region,
+			}).Debug("RPC forwarded to peer gateway")
+
+			return resp, true
+		}
+
+		lastErr = err
+		logrus.WithError(err).WithField("peer", p.Region).Debug("Failed to forward RPC to peer gateway")
+	}
+
+	if lastErr != nil {
+		return msg.ErrorResponse(errors.WithMessage(lastErr, "failed to forward to peer gateways")), true
+	}
+
+	return nil, false
+}
+
+// forwardTo forwards request to peer along with the regions forwarded through.
+func (r *peerRouter) forwardTo(
+	ctx context.Context, p *peerGateway, msg *rpc.JsonRpcMessage, via []string,
+) (*rpc.JsonRpcMessage, error) {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(peerViaHeader, strings.Join(append(via, r.conf.Region), ","))
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("unexpected status code %v", resp.StatusCode)
+	}
+
+	var result rpc.JsonRpcMessage
+	if err = json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	result.ID = msg.ID
+
+	return &result, nil
+}
+
+// withPeerVia injects the regions that request forwarded through into context.
+func withPeerVia(ctx context.Context, r *http.Request) context.Context {
+	if via := r.Header.Get(peerViaHeader); len(via) > 0 {
+		return context.WithValue(ctx, ctxKeyPeerVia, via)
+	}
+
+	return ctx
+}
+
+// parsePeerVia returns the regions that request forwarded through.
+func parsePeerVia(ctx context.Context) []string {
+	via, _ := ctx.Value(ctxKeyPeerVia).(string)
+	if len(via) == 0 {
+		return nil
+	}
+
+	var regions []string
+	for _, region := range strings.Split(via, ",") {
+		if region = strings.TrimSpace(region); len(region) > 0 {
+			regions = append(regions, region)
+		}
+	}
+
+	return regions
+}
+
+// forwardToPeer forwards evm space request of the default chain to peer gateway if local node
+// group unavailable.
+func forwardToPeer(ctx context.Context, msg *rpc.JsonRpcMessage, chain string) (*rpc.JsonRpcMessage, bool) {
+	if peering == nil || len(chain) > 0 {
+		return nil, false
+	}
+
+	return peering.forward(ctx, msg)
+}
@@ -0,0 +1,49 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPeerRouterForward(t *testing.T) {
+	var via string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		via = r.Header.Get(peerViaHeader)
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":"0x10"}`))
+	}))
+	defer server.Close()
+
+	router := &peerRouter{
+		conf:   PeeringConfig{Region: "us-east", MaxHops: 1},
+		client: server.Client(),
+		peers: []*peerGateway{
+			{PeerConfig: PeerConfig{Region: "ap-south", URL: "http://127.0.0.1:1"}, healthy: false},
+			{PeerConfig: PeerConfig{Region: "eu-west", URL: server.URL}, healthy: true},
+		},
+	}
+
+	msg := &rpc.JsonRpcMessage{Version: "2.0", ID: json.RawMessage("7"), Method: "eth_blockNumber"}
+
+	resp, ok := router.forward(context.Background(), msg)
+	assert.True(t, ok)
+	assert.Equal(t, json.RawMessage("7"), resp.ID)
+	assert.Equal(t, json.RawMessage(`"0x10"`), resp.Result)
+	assert.Equal(t, "us-east", via)
+
+	// max hops exceeded
+	ctx := context.WithValue(context.Background(), ctxKeyPeerVia, "eu-west")
+	_, ok = router.forward(ctx, msg)
+	assert.False(t, ok)
+
+	// never forward back to visited region
+	router.conf.MaxHops = 2
+	_, ok = router.forward(ctx, msg)
+	assert.False(t, ok)
+}
@@ -0,0 +1,201 @@
+package rpc
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/pkg/errors"
+	"github.com/scroll-tech/rpc-gateway/util/metrics"
+	"github.com/scroll-tech/rpc-gateway/util/reload"
+	"github.com/sirupsen/logrus"
+)
+
+// policies to handle notifications when websocket send queue is full
+const (
+	// drops the oldest queued notification
+	WsSendQueuePolicyDropOldest = "dropOldest"
+	// drops the new notification
+	WsSendQueuePolicyDropNewest = "dropNewest"
+	// closes the slow connection
+	WsSendQueuePolicyClose = "close"
+)
+
+// WsSendQueueConfig configurations of per connection bounded send queues for websocket
+// notifications, so that a slow consumer could not exhaust gateway memory.
+type WsSendQueueConfig struct {
+	Enabled bool
+	// max number of notifications queued per connection
+	Size int `default:"1000"`
+	// policy to handle notifications when queue is full, e.g. dropOldest, dropNewest or close
+	Policy string `default:"close"`
+}
+
+// wsNotification is a subscription notification queued to send.
+type wsNotification struct {
+	notifier *rpc.Notifier
+	id       rpc.ID
+	data     interface{}
+}
+
+// wsSendQueue is the bounded send queue of a websocket connection, which is drained by a
+// dedicated goroutine, so that subscriptions are never blocked by the slow connection.
+type wsSendQueue struct {
+	conf   *WsSendQueueConfig
+	space  string
+	client *rpc.Client
+
+	mu     sync.Mutex
+	items  []wsNotification
+	closed bool
+
+	signal chan struct{}
+}
+
+func newWsSendQueue(conf *WsSendQueueConfig, space string, client *rpc.Client) *wsSendQueue {
+	return &wsSendQueue{
+		conf:   conf,
+		space:  space,
+		client: client,
+		signal: make(chan struct{}, 1),
+	}
+}
+
+// push enqueues the notification, and handles overflow by the configured policy.
+func (q *wsSendQueue) push(n wsNotification) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return
+	}
+
+	if len(q.items) < q.conf.Size {
+		q.items = append(q.items, n)
+		metrics.Registry.PubSub.SendQueueLength(q.space).Update(int64(len(q.items)))
+
+		select {
+		case q.signal <- struct{}{}:
+		default:
+		}
+
+		return
+	}
+
+	switch q.conf.Policy {
+	case WsSendQueuePolicyDropOldest:
+		q.items = append(q.items[1:], n)
+		metrics.Registry.PubSub.SendQueueDropped(q.space).Mark(1)
+	case WsSendQueuePolicyDropNewest:
+		metrics.Registry.PubSub.SendQueueDropped(q.space).Mark(1)
+	default:
+		q.closed, q.items = true, nil
+		metrics.Registry.PubSub.SendQueueClosed(q.space).Mark(1)
+
+		logrus.WithField("size", q.conf.Size).Info("Websocket connection closed due to send queue overflow")
+
+		go q.client.Close()
+	}
+}
+
+// pop dequeues the oldest notification if any.
+func (q *wsSendQueue) pop() (wsNotification, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.items) == 0 {
+		return wsNotification{}, false
+	}
+
+	n := q.items[0]
+	q.items[0] = wsNotification{} // release reference
+	q.items = q.items[1:]
+
+	return n, true
+}
+
+// drain sends queued notifications until connection closed.
+func (q *wsSendQueue) drain(closed <-chan interface{}) {
+	for {
+		select {
+		case <-q.signal:
+		case <-closed:
+			return
+		}
+
+		for n, ok := q.pop(); ok; n, ok = q.pop() {
+			n.notifier.Notify(n.id, n.data)
+		}
+	}
+}
+
+var (
+	// wsSendQueueConf is the send queue config in use, which could be changed at runtime and
+	// applies to new connections.
+	wsSendQueueConf atomic.Value
+
+	// wsSendQueues is the send queues of websocket connections
+	wsSendQueues sync.Map // *rpc.Client => *wsSendQueue
+)
+
+func init() {
+	conf, err := loadWsSendQueueConfig()
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to load websocket send queue config")
+	}
+
+	wsSendQueueConf.Store(conf)
+
+	reload.Register("rpc_ws_send_queue", func() error {
+		conf, err := loadWsSendQueueConfig()
+		if err != nil {
+			return err
+		}
+
+		wsSendQueueConf.Store(conf)
+		return nil
+	})
+}
+
+func loadWsSendQueueConfig() (*WsSendQueueConfig, error) {
+	var conf WsSendQueueConfig
+	if err := viper.UnmarshalKey("rpc.wsSendQueue", &conf); err != nil {
+		return nil, err
+	}
+
+	if conf.Enabled && conf.Size <= 0 {
+		return nil, errors.New("websocket send queue size should be positive")
+	}
+
+	switch conf.Policy {
+	case WsSendQueuePolicyDropOldest, WsSendQueuePolicyDropNewest, WsSendQueuePolicyClose:
+	default:
+		return nil, errors.Errorf("invalid websocket send queue policy %v", conf.Policy)
+	}
+
+	return &conf, nil
+}
+
+// notifyWs notifies the subscription via the send queue of connection if enabled, otherwise
+// notifies directly in blocking way.
+func notifyWs(space string, notifier *rpc.Notifier, client *rpc.Client, id rpc.ID, data interface{}) {
+	conf := wsSendQueueConf.Load().(*WsSendQueueConfig)
+	if !conf.Enabled {
+		notifier.Notify(id, data)
+		return
+	}
+
+	queue, ok := wsSendQueues.Load(client)
+	if !ok {
+		var loaded bool
+		if queue, loaded = wsSendQueues.LoadOrStore(client, newWsSendQueue(conf, space, client)); !loaded {
+			go func() {
+				queue.(*wsSendQueue).drain(notifier.Closed())
+				wsSendQueues.Delete(client)
+			}()
+		}
+	}
+
+	queue.(*wsSendQueue).push(wsNotification{notifier, id, data})
+}
@@ -0,0 +1,28 @@
+package rpc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWsSendQueueDropPolicies(t *testing.T) {
+	for _, policy := range []string{WsSendQueuePolicyDropOldest, WsSendQueuePolicyDropNewest} {
+		q := newWsSendQueue(&WsSendQueueConfig{Enabled: true, Size: 2, Policy: policy}, "eth", nil)
+
+		for i := 1; i <= 3; i++ {
+			q.push(wsNotification{data: i})
+		}
+
+		var items []interface{}
+		for n, ok := q.pop(); ok; n, ok = q.pop() {
+			items = append(items, n.data)
+		}
+
+		if policy == WsSendQueuePolicyDropOldest {
+			assert.Equal(t, []interface{}{2, 3}, items)
+		} else {
+			assert.Equal(t, []interface{}{1, 2}, items)
+		}
+	}
+}
@@ -0,0 +1,300 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/pkg/errors"
+	"github.com/scroll-tech/rpc-gateway/node"
+	"github.com/scroll-tech/rpc-gateway/rpc/cache"
+	"github.com/scroll-tech/rpc-gateway/util/metrics"
+	"github.com/scroll-tech/rpc-gateway/util/reload"
+	rpcutil "github.com/scroll-tech/rpc-gateway/util/rpc"
+	"github.com/sirupsen/logrus"
+)
+
+var errNoQuorum = errors.New("no consensus among full nodes")
+
+// QuorumConfig configurations of consensus reads, which sends critical reads to multiple
+// nodes and returns the majority result to detect corrupted or malicious upstreams.
+type QuorumConfig struct {
+	Enabled bool
+	// number of nodes to read from
+	Nodes int `default:"3"`
+	// critical read methods
+	Methods []string
+	// whether to apply for reads at the latest block only
+	LatestOnly bool
+}
+
+type quorumPolicy struct {
+	QuorumConfig
+	methods map[string]bool
+}
+
+// quorum is the quorum read policy in use, which could be changed at runtime.
+var quorum atomic.Value
+
+func init() {
+	policy, err := loadQuorumPolicy()
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to load quorum read config")
+	}
+
+	quorum.Store(policy)
+
+	reload.Register("rpc_quorum", func() error {
+		policy, err := loadQuorumPolicy()
+		if err != nil {
+			return err
+		}
+
+		quorum.Store(policy)
+		return nil
+	})
+}
+
+func loadQuorumPolicy() (*quorumPolicy, error) {
+	var conf QuorumConfig
+	if err := viper.UnmarshalKey("ethrpc.quorum", &conf); err != nil {
+		return nil, err
+	}
+
+	if conf.Enabled && conf.Nodes < 2 {
+		return nil, errors.Errorf("at least 2 nodes required for quorum read, but got %v", conf.Nodes)
+	}
+
+	policy := quorumPolicy{QuorumConfig: conf, methods: make(map[string]bool)}
+	for _, m := range conf.Methods {
+		if hedgingUnsafeMethods[m] {
+			logrus.WithField("method", m).Warn("Method with side effects is not allowed for quorum read")
+			continue
+		}
+
+		policy.methods[m] = true
+	}
+
+	return &policy, nil
+}
+
+// shouldQuorum checks if quorum read is enabled for the specified RPC request.
+func shouldQuorum(msg *rpc.JsonRpcMessage) (*quorumPolicy, bool) {
+	policy := quorum.Load().(*quorumPolicy)
+	if !policy.Enabled || !policy.methods[msg.Method] {
+		return policy, false
+	}
+
+	if !policy.LatestOnly {
+		return policy, true
+	}
+
+	height, ok := parseEthRouteHeight(msg.Method, msg.Params)
+	return policy, ok && height == nil
+}
+
+// quorumCall handles RPC call with the primary client and other nodes of the same group,
+// and returns the majority result. Any divergence between nodes is logged and metered.
+func quorumCall(
+	ctx context.Context,
+	msg *rpc.JsonRpcMessage,
+	next rpc.HandleCallMsgFunc,
+	policy *quorumPolicy,
+	provider *node.EthClientProvider,
+	group node.Group,
+	primary *node.Web3goClient,
+) *rpc.JsonRpcMessage {
+	clients := []*node.Web3goClient{primary}
+	urls := []string{primary.URL}
+
+	for len(clients) < policy.Nodes {
+		client, err := provider.GetClientRandomByGroupExcept(group, urls...)
+		if err != nil { // not enough nodes
+			break
+		}
+
+		clients = append(clients, client)
+		urls = append(urls, client.URL)
+	}
+
+	if len(clients) < 2 { // no other node available
+		metrics.Registry.RPC.Percentage(msg.Method, "quorum/available").Mark(false)
+		return next(context.WithValue(ctx, ctxKeyClient, primary), msg)
+	}
+
+	metrics.Registry.RPC.Percentage(msg.Method, "quorum/available").Mark(true)
+
+	// pin the latest block to the lowest head among nodes, so that nodes slightly out of sync
+	// are not regarded as diverged
+	params := msg.Params
+	if height, ok := parseEthRouteHeight(msg.Method, msg.Params); ok && height == nil {
+		if head, ok := quorumHead(provider.Chain(), clients); ok {
+			if pinned, ok := pinLatestParam(msg.Method, msg.Params, head); ok {
+				params = pinned
+			}
+		}
+	}
+
+	resps := make([]*rpc.JsonRpcMessage, len(clients))
+
+	var wg sync.WaitGroup
+	for i := range clients {
+		wg.Add(1)
+
+		// each node requested with its own message, which may be mutated by middlewares
+		clone := *msg
+		clone.Params = params
+
+		go func(i int, msg *rpc.JsonRpcMessage) {
+			defer wg.Done()
+			resps[i] = next(context.WithValue(ctx, ctxKeyClient, clients[i]), msg)
+		}(i, &clone)
+	}
+
+	wg.Wait()
+
+	return quorumResult(msg, clients, resps)
+}
+
+// quorumResult returns the majority response of nodes, or error if no majority, e.g. tie.
+func quorumResult(
+	msg *rpc.JsonRpcMessage, clients []*node.Web3goClient, resps []*rpc.JsonRpcMessage,
+) *rpc.JsonRpcMessage {
+	// group responses by content
+	votes := make(map[string][]int)
+	for i, resp := range resps {
+		key := quorumVoteKey(resp)
+		votes[key] = append(votes[key], i)
+	}
+
+	var majority []int
+	for _, indexes := range votes {
+		if len(indexes) > len(majority) {
+			majority = indexes
+		}
+	}
+
+	diverged := len(votes) > 1
+	metrics.Registry.RPC.Percentage(msg.Method, "quorum/diverged").Mark(diverged)
+
+	if diverged {
+		agreed := make(map[int]bool, len(majority))
+		for _, i := range majority {
+			agreed[i] = true
+		}
+
+		for i, client := range clients {
+			nodeName := rpcutil.Url2NodeName(client.URL)
+			metrics.Registry.RPC.Percentage(msg.Method, "quorum/diverged/"+nodeName).Mark(!agreed[i])
+
+			if !agreed[i] {
+				logrus.WithFields(logrus.Fields{
+					"method":   msg.Method,
+					"params":   string(msg.Params),
+					"node":     nodeName,
+					"result":   string(resps[i].Result),
+					"expected": string(resps[majority[0]].Result),
+				}).Warn("Quorum read diverged from the majority")
+			}
+		}
+	}
+
+	if len(majority)*2 <= len(clients) {
+		return msg.ErrorResponse(errNoQuorum)
+	}
+
+	return resps[majority[0]]
+}
+
+// quorumHead returns the lowest head among nodes.
+func quorumHead(chain string, clients []*node.Web3goClient) (uint64, bool) {
+	heads := make([]*hexutil.Big, len(clients))
+	errs := make([]error, len(clients))
+
+	var wg sync.WaitGroup
+	for i := range clients {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+			heads[i], errs[i] = cache.Eth(chain).GetBlockNumber(clients[i])
+		}(i)
+	}
+
+	wg.Wait()
+
+	var lowest uint64
+	for i := range clients {
+		if errs[i] != nil {
+			logrus.WithField("node", clients[i].URL).
+				WithError(errs[i]).
+				Debug("Failed to get head of node for quorum read")
+			return 0, false
+		}
+
+		if head := heads[i].ToInt().Uint64(); i == 0 || head < lowest {
+			lowest = head
+		}
+	}
+
+	return lowest, true
+}
+
+// pinLatestParam rewrites the latest block parameter, which is either omitted, block tag or
+// EIP-1898 block parameter object, into the specified block number.
+func pinLatestParam(method string, rawParams json.RawMessage, bn uint64) (json.RawMessage, bool) {
+	index, ok := ethBlockParamIndexes[method]
+	if !ok {
+		return nil, false
+	}
+
+	var params []json.RawMessage
+	if err := json.Unmarshal(rawParams, &params); err != nil || index > len(params) {
+		return nil, false
+	}
+
+	pinned, _ := json.Marshal(hexutil.EncodeUint64(bn))
+
+	switch {
+	case index == len(params): // block parameter omitted
+		params = append(params, pinned)
+	case isLatestTag(params[index]):
+		params[index] = pinned
+	default:
+		var obj map[string]json.RawMessage
+		if err := json.Unmarshal(params[index], &obj); err != nil || !isLatestTag(obj["blockNumber"]) {
+			return nil, false
+		}
+
+		obj["blockNumber"] = pinned
+		params[index], _ = json.Marshal(obj)
+	}
+
+	result, err := json.Marshal(params)
+	return result, err == nil
+}
+
+// isLatestTag checks if the block parameter is the `latest` block tag.
+func isLatestTag(param json.RawMessage) bool {
+	var tag string
+	if err := json.Unmarshal(param, &tag); err != nil {
+		return false
+	}
+
+	return len(tag) == 0 || strings.EqualFold(tag, "latest")
+}
+
+// quorumVoteKey returns the key of response content to vote.
+func quorumVoteKey(resp *rpc.JsonRpcMessage) string {
+	if resp.Error != nil {
+		data, _ := json.Marshal(resp.Error)
+		return "error:" + string(data)
+	}
+
+	return string(resp.Result)
+}
@@ -0,0 +1,84 @@
+package rpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/scroll-tech/rpc-gateway/node"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuorumResult(t *testing.T) {
+	msg := &rpc.JsonRpcMessage{Version: "2.0", ID: json.RawMessage("1"), Method: "eth_getBalance"}
+
+	result := func(value string) *rpc.JsonRpcMessage {
+		return &rpc.JsonRpcMessage{Version: "2.0", ID: msg.ID, Result: json.RawMessage(value)}
+	}
+
+	failure := &rpc.JsonRpcMessage{
+		Version: "2.0", ID: msg.ID, Error: &rpc.JsonError{Code: -32000, Message: "header not found"},
+	}
+
+	tests := []struct {
+		resps  []*rpc.JsonRpcMessage
+		result string // empty means no quorum
+	}{
+		// unanimous
+		{[]*rpc.JsonRpcMessage{result(`"0x1"`), result(`"0x1"`), result(`"0x1"`)}, `"0x1"`},
+		// majority along with diverged node
+		{[]*rpc.JsonRpcMessage{result(`"0x1"`), result(`"0x2"`), result(`"0x1"`)}, `"0x1"`},
+		{[]*rpc.JsonRpcMessage{failure, result(`"0x2"`), result(`"0x2"`)}, `"0x2"`},
+		// tie
+		{[]*rpc.JsonRpcMessage{result(`"0x1"`), result(`"0x2"`)}, ""},
+		{[]*rpc.JsonRpcMessage{result(`"0x1"`), result(`"0x1"`), result(`"0x2"`), result(`"0x2"`)}, ""},
+		// all diverged
+		{[]*rpc.JsonRpcMessage{result(`"0x1"`), result(`"0x2"`), result(`"0x3"`)}, ""},
+	}
+
+	for _, tt := range tests {
+		clients := make([]*node.Web3goClient, len(tt.resps))
+		for i := range clients {
+			clients[i] = &node.Web3goClient{URL: fmt.Sprintf("http://127.0.0.%v:8545", i+1)}
+		}
+
+		resp := quorumResult(msg, clients, tt.resps)
+		if len(tt.result) == 0 {
+			assert.Equal(t, errNoQuorum.Error(), resp.Error.Message)
+		} else {
+			assert.Nil(t, resp.Error)
+			assert.Equal(t, json.RawMessage(tt.result), resp.Result)
+		}
+	}
+}
+
+func TestPinLatestParam(t *testing.T) {
+	tests := []struct {
+		method string
+		params string
+		pinned string // empty means not pinned
+	}{
+		{"eth_getBalance", `["0x0000000000000000000000000000000000000001"]`, `["0x0000000000000000000000000000000000000001","0x64"]`},
+		{"eth_getBalance", `["0x0000000000000000000000000000000000000001","latest"]`, `["0x0000000000000000000000000000000000000001","0x64"]`},
+		{"eth_call", `[{},{"blockNumber":"latest"}]`, `[{},{"blockNumber":"0x64"}]`},
+		{"eth_getStorageAt", `["0x0000000000000000000000000000000000000001","0x0",""]`, `["0x0000000000000000000000000000000000000001","0x0","0x64"]`},
+		{"eth_getBlockByNumber", `["latest",false]`, `["0x64",false]`},
+		// not the latest block
+		{"eth_getBalance", `["0x0000000000000000000000000000000000000001","pending"]`, ""},
+		{"eth_getBalance", `["0x0000000000000000000000000000000000000001","0x10"]`, ""},
+		{"eth_call", `[{},{"blockHash":"0x0000000000000000000000000000000000000000000000000000000000000001"}]`, ""},
+		// block parameter not supported
+		{"eth_getTransactionByHash", `["0x01"]`, ""},
+		{"eth_getStorageAt", `["0x0000000000000000000000000000000000000001"]`, ""},
+	}
+
+	for _, tt := range tests {
+		pinned, ok := pinLatestParam(tt.method, json.RawMessage(tt.params), 100)
+		assert.Equal(t, len(tt.pinned) > 0, ok, tt.params)
+
+		if ok {
+			assert.Equal(t, tt.pinned, string(pinned))
+		}
+	}
+}
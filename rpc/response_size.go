@@ -0,0 +1,429 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sync/atomic"
+
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/openweb3/go-rpc-provider"
+	web3Types "github.com/openweb3/web3go/types"
+	"github.com/pkg/errors"
+	"github.com/scroll-tech/rpc-gateway/util/metrics"
+	"github.com/scroll-tech/rpc-gateway/util/reload"
+	rpcutil "github.com/scroll-tech/rpc-gateway/util/rpc"
+	"github.com/sirupsen/logrus"
+)
+
+// errCodeResponseTooLarge is the JSON-RPC error code for responses exceeding size quota.
+const errCodeResponseTooLarge = -32008
+
+const (
+	// reserved size of JSON-RPC envelope of upstream response beyond the result
+	responseEnvelopeSize = 1024
+	// upstream response is read up to this times of the quota to truncate or paginate
+	responseBufferFactor = 4
+)
+
+// policies to handle responses exceeding size quota
+const (
+	// rejects the request with an error
+	ResponseSizePolicyReject = "reject"
+	// truncates list results, e.g. logs, and appends a marker element at the end
+	ResponseSizePolicyTruncate = "truncate"
+	// truncates logs at block boundary, and appends a marker element at the end along with
+	// the cursor to continue query by `gateway_getLogs`, otherwise rejects the request
+	ResponseSizePolicyPaginate = "paginate"
+	// streams logs or traces as notifications of `gateway_subscribe` over websocket, and responds
+	// with the subscription ID instead, otherwise rejects the request
+	ResponseSizePolicyStream = "stream"
+)
+
+// responseSizeAlternatives are alternative RPC methods suggested for oversized responses,
+// which return huge results page by page or as a stream over websocket.
+var responseSizeAlternatives = map[string][]string{
+	"eth_getLogs":  {"gateway_getLogs", "gateway_streamLogs"},
+	"trace_filter": {"gateway_streamTraces"},
+}
+
+// responseSizeStreams are the stream subscriptions of `gateway_subscribe` to deliver oversized
+// results over websocket.
+var responseSizeStreams = map[string]string{
+	"eth_getLogs":  "streamLogs",
+	"trace_filter": "streamTraces",
+}
+
+// MethodResponseSizeConfig response size quota shared by a set of RPC methods.
+type MethodResponseSizeConfig struct {
+	// RPC methods sharing the quota, which supports `*` suffix as wildcard, e.g. `trace_*`
+	Methods []string
+	// max size of response result in bytes
+	MaxSize int
+	// policy to handle oversized responses, e.g. reject, truncate, paginate or stream
+	Policy string `default:"reject"`
+}
+
+// ResponseSizeConfig configurations of per method response size quotas, so that a single
+// pathological query could not exhaust gateway memory or client bandwidth. Note, upstream
+// responses are aborted while reading once exceeding the read limit of quota, if request
+// context passed along to the upstream HTTP client.
+type ResponseSizeConfig struct {
+	Enabled bool
+	// max size of response result in bytes for methods without quota, and 0 for unlimited
+	MaxSize int
+	// policy to handle oversized responses for methods without quota
+	Policy string `default:"reject"`
+	// per method quotas, of which the first matched applies
+	Methods []MethodResponseSizeConfig
+}
+
+// responseTooLargeError is returned for responses exceeding size quota.
+type responseTooLargeError struct {
+	size, maxSize int
+	alternatives  []string // alternative RPC methods for huge results
+}
+
+func (e *responseTooLargeError) Error() string {
+	return fmt.Sprintf("response size %v exceeds limit %v, please narrow down the query", e.size, e.maxSize)
+}
+
+func (e *responseTooLargeError) ErrorCode() int { return errCodeResponseTooLarge }
+
+func (e *responseTooLargeError) ErrorData() interface{} {
+	data := map[string]interface{}{
+		"size":    e.size,
+		"maxSize": e.maxSize,
+	}
+
+	if len(e.alternatives) > 0 {
+		data["alternatives"] = e.alternatives
+	}
+
+	return data
+}
+
+// truncatedMarker is the last element appended to truncated list results.
+type truncatedMarker struct {
+	Truncated bool `json:"truncated"`
+	Returned  int  `json:"returned"`
+	Total     int  `json:"total"`
+}
+
+// paginatedMarker is the last element appended to paginated logs, which contains the cursor
+// to continue query with.
+type paginatedMarker struct {
+	truncatedMarker
+	// opaque cursor to continue query by the RPC method with the same filter
+	Cursor string `json:"cursor"`
+	Method string `json:"continueWith"`
+}
+
+// responseSize is the response size quotas in use, which could be changed at runtime.
+var responseSize atomic.Value
+
+func init() {
+	conf, err := loadResponseSizeConfig()
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to load response size quotas config")
+	}
+
+	responseSize.Store(conf)
+	rpcutil.SetResponseLimited(conf.Enabled)
+
+	reload.Register("rpc_response_size", func() error {
+		conf, err := loadResponseSizeConfig()
+		if err != nil {
+			return err
+		}
+
+		responseSize.Store(conf)
+		rpcutil.SetResponseLimited(conf.Enabled)
+
+		return nil
+	})
+}
+
+func loadResponseSizeConfig() (*ResponseSizeConfig, error) {
+	var conf ResponseSizeConfig
+	if err := viper.UnmarshalKey("rpc.responseSize", &conf); err != nil {
+		return nil, err
+	}
+
+	if conf.MaxSize < 0 || !isValidResponseSizePolicy(conf.Policy) {
+		return nil, errors.Errorf("invalid default response size quota %v with policy %v", conf.MaxSize, conf.Policy)
+	}
+
+	for _, mc := range conf.Methods {
+		if mc.MaxSize <= 0 || len(mc.Methods) == 0 {
+			return nil, errors.Errorf("invalid response size quota %v of methods %v", mc.MaxSize, mc.Methods)
+		}
+
+		if !isValidResponseSizePolicy(mc.Policy) {
+			return nil, errors.Errorf("invalid response size policy %v of methods %v", mc.Policy, mc.Methods)
+		}
+	}
+
+	return &conf, nil
+}
+
+func isValidResponseSizePolicy(policy string) bool {
+	switch policy {
+	case ResponseSizePolicyReject, ResponseSizePolicyTruncate,
+		ResponseSizePolicyPaginate, ResponseSizePolicyStream:
+		return true
+	default:
+		return false
+	}
+}
+
+// quota returns the first matched response size quota if any, otherwise the default one.
+func (conf *ResponseSizeConfig) quota(method string) (*MethodResponseSizeConfig, bool) {
+	for i := range conf.Methods {
+		if matchMethods(conf.Methods[i].Methods, method) {
+			return &conf.Methods[i], true
+		}
+	}
+
+	if conf.MaxSize > 0 {
+		return &MethodResponseSizeConfig{MaxSize: conf.MaxSize, Policy: conf.Policy}, true
+	}
+
+	return nil, false
+}
+
+// readLimit returns the max size of upstream response to read, beyond which the upstream
+// response is aborted while reading, and 0 for unlimited.
+func (quota *MethodResponseSizeConfig) readLimit() int {
+	switch quota.Policy {
+	case ResponseSizePolicyReject, ResponseSizePolicyStream:
+		return quota.MaxSize + responseEnvelopeSize
+	case ResponseSizePolicyTruncate, ResponseSizePolicyPaginate:
+		return quota.MaxSize * responseBufferFactor
+	default:
+		return 0
+	}
+}
+
+// truncateList truncates the JSON array result to fit in the max size, including the marker
+// element appended at the end. Returns false if result is not a JSON array.
+func truncateList(result json.RawMessage, maxSize int) (json.RawMessage, bool) {
+	var items []json.RawMessage
+	if err := json.Unmarshal(result, &items); err != nil {
+		return nil, false
+	}
+
+	// reserve space for the marker with max possible counters
+	reserved, _ := json.Marshal(truncatedMarker{true, len(items), len(items)})
+	size := len(reserved) + 2 // brackets
+
+	var n int
+	for ; n < len(items); n++ {
+		size += len(items[n]) + 1 // comma
+		if size > maxSize {
+			break
+		}
+	}
+
+	marker, _ := json.Marshal(truncatedMarker{true, n, len(items)})
+	truncated, err := json.Marshal(append(items[:n:n], marker))
+	if err != nil {
+		return nil, false
+	}
+
+	return truncated, true
+}
+
+// paginateLogs truncates logs of `eth_getLogs` at block boundary to fit in the max size, and
+// appends a marker element with cursor to continue query by `gateway_getLogs`. Returns false
+// if not paginatable, e.g. filtered by block hash or logs of the first block oversized.
+func paginateLogs(cursors *CursorCodec, params, result json.RawMessage, maxSize int) (json.RawMessage, bool) {
+	var filters []web3Types.FilterQuery
+	if err := json.Unmarshal(params, &filters); err != nil || len(filters) != 1 || filters[0].BlockHash != nil {
+		return nil, false
+	}
+
+	var items []json.RawMessage
+	if err := json.Unmarshal(result, &items); err != nil {
+		return nil, false
+	}
+
+	// reserve space for the marker with max possible counters and position
+	cursor, err := cursors.Encode("gateway_getLogs", &filters[0], math.MaxUint64)
+	if err != nil {
+		return nil, false
+	}
+
+	reserved, _ := json.Marshal(paginatedMarker{
+		truncatedMarker{true, len(items), len(items)}, cursor, "gateway_getLogs",
+	})
+	size := len(reserved) + 2 // brackets
+
+	var (
+		blockStart int    // index of the first log in current block
+		current    uint64 // block number of current log
+	)
+
+	for i, item := range items {
+		var log struct {
+			BlockNumber hexutil.Uint64 `json:"blockNumber"`
+		}
+
+		if err := json.Unmarshal(item, &log); err != nil {
+			return nil, false
+		}
+
+		if i == 0 || uint64(log.BlockNumber) != current {
+			blockStart, current = i, uint64(log.BlockNumber)
+		}
+
+		size += len(item) + 1 // comma
+		if size <= maxSize {
+			continue
+		}
+
+		// logs of a single block could not be split
+		if blockStart == 0 {
+			return nil, false
+		}
+
+		if cursor, err = cursors.Encode("gateway_getLogs", &filters[0], current); err != nil {
+			return nil, false
+		}
+
+		marker, _ := json.Marshal(paginatedMarker{
+			truncatedMarker{true, blockStart, len(items)}, cursor, "gateway_getLogs",
+		})
+
+		paginated, err := json.Marshal(append(items[:blockStart:blockStart], marker))
+		if err != nil {
+			return nil, false
+		}
+
+		return paginated, true
+	}
+
+	return result, true
+}
+
+// streamOversized re-issues the oversized request as stream subscription, which responds with
+// the subscription ID and notifies results frame by frame. Returns false if not streamable, e.g.
+// notifications unsupported over HTTP.
+func streamOversized(
+	ctx context.Context, msg *rpc.JsonRpcMessage, next rpc.HandleCallMsgFunc,
+) (*rpc.JsonRpcMessage, bool) {
+	topic, ok := responseSizeStreams[msg.Method]
+	if !ok {
+		return nil, false
+	}
+
+	var params []json.RawMessage
+	if err := json.Unmarshal(msg.Params, &params); err != nil {
+		return nil, false
+	}
+
+	name, _ := json.Marshal(topic)
+	streamParams, err := json.Marshal(append([]json.RawMessage{name}, params...))
+	if err != nil {
+		return nil, false
+	}
+
+	stream := *msg
+	stream.Method = "gateway_subscribe"
+	stream.Params = streamParams
+
+	resp := next(ctx, &stream)
+	if resp == nil || resp.Error != nil {
+		return nil, false
+	}
+
+	return resp, true
+}
+
+// responseSizeMiddleware enforces per method response size quotas.
+func responseSizeMiddleware(next rpc.HandleCallMsgFunc) rpc.HandleCallMsgFunc {
+	return func(ctx context.Context, msg *rpc.JsonRpcMessage) *rpc.JsonRpcMessage {
+		conf := responseSize.Load().(*ResponseSizeConfig)
+		if !conf.Enabled {
+			return next(ctx, msg)
+		}
+
+		quota, ok := conf.quota(msg.Method)
+		if !ok {
+			return next(ctx, msg)
+		}
+
+		limitCtx := ctx
+
+		var limit *rpcutil.ResponseLimit
+		if maxSize := quota.readLimit(); maxSize > 0 {
+			// abort upstream response while reading rather than buffering it in full
+			limitCtx, limit = rpcutil.WithResponseLimit(ctx, maxSize)
+		}
+
+		resp := next(limitCtx, msg)
+
+		if limit != nil {
+			if size, ok := limit.Exceeded(); ok {
+				metrics.Registry.RPC.ResponseOversized(msg.Method, quota.Policy).Mark(1)
+
+				logrus.WithFields(logrus.Fields{
+					"method":  msg.Method,
+					"size":    size,
+					"maxSize": quota.MaxSize,
+					"policy":  quota.Policy,
+				}).Debug("RPC upstream response aborted due to size quota exceeded")
+
+				if quota.Policy == ResponseSizePolicyStream {
+					if resp, ok := streamOversized(ctx, msg, next); ok {
+						return resp
+					}
+				}
+
+				return msg.ErrorResponse(&responseTooLargeError{
+					size, quota.MaxSize, responseSizeAlternatives[msg.Method],
+				})
+			}
+		}
+
+		if resp == nil || resp.Error != nil || len(resp.Result) <= quota.MaxSize {
+			return resp
+		}
+
+		metrics.Registry.RPC.ResponseOversized(msg.Method, quota.Policy).Mark(1)
+
+		logrus.WithFields(logrus.Fields{
+			"method":  msg.Method,
+			"size":    len(resp.Result),
+			"maxSize": quota.MaxSize,
+			"policy":  quota.Policy,
+		}).Debug("RPC response exceeds size quota")
+
+		switch quota.Policy {
+		case ResponseSizePolicyTruncate:
+			if result, ok := truncateList(resp.Result, quota.MaxSize); ok {
+				resp.Result = result
+				return resp
+			}
+		case ResponseSizePolicyPaginate:
+			if msg.Method != "eth_getLogs" {
+				break
+			}
+
+			if result, ok := paginateLogs(sharedCursors(), msg.Params, resp.Result, quota.MaxSize); ok {
+				resp.Result = result
+				return resp
+			}
+		case ResponseSizePolicyStream:
+			if resp, ok := streamOversized(ctx, msg, next); ok {
+				return resp
+			}
+		}
+
+		return msg.ErrorResponse(&responseTooLargeError{
+			len(resp.Result), quota.MaxSize, responseSizeAlternatives[msg.Method],
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/openweb3/go-rpc-provider"
+	web3Types "github.com/openweb3/web3go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTruncateList(t *testing.T) {
+	result, _ := json.Marshal([]string{"0x01", "0x02", "0x03", "0x04", "0x05"})
+
+	truncated, ok := truncateList(result, 60)
+	assert.True(t, ok)
+	assert.LessOrEqual(t, len(truncated), 60)
+
+	var items []json.RawMessage
+	assert.Nil(t, json.Unmarshal(truncated, &items))
+	assert.Equal(t, `"0x01"`, string(items[0]))
+
+	var marker truncatedMarker
+	assert.Nil(t, json.Unmarshal(items[len(items)-1], &marker))
+	assert.Equal(t, truncatedMarker{true, len(items) - 1, 5}, marker)
+
+	_, ok = truncateList(json.RawMessage(`"0x1234"`), 4)
+	assert.False(t, ok)
+}
+
+func TestPaginateLogs(t *testing.T) {
+	cursors, _ := NewCursorCodec(&CursorConfig{Expiry: time.Hour})
+
+	params := json.RawMessage(`[{"fromBlock":"0x1","toBlock":"0x10"}]`)
+	result, _ := json.Marshal([]map[string]string{
+		{"blockNumber": "0x1", "data": "0x01"},
+		{"blockNumber": "0x1", "data": "0x02"},
+		{"blockNumber": "0x2", "data": "0x03"},
+		{"blockNumber": "0x3", "data": "0x04"},
+	})
+
+	paginated, ok := paginateLogs(cursors, params, result, len(result)+200)
+	assert.True(t, ok)
+
+	var items []json.RawMessage
+	assert.Nil(t, json.Unmarshal(paginated, &items))
+	assert.Equal(t, 3, len(items))
+
+	var marker paginatedMarker
+	assert.Nil(t, json.Unmarshal(items[len(items)-1], &marker))
+	assert.Equal(t, truncatedMarker{true, 2, 4}, marker.truncatedMarker)
+	assert.Equal(t, "gateway_getLogs", marker.Method)
+
+	var filter web3Types.FilterQuery
+	assert.Nil(t, json.Unmarshal([]byte(`{"fromBlock":"0x1","toBlock":"0x10"}`), &filter))
+
+	position, err := cursors.Decode(marker.Cursor, "gateway_getLogs", &filter)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(2), position)
+
+	// logs of the first block could not be split
+	_, ok = paginateLogs(cursors, params, result, 10)
+	assert.False(t, ok)
+
+	// logs filtered by block hash could not be paginated
+	hashParams := json.RawMessage(`[{"blockHash":"0x0000000000000000000000000000000000000000000000000000000000000001"}]`)
+	_, ok = paginateLogs(cursors, hashParams, result, len(result)+200)
+	assert.False(t, ok)
+}
+
+func TestResponseSizeStream(t *testing.T) {
+	defer responseSize.Store(responseSize.Load())
+
+	responseSize.Store(&ResponseSizeConfig{
+		Enabled: true,
+		Methods: []MethodResponseSizeConfig{
+			{Methods: []string{"eth_getLogs", "trace_filter"}, MaxSize: 16, Policy: ResponseSizePolicyStream},
+		},
+	})
+
+	var subscribed []string // params of stream subscriptions
+	handler := func(websocket bool) rpc.HandleCallMsgFunc {
+		return responseSizeMiddleware(func(ctx context.Context, msg *rpc.JsonRpcMessage) *rpc.JsonRpcMessage {
+			if msg.Method != "gateway_subscribe" {
+				result := json.RawMessage(`[{"data":"0x01"},{"data":"0x02"},{"data":"0x03"}]`)
+				return &rpc.JsonRpcMessage{Version: msg.Version, ID: msg.ID, Result: result}
+			}
+
+			if !websocket {
+				return msg.ErrorResponse(rpc.ErrNotificationsUnsupported)
+			}
+
+			subscribed = append(subscribed, string(msg.Params))
+			return &rpc.JsonRpcMessage{Version: msg.Version, ID: msg.ID, Result: json.RawMessage(`"0x1234"`)}
+		})
+	}
+
+	call := func(websocket bool, method string) *rpc.JsonRpcMessage {
+		return handler(websocket)(context.Background(), &rpc.JsonRpcMessage{
+			Version: "2.0", ID: json.RawMessage("1"), Method: method, Params: json.RawMessage(`[{"fromBlock":"0x1"}]`),
+		})
+	}
+
+	// streamed over websocket
+	resp := call(true, "eth_getLogs")
+	assert.Nil(t, resp.Error)
+	assert.Equal(t, json.RawMessage(`"0x1234"`), resp.Result)
+
+	resp = call(true, "trace_filter")
+	assert.Nil(t, resp.Error)
+	assert.Equal(t, []string{`["streamLogs",{"fromBlock":"0x1"}]`, `["streamTraces",{"fromBlock":"0x1"}]`}, subscribed)
+
+	// rejected over HTTP
+	resp = call(false, "eth_getLogs")
+	assert.Equal(t, errCodeResponseTooLarge, resp.Error.Code)
+}
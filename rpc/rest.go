@@ -0,0 +1,266 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	viperutil "github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/pkg/errors"
+	"github.com/scroll-tech/rpc-gateway/util/rpc/handlers"
+)
+
+// RestConfig configurations of REST façade for common queries, which are translated into
+// JSON-RPC internally for integrators who can't easily issue JSON-RPC.
+type RestConfig struct {
+	Enabled bool
+	// path prefix of REST APIs, e.g. `/rest/block/latest`
+	Prefix string `default:"/rest"`
+}
+
+var errRestNotFound = errors.New("not found")
+
+// restCall is the JSON-RPC call translated from REST request.
+type restCall struct {
+	method string
+	params []interface{}
+}
+
+// restRoute translates REST request into JSON-RPC call, or returns false if not matched.
+type restRoute func(segments []string, query map[string][]string) (*restCall, bool, error)
+
+var restRoutes = []restRoute{
+	// GET /block/{number|hash|tag}?full=true
+	func(segments []string, query map[string][]string) (*restCall, bool, error) {
+		if len(segments) != 2 || segments[0] != "block" {
+			return nil, false, nil
+		}
+
+		full := len(query["full"]) > 0 && query["full"][0] == "true"
+
+		if isHashParam(segments[1]) {
+			return &restCall{"eth_getBlockByHash", []interface{}{segments[1], full}}, true, nil
+		}
+
+		block, err := parseBlockParam(segments[1])
+		if err != nil {
+			return nil, true, err
+		}
+
+		return &restCall{"eth_getBlockByNumber", []interface{}{block, full}}, true, nil
+	},
+	// GET /tx/{hash} and /tx/{hash}/receipt
+	func(segments []string, query map[string][]string) (*restCall, bool, error) {
+		if len(segments) < 2 || len(segments) > 3 || segments[0] != "tx" {
+			return nil, false, nil
+		}
+
+		if !isHashParam(segments[1]) {
+			return nil, true, errors.Errorf("invalid transaction hash %v", segments[1])
+		}
+
+		if len(segments) == 2 {
+			return &restCall{"eth_getTransactionByHash", []interface{}{segments[1]}}, true, nil
+		}
+
+		if segments[2] != "receipt" {
+			return nil, false, nil
+		}
+
+		return &restCall{"eth_getTransactionReceipt", []interface{}{segments[1]}}, true, nil
+	},
+	// GET /address/{addr}/balance?block={number|hash|tag}
+	func(segments []string, query map[string][]string) (*restCall, bool, error) {
+		if len(segments) != 3 || segments[0] != "address" || segments[2] != "balance" {
+			return nil, false, nil
+		}
+
+		block := "latest"
+		if len(query["block"]) > 0 {
+			block = query["block"][0]
+		}
+
+		if !isHashParam(block) {
+			var err error
+			if block, err = parseBlockParam(block); err != nil {
+				return nil, true, err
+			}
+		}
+
+		return &restCall{"eth_getBalance", []interface{}{segments[1], block}}, true, nil
+	},
+}
+
+func splitRestPath(path string) []string {
+	return strings.Split(strings.Trim(path, "/"), "/")
+}
+
+func isHashParam(v string) bool {
+	return len(v) == 66 && strings.HasPrefix(v, "0x")
+}
+
+// parseBlockParam parses block number in decimal or hex, or block tag, e.g. `latest`.
+func parseBlockParam(v string) (string, error) {
+	switch v {
+	case "latest", "earliest", "pending", "safe", "finalized":
+		return v, nil
+	}
+
+	if strings.HasPrefix(v, "0x") {
+		if _, err := strconv.ParseUint(v[2:], 16, 64); err != nil {
+			return "", errors.Errorf("invalid block number %v", v)
+		}
+
+		return v, nil
+	}
+
+	bn, err := strconv.ParseUint(v, 10, 64)
+	if err != nil {
+		return "", errors.Errorf("invalid block number %v", v)
+	}
+
+	return fmt.Sprintf("0x%x", bn), nil
+}
+
+// matchPathPrefix matches request path with the prefix, which is optionally preceded by access
+// token, e.g. `/${accessToken}/rest/...`, and returns the path after prefix. Note, access token
+// in context is cleared if absent, which is parsed from the first path segment by default.
+func matchPathPrefix(r *http.Request, prefix string) (*http.Request, string, bool) {
+	path := r.URL.EscapedPath()
+
+	if remaining, ok := trimPathPrefix(path, prefix); ok {
+		ctx := context.WithValue(r.Context(), handlers.CtxAccessToken, nil)
+		return r.WithContext(ctx), remaining, true
+	}
+
+	// with access token
+	if idx := strings.Index(strings.TrimPrefix(path, "/"), "/"); idx > 0 {
+		if remaining, ok := trimPathPrefix(path[idx+1:], prefix); ok {
+			return r, remaining, true
+		}
+	}
+
+	return nil, "", false
+}
+
+func trimPathPrefix(path, prefix string) (string, bool) {
+	if path == prefix {
+		return "", true
+	}
+
+	if strings.HasPrefix(path, prefix+"/") {
+		return path[len(prefix):], true
+	}
+
+	return "", false
+}
+
+// restMiddleware serves REST APIs by translating into JSON-RPC, which is then handled by the
+// next handler, so as to share the same auth, rate limiting and routing. Note, it should be
+// applied after HTTP middleware to inject context values.
+func restMiddleware() handlers.Middleware {
+	var conf RestConfig
+	viperutil.MustUnmarshalKey("rpc.rest", &conf)
+
+	return func(next http.Handler) http.Handler {
+		if !conf.Enabled {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet || len(r.Header.Get("Upgrade")) > 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			req, path, ok := matchPathPrefix(r, conf.Prefix)
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			serveRest(next, w, req, path)
+		})
+	}
+}
+
+func serveRest(next http.Handler, w http.ResponseWriter, r *http.Request, path string) {
+	query := r.URL.Query()
+
+	for _, route := range restRoutes {
+		call, matched, err := route(splitRestPath(path), query)
+		if !matched {
+			continue
+		}
+
+		if err != nil {
+			writeRestError(w, http.StatusBadRequest, &jsonRpcError{Code: -32602, Message: err.Error()})
+			return
+		}
+
+		serveRestCall(next, w, r, call)
+		return
+	}
+
+	writeRestError(w, http.StatusNotFound, &jsonRpcError{Code: -32601, Message: "REST API not found"})
+}
+
+// serveRestCall handles the translated JSON-RPC call by the next handler, and responds the
+// result directly.
+func serveRestCall(next http.Handler, w http.ResponseWriter, r *http.Request, call *restCall) {
+	req, err := newJsonRpcRequest(r.Context(), "/", call.method, call.params)
+	if err != nil {
+		writeRestError(w, http.StatusInternalServerError, &jsonRpcError{Code: -32603, Message: err.Error()})
+		return
+	}
+
+	for k, v := range r.Header {
+		if _, ok := req.Header[k]; !ok {
+			req.Header[k] = v
+		}
+	}
+
+	req.Header.Del("Accept-Encoding")
+	req.Host, req.RemoteAddr = r.Host, r.RemoteAddr
+
+	resp, recorder := invokeJsonRpc(next, req)
+
+	switch {
+	case resp == nil:
+		w.WriteHeader(recorder.status)
+		w.Write(recorder.body.Bytes())
+	case resp.Error != nil:
+		writeRestError(w, jsonRpcErrorStatus(r.Context(), resp.Error), resp.Error)
+	case resp.isNull():
+		writeRestError(w, http.StatusNotFound, &jsonRpcError{Code: -32000, Message: errRestNotFound.Error()})
+	default:
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(resp.Result)
+	}
+}
+
+// jsonRpcErrorStatus returns the HTTP status of JSON-RPC error.
+func jsonRpcErrorStatus(ctx context.Context, err *jsonRpcError) int {
+	if handlers.RateLimited(ctx) {
+		return http.StatusTooManyRequests
+	}
+
+	switch err.Code {
+	case -32600, -32602:
+		return http.StatusBadRequest
+	case -32601:
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func writeRestError(w http.ResponseWriter, status int, err *jsonRpcError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	json.NewEncoder(w).Encode(map[string]interface{}{"error": err})
+}
@@ -0,0 +1,69 @@
+package rpc
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/scroll-tech/rpc-gateway/util/rpc/handlers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRestBlock(t *testing.T) {
+	block, err := parseBlockParam("latest")
+	assert.Nil(t, err)
+	assert.Equal(t, "latest", block)
+
+	block, err = parseBlockParam("100")
+	assert.Nil(t, err)
+	assert.Equal(t, "0x64", block)
+
+	block, err = parseBlockParam("0x64")
+	assert.Nil(t, err)
+	assert.Equal(t, "0x64", block)
+
+	_, err = parseBlockParam("0xzz")
+	assert.NotNil(t, err)
+}
+
+func TestMatchPathPrefix(t *testing.T) {
+	r, _ := http.NewRequest(http.MethodGet, "/rest/block/latest", nil)
+	req, path, ok := matchPathPrefix(r, "/rest")
+	assert.True(t, ok)
+	assert.Equal(t, "/block/latest", path)
+	_, ok = handlers.GetAccessTokenFromContext(req.Context())
+	assert.False(t, ok)
+
+	r, _ = http.NewRequest(http.MethodGet, "/token/rest/block/latest", nil)
+	_, path, ok = matchPathPrefix(r, "/rest")
+	assert.True(t, ok)
+	assert.Equal(t, "/block/latest", path)
+
+	r, _ = http.NewRequest(http.MethodGet, "/token/restful", nil)
+	_, _, ok = matchPathPrefix(r, "/rest")
+	assert.False(t, ok)
+}
+
+func TestRestRoutes(t *testing.T) {
+	route := func(path string, query map[string][]string) *restCall {
+		for _, route := range restRoutes {
+			if call, matched, err := route(splitRestPath(path), query); matched && err == nil {
+				return call
+			}
+		}
+
+		return nil
+	}
+
+	call := route("/block/100", map[string][]string{"full": {"true"}})
+	assert.Equal(t, "eth_getBlockByNumber", call.method)
+	assert.Equal(t, []interface{}{"0x64", true}, call.params)
+
+	hash := "0x88df016429689c079f3b2f6ad39fa052532c56795b733da78a91ebe6a713944b"
+	assert.Equal(t, "eth_getTransactionReceipt", route("/tx/"+hash+"/receipt", nil).method)
+
+	call = route("/address/0x0000000000000000000000000000000000000001/balance", nil)
+	assert.Equal(t, "eth_getBalance", call.method)
+	assert.Equal(t, "latest", call.params[1])
+
+	assert.Nil(t, route("/unknown", nil))
+}
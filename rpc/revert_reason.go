@@ -0,0 +1,148 @@
+package rpc
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/scroll-tech/rpc-gateway/util/feature"
+)
+
+// featureRevertReason is the opt-in feature flag to decode revert reasons of reverted calls, which
+// is generally enabled for tenants (API keys) only, since error data is changed from hex string
+// into object.
+const featureRevertReason = "revertReason"
+
+var (
+	// selector of standard `Error(string)`
+	revertErrorSelector = []byte{0x08, 0xc3, 0x79, 0xa0}
+	// selector of standard `Panic(uint256)`
+	revertPanicSelector = []byte{0x4e, 0x48, 0x7b, 0x71}
+
+	// panic codes of solidity compiler
+	revertPanicReasons = map[uint64]string{
+		0x00: "generic compiler inserted panic",
+		0x01: "assertion failed",
+		0x11: "arithmetic underflow or overflow",
+		0x12: "division or modulo by zero",
+		0x21: "invalid enum value",
+		0x22: "invalid storage byte array encoding",
+		0x31: "pop on empty array",
+		0x32: "array index out of bounds",
+		0x41: "out of memory",
+		0x51: "call to zero-initialized function",
+	}
+)
+
+// RevertReason is the decoded revert reason of reverted call.
+type RevertReason struct {
+	// raw revert data in hex
+	Data string `json:"data"`
+	// `Error` or `Panic`
+	Kind string `json:"kind"`
+	// human-readable revert reason
+	Reason string `json:"reason"`
+	// panic code if kind is `Panic`
+	Code *hexutil.Uint64 `json:"code,omitempty"`
+}
+
+// decodeRevertReason decodes the standard `Error(string)` or `Panic(uint256)` revert payload.
+func decodeRevertReason(data []byte) (*RevertReason, bool) {
+	if len(data) < 4+32 {
+		return nil, false
+	}
+
+	selector, payload := data[:4], data[4:]
+
+	switch string(selector) {
+	case string(revertErrorSelector):
+		reason, ok := decodeAbiString(payload)
+		if !ok {
+			return nil, false
+		}
+
+		return &RevertReason{Data: hexutil.Encode(data), Kind: "Error", Reason: reason}, true
+	case string(revertPanicSelector):
+		code := new(big.Int).SetBytes(payload[:32])
+		if !code.IsUint64() {
+			return nil, false
+		}
+
+		panicCode := hexutil.Uint64(code.Uint64())
+
+		reason, ok := revertPanicReasons[code.Uint64()]
+		if !ok {
+			reason = fmt.Sprintf("unknown panic code %#x", code.Uint64())
+		}
+
+		return &RevertReason{Data: hexutil.Encode(data), Kind: "Panic", Reason: reason, Code: &panicCode}, true
+	default:
+		return nil, false
+	}
+}
+
+// decodeAbiString decodes ABI encoded dynamic string, namely offset, length and content.
+func decodeAbiString(payload []byte) (string, bool) {
+	offset, ok := decodeAbiUint(payload, 0)
+	if !ok || offset+32 > uint64(len(payload)) {
+		return "", false
+	}
+
+	length, ok := decodeAbiUint(payload, offset)
+	if !ok || offset+32+length > uint64(len(payload)) {
+		return "", false
+	}
+
+	return string(payload[offset+32 : offset+32+length]), true
+}
+
+// decodeAbiUint decodes ABI encoded uint256 at the specified position, which should fit in uint32
+// to avoid overflow.
+func decodeAbiUint(payload []byte, pos uint64) (uint64, bool) {
+	if pos+32 > uint64(len(payload)) {
+		return 0, false
+	}
+
+	word := payload[pos : pos+32]
+	for _, b := range word[:28] {
+		if b != 0 {
+			return 0, false
+		}
+	}
+
+	return uint64(binary.BigEndian.Uint32(word[28:])), true
+}
+
+// revertReasonMiddleware decodes revert reasons of reverted calls into error data for
+// tenants opted in by feature flag.
+func revertReasonMiddleware(next rpc.HandleCallMsgFunc) rpc.HandleCallMsgFunc {
+	return func(ctx context.Context, msg *rpc.JsonRpcMessage) *rpc.JsonRpcMessage {
+		if msg.Method != "eth_call" && msg.Method != "eth_estimateGas" {
+			return next(ctx, msg)
+		}
+
+		resp := next(ctx, msg)
+		if resp == nil || resp.Error == nil || !feature.OptedIn(ctx, featureRevertReason) {
+			return resp
+		}
+
+		hex, ok := resp.Error.Data.(string)
+		if !ok {
+			return resp
+		}
+
+		data, err := hexutil.Decode(hex)
+		if err != nil {
+			return resp
+		}
+
+		if reason, ok := decodeRevertReason(data); ok {
+			resp.Error.Data = reason
+		}
+
+		return resp
+	}
+}
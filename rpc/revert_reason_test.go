@@ -0,0 +1,43 @@
+package rpc
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeRevertReason(t *testing.T) {
+	// Error("not owner")
+	data := hexutil.MustDecode("0x08c379a0" +
+		"0000000000000000000000000000000000000000000000000000000000000020" +
+		"0000000000000000000000000000000000000000000000000000000000000009" +
+		"6e6f74206f776e65720000000000000000000000000000000000000000000000")
+
+	reason, ok := decodeRevertReason(data)
+	assert.True(t, ok)
+	assert.Equal(t, "Error", reason.Kind)
+	assert.Equal(t, "not owner", reason.Reason)
+	assert.Nil(t, reason.Code)
+
+	// Panic(0x11)
+	data = hexutil.MustDecode("0x4e487b71" +
+		"0000000000000000000000000000000000000000000000000000000000000011")
+
+	reason, ok = decodeRevertReason(data)
+	assert.True(t, ok)
+	assert.Equal(t, "Panic", reason.Kind)
+	assert.Equal(t, "arithmetic underflow or overflow", reason.Reason)
+	assert.Equal(t, hexutil.Uint64(0x11), *reason.Code)
+
+	// custom error
+	_, ok = decodeRevertReason(hexutil.MustDecode("0x12345678" +
+		"0000000000000000000000000000000000000000000000000000000000000001"))
+	assert.False(t, ok)
+
+	// malformed string length
+	_, ok = decodeRevertReason(hexutil.MustDecode("0x08c379a0" +
+		"0000000000000000000000000000000000000000000000000000000000000020" +
+		"00000000000000000000000000000000000000000000000000000000000000ff"))
+	assert.False(t, ok)
+}
@@ -0,0 +1,66 @@
+package rpc
+
+import (
+	"context"
+
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/scroll-tech/rpc-gateway/node"
+)
+
+// strategies to route RPC requests among nodes of some group
+const (
+	routeByIP     = "ip"
+	routeByHeight = "height"
+	routeRandom   = "random"
+)
+
+// ethRoute is the routing decision for evm space RPC request.
+type ethRoute struct {
+	Group    node.Group `json:"group"`
+	Strategy string     `json:"strategy"`
+	Height   *uint64    `json:"height,omitempty"`
+}
+
+// decideEthRoute decides the node group and strategy to route evm space RPC request, which
+// depends on the request only, so that it could be verified against golden files.
+func decideEthRoute(chain, lbMode string, msg *rpc.JsonRpcMessage) ethRoute {
+	// config-driven node groups are available for the default chain only
+	if matched, routing, ok := node.MatchGroup("eth", msg.Method); ok && len(chain) == 0 {
+		if routing == node.RoutingRandom {
+			return ethRoute{Group: matched, Strategy: routeRandom}
+		}
+
+		return ethRoute{Group: matched, Strategy: routeByIP}
+	}
+
+	strategy := routeRandom
+	if lbMode == "consistentHashing" {
+		strategy = routeByIP
+	}
+
+	switch msg.Method {
+	case "eth_getLogs", "gateway_getLogs":
+		return ethRoute{Group: node.GroupEthLogs, Strategy: strategy}
+	}
+
+	// route by block height if requested
+	if strategy == routeByIP {
+		if height, ok := parseEthRouteHeight(msg.Method, msg.Params); ok {
+			return ethRoute{Group: node.GroupEthHttp, Strategy: routeByHeight, Height: height}
+		}
+	}
+
+	return ethRoute{Group: node.GroupEthHttp, Strategy: strategy}
+}
+
+// client selects client of the decided node group.
+func (r *ethRoute) client(ctx context.Context, provider *node.EthClientProvider) (*node.Web3goClient, error) {
+	switch r.Strategy {
+	case routeRandom:
+		return provider.GetClientRandomByGroup(r.Group)
+	case routeByHeight:
+		return provider.GetClientByIPGroupHeight(ctx, r.Group, r.Height)
+	default:
+		return provider.GetClientByIPGroup(ctx, r.Group)
+	}
+}
@@ -0,0 +1,56 @@
+package rpc
+
+import (
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"testing"
+
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/scroll-tech/rpc-gateway/rpc/capture"
+	"github.com/stretchr/testify/assert"
+)
+
+// update golden files by `go test ./rpc -run TestRoutingFixtures -update`
+var updateGolden = flag.Bool("update", false, "update golden files")
+
+type routingGolden struct {
+	Name  string   `json:"name"`
+	Route ethRoute `json:"route"`
+}
+
+// TestRoutingFixtures verifies routing decisions of fixtures generated from capture logs by
+// the `test fixture` command against golden files.
+func TestRoutingFixtures(t *testing.T) {
+	file2Fixtures, err := capture.LoadFixtures("testdata/routing")
+	assert.Nil(t, err)
+
+	for file, fixtures := range file2Fixtures {
+		var actual []routingGolden
+
+		for _, fixture := range fixtures {
+			if fixture.Space != "eth" {
+				continue
+			}
+
+			msg := rpc.JsonRpcMessage{Method: fixture.Method, Params: fixture.Params}
+			route := decideEthRoute(fixture.Chain, "consistentHashing", &msg)
+			actual = append(actual, routingGolden{fixture.Name, route})
+		}
+
+		goldenFile := capture.GoldenFile(file)
+		if *updateGolden {
+			assert.Nil(t, capture.WriteJSONFile(goldenFile, actual))
+			continue
+		}
+
+		data, err := ioutil.ReadFile(goldenFile)
+		if !assert.Nil(t, err, "golden file missing, run with -update to generate") {
+			continue
+		}
+
+		var expected []routingGolden
+		assert.Nil(t, json.Unmarshal(data, &expected))
+		assert.Equal(t, expected, actual, file)
+	}
+}
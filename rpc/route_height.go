@@ -0,0 +1,72 @@
+package rpc
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// ethBlockParamIndexes is the index of block number parameter for evm space RPC methods,
+// which is used to route RPC requests by block height.
+var ethBlockParamIndexes = map[string]int{
+	"eth_getBalance":                          1,
+	"eth_getCode":                             1,
+	"eth_getTransactionCount":                 1,
+	"eth_call":                                1,
+	"eth_estimateGas":                         1,
+	"eth_feeHistory":                          1,
+	"eth_getStorageAt":                        2,
+	"eth_getBlockByNumber":                    0,
+	"eth_getBlockTransactionCountByNumber":    0,
+	"eth_getTransactionByBlockNumberAndIndex": 0,
+	"eth_getUncleByBlockNumberAndIndex":       0,
+}
+
+// parseEthRouteHeight parses the requested block height from RPC params to route RPC
+// request. It returns nil height for the latest block, or false if the RPC request is
+// not height sensitive (e.g., by block hash or earliest block).
+func parseEthRouteHeight(method string, rawParams json.RawMessage) (*uint64, bool) {
+	index, ok := ethBlockParamIndexes[method]
+	if !ok {
+		return nil, false
+	}
+
+	var params []json.RawMessage
+	if err := json.Unmarshal(rawParams, &params); err != nil {
+		return nil, false
+	}
+
+	// block parameter omitted, which defaults to the latest block
+	if index >= len(params) {
+		return nil, true
+	}
+
+	var blockNumOrTag string
+	if err := json.Unmarshal(params[index], &blockNumOrTag); err != nil {
+		// EIP-1898 block parameter object
+		var blockNumOrHash struct {
+			BlockNumber *string `json:"blockNumber"`
+		}
+
+		if err := json.Unmarshal(params[index], &blockNumOrHash); err != nil || blockNumOrHash.BlockNumber == nil {
+			return nil, false
+		}
+
+		blockNumOrTag = *blockNumOrHash.BlockNumber
+	}
+
+	switch strings.ToLower(blockNumOrTag) {
+	case "", "latest", "pending":
+		return nil, true
+	case "earliest", "safe", "finalized":
+		return nil, false
+	}
+
+	height, err := hexutil.DecodeUint64(blockNumOrTag)
+	if err != nil {
+		return nil, false
+	}
+
+	return &height, true
+}
@@ -0,0 +1,37 @@
+package rpc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseEthRouteHeight(t *testing.T) {
+	uint64Ptr := func(v uint64) *uint64 { return &v }
+
+	testCases := []struct {
+		method string
+		params string
+		height *uint64
+		ok     bool
+	}{
+		{"eth_blockNumber", `[]`, nil, false},
+		{"eth_getBalance", `["0x0000000000000000000000000000000000000000"]`, nil, true},
+		{"eth_getBalance", `["0x0000000000000000000000000000000000000000", "latest"]`, nil, true},
+		{"eth_getBalance", `["0x0000000000000000000000000000000000000000", "pending"]`, nil, true},
+		{"eth_getBalance", `["0x0000000000000000000000000000000000000000", "earliest"]`, nil, false},
+		{"eth_getBalance", `["0x0000000000000000000000000000000000000000", "0x10"]`, uint64Ptr(16), true},
+		{"eth_getStorageAt", `["0x0000000000000000000000000000000000000000", "0x0", "0x20"]`, uint64Ptr(32), true},
+		{"eth_getBlockByNumber", `["0x64", false]`, uint64Ptr(100), true},
+		{"eth_call", `[{}, {"blockNumber": "0x1"}]`, uint64Ptr(1), true},
+		{"eth_call", `[{}, {"blockHash": "0x01"}]`, nil, false},
+		{"eth_call", `[{}, null]`, nil, true},
+		{"eth_call", `invalid`, nil, false},
+	}
+
+	for _, tc := range testCases {
+		height, ok := parseEthRouteHeight(tc.method, []byte(tc.params))
+		assert.Equal(t, tc.ok, ok, "%v %v", tc.method, tc.params)
+		assert.Equal(t, tc.height, height, "%v %v", tc.method, tc.params)
+	}
+}
@@ -0,0 +1,170 @@
+package rpc
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/scroll-tech/rpc-gateway/node"
+	"github.com/scroll-tech/rpc-gateway/util/reload"
+	"github.com/scroll-tech/rpc-gateway/util/rpc/handlers"
+	"github.com/sirupsen/logrus"
+)
+
+// request headers of routing hints, which are honored for privileged API keys only
+const (
+	routeNodeHeader  = "X-Route-Node"
+	routeGroupHeader = "X-Route-Group"
+	noCacheHeader    = "X-No-Cache"
+)
+
+const ctxKeyRouteHints = handlers.CtxKey("Infura-RPC-Route-Hints")
+
+// RouteHintConfig configurations to honor routing hints in request headers, so that debugging
+// tools could pin requests to some node or bypass cache. Note, it only applies to evm space.
+type RouteHintConfig struct {
+	Enabled bool
+	// privileged API keys allowed to override routing
+	Keys []string
+}
+
+type routeHintPolicy struct {
+	RouteHintConfig
+	keys map[string]bool
+}
+
+// routeHint is the routing hint policy in use, which could be changed at runtime.
+var routeHint atomic.Value
+
+func init() {
+	policy, err := loadRouteHintPolicy()
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to load route hint config")
+	}
+
+	routeHint.Store(policy)
+
+	reload.Register("rpc_route_hint", func() error {
+		policy, err := loadRouteHintPolicy()
+		if err != nil {
+			return err
+		}
+
+		routeHint.Store(policy)
+		return nil
+	})
+}
+
+func loadRouteHintPolicy() (*routeHintPolicy, error) {
+	var conf RouteHintConfig
+	if err := viper.UnmarshalKey("rpc.routeHint", &conf); err != nil {
+		return nil, err
+	}
+
+	policy := routeHintPolicy{RouteHintConfig: conf, keys: make(map[string]bool)}
+	for _, key := range conf.Keys {
+		if len(key) > 0 {
+			policy.keys[key] = true
+		}
+	}
+
+	return &policy, nil
+}
+
+// routeHints is the routing overrides requested by privileged API key.
+type routeHints struct {
+	apiKey  string
+	Node    string
+	Group   node.Group
+	NoCache bool
+}
+
+// pinned checks if requests are pinned to some node, in which case requests should not be
+// fanned out to other nodes or peer gateways.
+func (h *routeHints) pinned() bool {
+	return h != nil && len(h.Node) > 0
+}
+
+// parseRouteHints parses routing hints from request headers.
+func parseRouteHints(r *http.Request, apiKey string) (*routeHints, bool) {
+	hints := routeHints{
+		apiKey: apiKey,
+		Node:   strings.TrimSpace(r.Header.Get(routeNodeHeader)),
+		Group:  node.Group(strings.TrimSpace(r.Header.Get(routeGroupHeader))),
+	}
+
+	if v := r.Header.Get(noCacheHeader); len(v) > 0 {
+		// presence of header means no cache unless explicitly false
+		noCache, err := strconv.ParseBool(v)
+		hints.NoCache = err != nil || noCache
+	}
+
+	if len(hints.Node) == 0 && len(hints.Group) == 0 && !hints.NoCache {
+		return nil, false
+	}
+
+	return &hints, true
+}
+
+// withRouteHints injects routing hints into context if requested by privileged API key.
+func withRouteHints(ctx context.Context, r *http.Request) context.Context {
+	policy := routeHint.Load().(*routeHintPolicy)
+	if !policy.Enabled {
+		return ctx
+	}
+
+	apiKey := handlers.GetAccessToken(r)
+	if !policy.keys[apiKey] {
+		return ctx
+	}
+
+	if hints, ok := parseRouteHints(r, apiKey); ok {
+		ctx = context.WithValue(ctx, ctxKeyRouteHints, hints)
+	}
+
+	return ctx
+}
+
+func routeHintsFromContext(ctx context.Context) (*routeHints, bool) {
+	hints, ok := ctx.Value(ctxKeyRouteHints).(*routeHints)
+	return hints, ok
+}
+
+// cacheBypassed checks if cache and store should be bypassed for the RPC request.
+func cacheBypassed(ctx context.Context) bool {
+	hints, ok := routeHintsFromContext(ctx)
+	return ok && hints.NoCache
+}
+
+// overrideEthRoute overrides the routing decision by routing hints, and audits the override.
+func overrideEthRoute(ctx context.Context, msg *rpc.JsonRpcMessage, route ethRoute, hints *routeHints) ethRoute {
+	if len(hints.Group) > 0 {
+		route = ethRoute{Group: hints.Group, Strategy: routeByIP}
+	}
+
+	ip, _ := handlers.GetIPAddressFromContext(ctx)
+
+	logrus.WithFields(logrus.Fields{
+		"apiKey":  maskApiKey(hints.apiKey),
+		"ip":      ip,
+		"method":  msg.Method,
+		"node":    hints.Node,
+		"group":   route.Group,
+		"noCache": hints.NoCache,
+	}).Info("Routing overridden by request headers")
+
+	return route
+}
+
+// maskApiKey masks API key for audit logs.
+func maskApiKey(key string) string {
+	if len(key) <= 6 {
+		return "***"
+	}
+
+	return key[:6] + "***"
+}
@@ -0,0 +1,51 @@
+package rpc
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/scroll-tech/rpc-gateway/node"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithRouteHints(t *testing.T) {
+	defer routeHint.Store(routeHint.Load())
+
+	routeHint.Store(&routeHintPolicy{
+		RouteHintConfig: RouteHintConfig{Enabled: true},
+		keys:            map[string]bool{"privileged": true},
+	})
+
+	r := httptest.NewRequest("POST", "/privileged", nil)
+	r.Header.Set(routeNodeHeader, "10.0.0.1:8545")
+	r.Header.Set(routeGroupHeader, "ethhttp")
+	r.Header.Set(noCacheHeader, "1")
+
+	hints, ok := routeHintsFromContext(withRouteHints(context.Background(), r))
+	assert.True(t, ok)
+	assert.Equal(t, "10.0.0.1:8545", hints.Node)
+	assert.Equal(t, node.Group("ethhttp"), hints.Group)
+	assert.True(t, hints.NoCache)
+	assert.True(t, hints.pinned())
+
+	// headers ignored for unprivileged API key
+	r.URL.Path = "/normal"
+	_, ok = routeHintsFromContext(withRouteHints(context.Background(), r))
+	assert.False(t, ok)
+
+	// no cache only
+	r = httptest.NewRequest("POST", "/privileged", nil)
+	r.Header.Set(noCacheHeader, "true")
+	ctx := withRouteHints(context.Background(), r)
+	assert.True(t, cacheBypassed(ctx))
+	hints, _ = routeHintsFromContext(ctx)
+	assert.False(t, hints.pinned())
+
+	r.Header.Set(noCacheHeader, "false")
+	_, ok = routeHintsFromContext(withRouteHints(context.Background(), r))
+	assert.False(t, ok)
+
+	var nilHints *routeHints
+	assert.False(t, nilHints.pinned())
+}
@@ -0,0 +1,110 @@
+package rpc
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/scroll-tech/rpc-gateway/node"
+	"github.com/scroll-tech/rpc-gateway/rpc/cache"
+	"github.com/scroll-tech/rpc-gateway/util/metrics"
+)
+
+// tierEthRoute routes request to the full-history group if the requested block is older than
+// the pruning horizon of recent nodes in the decided group. Note, it only applies to the
+// default evm chain.
+func tierEthRoute(route ethRoute, msg *rpc.JsonRpcMessage, provider *node.EthClientProvider) ethRoute {
+	if len(provider.Chain()) > 0 {
+		return route
+	}
+
+	tier, ok := node.TierOf(route.Group)
+	if !ok {
+		return route
+	}
+
+	height, ok := parseEthTierHeight(msg.Method, msg.Params)
+	if !ok {
+		return route
+	}
+
+	// stay in recent nodes if the latest height unknown
+	latest, ok := ethLatestHeight(provider, route.Group)
+	if !ok {
+		return route
+	}
+
+	history := latest >= tier.Horizon && height <= latest-tier.Horizon
+	metrics.Registry.RPC.Percentage(msg.Method, "tier/history").Mark(history)
+
+	if history {
+		route.Group = node.Group(tier.History)
+	}
+
+	return route
+}
+
+// ethLatestHeight returns the latest block height of the default evm chain, which is the
+// canonical head if head broadcaster started, or the cached height of any node in group.
+func ethLatestHeight(provider *node.EthClientProvider, group node.Group) (uint64, bool) {
+	if head, ok := node.Heads().Head(); ok {
+		return head.Number, true
+	}
+
+	client, err := provider.GetClientRandomByGroup(group)
+	if err != nil {
+		return 0, false
+	}
+
+	bn, err := cache.Eth(provider.Chain()).GetBlockNumber(client)
+	if err != nil {
+		return 0, false
+	}
+
+	return bn.ToInt().Uint64(), true
+}
+
+// parseEthTierHeight parses the oldest block height requested, where `earliest` stands for the
+// genesis block. It returns false if not requested by block number.
+func parseEthTierHeight(method string, rawParams json.RawMessage) (uint64, bool) {
+	var params []json.RawMessage
+	if err := json.Unmarshal(rawParams, &params); err != nil || len(params) == 0 {
+		return 0, false
+	}
+
+	// the oldest block of log filter
+	if method == "eth_getLogs" {
+		return parseEthTierBlockParam(params[0], "fromBlock")
+	}
+
+	index, ok := ethBlockParamIndexes[method]
+	if !ok || index >= len(params) {
+		return 0, false
+	}
+
+	return parseEthTierBlockParam(params[index], "blockNumber")
+}
+
+// parseEthTierBlockParam parses block number of block parameter, which is either block number
+// or object with block number field.
+func parseEthTierBlockParam(param json.RawMessage, field string) (uint64, bool) {
+	var blockNumOrTag string
+	if err := json.Unmarshal(param, &blockNumOrTag); err != nil {
+		var obj map[string]json.RawMessage
+		if err := json.Unmarshal(param, &obj); err != nil || obj[field] == nil {
+			return 0, false
+		}
+
+		if err := json.Unmarshal(obj[field], &blockNumOrTag); err != nil {
+			return 0, false
+		}
+	}
+
+	if strings.EqualFold(blockNumOrTag, "earliest") {
+		return 0, true
+	}
+
+	height, err := hexutil.DecodeUint64(blockNumOrTag)
+	return height, err == nil
+}
@@ -0,0 +1,32 @@
+package rpc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseEthTierHeight(t *testing.T) {
+	testCases := []struct {
+		method string
+		params string
+		height uint64
+		ok     bool
+	}{
+		{"eth_blockNumber", `[]`, 0, false},
+		{"eth_getBalance", `["0x0000000000000000000000000000000000000000"]`, 0, false},
+		{"eth_getBalance", `["0x0000000000000000000000000000000000000000", "latest"]`, 0, false},
+		{"eth_getBalance", `["0x0000000000000000000000000000000000000000", "earliest"]`, 0, true},
+		{"eth_getBalance", `["0x0000000000000000000000000000000000000000", "0x10"]`, 16, true},
+		{"eth_call", `[{}, {"blockNumber": "0x1"}]`, 1, true},
+		{"eth_call", `[{}, {"blockHash": "0x01"}]`, 0, false},
+		{"eth_getLogs", `[{"fromBlock": "0x20", "toBlock": "latest"}]`, 32, true},
+		{"eth_getLogs", `[{"blockHash": "0x01"}]`, 0, false},
+	}
+
+	for _, tc := range testCases {
+		height, ok := parseEthTierHeight(tc.method, []byte(tc.params))
+		assert.Equal(t, tc.ok, ok, "%v %v", tc.method, tc.params)
+		assert.Equal(t, tc.height, height, "%v %v", tc.method, tc.params)
+	}
+}
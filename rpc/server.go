@@ -1,6 +1,7 @@
 package rpc
 
 import (
+	"github.com/Conflux-Chain/go-conflux-util/viper"
 	infuraNode "github.com/scroll-tech/rpc-gateway/node"
 	"github.com/scroll-tech/rpc-gateway/rpc/handler"
 	"github.com/scroll-tech/rpc-gateway/util/rate"
@@ -36,7 +37,12 @@ func MustNewNativeSpaceServer(
 
 	middleware := httpMiddleware(rate.DefaultRegistryCfx, clientProvider)
 
-	return rpc.MustNewServer(nativeSpaceRpcServerName, exposedApis, middleware)
+	server := rpc.MustNewServer(
+		nativeSpaceRpcServerName, exposedApis, middleware, subscriptionQuotaMiddleware, debugAnnotationMiddleware,
+	)
+	mustEnableTls(server)
+
+	return server
 }
 
 // MustNewEvmSpaceServer new evm space RPC server by specifying router, and exposed modules.
@@ -45,8 +51,23 @@ func MustNewNativeSpaceServer(
 func MustNewEvmSpaceServer(
 	router infuraNode.Router, exposedModules []string, option ...EthAPIOption,
 ) *rpc.Server {
-	// retrieve all available evm space rpc apis
 	clientProvider := infuraNode.NewEthClientProvider(router)
+	return mustNewEvmSpaceServer(evmSpaceRpcServerName, clientProvider, exposedModules, option...)
+}
+
+// MustNewEvmChainServer new evm space RPC server for the specified extra chain, which has
+// independent node pools, routing, caching and metrics from the default chain.
+func MustNewEvmChainServer(
+	chain string, router infuraNode.Router, exposedModules []string, option ...EthAPIOption,
+) *rpc.Server {
+	clientProvider := infuraNode.NewChainEthClientProvider(router, chain)
+	return mustNewEvmSpaceServer(evmSpaceRpcServerName+"_"+chain, clientProvider, exposedModules, option...)
+}
+
+func mustNewEvmSpaceServer(
+	name string, clientProvider *infuraNode.EthClientProvider, exposedModules []string, option ...EthAPIOption,
+) *rpc.Server {
+	// retrieve all available evm space rpc apis
 	allApis, err := evmSpaceApis(clientProvider, option...)
 	if err != nil {
 		logrus.WithError(err).Fatal("Failed to new EVM space RPC server")
@@ -61,7 +82,21 @@ func MustNewEvmSpaceServer(
 
 	middleware := httpMiddleware(rate.DefaultRegistryEth, clientProvider)
 
-	return rpc.MustNewServer(evmSpaceRpcServerName, exposedApis, middleware)
+	server := rpc.MustNewServer(
+		name, exposedApis, middleware, subscriptionQuotaMiddleware, debugAnnotationMiddleware,
+		graphqlMiddleware(clientProvider), restMiddleware(),
+	)
+	mustEnableTls(server)
+
+	return server
+}
+
+// mustEnableTls serves public RPC server over TLS if configured, which optionally verifies
+// client certificates.
+func mustEnableTls(server *rpc.Server) {
+	var conf rpc.TlsServerConfig
+	viper.MustUnmarshalKey("rpc.tls", &conf)
+	server.MustEnableTls(&conf)
 }
 
 type CfxBridgeServerConfig struct {
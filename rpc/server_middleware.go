@@ -3,40 +3,84 @@ package rpc
 import (
 	"context"
 	"net/http"
+	"sync/atomic"
 
 	sdk "github.com/Conflux-Chain/go-conflux-sdk"
 	"github.com/openweb3/go-rpc-provider"
+	"github.com/pkg/errors"
 	"github.com/scroll-tech/rpc-gateway/node"
+	"github.com/scroll-tech/rpc-gateway/util/feature"
 	"github.com/scroll-tech/rpc-gateway/util/rate"
+	"github.com/scroll-tech/rpc-gateway/util/reload"
 	"github.com/scroll-tech/rpc-gateway/util/rpc/handlers"
 	"github.com/scroll-tech/rpc-gateway/util/rpc/middlewares"
-	"github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
 )
 
+// feature flags to gate routing behaviors
+const (
+	featureQuorum  = "quorum"
+	featureHedging = "hedging"
+	featureShadow  = "shadow"
+)
+
 const (
 	ctxKeyClientProvider = handlers.CtxKey("Infura-RPC-Client-Provider")
 	ctxKeyClient         = handlers.CtxKey("Infura-RPC-Client")
 )
 
+// loadBalancerMode is the routing mode for evm space RPC requests, which could be
+// changed at runtime.
+var loadBalancerMode atomic.Value
+
 // go-rpc-provider only supports static middlewares for RPC server.
 func init() {
 	viper.SetDefault("rpc.loadBalancerMode", "consistentHashing")
+	loadBalancerMode.Store(viper.GetString("rpc.loadBalancerMode"))
+
+	viper.SetDefault("rpc.ipv6PrefixLength", 64)
+	handlers.SetIPv6PrefixLength(viper.GetInt("rpc.ipv6PrefixLength"))
+
+	reload.Register("rpc_load_balancer", func() error {
+		loadBalancerMode.Store(viper.GetString("rpc.loadBalancerMode"))
+		return nil
+	})
+
 	// middlewares executed in order
 
 	// panic recovery
 	rpc.HookHandleCallMsg(middlewares.Recover)
 
-	// web3pay billing
-	if web3payClient, ok := middlewares.MustNewWeb3PayClient(); ok {
-		logrus.Info("Web3Pay billing RPC middleware enabled")
-		rpc.HookHandleCallMsg(middlewares.Billing(web3payClient))
-	}
+	// method aliasing and namespace rewriting before any method specific handling
+	rpc.HookHandleCallMsg(methodRewriteMiddleware)
+
+	// serving metadata for debugging
+	rpc.HookHandleCallMsg(servingTotalMiddleware)
+
+	// kill switches to disable methods gateway-wide during upstream incidents
+	rpc.HookHandleCallMsg(killSwitchMiddleware)
+
+	// web3pay billing, which is enabled once billing subsystem initialized, and bypassed in
+	// dry-run mode
+	rpc.HookHandleCallMsg(sandboxBilling(middlewares.GatedBilling))
+
+	// built-in API key validation, which is enabled once API key subsystem initialized
+	rpc.HookHandleCallMsg(middlewares.ApiKeyAuth)
+
+	// per API key usage analytics, which is enabled once usage subsystem initialized
+	rpc.HookHandleCallMsg(usageMiddleware)
+
+	// traffic anomaly detection with temporary stricter limits
+	rpc.HookHandleCallMsg(anomalyMiddleware)
 
 	// rate limit
 	rpc.HookHandleBatch(middlewares.RateLimitBatch)
 	rpc.HookHandleCallMsg(middlewares.RateLimit)
 
+	// gateway-wide and per method concurrency limits
+	rpc.HookHandleCallMsg(concurrencyMiddleware)
+	rpc.HookHandleCallMsg(responseSizeMiddleware)
+
 	// metrics
 	rpc.HookHandleBatch(middlewares.MetricsBatch)
 	rpc.HookHandleCallMsg(middlewares.Metrics)
@@ -45,9 +89,46 @@ func init() {
 	rpc.HookHandleBatch(middlewares.LogBatch)
 	rpc.HookHandleCallMsg(middlewares.Log)
 
+	// per method timeouts and client deadlines
+	rpc.HookHandleCallMsg(timeoutMiddleware)
+
+	// sample traffic into capture log for test fixtures
+	rpc.HookHandleCallMsg(captureMiddleware)
+
+	// routing experiments
+	rpc.HookHandleCallMsg(experimentMiddleware)
+
+	// resolve `safe` and `finalized` block tags before routing by height
+	rpc.HookHandleCallMsg(finalityTagMiddleware)
+
+	// static responses of chain constants if upstream nodes unavailable
+	rpc.HookHandleCallMsg(staticFallbackMiddleware)
+
 	// cfx/eth client
 	rpc.HookHandleCallMsg(clientMiddleware)
 
+	// canned responses in dry-run mode without touching upstream nodes
+	rpc.HookHandleCallMsg(dryRunMiddleware)
+
+	// fault injection per method and upstream node for resilience tests in staging
+	rpc.HookHandleCallMsg(chaosMiddleware)
+
+	// per node concurrency, upstream quota and serving metadata
+	rpc.HookHandleCallMsg(nodeConcurrencyMiddleware)
+	rpc.HookHandleCallMsg(upstreamQuotaMiddleware)
+	rpc.HookHandleCallMsg(servingUpstreamMiddleware)
+
+	// decode revert reasons for opted-in tenants, and consistent upstream errors regardless of
+	// execution clients
+	rpc.HookHandleCallMsg(revertReasonMiddleware)
+	rpc.HookHandleCallMsg(errorNormalizationMiddleware)
+
+	// adaptive log sampling per method and upstream node
+	rpc.HookHandleCallMsg(logSamplingMiddleware)
+
+	// slow query log per method and upstream node
+	rpc.HookHandleCallMsg(slowLogMiddleware)
+
 	// invalid json rpc request without `ID``
 	rpc.HookHandleCallMsg(rpc.PreventMessagesWithouID)
 }
@@ -56,56 +137,139 @@ func init() {
 func httpMiddleware(registry *rate.Registry, clientProvider interface{}) handlers.Middleware {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			ctx := r.Context()
+			ctx, cancel := clientDeadline(r.Context(), r)
+			defer cancel()
+
+			// rate limit headers in response
+			ctx, w = handlers.WithRateLimitFeedback(ctx, w, r)
+
+			// connections dropped by chaos injection
+			ctx, w = withChaosDrop(ctx, w, r)
 
 			if token := handlers.GetAccessToken(r); len(token) > 0 { // optional
 				ctx = context.WithValue(ctx, handlers.CtxAccessToken, token)
 			}
 
+			// regions forwarded through by peer gateways
+			ctx = withPeerVia(ctx, r)
+
+			if _, ok := clientProvider.(*node.EthClientProvider); ok {
+				ctx = withRouteHints(ctx, r)
+			}
+
+			ctx = withDryRun(ctx, r)
+
 			ctx = context.WithValue(ctx, handlers.CtxKeyRealIP, handlers.GetIPAddress(r))
 			ctx = context.WithValue(ctx, handlers.CtxKeyRateRegistry, registry)
 			ctx = context.WithValue(ctx, ctxKeyClientProvider, clientProvider)
 
+			if ethProvider, ok := clientProvider.(*node.EthClientProvider); ok && len(ethProvider.Chain()) > 0 {
+				ctx = context.WithValue(ctx, handlers.CtxKeyChain, ethProvider.Chain())
+			}
+
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
 
 func clientMiddleware(next rpc.HandleCallMsgFunc) rpc.HandleCallMsgFunc {
-	loadBalancerMode := viper.GetString("rpc.loadBalancerMode")
 	return func(ctx context.Context, msg *rpc.JsonRpcMessage) *rpc.JsonRpcMessage {
 		var client interface{}
 		var err error
 
 		if cfxProvider, ok := ctx.Value(ctxKeyClientProvider).(*node.CfxClientProvider); ok {
-			switch msg.Method {
-			case "cfx_getLogs":
-				client, err = cfxProvider.GetClientByIPGroup(ctx, node.GroupCfxLogs)
-			default:
-				client, err = cfxProvider.GetClientByIP(ctx)
-			}
-		} else if ethProvider, ok := ctx.Value(ctxKeyClientProvider).(*node.EthClientProvider); ok {
-			if (loadBalancerMode == "consistentHashing") {
+			group := node.Group(node.GroupCfxHttp)
+
+			if matched, routing, ok := node.MatchGroup("cfx", msg.Method); ok {
+				// config-driven node group
+				group = matched
+				if routing == node.RoutingRandom {
+					client, err = cfxProvider.GetClientRandomByGroup(group)
+				} else {
+					client, err = cfxProvider.GetClientByIPGroup(ctx, group)
+				}
+			} else {
 				switch msg.Method {
-				case "eth_getLogs":
-					client, err = ethProvider.GetClientByIPGroup(ctx, node.GroupEthLogs)
+				case "cfx_getLogs":
+					group = node.GroupCfxLogs
+					client, err = cfxProvider.GetClientByIPGroup(ctx, group)
 				default:
-					client, err = ethProvider.GetClientByIP(ctx)
+					client, err = cfxProvider.GetClientByIP(ctx)
 				}
+			}
+
+			// per group kill switch
+			if ksErr := defaultKillSwitches.check(msg.Method, group); ksErr != nil {
+				return msg.ErrorResponse(ksErr)
+			}
+		} else if ethProvider, ok := ctx.Value(ctxKeyClientProvider).(*node.EthClientProvider); ok {
+			arm := experimentArmFromContext(ctx) // nil if not in any experiment
+
+			route := decideEthRoute(ethProvider.Chain(), arm.loadBalancerMode(), msg)
+			route = tierEthRoute(route, msg, ethProvider)
+
+			// routing overrides of privileged API keys for debugging
+			hints, hinted := routeHintsFromContext(ctx)
+			if hinted {
+				route = overrideEthRoute(ctx, msg, route, hints)
+			}
+
+			group := route.Group
+			if hints.pinned() {
+				client, err = ethProvider.GetClientByNodeName(group, hints.Node)
 			} else {
-				switch msg.Method{
-				case "eth_getLogs":
-					client, err = ethProvider.GetClientRandomByGroup(node.GroupEthLogs)
-				default:
-					client, err = ethProvider.GetClientRandom()
+				client, err = route.client(ctx, ethProvider)
+			}
+
+			// per group kill switch
+			if ksErr := defaultKillSwitches.check(msg.Method, group); ksErr != nil {
+				return msg.ErrorResponse(ksErr)
+			}
+
+			// forward to peer gateway in other region if local node group unavailable
+			if err != nil && !hints.pinned() && !isDryRun(ctx) {
+				if resp, ok := forwardToPeer(ctx, msg, ethProvider.Chain()); ok {
+					return resp
 				}
 			}
+
+			// node group to reroute requests if necessary
+			ctx = context.WithValue(ctx, ctxKeyGroup, group)
+
+			// new routing behaviors are gated by feature flags for gradual rollout, and could
+			// be disabled by experiment arm for evaluation, and requests pinned to some node
+			// or in dry-run mode are never fanned out to others
+			fanOut := err == nil && !hints.pinned() && !isDryRun(ctx)
+
+			// aggregate fee estimates from multiple nodes unless cache bypassed
+			if conf, ok := shouldAggregateGas(msg); ok && fanOut && !cacheBypassed(ctx) {
+				return gasOracleCall(ctx, msg, next, conf, ethProvider, group, client.(*node.Web3goClient))
+			}
+
+			// read from multiple nodes and return the majority result
+			if policy, ok := shouldQuorum(msg); ok && fanOut && routingEnabled(ctx, arm, featureQuorum) {
+				return quorumCall(ctx, msg, next, policy, ethProvider, group, client.(*node.Web3goClient))
+			}
+
+			// hedge request to another node for slow node
+			if policy, ok := shouldHedge(msg.Method); ok && fanOut && routingEnabled(ctx, arm, featureHedging) {
+				return hedgeCall(ctx, msg, next, policy, ethProvider, group, client.(*node.Web3goClient))
+			}
+
+			// duplicate request to candidate node to diff responses
+			if policy, ok := shouldShadow(msg.Method); ok && fanOut && routingEnabled(ctx, arm, featureShadow) {
+				return shadowCall(ctx, msg, next, policy, client.(*node.Web3goClient))
+			}
 		} else {
 			return next(ctx, msg)
 		}
 
 		// no fullnode available to request RPC
 		if err != nil {
+			if errors.Cause(err) == node.ErrClientUnavailable {
+				markUpstreamUnavailable(ctx)
+			}
+
 			return msg.ErrorResponse(err)
 		}
 
@@ -115,6 +279,12 @@ func clientMiddleware(next rpc.HandleCallMsgFunc) rpc.HandleCallMsgFunc {
 	}
 }
 
+// routingEnabled checks if the routing behavior is enabled by feature flag and not disabled by
+// experiment arm.
+func routingEnabled(ctx context.Context, arm *experimentArm, behavior string) bool {
+	return feature.Enabled(ctx, behavior) && arm.allows(behavior)
+}
+
 func GetCfxClientFromContext(ctx context.Context) sdk.ClientOperator {
 	return ctx.Value(ctxKeyClient).(sdk.ClientOperator)
 }
@@ -0,0 +1,197 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"math/rand"
+	"reflect"
+	"sync/atomic"
+	"time"
+
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/pkg/errors"
+	"github.com/scroll-tech/rpc-gateway/node"
+	"github.com/scroll-tech/rpc-gateway/util/metrics"
+	"github.com/scroll-tech/rpc-gateway/util/reload"
+	rpcutil "github.com/scroll-tech/rpc-gateway/util/rpc"
+	"github.com/sirupsen/logrus"
+)
+
+// maxShadowLogResultLen is the max length of result logged for diverged shadow response.
+const maxShadowLogResultLen = 1024
+
+// ShadowConfig configurations of shadow traffic, which duplicates a percentage of traffic
+// to a candidate node (e.g. a new client version) and diffs the responses, without affecting
+// the answer returned to user.
+type ShadowConfig struct {
+	Enabled bool
+	// candidate node URL
+	URL string
+	// percentage of traffic to duplicate, in range [0, 100]
+	Percentage float64
+	// methods to shadow, and all methods without side effects if empty
+	Methods []string
+	// max number of concurrent shadow requests, and more will be dropped
+	MaxConcurrency int `default:"64"`
+}
+
+type shadowPolicy struct {
+	ShadowConfig
+	methods map[string]bool
+	client  *node.Web3goClient
+	sem     chan struct{}
+}
+
+// shadow is the shadow traffic policy in use, which could be changed at runtime.
+var shadow atomic.Value
+
+func init() {
+	policy, err := loadShadowPolicy()
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to load shadow traffic config")
+	}
+
+	shadow.Store(policy)
+
+	reload.Register("rpc_shadow", func() error {
+		policy, err := loadShadowPolicy()
+		if err != nil {
+			return err
+		}
+
+		shadow.Store(policy)
+		return nil
+	})
+}
+
+func loadShadowPolicy() (*shadowPolicy, error) {
+	var conf ShadowConfig
+	if err := viper.UnmarshalKey("ethrpc.shadow", &conf); err != nil {
+		return nil, err
+	}
+
+	policy := shadowPolicy{ShadowConfig: conf, methods: make(map[string]bool)}
+	if !conf.Enabled {
+		return &policy, nil
+	}
+
+	if conf.MaxConcurrency <= 0 {
+		return nil, errors.Errorf("invalid shadow traffic max concurrency %v", conf.MaxConcurrency)
+	}
+
+	client, err := rpcutil.NewEthClient(conf.URL)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to connect to shadow candidate node")
+	}
+
+	policy.client = &node.Web3goClient{Client: client, URL: conf.URL}
+	policy.sem = make(chan struct{}, conf.MaxConcurrency)
+
+	for _, m := range conf.Methods {
+		policy.methods[m] = true
+	}
+
+	return &policy, nil
+}
+
+// shouldShadow checks if the RPC request should be duplicated to the candidate node.
+func shouldShadow(method string) (*shadowPolicy, bool) {
+	policy := shadow.Load().(*shadowPolicy)
+	if !policy.Enabled || hedgingUnsafeMethods[method] {
+		return policy, false
+	}
+
+	if len(policy.methods) > 0 && !policy.methods[method] {
+		return policy, false
+	}
+
+	return policy, rand.Float64()*100 < policy.Percentage
+}
+
+// shadowCall handles RPC call with the primary client, and duplicates the request to the
+// candidate node asynchronously to diff responses.
+func shadowCall(
+	ctx context.Context,
+	msg *rpc.JsonRpcMessage,
+	next rpc.HandleCallMsgFunc,
+	policy *shadowPolicy,
+	primary *node.Web3goClient,
+) *rpc.JsonRpcMessage {
+	start := time.Now()
+	resp := next(context.WithValue(ctx, ctxKeyClient, primary), msg)
+	elapsed := time.Since(start)
+
+	select {
+	case policy.sem <- struct{}{}:
+	default: // too many shadow requests in flight
+		metrics.Registry.RPC.Percentage(msg.Method, "shadow/dropped").Mark(true)
+		return resp
+	}
+
+	metrics.Registry.RPC.Percentage(msg.Method, "shadow/dropped").Mark(false)
+
+	go func() {
+		defer func() { <-policy.sem }()
+
+		// shadow request should not be canceled along with user request
+		shadowCtx := context.WithValue(detachedContext{ctx}, ctxKeyClient, policy.client)
+
+		shadowStart := time.Now()
+		shadowResp := next(shadowCtx, msg)
+		metrics.Registry.RPC.ShadowLatencyDiff(msg.Method).Update(time.Since(shadowStart).Nanoseconds() - elapsed.Nanoseconds())
+
+		diverged := !isSameJsonRpcResponse(resp, shadowResp)
+		metrics.Registry.RPC.Percentage(msg.Method, "shadow/diverged").Mark(diverged)
+
+		if diverged {
+			logrus.WithFields(logrus.Fields{
+				"method":    msg.Method,
+				"params":    string(msg.Params),
+				"primary":   rpcutil.Url2NodeName(primary.URL),
+				"candidate": rpcutil.Url2NodeName(policy.client.URL),
+				"expected":  truncateShadowResult(resp),
+				"actual":    truncateShadowResult(shadowResp),
+			}).Warn("Shadow response diverged from the primary node")
+		}
+	}()
+
+	return resp
+}
+
+// isSameJsonRpcResponse checks if the two responses are semantically equal.
+func isSameJsonRpcResponse(r1, r2 *rpc.JsonRpcMessage) bool {
+	if (r1.Error == nil) != (r2.Error == nil) {
+		return false
+	}
+
+	if r1.Error != nil {
+		return quorumVoteKey(r1) == quorumVoteKey(r2)
+	}
+
+	var v1, v2 interface{}
+	if json.Unmarshal(r1.Result, &v1) != nil || json.Unmarshal(r2.Result, &v2) != nil {
+		return string(r1.Result) == string(r2.Result)
+	}
+
+	return reflect.DeepEqual(v1, v2)
+}
+
+func truncateShadowResult(resp *rpc.JsonRpcMessage) string {
+	result := quorumVoteKey(resp)
+	if len(result) > maxShadowLogResultLen {
+		return result[:maxShadowLogResultLen] + "..."
+	}
+
+	return result
+}
+
+// detachedContext keeps values of the parent context, but never canceled.
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool)         { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}               { return nil }
+func (detachedContext) Err() error                          { return nil }
+func (c detachedContext) Value(key interface{}) interface{} { return c.parent.Value(key) }
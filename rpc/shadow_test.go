@@ -0,0 +1,17 @@
+package rpc
+
+import (
+	"testing"
+
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsSameJsonRpcResponse(t *testing.T) {
+	r1 := &rpc.JsonRpcMessage{Result: []byte(`{"a":1,"b":"0x2"}`)}
+	r2 := &rpc.JsonRpcMessage{Result: []byte(`{"b":"0x2", "a":1}`)}
+	assert.True(t, isSameJsonRpcResponse(r1, r2))
+
+	r3 := &rpc.JsonRpcMessage{Result: []byte(`{"a":1,"b":"0x3"}`)}
+	assert.False(t, isSameJsonRpcResponse(r1, r3))
+}
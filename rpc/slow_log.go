@@ -0,0 +1,64 @@
+package rpc
+
+import (
+	"context"
+	"time"
+
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/scroll-tech/rpc-gateway/rpc/slowlog"
+	"github.com/sirupsen/logrus"
+)
+
+// slowLog records requests exceeding latency or response size threshold, which could be
+// analyzed by the administrative RPC `admin_slowQueries`.
+var slowLog *slowlog.Log
+
+func init() {
+	var conf slowlog.Config
+	viper.MustUnmarshalKey("rpc.slowLog", &conf)
+
+	var err error
+	if slowLog, err = slowlog.New(conf); err != nil {
+		logrus.WithError(err).Fatal("Failed to init slow log")
+	}
+}
+
+func slowLogMiddleware(next rpc.HandleCallMsgFunc) rpc.HandleCallMsgFunc {
+	return func(ctx context.Context, msg *rpc.JsonRpcMessage) *rpc.JsonRpcMessage {
+		if !slowLog.Enabled() {
+			return next(ctx, msg)
+		}
+
+		start := time.Now()
+		resp := next(ctx, msg)
+		duration := time.Since(start)
+
+		var size int
+		if resp != nil {
+			size = len(resp.Result)
+		}
+
+		if !slowLog.Heavy(duration, size) {
+			return resp
+		}
+
+		entry := slowlog.Entry{
+			Time:     start,
+			Method:   msg.Method,
+			Params:   slowLog.Summarize(msg.Params),
+			Duration: duration,
+			Size:     size,
+		}
+
+		entry.Node, _ = servingNodeName(ctx)
+
+		if resp != nil && resp.Error != nil {
+			entry.ErrorCode = resp.Error.Code
+		}
+
+		slowLog.Record(&entry)
+
+		return resp
+	}
+}
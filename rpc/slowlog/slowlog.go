@@ -0,0 +1,258 @@
+// Package slowlog records heavy RPC requests, which exceed latency or response size threshold,
+// into a rotating slow log, and analyzes the top offenders over a recent window.
+package slowlog
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// Config configurations of slow query log.
+type Config struct {
+	Enabled bool
+	// requests slower than the threshold are recorded, and 0 to disable
+	Latency time.Duration `default:"3s"`
+	// requests with response larger than the threshold in bytes are recorded, and 0 to disable
+	ResponseSize int `default:"1048576"`
+	// slow log file, which is appended in JSON lines
+	Path string `default:"slow.jsonl"`
+	// max size of slow log file in bytes before rotated
+	MaxFileSize int64 `default:"104857600"`
+	// max number of rotated files to retain, e.g. `slow.jsonl.1`
+	MaxBackups int `default:"5"`
+	// max length of params summary
+	ParamsLimit int `default:"256"`
+	// max number of entries pending to write, and more will be dropped
+	BufferSize int `default:"1024"`
+	// max number of recent entries retained in memory for analysis
+	MaxEntries int `default:"10000"`
+}
+
+// Entry is a heavy request recorded in slow log.
+type Entry struct {
+	Time      time.Time     `json:"time"`
+	Method    string        `json:"method"`
+	Params    string        `json:"params,omitempty"` // summary of params
+	Node      string        `json:"node,omitempty"`
+	Duration  time.Duration `json:"duration"`
+	Size      int           `json:"size"`
+	ErrorCode int           `json:"errorCode,omitempty"`
+}
+
+// Offender is the aggregated heavy requests by method and node.
+type Offender struct {
+	Method        string        `json:"method"`
+	Node          string        `json:"node,omitempty"`
+	Count         int           `json:"count"`
+	TotalDuration time.Duration `json:"totalDuration"`
+	MaxDuration   time.Duration `json:"maxDuration"`
+	MaxSize       int           `json:"maxSize"`
+	// params summary of the slowest request
+	SlowestParams string `json:"slowestParams,omitempty"`
+}
+
+// Log records heavy requests into rotating slow log asynchronously, and retains recent
+// entries in memory for analysis.
+type Log struct {
+	conf    Config
+	entries chan *Entry
+	once    sync.Once
+
+	mu     sync.Mutex
+	recent []*Entry // ring buffer
+	next   int      // next position to overwrite once full
+}
+
+func New(conf Config) (*Log, error) {
+	if conf.BufferSize <= 0 || conf.MaxEntries <= 0 {
+		return nil, errors.Errorf("invalid slow log buffer size %v or max entries %v", conf.BufferSize, conf.MaxEntries)
+	}
+
+	return &Log{
+		conf:    conf,
+		entries: make(chan *Entry, conf.BufferSize),
+	}, nil
+}
+
+// Enabled checks if slow log is enabled.
+func (l *Log) Enabled() bool {
+	return l.conf.Enabled
+}
+
+// Heavy checks if the request exceeds latency or response size threshold.
+func (l *Log) Heavy(duration time.Duration, size int) bool {
+	return (l.conf.Latency > 0 && duration >= l.conf.Latency) ||
+		(l.conf.ResponseSize > 0 && size >= l.conf.ResponseSize)
+}
+
+// Summarize returns the params summary truncated to the params limit.
+func (l *Log) Summarize(params json.RawMessage) string {
+	if len(params) <= l.conf.ParamsLimit {
+		return string(params)
+	}
+
+	return fmt.Sprintf("%v...(%v bytes)", string(params[:l.conf.ParamsLimit]), len(params))
+}
+
+// Record records the heavy request, and drops it from slow log file if too many pending.
+func (l *Log) Record(entry *Entry) {
+	l.mu.Lock()
+	if len(l.recent) < l.conf.MaxEntries {
+		l.recent = append(l.recent, entry)
+	} else {
+		l.recent[l.next] = entry
+		l.next = (l.next + 1) % l.conf.MaxEntries
+	}
+	l.mu.Unlock()
+
+	// file opened lazily upon the first heavy request
+	l.once.Do(func() {
+		go l.loop()
+	})
+
+	select {
+	case l.entries <- entry:
+	default:
+		logrus.WithField("method", entry.Method).Debug("Slow log entry dropped due to too many pending")
+	}
+}
+
+// Top returns the top offenders within the window, ordered by total duration desc.
+func (l *Log) Top(window time.Duration, limit int) []*Offender {
+	since := time.Now().Add(-window)
+	offenders := make(map[string]*Offender)
+
+	l.mu.Lock()
+	for _, e := range l.recent {
+		if e.Time.Before(since) {
+			continue
+		}
+
+		key := e.Method + "@" + e.Node
+		o, ok := offenders[key]
+		if !ok {
+			o = &Offender{Method: e.Method, Node: e.Node}
+			offenders[key] = o
+		}
+
+		o.Count++
+		o.TotalDuration += e.Duration
+
+		if e.Duration > o.MaxDuration {
+			o.MaxDuration = e.Duration
+			o.SlowestParams = e.Params
+		}
+
+		if e.Size > o.MaxSize {
+			o.MaxSize = e.Size
+		}
+	}
+	l.mu.Unlock()
+
+	result := make([]*Offender, 0, len(offenders))
+	for _, o := range offenders {
+		result = append(result, o)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].TotalDuration > result[j].TotalDuration
+	})
+
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+
+	return result
+}
+
+func (l *Log) loop() {
+	file, size, err := l.open()
+	if err != nil {
+		logrus.WithError(err).WithField("path", l.conf.Path).Error("Failed to open slow log")
+		return
+	}
+
+	writer := bufio.NewWriter(file)
+	defer func() {
+		writer.Flush()
+		file.Close()
+	}()
+
+	for entry := range l.entries {
+		data, err := json.Marshal(entry)
+		if err != nil {
+			logrus.WithError(err).Debug("Failed to encode slow log entry")
+			continue
+		}
+
+		if l.conf.MaxFileSize > 0 && size+int64(len(data))+1 > l.conf.MaxFileSize && size > 0 {
+			writer.Flush()
+			file.Close()
+
+			if file, err = l.rotate(); err != nil {
+				logrus.WithError(err).WithField("path", l.conf.Path).Error("Failed to rotate slow log")
+				return
+			}
+
+			writer.Reset(file)
+			size = 0
+		}
+
+		writer.Write(data)
+		writer.WriteByte('\n')
+		size += int64(len(data)) + 1
+
+		// flush once no more entries pending
+		if len(l.entries) == 0 {
+			if err := writer.Flush(); err != nil {
+				logrus.WithError(err).Warn("Failed to flush slow log")
+			}
+		}
+	}
+}
+
+// open opens the slow log file for appending, and returns the current file size.
+func (l *Log) open() (*os.File, int64, error) {
+	file, err := os.OpenFile(l.conf.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, 0, err
+	}
+
+	return file, info.Size(), nil
+}
+
+// rotate shifts the rotated files, e.g. `slow.jsonl.1` to `slow.jsonl.2`, and reopens a new
+// slow log file.
+func (l *Log) rotate() (*os.File, error) {
+	if l.conf.MaxBackups > 0 {
+		for i := l.conf.MaxBackups - 1; i > 0; i-- {
+			src := fmt.Sprintf("%v.%v", l.conf.Path, i)
+			if _, err := os.Stat(src); err == nil {
+				os.Rename(src, fmt.Sprintf("%v.%v", l.conf.Path, i+1))
+			}
+		}
+
+		if err := os.Rename(l.conf.Path, l.conf.Path+".1"); err != nil {
+			return nil, err
+		}
+	} else if err := os.Remove(l.conf.Path); err != nil {
+		return nil, err
+	}
+
+	file, _, err := l.open()
+	return file, err
+}
@@ -0,0 +1,32 @@
+package slowlog
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTopOffenders(t *testing.T) {
+	l, err := New(Config{Latency: time.Second, BufferSize: 1, MaxEntries: 3, ParamsLimit: 4})
+	assert.Nil(t, err)
+
+	assert.True(t, l.Heavy(time.Second, 0))
+	assert.False(t, l.Heavy(time.Millisecond, 100))
+	assert.Equal(t, `["0x...(8 bytes)`, l.Summarize([]byte(`["0x12"]`)))
+
+	now := time.Now()
+	l.recent = []*Entry{
+		{Time: now.Add(-2 * time.Hour), Method: "eth_call", Duration: time.Minute},
+		{Time: now, Method: "eth_getLogs", Params: "a", Duration: time.Second},
+		{Time: now, Method: "eth_getLogs", Params: "b", Duration: 3 * time.Second, Size: 10},
+	}
+
+	top := l.Top(time.Hour, 10)
+	assert.Equal(t, 1, len(top))
+	assert.Equal(t, "eth_getLogs", top[0].Method)
+	assert.Equal(t, 2, top[0].Count)
+	assert.Equal(t, 4*time.Second, top[0].TotalDuration)
+	assert.Equal(t, "b", top[0].SlowestParams)
+	assert.Equal(t, 10, top[0].MaxSize)
+}
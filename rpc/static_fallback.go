@@ -0,0 +1,160 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"regexp"
+	"sync/atomic"
+
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/pkg/errors"
+	"github.com/scroll-tech/rpc-gateway/node"
+	"github.com/scroll-tech/rpc-gateway/util/metrics"
+	"github.com/scroll-tech/rpc-gateway/util/reload"
+	"github.com/scroll-tech/rpc-gateway/util/rpc/handlers"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// ctxKeyUpstreamUnavailable marks that none upstream node available to serve the request.
+	ctxKeyUpstreamUnavailable = handlers.CtxKey("Infura-RPC-Upstream-Unavailable")
+
+	// errCodeDefault is the JSON-RPC error code of errors without code, e.g. transport errors.
+	errCodeDefault = -32000
+)
+
+// unreachableMessagePattern matches messages of transport or connection errors to request
+// upstream nodes, which are answered with the default error code.
+var unreachableMessagePattern = regexp.MustCompile(
+	`(?i)(connection refused|connection reset by peer|no such host|network is unreachable|no route to host|broken pipe|use of closed network connection|client is closed)`,
+)
+
+// StaticResponseConfig static response of RPC method that returns immutable chain constant.
+type StaticResponseConfig struct {
+	Method string
+	// result of the method, e.g. `0x82750` for `eth_chainId`
+	Result string
+	// extra evm chain of the response, empty means the default evm space chain
+	Chain string
+}
+
+// StaticFallbackConfig configurations to answer immutable chain constants from static responses
+// when upstream nodes unavailable, so that connectivity probes of clients don't fail during
+// upstream outages. Note, it only applies to evm space.
+type StaticFallbackConfig struct {
+	Enabled   bool
+	Responses []StaticResponseConfig
+
+	results map[string]json.RawMessage // chain/method => result
+}
+
+// staticFallbacks is the static fallback config in use, which could be changed at runtime.
+var staticFallbacks atomic.Value
+
+func init() {
+	conf, err := loadStaticFallbackConfig()
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to load static fallback config")
+	}
+
+	staticFallbacks.Store(conf)
+
+	reload.Register("rpc_static_fallback", func() error {
+		conf, err := loadStaticFallbackConfig()
+		if err != nil {
+			return err
+		}
+
+		staticFallbacks.Store(conf)
+		return nil
+	})
+}
+
+func loadStaticFallbackConfig() (*StaticFallbackConfig, error) {
+	var conf StaticFallbackConfig
+	if err := viper.UnmarshalKey("rpc.staticFallback", &conf); err != nil {
+		return nil, err
+	}
+
+	conf.results = make(map[string]json.RawMessage, len(conf.Responses))
+
+	for _, sr := range conf.Responses {
+		if len(sr.Method) == 0 || len(sr.Result) == 0 {
+			return nil, errors.Errorf("invalid static response %+v", sr)
+		}
+
+		result, err := json.Marshal(sr.Result)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "failed to marshal static response of %v", sr.Method)
+		}
+
+		conf.results[sr.Chain+"/"+sr.Method] = result
+	}
+
+	return &conf, nil
+}
+
+// result returns the static response of the specified method on chain if any.
+func (conf *StaticFallbackConfig) result(chain, method string) (json.RawMessage, bool) {
+	result, ok := conf.results[chain+"/"+method]
+	return result, ok
+}
+
+// markUpstreamUnavailable marks that none upstream node available to serve the request if
+// static fallback applies.
+func markUpstreamUnavailable(ctx context.Context) {
+	if unavailable, ok := ctx.Value(ctxKeyUpstreamUnavailable).(*bool); ok {
+		*unavailable = true
+	}
+}
+
+// isUpstreamUnreachable checks if the error response indicates that none upstream node reachable,
+// e.g. none node available or connection failure, while any other error, e.g. kill switch, rate
+// limit, timeout or invalid params, is not.
+func isUpstreamUnreachable(unavailable bool, resp *rpc.JsonRpcMessage) bool {
+	if unavailable {
+		return true
+	}
+
+	return resp.Error.Code == errCodeDefault && unreachableMessagePattern.MatchString(resp.Error.Message)
+}
+
+// staticFallbackMiddleware answers immutable chain constants from static responses if none
+// upstream node reachable, e.g. none node available or connection failure.
+func staticFallbackMiddleware(next rpc.HandleCallMsgFunc) rpc.HandleCallMsgFunc {
+	return func(ctx context.Context, msg *rpc.JsonRpcMessage) *rpc.JsonRpcMessage {
+		conf := staticFallbacks.Load().(*StaticFallbackConfig)
+		if !conf.Enabled {
+			return next(ctx, msg)
+		}
+
+		if _, ok := ctx.Value(ctxKeyClientProvider).(*node.EthClientProvider); !ok {
+			return next(ctx, msg)
+		}
+
+		chain, _ := ctx.Value(handlers.CtxKeyChain).(string)
+
+		result, ok := conf.result(chain, msg.Method)
+		if !ok {
+			return next(ctx, msg)
+		}
+
+		var unavailable bool
+		resp := next(context.WithValue(ctx, ctxKeyUpstreamUnavailable, &unavailable), msg)
+		if resp == nil || resp.Error == nil || !isUpstreamUnreachable(unavailable, resp) {
+			return resp
+		}
+
+		metrics.Registry.RPC.StaticFallback(msg.Method).Mark(1)
+
+		logrus.WithFields(logrus.Fields{
+			"chain": chain, "method": msg.Method, "err": resp.Error,
+		}).Debug("RPC answered with static fallback response")
+
+		resp.Error = nil
+		resp.Result = result
+
+		return resp
+	}
+}
@@ -0,0 +1,62 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/scroll-tech/rpc-gateway/node"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStaticFallbackMiddleware(t *testing.T) {
+	defer staticFallbacks.Store(staticFallbacks.Load())
+
+	staticFallbacks.Store(&StaticFallbackConfig{
+		Enabled: true,
+		results: map[string]json.RawMessage{"/eth_chainId": json.RawMessage(`"0x82750"`)},
+	})
+
+	ctx := context.WithValue(context.Background(), ctxKeyClientProvider, (*node.EthClientProvider)(nil))
+	msg := &rpc.JsonRpcMessage{Version: "2.0", ID: json.RawMessage("1"), Method: "eth_chainId"}
+
+	call := func(next rpc.HandleCallMsgFunc) *rpc.JsonRpcMessage {
+		return staticFallbackMiddleware(next)(ctx, msg)
+	}
+
+	// none node available
+	resp := call(func(ctx context.Context, msg *rpc.JsonRpcMessage) *rpc.JsonRpcMessage {
+		markUpstreamUnavailable(ctx)
+		return msg.ErrorResponse(node.ErrClientUnavailable)
+	})
+	assert.Nil(t, resp.Error)
+	assert.Equal(t, json.RawMessage(`"0x82750"`), resp.Result)
+
+	tests := []struct {
+		err      *rpc.JsonError
+		fallback bool
+	}{
+		// transport errors
+		{&rpc.JsonError{Code: -32000, Message: `Post "http://127.0.0.1:8545": dial tcp 127.0.0.1:8545: connect: connection refused`}, true},
+		{&rpc.JsonError{Code: -32000, Message: "bad full node connection: dial tcp: lookup node: no such host"}, true},
+		// errors that should never be masked
+		{&rpc.JsonError{Code: errCodeMaintenance, Message: "method eth_chainId is under maintenance"}, false},
+		{&rpc.JsonError{Code: -32005, Message: "too many requests"}, false},
+		{&rpc.JsonError{Code: -32000, Message: "context deadline exceeded"}, false},
+		{&rpc.JsonError{Code: -32602, Message: "too many arguments, want at most 0"}, false},
+	}
+
+	for _, tt := range tests {
+		resp := call(func(ctx context.Context, msg *rpc.JsonRpcMessage) *rpc.JsonRpcMessage {
+			return &rpc.JsonRpcMessage{Version: msg.Version, ID: msg.ID, Error: tt.err}
+		})
+
+		if tt.fallback {
+			assert.Nil(t, resp.Error, tt.err.Message)
+			assert.Equal(t, json.RawMessage(`"0x82750"`), resp.Result)
+		} else {
+			assert.Equal(t, tt.err, resp.Error)
+		}
+	}
+}
@@ -0,0 +1,275 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/scroll-tech/rpc-gateway/util/apikey"
+	"github.com/scroll-tech/rpc-gateway/util/rate"
+	"github.com/scroll-tech/rpc-gateway/util/reload"
+	"github.com/scroll-tech/rpc-gateway/util/rpc/handlers"
+	"github.com/sirupsen/logrus"
+)
+
+// errCodeQuotaExceeded is the JSON-RPC error code for requests exceeding subscription quotas.
+const errCodeQuotaExceeded = -32005
+
+// kinds of subscription quota
+const (
+	quotaConnections   = "connections"
+	quotaSubscriptions = "subscriptions"
+)
+
+// SubscriptionQuotaTier websocket quotas of API keys bound to the rate limit strategy.
+type SubscriptionQuotaTier struct {
+	// rate limit strategy name bound to API keys
+	Strategy string
+	// max number of concurrent websocket connections, and 0 for unlimited
+	MaxConnections int
+	// max number of active subscriptions, and 0 for unlimited
+	MaxSubscriptions int
+}
+
+// SubscriptionQuotaConfig configurations of per API key websocket connection and subscription
+// quotas, which could be configured by tier, namely the bound rate limit strategy.
+type SubscriptionQuotaConfig struct {
+	Enabled bool
+	// default max number of concurrent websocket connections, and 0 for unlimited
+	MaxConnections int `default:"10"`
+	// default max number of active subscriptions, and 0 for unlimited
+	MaxSubscriptions int `default:"100"`
+	// quotas by tier, which override the default ones
+	Tiers []SubscriptionQuotaTier
+}
+
+// limits returns the quotas of the specified tier.
+func (conf *SubscriptionQuotaConfig) limits(tier string) (maxConnections, maxSubscriptions int) {
+	for _, t := range conf.Tiers {
+		if len(tier) > 0 && t.Strategy == tier {
+			return t.MaxConnections, t.MaxSubscriptions
+		}
+	}
+
+	return conf.MaxConnections, conf.MaxSubscriptions
+}
+
+// SubscriptionUsage is the current websocket usage of API key along with quotas.
+type SubscriptionUsage struct {
+	Key              string `json:"key"`
+	Tier             string `json:"tier,omitempty"`
+	Connections      int    `json:"connections"`
+	MaxConnections   int    `json:"maxConnections"`
+	Subscriptions    int    `json:"subscriptions"`
+	MaxSubscriptions int    `json:"maxSubscriptions"`
+}
+
+// subscriptionQuotaError is returned for requests exceeding subscription quotas.
+type subscriptionQuotaError struct {
+	quota string
+	limit int
+	tier  string
+}
+
+func (e *subscriptionQuotaError) Error() string {
+	return fmt.Sprintf("quota exceeded, max %v %v allowed per API key", e.limit, e.quota)
+}
+
+func (e *subscriptionQuotaError) ErrorCode() int { return errCodeQuotaExceeded }
+
+func (e *subscriptionQuotaError) ErrorData() interface{} {
+	data := map[string]interface{}{
+		"quota": e.quota,
+		"limit": e.limit,
+	}
+
+	if len(e.tier) > 0 {
+		data["tier"] = e.tier
+	}
+
+	return data
+}
+
+// subscriptionQuotas tracks websocket connections and subscriptions per API key.
+type subscriptionQuotas struct {
+	mu     sync.Mutex
+	usages map[string]*SubscriptionUsage // API key => usage
+}
+
+func newSubscriptionQuotas() *subscriptionQuotas {
+	return &subscriptionQuotas{usages: make(map[string]*SubscriptionUsage)}
+}
+
+// acquire reserves a connection or subscription of API key, and returns the release function.
+func (q *subscriptionQuotas) acquire(
+	conf *SubscriptionQuotaConfig, key, tier, quota string,
+) (func(), error) {
+	maxConnections, maxSubscriptions := conf.limits(tier)
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	usage, ok := q.usages[key]
+	if !ok {
+		usage = &SubscriptionUsage{Key: key}
+		q.usages[key] = usage
+	}
+
+	usage.Tier, usage.MaxConnections, usage.MaxSubscriptions = tier, maxConnections, maxSubscriptions
+
+	counter, limit := &usage.Connections, maxConnections
+	if quota == quotaSubscriptions {
+		counter, limit = &usage.Subscriptions, maxSubscriptions
+	}
+
+	if limit > 0 && *counter >= limit {
+		return nil, &subscriptionQuotaError{quota, limit, tier}
+	}
+
+	*counter++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			q.mu.Lock()
+			defer q.mu.Unlock()
+
+			*counter--
+			q.removeIdle(usage)
+		})
+	}, nil
+}
+
+// removeIdle removes usage without any connection or subscription, which should be called
+// with lock held.
+func (q *subscriptionQuotas) removeIdle(usage *SubscriptionUsage) {
+	if usage.Connections == 0 && usage.Subscriptions == 0 {
+		delete(q.usages, usage.Key)
+	}
+}
+
+// snapshot returns the current usages of all API keys, or the specified one if any.
+func (q *subscriptionQuotas) snapshot(key string) []SubscriptionUsage {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var usages []SubscriptionUsage
+	for k, usage := range q.usages {
+		if len(key) == 0 || k == key {
+			usages = append(usages, *usage)
+		}
+	}
+
+	sort.Slice(usages, func(i, j int) bool {
+		return usages[i].Key < usages[j].Key
+	})
+
+	return usages
+}
+
+var (
+	// subscriptionQuota is the subscription quotas config in use, which could be changed
+	// at runtime.
+	subscriptionQuota atomic.Value
+
+	defaultSubscriptionQuotas = newSubscriptionQuotas()
+)
+
+func init() {
+	conf, err := loadSubscriptionQuotaConfig()
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to load subscription quotas config")
+	}
+
+	subscriptionQuota.Store(conf)
+
+	reload.Register("rpc_subscription_quota", func() error {
+		conf, err := loadSubscriptionQuotaConfig()
+		if err != nil {
+			return err
+		}
+
+		subscriptionQuota.Store(conf)
+		return nil
+	})
+}
+
+func loadSubscriptionQuotaConfig() (*SubscriptionQuotaConfig, error) {
+	var conf SubscriptionQuotaConfig
+	if err := viper.UnmarshalKey("rpc.subscriptionQuota", &conf); err != nil {
+		return nil, err
+	}
+
+	return &conf, nil
+}
+
+// subscriptionTier returns the tier of API key, namely the name of bound rate limit strategy.
+func subscriptionTier(ctx context.Context, key string) string {
+	registry, ok := ctx.Value(handlers.CtxKeyRateRegistry).(*rate.Registry)
+	if !ok {
+		return ""
+	}
+
+	if m, ok := apikey.Default(); ok {
+		if k, err := m.Validate(key); err == nil {
+			if s, ok := registry.Strategy(k.SID); ok {
+				return s.Name
+			}
+		}
+	}
+
+	if s, ok := registry.KeyStrategy(key); ok {
+		return s.Name
+	}
+
+	return ""
+}
+
+// acquireQuota reserves a connection or subscription of API key in context if any, and returns
+// the release function.
+func acquireQuota(ctx context.Context, quota string) (func(), error) {
+	conf := subscriptionQuota.Load().(*SubscriptionQuotaConfig)
+	if !conf.Enabled {
+		return func() {}, nil
+	}
+
+	key, ok := handlers.GetAccessTokenFromContext(ctx)
+	if !ok {
+		return func() {}, nil
+	}
+
+	return defaultSubscriptionQuotas.acquire(conf, key, subscriptionTier(ctx, key), quota)
+}
+
+// acquireSubscriptionQuota reserves an active subscription of API key in context if any, and
+// returns the release function, which should be called once subscription terminated.
+func acquireSubscriptionQuota(ctx context.Context) (func(), error) {
+	return acquireQuota(ctx, quotaSubscriptions)
+}
+
+// subscriptionQuotaMiddleware limits concurrent websocket connections per API key, which
+// rejects the websocket handshake if quota exceeded. Note, it should be applied after HTTP
+// middleware to inject context values.
+func subscriptionQuotaMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.Header.Get("Upgrade")) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		release, err := acquireQuota(r.Context(), quotaConnections)
+		if err != nil {
+			handlers.WriteJsonRpcError(w, http.StatusTooManyRequests, errCodeQuotaExceeded, err.Error())
+			return
+		}
+
+		// websocket handler returns once connection closed
+		defer release()
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,46 @@
+package rpc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubscriptionQuotas(t *testing.T) {
+	conf := SubscriptionQuotaConfig{
+		Enabled:          true,
+		MaxConnections:   1,
+		MaxSubscriptions: 2,
+		Tiers:            []SubscriptionQuotaTier{{Strategy: "vip", MaxConnections: 2, MaxSubscriptions: 0}},
+	}
+
+	quotas := newSubscriptionQuotas()
+
+	release, err := quotas.acquire(&conf, "key1", "", quotaConnections)
+	assert.Nil(t, err)
+
+	_, err = quotas.acquire(&conf, "key1", "", quotaConnections)
+	assert.Equal(t, &subscriptionQuotaError{quotaConnections, 1, ""}, err)
+
+	// tier quotas
+	for i := 0; i < 2; i++ {
+		_, err = quotas.acquire(&conf, "key2", "vip", quotaConnections)
+		assert.Nil(t, err)
+	}
+
+	for i := 0; i < 10; i++ {
+		_, err = quotas.acquire(&conf, "key2", "vip", quotaSubscriptions)
+		assert.Nil(t, err)
+	}
+
+	usages := quotas.snapshot("key2")
+	assert.Equal(t, []SubscriptionUsage{{"key2", "vip", 2, 2, 10, 0}}, usages)
+
+	// released twice by mistake
+	release()
+	release()
+	assert.Empty(t, quotas.snapshot("key1"))
+
+	_, err = quotas.acquire(&conf, "key1", "", quotaConnections)
+	assert.Nil(t, err)
+}
@@ -0,0 +1,181 @@
+package rpc
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/pkg/errors"
+	"github.com/scroll-tech/rpc-gateway/util/metrics"
+	"github.com/scroll-tech/rpc-gateway/util/reload"
+	"github.com/sirupsen/logrus"
+)
+
+// JSON-RPC error codes for requests cancelled due to timeout or by client.
+const (
+	errCodeTimeout   = -32009
+	errCodeCancelled = -32010
+)
+
+// MethodTimeoutConfig timeout shared by a set of RPC methods.
+type MethodTimeoutConfig struct {
+	// RPC methods sharing the timeout, which supports `*` suffix as wildcard, e.g. `debug_*`
+	Methods []string
+	Timeout time.Duration
+}
+
+// TimeoutConfig configurations of per method request timeouts and client deadline propagation.
+type TimeoutConfig struct {
+	Enabled bool
+	// default timeout for methods not configured, and 0 means no timeout
+	Default time.Duration
+	// per method timeouts, of which the first matched applies
+	Methods []MethodTimeoutConfig
+	// HTTP header for client to supply request timeout, either in Go duration format, e.g. `1.5s`,
+	// or in milliseconds. Empty to ignore client supplied timeout.
+	ClientHeader string `default:"X-Request-Timeout"`
+}
+
+// timeoutError is returned for requests cancelled due to timeout or by client.
+type timeoutError struct {
+	cause error
+}
+
+func (e *timeoutError) Error() string {
+	if errors.Is(e.cause, context.DeadlineExceeded) {
+		return "request timed out"
+	}
+
+	return "request cancelled"
+}
+
+func (e *timeoutError) ErrorCode() int {
+	if errors.Is(e.cause, context.DeadlineExceeded) {
+		return errCodeTimeout
+	}
+
+	return errCodeCancelled
+}
+
+// timeouts is the timeout config in use, which could be changed at runtime.
+var timeouts atomic.Value
+
+func init() {
+	conf, err := loadTimeoutConfig()
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to load request timeout config")
+	}
+
+	timeouts.Store(conf)
+
+	reload.Register("rpc_timeout", func() error {
+		conf, err := loadTimeoutConfig()
+		if err != nil {
+			return err
+		}
+
+		timeouts.Store(conf)
+		return nil
+	})
+}
+
+func loadTimeoutConfig() (*TimeoutConfig, error) {
+	var conf TimeoutConfig
+	if err := viper.UnmarshalKey("rpc.timeout", &conf); err != nil {
+		return nil, err
+	}
+
+	if conf.Default < 0 {
+		return nil, errors.Errorf("invalid default timeout %v", conf.Default)
+	}
+
+	for _, mc := range conf.Methods {
+		if mc.Timeout <= 0 || len(mc.Methods) == 0 {
+			return nil, errors.Errorf("invalid timeout %v of methods %v", mc.Timeout, mc.Methods)
+		}
+	}
+
+	return &conf, nil
+}
+
+// timeout returns the timeout of the first matched method, or the default one.
+func (conf *TimeoutConfig) timeout(method string) time.Duration {
+	for _, mc := range conf.Methods {
+		if matchMethods(mc.Methods, method) {
+			return mc.Timeout
+		}
+	}
+
+	return conf.Default
+}
+
+// parseClientTimeout parses client supplied timeout in Go duration format or milliseconds.
+func parseClientTimeout(value string) (time.Duration, bool) {
+	if ms, err := strconv.ParseUint(value, 10, 32); err == nil {
+		return time.Duration(ms) * time.Millisecond, ms > 0
+	}
+
+	d, err := time.ParseDuration(value)
+	return d, err == nil && d > 0
+}
+
+// clientDeadline applies the client supplied timeout to the request context if any, and
+// returns the function to release resources.
+func clientDeadline(ctx context.Context, r *http.Request) (context.Context, context.CancelFunc) {
+	conf := timeouts.Load().(*TimeoutConfig)
+	if !conf.Enabled || len(conf.ClientHeader) == 0 {
+		return ctx, func() {}
+	}
+
+	value := r.Header.Get(conf.ClientHeader)
+	if len(value) == 0 {
+		return ctx, func() {}
+	}
+
+	timeout, ok := parseClientTimeout(value)
+	if !ok {
+		return ctx, func() {}
+	}
+
+	return context.WithTimeout(ctx, timeout)
+}
+
+// timeoutMiddleware cancels request context once the method timeout or client deadline
+// exceeded, and responds with a timeout error at once. Note, as upstream clients are not
+// context aware, the abandoned upstream call is still bounded by the client request timeout.
+func timeoutMiddleware(next rpc.HandleCallMsgFunc) rpc.HandleCallMsgFunc {
+	return func(ctx context.Context, msg *rpc.JsonRpcMessage) *rpc.JsonRpcMessage {
+		conf := timeouts.Load().(*TimeoutConfig)
+		if !conf.Enabled {
+			return next(ctx, msg)
+		}
+
+		if timeout := conf.timeout(msg.Method); timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+
+		if _, ok := ctx.Deadline(); !ok {
+			return next(ctx, msg)
+		}
+
+		respCh := make(chan *rpc.JsonRpcMessage, 1)
+		go func() {
+			respCh <- next(ctx, msg)
+		}()
+
+		select {
+		case resp := <-respCh:
+			metrics.Registry.RPC.Percentage(msg.Method, "timeout").Mark(false)
+			return resp
+		case <-ctx.Done():
+			metrics.Registry.RPC.Percentage(msg.Method, "timeout").Mark(true)
+			return msg.ErrorResponse(&timeoutError{ctx.Err()})
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package rpc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseClientTimeout(t *testing.T) {
+	d, ok := parseClientTimeout("1500")
+	assert.True(t, ok)
+	assert.Equal(t, 1500*time.Millisecond, d)
+
+	d, ok = parseClientTimeout("2s")
+	assert.True(t, ok)
+	assert.Equal(t, 2*time.Second, d)
+
+	_, ok = parseClientTimeout("0")
+	assert.False(t, ok)
+
+	_, ok = parseClientTimeout("-1s")
+	assert.False(t, ok)
+
+	_, ok = parseClientTimeout("abc")
+	assert.False(t, ok)
+}
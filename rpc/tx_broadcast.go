@@ -0,0 +1,87 @@
+package rpc
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/scroll-tech/rpc-gateway/node"
+	"github.com/scroll-tech/rpc-gateway/util/metrics"
+	"github.com/sirupsen/logrus"
+)
+
+// featurePrivateRelay is the opt-in feature flag to send raw transactions to private relay(s)
+// rather than public nodes, e.g. for MEV protection, which is generally enabled for tenants only.
+const featurePrivateRelay = "privateRelay"
+
+// TxBroadcastConfig configurations to broadcast raw transactions to fullnodes.
+type TxBroadcastConfig struct {
+	// number of fullnodes to send raw transaction simultaneously, so that transaction will
+	// not be lost due to a single node with full txpool or about to restart
+	Fanout int `default:"1"`
+}
+
+// txBroadcastResult is the result to send raw transaction to some fullnode.
+type txBroadcastResult struct {
+	txHash common.Hash
+	url    string
+	err    error
+}
+
+// txBroadcastClientProvider provides other fullnodes of the same group to broadcast raw transaction.
+type txBroadcastClientProvider interface {
+	GetClientRandomByGroupExcept(group node.Group, excludedURLs ...string) (*node.Web3goClient, error)
+}
+
+// broadcastTx sends raw transaction to the primary fullnode along with other fullnodes of the
+// same group up to the fanout number, and returns success if any accepts. Otherwise, error of
+// the primary fullnode is returned.
+func broadcastTx(
+	conf TxBroadcastConfig,
+	provider txBroadcastClientProvider,
+	primary *node.Web3goClient,
+	send func(w3c *node.Web3goClient) (common.Hash, error),
+) (common.Hash, string, error) {
+	clients := []*node.Web3goClient{primary}
+	urls := []string{primary.URL}
+
+	for len(clients) < conf.Fanout {
+		client, err := provider.GetClientRandomByGroupExcept(node.GroupEthHttp, urls...)
+		if err != nil { // no more fullnodes available
+			break
+		}
+
+		clients = append(clients, client)
+		urls = append(urls, client.URL)
+	}
+
+	if len(clients) == 1 {
+		txHash, err := send(primary)
+		return txHash, primary.URL, err
+	}
+
+	// buffered to not block the slower sends once any accepted
+	resultCh := make(chan txBroadcastResult, len(clients))
+	for _, client := range clients {
+		go func(client *node.Web3goClient) {
+			txHash, err := send(client)
+			resultCh <- txBroadcastResult{txHash, client.URL, err}
+		}(client)
+	}
+
+	var primaryErr error
+	for range clients {
+		res := <-resultCh
+		if res.err == nil {
+			metrics.Registry.RPC.Percentage("eth_sendRawTransaction", "fanout/accepted").Mark(true)
+			return res.txHash, res.url, nil
+		}
+
+		logrus.WithError(res.err).WithField("url", res.url).Debug("Fullnode rejected raw transaction in fanout")
+
+		if res.url == primary.URL {
+			primaryErr = res.err
+		}
+	}
+
+	metrics.Registry.RPC.Percentage("eth_sendRawTransaction", "fanout/accepted").Mark(false)
+
+	return common.Hash{}, "", primaryErr
+}
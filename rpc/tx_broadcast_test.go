@@ -0,0 +1,206 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/scroll-tech/rpc-gateway/node"
+	"github.com/scroll-tech/rpc-gateway/util/relay"
+	"github.com/scroll-tech/rpc-gateway/util/reload"
+	"github.com/scroll-tech/rpc-gateway/util/rpc/handlers"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+// newRawTxServer creates a fake node that answers eth_sendRawTransaction with the specified
+// result or RPC error, and counts the received transactions.
+func newRawTxServer(result string, rpcErr string, received *int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct{ ID json.RawMessage }
+		json.NewDecoder(r.Body).Decode(&req)
+
+		*received++
+
+		resp := map[string]interface{}{"jsonrpc": "2.0", "id": req.ID}
+		if len(rpcErr) > 0 {
+			resp["error"] = map[string]interface{}{"code": -32000, "message": rpcErr}
+		} else {
+			resp["result"] = result
+		}
+
+		json.NewEncoder(w).Encode(resp)
+	}))
+}
+
+// newTxTestAPI creates the evm space API to send raw transactions in tests, which broadcasts
+// to public node by the send function in tests, and deduplicates retries if dedup enabled.
+func newTxTestAPI(dedup TxDedupConfig) *ethAPI {
+	return &ethAPI{
+		txInclusion: newTxInclusionTracker("eth"),
+		txDedup:     newTxDedupCache(dedup),
+		txReplay:    newTxReplayGuard(TxReplayConfig{}),
+		txValidator: newTxValidator(TxValidationConfig{}, 1337),
+	}
+}
+
+func TestSendRawTransactionPrivateRelay(t *testing.T) {
+	// opt in tenant for private relay
+	viper.Set("features.flags", []map[string]interface{}{
+		{"name": featurePrivateRelay, "enabled": true, "tenants": []string{"tenant"}},
+	})
+	reload.Reload()
+
+	defer func() {
+		viper.Set("features.flags", nil)
+		reload.Reload()
+	}()
+
+	txHash := common.HexToHash("0x01")
+	signedTx := hexutil.Bytes{0x01, 0x02}
+
+	var relayed, broadcast int
+	relayServer := newRawTxServer(txHash.Hex(), "", &relayed)
+	defer relayServer.Close()
+
+	send := func(w3c *node.Web3goClient) (common.Hash, error) {
+		broadcast++
+		return txHash, nil
+	}
+
+	tenant := context.WithValue(context.Background(), handlers.CtxAccessToken, "tenant")
+	tenant = context.WithValue(tenant, ctxKeyClient, &node.Web3goClient{URL: "http://127.0.0.1:8545"})
+	public := context.WithValue(context.Background(), ctxKeyClient, &node.Web3goClient{URL: "http://127.0.0.1:8545"})
+
+	api := newTxTestAPI(TxDedupConfig{})
+	api.PrivateRelay = relay.MustNewSequencerRouter(&relay.SequencerConfig{Urls: []string{relayServer.URL}})
+
+	// opted-in tenant sends to private relay only
+	hash, err := api.sendRawTransaction(tenant, "eth_sendRawTransaction", signedTx, send)
+	assert.NoError(t, err)
+	assert.Equal(t, txHash, hash)
+	assert.Equal(t, 1, relayed)
+	assert.Equal(t, 0, broadcast)
+
+	// others broadcast to public nodes
+	_, err = api.sendRawTransaction(public, "eth_sendRawTransaction", signedTx, send)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, relayed)
+	assert.Equal(t, 1, broadcast)
+
+	// private relay not configured
+	api.PrivateRelay = nil
+	_, err = api.sendRawTransaction(tenant, "eth_sendRawTransaction", signedTx, send)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, broadcast)
+
+	// RPC error of private relay returned to tenant as it is
+	var rejected int
+	rejectServer := newRawTxServer("", "nonce too low", &rejected)
+	defer rejectServer.Close()
+
+	api.PrivateRelay = relay.MustNewSequencerRouter(&relay.SequencerConfig{Urls: []string{rejectServer.URL}})
+	_, err = api.sendRawTransaction(tenant, "eth_sendRawTransaction", signedTx, send)
+	assert.EqualError(t, err, "nonce too low")
+	assert.Equal(t, 1, rejected)
+	assert.Equal(t, 2, broadcast)
+
+	// never fall back to public nodes even if private relay unavailable
+	rejectServer.Close()
+	_, err = api.sendRawTransaction(tenant, "eth_sendRawTransaction", signedTx, send)
+	assert.Error(t, err)
+	assert.Equal(t, 2, broadcast)
+}
+
+// broadcastNodes provides fullnodes in order to broadcast raw transaction.
+type broadcastNodes []*node.Web3goClient
+
+func (nodes broadcastNodes) GetClientRandomByGroupExcept(
+	group node.Group, excludedURLs ...string,
+) (*node.Web3goClient, error) {
+	excluded := make(map[string]bool)
+	for _, url := range excludedURLs {
+		excluded[url] = true
+	}
+
+	for _, client := range nodes {
+		if !excluded[client.URL] {
+			return client, nil
+		}
+	}
+
+	return nil, node.ErrClientUnavailable
+}
+
+func TestBroadcastTx(t *testing.T) {
+	primary := &node.Web3goClient{URL: "http://127.0.0.1:8545"}
+	others := broadcastNodes{
+		{URL: "http://127.0.0.2:8545"},
+		{URL: "http://127.0.0.3:8545"},
+	}
+
+	txHash := common.HexToHash("0x01")
+	errPrimary := errors.New("txpool is full")
+
+	tests := []struct {
+		fanout   int
+		rejected map[string]bool // fullnodes that reject the transaction
+		sent     []string
+		err      error
+	}{
+		// primary only
+		{1, nil, []string{primary.URL}, nil},
+		{1, map[string]bool{primary.URL: true}, []string{primary.URL}, errPrimary},
+		// accepted by any fullnode
+		{2, map[string]bool{primary.URL: true}, []string{primary.URL, others[0].URL}, nil},
+		{3, nil, []string{primary.URL, others[0].URL, others[1].URL}, nil},
+		// not enough fullnodes to fan out
+		{5, nil, []string{primary.URL, others[0].URL, others[1].URL}, nil},
+		// rejected by all, and error of primary returned
+		{3, map[string]bool{primary.URL: true, others[0].URL: true, others[1].URL: true},
+			[]string{primary.URL, others[0].URL, others[1].URL}, errPrimary},
+	}
+
+	for _, tt := range tests {
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+		var sent []string
+
+		wg.Add(len(tt.sent))
+
+		send := func(w3c *node.Web3goClient) (common.Hash, error) {
+			defer wg.Done()
+
+			mu.Lock()
+			sent = append(sent, w3c.URL)
+			mu.Unlock()
+
+			if tt.rejected[w3c.URL] {
+				if w3c.URL == primary.URL {
+					return common.Hash{}, errPrimary
+				}
+
+				return common.Hash{}, errors.New("nonce too low")
+			}
+
+			return txHash, nil
+		}
+
+		hash, url, err := broadcastTx(TxBroadcastConfig{Fanout: tt.fanout}, others, primary, send)
+		wg.Wait() // slower sends completed in background
+
+		assert.ElementsMatch(t, tt.sent, sent)
+		assert.Equal(t, tt.err, err)
+
+		if tt.err == nil {
+			assert.Equal(t, txHash, hash)
+			assert.False(t, tt.rejected[url])
+		}
+	}
+}
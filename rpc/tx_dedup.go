@@ -0,0 +1,59 @@
+package rpc
+
+import (
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/scroll-tech/rpc-gateway/util"
+	"github.com/scroll-tech/rpc-gateway/util/metrics"
+)
+
+// TxDedupConfig configurations to deduplicate raw transaction submissions.
+type TxDedupConfig struct {
+	// window to answer duplicate submissions from cache, and 0 means disabled
+	Window time.Duration `default:"1m"`
+	// max number of recently submitted transactions to cache
+	Size int `default:"10000"`
+}
+
+// txDedupCache caches recently submitted raw transactions, so that duplicate retries within
+// the window are answered from cache rather than re-broadcast.
+type txDedupCache struct {
+	txs *util.ExpirableLruCache // raw transaction hash => transaction hash
+}
+
+func newTxDedupCache(conf TxDedupConfig) *txDedupCache {
+	if conf.Window <= 0 || conf.Size <= 0 {
+		return &txDedupCache{}
+	}
+
+	return &txDedupCache{
+		txs: util.NewExpirableLruCache(conf.Size, conf.Window),
+	}
+}
+
+// get returns the transaction hash if the raw transaction submitted recently, and also updates
+// the duplicate rate metrics of the specified method.
+func (c *txDedupCache) get(method string, signedTx hexutil.Bytes) (common.Hash, bool) {
+	if c.txs == nil {
+		return common.Hash{}, false
+	}
+
+	v, ok := c.txs.Get(crypto.Keccak256Hash(signedTx))
+	metrics.Registry.RPC.Percentage(method, "duplicate").Mark(ok)
+
+	if !ok {
+		return common.Hash{}, false
+	}
+
+	return v.(common.Hash), true
+}
+
+// add caches the raw transaction accepted by upstream.
+func (c *txDedupCache) add(signedTx hexutil.Bytes, txHash common.Hash) {
+	if c.txs != nil {
+		c.txs.Add(crypto.Keccak256Hash(signedTx), txHash)
+	}
+}
@@ -0,0 +1,73 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/scroll-tech/rpc-gateway/node"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSendRawTransactionDedup(t *testing.T) {
+	ctx := context.WithValue(context.Background(), ctxKeyClient, &node.Web3goClient{URL: "http://127.0.0.1:8545"})
+	signedTx := hexutil.Bytes{0x01, 0x02}
+
+	// hash of each broadcast, and zero hash means rejected
+	var results []common.Hash
+	var broadcast int
+
+	send := func(w3c *node.Web3goClient) (common.Hash, error) {
+		result := results[broadcast]
+		broadcast++
+
+		if result == (common.Hash{}) {
+			return common.Hash{}, errors.New("txpool is full")
+		}
+
+		return result, nil
+	}
+
+	// retries of the same client answered with the first hash
+	api := newTxTestAPI(TxDedupConfig{Window: time.Minute, Size: 16})
+	results = []common.Hash{common.HexToHash("0x01"), common.HexToHash("0x02")}
+
+	for i := 0; i < 3; i++ {
+		hash, err := api.sendRawTransaction(ctx, "eth_sendRawTransaction", signedTx, send)
+		assert.NoError(t, err)
+		assert.Equal(t, common.HexToHash("0x01"), hash)
+	}
+
+	assert.Equal(t, 1, broadcast)
+
+	// failed broadcast is not cached, and retried
+	api = newTxTestAPI(TxDedupConfig{Window: time.Minute, Size: 16})
+	results, broadcast = []common.Hash{{}, common.HexToHash("0x01"), common.HexToHash("0x02")}, 0
+
+	_, err := api.sendRawTransaction(ctx, "eth_sendRawTransaction", signedTx, send)
+	assert.Error(t, err)
+
+	hash, err := api.sendRawTransaction(ctx, "eth_sendRawTransaction", signedTx, send)
+	assert.NoError(t, err)
+	assert.Equal(t, common.HexToHash("0x01"), hash)
+
+	hash, err = api.sendRawTransaction(ctx, "eth_sendRawTransaction", signedTx, send)
+	assert.NoError(t, err)
+	assert.Equal(t, common.HexToHash("0x01"), hash)
+	assert.Equal(t, 2, broadcast)
+
+	// disabled
+	api = newTxTestAPI(TxDedupConfig{})
+	results, broadcast = []common.Hash{common.HexToHash("0x01"), common.HexToHash("0x02")}, 0
+
+	for i := range results {
+		hash, err := api.sendRawTransaction(ctx, "eth_sendRawTransaction", signedTx, send)
+		assert.NoError(t, err)
+		assert.Equal(t, results[i], hash)
+	}
+
+	assert.Equal(t, 2, broadcast)
+}
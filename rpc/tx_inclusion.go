@@ -0,0 +1,87 @@
+package rpc
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/scroll-tech/rpc-gateway/util"
+	"github.com/scroll-tech/rpc-gateway/util/metrics"
+	rpcutil "github.com/scroll-tech/rpc-gateway/util/rpc"
+)
+
+const (
+	// max number of broadcasted transactions to track inclusion latency
+	txInclusionTrackerSize = 50_000
+	// transactions not included within this duration will be untracked
+	txInclusionTrackerTTL = 30 * time.Minute
+)
+
+// txBroadcast records a transaction broadcasted through the gateway.
+type txBroadcast struct {
+	mu          sync.Mutex
+	broadcastAt time.Time
+	endpoint    string          // node name of the sequencer or fullnode which accepts the transaction
+	included    bool            // whether inclusion latency of broadcast endpoint recorded
+	nodes       map[string]bool // nodes which already returned receipt
+}
+
+// txInclusionTracker measures the time from transaction broadcasted through the gateway
+// to receipt available on each node, so as to evaluate the quality of upstream nodes.
+type txInclusionTracker struct {
+	space string
+	txs   *util.ExpirableLruCache // tx hash => *txBroadcast
+}
+
+func newTxInclusionTracker(space string) *txInclusionTracker {
+	return &txInclusionTracker{
+		space: space,
+		txs:   util.NewExpirableLruCache(txInclusionTrackerSize, txInclusionTrackerTTL),
+	}
+}
+
+// onBroadcast starts to track the transaction broadcasted to the specified endpoint url.
+func (t *txInclusionTracker) onBroadcast(txHash common.Hash, url string) {
+	t.txs.Add(txHash, &txBroadcast{
+		broadcastAt: time.Now(),
+		endpoint:    rpcutil.Url2NodeName(url),
+		nodes:       make(map[string]bool),
+	})
+}
+
+// lookup returns the broadcast time and endpoint node name of the tracked transaction.
+func (t *txInclusionTracker) lookup(txHash common.Hash) (time.Time, string, bool) {
+	v, ok := t.txs.Get(txHash)
+	if !ok {
+		return time.Time{}, "", false
+	}
+
+	tx := v.(*txBroadcast)
+	return tx.broadcastAt, tx.endpoint, true
+}
+
+// onReceipt records inclusion latency when receipt is available on the specified node
+// url at the first time.
+func (t *txInclusionTracker) onReceipt(txHash common.Hash, url string) {
+	v, ok := t.txs.Get(txHash)
+	if !ok { // not broadcasted through the gateway or expired
+		return
+	}
+
+	tx := v.(*txBroadcast)
+	node := rpcutil.Url2NodeName(url)
+	latency := time.Since(tx.broadcastAt).Nanoseconds()
+
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+
+	if !tx.nodes[node] {
+		tx.nodes[node] = true
+		metrics.Registry.RPC.TxInclusionLatency(t.space, "node", node).Update(latency)
+	}
+
+	if !tx.included {
+		tx.included = true
+		metrics.Registry.RPC.TxInclusionLatency(t.space, "endpoint", tx.endpoint).Update(latency)
+	}
+}
@@ -0,0 +1,57 @@
+package rpc
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTxInclusionTracker(t *testing.T) {
+	tracked := common.HexToHash("0x01")
+	untracked := common.HexToHash("0x02")
+
+	tracker := newTxInclusionTracker("eth")
+	tracker.onBroadcast(tracked, "http://sequencer:8545")
+
+	broadcastAt, endpoint, ok := tracker.lookup(tracked)
+	assert.True(t, ok)
+	assert.Equal(t, "sequencer:8545", endpoint)
+	assert.False(t, broadcastAt.IsZero())
+
+	tests := []struct {
+		txHash common.Hash
+		url    string // node which returned receipt
+		nodes  []string
+	}{
+		{tracked, "http://127.0.0.1:8545", []string{"127.0.0.1:8545"}},
+		// recorded only at the first time for each node
+		{tracked, "http://127.0.0.1:8545", []string{"127.0.0.1:8545"}},
+		{tracked, "http://sequencer:8545", []string{"127.0.0.1:8545", "sequencer:8545"}},
+		// not broadcasted through the gateway
+		{untracked, "http://127.0.0.1:8545", nil},
+	}
+
+	for _, tt := range tests {
+		tracker.onReceipt(tt.txHash, tt.url)
+
+		v, ok := tracker.txs.Get(tt.txHash)
+		if tt.nodes == nil {
+			assert.False(t, ok)
+			continue
+		}
+
+		tx := v.(*txBroadcast)
+
+		var nodes []string
+		for node := range tx.nodes {
+			nodes = append(nodes, node)
+		}
+
+		assert.ElementsMatch(t, tt.nodes, nodes)
+		assert.True(t, tx.included)
+	}
+
+	_, _, ok = tracker.lookup(untracked)
+	assert.False(t, ok)
+}
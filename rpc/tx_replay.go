@@ -0,0 +1,169 @@
+package rpc
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	gethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+	"github.com/scroll-tech/rpc-gateway/util"
+	"github.com/scroll-tech/rpc-gateway/util/feature"
+	"github.com/scroll-tech/rpc-gateway/util/metrics"
+	"github.com/sirupsen/logrus"
+)
+
+var errTxReplay = errors.New("transaction already submitted by another client recently, possible replay")
+
+// TxReplayConfig configurations to reject raw transactions re-broadcast by different clients.
+type TxReplayConfig struct {
+	Enabled bool
+	// window to remember submitted transactions of each sender
+	Window time.Duration `default:"10m"`
+	// max number of senders to track
+	Senders int `default:"100000"`
+	// max number of transactions to remember for each sender
+	TxsPerSender int `default:"64"`
+}
+
+// seenTx is a raw transaction submitted recently.
+type seenTx struct {
+	client string // idempotency context, e.g. API key or client IP
+	seenAt time.Time
+}
+
+// senderTxs is the recently submitted transactions of a sender.
+type senderTxs struct {
+	mu  sync.Mutex
+	txs map[common.Hash]seenTx
+}
+
+// txReplayGuard detects re-broadcast of raw transactions already submitted recently by
+// another client, e.g. replay attacks from leaked requests. Note, retries from the same
+// client are allowed.
+type txReplayGuard struct {
+	conf    TxReplayConfig
+	senders *util.ExpirableLruCache // sender => *senderTxs
+}
+
+func newTxReplayGuard(conf TxReplayConfig) *txReplayGuard {
+	if !conf.Enabled || conf.Window <= 0 || conf.Senders <= 0 {
+		return &txReplayGuard{}
+	}
+
+	return &txReplayGuard{
+		conf:    conf,
+		senders: util.NewExpirableLruCache(conf.Senders, conf.Window),
+	}
+}
+
+// check returns error if the raw transaction already submitted recently by another client, and
+// also updates the replay rate metrics of the specified method.
+func (g *txReplayGuard) check(ctx context.Context, method string, signedTx hexutil.Bytes) error {
+	if g.senders == nil {
+		return nil
+	}
+
+	sender, txHash, ok := decodeTxSender(signedTx)
+	if !ok { // leave invalid transaction to upstream
+		return nil
+	}
+
+	client := feature.BucketKey(ctx)
+
+	v, ok := g.senders.Get(sender)
+	if !ok {
+		metrics.Registry.RPC.Percentage(method, "replay").Mark(false)
+		return nil
+	}
+
+	st := v.(*senderTxs)
+	st.mu.Lock()
+	seen, ok := st.txs[txHash]
+	st.mu.Unlock()
+
+	replay := ok && seen.client != client && time.Since(seen.seenAt) < g.conf.Window
+	metrics.Registry.RPC.Percentage(method, "replay").Mark(replay)
+
+	if replay {
+		logrus.WithFields(logrus.Fields{
+			"sender": sender, "txHash": txHash, "client": client,
+		}).Warn("Possible replay of raw transaction rejected")
+
+		return errTxReplay
+	}
+
+	return nil
+}
+
+// add remembers the raw transaction accepted by upstream.
+func (g *txReplayGuard) add(ctx context.Context, signedTx hexutil.Bytes) {
+	if g.senders == nil {
+		return
+	}
+
+	sender, txHash, ok := decodeTxSender(signedTx)
+	if !ok {
+		return
+	}
+
+	var st *senderTxs
+	if v, ok := g.senders.Get(sender); ok {
+		st = v.(*senderTxs)
+	} else {
+		st = &senderTxs{txs: make(map[common.Hash]seenTx)}
+	}
+
+	// refresh sender expiration
+	g.senders.Add(sender, st)
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	if _, ok := st.txs[txHash]; ok { // keep the original client
+		return
+	}
+
+	st.txs[txHash] = seenTx{client: feature.BucketKey(ctx), seenAt: time.Now()}
+	st.prune(g.conf.Window, g.conf.TxsPerSender)
+}
+
+// prune removes expired transactions, and the oldest ones if exceeds the max number, which
+// should be called with lock held.
+func (st *senderTxs) prune(window time.Duration, max int) {
+	for txHash, seen := range st.txs {
+		if time.Since(seen.seenAt) >= window {
+			delete(st.txs, txHash)
+		}
+	}
+
+	for len(st.txs) > max {
+		var oldest common.Hash
+		var oldestAt time.Time
+
+		for txHash, seen := range st.txs {
+			if oldestAt.IsZero() || seen.seenAt.Before(oldestAt) {
+				oldest, oldestAt = txHash, seen.seenAt
+			}
+		}
+
+		delete(st.txs, oldest)
+	}
+}
+
+// decodeTxSender decodes the raw transaction and recovers the sender.
+func decodeTxSender(signedTx hexutil.Bytes) (common.Address, common.Hash, bool) {
+	var tx gethTypes.Transaction
+	if err := tx.UnmarshalBinary(signedTx); err != nil {
+		return common.Address{}, common.Hash{}, false
+	}
+
+	sender, err := gethTypes.Sender(gethTypes.LatestSignerForChainID(tx.ChainId()), &tx)
+	if err != nil {
+		return common.Address{}, common.Hash{}, false
+	}
+
+	return sender, tx.Hash(), true
+}
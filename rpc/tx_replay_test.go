@@ -0,0 +1,40 @@
+package rpc
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	gethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/scroll-tech/rpc-gateway/util/rpc/handlers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTxReplayGuard(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	signer := gethTypes.LatestSignerForChainID(big.NewInt(534352))
+	tx := gethTypes.MustSignNewTx(key, signer, &gethTypes.LegacyTx{
+		Nonce: 1, To: &common.Address{}, Gas: 21000, GasPrice: big.NewInt(1),
+	})
+	signedTx, _ := tx.MarshalBinary()
+
+	guard := newTxReplayGuard(TxReplayConfig{Enabled: true, Window: time.Minute, Senders: 10, TxsPerSender: 2})
+
+	alice := context.WithValue(context.Background(), handlers.CtxAccessToken, "alice")
+	bob := context.WithValue(context.Background(), handlers.CtxAccessToken, "bob")
+
+	assert.Nil(t, guard.check(alice, "eth_sendRawTransaction", signedTx))
+	guard.add(alice, signedTx)
+
+	// retry from the same client
+	assert.Nil(t, guard.check(alice, "eth_sendRawTransaction", signedTx))
+
+	// re-broadcast by another client
+	assert.Equal(t, errTxReplay, guard.check(bob, "eth_sendRawTransaction", signedTx))
+
+	// invalid transaction left to upstream
+	assert.Nil(t, guard.check(bob, "eth_sendRawTransaction", []byte{0x1}))
+}
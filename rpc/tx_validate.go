@@ -0,0 +1,100 @@
+package rpc
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	gethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+	"github.com/scroll-tech/rpc-gateway/util/metrics"
+	"github.com/sirupsen/logrus"
+)
+
+// TxValidationConfig configurations to validate raw transactions before forwarding to upstream,
+// so that obviously invalid submissions are rejected without txpool churn.
+type TxValidationConfig struct {
+	Enabled bool
+	// whether to reject transactions without EIP-155 replay protection
+	RequireReplayProtection bool `default:"true"`
+	// max gas limit, and 0 means unlimited
+	MaxGas uint64
+	// min gas price or max fee per gas in wei, and 0 means no floor
+	MinGasPrice uint64
+	// max size of transaction data in bytes, and 0 means unlimited
+	MaxDataSize int `default:"131072"`
+}
+
+// txValidator validates raw transactions against chain ID, gas limits, fee floor, data size and
+// signature.
+type txValidator struct {
+	conf    TxValidationConfig
+	chainId *big.Int
+}
+
+func newTxValidator(conf TxValidationConfig, chainId uint64) *txValidator {
+	return &txValidator{conf: conf, chainId: new(big.Int).SetUint64(chainId)}
+}
+
+// check returns error if the raw transaction is invalid, and also updates the invalid rate
+// metrics of the specified method.
+func (v *txValidator) check(method string, signedTx hexutil.Bytes) error {
+	if !v.conf.Enabled {
+		return nil
+	}
+
+	err := v.validate(signedTx)
+	metrics.Registry.RPC.Percentage(method, "invalid").Mark(err != nil)
+
+	if err != nil {
+		logrus.WithError(err).WithField("method", method).Debug("Invalid raw transaction rejected")
+	}
+
+	return err
+}
+
+func (v *txValidator) validate(signedTx hexutil.Bytes) error {
+	var tx gethTypes.Transaction
+	if err := tx.UnmarshalBinary(signedTx); err != nil {
+		return errors.WithMessage(err, "invalid raw transaction")
+	}
+
+	if tx.Protected() {
+		if tx.ChainId().Cmp(v.chainId) != 0 {
+			return errors.Errorf("invalid chain id, expected %v, got %v", v.chainId, tx.ChainId())
+		}
+	} else if v.conf.RequireReplayProtection {
+		return errors.New("only replay-protected (EIP-155) transactions allowed over RPC")
+	}
+
+	if v.conf.MaxDataSize > 0 && len(tx.Data()) > v.conf.MaxDataSize {
+		return errors.Errorf("transaction data too large, max %v bytes, got %v", v.conf.MaxDataSize, len(tx.Data()))
+	}
+
+	intrinsicGas, err := core.IntrinsicGas(tx.Data(), tx.AccessList(), tx.To() == nil, true, true)
+	if err != nil {
+		return errors.WithMessage(err, "invalid transaction data")
+	}
+
+	if tx.Gas() < intrinsicGas {
+		return errors.Errorf("intrinsic gas too low, min %v, got %v", intrinsicGas, tx.Gas())
+	}
+
+	if v.conf.MaxGas > 0 && tx.Gas() > v.conf.MaxGas {
+		return errors.Errorf("exceeds block gas limit, max %v, got %v", v.conf.MaxGas, tx.Gas())
+	}
+
+	if tx.GasTipCapIntCmp(tx.GasFeeCap()) > 0 {
+		return errors.New("max priority fee per gas higher than max fee per gas")
+	}
+
+	if tx.GasFeeCapIntCmp(new(big.Int).SetUint64(v.conf.MinGasPrice)) < 0 {
+		return errors.Errorf("transaction underpriced, min gas price %v wei, got %v", v.conf.MinGasPrice, tx.GasFeeCap())
+	}
+
+	if _, err := gethTypes.Sender(gethTypes.LatestSignerForChainID(tx.ChainId()), &tx); err != nil {
+		return errors.WithMessage(err, "invalid sender")
+	}
+
+	return nil
+}
@@ -0,0 +1,62 @@
+package rpc
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	gethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTxValidator(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+
+	sign := func(chainId int64, tx gethTypes.TxData) []byte {
+		signer := gethTypes.LatestSignerForChainID(big.NewInt(chainId))
+		signedTx, _ := gethTypes.MustSignNewTx(key, signer, tx).MarshalBinary()
+		return signedTx
+	}
+
+	v := newTxValidator(TxValidationConfig{
+		Enabled: true, RequireReplayProtection: true, MaxGas: 1000000, MinGasPrice: 100, MaxDataSize: 32,
+	}, 534352)
+
+	valid := &gethTypes.LegacyTx{Nonce: 1, To: &common.Address{}, Gas: 21000, GasPrice: big.NewInt(100)}
+	assert.Nil(t, v.validate(sign(534352, valid)))
+
+	// malformed
+	assert.NotNil(t, v.validate([]byte{0x1}))
+
+	// wrong chain id
+	assert.NotNil(t, v.validate(sign(1, valid)))
+
+	// intrinsic gas too low
+	assert.NotNil(t, v.validate(sign(534352, &gethTypes.LegacyTx{
+		Nonce: 1, To: &common.Address{}, Gas: 20000, GasPrice: big.NewInt(100),
+	})))
+
+	// exceeds max gas
+	assert.NotNil(t, v.validate(sign(534352, &gethTypes.LegacyTx{
+		Nonce: 1, To: &common.Address{}, Gas: 1000001, GasPrice: big.NewInt(100),
+	})))
+
+	// underpriced
+	assert.NotNil(t, v.validate(sign(534352, &gethTypes.DynamicFeeTx{
+		ChainID: big.NewInt(534352), Nonce: 1, To: &common.Address{}, Gas: 21000,
+		GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(99),
+	})))
+
+	// data too large
+	assert.NotNil(t, v.validate(sign(534352, &gethTypes.LegacyTx{
+		Nonce: 1, To: &common.Address{}, Gas: 100000, GasPrice: big.NewInt(100), Data: make([]byte, 33),
+	})))
+
+	// unprotected
+	unprotected, _ := gethTypes.MustSignNewTx(key, gethTypes.HomesteadSigner{}, valid).MarshalBinary()
+	assert.NotNil(t, v.validate(unprotected))
+
+	v.conf.RequireReplayProtection = false
+	assert.Nil(t, v.validate(unprotected))
+}
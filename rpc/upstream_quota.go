@@ -0,0 +1,157 @@
+package rpc
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/pkg/errors"
+	"github.com/scroll-tech/rpc-gateway/node"
+	"github.com/scroll-tech/rpc-gateway/util/metrics"
+	"github.com/scroll-tech/rpc-gateway/util/rate"
+	"github.com/sirupsen/logrus"
+)
+
+// UpstreamQuotaConfig global rate limit enforced by upstream provider.
+type UpstreamQuotaConfig struct {
+	// unique provider name, which is used as the shared bucket key
+	Name string
+	// upstream nodes of the provider by node name, which supports `*` suffix as wildcard
+	Nodes []string
+	// requests per second allowed by the provider
+	Rate float64
+	// max burst of requests allowed by the provider
+	Burst int
+}
+
+// UpstreamQuotasConfig configurations of upstream quota budgeting, which coordinates consumption
+// across all gateway replicas via shared token buckets in Redis, so that the fleet collectively
+// stays under the rate limits of upstream providers.
+type UpstreamQuotasConfig struct {
+	Enabled  bool
+	RedisUrl string
+	// key prefix of shared token buckets
+	KeyPrefix string `default:"gateway:upstream:quota:"`
+	// number of tokens to prefetch from Redis in batch
+	Prefetch int `default:"1"`
+	// max duration to wait for token, and 0 to reject immediately once quota exhausted
+	MaxWait time.Duration
+	// whether to allow requests if Redis unavailable
+	FailOpen bool `default:"true"`
+	// upstream providers with global rate limits
+	Providers []UpstreamQuotaConfig
+}
+
+// quotaBucket takes tokens of upstream provider, e.g. shared token bucket in Redis.
+type quotaBucket interface {
+	Take(ctx context.Context) (bool, time.Duration, error)
+}
+
+type upstreamQuotaBucket struct {
+	UpstreamQuotaConfig
+	bucket quotaBucket
+}
+
+type upstreamQuotaPolicy struct {
+	UpstreamQuotasConfig
+	buckets []*upstreamQuotaBucket
+}
+
+// bucket returns the bucket of the first provider matched by node name.
+func (p *upstreamQuotaPolicy) bucket(nodeName string) (*upstreamQuotaBucket, bool) {
+	for _, b := range p.buckets {
+		for _, pattern := range b.Nodes {
+			if node.MatchMethod(pattern, nodeName) {
+				return b, true
+			}
+		}
+	}
+
+	return nil, false
+}
+
+// upstreamQuota is the upstream quota policy, which is set once upstream quota subsystem
+// initialized.
+var upstreamQuota atomic.Value
+
+// SetUpstreamQuota enables upstream quota budgeting with the Redis client of shared token buckets.
+func SetUpstreamQuota(conf *UpstreamQuotasConfig, client *redis.Client) error {
+	policy := upstreamQuotaPolicy{UpstreamQuotasConfig: *conf}
+
+	for _, pc := range conf.Providers {
+		if len(pc.Name) == 0 || pc.Rate <= 0 || pc.Burst <= 0 {
+			return errors.Errorf("invalid upstream quota of provider %v", pc.Name)
+		}
+
+		bucket := rate.NewSharedTokenBucket(client, conf.KeyPrefix+pc.Name, pc.Rate, pc.Burst, conf.Prefetch)
+		policy.buckets = append(policy.buckets, &upstreamQuotaBucket{pc, bucket})
+	}
+
+	upstreamQuota.Store(&policy)
+
+	return nil
+}
+
+// take takes a token of the provider, and waits up to max wait duration if necessary.
+func (p *upstreamQuotaPolicy) take(ctx context.Context, b *upstreamQuotaBucket) bool {
+	deadline := time.Now().Add(p.MaxWait)
+
+	for {
+		ok, wait, err := b.bucket.Take(ctx)
+		if err != nil {
+			logrus.WithError(err).WithField("provider", b.Name).Warn("Failed to take upstream quota")
+			return p.FailOpen
+		}
+
+		if ok {
+			return true
+		}
+
+		if wait <= 0 || time.Now().Add(wait).After(deadline) {
+			return false
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return false
+		case <-timer.C:
+		}
+	}
+}
+
+// upstreamQuotaMiddleware consumes the shared quota of upstream provider before requesting the
+// upstream node, and rejects requests with a retriable error once quota exhausted.
+func upstreamQuotaMiddleware(next rpc.HandleCallMsgFunc) rpc.HandleCallMsgFunc {
+	return func(ctx context.Context, msg *rpc.JsonRpcMessage) *rpc.JsonRpcMessage {
+		policy, ok := upstreamQuota.Load().(*upstreamQuotaPolicy)
+		if !ok || !policy.Enabled {
+			return next(ctx, msg)
+		}
+
+		nodeName, ok := servingNodeName(ctx)
+		if !ok {
+			return next(ctx, msg)
+		}
+
+		b, ok := policy.bucket(nodeName)
+		if !ok {
+			return next(ctx, msg)
+		}
+
+		allowed := policy.take(ctx, b)
+		metrics.Registry.RPC.UpstreamQuotaExhausted(b.Name).Mark(!allowed)
+
+		if !allowed {
+			return msg.ErrorResponse(&busyError{
+				message:    "upstream quota exhausted, please try again later",
+				retryAfter: time.Duration(float64(time.Second) / b.Rate),
+			})
+		}
+
+		return next(ctx, msg)
+	}
+}
@@ -0,0 +1,115 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/scroll-tech/rpc-gateway/node"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeQuotaBucket replies token requests in order, and then grants all the rest.
+type fakeQuotaBucket struct {
+	waits []time.Duration // wait duration if exhausted, 0 means granted
+	err   error
+	takes int
+}
+
+func (b *fakeQuotaBucket) Take(ctx context.Context) (bool, time.Duration, error) {
+	b.takes++
+
+	if b.err != nil {
+		return false, 0, b.err
+	}
+
+	if b.takes > len(b.waits) || b.waits[b.takes-1] == 0 {
+		return true, 0, nil
+	}
+
+	return false, b.waits[b.takes-1], nil
+}
+
+func TestSetUpstreamQuota(t *testing.T) {
+	defer upstreamQuota.Store(&upstreamQuotaPolicy{})
+
+	tests := []struct {
+		provider UpstreamQuotaConfig
+		ok       bool
+	}{
+		{UpstreamQuotaConfig{Name: "infura", Rate: 10, Burst: 20}, true},
+		// misconfigured
+		{UpstreamQuotaConfig{Rate: 10, Burst: 20}, false},
+		{UpstreamQuotaConfig{Name: "infura", Burst: 20}, false},
+		{UpstreamQuotaConfig{Name: "infura", Rate: 10}, false},
+	}
+
+	for _, tt := range tests {
+		conf := UpstreamQuotasConfig{Enabled: true, Providers: []UpstreamQuotaConfig{tt.provider}}
+		err := SetUpstreamQuota(&conf, nil)
+		assert.Equal(t, tt.ok, err == nil)
+
+		if tt.ok {
+			assert.Equal(t, 1, len(upstreamQuota.Load().(*upstreamQuotaPolicy).buckets))
+		}
+	}
+}
+
+func TestUpstreamQuotaMiddleware(t *testing.T) {
+	defer upstreamQuota.Store(&upstreamQuotaPolicy{})
+
+	errRedis := errors.New("redis unavailable")
+
+	ctx := context.WithValue(context.Background(), ctxKeyClient, &node.Web3goClient{URL: "http://127.0.0.1:8545"})
+	msg := &rpc.JsonRpcMessage{Version: "2.0", ID: json.RawMessage("1"), Method: "eth_blockNumber"}
+
+	tests := []struct {
+		enabled  bool
+		nodes    []string
+		maxWait  time.Duration
+		failOpen bool
+		bucket   *fakeQuotaBucket
+		allowed  bool
+		takes    int
+	}{
+		// disabled
+		{false, []string{"*"}, 0, false, &fakeQuotaBucket{err: errRedis}, true, 0},
+		// node not matched
+		{true, []string{"127.0.0.2*"}, 0, false, &fakeQuotaBucket{err: errRedis}, true, 0},
+		{true, []string{"127.0.0.1*"}, 0, false, &fakeQuotaBucket{}, true, 1},
+		// rejected immediately without max wait
+		{true, []string{"*"}, 0, false, &fakeQuotaBucket{waits: []time.Duration{time.Millisecond}}, false, 1},
+		// waited for the next token
+		{true, []string{"*"}, time.Second, false, &fakeQuotaBucket{waits: []time.Duration{time.Millisecond}}, true, 2},
+		{true, []string{"*"}, time.Second, false, &fakeQuotaBucket{waits: []time.Duration{time.Minute}}, false, 1},
+		// redis unavailable
+		{true, []string{"*"}, 0, true, &fakeQuotaBucket{err: errRedis}, true, 1},
+		{true, []string{"*"}, 0, false, &fakeQuotaBucket{err: errRedis}, false, 1},
+	}
+
+	for i, tt := range tests {
+		upstreamQuota.Store(&upstreamQuotaPolicy{
+			UpstreamQuotasConfig: UpstreamQuotasConfig{Enabled: tt.enabled, MaxWait: tt.maxWait, FailOpen: tt.failOpen},
+			buckets: []*upstreamQuotaBucket{{
+				UpstreamQuotaConfig: UpstreamQuotaConfig{Name: "infura", Nodes: tt.nodes, Rate: 10, Burst: 1},
+				bucket:              tt.bucket,
+			}},
+		})
+
+		var served bool
+		resp := upstreamQuotaMiddleware(func(ctx context.Context, msg *rpc.JsonRpcMessage) *rpc.JsonRpcMessage {
+			served = true
+			return &rpc.JsonRpcMessage{Version: msg.Version, ID: msg.ID, Result: json.RawMessage("1")}
+		})(ctx, msg)
+
+		assert.Equal(t, tt.allowed, served, i)
+		assert.Equal(t, tt.takes, tt.bucket.takes, i)
+
+		if !tt.allowed {
+			assert.Equal(t, errCodeBusy, resp.Error.Code, i)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package rpc
+
+import (
+	"context"
+
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/pkg/errors"
+	"github.com/scroll-tech/rpc-gateway/util/rpc/handlers"
+	"github.com/scroll-tech/rpc-gateway/util/usage"
+)
+
+var (
+	errUsageDisabled    = errors.New("usage analytics not enabled")
+	errUsageKeyRequired = errors.New("api key required to query usage")
+)
+
+// usageMiddleware aggregates per API key usages once usage analytics enabled.
+func usageMiddleware(next rpc.HandleCallMsgFunc) rpc.HandleCallMsgFunc {
+	return func(ctx context.Context, msg *rpc.JsonRpcMessage) *rpc.JsonRpcMessage {
+		aggregator, ok := usage.Default()
+		if !ok {
+			return next(ctx, msg)
+		}
+
+		token, ok := handlers.GetAccessTokenFromContext(ctx)
+		if !ok {
+			return next(ctx, msg)
+		}
+
+		resp := next(ctx, msg)
+
+		var size int
+		failed := true
+
+		if resp != nil {
+			size = len(resp.Result)
+			failed = resp.Error != nil
+		}
+
+		aggregator.Add(token, msg.Method, failed, size)
+
+		return resp
+	}
+}
+
+// Usage returns the daily usages of the API key in use within the day range inclusively, e.g.
+// `2022-10-01`, which defaults to today if not specified.
+func (api *gatewayAPI) Usage(ctx context.Context, dayFrom, dayTo *string) ([]*usage.DailyUsage, error) {
+	token, ok := handlers.GetAccessTokenFromContext(ctx)
+	if !ok {
+		return nil, errUsageKeyRequired
+	}
+
+	return queryUsage(token, dayFrom, dayTo)
+}
+
+func queryUsage(key string, dayFrom, dayTo *string) ([]*usage.DailyUsage, error) {
+	aggregator, ok := usage.Default()
+	if !ok {
+		return nil, errUsageDisabled
+	}
+
+	var from, to string
+	if dayFrom != nil {
+		from = *dayFrom
+	}
+
+	if dayTo != nil {
+		to = *dayTo
+	}
+
+	return aggregator.Query(key, from, to)
+}
@@ -7,10 +7,12 @@ import (
 )
 
 // web3API provides evm space web3 RPC proxy API.
-type web3API struct{}
+type web3API struct {
+	cache *cache.EthCache
+}
 
 // ClientVersion returns the current client version.
 func (api *web3API) ClientVersion(ctx context.Context) (string, error) {
 	w3c := GetEthClientFromContext(ctx)
-	return cache.EthDefault.GetClientVersion(w3c.Client)
+	return api.cache.GetClientVersion(w3c.Client)
 }
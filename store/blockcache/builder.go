@@ -0,0 +1,183 @@
+package blockcache
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/scroll-tech/rpc-gateway/store"
+)
+
+// Builder generates block cache file offline from continuous finalized blocks.
+type Builder struct {
+	path   string
+	file   *os.File
+	writer *bufio.Writer
+	offset uint64
+	header header
+
+	blocks []blockEntry
+	txs    []txEntry
+}
+
+// NewBuilder creates a builder to generate block cache file, which is written into a
+// temporary file and renamed once finished.
+func NewBuilder(path string, chainID, firstBlock uint64) (*Builder, error) {
+	file, err := os.Create(path + ".tmp")
+	if err != nil {
+		return nil, err
+	}
+
+	b := Builder{
+		path:   path,
+		file:   file,
+		writer: bufio.NewWriter(file),
+		header: header{ChainID: chainID, FirstBlock: firstBlock},
+	}
+
+	// header is written once finished
+	if err := b.write(make([]byte, headerSize)); err != nil {
+		b.Abort()
+		return nil, err
+	}
+
+	return &b, nil
+}
+
+func (b *Builder) write(data []byte) error {
+	if _, err := b.writer.Write(data); err != nil {
+		return err
+	}
+
+	b.offset += uint64(len(data))
+
+	return nil
+}
+
+func (b *Builder) writeBlob(v interface{}) (uint64, uint32, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	offset := b.offset
+	if err := b.write(data); err != nil {
+		return 0, 0, err
+	}
+
+	return offset, uint32(len(data)), nil
+}
+
+// Add appends the next block along with receipts.
+func (b *Builder) Add(data *store.EthData) error {
+	expected := b.header.FirstBlock + uint64(len(b.blocks))
+	if data.Number != expected {
+		return errors.Errorf("block number not continuous, expect %v got %v", expected, data.Number)
+	}
+
+	blockIdx := uint32(len(b.blocks))
+	entry := blockEntry{Hash: data.Block.Hash}
+
+	var err error
+	if entry.Offset, entry.Length, err = b.writeBlob(data.Block); err != nil {
+		return errors.WithMessagef(err, "failed to write block %v", data.Number)
+	}
+
+	for i, tx := range data.Block.Transactions.Transactions() {
+		receipt, ok := data.Receipts[tx.Hash]
+		if !ok {
+			return errors.Errorf("receipt missing for tx %v", tx.Hash)
+		}
+
+		te := txEntry{Hash: tx.Hash, BlockIdx: blockIdx, TxIdx: uint32(i)}
+		if te.Offset, te.Length, err = b.writeBlob(receipt); err != nil {
+			return errors.WithMessagef(err, "failed to write receipt of tx %v", tx.Hash)
+		}
+
+		b.txs = append(b.txs, te)
+	}
+
+	b.blocks = append(b.blocks, entry)
+
+	return nil
+}
+
+// Finish writes indexes and header, and then renames the temporary file.
+func (b *Builder) Finish() error {
+	if err := b.finish(); err != nil {
+		b.Abort()
+		return err
+	}
+
+	return os.Rename(b.path+".tmp", b.path)
+}
+
+func (b *Builder) finish() error {
+	b.header.NumBlocks = uint64(len(b.blocks))
+	b.header.NumTxs = uint64(len(b.txs))
+
+	// block index in number order
+	b.header.BlockIndexOff = b.offset
+	buf := make([]byte, txEntrySize)
+	for i := range b.blocks {
+		b.blocks[i].encode(buf)
+		if err := b.write(buf[:blockEntrySize]); err != nil {
+			return err
+		}
+	}
+
+	// hash index sorted by block hash
+	b.header.HashIndexOff = b.offset
+	hashIndexes := make([]uint32, len(b.blocks))
+	for i := range hashIndexes {
+		hashIndexes[i] = uint32(i)
+	}
+
+	sort.Slice(hashIndexes, func(i, j int) bool {
+		return bytes.Compare(b.blocks[hashIndexes[i]].Hash[:], b.blocks[hashIndexes[j]].Hash[:]) < 0
+	})
+
+	for _, idx := range hashIndexes {
+		copy(buf, b.blocks[idx].Hash[:])
+		putUint32(buf[32:], idx)
+		if err := b.write(buf[:hashEntrySize]); err != nil {
+			return err
+		}
+	}
+
+	// tx index sorted by transaction hash
+	b.header.TxIndexOff = b.offset
+	sort.Slice(b.txs, func(i, j int) bool {
+		return bytes.Compare(b.txs[i].Hash[:], b.txs[j].Hash[:]) < 0
+	})
+
+	for i := range b.txs {
+		b.txs[i].encode(buf)
+		if err := b.write(buf[:txEntrySize]); err != nil {
+			return err
+		}
+	}
+
+	if err := b.writer.Flush(); err != nil {
+		return err
+	}
+
+	if _, err := b.file.WriteAt(b.header.encode(), 0); err != nil {
+		return err
+	}
+
+	if err := b.file.Sync(); err != nil {
+		return err
+	}
+
+	return b.file.Close()
+}
+
+// Abort discards the temporary file.
+func (b *Builder) Abort() {
+	b.file.Close()
+	os.Remove(b.path + ".tmp")
+}
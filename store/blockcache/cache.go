@@ -0,0 +1,201 @@
+package blockcache
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"os"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	web3Types "github.com/openweb3/web3go/types"
+	"github.com/pkg/errors"
+	"github.com/scroll-tech/rpc-gateway/store"
+)
+
+// Cache is a memory mapped read-only block cache file, which is safe for concurrent use.
+type Cache struct {
+	path   string
+	data   []byte
+	header *header
+	unmap  func() error
+}
+
+// Open memory maps the block cache file.
+func Open(path string) (*Cache, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	if info.Size() < headerSize {
+		return nil, errors.Errorf("block cache file %v too small", path)
+	}
+
+	data, unmap, err := mmap(file, int(info.Size()))
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed to mmap block cache file %v", path)
+	}
+
+	h, err := decodeHeader(data)
+	if err != nil {
+		unmap()
+		return nil, errors.WithMessagef(err, "invalid block cache file %v", path)
+	}
+
+	return &Cache{path, data, h, unmap}, nil
+}
+
+// Close unmaps the block cache file.
+func (c *Cache) Close() error {
+	return c.unmap()
+}
+
+func (c *Cache) Path() string {
+	return c.path
+}
+
+func (c *Cache) ChainID() uint64 {
+	return c.header.ChainID
+}
+
+// Range returns the cached block range, which is empty if no block cached.
+func (c *Cache) Range() (first, last uint64, ok bool) {
+	if c.header.NumBlocks == 0 {
+		return 0, 0, false
+	}
+
+	return c.header.FirstBlock, c.header.FirstBlock + c.header.NumBlocks - 1, true
+}
+
+func (c *Cache) blob(offset uint64, length uint32) ([]byte, error) {
+	end := offset + uint64(length)
+	if offset < headerSize || end > c.header.BlockIndexOff {
+		return nil, errCorrupted
+	}
+
+	return c.data[offset:end], nil
+}
+
+func (c *Cache) blockEntry(idx uint64) blockEntry {
+	off := c.header.BlockIndexOff + idx*blockEntrySize
+	return decodeBlockEntry(c.data[off : off+blockEntrySize])
+}
+
+func (c *Cache) block(idx uint64, fullTx bool) (*web3Types.Block, error) {
+	entry := c.blockEntry(idx)
+
+	data, err := c.blob(entry.Offset, entry.Length)
+	if err != nil {
+		return nil, err
+	}
+
+	var block web3Types.Block
+	if err := json.Unmarshal(data, &block); err != nil {
+		return nil, errors.WithMessage(err, "failed to decode cached block")
+	}
+
+	if !fullTx {
+		txs := block.Transactions.Transactions()
+		hashes := make([]common.Hash, 0, len(txs))
+		for i := range txs {
+			hashes = append(hashes, txs[i].Hash)
+		}
+
+		block.Transactions = *web3Types.NewTxOrHashListByHashes(hashes)
+	}
+
+	return &block, nil
+}
+
+// BlockByNumber returns the cached block, or store.ErrNotFound if not cached.
+func (c *Cache) BlockByNumber(bn uint64, fullTx bool) (*web3Types.Block, error) {
+	if bn < c.header.FirstBlock || bn-c.header.FirstBlock >= c.header.NumBlocks {
+		return nil, store.ErrNotFound
+	}
+
+	return c.block(bn-c.header.FirstBlock, fullTx)
+}
+
+// BlockByHash returns the cached block, or store.ErrNotFound if not cached.
+func (c *Cache) BlockByHash(hash common.Hash, fullTx bool) (*web3Types.Block, error) {
+	n := int(c.header.NumBlocks)
+	entryAt := func(i int) []byte {
+		off := c.header.HashIndexOff + uint64(i)*hashEntrySize
+		return c.data[off : off+hashEntrySize]
+	}
+
+	i := sort.Search(n, func(i int) bool {
+		return bytes.Compare(entryAt(i)[:32], hash[:]) >= 0
+	})
+
+	if i >= n || !bytes.Equal(entryAt(i)[:32], hash[:]) {
+		return nil, store.ErrNotFound
+	}
+
+	return c.block(uint64(binary.LittleEndian.Uint32(entryAt(i)[32:])), fullTx)
+}
+
+func (c *Cache) txEntry(hash common.Hash) (txEntry, bool) {
+	n := int(c.header.NumTxs)
+	entryAt := func(i int) []byte {
+		off := c.header.TxIndexOff + uint64(i)*txEntrySize
+		return c.data[off : off+txEntrySize]
+	}
+
+	i := sort.Search(n, func(i int) bool {
+		return bytes.Compare(entryAt(i)[:32], hash[:]) >= 0
+	})
+
+	if i >= n || !bytes.Equal(entryAt(i)[:32], hash[:]) {
+		return txEntry{}, false
+	}
+
+	return decodeTxEntry(entryAt(i)), true
+}
+
+// TransactionByHash returns the cached transaction, or store.ErrNotFound if not cached.
+func (c *Cache) TransactionByHash(hash common.Hash) (*web3Types.TransactionDetail, error) {
+	entry, ok := c.txEntry(hash)
+	if !ok || uint64(entry.BlockIdx) >= c.header.NumBlocks {
+		return nil, store.ErrNotFound
+	}
+
+	block, err := c.block(uint64(entry.BlockIdx), true)
+	if err != nil {
+		return nil, err
+	}
+
+	txs := block.Transactions.Transactions()
+	if int(entry.TxIdx) >= len(txs) {
+		return nil, errCorrupted
+	}
+
+	return &txs[entry.TxIdx], nil
+}
+
+// TransactionReceipt returns the cached receipt, or store.ErrNotFound if not cached.
+func (c *Cache) TransactionReceipt(hash common.Hash) (*web3Types.Receipt, error) {
+	entry, ok := c.txEntry(hash)
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+
+	data, err := c.blob(entry.Offset, entry.Length)
+	if err != nil {
+		return nil, err
+	}
+
+	var receipt web3Types.Receipt
+	if err := json.Unmarshal(data, &receipt); err != nil {
+		return nil, errors.WithMessage(err, "failed to decode cached receipt")
+	}
+
+	return &receipt, nil
+}
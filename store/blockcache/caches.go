@@ -0,0 +1,106 @@
+package blockcache
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	web3Types "github.com/openweb3/web3go/types"
+	"github.com/pkg/errors"
+	"github.com/scroll-tech/rpc-gateway/store"
+	"github.com/sirupsen/logrus"
+)
+
+// Config configurations of block cache files mounted by gateway.
+type Config struct {
+	// block cache files generated offline by the `blockcache build` command
+	Paths []string
+}
+
+// Caches is a set of block cache files, e.g. for different block ranges.
+type Caches []*Cache
+
+// OpenCaches opens the block cache files of the specified chain, and files of other chains
+// are ignored.
+func OpenCaches(paths []string, chainID uint64) (Caches, error) {
+	var caches Caches
+
+	for _, path := range paths {
+		cache, err := Open(path)
+		if err != nil {
+			caches.Close()
+			return nil, err
+		}
+
+		if cache.ChainID() != chainID {
+			cache.Close()
+			continue
+		}
+
+		first, last, _ := cache.Range()
+		logrus.WithFields(logrus.Fields{
+			"path":  path,
+			"first": first,
+			"last":  last,
+		}).Info("Block cache file mounted")
+
+		caches = append(caches, cache)
+	}
+
+	return caches, nil
+}
+
+func (cs Caches) Close() {
+	for _, c := range cs {
+		c.Close()
+	}
+}
+
+// BlockByNumber returns the cached block, or store.ErrNotFound if not cached.
+func (cs Caches) BlockByNumber(bn uint64, fullTx bool) (block *web3Types.Block, err error) {
+	err = cs.lookup(func(c *Cache) (err error) {
+		block, err = c.BlockByNumber(bn, fullTx)
+		return
+	})
+
+	return
+}
+
+// BlockByHash returns the cached block, or store.ErrNotFound if not cached.
+func (cs Caches) BlockByHash(hash common.Hash, fullTx bool) (block *web3Types.Block, err error) {
+	err = cs.lookup(func(c *Cache) (err error) {
+		block, err = c.BlockByHash(hash, fullTx)
+		return
+	})
+
+	return
+}
+
+// TransactionByHash returns the cached transaction, or store.ErrNotFound if not cached.
+func (cs Caches) TransactionByHash(hash common.Hash) (tx *web3Types.TransactionDetail, err error) {
+	err = cs.lookup(func(c *Cache) (err error) {
+		tx, err = c.TransactionByHash(hash)
+		return
+	})
+
+	return
+}
+
+// TransactionReceipt returns the cached receipt, or store.ErrNotFound if not cached.
+func (cs Caches) TransactionReceipt(hash common.Hash) (receipt *web3Types.Receipt, err error) {
+	err = cs.lookup(func(c *Cache) (err error) {
+		receipt, err = c.TransactionReceipt(hash)
+		return
+	})
+
+	return
+}
+
+// lookup looks up caches in order until found or any unexpected error.
+func (cs Caches) lookup(fn func(c *Cache) error) error {
+	for _, c := range cs {
+		err := fn(c)
+		if !errors.Is(err, store.ErrNotFound) {
+			return err
+		}
+	}
+
+	return store.ErrNotFound
+}
@@ -0,0 +1,137 @@
+// Package blockcache provides a read-only block cache file format for finalized blocks and
+// receipts, which is generated offline and memory mapped by gateway instances to serve hot
+// historical ranges with near-zero memory overhead.
+//
+// File layout (little endian):
+//
+//	header       fixed size header, see headerSize
+//	data         block (with full transactions) and receipt JSON blobs
+//	block index  entries in block number order: hash, offset and length of block blob
+//	hash index   entries sorted by block hash: hash and block index
+//	tx index     entries sorted by transaction hash: hash, offset and length of receipt
+//	             blob, block index and transaction index in block
+package blockcache
+
+import (
+	"encoding/binary"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	magic   = "GWBC"
+	version = 1
+
+	headerSize     = 64
+	blockEntrySize = 32 + 8 + 4
+	hashEntrySize  = 32 + 4
+	txEntrySize    = 32 + 8 + 4 + 4 + 4
+)
+
+var errCorrupted = errors.New("block cache file corrupted")
+
+type header struct {
+	ChainID       uint64
+	FirstBlock    uint64
+	NumBlocks     uint64
+	NumTxs        uint64
+	BlockIndexOff uint64
+	HashIndexOff  uint64
+	TxIndexOff    uint64
+}
+
+func (h *header) encode() []byte {
+	buf := make([]byte, headerSize)
+
+	copy(buf, magic)
+	binary.LittleEndian.PutUint32(buf[4:], version)
+	binary.LittleEndian.PutUint64(buf[8:], h.ChainID)
+	binary.LittleEndian.PutUint64(buf[16:], h.FirstBlock)
+	binary.LittleEndian.PutUint64(buf[24:], h.NumBlocks)
+	binary.LittleEndian.PutUint64(buf[32:], h.NumTxs)
+	binary.LittleEndian.PutUint64(buf[40:], h.BlockIndexOff)
+	binary.LittleEndian.PutUint64(buf[48:], h.HashIndexOff)
+	binary.LittleEndian.PutUint64(buf[56:], h.TxIndexOff)
+
+	return buf
+}
+
+// decodeHeader decodes and validates header against the file size.
+func decodeHeader(data []byte) (*header, error) {
+	if len(data) < headerSize || string(data[:4]) != magic {
+		return nil, errors.New("not a block cache file")
+	}
+
+	if v := binary.LittleEndian.Uint32(data[4:]); v != version {
+		return nil, errors.Errorf("unsupported block cache version %v", v)
+	}
+
+	h := header{
+		ChainID:       binary.LittleEndian.Uint64(data[8:]),
+		FirstBlock:    binary.LittleEndian.Uint64(data[16:]),
+		NumBlocks:     binary.LittleEndian.Uint64(data[24:]),
+		NumTxs:        binary.LittleEndian.Uint64(data[32:]),
+		BlockIndexOff: binary.LittleEndian.Uint64(data[40:]),
+		HashIndexOff:  binary.LittleEndian.Uint64(data[48:]),
+		TxIndexOff:    binary.LittleEndian.Uint64(data[56:]),
+	}
+
+	size := uint64(len(data))
+	if h.BlockIndexOff+h.NumBlocks*blockEntrySize != h.HashIndexOff ||
+		h.HashIndexOff+h.NumBlocks*hashEntrySize != h.TxIndexOff ||
+		h.TxIndexOff+h.NumTxs*txEntrySize != size {
+		return nil, errCorrupted
+	}
+
+	return &h, nil
+}
+
+type blockEntry struct {
+	Hash   [32]byte
+	Offset uint64
+	Length uint32
+}
+
+func (e *blockEntry) encode(buf []byte) {
+	copy(buf, e.Hash[:])
+	binary.LittleEndian.PutUint64(buf[32:], e.Offset)
+	binary.LittleEndian.PutUint32(buf[40:], e.Length)
+}
+
+func decodeBlockEntry(buf []byte) blockEntry {
+	var e blockEntry
+	copy(e.Hash[:], buf)
+	e.Offset = binary.LittleEndian.Uint64(buf[32:])
+	e.Length = binary.LittleEndian.Uint32(buf[40:])
+	return e
+}
+
+type txEntry struct {
+	Hash     [32]byte
+	Offset   uint64 // receipt blob
+	Length   uint32
+	BlockIdx uint32
+	TxIdx    uint32
+}
+
+func (e *txEntry) encode(buf []byte) {
+	copy(buf, e.Hash[:])
+	binary.LittleEndian.PutUint64(buf[32:], e.Offset)
+	binary.LittleEndian.PutUint32(buf[40:], e.Length)
+	binary.LittleEndian.PutUint32(buf[44:], e.BlockIdx)
+	binary.LittleEndian.PutUint32(buf[48:], e.TxIdx)
+}
+
+func decodeTxEntry(buf []byte) txEntry {
+	var e txEntry
+	copy(e.Hash[:], buf)
+	e.Offset = binary.LittleEndian.Uint64(buf[32:])
+	e.Length = binary.LittleEndian.Uint32(buf[40:])
+	e.BlockIdx = binary.LittleEndian.Uint32(buf[44:])
+	e.TxIdx = binary.LittleEndian.Uint32(buf[48:])
+	return e
+}
+
+func putUint32(buf []byte, v uint32) {
+	binary.LittleEndian.PutUint32(buf, v)
+}
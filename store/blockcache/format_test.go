@@ -0,0 +1,44 @@
+package blockcache
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHeaderCodec(t *testing.T) {
+	h := header{
+		ChainID:       534352,
+		FirstBlock:    100,
+		NumBlocks:     2,
+		NumTxs:        3,
+		BlockIndexOff: 1000,
+	}
+	h.HashIndexOff = h.BlockIndexOff + h.NumBlocks*blockEntrySize
+	h.TxIndexOff = h.HashIndexOff + h.NumBlocks*hashEntrySize
+
+	size := h.TxIndexOff + h.NumTxs*txEntrySize
+	data := make([]byte, size)
+	copy(data, h.encode())
+
+	decoded, err := decodeHeader(data)
+	assert.Nil(t, err)
+	assert.Equal(t, h, *decoded)
+
+	// truncated file
+	_, err = decodeHeader(data[:size-1])
+	assert.Equal(t, errCorrupted, err)
+
+	// not a block cache file
+	_, err = decodeHeader(make([]byte, size))
+	assert.NotNil(t, err)
+}
+
+func TestTxEntryCodec(t *testing.T) {
+	e := txEntry{Offset: 64, Length: 512, BlockIdx: 7, TxIdx: 3}
+	e.Hash[0], e.Hash[31] = 0xab, 0xcd
+
+	buf := make([]byte, txEntrySize)
+	e.encode(buf)
+	assert.Equal(t, e, decodeTxEntry(buf))
+}
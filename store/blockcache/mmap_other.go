@@ -0,0 +1,19 @@
+//go:build !darwin && !freebsd && !linux
+// +build !darwin,!freebsd,!linux
+
+package blockcache
+
+import (
+	"io"
+	"os"
+)
+
+// mmap falls back to read the whole file into memory on platforms without mmap support.
+func mmap(file *os.File, size int) ([]byte, func() error, error) {
+	data := make([]byte, size)
+	if _, err := io.ReadFull(file, data); err != nil {
+		return nil, nil, err
+	}
+
+	return data, func() error { return nil }, nil
+}
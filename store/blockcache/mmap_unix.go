@@ -0,0 +1,19 @@
+//go:build darwin || freebsd || linux
+// +build darwin freebsd linux
+
+package blockcache
+
+import (
+	"os"
+	"syscall"
+)
+
+// mmap maps the whole file read-only into memory.
+func mmap(file *os.File, size int) ([]byte, func() error, error) {
+	data, err := syscall.Mmap(int(file.Fd()), 0, size, syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return data, func() error { return syscall.Munmap(data) }, nil
+}
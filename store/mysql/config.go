@@ -21,9 +21,14 @@ var allModels = []interface{}{
 	&conf{},
 	&RateLimit{},
 	&User{},
+	&ApiKey{},
+	&KeyUsage{},
 	&Contract{},
 	&epochBlockMap{},
 	&bnPartition{},
+	&logBloom{},
+	&coldSegment{},
+	&coldBlockHash{},
 }
 
 // Config represents the mysql configurations to open a database instance.
@@ -45,6 +50,12 @@ type Config struct {
 	AddressIndexedLogPartitions uint32 `default:"100"`
 
 	MaxBnRangedArchiveLogPartitions uint32 `default:"5"`
+
+	// whether to index event logs by section bloom to speed up `getLogs` over large ranges
+	LogBloomEnabled bool
+
+	// offload old blocks to object storage
+	Cold ColdConfig
 }
 
 func mustNewConfigFromViper(key string) *Config {
@@ -109,6 +120,8 @@ func (config *Config) MustOpenOrCreate(option StoreOption) *MysqlStore {
 				WithField("partitions", config.AddressIndexedLogPartitions).
 				Fatal("Failed to create address indexed log tables")
 		}
+	} else {
+		config.mustMigrate(db)
 	}
 
 	if sqlDb, err := db.DB(); err != nil {
@@ -124,6 +137,21 @@ func (config *Config) MustOpenOrCreate(option StoreOption) *MysqlStore {
 	return mustNewStore(db, config, option)
 }
 
+// mustMigrate creates tables of optional features if absent for database created in old version.
+func (config *Config) mustMigrate(db *gorm.DB) {
+	if config.LogBloomEnabled {
+		if err := newLogBloomStore(db).MigrateLogBlooms(); err != nil {
+			logrus.WithError(err).Fatal("Failed to migrate log bloom table")
+		}
+	}
+
+	if config.Cold.Enabled {
+		if err := db.AutoMigrate(&coldSegment{}, &coldBlockHash{}); err != nil {
+			logrus.WithError(err).Fatal("Failed to migrate cold block tables")
+		}
+	}
+}
+
 func (config *Config) mustNewDB(database string) *gorm.DB {
 	logrusLogLevel := logrus.GetLevel()
 	gLogLevel := gormLogger.Warn
@@ -9,6 +9,7 @@ import (
 	"github.com/scroll-tech/rpc-gateway/store"
 	citypes "github.com/scroll-tech/rpc-gateway/types"
 	"github.com/scroll-tech/rpc-gateway/util/metrics"
+	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
 )
 
@@ -32,10 +33,13 @@ type MysqlStore struct {
 	*confStore
 	*UserStore
 	*RateLimitStore
+	*ApiKeyStore
+	*KeyUsageStore
 	ls   *logStore
 	ails *AddressIndexedLogStore
 	bcls *bigContractLogStore
 	cs   *ContractStore
+	lbs  *logBloomStore
 
 	// config
 	config *Config
@@ -51,18 +55,29 @@ func mustNewStore(db *gorm.DB, config *Config, option StoreOption) *MysqlStore {
 	ebms := newEpochBlockMapStore(db, config)
 	ails := NewAddressIndexedLogStore(db, cs, config.AddressIndexedLogPartitions)
 
+	var cold *coldBlockStore
+	if config.Cold.Enabled {
+		var err error
+		if cold, err = newColdBlockStore(db, config.Cold); err != nil {
+			logrus.WithError(err).Fatal("Failed to create cold block store")
+		}
+	}
+
 	return &MysqlStore{
 		baseStore:          newBaseStore(db),
 		epochBlockMapStore: ebms,
 		txStore:            newTxStore(db),
-		blockStore:         newBlockStore(db),
+		blockStore:         newBlockStore(db, cold),
 		confStore:          newConfStore(db),
 		UserStore:          newUserStore(db),
 		RateLimitStore:     NewRateLimitStore(db),
+		ApiKeyStore:        NewApiKeyStore(db),
+		KeyUsageStore:      NewKeyUsageStore(db),
 		ls:                 newLogStore(db, cs, ebms, pruner.newBnPartitionObsChan),
 		bcls:               newBigContractLogStore(db, cs, ebms, ails, pruner.newBnPartitionObsChan),
 		ails:               ails,
 		cs:                 cs,
+		lbs:                newLogBloomStore(db),
 		config:             config,
 		disabler:           option.Disabler,
 		pruner:             pruner,
@@ -167,6 +182,13 @@ func (ms *MysqlStore) Pushn(dataSlice []*store.EpochData) error {
 			if err := ms.ls.Add(dbTx, dataSlice, logPartition); err != nil {
 				return errors.WithMessage(err, "failed to save event logs")
 			}
+
+			// save event log blooms
+			if ms.config.LogBloomEnabled {
+				if err := ms.lbs.Add(dbTx, dataSlice); err != nil {
+					return errors.WithMessage(err, "failed to save event log blooms")
+				}
+			}
 		}
 
 		// save epoch to block mapping data
@@ -237,6 +259,48 @@ func (ms *MysqlStore) GetLogs(ctx context.Context, storeFilter store.LogFilter)
 	updater := metrics.Registry.Store.GetLogs()
 	defer updater.Update()
 
+	if !ms.config.LogBloomEnabled {
+		return ms.getLogs(ctx, storeFilter)
+	}
+
+	// skip block ranges without any matched event log by section blooms
+	ranges, err := ms.lbs.MatchedRanges(&storeFilter)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to match log blooms")
+	}
+
+	if len(ranges) == 0 && storeFilter.Contracts.IsNull() {
+		// check if block range already pruned
+		_, _, err := ms.ls.searchPartitions(bnPartitionedLogEntity, citypes.RangeUint64{
+			From: storeFilter.BlockFrom,
+			To:   storeFilter.BlockTo,
+		})
+
+		return nil, err
+	}
+
+	var result []*store.Log
+	for _, r := range ranges {
+		filter := storeFilter
+		filter.BlockFrom, filter.BlockTo = r.From, r.To
+
+		logs, err := ms.getLogs(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, logs...)
+
+		// check log count
+		if len(result) > int(store.MaxLogLimit) {
+			return nil, store.ErrGetLogsResultSetTooLarge
+		}
+	}
+
+	return result, nil
+}
+
+func (ms *MysqlStore) getLogs(ctx context.Context, storeFilter store.LogFilter) ([]*store.Log, error) {
 	contracts := storeFilter.Contracts.ToSlice()
 
 	// if address not specified, query from universal event log table partition
@@ -324,4 +388,9 @@ func (ms *MysqlStore) GetLogs(ctx context.Context, storeFilter store.LogFilter)
 // Prune prune data from db store.
 func (ms *MysqlStore) Prune() {
 	go ms.pruner.schedulePrune(ms.config)
+
+	// offload old blocks to object storage
+	if ms.blockStore.cold != nil {
+		go ms.blockStore.cold.scheduleOffload()
+	}
 }
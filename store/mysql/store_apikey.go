@@ -0,0 +1,128 @@
+package mysql
+
+import (
+	"time"
+
+	"github.com/scroll-tech/rpc-gateway/util/apikey"
+	"gorm.io/gorm"
+)
+
+var _ apikey.Store = (*ApiKeyStore)(nil)
+
+// ApiKey API key issued by the gateway.
+type ApiKey struct {
+	ID        uint32
+	ApiKey    string `gorm:"size:128;not null;unique"`
+	Name      string `gorm:"size:256;not null"`
+	SID       uint32 // bound rate limit strategy ID
+	ExpiresAt *time.Time
+	Revoked   bool `gorm:"not null;default:false"`
+	// last time to remind rotation
+	RemindedAt *time.Time
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+func (ApiKey) TableName() string {
+	return "api_keys"
+}
+
+func (model *ApiKey) toKey() *apikey.Key {
+	k := apikey.Key{
+		Key:       model.ApiKey,
+		Name:      model.Name,
+		SID:       model.SID,
+		CreatedAt: model.CreatedAt,
+		Revoked:   model.Revoked,
+	}
+
+	if model.ExpiresAt != nil {
+		k.ExpiresAt = *model.ExpiresAt
+	}
+
+	if model.RemindedAt != nil {
+		k.RemindedAt = *model.RemindedAt
+	}
+
+	return &k
+}
+
+type ApiKeyStore struct {
+	*baseStore
+}
+
+func NewApiKeyStore(db *gorm.DB) *ApiKeyStore {
+	return &ApiKeyStore{
+		baseStore: newBaseStore(db),
+	}
+}
+
+// MigrateApiKeys creates the API key table if absent, e.g. database created in old version.
+func (as *ApiKeyStore) MigrateApiKeys() error {
+	return as.db.AutoMigrate(&ApiKey{})
+}
+
+// GetApiKey implements the apikey.Store interface.
+func (as *ApiKeyStore) GetApiKey(key string) (*apikey.Key, bool, error) {
+	var model ApiKey
+	exists, err := as.exists(&model, "api_key = ?", key)
+	if err != nil || !exists {
+		return nil, false, err
+	}
+
+	return model.toKey(), true, nil
+}
+
+// ListApiKeys implements the apikey.Store interface.
+func (as *ApiKeyStore) ListApiKeys() ([]*apikey.Key, error) {
+	var models []ApiKey
+	if err := as.db.Find(&models).Error; err != nil {
+		return nil, err
+	}
+
+	keys := make([]*apikey.Key, 0, len(models))
+	for i := range models {
+		keys = append(keys, models[i].toKey())
+	}
+
+	return keys, nil
+}
+
+// SaveApiKey implements the apikey.Store interface.
+func (as *ApiKeyStore) SaveApiKey(k *apikey.Key) error {
+	var expiresAt *time.Time
+	if !k.ExpiresAt.IsZero() {
+		expiresAt = &k.ExpiresAt
+	}
+
+	var remindedAt *time.Time
+	if !k.RemindedAt.IsZero() {
+		remindedAt = &k.RemindedAt
+	}
+
+	var model ApiKey
+	exists, err := as.exists(&model, "api_key = ?", k.Key)
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		return as.db.Create(&ApiKey{
+			ApiKey:     k.Key,
+			Name:       k.Name,
+			SID:        k.SID,
+			ExpiresAt:  expiresAt,
+			Revoked:    k.Revoked,
+			RemindedAt: remindedAt,
+			CreatedAt:  k.CreatedAt,
+		}).Error
+	}
+
+	return as.db.Model(&model).Updates(map[string]interface{}{
+		"name":        k.Name,
+		"sid":         k.SID,
+		"expires_at":  expiresAt,
+		"revoked":     k.Revoked,
+		"reminded_at": remindedAt,
+	}).Error
+}
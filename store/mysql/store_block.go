@@ -5,6 +5,7 @@ import (
 
 	"github.com/Conflux-Chain/go-conflux-sdk/types"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
 	"github.com/scroll-tech/rpc-gateway/store"
 	"github.com/scroll-tech/rpc-gateway/util"
 	"gorm.io/gorm"
@@ -69,17 +70,34 @@ func (block *block) parseBlockExtra() *store.BlockExtra {
 
 type blockStore struct {
 	db *gorm.DB
+	// store of blocks offloaded to object storage, nil if disabled
+	cold *coldBlockStore
 }
 
-func newBlockStore(db *gorm.DB) *blockStore {
+func newBlockStore(db *gorm.DB, cold *coldBlockStore) *blockStore {
 	return &blockStore{
-		db: db,
+		db:   db,
+		cold: cold,
 	}
 }
 
-func (bs *blockStore) loadBlockSummary(whereClause string, args ...interface{}) (*store.BlockSummary, error) {
+// loadBlockSummary loads block summary from database, or from cold store via the fallback
+// function if not found in database and cold store enabled.
+func (bs *blockStore) loadBlockSummary(
+	ctx context.Context, coldFunc func(ctx context.Context) (*block, error),
+	whereClause string, args ...interface{},
+) (*store.BlockSummary, error) {
 	var blk block
-	if err := bs.db.Where(whereClause, args...).First(&blk).Error; err != nil {
+	err := bs.db.Where(whereClause, args...).First(&blk).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) && bs.cold != nil {
+		var coldBlk *block
+		if coldBlk, err = coldFunc(ctx); err == nil {
+			blk = *coldBlk
+		}
+	}
+
+	if err != nil {
 		return nil, err
 	}
 
@@ -110,6 +128,17 @@ func (bs *blockStore) GetBlocksByEpoch(ctx context.Context, epochNumber uint64)
 		result = append(result, types.Hash(hash))
 	}
 
+	if len(result) == 0 && bs.cold != nil {
+		blocks, err := bs.cold.blocksByEpoch(ctx, epochNumber)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+
+		for _, b := range blocks {
+			result = append(result, types.Hash(b.Hash))
+		}
+	}
+
 	if len(result) == 0 { // each epoch has at least 1 block (pivot block)
 		return result, gorm.ErrRecordNotFound
 	}
@@ -123,7 +152,9 @@ func (bs *blockStore) GetBlockByEpoch(ctx context.Context, epochNumber uint64) (
 }
 
 func (bs *blockStore) GetBlockSummaryByEpoch(ctx context.Context, epochNumber uint64) (*store.BlockSummary, error) {
-	return bs.loadBlockSummary("epoch = ? AND pivot = true", epochNumber)
+	return bs.loadBlockSummary(ctx, func(ctx context.Context) (*block, error) {
+		return bs.cold.pivotBlockByEpoch(ctx, epochNumber)
+	}, "epoch = ? AND pivot = true", epochNumber)
 }
 
 func (bs *blockStore) GetBlockByHash(ctx context.Context, blockHash types.Hash) (*store.Block, error) {
@@ -132,7 +163,9 @@ func (bs *blockStore) GetBlockByHash(ctx context.Context, blockHash types.Hash)
 
 func (bs *blockStore) GetBlockSummaryByHash(ctx context.Context, blockHash types.Hash) (*store.BlockSummary, error) {
 	hash := blockHash.String()
-	return bs.loadBlockSummary("hash_id = ? AND hash = ?", util.GetShortIdOfHash(hash), hash)
+	return bs.loadBlockSummary(ctx, func(ctx context.Context) (*block, error) {
+		return bs.cold.blockByHash(ctx, hash)
+	}, "hash_id = ? AND hash = ?", util.GetShortIdOfHash(hash), hash)
 }
 
 func (bs *blockStore) GetBlockByBlockNumber(ctx context.Context, blockNumber uint64) (*store.Block, error) {
@@ -140,7 +173,9 @@ func (bs *blockStore) GetBlockByBlockNumber(ctx context.Context, blockNumber uin
 }
 
 func (bs *blockStore) GetBlockSummaryByBlockNumber(ctx context.Context, blockNumber uint64) (*store.BlockSummary, error) {
-	return bs.loadBlockSummary("block_number = ?", blockNumber)
+	return bs.loadBlockSummary(ctx, func(ctx context.Context) (*block, error) {
+		return bs.cold.blockByNumber(ctx, blockNumber)
+	}, "block_number = ?", blockNumber)
 }
 
 // Add batch save epoch blocks into db store.
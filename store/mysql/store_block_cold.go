@@ -0,0 +1,371 @@
+package mysql
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/gob"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/pkg/errors"
+	"github.com/scroll-tech/rpc-gateway/util"
+	"github.com/scroll-tech/rpc-gateway/util/objstore"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// ColdConfig configurations to offload blocks older than max age to S3-compatible object
+// storage, so that only hot data kept in database while historical queries still served.
+type ColdConfig struct {
+	Enabled bool
+	// blocks older than the age are offloaded
+	MaxAge time.Duration `default:"720h"`
+	// number of epochs per segment object
+	SegmentSize uint64 `default:"10000"`
+	// interval to check blocks to offload
+	Interval time.Duration `default:"10m"`
+	// number of decoded segments cached in memory
+	CacheSize int `default:"16"`
+	S3        objstore.Config
+}
+
+// coldSegment is a range of epochs whose blocks offloaded to object storage.
+type coldSegment struct {
+	ID        uint64
+	EpochFrom uint64 `gorm:"not null;uniqueIndex"`
+	EpochTo   uint64 `gorm:"not null;index"`
+	BnFrom    uint64 `gorm:"not null;index"`
+	BnTo      uint64 `gorm:"not null;index"`
+	ObjectKey string `gorm:"size:256;not null"`
+	Count     uint64 `gorm:"not null"` // number of blocks
+	CreatedAt time.Time
+}
+
+func (coldSegment) TableName() string {
+	return "cold_block_segments"
+}
+
+// coldBlockHash indexes offloaded blocks by hash.
+type coldBlockHash struct {
+	ID     uint64
+	HashId uint64 `gorm:"not null;index"`
+	Epoch  uint64 `gorm:"not null"`
+}
+
+func (coldBlockHash) TableName() string {
+	return "cold_block_hashes"
+}
+
+// blockColumns is the columnar format of blocks within segment object, which is gob encoded
+// and gzip compressed.
+type blockColumns struct {
+	Epochs       []uint64
+	BlockNumbers []uint64
+	Hashes       []string
+	Pivots       []bool
+	RawData      [][]byte
+	Extra        [][]byte
+}
+
+func encodeBlockColumns(blocks []*block) ([]byte, error) {
+	var cols blockColumns
+	for _, b := range blocks {
+		cols.Epochs = append(cols.Epochs, b.Epoch)
+		cols.BlockNumbers = append(cols.BlockNumbers, b.BlockNumber)
+		cols.Hashes = append(cols.Hashes, b.Hash)
+		cols.Pivots = append(cols.Pivots, b.Pivot)
+		cols.RawData = append(cols.RawData, b.RawData)
+		cols.Extra = append(cols.Extra, b.Extra)
+	}
+
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+
+	if err := gob.NewEncoder(writer).Encode(&cols); err != nil {
+		return nil, errors.WithMessage(err, "failed to encode block columns")
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, errors.WithMessage(err, "failed to compress block columns")
+	}
+
+	return buf.Bytes(), nil
+}
+
+func decodeBlockColumns(data []byte) ([]*block, error) {
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to decompress block columns")
+	}
+
+	decompressed, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to decompress block columns")
+	}
+
+	var cols blockColumns
+	if err := gob.NewDecoder(bytes.NewReader(decompressed)).Decode(&cols); err != nil {
+		return nil, errors.WithMessage(err, "failed to decode block columns")
+	}
+
+	blocks := make([]*block, len(cols.Epochs))
+	for i := range cols.Epochs {
+		blocks[i] = &block{
+			Epoch:       cols.Epochs[i],
+			BlockNumber: cols.BlockNumbers[i],
+			Hash:        cols.Hashes[i],
+			Pivot:       cols.Pivots[i],
+			RawData:     cols.RawData[i],
+			RawDataLen:  uint64(len(cols.RawData[i])),
+			Extra:       cols.Extra[i],
+		}
+	}
+
+	return blocks, nil
+}
+
+// coldBlockStore offloads old blocks to object storage, and serves queries of offloaded blocks.
+type coldBlockStore struct {
+	*baseStore
+	conf     ColdConfig
+	s3       *objstore.S3
+	segments *lru.Cache // object key => []*block
+}
+
+func newColdBlockStore(db *gorm.DB, conf ColdConfig) (*coldBlockStore, error) {
+	if conf.SegmentSize == 0 || conf.CacheSize <= 0 {
+		return nil, errors.Errorf("invalid segment size %v or cache size %v", conf.SegmentSize, conf.CacheSize)
+	}
+
+	s3, err := objstore.NewS3(conf.S3)
+	if err != nil {
+		return nil, err
+	}
+
+	segments, _ := lru.New(conf.CacheSize)
+
+	return &coldBlockStore{
+		baseStore: newBaseStore(db),
+		conf:      conf,
+		s3:        s3,
+		segments:  segments,
+	}, nil
+}
+
+// loadSegment loads blocks of the segment from cache or object storage.
+func (cbs *coldBlockStore) loadSegment(ctx context.Context, seg *coldSegment) ([]*block, error) {
+	if blocks, ok := cbs.segments.Get(seg.ObjectKey); ok {
+		return blocks.([]*block), nil
+	}
+
+	data, err := cbs.s3.GetObject(ctx, seg.ObjectKey)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed to get cold segment %v", seg.ObjectKey)
+	}
+
+	blocks, err := decodeBlockColumns(data)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed to decode cold segment %v", seg.ObjectKey)
+	}
+
+	cbs.segments.Add(seg.ObjectKey, blocks)
+
+	return blocks, nil
+}
+
+// find returns the offloaded blocks matched within the segment of where clause.
+func (cbs *coldBlockStore) find(
+	ctx context.Context, match func(*block) bool, whereClause string, args ...interface{},
+) ([]*block, error) {
+	var seg coldSegment
+	if err := cbs.db.Where(whereClause, args...).Where("`count` > 0").First(&seg).Error; err != nil {
+		return nil, err
+	}
+
+	blocks, err := cbs.loadSegment(ctx, &seg)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*block
+	for _, b := range blocks {
+		if match(b) {
+			result = append(result, b)
+		}
+	}
+
+	if len(result) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	return result, nil
+}
+
+func (cbs *coldBlockStore) blocksByEpoch(ctx context.Context, epoch uint64) ([]*block, error) {
+	return cbs.find(ctx, func(b *block) bool {
+		return b.Epoch == epoch
+	}, "epoch_from <= ? AND epoch_to >= ?", epoch, epoch)
+}
+
+func (cbs *coldBlockStore) pivotBlockByEpoch(ctx context.Context, epoch uint64) (*block, error) {
+	blocks, err := cbs.find(ctx, func(b *block) bool {
+		return b.Epoch == epoch && b.Pivot
+	}, "epoch_from <= ? AND epoch_to >= ?", epoch, epoch)
+	if err != nil {
+		return nil, err
+	}
+
+	return blocks[0], nil
+}
+
+func (cbs *coldBlockStore) blockByNumber(ctx context.Context, bn uint64) (*block, error) {
+	blocks, err := cbs.find(ctx, func(b *block) bool {
+		return b.BlockNumber == bn
+	}, "bn_from <= ? AND bn_to >= ?", bn, bn)
+	if err != nil {
+		return nil, err
+	}
+
+	return blocks[0], nil
+}
+
+func (cbs *coldBlockStore) blockByHash(ctx context.Context, hash string) (*block, error) {
+	var indexes []coldBlockHash
+	if err := cbs.db.Where("hash_id = ?", util.GetShortIdOfHash(hash)).Find(&indexes).Error; err != nil {
+		return nil, err
+	}
+
+	// short id of hash may collide
+	for _, v := range indexes {
+		blocks, err := cbs.find(ctx, func(b *block) bool {
+			return b.Hash == hash
+		}, "epoch_from <= ? AND epoch_to >= ?", v.Epoch, v.Epoch)
+
+		if err == nil {
+			return blocks[0], nil
+		}
+
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+	}
+
+	return nil, gorm.ErrRecordNotFound
+}
+
+// scheduleOffload periodically offloads blocks older than max age. Be noted this function will
+// block caller thread.
+func (cbs *coldBlockStore) scheduleOffload() {
+	ticker := time.NewTicker(cbs.conf.Interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		for {
+			offloaded, err := cbs.offloadOnce(context.Background())
+			if err != nil {
+				logrus.WithError(err).Error("Failed to offload cold blocks")
+			}
+
+			if err != nil || !offloaded {
+				break
+			}
+		}
+	}
+}
+
+// offloadOnce offloads the next segment of blocks if older than max age, and returns true if
+// offloaded.
+func (cbs *coldBlockStore) offloadOnce(ctx context.Context) (bool, error) {
+	var minEpoch, maxEpoch *uint64
+	if err := cbs.db.Raw("SELECT MIN(epoch), MAX(epoch) FROM blocks").Row().Scan(&minEpoch, &maxEpoch); err != nil {
+		return false, errors.WithMessage(err, "failed to get epoch range of blocks")
+	}
+
+	if minEpoch == nil || maxEpoch == nil { // no blocks
+		return false, nil
+	}
+
+	from := *minEpoch
+
+	var lastSegs []coldSegment
+	if err := cbs.db.Order("epoch_to DESC").Limit(1).Find(&lastSegs).Error; err != nil {
+		return false, errors.WithMessage(err, "failed to get the last cold segment")
+	}
+
+	if len(lastSegs) > 0 {
+		from = util.MaxUint64(from, lastSegs[0].EpochTo+1)
+	}
+
+	to := from + cbs.conf.SegmentSize - 1
+	if *maxEpoch <= to { // segment not completed yet
+		return false, nil
+	}
+
+	var blocks []*block
+	if err := cbs.db.Where("epoch BETWEEN ? AND ?", from, to).Order("epoch, id").Find(&blocks).Error; err != nil {
+		return false, errors.WithMessage(err, "failed to get blocks to offload")
+	}
+
+	seg := coldSegment{EpochFrom: from, EpochTo: to, Count: uint64(len(blocks))}
+
+	if len(blocks) > 0 {
+		// check age by the latest block of segment
+		var summary types.BlockSummary
+		util.MustUnmarshalRLP(blocks[len(blocks)-1].RawData, &summary)
+
+		if summary.Timestamp != nil {
+			ts := time.Unix(summary.Timestamp.ToInt().Int64(), 0)
+			if time.Since(ts) < cbs.conf.MaxAge {
+				return false, nil
+			}
+		}
+
+		seg.BnFrom, seg.BnTo = blocks[0].BlockNumber, blocks[0].BlockNumber
+		for _, b := range blocks {
+			seg.BnFrom = util.MinUint64(seg.BnFrom, b.BlockNumber)
+			seg.BnTo = util.MaxUint64(seg.BnTo, b.BlockNumber)
+		}
+
+		data, err := encodeBlockColumns(blocks)
+		if err != nil {
+			return false, err
+		}
+
+		seg.ObjectKey = fmt.Sprintf("blocks/%020d-%020d.gob.gz", from, to)
+		if err := cbs.s3.PutObject(ctx, seg.ObjectKey, data); err != nil {
+			return false, errors.WithMessage(err, "failed to upload cold segment")
+		}
+	}
+
+	err := cbs.db.Transaction(func(dbTx *gorm.DB) error {
+		if err := dbTx.Create(&seg).Error; err != nil {
+			return errors.WithMessage(err, "failed to save cold segment")
+		}
+
+		var hashes []*coldBlockHash
+		for _, b := range blocks {
+			hashes = append(hashes, &coldBlockHash{HashId: b.HashId, Epoch: b.Epoch})
+		}
+
+		if len(hashes) > 0 {
+			if err := dbTx.CreateInBatches(hashes, defaultBatchSizeBlockInsert).Error; err != nil {
+				return errors.WithMessage(err, "failed to save cold block hashes")
+			}
+		}
+
+		return dbTx.Where("epoch BETWEEN ? AND ?", from, to).Delete(&block{}).Error
+	})
+	if err != nil {
+		return false, err
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"epochFrom": from, "epochTo": to, "blocks": len(blocks), "object": seg.ObjectKey,
+	}).Info("Cold blocks offloaded to object storage")
+
+	return true, nil
+}
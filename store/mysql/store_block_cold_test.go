@@ -0,0 +1,21 @@
+package mysql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBlockColumnsCodec(t *testing.T) {
+	blocks := []*block{
+		{Epoch: 1, BlockNumber: 1, Hash: "0x01", Pivot: true, RawData: []byte{1, 2}, RawDataLen: 2, Extra: []byte("{}")},
+		{Epoch: 2, BlockNumber: 2, Hash: "0x02", RawData: []byte{3}, RawDataLen: 1, Extra: []byte("{}")},
+	}
+
+	data, err := encodeBlockColumns(blocks)
+	assert.NoError(t, err)
+
+	decoded, err := decodeBlockColumns(data)
+	assert.NoError(t, err)
+	assert.Equal(t, blocks, decoded)
+}
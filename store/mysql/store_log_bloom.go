@@ -0,0 +1,213 @@
+package mysql
+
+import (
+	"fmt"
+	"strings"
+
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+	"github.com/scroll-tech/rpc-gateway/store"
+	"github.com/scroll-tech/rpc-gateway/types"
+	"github.com/scroll-tech/rpc-gateway/util"
+	"github.com/scroll-tech/rpc-gateway/util/metrics"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// number of blocks per log bloom section
+const logBloomSectionSize = 4096
+
+// logBloom is the bloom of contract addresses and topics of event logs within a section of
+// blocks, so as to skip sections without any matched event log for `getLogs` over large ranges.
+type logBloom struct {
+	Section uint64 `gorm:"primaryKey;autoIncrement:false"`
+	Bloom   []byte `gorm:"type:binary(256);not null"`
+	// min block number indexed in the section, blocks before which are not indexed,
+	// e.g. synced before log bloom enabled.
+	BnMin uint64 `gorm:"column:bn_min;not null"`
+}
+
+func (logBloom) TableName() string {
+	return "log_blooms"
+}
+
+// logBloomAddressKey returns the bloom key of contract address.
+func logBloomAddressKey(addr string) []byte {
+	return []byte(strings.ToLower(addr))
+}
+
+// logBloomTopicKey returns the bloom key of topic, which is positional.
+func logBloomTopicKey(index int, topic string) []byte {
+	return []byte(fmt.Sprintf("%v:%v", index, strings.ToLower(topic)))
+}
+
+// matchLogBloom checks if the bloom possibly contains any event log matched with the filter.
+func matchLogBloom(bloom *ethtypes.Bloom, filter *store.LogFilter) bool {
+	testAny := func(vv *store.VariadicValue, key func(string) []byte) bool {
+		if vv.IsNull() {
+			return true
+		}
+
+		for _, v := range vv.ToSlice() {
+			if bloom.Test(key(v)) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	if !testAny(&filter.Contracts, func(v string) []byte { return logBloomAddressKey(v) }) {
+		return false
+	}
+
+	for i := range filter.Topics {
+		index := i
+		if !testAny(&filter.Topics[i], func(v string) []byte { return logBloomTopicKey(index, v) }) {
+			return false
+		}
+	}
+
+	return true
+}
+
+type logBloomStore struct {
+	*baseStore
+}
+
+func newLogBloomStore(db *gorm.DB) *logBloomStore {
+	return &logBloomStore{
+		baseStore: newBaseStore(db),
+	}
+}
+
+// MigrateLogBlooms creates the log bloom table if absent, e.g. database created in old version.
+func (lbs *logBloomStore) MigrateLogBlooms() error {
+	return lbs.db.AutoMigrate(&logBloom{})
+}
+
+// Add merges contract addresses and topics of event logs within epoch data slice into
+// section blooms. Note, blooms are never shrunk when event logs popped or pruned, which
+// is still correct since bloom is only used to skip sections.
+func (lbs *logBloomStore) Add(dbTx *gorm.DB, dataSlice []*store.EpochData) error {
+	sections := make(map[uint64]*logBloom)
+
+	for _, data := range dataSlice {
+		for _, block := range data.Blocks {
+			bn := block.BlockNumber.ToInt().Uint64()
+
+			section, ok := sections[bn/logBloomSectionSize]
+			if !ok {
+				section = &logBloom{Section: bn / logBloomSectionSize, BnMin: bn}
+				sections[section.Section] = section
+			}
+
+			var bloom ethtypes.Bloom
+			if len(section.Bloom) > 0 {
+				bloom = ethtypes.BytesToBloom(section.Bloom)
+			}
+
+			for _, tx := range block.Transactions {
+				receipt := data.Receipts[tx.Hash]
+
+				// Skip transactions that unexecuted in block.
+				if receipt == nil || !util.IsTxExecutedInBlock(&tx) {
+					continue
+				}
+
+				for _, rlog := range receipt.Logs {
+					bloom.Add(logBloomAddressKey(rlog.Address.MustGetBase32Address()))
+
+					for i, topic := range rlog.Topics {
+						bloom.Add(logBloomTopicKey(i, topic.String()))
+					}
+				}
+			}
+
+			section.Bloom = bloom.Bytes()
+		}
+	}
+
+	if len(sections) == 0 {
+		return nil
+	}
+
+	var sectionIds []uint64
+	for id := range sections {
+		sectionIds = append(sectionIds, id)
+	}
+
+	// merge with existing section blooms
+	var existing []*logBloom
+	err := dbTx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("section IN (?)", sectionIds).
+		Find(&existing).Error
+	if err != nil {
+		return errors.WithMessage(err, "failed to get existing log blooms")
+	}
+
+	for _, v := range existing {
+		section := sections[v.Section]
+
+		merged := ethtypes.BytesToBloom(section.Bloom)
+		for i, b := range v.Bloom {
+			merged[i] |= b
+		}
+
+		section.Bloom = merged.Bytes()
+		section.BnMin = util.MinUint64(section.BnMin, v.BnMin)
+	}
+
+	var models []*logBloom
+	for _, section := range sections {
+		models = append(models, section)
+	}
+
+	return dbTx.Clauses(clause.OnConflict{UpdateAll: true}).Create(&models).Error
+}
+
+// MatchedRanges returns the ascending block ranges that possibly contain event logs matched
+// with the filter. Sections not indexed are always regarded as matched.
+func (lbs *logBloomStore) MatchedRanges(filter *store.LogFilter) ([]types.RangeUint64, error) {
+	sectionFrom := filter.BlockFrom / logBloomSectionSize
+	sectionTo := filter.BlockTo / logBloomSectionSize
+
+	var blooms []*logBloom
+	err := lbs.db.Where("section BETWEEN ? AND ?", sectionFrom, sectionTo).Find(&blooms).Error
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to get log blooms")
+	}
+
+	section2Blooms := make(map[uint64]*logBloom, len(blooms))
+	for _, v := range blooms {
+		section2Blooms[v.Section] = v
+	}
+
+	var ranges []types.RangeUint64
+
+	for section := sectionFrom; section <= sectionTo; section++ {
+		from := util.MaxUint64(section*logBloomSectionSize, filter.BlockFrom)
+		to := util.MinUint64((section+1)*logBloomSectionSize-1, filter.BlockTo)
+
+		matched := true
+		if v, ok := section2Blooms[section]; ok && from >= v.BnMin {
+			bloom := ethtypes.BytesToBloom(v.Bloom)
+			matched = matchLogBloom(&bloom, filter)
+		}
+
+		metrics.Registry.Store.LogBloomSkipped().Mark(!matched)
+
+		if !matched {
+			continue
+		}
+
+		// merge with the previous adjacent range
+		if n := len(ranges); n > 0 && ranges[n-1].To+1 == from {
+			ranges[n-1].To = to
+		} else {
+			ranges = append(ranges, types.RangeUint64{From: from, To: to})
+		}
+	}
+
+	return ranges, nil
+}
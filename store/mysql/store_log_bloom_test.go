@@ -0,0 +1,34 @@
+package mysql
+
+import (
+	"testing"
+
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/scroll-tech/rpc-gateway/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchLogBloom(t *testing.T) {
+	var bloom ethtypes.Bloom
+	bloom.Add(logBloomAddressKey("cfx:acc7uawf5ubtnmezvhu9dhc6sghea0403y2dgpyfjp"))
+	bloom.Add(logBloomTopicKey(0, "0xDDF252AD1BE2C89B69C2B068FC378DAA952BA7F163C4A11628F55A4DF523B3EF"))
+
+	assert.True(t, matchLogBloom(&bloom, &store.LogFilter{}))
+	assert.True(t, matchLogBloom(&bloom, &store.LogFilter{
+		Contracts: store.NewVariadicValue("CFX:ACC7UAWF5UBTNMEZVHU9DHC6SGHEA0403Y2DGPYFJP"),
+		Topics: []store.VariadicValue{
+			store.NewVariadicValue("0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef", "0x01"),
+		},
+	}))
+
+	// topic is positional
+	assert.False(t, matchLogBloom(&bloom, &store.LogFilter{
+		Topics: []store.VariadicValue{
+			{},
+			store.NewVariadicValue("0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"),
+		},
+	}))
+	assert.False(t, matchLogBloom(&bloom, &store.LogFilter{
+		Contracts: store.NewVariadicValue("cfx:aak2rra2njvd77ezwjvx04kkds9fzagfe6ku8scz91"),
+	}))
+}
@@ -0,0 +1,76 @@
+package mysql
+
+import (
+	"github.com/scroll-tech/rpc-gateway/util/usage"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var _ usage.Store = (*KeyUsageStore)(nil)
+
+// KeyUsage daily usage rollup of API key for a RPC method.
+type KeyUsage struct {
+	ID     uint64
+	Day    string `gorm:"size:10;not null;uniqueIndex:uidx_day_key_method,priority:1"`
+	ApiKey string `gorm:"size:128;not null;uniqueIndex:uidx_day_key_method,priority:2;index:idx_key_day,priority:1"`
+	Method string `gorm:"size:128;not null;uniqueIndex:uidx_day_key_method,priority:3"`
+	Calls  uint64 `gorm:"not null"`
+	Errors uint64 `gorm:"not null"`
+	Bytes  uint64 `gorm:"not null"`
+}
+
+func (KeyUsage) TableName() string {
+	return "key_usages"
+}
+
+type KeyUsageStore struct {
+	*baseStore
+}
+
+func NewKeyUsageStore(db *gorm.DB) *KeyUsageStore {
+	return &KeyUsageStore{
+		baseStore: newBaseStore(db),
+	}
+}
+
+// MigrateKeyUsages creates the key usage table if absent, e.g. database created in old version.
+func (kus *KeyUsageStore) MigrateKeyUsages() error {
+	return kus.db.AutoMigrate(&KeyUsage{})
+}
+
+// AddUsages implements the usage.Store interface.
+func (kus *KeyUsageStore) AddUsages(records []*usage.Record) error {
+	models := make([]*KeyUsage, 0, len(records))
+	for _, r := range records {
+		models = append(models, &KeyUsage{
+			Day: r.Day, ApiKey: r.Key, Method: r.Method, Calls: r.Calls, Errors: r.Errors, Bytes: r.Bytes,
+		})
+	}
+
+	return kus.db.Clauses(clause.OnConflict{
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"calls":  gorm.Expr("calls + VALUES(calls)"),
+			"errors": gorm.Expr("errors + VALUES(errors)"),
+			"bytes":  gorm.Expr("bytes + VALUES(bytes)"),
+		}),
+	}).CreateInBatches(models, 500).Error
+}
+
+// GetUsages implements the usage.Store interface.
+func (kus *KeyUsageStore) GetUsages(key string, dayFrom, dayTo string) ([]*usage.Record, error) {
+	var models []*KeyUsage
+
+	err := kus.db.Where("api_key = ? AND day >= ? AND day <= ?", key, dayFrom, dayTo).Find(&models).Error
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]*usage.Record, 0, len(models))
+	for _, m := range models {
+		records = append(records, &usage.Record{
+			Day: m.Day, Key: m.ApiKey, Method: m.Method, Calls: m.Calls, Errors: m.Errors, Bytes: m.Bytes,
+		})
+	}
+
+	return records, nil
+}
@@ -53,19 +53,30 @@ func MustNewRedisStoreFromViper(disabler store.StoreDisabler) (*RedisStore, bool
 }
 
 func MustNewRedisClient(url string) *redis.Client {
+	client, err := NewRedisClient(url)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to create redis client")
+	}
+
+	return client
+}
+
+// NewRedisClient creates redis client and tests the connection.
+func NewRedisClient(url string) (*redis.Client, error) {
 	opt, err := redis.ParseURL(url)
 	if err != nil {
-		logrus.WithError(err).Fatal("Failed to parse redis url")
+		return nil, errors.WithMessage(err, "failed to parse redis url")
 	}
 
 	client := redis.NewClient(opt)
 
 	// Test connection
 	if _, err := client.Ping(context.Background()).Result(); err != nil {
-		logrus.WithError(err).Fatal("Failed to ping redis")
+		client.Close()
+		return nil, errors.WithMessage(err, "failed to ping redis")
 	}
 
-	return client
+	return client, nil
 }
 
 func (rs *RedisStore) IsRecordNotFound(err error) bool {
@@ -0,0 +1,86 @@
+package sync
+
+import (
+	web3Types "github.com/openweb3/web3go/types"
+	"github.com/pkg/errors"
+	"github.com/scroll-tech/rpc-gateway/util/metrics"
+	"github.com/sirupsen/logrus"
+)
+
+// reorgConfig configurations to handle chain reorganization.
+type reorgConfig struct {
+	// max number of blocks to look back for the common ancestor
+	MaxDepth uint64 `default:"1000"`
+	// reorgs not shallower than the depth are alerted
+	AlertDepth uint64 `default:"6"`
+}
+
+// handleReorg detects the chain reorganization depth by comparing block hashes in store with
+// the canonical chain, and rolls back all the affected blocks and event logs in store at once.
+func (syncer *EthSyncer) handleReorg() error {
+	latest := syncer.latestStoreBlock()
+
+	ancestor, err := syncer.findCommonAncestor(latest)
+	if err != nil {
+		metrics.Registry.Sync.ReorgFailures("eth").Mark(1)
+		return errors.WithMessage(err, "failed to find common ancestor")
+	}
+
+	depth := latest - ancestor
+
+	if err := syncer.reorgRevert(ancestor + 1); err != nil {
+		metrics.Registry.Sync.ReorgFailures("eth").Mark(1)
+		return err
+	}
+
+	metrics.Registry.Sync.ReorgDepth("eth").Update(int64(depth))
+
+	logger := logrus.WithFields(logrus.Fields{
+		"latestBlock": latest, "ancestor": ancestor, "depth": depth,
+	})
+
+	// error level logs are alerted by logrus hook
+	if depth >= syncer.conf.Reorg.AlertDepth {
+		logger.Error("ETH syncer detected deep chain re-org")
+	} else {
+		logger.Info("ETH syncer detected chain re-org")
+	}
+
+	return nil
+}
+
+// findCommonAncestor walks back from the specified block to find the latest block in store
+// which is still on the canonical chain.
+func (syncer *EthSyncer) findCommonAncestor(from uint64) (uint64, error) {
+	for bn := from; bn > 0 && from-bn < syncer.conf.Reorg.MaxDepth; bn-- {
+		storeHash, err := syncer.getStoreBlockHash(bn)
+		if err != nil {
+			return 0, errors.WithMessagef(err, "failed to get block hash of %v from store", bn)
+		}
+
+		if len(storeHash) == 0 { // not synced, e.g. block before sync start
+			return bn, nil
+		}
+
+		block, err := syncer.w3c.Eth.BlockByNumber(web3Types.BlockNumber(bn), false)
+		if err != nil {
+			return 0, errors.WithMessagef(err, "failed to get block %v from blockchain", bn)
+		}
+
+		if block != nil && block.Hash.Hex() == storeHash {
+			return bn, nil
+		}
+	}
+
+	return 0, errors.Errorf("chain re-org deeper than max depth %v", syncer.conf.Reorg.MaxDepth)
+}
+
+func (syncer *EthSyncer) getStoreBlockHash(bn uint64) (string, error) {
+	// load from in-memory cache first
+	if blockHash, ok := syncer.epochPivotWin.getPivotHash(bn); ok {
+		return string(blockHash), nil
+	}
+
+	pivotHash, _, err := syncer.db.PivotHash(bn)
+	return pivotHash, err
+}
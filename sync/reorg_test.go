@@ -0,0 +1,119 @@
+package sync
+
+import (
+	"fmt"
+	"math/big"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/openweb3/web3go"
+	"github.com/scroll-tech/rpc-gateway/store"
+	"github.com/scroll-tech/rpc-gateway/util/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+// memoryEthStore is an in-memory store of synchronized block hashes.
+type memoryEthStore struct {
+	hashes map[uint64]string // block number => block hash
+}
+
+func (s *memoryEthStore) Pushn(dataSlice []*store.EpochData) error {
+	return nil
+}
+
+func (s *memoryEthStore) Popn(epochUntil uint64) error {
+	for bn := range s.hashes {
+		if bn >= epochUntil {
+			delete(s.hashes, bn)
+		}
+	}
+
+	return nil
+}
+
+func (s *memoryEthStore) MaxEpoch() (uint64, bool, error) {
+	var max uint64
+	for bn := range s.hashes {
+		if bn > max {
+			max = bn
+		}
+	}
+
+	return max, len(s.hashes) > 0, nil
+}
+
+func (s *memoryEthStore) PivotHash(epoch uint64) (string, bool, error) {
+	hash, ok := s.hashes[epoch]
+	return hash, ok, nil
+}
+
+func TestHandleReorg(t *testing.T) {
+	chain := mock.NewChain(mock.ChainConfig{ChainId: 1337, Height: 200})
+
+	handler, err := mock.NewHandler(chain)
+	assert.NoError(t, err)
+
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	w3c, err := web3go.NewClient(server.URL)
+	assert.NoError(t, err)
+
+	// blocks [80, 100] on canonical chain, and [101, 105] re-orged
+	newSyncer := func(maxDepth uint64) *EthSyncer {
+		db := &memoryEthStore{hashes: make(map[uint64]string)}
+		win := newEpochPivotWindow(10)
+
+		var parentHash string
+		for bn := uint64(80); bn <= 105; bn++ {
+			block, _ := chain.Block(bn)
+			hash := block.Hash.Hex()
+
+			if bn > 100 {
+				hash = fmt.Sprintf("0x%064x", bn)
+			}
+
+			db.hashes[bn] = hash
+
+			assert.NoError(t, win.push(&types.Block{BlockHeader: types.BlockHeader{
+				EpochNumber: (*hexutil.Big)(big.NewInt(int64(bn))),
+				Hash:        types.Hash(hash),
+				ParentHash:  types.Hash(parentHash),
+			}}))
+
+			parentHash = hash
+		}
+
+		return &EthSyncer{
+			conf:          &syncEthConfig{Reorg: reorgConfig{MaxDepth: maxDepth, AlertDepth: 6}},
+			w3c:           w3c,
+			db:            db,
+			fromBlock:     106,
+			epochPivotWin: win,
+		}
+	}
+
+	syncer := newSyncer(1000)
+	assert.NoError(t, syncer.handleReorg())
+
+	// all re-orged blocks above the common ancestor rolled back at once
+	assert.Equal(t, uint64(101), syncer.fromBlock)
+
+	maxEpoch, _, _ := syncer.db.MaxEpoch()
+	assert.Equal(t, uint64(100), maxEpoch)
+
+	for bn := uint64(96); bn <= 105; bn++ {
+		_, ok := syncer.epochPivotWin.getPivotHash(bn)
+		assert.Equal(t, bn <= 100, ok, bn)
+	}
+
+	// common ancestor not found within max depth
+	syncer = newSyncer(3)
+	assert.Error(t, syncer.handleReorg())
+	assert.Equal(t, uint64(106), syncer.fromBlock)
+
+	maxEpoch, _, _ = syncer.db.MaxEpoch()
+	assert.Equal(t, uint64(105), maxEpoch)
+}
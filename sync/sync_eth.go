@@ -27,6 +27,15 @@ type syncEthConfig struct {
 	FromBlock uint64 `default:"1"`
 	MaxBlocks uint64 `default:"10"`
 	UseBatch  bool   `default:"false"`
+	Reorg     reorgConfig
+}
+
+// ethSyncStore is the db store to synchronize evm space blockchain data into.
+type ethSyncStore interface {
+	Pushn(dataSlice []*store.EpochData) error
+	Popn(epochUntil uint64) error
+	MaxEpoch() (uint64, bool, error)
+	PivotHash(epoch uint64) (string, bool, error)
 }
 
 // EthSyncer is used to synchronize evm space blockchain data into db store.
@@ -37,7 +46,7 @@ type EthSyncer struct {
 	// EVM space chain id
 	chainId uint32
 	// db store
-	db *mysql.MysqlStore
+	db ethSyncStore
 	// block number to sync chaindata from
 	fromBlock uint64
 	// maximum number of blocks to sync once
@@ -183,7 +192,7 @@ func (syncer *EthSyncer) syncOnce() (bool, error) {
 			}
 
 			if len(latestBlockHash) > 0 && data.Block.ParentHash.Hex() != latestBlockHash {
-				if err := syncer.reorgRevert(syncer.latestStoreBlock()); err != nil {
+				if err := syncer.handleReorg(); err != nil {
 					parentBlockHash := data.Block.ParentHash.Hex()
 
 					blogger.WithFields(logrus.Fields{
@@ -196,10 +205,6 @@ func (syncer *EthSyncer) syncOnce() (bool, error) {
 					return false, errors.WithMessage(err, "failed to revert block data from ethdb")
 				}
 
-				blogger.WithField("latestBlockHash", latestBlockHash).Info(
-					"ETH syncer reverted latest block from ethdb store due to parent hash mismatched",
-				)
-
 				return false, nil
 			}
 		} else { // otherwise non-first block must also be continuous to previous one
@@ -328,15 +333,7 @@ func (syncer *EthSyncer) getStoreLatestBlockHash() (string, error) {
 		return "", nil
 	}
 
-	latestBlockNo := syncer.latestStoreBlock()
-
-	// load from in-memory cache first
-	if blockHash, ok := syncer.epochPivotWin.getPivotHash(latestBlockNo); ok {
-		return string(blockHash), nil
-	}
-
-	pivotHash, _, err := syncer.db.PivotHash(latestBlockNo)
-	return pivotHash, err
+	return syncer.getStoreBlockHash(syncer.latestStoreBlock())
 }
 
 // convertToEpochData converts evm space block data to core space epoch data. This is used to bridge
@@ -0,0 +1,235 @@
+package apikey
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/scroll-tech/rpc-gateway/util"
+)
+
+var (
+	ErrKeyNotFound = errors.New("api key not found")
+	ErrKeyRevoked  = errors.New("api key revoked")
+	ErrKeyExpired  = errors.New("api key expired")
+	// returned if key not rotated within grace period after max age reached
+	ErrKeyRotationRequired = errors.New("api key exceeds max age, please rotate")
+)
+
+// Config configurations of built-in API key store.
+type Config struct {
+	Enabled bool
+	// store backend, `redis` or `mysql`
+	Backend string `default:"redis"`
+	// redis for `redis` backend
+	RedisUrl string
+	// redis key prefix for `redis` backend
+	RedisKeyPrefix string `default:"confura:apikey:"`
+	// reject requests without valid API key or Web3Pay billing, otherwise rate limited by IP
+	Required bool
+	// max number of cached API keys
+	CacheSize int `default:"10000"`
+	// expiration TTL of cached API keys, which bounds the delay of revocation across replicas
+	CacheTTL time.Duration `default:"1m"`
+	// key lifecycle policies, e.g. max age and rotation reminders
+	Lifecycle LifecycleConfig
+}
+
+// Key is the API key issued by the gateway.
+type Key struct {
+	Key  string `json:"key"`
+	Name string `json:"name"`
+	// bound rate limit strategy ID, 0 means default strategy
+	SID       uint32    `json:"sid"`
+	CreatedAt time.Time `json:"createdAt"`
+	// zero means never expires
+	ExpiresAt time.Time `json:"expiresAt,omitempty"`
+	Revoked   bool      `json:"revoked"`
+	// last time to remind rotation, zero means never
+	RemindedAt time.Time `json:"remindedAt,omitempty"`
+}
+
+// Validate checks if the API key is still valid at the specified time.
+func (k *Key) Validate(now time.Time) error {
+	if k.Revoked {
+		return ErrKeyRevoked
+	}
+
+	if !k.ExpiresAt.IsZero() && now.After(k.ExpiresAt) {
+		return ErrKeyExpired
+	}
+
+	return nil
+}
+
+// Store is the persistent store of API keys.
+type Store interface {
+	// GetApiKey returns the API key if exists.
+	GetApiKey(key string) (*Key, bool, error)
+	// SaveApiKey creates or updates the API key.
+	SaveApiKey(key *Key) error
+	// ListApiKeys returns all API keys, including revoked and expired ones.
+	ListApiKeys() ([]*Key, error)
+}
+
+// Manager manages API keys with cached lookup.
+type Manager struct {
+	store     Store
+	cache     *util.ExpirableLruCache // key => *Key (nil if missing)
+	lifecycle LifecycleConfig
+}
+
+func NewManager(store Store, cacheSize int, cacheTTL time.Duration) *Manager {
+	return &Manager{
+		store: store,
+		cache: util.NewExpirableLruCache(cacheSize, cacheTTL),
+	}
+}
+
+// Validate validates the API key from cache or store.
+func (m *Manager) Validate(key string) (*Key, error) {
+	k, err := m.lookup(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if k == nil {
+		return nil, ErrKeyNotFound
+	}
+
+	now := time.Now()
+	if err := k.Validate(now); err != nil {
+		return nil, err
+	}
+
+	if m.lifecycle.enforced(k, now) {
+		return nil, ErrKeyRotationRequired
+	}
+
+	return k, nil
+}
+
+func (m *Manager) lookup(key string) (*Key, error) {
+	if v, ok := m.cache.Get(key); ok {
+		return v.(*Key), nil
+	}
+
+	k, ok, err := m.store.GetApiKey(key)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to get api key from store")
+	}
+
+	if !ok {
+		k = nil
+	}
+
+	// cache missing key as well to avoid store penetration
+	m.cache.Add(key, k)
+
+	return k, nil
+}
+
+// Create issues a new API key bound to the specified rate limit strategy.
+func (m *Manager) Create(name string, sid uint32) (*Key, error) {
+	key, err := generateKey()
+	if err != nil {
+		return nil, err
+	}
+
+	k := Key{Key: key, Name: name, SID: sid, CreatedAt: time.Now()}
+	if err := m.store.SaveApiKey(&k); err != nil {
+		return nil, errors.WithMessage(err, "failed to save api key")
+	}
+
+	m.cache.Remove(key)
+
+	return &k, nil
+}
+
+// Rotate issues a new API key with the same attributes, and expires the old one after the
+// grace period, so that clients could switch to the new key without downtime. Note, keys
+// rejected due to max age exceeded could still be rotated.
+func (m *Manager) Rotate(key string, grace time.Duration) (*Key, error) {
+	old, err := m.lookup(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if old == nil {
+		return nil, ErrKeyNotFound
+	}
+
+	if err := old.Validate(time.Now()); err != nil {
+		return nil, err
+	}
+
+	k, err := m.Create(old.Name, old.SID)
+	if err != nil {
+		return nil, err
+	}
+
+	if expiresAt := time.Now().Add(grace); old.ExpiresAt.IsZero() || expiresAt.Before(old.ExpiresAt) {
+		updated := *old
+		updated.ExpiresAt = expiresAt
+
+		if err := m.update(&updated); err != nil {
+			return nil, err
+		}
+	}
+
+	return k, nil
+}
+
+// Revoke revokes the API key immediately. Note, other gateway replicas may still accept the
+// revoked key until cache expired.
+func (m *Manager) Revoke(key string) error {
+	k, err := m.lookup(key)
+	if err != nil {
+		return err
+	}
+
+	if k == nil {
+		return ErrKeyNotFound
+	}
+
+	revoked := *k
+	revoked.Revoked = true
+
+	return m.update(&revoked)
+}
+
+func (m *Manager) update(k *Key) error {
+	if err := m.store.SaveApiKey(k); err != nil {
+		return errors.WithMessage(err, "failed to save api key")
+	}
+
+	m.cache.Remove(k.Key)
+
+	return nil
+}
+
+// generateKey generates a random API key in hex.
+func generateKey() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", errors.WithMessage(err, "failed to generate api key")
+	}
+
+	return hex.EncodeToString(buf), nil
+}
+
+// defaultManager is the API key manager in use, which is set once API key subsystem initialized.
+var defaultManager atomic.Value
+
+// SetDefault sets the API key manager in use.
+func SetDefault(m *Manager) {
+	defaultManager.Store(m)
+}
+
+// Default returns the API key manager in use if any.
+func Default() (*Manager, bool) {
+	m, ok := defaultManager.Load().(*Manager)
+	return m, ok
+}
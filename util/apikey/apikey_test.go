@@ -0,0 +1,97 @@
+package apikey
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type memoryStore map[string]Key
+
+func (s memoryStore) GetApiKey(key string) (*Key, bool, error) {
+	k, ok := s[key]
+	return &k, ok, nil
+}
+
+func (s memoryStore) SaveApiKey(k *Key) error {
+	s[k.Key] = *k
+	return nil
+}
+
+func (s memoryStore) ListApiKeys() ([]*Key, error) {
+	var keys []*Key
+	for _, k := range s {
+		k := k
+		keys = append(keys, &k)
+	}
+
+	return keys, nil
+}
+
+func TestManagerLifecycle(t *testing.T) {
+	m := NewManager(make(memoryStore), 100, time.Minute)
+
+	_, err := m.Validate("missing")
+	assert.Equal(t, ErrKeyNotFound, err)
+
+	k, err := m.Create("alice", 1)
+	assert.Nil(t, err)
+
+	validated, err := m.Validate(k.Key)
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(1), validated.SID)
+
+	// old key keeps working during grace period
+	rotated, err := m.Rotate(k.Key, time.Hour)
+	assert.Nil(t, err)
+	assert.NotEqual(t, k.Key, rotated.Key)
+
+	_, err = m.Validate(k.Key)
+	assert.Nil(t, err)
+
+	// old key expired without grace period
+	_, err = m.Rotate(rotated.Key, 0)
+	assert.Nil(t, err)
+
+	time.Sleep(time.Millisecond)
+	_, err = m.Validate(rotated.Key)
+	assert.Equal(t, ErrKeyExpired, err)
+
+	assert.Nil(t, m.Revoke(k.Key))
+	_, err = m.Validate(k.Key)
+	assert.Equal(t, ErrKeyRevoked, err)
+}
+
+func TestKeyRotationPolicy(t *testing.T) {
+	store := make(memoryStore)
+	m := NewManager(store, 100, time.Minute)
+	m.SetLifecycle(LifecycleConfig{
+		MaxAge:         24 * time.Hour,
+		RemindBefore:   time.Hour,
+		RemindInterval: time.Hour,
+		Enforce:        true,
+		Grace:          time.Hour,
+	})
+
+	now := time.Now()
+	store["fresh"] = Key{Key: "fresh", CreatedAt: now}
+	store["due"] = Key{Key: "due", CreatedAt: now.Add(-24 * time.Hour)}
+	store["outdated"] = Key{Key: "outdated", CreatedAt: now.Add(-26 * time.Hour)}
+
+	_, err := m.Validate("due")
+	assert.Nil(t, err)
+
+	_, err = m.Validate("outdated")
+	assert.Equal(t, ErrKeyRotationRequired, err)
+
+	// outdated key could still be rotated
+	_, err = m.Rotate("outdated", time.Hour)
+	assert.Nil(t, err)
+
+	assert.Nil(t, m.RemindRotation(context.Background()))
+	assert.True(t, store["fresh"].RemindedAt.IsZero())
+	assert.False(t, store["due"].RemindedAt.IsZero())
+	assert.True(t, store["outdated"].RemindedAt.IsZero()) // rotated
+}
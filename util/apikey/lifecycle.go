@@ -0,0 +1,217 @@
+package apikey
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// EmailConfig SMTP configurations to send rotation reminders.
+type EmailConfig struct {
+	// SMTP server address, e.g. `smtp.example.com:587`
+	SmtpAddr string
+	Username string
+	Password string
+	From     string
+	To       []string
+}
+
+// LifecycleConfig key lifecycle policies, e.g. max age, rotation reminders and enforcement.
+type LifecycleConfig struct {
+	// max age of API keys before rotation required, and 0 means never
+	MaxAge time.Duration
+	// remind rotation in advance before max age reached
+	RemindBefore time.Duration `default:"168h"`
+	// min interval to repeat reminder for the same key
+	RemindInterval time.Duration `default:"24h"`
+	// interval to check API keys for rotation reminders
+	CheckInterval time.Duration `default:"1h"`
+	// reject API keys not rotated within grace period after max age reached
+	Enforce bool
+	Grace   time.Duration `default:"168h"`
+	// webhook to post rotation reminders in JSON
+	Webhook string
+	// email to send rotation reminders
+	Email EmailConfig
+}
+
+// dueAt returns the time when rotation of API key is due.
+func (conf *LifecycleConfig) dueAt(k *Key) time.Time {
+	return k.CreatedAt.Add(conf.MaxAge)
+}
+
+// enforced checks if API key should be rejected since not rotated in time.
+func (conf *LifecycleConfig) enforced(k *Key, now time.Time) bool {
+	return conf.MaxAge > 0 && conf.Enforce && now.After(conf.dueAt(k).Add(conf.Grace))
+}
+
+// remindable checks if rotation reminder should be sent for API key. Note, keys scheduled to
+// expire, e.g. rotated ones, are not reminded.
+func (conf *LifecycleConfig) remindable(k *Key, now time.Time) bool {
+	if conf.MaxAge <= 0 || k.Revoked || !k.ExpiresAt.IsZero() {
+		return false
+	}
+
+	if now.Before(conf.dueAt(k).Add(-conf.RemindBefore)) {
+		return false
+	}
+
+	return k.RemindedAt.IsZero() || now.Sub(k.RemindedAt) >= conf.RemindInterval
+}
+
+// Reminder is the rotation reminder of API key.
+type Reminder struct {
+	Key  string    `json:"key"`
+	Name string    `json:"name"`
+	Due  time.Time `json:"due"`
+	// time to reject the key if enforced, zero means not enforced
+	EnforceAt time.Time `json:"enforceAt,omitempty"`
+}
+
+func (r *Reminder) String() string {
+	msg := fmt.Sprintf("API key %v (%v) is due for rotation at %v.", r.Key, r.Name, r.Due.Format(time.RFC3339))
+	if !r.EnforceAt.IsZero() {
+		msg += fmt.Sprintf(" It will be rejected after %v if not rotated.", r.EnforceAt.Format(time.RFC3339))
+	}
+
+	return msg
+}
+
+// SetLifecycle sets the key lifecycle policies, which should be called before serving.
+func (m *Manager) SetLifecycle(conf LifecycleConfig) {
+	m.lifecycle = conf
+}
+
+// RunLifecycle checks API keys for rotation reminders periodically until context done.
+func (m *Manager) RunLifecycle(ctx context.Context) {
+	if m.lifecycle.MaxAge <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(m.lifecycle.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := m.RemindRotation(ctx); err != nil {
+			logrus.WithError(err).Warn("Failed to remind API key rotation")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RemindRotation sends rotation reminders for API keys approaching or exceeding max age.
+func (m *Manager) RemindRotation(ctx context.Context) error {
+	keys, err := m.store.ListApiKeys()
+	if err != nil {
+		return errors.WithMessage(err, "failed to list api keys")
+	}
+
+	now := time.Now()
+
+	for _, k := range keys {
+		if !m.lifecycle.remindable(k, now) {
+			continue
+		}
+
+		reminder := Reminder{Key: k.Key, Name: k.Name, Due: m.lifecycle.dueAt(k)}
+		if m.lifecycle.Enforce {
+			reminder.EnforceAt = reminder.Due.Add(m.lifecycle.Grace)
+		}
+
+		if err := m.notify(ctx, &reminder); err != nil {
+			return errors.WithMessagef(err, "failed to notify rotation of api key %v", k.Name)
+		}
+
+		reminded := *k
+		reminded.RemindedAt = now
+
+		if err := m.update(&reminded); err != nil {
+			return err
+		}
+
+		logrus.WithField("reminder", reminder).Info("API key rotation reminded")
+	}
+
+	return nil
+}
+
+func (m *Manager) notify(ctx context.Context, r *Reminder) error {
+	if len(m.lifecycle.Webhook) > 0 {
+		if err := postWebhook(ctx, m.lifecycle.Webhook, r); err != nil {
+			return err
+		}
+	}
+
+	if conf := m.lifecycle.Email; len(conf.SmtpAddr) > 0 && len(conf.To) > 0 {
+		if err := sendEmail(&conf, "API key rotation reminder", r.String()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func postWebhook(ctx context.Context, url string, r *Reminder) error {
+	body, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return errors.WithMessage(err, "failed to post webhook")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return errors.Errorf("webhook responded with status %v", resp.Status)
+	}
+
+	return nil
+}
+
+func sendEmail(conf *EmailConfig, subject, body string) error {
+	var auth smtp.Auth
+	if len(conf.Username) > 0 {
+		host := conf.SmtpAddr
+		if i := strings.LastIndex(host, ":"); i >= 0 {
+			host = host[:i]
+		}
+
+		auth = smtp.PlainAuth("", conf.Username, conf.Password, host)
+	}
+
+	msg := fmt.Sprintf(
+		"From: %v\r\nTo: %v\r\nSubject: %v\r\n\r\n%v\r\n",
+		conf.From, strings.Join(conf.To, ", "), subject, body,
+	)
+
+	if err := smtp.SendMail(conf.SmtpAddr, auth, conf.From, conf.To, []byte(msg)); err != nil {
+		return errors.WithMessage(err, "failed to send email")
+	}
+
+	return nil
+}
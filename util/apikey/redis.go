@@ -0,0 +1,73 @@
+package apikey
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/pkg/errors"
+)
+
+// RedisStore stores API keys in redis as JSON.
+type RedisStore struct {
+	ctx    context.Context
+	client *redis.Client
+	prefix string
+}
+
+func NewRedisStore(ctx context.Context, client *redis.Client, prefix string) *RedisStore {
+	return &RedisStore{ctx: ctx, client: client, prefix: prefix}
+}
+
+// GetApiKey implements the Store interface.
+func (s *RedisStore) GetApiKey(key string) (*Key, bool, error) {
+	data, err := s.client.Get(s.ctx, s.prefix+key).Bytes()
+	if err == redis.Nil {
+		return nil, false, nil
+	}
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	var k Key
+	if err := json.Unmarshal(data, &k); err != nil {
+		return nil, false, errors.WithMessage(err, "invalid api key data")
+	}
+
+	return &k, true, nil
+}
+
+// SaveApiKey implements the Store interface.
+func (s *RedisStore) SaveApiKey(k *Key) error {
+	data, err := json.Marshal(k)
+	if err != nil {
+		return err
+	}
+
+	return s.client.Set(s.ctx, s.prefix+k.Key, data, 0).Err()
+}
+
+// ListApiKeys implements the Store interface.
+func (s *RedisStore) ListApiKeys() ([]*Key, error) {
+	var keys []*Key
+
+	iter := s.client.Scan(s.ctx, 0, s.prefix+"*", 1000).Iterator()
+	for iter.Next(s.ctx) {
+		k, ok, err := s.GetApiKey(strings.TrimPrefix(iter.Val(), s.prefix))
+		if err != nil {
+			return nil, err
+		}
+
+		if ok {
+			keys = append(keys, k)
+		}
+	}
+
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
@@ -0,0 +1,143 @@
+package cron
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Schedule is a standard cron schedule with 5 fields: minute, hour, day of month, month and
+// day of week, e.g. `30 3 * * 1-5`. Each field supports `*`, `a`, `a-b`, `*/n`, `a-b/n` and
+// comma separated list of them.
+type Schedule struct {
+	minute, hour, dom, month, dow uint64 // bit set of allowed values
+
+	// whether day of month or day of week restricted, in which case either matched is ok
+	domRestricted, dowRestricted bool
+}
+
+// field bounds of cron schedule
+var bounds = []struct{ min, max int }{
+	{0, 59}, // minute
+	{0, 23}, // hour
+	{1, 31}, // day of month
+	{1, 12}, // month
+	{0, 7},  // day of week, both 0 and 7 are Sunday
+}
+
+// Parse parses the specified cron expression.
+func Parse(expr string) (*Schedule, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != len(bounds) {
+		return nil, errors.Errorf("expected %v fields, got %v", len(bounds), len(fields))
+	}
+
+	bits := make([]uint64, len(fields))
+	for i, f := range fields {
+		var err error
+		if bits[i], err = parseField(f, bounds[i].min, bounds[i].max); err != nil {
+			return nil, errors.WithMessagef(err, "invalid field %q", f)
+		}
+	}
+
+	// Sunday could be either 0 or 7
+	if bits[4]&(1<<7) != 0 {
+		bits[4] |= 1
+	}
+
+	return &Schedule{
+		minute:        bits[0],
+		hour:          bits[1],
+		dom:           bits[2],
+		month:         bits[3],
+		dow:           bits[4],
+		domRestricted: fields[2] != "*",
+		dowRestricted: fields[4] != "*",
+	}, nil
+}
+
+// parseField parses a comma separated field into bit set.
+func parseField(field string, min, max int) (uint64, error) {
+	var bits uint64
+
+	for _, part := range strings.Split(field, ",") {
+		rangeExpr, step := part, 1
+
+		if i := strings.Index(part, "/"); i >= 0 {
+			var err error
+			if step, err = strconv.Atoi(part[i+1:]); err != nil || step <= 0 {
+				return 0, errors.Errorf("invalid step %q", part[i+1:])
+			}
+
+			rangeExpr = part[:i]
+		}
+
+		lo, hi := min, max
+
+		switch i := strings.Index(rangeExpr, "-"); {
+		case rangeExpr == "*":
+		case i >= 0:
+			var err1, err2 error
+			lo, err1 = strconv.Atoi(rangeExpr[:i])
+			hi, err2 = strconv.Atoi(rangeExpr[i+1:])
+			if err1 != nil || err2 != nil {
+				return 0, errors.Errorf("invalid range %q", rangeExpr)
+			}
+		default:
+			v, err := strconv.Atoi(rangeExpr)
+			if err != nil {
+				return 0, errors.Errorf("invalid value %q", rangeExpr)
+			}
+
+			lo = v
+			// `a/n` means from a to max
+			if step == 1 {
+				hi = v
+			}
+		}
+
+		if lo < min || hi > max || lo > hi {
+			return 0, errors.Errorf("value out of range [%v, %v]", min, max)
+		}
+
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+
+	return bits, nil
+}
+
+// Match checks if the schedule fires at the minute of the specified time.
+func (s *Schedule) Match(t time.Time) bool {
+	if s.minute&(1<<uint(t.Minute())) == 0 || s.hour&(1<<uint(t.Hour())) == 0 ||
+		s.month&(1<<uint(t.Month())) == 0 {
+		return false
+	}
+
+	domMatched := s.dom&(1<<uint(t.Day())) != 0
+	dowMatched := s.dow&(1<<uint(t.Weekday())) != 0
+
+	// same as standard cron, either matched if both restricted
+	if s.domRestricted && s.dowRestricted {
+		return domMatched || dowMatched
+	}
+
+	return domMatched && dowMatched
+}
+
+// Within checks if the specified time is within a window that starts when the schedule fires
+// and lasts for the specified duration.
+func (s *Schedule) Within(t time.Time, duration time.Duration) bool {
+	start := t.Truncate(time.Minute)
+
+	for at := start; t.Sub(at) < duration; at = at.Add(-time.Minute) {
+		if s.Match(at) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,50 @@
+package cron
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	for _, expr := range []string{"* * * * *", "30 3 * * *", "*/15 0-6 1,15 * 1-5", "0 0 * * 7"} {
+		_, err := Parse(expr)
+		assert.NoError(t, err, expr)
+	}
+
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "*/0 * * * *", "5-1 * * * *", "a * * * *"} {
+		_, err := Parse(expr)
+		assert.Error(t, err, expr)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	// Monday
+	at := time.Date(2022, 8, 1, 3, 30, 15, 0, time.UTC)
+
+	s, _ := Parse("30 3 * * *")
+	assert.True(t, s.Match(at))
+	assert.False(t, s.Match(at.Add(time.Minute)))
+
+	s, _ = Parse("*/10 3 * * 1-5")
+	assert.True(t, s.Match(at))
+	assert.False(t, s.Match(at.AddDate(0, 0, 5)))
+
+	// either day of month or day of week matched
+	s, _ = Parse("30 3 15 * 1")
+	assert.True(t, s.Match(at))
+
+	s, _ = Parse("30 3 * * 0")
+	assert.True(t, s.Match(at.AddDate(0, 0, 6)))
+}
+
+func TestWithin(t *testing.T) {
+	s, _ := Parse("0 3 * * *")
+	start := time.Date(2022, 8, 1, 3, 0, 0, 0, time.UTC)
+
+	assert.False(t, s.Within(start.Add(-time.Second), 30*time.Minute))
+	assert.True(t, s.Within(start, 30*time.Minute))
+	assert.True(t, s.Within(start.Add(29*time.Minute), 30*time.Minute))
+	assert.False(t, s.Within(start.Add(30*time.Minute), 30*time.Minute))
+}
@@ -0,0 +1,207 @@
+package feature
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/cespare/xxhash"
+	"github.com/scroll-tech/rpc-gateway/util/reload"
+	"github.com/scroll-tech/rpc-gateway/util/rpc/handlers"
+	"github.com/sirupsen/logrus"
+)
+
+// FlagConfig configurations of feature flag to gate new behaviors.
+type FlagConfig struct {
+	Name string
+	// kill switch, and the feature is disabled for all traffic if false
+	Enabled bool
+	// percentage of traffic in range [0, 100] to enable the feature
+	Percentage float64
+	// tenants (API keys) to always enable the feature regardless of percentage
+	Tenants []string
+}
+
+type flag struct {
+	FlagConfig
+	tenants map[string]bool
+}
+
+func newFlag(conf FlagConfig) *flag {
+	f := flag{FlagConfig: conf, tenants: make(map[string]bool)}
+	for _, t := range conf.Tenants {
+		f.tenants[t] = true
+	}
+
+	return &f
+}
+
+// enabledFor checks if the feature is enabled for the specified tenant and bucket key.
+func (f *flag) enabledFor(tenant, key string) bool {
+	if !f.Enabled {
+		return false
+	}
+
+	if len(tenant) > 0 && f.tenants[tenant] {
+		return true
+	}
+
+	if f.Percentage >= 100 {
+		return true
+	}
+
+	if f.Percentage <= 0 {
+		return false
+	}
+
+	return float64(Bucket(f.Name, key)) < f.Percentage*100
+}
+
+// Bucket returns the traffic bucket in range [0, 10000) of the specified key, which is hashed
+// with name so that different features or experiments are rolled out to different traffic.
+func Bucket(name, key string) uint64 {
+	return xxhash.Sum64String(name+"/"+key) % 10000
+}
+
+// BucketKey returns the key to bucket RPC request in context, which is the API key if present,
+// otherwise the client IP.
+func BucketKey(ctx context.Context) string {
+	if token, ok := handlers.GetAccessTokenFromContext(ctx); ok && len(token) > 0 {
+		return token
+	}
+
+	ip, _ := handlers.GetIPAddressFromContext(ctx)
+	return ip
+}
+
+var (
+	// configured flags from config file, name => flag
+	configFlags atomic.Value
+	// flags from remote provider, which override configured ones, name => flag
+	remoteFlags atomic.Value
+
+	// merged flags in use, name => flag
+	flags   atomic.Value
+	flagsMu sync.Mutex
+)
+
+func init() {
+	confs, err := loadConfigFlags()
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to load feature flags")
+	}
+
+	setConfigFlags(confs)
+
+	reload.Register("feature_flags", func() error {
+		confs, err := loadConfigFlags()
+		if err != nil {
+			return err
+		}
+
+		setConfigFlags(confs)
+		return nil
+	})
+}
+
+func loadConfigFlags() ([]FlagConfig, error) {
+	// list could only be unmarshalled as field, but not directly by key
+	var features struct {
+		Flags []FlagConfig
+	}
+
+	if err := viper.UnmarshalKey("features", &features); err != nil {
+		return nil, err
+	}
+
+	return features.Flags, nil
+}
+
+func setConfigFlags(confs []FlagConfig) {
+	configFlags.Store(toFlags(confs))
+	merge()
+}
+
+func setRemoteFlags(confs []FlagConfig) {
+	remoteFlags.Store(toFlags(confs))
+	merge()
+}
+
+func toFlags(confs []FlagConfig) map[string]*flag {
+	result := make(map[string]*flag, len(confs))
+	for _, conf := range confs {
+		result[conf.Name] = newFlag(conf)
+	}
+
+	return result
+}
+
+// merge merges configured and remote flags, and remote flags take precedence.
+func merge() {
+	flagsMu.Lock()
+	defer flagsMu.Unlock()
+
+	merged := make(map[string]*flag)
+
+	if m, ok := configFlags.Load().(map[string]*flag); ok {
+		for name, f := range m {
+			merged[name] = f
+		}
+	}
+
+	if m, ok := remoteFlags.Load().(map[string]*flag); ok {
+		for name, f := range m {
+			merged[name] = f
+		}
+	}
+
+	flags.Store(merged)
+}
+
+// IsEnabled checks if the feature is enabled for the specified tenant (API key, optional) and
+// bucket key (e.g. client IP) used for percentage rollout. Note, features without any flag
+// configured are enabled by default.
+func IsEnabled(name, tenant, key string) bool {
+	m, _ := flags.Load().(map[string]*flag)
+
+	f, ok := m[name]
+	if !ok {
+		return true
+	}
+
+	return f.enabledFor(tenant, key)
+}
+
+// Enabled checks if the feature is enabled for the RPC request in context, which is bucketed
+// by API key if present, otherwise by client IP.
+func Enabled(ctx context.Context, name string) bool {
+	tenant, _ := handlers.GetAccessTokenFromContext(ctx)
+	return IsEnabled(name, tenant, BucketKey(ctx))
+}
+
+// OptedIn checks if the opt-in feature is enabled for the RPC request in context, which differs
+// from `Enabled` in that features without any flag configured are disabled.
+func OptedIn(ctx context.Context, name string) bool {
+	m, _ := flags.Load().(map[string]*flag)
+
+	f, ok := m[name]
+	if !ok {
+		return false
+	}
+
+	tenant, _ := handlers.GetAccessTokenFromContext(ctx)
+	return f.enabledFor(tenant, BucketKey(ctx))
+}
+
+// Flags returns all the feature flags in use.
+func Flags() []FlagConfig {
+	m, _ := flags.Load().(map[string]*flag)
+
+	result := make([]FlagConfig, 0, len(m))
+	for _, f := range m {
+		result = append(result, f.FlagConfig)
+	}
+
+	return result
+}
@@ -0,0 +1,40 @@
+package feature
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFlagEnabledFor(t *testing.T) {
+	f := newFlag(FlagConfig{Name: "hedging", Enabled: true, Percentage: 30, Tenants: []string{"vip"}})
+
+	var enabled int
+	for i := 0; i < 10000; i++ {
+		if f.enabledFor("", fmt.Sprintf("10.0.%v.%v", i/256, i%256)) {
+			enabled++
+		}
+	}
+
+	assert.InDelta(t, 3000, enabled, 300)
+	assert.True(t, f.enabledFor("vip", "any"))
+
+	// kill switch
+	f = newFlag(FlagConfig{Name: "hedging", Enabled: false, Percentage: 100, Tenants: []string{"vip"}})
+	assert.False(t, f.enabledFor("vip", "any"))
+}
+
+func TestIsEnabledRemoteOverride(t *testing.T) {
+	setConfigFlags([]FlagConfig{{Name: "quorum", Enabled: true, Percentage: 100}})
+	assert.True(t, IsEnabled("quorum", "", "1.2.3.4"))
+
+	// undefined feature enabled by default
+	assert.True(t, IsEnabled("unknown", "", "1.2.3.4"))
+
+	setRemoteFlags([]FlagConfig{{Name: "quorum", Enabled: false}})
+	assert.False(t, IsEnabled("quorum", "", "1.2.3.4"))
+
+	setRemoteFlags(nil)
+	assert.True(t, IsEnabled("quorum", "", "1.2.3.4"))
+}
@@ -0,0 +1,62 @@
+package feature
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// RemoteConfig configurations of remote feature flag provider, so that flags could be changed
+// across gateway replicas instantly without config reload.
+type RemoteConfig struct {
+	// redis to load feature flags from, empty means disabled
+	RedisUrl string
+	// redis key of feature flags in JSON array
+	Key string `default:"confura:features"`
+	// interval to poll feature flags
+	Interval time.Duration `default:"10s"`
+}
+
+// LoadRemote loads feature flags from redis once.
+func LoadRemote(ctx context.Context, client *redis.Client, key string) error {
+	data, err := client.Get(ctx, key).Bytes()
+	if err == redis.Nil { // no remote flags
+		setRemoteFlags(nil)
+		return nil
+	}
+
+	if err != nil {
+		return errors.WithMessage(err, "failed to get feature flags from redis")
+	}
+
+	var confs []FlagConfig
+	if err := json.Unmarshal(data, &confs); err != nil {
+		return errors.WithMessage(err, "invalid feature flags")
+	}
+
+	setRemoteFlags(confs)
+
+	return nil
+}
+
+// PollRemote polls feature flags from redis periodically until context done. Note, the last
+// loaded flags remain in use if failed to poll.
+func PollRemote(ctx context.Context, client *redis.Client, key string, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := LoadRemote(ctx, client, key); err != nil {
+				logrus.WithError(err).WithField("key", key).Warn("Failed to poll remote feature flags")
+			}
+		}
+	}
+}
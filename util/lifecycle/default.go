@@ -0,0 +1,36 @@
+package lifecycle
+
+import (
+	"context"
+	"time"
+
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+)
+
+// Default is the default lifecycle manager of the process.
+var Default *Manager
+
+func init() {
+	var config struct {
+		// max duration for health gate of subsystem to pass after initialized
+		HealthTimeout time.Duration `default:"10s"`
+	}
+	viper.MustUnmarshalKey("lifecycle", &config)
+
+	Default = NewManager(config.HealthTimeout)
+}
+
+// Register registers a subsystem to the default lifecycle manager.
+func Register(s Subsystem) error {
+	return Default.Register(s)
+}
+
+// Start initializes all subsystems registered to the default lifecycle manager.
+func Start(ctx context.Context) error {
+	return Default.Start(ctx)
+}
+
+// Shutdown cleans up all subsystems initialized by the default lifecycle manager.
+func Shutdown() {
+	Default.Shutdown()
+}
@@ -0,0 +1,323 @@
+package lifecycle
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// State is the lifecycle state of subsystem.
+type State string
+
+const (
+	StatePending  State = "pending"
+	StateRunning  State = "running"
+	StateDegraded State = "degraded" // failed to initialize or unhealthy, but not critical
+	StateSkipped  State = "skipped"  // skipped due to any dependency not running
+	StateStopped  State = "stopped"
+)
+
+// Subsystem is a component initialized at startup, e.g. redis, web3pay or node router.
+type Subsystem struct {
+	// unique subsystem name
+	Name string
+	// names of subsystems that should be running before this one
+	DependsOn []string
+	// failure of critical subsystem aborts the startup, otherwise the service keeps
+	// running in degraded mode without this subsystem
+	Critical bool
+	// initializes the subsystem
+	Init func(ctx context.Context) error
+	// optional health gate, which should pass within health timeout after initialized
+	Health func(ctx context.Context) error
+	// optional cleanup on shutdown, which is called in reverse order of initialization
+	Shutdown func()
+	// optional readiness check to serve traffic, which is checked by readiness probe
+	// regardless of the lifecycle state, e.g. required dependency failed to initialize
+	Ready func(ctx context.Context) error
+}
+
+// Status is the lifecycle status of subsystem for diagnostics.
+type Status struct {
+	State State  `json:"state"`
+	Error string `json:"error,omitempty"`
+}
+
+// Manager manages subsystems with explicit initialization order, dependencies, health gates
+// and shutdown order.
+type Manager struct {
+	healthTimeout time.Duration
+	subsystems    []*Subsystem
+	statuses      map[string]*Status
+	started       []*Subsystem // in initialization order
+	mu            sync.Mutex
+}
+
+func NewManager(healthTimeout time.Duration) *Manager {
+	return &Manager{
+		healthTimeout: healthTimeout,
+		statuses:      make(map[string]*Status),
+	}
+}
+
+// Register registers a subsystem, which will be initialized once started.
+func (m *Manager) Register(s Subsystem) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(s.Name) == 0 || s.Init == nil {
+		return errors.New("subsystem name and init function required")
+	}
+
+	if _, ok := m.statuses[s.Name]; ok {
+		return errors.Errorf("duplicate subsystem %v", s.Name)
+	}
+
+	m.subsystems = append(m.subsystems, &s)
+	m.statuses[s.Name] = &Status{State: StatePending}
+
+	return nil
+}
+
+// Start initializes all registered subsystems in dependency order, and returns error if any
+// critical subsystem failed. Subsystems depending on a failed one are skipped.
+func (m *Manager) Start(ctx context.Context) error {
+	ordered, err := m.sort()
+	if err != nil {
+		return err
+	}
+
+	for _, s := range ordered {
+		if err := m.start(ctx, s); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *Manager) start(ctx context.Context, s *Subsystem) error {
+	logger := logrus.WithField("subsystem", s.Name)
+
+	for _, dep := range s.DependsOn {
+		if m.state(dep) == StateRunning {
+			continue
+		}
+
+		err := errors.Errorf("dependency %v not running", dep)
+		m.setStatus(s.Name, StateSkipped, err)
+
+		if s.Critical {
+			return errors.WithMessagef(err, "critical subsystem %v skipped", s.Name)
+		}
+
+		logger.WithError(err).Warn("Subsystem skipped, running in degraded mode")
+		return nil
+	}
+
+	err := s.Init(ctx)
+	if err == nil {
+		m.track(s)
+		err = m.waitHealthy(ctx, s)
+	}
+
+	if err != nil {
+		m.setStatus(s.Name, StateDegraded, err)
+
+		if s.Critical {
+			return errors.WithMessagef(err, "critical subsystem %v failed", s.Name)
+		}
+
+		logger.WithError(err).Warn("Subsystem failed, running in degraded mode")
+		return nil
+	}
+
+	m.setStatus(s.Name, StateRunning, nil)
+	logger.Info("Subsystem started")
+
+	return nil
+}
+
+// waitHealthy waits for the health gate of subsystem to pass until health timeout.
+func (m *Manager) waitHealthy(ctx context.Context, s *Subsystem) error {
+	if s.Health == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, m.healthTimeout)
+	defer cancel()
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		err := s.Health(ctx)
+		if err == nil {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return errors.WithMessage(err, "health gate not passed")
+		case <-ticker.C:
+		}
+	}
+}
+
+// sort sorts subsystems in dependency order, and preserves the registration order otherwise.
+func (m *Manager) sort() ([]*Subsystem, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	visited := make(map[string]bool)
+	visiting := make(map[string]bool)
+	name2Subsystems := make(map[string]*Subsystem)
+
+	for _, s := range m.subsystems {
+		name2Subsystems[s.Name] = s
+	}
+
+	var ordered []*Subsystem
+
+	var visit func(s *Subsystem) error
+	visit = func(s *Subsystem) error {
+		if visited[s.Name] {
+			return nil
+		}
+
+		if visiting[s.Name] {
+			return errors.Errorf("cyclic dependency on subsystem %v", s.Name)
+		}
+
+		visiting[s.Name] = true
+
+		for _, dep := range s.DependsOn {
+			ds, ok := name2Subsystems[dep]
+			if !ok {
+				return errors.Errorf("unknown dependency %v of subsystem %v", dep, s.Name)
+			}
+
+			if err := visit(ds); err != nil {
+				return err
+			}
+		}
+
+		visiting[s.Name] = false
+		visited[s.Name] = true
+		ordered = append(ordered, s)
+
+		return nil
+	}
+
+	for _, s := range m.subsystems {
+		if err := visit(s); err != nil {
+			return nil, err
+		}
+	}
+
+	return ordered, nil
+}
+
+// Shutdown cleans up the initialized subsystems in reverse order of initialization.
+func (m *Manager) Shutdown() {
+	m.mu.Lock()
+	started := m.started
+	m.started = nil
+	m.mu.Unlock()
+
+	for i := len(started) - 1; i >= 0; i-- {
+		s := started[i]
+
+		if s.Shutdown != nil {
+			s.Shutdown()
+		}
+
+		m.setStatus(s.Name, StateStopped, nil)
+		logrus.WithField("subsystem", s.Name).Info("Subsystem stopped")
+	}
+}
+
+// Status returns the lifecycle status of all registered subsystems.
+func (m *Manager) Status() map[string]Status {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	result := make(map[string]Status, len(m.statuses))
+	for name, status := range m.statuses {
+		result[name] = *status
+	}
+
+	return result
+}
+
+// Ready checks if the process is ready to serve traffic, which requires all subsystems
+// initialized, running subsystems healthy and readiness checks passed.
+func (m *Manager) Ready(ctx context.Context) error {
+	m.mu.Lock()
+	subsystems := m.subsystems
+	m.mu.Unlock()
+
+	if len(subsystems) == 0 {
+		return errors.New("no subsystem registered")
+	}
+
+	for _, s := range subsystems {
+		switch state := m.state(s.Name); state {
+		case StatePending, StateStopped:
+			return errors.Errorf("subsystem %v %v", s.Name, state)
+		case StateRunning:
+			if s.Health != nil {
+				if err := s.Health(ctx); err != nil {
+					return errors.WithMessagef(err, "subsystem %v unhealthy", s.Name)
+				}
+			}
+		}
+
+		if s.Ready != nil {
+			if err := s.Ready(ctx); err != nil {
+				return errors.WithMessagef(err, "subsystem %v not ready", s.Name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// IsRunning checks if the specified subsystem is running.
+func (m *Manager) IsRunning(name string) bool {
+	return m.state(name) == StateRunning
+}
+
+func (m *Manager) state(name string) State {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if status, ok := m.statuses[name]; ok {
+		return status.State
+	}
+
+	return ""
+}
+
+func (m *Manager) setStatus(name string, state State, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	status := Status{State: state}
+	if err != nil {
+		status.Error = err.Error()
+	}
+
+	m.statuses[name] = &status
+}
+
+// track tracks the initialized subsystem to shutdown, even if it's unhealthy.
+func (m *Manager) track(s *Subsystem) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.started = append(m.started, s)
+}
@@ -0,0 +1,100 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManagerDependencyOrder(t *testing.T) {
+	m := NewManager(time.Second)
+
+	var inits, shutdowns []string
+	register := func(name string, deps ...string) {
+		assert.Nil(t, m.Register(Subsystem{
+			Name:      name,
+			DependsOn: deps,
+			Init:      func(context.Context) error { inits = append(inits, name); return nil },
+			Shutdown:  func() { shutdowns = append(shutdowns, name) },
+		}))
+	}
+
+	register("server", "router", "redis")
+	register("router")
+	register("redis")
+
+	assert.NotNil(t, m.Register(Subsystem{Name: "redis", Init: func(context.Context) error { return nil }}))
+
+	assert.Nil(t, m.Start(context.Background()))
+	assert.Equal(t, []string{"router", "redis", "server"}, inits)
+
+	m.Shutdown()
+	assert.Equal(t, []string{"server", "redis", "router"}, shutdowns)
+}
+
+func TestManagerDegraded(t *testing.T) {
+	m := NewManager(time.Second)
+
+	m.Register(Subsystem{Name: "web3pay", Init: func(context.Context) error { return errors.New("dial failed") }})
+	m.Register(Subsystem{Name: "billing", DependsOn: []string{"web3pay"}, Init: func(context.Context) error { return nil }})
+	m.Register(Subsystem{Name: "router", Init: func(context.Context) error { return nil }})
+
+	assert.Nil(t, m.Start(context.Background()))
+
+	status := m.Status()
+	assert.Equal(t, StateDegraded, status["web3pay"].State)
+	assert.Equal(t, "dial failed", status["web3pay"].Error)
+	assert.Equal(t, StateSkipped, status["billing"].State)
+	assert.True(t, m.IsRunning("router"))
+}
+
+func TestManagerCritical(t *testing.T) {
+	m := NewManager(time.Second)
+
+	m.Register(Subsystem{Name: "a", DependsOn: []string{"b"}, Init: func(context.Context) error { return nil }})
+	m.Register(Subsystem{Name: "b", DependsOn: []string{"a"}, Init: func(context.Context) error { return nil }})
+	assert.NotNil(t, m.Start(context.Background()))
+
+	m = NewManager(time.Second)
+	m.Register(Subsystem{
+		Name:     "router",
+		Critical: true,
+		Init:     func(context.Context) error { return nil },
+		Health:   func(context.Context) error { return errors.New("no node available") },
+	})
+	assert.NotNil(t, m.Start(context.Background()))
+}
+
+func TestManagerReady(t *testing.T) {
+	m := NewManager(time.Second)
+
+	var healthy, ready bool
+	m.Register(Subsystem{Name: "redis", Init: func(context.Context) error { return nil }, Health: func(context.Context) error {
+		if !healthy {
+			return errors.New("connection refused")
+		}
+		return nil
+	}})
+	m.Register(Subsystem{Name: "web3pay", Init: func(context.Context) error { return errors.New("dial failed") }, Ready: func(context.Context) error {
+		if !ready {
+			return errors.New("billing unavailable")
+		}
+		return nil
+	}})
+
+	// not started yet
+	assert.NotNil(t, m.Ready(context.Background()))
+
+	healthy = true
+	assert.Nil(t, m.Start(context.Background()))
+	assert.NotNil(t, m.Ready(context.Background()))
+
+	ready = true
+	assert.Nil(t, m.Ready(context.Background()))
+
+	healthy = false
+	assert.NotNil(t, m.Ready(context.Background()))
+}
@@ -59,3 +59,11 @@ func (c *ExpirableLruCache) Get(key interface{}) (interface{}, bool) {
 
 	return ev.value, true
 }
+
+// Remove removes the provided key from the cache, and returns true if the key was contained.
+func (c *ExpirableLruCache) Remove(key interface{}) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.lru.Remove(key)
+}
@@ -60,6 +60,67 @@ func (*RpcMetrics) UpdateDuration(method string, err error, start time.Time) {
 	}
 }
 
+func (*RpcMetrics) Duration(method string) metrics.Timer {
+	return GetOrRegisterTimer("infura/rpc/duration/%v", method)
+}
+
+// RPC metrics - latency of candidate node minus primary node in ns for shadow traffic.
+func (*RpcMetrics) ShadowLatencyDiff(method string) metrics.Histogram {
+	return GetOrRegisterHistogram("infura/rpc/shadow/latency/diff/%v", method)
+}
+
+// RPC metrics - routing experiments segmented by experiment arm.
+
+func (*RpcMetrics) ExperimentDuration(experiment, arm, method string) metrics.Timer {
+	return GetOrRegisterTimer("infura/rpc/experiment/%v/%v/duration/%v", experiment, arm, method)
+}
+
+func (*RpcMetrics) ExperimentSuccess(experiment, arm, method string) Percentage {
+	return GetOrRegisterTimeWindowPercentageDefault("infura/rpc/experiment/%v/%v/success/%v", experiment, arm, method)
+}
+
+// RPC metrics - requests rejected by concurrency limits or upstream quotas.
+
+func (*RpcMetrics) ConcurrencyRejected(scope, method string) metrics.Meter {
+	return GetOrRegisterMeter("infura/rpc/concurrency/rejected/%v/%v", scope, method)
+}
+
+func (*RpcMetrics) UpstreamQuotaExhausted(provider string) Percentage {
+	return GetOrRegisterTimeWindowPercentageDefault("infura/rpc/upstream/quota/exhausted/%v", provider)
+}
+
+// RPC metrics - responses exceeding size quota per method and policy.
+
+func (*RpcMetrics) ResponseOversized(method, policy string) metrics.Meter {
+	return GetOrRegisterMeter("infura/rpc/response/oversized/%v/%v", method, policy)
+}
+
+// RPC metrics - requests overflowed per upstream node, e.g. rerouted or rejected.
+
+func (*RpcMetrics) NodeOverflow(node, action string) metrics.Meter {
+	return GetOrRegisterMeter("infura/rpc/node/overflow/%v/%v", action, node)
+}
+
+// RPC metrics - gateway envelope across federated gateway instances.
+
+func (*RpcMetrics) EnvelopeRejected(reason string) metrics.Meter {
+	return GetOrRegisterMeter("infura/rpc/envelope/rejected/%v", reason)
+}
+
+func (*RpcMetrics) EnvelopeHops() metrics.Histogram {
+	return GetOrRegisterHistogram("infura/rpc/envelope/hops")
+}
+
+// RPC metrics - anomalous traffic detected per kind, and clients throttled by stricter limits.
+
+func (*RpcMetrics) Anomaly(kind string) metrics.Meter {
+	return GetOrRegisterMeter("infura/rpc/anomaly/%v", kind)
+}
+
+func (*RpcMetrics) AnomalyThrottled() metrics.Gauge {
+	return GetOrRegisterGauge("infura/rpc/anomaly/throttled")
+}
+
 // RPC metrics - inputs
 
 func (*RpcMetrics) InputEpoch(method, epoch string) Percentage {
@@ -117,6 +178,50 @@ func (*RpcMetrics) FullnodeNonRpcErrorRate(node ...string) Percentage {
 	return GetOrRegisterTimeWindowPercentageDefault("infura/rpc/fullnode/rate/nonRpcErr/%v", node[0])
 }
 
+// FullnodeCanaryErrorRate is the overall error rate of canary nodes or the others, so as to
+// compare new node versions against the stable ones.
+func (*RpcMetrics) FullnodeCanaryErrorRate(canary bool) Percentage {
+	if canary {
+		return GetOrRegisterTimeWindowPercentageDefault("infura/rpc/fullnode/rate/canary/error")
+	}
+
+	return GetOrRegisterTimeWindowPercentageDefault("infura/rpc/fullnode/rate/stable/error")
+}
+
+func (*RpcMetrics) StaticFallback(method string) metrics.Meter {
+	return GetOrRegisterMeter("infura/rpc/fallback/static/%v", method)
+}
+
+func (*RpcMetrics) ErrorNormalized(method string) metrics.Meter {
+	return GetOrRegisterMeter("infura/rpc/error/normalized/%v", method)
+}
+
+func (*RpcMetrics) FinalityResolved(method string) metrics.Meter {
+	return GetOrRegisterMeter("infura/rpc/finality/resolved/%v", method)
+}
+
+// ChaosInjected is the number of faults injected by chaos rules, e.g. latency, error or drop.
+func (*RpcMetrics) ChaosInjected(method, fault string) metrics.Meter {
+	return GetOrRegisterMeter("infura/rpc/chaos/%v/%v", fault, method)
+}
+
+// PeerForwarded is the success rate of requests forwarded to peer gateway in the region.
+func (*RpcMetrics) PeerForwarded(region string) Percentage {
+	return GetOrRegisterTimeWindowPercentageDefault("infura/rpc/peering/%v/forwarded", region)
+}
+
+// PeerLatency is the latency in milliseconds to probe peer gateway in the region.
+func (*RpcMetrics) PeerLatency(region string) metrics.Gauge {
+	return GetOrRegisterGauge("infura/rpc/peering/%v/latency", region)
+}
+
+// RPC metrics - transaction inclusion latency from broadcast to receipt available,
+// kind is either "node" or "endpoint" (sequencer or fullnode that accepts transaction).
+
+func (*RpcMetrics) TxInclusionLatency(space, kind, name string) metrics.Histogram {
+	return GetOrRegisterHistogram("infura/rpc/tx/inclusion/%v/%v/%v", space, kind, name)
+}
+
 // Sync service metrics
 type SyncMetrics struct{}
 
@@ -132,6 +237,14 @@ func (*SyncMetrics) SyncOnceSize(space, storeName string) metrics.Histogram {
 	return GetOrRegisterHistogram("infura/sync/%v/%v/once/size", space, storeName)
 }
 
+func (*SyncMetrics) ReorgDepth(space string) metrics.Histogram {
+	return GetOrRegisterHistogram("infura/sync/%v/reorg/depth", space)
+}
+
+func (*SyncMetrics) ReorgFailures(space string) metrics.Meter {
+	return GetOrRegisterMeter("infura/sync/%v/reorg/failures", space)
+}
+
 func (*SyncMetrics) QueryEpochData(space string) TimerUpdater {
 	return NewTimerUpdaterByName(fmt.Sprintf("infura/sync/%v/fullnode", space))
 }
@@ -155,6 +268,10 @@ func (*StoreMetrics) GetLogs() TimerUpdater {
 	return NewTimerUpdaterByName("infura/store/mysql/getlogs")
 }
 
+func (*StoreMetrics) LogBloomSkipped() Percentage {
+	return GetOrRegisterTimeWindowPercentageDefault("infura/store/mysql/getlogs/bloom/skipped")
+}
+
 // Node manager metrics
 type NodeManagerMetrics struct{}
 
@@ -162,6 +279,32 @@ func (*NodeManagerMetrics) Routes(space, group, node string) metrics.Meter {
 	return GetOrRegisterMeter("infura/nodes/%v/routes/%v/%v", space, group, node)
 }
 
+func (*NodeManagerMetrics) Quarantined(group string) metrics.Gauge {
+	return GetOrRegisterGauge("infura/nodes/quarantined/%v", group)
+}
+
+// RingSkew is the max ratio of partitions owned by a node to the mean, or the reciprocal.
+func (*NodeManagerMetrics) RingSkew(space, group string) metrics.GaugeFloat64 {
+	return GetOrRegisterGaugeFloat64("infura/nodes/%v/ring/skew/%v", space, group)
+}
+
+func (*NodeManagerMetrics) RingRebalanced(space, group string) metrics.Meter {
+	return GetOrRegisterMeter("infura/nodes/%v/ring/rebalanced/%v", space, group)
+}
+
+// Head is the canonical head of the default evm chain reconciled by head broadcaster.
+func (*NodeManagerMetrics) Head() metrics.Gauge {
+	return GetOrRegisterGauge("infura/nodes/eth/head")
+}
+
+func (*NodeManagerMetrics) HeadReorg() metrics.Meter {
+	return GetOrRegisterMeter("infura/nodes/eth/head/reorg")
+}
+
+func (*NodeManagerMetrics) HeadDropped() metrics.Meter {
+	return GetOrRegisterMeter("infura/nodes/eth/head/dropped")
+}
+
 func (*NodeManagerMetrics) NodeLatency(space, group, node string) string {
 	return fmt.Sprintf("infura/nodes/%v/latency/%v/%v", space, group, node)
 }
@@ -180,3 +323,17 @@ func (*PubSubMetrics) Sessions(space, topic, node string) metrics.Gauge {
 func (*PubSubMetrics) InputLogFilter(space string) Percentage {
 	return GetOrRegisterTimeWindowPercentageDefault("infura/pubsub/%v/input/logFilter", space)
 }
+
+// PubSub metrics - per connection send queue of websocket notifications.
+
+func (*PubSubMetrics) SendQueueLength(space string) metrics.Histogram {
+	return GetOrRegisterHistogram("infura/pubsub/%v/sendQueue/length", space)
+}
+
+func (*PubSubMetrics) SendQueueDropped(space string) metrics.Meter {
+	return GetOrRegisterMeter("infura/pubsub/%v/sendQueue/dropped", space)
+}
+
+func (*PubSubMetrics) SendQueueClosed(space string) metrics.Meter {
+	return GetOrRegisterMeter("infura/pubsub/%v/sendQueue/closed", space)
+}
@@ -0,0 +1,318 @@
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// PushConfig configurations to push metrics periodically, for deployments where pull-based
+// scraping of ephemeral instances isn't feasible.
+type PushConfig struct {
+	Enabled  bool
+	Interval time.Duration `default:"10s"`
+	// Prometheus Pushgateway, which keeps the latest metrics only
+	Pushgateway struct {
+		Url string
+		Job string `default:"confura"`
+		// instance label, and empty means hostname
+		Instance string
+	}
+	// InfluxDB v2, of which unsent points are batched and retried in next push
+	InfluxDBV2 struct {
+		Url    string
+		Token  string
+		Org    string
+		Bucket string
+		// extra tags appended to all points
+		Tags map[string]string
+	}
+	// max number of lines per push request
+	BatchSize int `default:"5000"`
+	// max number of pending lines kept for retry, and the oldest ones are dropped once exceeded
+	MaxPending int `default:"100000"`
+	// retries with exponential backoff for each push
+	MaxRetries    int           `default:"3"`
+	RetryInterval time.Duration `default:"1s"`
+	Timeout       time.Duration `default:"5s"`
+}
+
+// pushTarget pushes encoded metrics to the remote.
+type pushTarget interface {
+	name() string
+	// encode encodes metrics snapshot into lines
+	encode(r metrics.Registry, now time.Time) []string
+	// send sends lines to the remote
+	send(client *http.Client, lines []string) error
+	// accumulate indicates whether unsent lines should be kept for retry
+	accumulate() bool
+}
+
+// pusher pushes metrics to the target with batching and retry.
+type pusher struct {
+	conf    PushConfig
+	target  pushTarget
+	client  *http.Client
+	pending []string
+}
+
+// startPush starts to push metrics to the configured targets periodically.
+func startPush(conf PushConfig) {
+	var targets []pushTarget
+
+	if len(conf.Pushgateway.Url) > 0 {
+		instance := conf.Pushgateway.Instance
+		if len(instance) == 0 {
+			instance, _ = os.Hostname()
+		}
+
+		targets = append(targets, &pushgatewayTarget{
+			url: conf.Pushgateway.Url, job: conf.Pushgateway.Job, instance: instance,
+		})
+	}
+
+	if len(conf.InfluxDBV2.Url) > 0 {
+		targets = append(targets, &influxDBV2Target{
+			url:    conf.InfluxDBV2.Url,
+			token:  conf.InfluxDBV2.Token,
+			org:    conf.InfluxDBV2.Org,
+			bucket: conf.InfluxDBV2.Bucket,
+			tags:   encodeInfluxTags(conf.InfluxDBV2.Tags),
+		})
+	}
+
+	for _, t := range targets {
+		p := pusher{conf: conf, target: t, client: &http.Client{Timeout: conf.Timeout}}
+		go p.run()
+
+		logrus.WithField("target", t.name()).Info("Start to push metrics periodically")
+	}
+}
+
+func (p *pusher) run() {
+	ticker := time.NewTicker(p.conf.Interval)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		p.pushOnce(now)
+	}
+}
+
+func (p *pusher) pushOnce(now time.Time) {
+	lines := p.target.encode(InfuraRegistry, now)
+
+	if p.target.accumulate() {
+		p.pending = append(p.pending, lines...)
+		if overflow := len(p.pending) - p.conf.MaxPending; overflow > 0 {
+			p.pending = p.pending[overflow:]
+		}
+	} else {
+		p.pending = lines
+	}
+
+	for len(p.pending) > 0 {
+		size := len(p.pending)
+		if p.conf.BatchSize > 0 && size > p.conf.BatchSize {
+			size = p.conf.BatchSize
+		}
+
+		if err := p.sendWithRetry(p.pending[:size]); err != nil {
+			logrus.WithError(err).WithFields(logrus.Fields{
+				"target": p.target.name(), "pending": len(p.pending),
+			}).Warn("Failed to push metrics")
+			return
+		}
+
+		p.pending = p.pending[size:]
+	}
+}
+
+func (p *pusher) sendWithRetry(lines []string) (err error) {
+	backoff := p.conf.RetryInterval
+
+	for i := 0; ; i++ {
+		if err = p.target.send(p.client, lines); err == nil || i >= p.conf.MaxRetries {
+			return err
+		}
+
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// postLines posts lines to the remote, and returns error if not succeeded.
+func postLines(client *http.Client, req *http.Request) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return errors.Errorf("unexpected status code %v", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// pushgatewayTarget pushes metrics to Prometheus Pushgateway in text exposition format.
+type pushgatewayTarget struct {
+	url, job, instance string
+}
+
+func (t *pushgatewayTarget) name() string     { return "pushgateway" }
+func (t *pushgatewayTarget) accumulate() bool { return false }
+
+func (t *pushgatewayTarget) send(client *http.Client, lines []string) error {
+	u := fmt.Sprintf(
+		"%v/metrics/job/%v/instance/%v",
+		strings.TrimSuffix(t.url, "/"), url.PathEscape(t.job), url.PathEscape(t.instance),
+	)
+
+	// PUT replaces all metrics of the same grouping key
+	req, err := http.NewRequest(http.MethodPut, u, strings.NewReader(strings.Join(lines, "\n")+"\n"))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "text/plain; version=0.0.4")
+
+	return postLines(client, req)
+}
+
+var invalidPromNameChars = regexp.MustCompile(`[^a-zA-Z0-9_:]`)
+
+func (t *pushgatewayTarget) encode(r metrics.Registry, now time.Time) []string {
+	var lines []string
+
+	gauge := func(name string, value interface{}) {
+		lines = append(lines, fmt.Sprintf("%v %v", name, value))
+	}
+
+	r.Each(func(name string, i interface{}) {
+		name = invalidPromNameChars.ReplaceAllString(name, "_")
+
+		switch m := i.(type) {
+		case metrics.Counter:
+			gauge(name, m.Count())
+		case metrics.Gauge:
+			gauge(name, m.Value())
+		case metrics.GaugeFloat64:
+			gauge(name, m.Value())
+		case metrics.Meter:
+			s := m.Snapshot()
+			gauge(name+"_count", s.Count())
+			gauge(name+"_rate1", s.Rate1())
+		case metrics.Histogram:
+			s := m.Snapshot()
+			gauge(name+"_count", s.Count())
+			for q, v := range s.Percentiles(pushQuantiles) {
+				lines = append(lines, fmt.Sprintf("%v{quantile=\"%v\"} %v", name, pushQuantiles[q], v))
+			}
+		case metrics.Timer:
+			s := m.Snapshot()
+			gauge(name+"_count", s.Count())
+			gauge(name+"_rate1", s.Rate1())
+			for q, v := range s.Percentiles(pushQuantiles) {
+				lines = append(lines, fmt.Sprintf("%v{quantile=\"%v\"} %v", name, pushQuantiles[q], v))
+			}
+		}
+	})
+
+	return lines
+}
+
+var pushQuantiles = []float64{0.5, 0.9, 0.99}
+
+// influxDBV2Target pushes metrics to InfluxDB v2 in line protocol.
+type influxDBV2Target struct {
+	url, token, org, bucket string
+	tags                    string // encoded tags, e.g. `,host=a,region=b`
+}
+
+func (t *influxDBV2Target) name() string     { return "influxdbv2" }
+func (t *influxDBV2Target) accumulate() bool { return true }
+
+func (t *influxDBV2Target) send(client *http.Client, lines []string) error {
+	u := fmt.Sprintf(
+		"%v/api/v2/write?org=%v&bucket=%v&precision=ns",
+		strings.TrimSuffix(t.url, "/"), url.QueryEscape(t.org), url.QueryEscape(t.bucket),
+	)
+
+	req, err := http.NewRequest(http.MethodPost, u, bytes.NewBufferString(strings.Join(lines, "\n")))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Authorization", "Token "+t.token)
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+
+	return postLines(client, req)
+}
+
+func (t *influxDBV2Target) encode(r metrics.Registry, now time.Time) []string {
+	var lines []string
+
+	point := func(name, fields string) {
+		lines = append(lines, fmt.Sprintf("%v%v %v %v", escapeInfluxKey(name), t.tags, fields, now.UnixNano()))
+	}
+
+	r.Each(func(name string, i interface{}) {
+		switch m := i.(type) {
+		case metrics.Counter:
+			point(name, fmt.Sprintf("count=%vi", m.Count()))
+		case metrics.Gauge:
+			point(name, fmt.Sprintf("value=%vi", m.Value()))
+		case metrics.GaugeFloat64:
+			point(name, fmt.Sprintf("value=%v", m.Value()))
+		case metrics.Meter:
+			s := m.Snapshot()
+			point(name, fmt.Sprintf("count=%vi,m1=%v,m5=%v,m15=%v,mean=%v",
+				s.Count(), s.Rate1(), s.Rate5(), s.Rate15(), s.RateMean()))
+		case metrics.Histogram:
+			s := m.Snapshot()
+			ps := s.Percentiles(pushQuantiles)
+			point(name, fmt.Sprintf("count=%vi,mean=%v,p50=%v,p90=%v,p99=%v",
+				s.Count(), s.Mean(), ps[0], ps[1], ps[2]))
+		case metrics.Timer:
+			s := m.Snapshot()
+			ps := s.Percentiles(pushQuantiles)
+			point(name, fmt.Sprintf("count=%vi,m1=%v,mean=%v,p50=%v,p90=%v,p99=%v",
+				s.Count(), s.Rate1(), s.Mean(), ps[0], ps[1], ps[2]))
+		}
+	})
+
+	return lines
+}
+
+var influxKeyEscaper = strings.NewReplacer(",", `\,`, " ", `\ `, "=", `\=`)
+
+func escapeInfluxKey(key string) string {
+	return influxKeyEscaper.Replace(key)
+}
+
+func encodeInfluxTags(tags map[string]string) string {
+	var keys []string
+	for k := range tags {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys) // tags sorted by key for better performance
+
+	var sb strings.Builder
+	for _, k := range keys {
+		fmt.Fprintf(&sb, ",%v=%v", escapeInfluxKey(k), escapeInfluxKey(tags[k]))
+	}
+
+	return sb.String()
+}
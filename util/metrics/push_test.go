@@ -0,0 +1,100 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakePushTarget encodes fixed lines, and fails the specified number of sends.
+type fakePushTarget struct {
+	lines    []string
+	keep     bool
+	failures int
+	sent     [][]string
+}
+
+func (t *fakePushTarget) name() string     { return "fake" }
+func (t *fakePushTarget) accumulate() bool { return t.keep }
+
+func (t *fakePushTarget) encode(r metrics.Registry, now time.Time) []string {
+	return t.lines
+}
+
+func (t *fakePushTarget) send(client *http.Client, lines []string) error {
+	if t.failures > 0 {
+		t.failures--
+		return errors.New("remote unavailable")
+	}
+
+	t.sent = append(t.sent, lines)
+	return nil
+}
+
+func TestPusherPushOnce(t *testing.T) {
+	lines := []string{"a", "b", "c", "d", "e"}
+
+	tests := []struct {
+		keep       bool
+		failures   int   // failed sends in the first push
+		sent       []int // size of sent batches in two pushes
+		pending    int   // pending lines after the first push
+		maxPending int
+	}{
+		// sent in batches
+		{false, 0, []int{2, 2, 1, 2, 2, 1}, 0, 100},
+		// recovered by retry
+		{false, 1, []int{2, 2, 1, 2, 2, 1}, 0, 100},
+		// latest lines only
+		{false, 2, []int{2, 2, 1}, 5, 100},
+		// unsent lines retried in the next push
+		{true, 2, []int{2, 2, 2, 2, 2}, 5, 100},
+		// oldest lines dropped once exceeded max pending
+		{true, 2, []int{2, 2, 2, 1}, 5, 7},
+	}
+
+	for _, tt := range tests {
+		target := &fakePushTarget{lines: lines, keep: tt.keep, failures: tt.failures}
+		p := pusher{
+			conf:   PushConfig{BatchSize: 2, MaxPending: tt.maxPending, MaxRetries: 1},
+			target: target,
+		}
+
+		p.pushOnce(time.Now())
+		assert.Equal(t, tt.pending, len(p.pending))
+
+		p.pushOnce(time.Now())
+		assert.Empty(t, p.pending)
+
+		var sent []int
+		for _, batch := range target.sent {
+			sent = append(sent, len(batch))
+		}
+
+		assert.Equal(t, tt.sent, sent)
+	}
+}
+
+func TestPushEncode(t *testing.T) {
+	r := metrics.NewRegistry()
+	metrics.NewRegisteredCounterForced("rpc/requests", r).Inc(3)
+
+	gauge := &metrics.StandardGauge{}
+	gauge.Update(7)
+	r.Register("node cache,size", gauge)
+
+	now := time.Unix(0, 100)
+
+	pushgateway := &pushgatewayTarget{}
+	assert.ElementsMatch(t, []string{"rpc_requests 3", "node_cache_size 7"}, pushgateway.encode(r, now))
+
+	influx := &influxDBV2Target{tags: encodeInfluxTags(map[string]string{"region": "us", "host": "a b"})}
+	assert.ElementsMatch(t, []string{
+		`rpc/requests,host=a\ b,region=us count=3i 100`,
+		`node\ cache\,size,host=a\ b,region=us value=7i 100`,
+	}, influx.encode(r, now))
+}
@@ -27,13 +27,22 @@ func Init() {
 			Enabled  bool
 			Interval time.Duration `default:"10s"`
 		}
+		Push PushConfig
 	}
 
 	viper.MustUnmarshalKey("metrics", &config)
 
 	metrics.Enabled = config.Enabled
 
-	if !metrics.Enabled || !config.Report.Enabled {
+	if !metrics.Enabled {
+		return
+	}
+
+	if config.Push.Enabled {
+		startPush(config.Push)
+	}
+
+	if !config.Report.Enabled {
 		return
 	}
 
@@ -0,0 +1,171 @@
+// Package mock provides a deterministic fake evm chain served over JSON-RPC, which is used for
+// tests and local development without real full nodes.
+package mock
+
+import (
+	"encoding/binary"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const (
+	// timestamp of the genesis block
+	genesisTimestamp = 1700000000
+	// max number of transactions in a block
+	maxBlockTxs = 4
+	// gas used by each transaction
+	txGas = 21000
+)
+
+// ChainConfig configurations of the mock chain.
+type ChainConfig struct {
+	ChainId uint64 `default:"1337"`
+	// initial height of the chain head
+	Height uint64 `default:"100"`
+	// interval to produce a new block, and 0 means the chain head never moves
+	BlockTime time.Duration `default:"2s"`
+}
+
+// Chain is a deterministic fake chain, of which block data depends on chain ID and height only,
+// and the chain head moves along with time since created.
+type Chain struct {
+	conf    ChainConfig
+	started time.Time
+	now     func() time.Time
+}
+
+func NewChain(conf ChainConfig) *Chain {
+	return &Chain{conf: conf, started: time.Now(), now: time.Now}
+}
+
+// ChainId returns the chain ID.
+func (c *Chain) ChainId() uint64 {
+	return c.conf.ChainId
+}
+
+// Head returns the height of the chain head.
+func (c *Chain) Head() uint64 {
+	if c.conf.BlockTime <= 0 {
+		return c.conf.Height
+	}
+
+	return c.conf.Height + uint64(c.now().Sub(c.started)/c.conf.BlockTime)
+}
+
+// Tx is a transaction of the mock chain.
+type Tx struct {
+	Hash        common.Hash
+	BlockNumber uint64
+	BlockHash   common.Hash
+	Index       uint64
+	From        common.Address
+	To          common.Address
+	Value       *big.Int
+}
+
+// Block is a block of the mock chain.
+type Block struct {
+	Number     uint64
+	Hash       common.Hash
+	ParentHash common.Hash
+	Timestamp  uint64
+	Txs        []*Tx
+}
+
+// Block returns the block of the specified height, and false if beyond the chain head.
+func (c *Chain) Block(number uint64) (*Block, bool) {
+	if number > c.Head() {
+		return nil, false
+	}
+
+	block := Block{
+		Number:    number,
+		Hash:      c.blockHash(number),
+		Timestamp: genesisTimestamp + number*c.blockSeconds(),
+	}
+
+	if number > 0 {
+		block.ParentHash = c.blockHash(number - 1)
+	}
+
+	for i := uint64(0); i < number%(maxBlockTxs+1); i++ {
+		block.Txs = append(block.Txs, &Tx{
+			Hash:        c.txHash(number, i),
+			BlockNumber: number,
+			BlockHash:   block.Hash,
+			Index:       i,
+			From:        c.account(number + i),
+			To:          c.account(number + i + 1),
+			Value:       new(big.Int).SetUint64((number + 1) * (i + 1)),
+		})
+	}
+
+	return &block, true
+}
+
+// BlockByHash returns the block of the specified hash if any.
+func (c *Chain) BlockByHash(hash common.Hash) (*Block, bool) {
+	// block height is encoded in the last 8 bytes of block hash
+	number := binary.BigEndian.Uint64(hash[24:])
+	if c.blockHash(number) != hash {
+		return nil, false
+	}
+
+	return c.Block(number)
+}
+
+// Tx returns the transaction of the specified hash if any.
+func (c *Chain) Tx(hash common.Hash) (*Tx, bool) {
+	// block height and tx index are encoded in the last 12 bytes of tx hash
+	number := binary.BigEndian.Uint64(hash[24:])
+	index := uint64(binary.BigEndian.Uint32(hash[20:24]))
+
+	if c.txHash(number, index) != hash {
+		return nil, false
+	}
+
+	block, ok := c.Block(number)
+	if !ok || index >= uint64(len(block.Txs)) {
+		return nil, false
+	}
+
+	return block.Txs[index], true
+}
+
+func (c *Chain) blockSeconds() uint64 {
+	if secs := uint64(c.conf.BlockTime / time.Second); secs > 0 {
+		return secs
+	}
+
+	return 1
+}
+
+func (c *Chain) blockHash(number uint64) common.Hash {
+	hash := c.keccak("block", number)
+	binary.BigEndian.PutUint64(hash[24:], number)
+	return hash
+}
+
+func (c *Chain) txHash(number, index uint64) common.Hash {
+	hash := c.keccak("tx", number, index)
+	binary.BigEndian.PutUint32(hash[20:24], uint32(index))
+	binary.BigEndian.PutUint64(hash[24:], number)
+	return hash
+}
+
+func (c *Chain) account(seed uint64) common.Address {
+	return common.BytesToAddress(c.keccak("account", seed%16).Bytes())
+}
+
+func (c *Chain) keccak(kind string, values ...uint64) common.Hash {
+	data := append([]byte(kind), hexutil.EncodeUint64(c.conf.ChainId)...)
+	for _, v := range values {
+		data = append(data, hexutil.EncodeUint64(v)...)
+	}
+
+	return crypto.Keccak256Hash(data)
+}
@@ -0,0 +1,94 @@
+package mock
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChain(t *testing.T) {
+	now := time.Now()
+
+	chain := NewChain(ChainConfig{ChainId: 1337, Height: 100, BlockTime: 2 * time.Second})
+	chain.now = func() time.Time { return now }
+	chain.started = now
+
+	assert.Equal(t, uint64(100), chain.Head())
+
+	now = now.Add(5 * time.Second)
+	assert.Equal(t, uint64(102), chain.Head())
+
+	_, ok := chain.Block(103)
+	assert.False(t, ok)
+
+	block, ok := chain.Block(102)
+	assert.True(t, ok)
+	assert.Len(t, block.Txs, 2)
+
+	// deterministic regardless of chain instance
+	other := NewChain(ChainConfig{ChainId: 1337, Height: 200, BlockTime: 2 * time.Second})
+	otherBlock, _ := other.Block(102)
+	assert.Equal(t, block, otherBlock)
+
+	parent, _ := chain.Block(101)
+	assert.Equal(t, parent.Hash, block.ParentHash)
+
+	byHash, ok := chain.BlockByHash(block.Hash)
+	assert.True(t, ok)
+	assert.Equal(t, block, byHash)
+
+	_, ok = chain.BlockByHash(common.Hash{})
+	assert.False(t, ok)
+
+	tx, ok := chain.Tx(block.Txs[1].Hash)
+	assert.True(t, ok)
+	assert.Equal(t, block.Txs[1], tx)
+
+	// different chain
+	_, ok = NewChain(ChainConfig{ChainId: 1, Height: 200}).Tx(tx.Hash)
+	assert.False(t, ok)
+}
+
+func TestHandler(t *testing.T) {
+	handler, err := NewHandler(NewChain(ChainConfig{ChainId: 1337, Height: 100}))
+	assert.NoError(t, err)
+
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	call := func(method string, params ...interface{}) json.RawMessage {
+		body, _ := json.Marshal(map[string]interface{}{
+			"jsonrpc": "2.0", "id": 1, "method": method, "params": params,
+		})
+
+		resp, err := http.Post(server.URL, "application/json", bytes.NewReader(body))
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+
+		var result struct {
+			Result json.RawMessage
+		}
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+
+		return result.Result
+	}
+
+	assert.Equal(t, json.RawMessage(`"0x539"`), call("eth_chainId"))
+	assert.Equal(t, json.RawMessage(`"0x64"`), call("eth_blockNumber"))
+	assert.Equal(t, json.RawMessage(`"1337"`), call("net_version"))
+	assert.Equal(t, json.RawMessage("null"), call("eth_getBlockByNumber", "0x65", false))
+
+	var block struct {
+		Number       string
+		Transactions []common.Hash
+	}
+	assert.NoError(t, json.Unmarshal(call("eth_getBlockByNumber", "latest", false), &block))
+	assert.Equal(t, "0x64", block.Number)
+	assert.Empty(t, block.Transactions)
+}
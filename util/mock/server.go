@@ -0,0 +1,243 @@
+package mock
+
+import (
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	gethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/pkg/errors"
+)
+
+var (
+	// balance of any account
+	mockBalance = new(big.Int).Mul(big.NewInt(1000), big.NewInt(1e18))
+	// gas price of any transaction
+	mockGasPrice = big.NewInt(1e9)
+	// gas limit of any block
+	mockGasLimit = uint64(10000000)
+)
+
+// NewHandler creates JSON-RPC HTTP handler of the mock chain, which supports common methods
+// of `eth`, `net` and `web3` namespaces.
+func NewHandler(chain *Chain) (http.Handler, error) {
+	server := rpc.NewServer()
+
+	services := map[string]interface{}{
+		"eth":  &ethService{chain},
+		"net":  &netService{chain},
+		"web3": &web3Service{},
+	}
+
+	for namespace, service := range services {
+		if err := server.RegisterName(namespace, service); err != nil {
+			return nil, errors.WithMessagef(err, "failed to register %v namespace", namespace)
+		}
+	}
+
+	return server, nil
+}
+
+type web3Service struct{}
+
+func (s *web3Service) ClientVersion() string {
+	return "mock/v1.0.0"
+}
+
+type netService struct {
+	chain *Chain
+}
+
+func (s *netService) Version() string {
+	return strconv.FormatUint(s.chain.ChainId(), 10)
+}
+
+func (s *netService) Listening() bool {
+	return true
+}
+
+func (s *netService) PeerCount() hexutil.Uint {
+	return 0
+}
+
+type ethService struct {
+	chain *Chain
+}
+
+func (s *ethService) ChainId() hexutil.Uint64 {
+	return hexutil.Uint64(s.chain.ChainId())
+}
+
+func (s *ethService) BlockNumber() hexutil.Uint64 {
+	return hexutil.Uint64(s.chain.Head())
+}
+
+func (s *ethService) Syncing() bool {
+	return false
+}
+
+func (s *ethService) GasPrice() *hexutil.Big {
+	return (*hexutil.Big)(mockGasPrice)
+}
+
+func (s *ethService) MaxPriorityFeePerGas() *hexutil.Big {
+	return (*hexutil.Big)(big.NewInt(0))
+}
+
+func (s *ethService) GetBalance(address common.Address, block *json.RawMessage) *hexutil.Big {
+	return (*hexutil.Big)(mockBalance)
+}
+
+func (s *ethService) GetTransactionCount(address common.Address, block *json.RawMessage) hexutil.Uint64 {
+	return 0
+}
+
+func (s *ethService) GetCode(address common.Address, block *json.RawMessage) hexutil.Bytes {
+	return hexutil.Bytes{}
+}
+
+func (s *ethService) GetStorageAt(address common.Address, key string, block *json.RawMessage) common.Hash {
+	return common.Hash{}
+}
+
+func (s *ethService) Call(request json.RawMessage, block *json.RawMessage) hexutil.Bytes {
+	return hexutil.Bytes{}
+}
+
+func (s *ethService) EstimateGas(request json.RawMessage, block *json.RawMessage) hexutil.Uint64 {
+	return txGas
+}
+
+func (s *ethService) GetLogs(filter json.RawMessage) []interface{} {
+	return []interface{}{}
+}
+
+// SendRawTransaction accepts any raw transaction without execution, and returns the hash.
+func (s *ethService) SendRawTransaction(signedTx hexutil.Bytes) common.Hash {
+	return crypto.Keccak256Hash(signedTx)
+}
+
+func (s *ethService) GetBlockByNumber(blockNum string, fullTx bool) (map[string]interface{}, error) {
+	number, err := s.parseBlockNumber(blockNum)
+	if err != nil {
+		return nil, err
+	}
+
+	if block, ok := s.chain.Block(number); ok {
+		return marshalBlock(block, fullTx), nil
+	}
+
+	return nil, nil
+}
+
+func (s *ethService) GetBlockByHash(hash common.Hash, fullTx bool) map[string]interface{} {
+	if block, ok := s.chain.BlockByHash(hash); ok {
+		return marshalBlock(block, fullTx)
+	}
+
+	return nil
+}
+
+func (s *ethService) GetTransactionByHash(hash common.Hash) map[string]interface{} {
+	if tx, ok := s.chain.Tx(hash); ok {
+		return marshalTx(tx)
+	}
+
+	return nil
+}
+
+func (s *ethService) GetTransactionReceipt(hash common.Hash) map[string]interface{} {
+	tx, ok := s.chain.Tx(hash)
+	if !ok {
+		return nil
+	}
+
+	return map[string]interface{}{
+		"transactionHash":   tx.Hash,
+		"transactionIndex":  hexutil.Uint64(tx.Index),
+		"blockHash":         tx.BlockHash,
+		"blockNumber":       hexutil.Uint64(tx.BlockNumber),
+		"from":              tx.From,
+		"to":                tx.To,
+		"contractAddress":   nil,
+		"cumulativeGasUsed": hexutil.Uint64(txGas * (tx.Index + 1)),
+		"gasUsed":           hexutil.Uint64(txGas),
+		"effectiveGasPrice": (*hexutil.Big)(mockGasPrice),
+		"logs":              []interface{}{},
+		"logsBloom":         gethTypes.Bloom{},
+		"status":            hexutil.Uint64(1),
+		"type":              hexutil.Uint64(0),
+	}
+}
+
+// parseBlockNumber parses block number or tag, and any tag other than `earliest` is regarded
+// as the chain head.
+func (s *ethService) parseBlockNumber(blockNum string) (uint64, error) {
+	switch blockNum {
+	case "earliest":
+		return 0, nil
+	case "latest", "pending", "safe", "finalized":
+		return s.chain.Head(), nil
+	}
+
+	return hexutil.DecodeUint64(blockNum)
+}
+
+func marshalBlock(block *Block, fullTx bool) map[string]interface{} {
+	txs := make([]interface{}, 0, len(block.Txs))
+	for _, tx := range block.Txs {
+		if fullTx {
+			txs = append(txs, marshalTx(tx))
+		} else {
+			txs = append(txs, tx.Hash)
+		}
+	}
+
+	return map[string]interface{}{
+		"number":           hexutil.Uint64(block.Number),
+		"hash":             block.Hash,
+		"parentHash":       block.ParentHash,
+		"nonce":            gethTypes.BlockNonce{},
+		"mixHash":          common.Hash{},
+		"sha3Uncles":       gethTypes.EmptyUncleHash,
+		"logsBloom":        gethTypes.Bloom{},
+		"transactionsRoot": gethTypes.EmptyRootHash,
+		"stateRoot":        gethTypes.EmptyRootHash,
+		"receiptsRoot":     gethTypes.EmptyRootHash,
+		"miner":            common.Address{},
+		"difficulty":       (*hexutil.Big)(big.NewInt(0)),
+		"totalDifficulty":  (*hexutil.Big)(big.NewInt(0)),
+		"extraData":        hexutil.Bytes{},
+		"size":             hexutil.Uint64(0),
+		"gasLimit":         hexutil.Uint64(mockGasLimit),
+		"gasUsed":          hexutil.Uint64(txGas * uint64(len(block.Txs))),
+		"timestamp":        hexutil.Uint64(block.Timestamp),
+		"transactions":     txs,
+		"uncles":           []common.Hash{},
+	}
+}
+
+func marshalTx(tx *Tx) map[string]interface{} {
+	return map[string]interface{}{
+		"hash":             tx.Hash,
+		"blockHash":        tx.BlockHash,
+		"blockNumber":      hexutil.Uint64(tx.BlockNumber),
+		"transactionIndex": hexutil.Uint64(tx.Index),
+		"from":             tx.From,
+		"to":               tx.To,
+		"value":            (*hexutil.Big)(tx.Value),
+		"gas":              hexutil.Uint64(txGas),
+		"gasPrice":         (*hexutil.Big)(mockGasPrice),
+		"nonce":            hexutil.Uint64(tx.BlockNumber),
+		"input":            hexutil.Bytes{},
+		"type":             hexutil.Uint64(0),
+		"v":                (*hexutil.Big)(big.NewInt(0)),
+		"r":                (*hexutil.Big)(big.NewInt(0)),
+		"s":                (*hexutil.Big)(big.NewInt(0)),
+	}
+}
@@ -0,0 +1,161 @@
+// Package objstore provides a minimal client of S3-compatible object storage, which signs
+// requests with AWS signature version 4 and supports path-style addressing only.
+package objstore
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var ErrObjectNotFound = errors.New("object not found")
+
+// Config configurations of S3-compatible object storage.
+type Config struct {
+	// endpoint of object storage, e.g. `https://s3.us-east-1.amazonaws.com`
+	Endpoint  string
+	Region    string `default:"us-east-1"`
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	// key prefix of all objects
+	Prefix  string
+	Timeout time.Duration `default:"30s"`
+}
+
+// S3 is the client of S3-compatible object storage.
+type S3 struct {
+	conf   Config
+	client *http.Client
+}
+
+func NewS3(conf Config) (*S3, error) {
+	if len(conf.Endpoint) == 0 || len(conf.Bucket) == 0 {
+		return nil, errors.New("object storage endpoint or bucket not specified")
+	}
+
+	if _, err := url.Parse(conf.Endpoint); err != nil {
+		return nil, errors.WithMessage(err, "invalid object storage endpoint")
+	}
+
+	return &S3{
+		conf:   conf,
+		client: &http.Client{Timeout: conf.Timeout},
+	}, nil
+}
+
+// PutObject uploads the object with the specified key.
+func (s *S3) PutObject(ctx context.Context, key string, data []byte) error {
+	resp, err := s.do(ctx, http.MethodPut, key, data)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return errors.Errorf("failed to put object %v, status = %v, body = %v", key, resp.Status, string(body))
+	}
+
+	return nil
+}
+
+// GetObject downloads the object with the specified key.
+func (s *S3) GetObject(ctx context.Context, key string) ([]byte, error) {
+	resp, err := s.do(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrObjectNotFound
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed to read object %v", key)
+	}
+
+	if resp.StatusCode/100 != 2 {
+		return nil, errors.Errorf("failed to get object %v, status = %v, body = %v", key, resp.Status, string(body))
+	}
+
+	return body, nil
+}
+
+func (s *S3) do(ctx context.Context, method, key string, body []byte) (*http.Response, error) {
+	endpoint, _ := url.Parse(s.conf.Endpoint)
+	endpoint.Path = "/" + s.conf.Bucket + "/" + strings.TrimPrefix(s.conf.Prefix+key, "/")
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	s.sign(req, body, time.Now().UTC())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed to request object %v", key)
+	}
+
+	return resp, nil
+}
+
+// sign signs the request with AWS signature version 4.
+func (s *S3) sign(req *http.Request, body []byte, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := sha256Hex(body)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalHeaders := fmt.Sprintf(
+		"host:%v\nx-amz-content-sha256:%v\nx-amz-date:%v\n", req.URL.Host, payloadHash, amzDate,
+	)
+
+	canonicalRequest := strings.Join([]string{
+		req.Method, req.URL.EscapedPath(), req.URL.RawQuery, canonicalHeaders, signedHeaders, payloadHash,
+	}, "\n")
+
+	scope := fmt.Sprintf("%v/%v/s3/aws4_request", date, s.conf.Region)
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256", amzDate, scope, sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.conf.SecretKey), date)
+	key = hmacSHA256(key, s.conf.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%v/%v, SignedHeaders=%v, Signature=%v",
+		s.conf.AccessKey, scope, signedHeaders, signature,
+	))
+}
+
+func sha256Hex(data []byte) string {
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -1,6 +1,7 @@
 package rate
 
 import (
+	"math"
 	"sync"
 	"time"
 
@@ -15,10 +16,23 @@ type VisitContext struct {
 
 type Limiter interface {
 	Allow(vc *VisitContext, n int) bool
+	Status(vc *VisitContext) Status
 	GC(timeout time.Duration)
 	Update(option Option) bool
 }
 
+// Status is the rate limit budget of visitor, so that clients could implement proper backoff.
+type Status struct {
+	Limit     int           // max burst
+	Remaining int           // remaining tokens
+	Reset     time.Duration // duration until fully replenished
+}
+
+// ResetSeconds returns the seconds until fully replenished, which is rounded up.
+func (s Status) ResetSeconds() int64 {
+	return int64(math.Ceil(s.Reset.Seconds()))
+}
+
 // IpLimiter limiting by IP address
 type IpLimiter struct {
 	*visitLimiter
@@ -34,6 +48,10 @@ func (l *IpLimiter) Allow(vc *VisitContext, n int) bool {
 	return l.visitLimiter.Allow(vc.Ip, n)
 }
 
+func (l *IpLimiter) Status(vc *VisitContext) Status {
+	return l.visitLimiter.Status(vc.Ip)
+}
+
 // KeyLimiter limiting by limit key
 type KeyLimiter struct {
 	*visitLimiter
@@ -49,6 +67,10 @@ func (l *KeyLimiter) Allow(vc *VisitContext, n int) bool {
 	return l.visitLimiter.Allow(vc.Key, n)
 }
 
+func (l *KeyLimiter) Status(vc *VisitContext) Status {
+	return l.visitLimiter.Status(vc.Key)
+}
+
 type Option struct {
 	Rate  rate.Limit
 	Burst int
@@ -101,6 +123,39 @@ func (l *visitLimiter) Allow(entity string, n int) bool {
 	return v.limiter.AllowN(v.lastSeen, n)
 }
 
+// Status returns the rate limit budget of the entity without consuming any token.
+func (l *visitLimiter) Status(entity string) Status {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	status := Status{Limit: l.Burst, Remaining: l.Burst}
+
+	v, ok := l.visitors[entity]
+	if !ok {
+		return status
+	}
+
+	// reserve the full burst to find out the missing tokens, and cancel at once
+	now := time.Now()
+	r := v.limiter.ReserveN(now, l.Burst)
+	if !r.OK() {
+		status.Remaining = 0
+		return status
+	}
+
+	status.Reset = r.DelayFrom(now)
+	r.CancelAt(now)
+
+	if l.Rate > 0 && status.Reset > 0 {
+		missing := int(math.Ceil(status.Reset.Seconds() * float64(l.Rate)))
+		if status.Remaining = l.Burst - missing; status.Remaining < 0 {
+			status.Remaining = 0
+		}
+	}
+
+	return status
+}
+
 func (l *visitLimiter) GC(timeout time.Duration) {
 	now := time.Now()
 
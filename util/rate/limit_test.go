@@ -0,0 +1,26 @@
+package rate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVisitLimiterStatus(t *testing.T) {
+	l := newVisitLimiter(1, 5)
+
+	// unvisited entity has full budget
+	assert.Equal(t, Status{Limit: 5, Remaining: 5}, l.Status("ip"))
+
+	assert.True(t, l.Allow("ip", 3))
+
+	status := l.Status("ip")
+	assert.Equal(t, 5, status.Limit)
+	assert.Equal(t, 2, status.Remaining)
+	assert.Equal(t, int64(3), status.ResetSeconds())
+
+	// status does not consume tokens
+	assert.True(t, l.Allow("ip", 2))
+	assert.False(t, l.Allow("ip", 1))
+	assert.Equal(t, 0, l.Status("ip").Remaining)
+}
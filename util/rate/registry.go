@@ -2,6 +2,7 @@ package rate
 
 import (
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
@@ -35,6 +36,8 @@ type Registry struct {
 	keyCache *util.ExpirableLruCache
 	// loader to retrieve keyset from store
 	keyLoader func(key string) (*KeyInfo, error)
+	// loader to retrieve rate limit config from store
+	configLoader func() *Config
 
 	// default IP limiter set
 	defaultLimiterSet *IpLimiterSet
@@ -54,6 +57,42 @@ func NewRegistry() *Registry {
 	}
 }
 
+// Strategies returns all the available rate limit strategies ordered by ID.
+func (m *Registry) Strategies() []*Strategy {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	strategies := make([]*Strategy, 0, len(m.strategies))
+	for _, s := range m.strategies {
+		strategies = append(strategies, s)
+	}
+
+	sort.Slice(strategies, func(i, j int) bool {
+		return strategies[i].ID < strategies[j].ID
+	})
+
+	return strategies
+}
+
+// Strategy returns the rate limit strategy of the specified ID if any.
+func (m *Registry) Strategy(sid uint32) (*Strategy, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.strategies[sid]
+	return s, ok
+}
+
+// KeyStrategy returns the rate limit strategy bound to the specified limit key if any.
+func (m *Registry) KeyStrategy(key string) (*Strategy, bool) {
+	ki, ok := m.loadKeyInfo(key)
+	if !ok || ki == nil {
+		return nil, false
+	}
+
+	return m.Strategy(ki.SID)
+}
+
 func (m *Registry) Get(vc *VisitContext) (Limiter, bool) {
 	if len(vc.Key) == 0 { // no limit key provided?
 		logrus.WithField("visitContext", vc).
@@ -176,6 +215,26 @@ func (m *Registry) loadKeyInfo(key string) (*KeyInfo, bool) {
 	return ki, true
 }
 
+// SetKeyCacheTTL resets the limit key cache with the specified expiration TTL, which
+// should be called before serving.
+func (m *Registry) SetKeyCacheTTL(ttl time.Duration) {
+	m.keyCache = util.NewExpirableLruCache(LimitKeyCacheSize, ttl)
+}
+
+// Revoke evicts the specified limit keys from cache, so that the revoked keys stop
+// working immediately rather than at cache expiry.
+func (m *Registry) Revoke(keys ...string) int {
+	var revoked int
+
+	for _, key := range keys {
+		if m.keyCache.Remove(key) {
+			revoked++
+		}
+	}
+
+	return revoked
+}
+
 // Strategy Management
 
 func (m *Registry) removeStrategy(s *Strategy) {
@@ -3,6 +3,7 @@ package rate
 import (
 	"time"
 
+	"github.com/scroll-tech/rpc-gateway/util/reload"
 	"github.com/sirupsen/logrus"
 )
 
@@ -32,6 +33,11 @@ func (m *Registry) AutoReload(interval time.Duration, reloader func() *Config, k
 	// init registry key loader
 	m.initKeyLoader(kloader)
 
+	// init registry config loader, and reload strategies immediately once
+	// configurations reloaded
+	m.initConfigLoader(reloader)
+	reload.Register("ratelimit", m.Reload)
+
 	// warm up limit key cache for better performance
 	m.warmUpKeyCache(kloader)
 
@@ -48,6 +54,19 @@ func (m *Registry) AutoReload(interval time.Duration, reloader func() *Config, k
 	}
 }
 
+// Reload reloads rate limit strategies immediately.
+func (m *Registry) Reload() error {
+	m.mu.Lock()
+	loader := m.configLoader
+	m.mu.Unlock()
+
+	if loader != nil {
+		m.reloadOnce(loader())
+	}
+
+	return nil
+}
+
 func (m *Registry) reloadOnce(rconf *Config) {
 	if rconf == nil {
 		return
@@ -99,6 +118,13 @@ func (m *Registry) initKeyLoader(kloader KeysetLoader) {
 	}
 }
 
+func (m *Registry) initConfigLoader(reloader func() *Config) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.configLoader = reloader
+}
+
 func (m *Registry) warmUpKeyCache(kloader KeysetLoader) {
 	kis, err := kloader(&KeysetFilter{Limit: (LimitKeyCacheSize * 3 / 4)})
 	if err != nil {
@@ -0,0 +1,67 @@
+package rate
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/sirupsen/logrus"
+)
+
+// RevocationConfig configurations of limit key cache and revocation push.
+type RevocationConfig struct {
+	// expiration TTL of cached limit keys, which could be long if revocation pushed
+	KeyCacheTTL time.Duration `default:"75s"`
+	// redis to subscribe revoked limit keys from, empty means disabled
+	RedisUrl string
+	// redis pub/sub channel, where message payload is comma separated limit keys
+	Channel string `default:"confura:ratelimit:revoked"`
+}
+
+// SubscribeRevocation subscribes revoked limit keys from redis pub/sub channel and evicts
+// them from the registries, until context done.
+func SubscribeRevocation(ctx context.Context, client *redis.Client, channel string, registries ...*Registry) {
+	pubsub := client.Subscribe(ctx, channel)
+	defer pubsub.Close()
+
+	logger := logrus.WithField("channel", channel)
+	logger.Info("Limit key revocation subscribed")
+
+	// channel is closed once pubsub closed, and reconnected automatically on network error
+	ch := pubsub.Channel()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-ch:
+			if !ok {
+				return
+			}
+
+			keys := parseRevokedKeys(msg.Payload)
+
+			var revoked int
+			for _, r := range registries {
+				revoked += r.Revoke(keys...)
+			}
+
+			logger.WithFields(logrus.Fields{
+				"keys": len(keys), "revoked": revoked,
+			}).Info("Limit keys revoked")
+		}
+	}
+}
+
+func parseRevokedKeys(payload string) []string {
+	var keys []string
+
+	for _, key := range strings.Split(payload, ",") {
+		if key = strings.TrimSpace(key); len(key) > 0 {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys
+}
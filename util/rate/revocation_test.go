@@ -0,0 +1,143 @@
+package rate
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+)
+
+// newPubSubServer creates a fake redis server, which acknowledges the subscription and
+// then publishes the specified payloads to the subscribed channel.
+func newPubSubServer(t *testing.T, payloads ...string) net.Listener {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		// SUBSCRIBE <channel> as RESP array of 2 bulk strings, namely 5 lines
+		r := bufio.NewReader(conn)
+		var args []string
+		for i := 0; i < 5; i++ {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+
+			args = append(args, line[:len(line)-2])
+		}
+
+		channel := args[4]
+		fmt.Fprintf(conn, "*3\r\n$9\r\nsubscribe\r\n$%d\r\n%v\r\n:1\r\n", len(channel), channel)
+
+		for _, payload := range payloads {
+			fmt.Fprintf(conn, "*3\r\n$7\r\nmessage\r\n$%d\r\n%v\r\n$%d\r\n%v\r\n",
+				len(channel), channel, len(payload), payload)
+		}
+
+		// hold until client closed
+		r.ReadString('\n')
+	}()
+
+	return listener
+}
+
+func TestParseRevokedKeys(t *testing.T) {
+	tests := []struct {
+		payload string
+		keys    []string
+	}{
+		{"", nil},
+		{" , ,", nil},
+		{"key1", []string{"key1"}},
+		{"key1, key2,,key3 ", []string{"key1", "key2", "key3"}},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.keys, parseRevokedKeys(tt.payload))
+	}
+}
+
+func TestSubscribeRevocation(t *testing.T) {
+	cached := func(r *Registry, keys ...string) []string {
+		var result []string
+		for _, key := range keys {
+			if _, ok := r.keyCache.Get(key); ok {
+				result = append(result, key)
+			}
+		}
+
+		return result
+	}
+
+	tests := []struct {
+		payloads []string // nil means redis unreachable
+		remained []string
+	}{
+		{[]string{"key1", "key2, unknown"}, []string{"key3"}},
+		{[]string{""}, []string{"key1", "key2", "key3"}},
+		// cached keys still work if revocation not pushed
+		{nil, []string{"key1", "key2", "key3"}},
+	}
+
+	for _, tt := range tests {
+		r1, r2 := NewRegistry(), NewRegistry()
+		r1.keyCache.Add("key1", &KeyInfo{})
+		r1.keyCache.Add("key2", &KeyInfo{})
+		r2.keyCache.Add("key3", &KeyInfo{})
+
+		addr := "127.0.0.1:1"
+		if tt.payloads != nil {
+			listener := newPubSubServer(t, tt.payloads...)
+			defer listener.Close()
+
+			addr = listener.Addr().String()
+		}
+
+		client := redis.NewClient(&redis.Options{Addr: addr, MaxRetries: -1})
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+
+		done := make(chan struct{})
+		go func() {
+			SubscribeRevocation(ctx, client, "revoked", r1, r2)
+			close(done)
+		}()
+
+		// returns once context done
+		<-done
+		cancel()
+		client.Close()
+
+		remained := append(cached(r1, "key1", "key2", "key3"), cached(r2, "key1", "key2", "key3")...)
+		assert.ElementsMatch(t, tt.remained, remained)
+	}
+}
+
+func TestRegistryRevoke(t *testing.T) {
+	r := NewRegistry()
+	r.SetKeyCacheTTL(50 * time.Millisecond)
+
+	r.keyCache.Add("key1", &KeyInfo{})
+	r.keyCache.Add("key2", &KeyInfo{})
+
+	assert.Equal(t, 1, r.Revoke("key1", "unknown"))
+	assert.Equal(t, 0, r.Revoke("key1"))
+
+	_, ok := r.keyCache.Get("key2")
+	assert.True(t, ok)
+
+	// expired at cache TTL if not revoked
+	time.Sleep(100 * time.Millisecond)
+	_, ok = r.keyCache.Get("key2")
+	assert.False(t, ok)
+}
@@ -0,0 +1,111 @@
+package rate
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/pkg/errors"
+)
+
+// sharedTokenBucketScript refills and takes tokens atomically based on Redis server time, so
+// that the bucket is consistent across gateway replicas regardless of clock skew.
+//
+// KEYS[1]: bucket key
+// ARGV: rate (tokens per second), burst, requested tokens
+// Returns: granted tokens, and milliseconds to wait for the rest
+var sharedTokenBucketScript = redis.NewScript(`
+redis.replicate_commands()
+
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local requested = tonumber(ARGV[3])
+
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1]) or burst
+local ts = tonumber(state[2]) or now
+
+tokens = math.min(burst, tokens + math.max(0, now - ts) * rate / 1000)
+
+local granted = math.min(requested, math.floor(tokens))
+tokens = tokens - granted
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', now)
+redis.call('PEXPIRE', KEYS[1], math.ceil(burst * 1000 / rate) + 1000)
+
+local wait = 0
+if granted < requested then
+	wait = math.ceil((requested - granted - tokens) * 1000 / rate)
+end
+
+return {granted, wait}
+`)
+
+// SharedTokenBucket is a token bucket shared by multiple processes via Redis, e.g. to keep all
+// gateway replicas collectively under the global rate limit of upstream provider.
+type SharedTokenBucket struct {
+	client   *redis.Client
+	key      string
+	rate     float64
+	burst    int
+	prefetch int
+
+	mu    sync.Mutex
+	local int // tokens prefetched locally
+}
+
+// NewSharedTokenBucket creates a shared token bucket. Tokens could be prefetched in batch to
+// reduce round trips to Redis, at the cost of fairness among replicas.
+func NewSharedTokenBucket(client *redis.Client, key string, rate float64, burst, prefetch int) *SharedTokenBucket {
+	if prefetch <= 0 {
+		prefetch = 1
+	}
+
+	if prefetch > burst {
+		prefetch = burst
+	}
+
+	return &SharedTokenBucket{
+		client:   client,
+		key:      key,
+		rate:     rate,
+		burst:    burst,
+		prefetch: prefetch,
+	}
+}
+
+// Take takes a token, and returns the duration to wait for the next token if not available.
+func (b *SharedTokenBucket) Take(ctx context.Context) (bool, time.Duration, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.local > 0 {
+		b.local--
+		return true, 0, nil
+	}
+
+	result, err := sharedTokenBucketScript.Run(ctx, b.client, []string{b.key}, b.rate, b.burst, b.prefetch).Result()
+	if err != nil {
+		return false, 0, errors.WithMessage(err, "failed to take tokens from shared bucket")
+	}
+
+	values, ok := result.([]interface{})
+	if !ok || len(values) != 2 {
+		return false, 0, errors.Errorf("invalid shared bucket result %v", result)
+	}
+
+	granted, _ := values[0].(int64)
+	waitMs, _ := values[1].(int64)
+
+	if granted <= 0 {
+		return false, time.Duration(waitMs) * time.Millisecond, nil
+	}
+
+	b.local = int(granted) - 1
+
+	return true, 0, nil
+}
@@ -175,6 +175,14 @@ func (ls *keyBasedIpLimiter) Allow(vc *VisitContext, n int) bool {
 	return l.Allow(vc, n)
 }
 
+func (ls *keyBasedIpLimiter) Status(vc *VisitContext) Status {
+	if l, ok := ls.limiters[vc.Key]; ok {
+		return l.Status(vc)
+	}
+
+	return Status{Limit: ls.Burst, Remaining: ls.Burst}
+}
+
 func (ls *keyBasedIpLimiter) GC(timeout time.Duration) {
 	for _, l := range ls.limiters {
 		l.GC(timeout)
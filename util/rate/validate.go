@@ -0,0 +1,56 @@
+package rate
+
+import (
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// ValidateStrategies checks rate limit strategies for consistency, and returns an error for
+// each inconsistent strategy with actionable message.
+func ValidateStrategies(strategies map[uint32]*Strategy) []error {
+	var sids []uint32
+	for sid := range strategies {
+		sids = append(sids, sid)
+	}
+
+	// deterministic order regardless of map iteration
+	sort.Slice(sids, func(i, j int) bool { return sids[i] < sids[j] })
+
+	var errs []error
+	names := make(map[string]uint32)
+
+	for _, sid := range sids {
+		s := strategies[sid]
+
+		if prev, ok := names[s.Name]; ok {
+			errs = append(errs, errors.Errorf(
+				"strategy %v (#%v) has the same name as strategy #%v, please rename either", s.Name, sid, prev,
+			))
+		} else {
+			names[s.Name] = sid
+		}
+
+		if len(s.Rules) == 0 {
+			errs = append(errs, errors.Errorf("strategy %v (#%v) has no limit rule", s.Name, sid))
+		}
+
+		var rules []string
+		for name := range s.Rules {
+			rules = append(rules, name)
+		}
+
+		sort.Strings(rules)
+
+		for _, name := range rules {
+			if opt := s.Rules[name]; opt.Rate <= 0 || opt.Burst <= 0 {
+				errs = append(errs, errors.Errorf(
+					"rule %v of strategy %v (#%v) should have positive rate and burst, but got %v and %v",
+					name, s.Name, sid, opt.Rate, opt.Burst,
+				))
+			}
+		}
+	}
+
+	return errs
+}
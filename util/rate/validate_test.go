@@ -0,0 +1,19 @@
+package rate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateStrategies(t *testing.T) {
+	strategies := map[uint32]*Strategy{
+		1: {ID: 1, Name: "free", Rules: map[string]Option{"default": NewOption(10, 20)}},
+		2: {ID: 2, Name: "vip", Rules: map[string]Option{"default": NewOption(100, 200)}},
+	}
+	assert.Empty(t, ValidateStrategies(strategies))
+
+	strategies[3] = &Strategy{ID: 3, Name: "vip", Rules: map[string]Option{"eth_getLogs": NewOption(0, 10)}}
+	strategies[4] = &Strategy{ID: 4, Name: "empty"}
+	assert.Len(t, ValidateStrategies(strategies), 3)
+}
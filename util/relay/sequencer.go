@@ -0,0 +1,108 @@
+package relay
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/openweb3/go-rpc-provider/utils"
+	"github.com/openweb3/web3go"
+	"github.com/pkg/errors"
+	"github.com/scroll-tech/rpc-gateway/util/rpc"
+	"github.com/sirupsen/logrus"
+)
+
+// defaultSequencerFailoverCooldown is used if failover cooldown not configured.
+const defaultSequencerFailoverCooldown = 30 * time.Second
+
+type SequencerConfig struct {
+	// sequencer endpoints in priority order
+	Urls []string
+	// duration to deprioritize failed sequencer
+	FailoverCooldown time.Duration `default:"30s"`
+}
+
+// SequencerRouter sends raw transactions directly to rollup sequencer(s) with failover,
+// since only the sequencer accepts transactions in rollup topologies.
+type SequencerRouter struct {
+	sequencers []*sequencer
+	config     *SequencerConfig
+}
+
+type sequencer struct {
+	url      string
+	client   *web3go.Client
+	failedAt int64 // unix nano time of the last failure
+}
+
+func MustNewSequencerRouterFromViper() *SequencerRouter {
+	var conf SequencerConfig
+	viper.MustUnmarshalKey("ethrpc.sequencer", &conf)
+
+	return MustNewSequencerRouter(&conf)
+}
+
+func MustNewSequencerRouter(conf *SequencerConfig) *SequencerRouter {
+	if conf.FailoverCooldown == 0 {
+		conf.FailoverCooldown = defaultSequencerFailoverCooldown
+	}
+
+	router := &SequencerRouter{config: conf}
+
+	for _, url := range conf.Urls {
+		client, err := rpc.NewEthClient(url, rpc.WithClientHookMetrics(true))
+		if err != nil {
+			logrus.WithField("url", url).WithError(err).Fatal("Failed to create sequencer client")
+		}
+
+		router.sequencers = append(router.sequencers, &sequencer{url: url, client: client})
+	}
+
+	return router
+}
+
+// Enabled returns whether any sequencer configured.
+func (r *SequencerRouter) Enabled() bool {
+	return r != nil && len(r.sequencers) > 0
+}
+
+// SendRawTransaction sends raw transaction to sequencers in priority order, and fails over
+// to the next one on non RPC error. Sequencers failed recently are tried as last resort.
+// Returns the url of sequencer that accepts the transaction as well.
+func (r *SequencerRouter) SendRawTransaction(signedTx hexutil.Bytes) (common.Hash, string, error) {
+	var lastErr error
+
+	for _, s := range r.candidates() {
+		txHash, err := s.client.Eth.SendRawTransaction(signedTx)
+		if err == nil || utils.IsRPCJSONError(err) {
+			// RPC error, e.g. nonce too low, should be returned to user directly
+			return txHash, s.url, err
+		}
+
+		atomic.StoreInt64(&s.failedAt, time.Now().UnixNano())
+
+		logrus.WithField("url", s.url).WithError(err).Warn("Failed to send raw transaction to sequencer")
+		lastErr = err
+	}
+
+	return common.Hash{}, "", errors.WithMessage(lastErr, "all sequencers unavailable")
+}
+
+// candidates returns sequencers in priority order, with recently failed ones moved back.
+func (r *SequencerRouter) candidates() []*sequencer {
+	var healthy, failed []*sequencer
+
+	cooldown := time.Now().Add(-r.config.FailoverCooldown).UnixNano()
+
+	for _, s := range r.sequencers {
+		if atomic.LoadInt64(&s.failedAt) > cooldown {
+			failed = append(failed, s)
+		} else {
+			healthy = append(healthy, s)
+		}
+	}
+
+	return append(healthy, failed...)
+}
@@ -0,0 +1,94 @@
+package relay
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/assert"
+)
+
+// newSequencerServer creates a fake sequencer that accepts raw transactions with the specified
+// transaction hash, or rejects them with RPC error.
+func newSequencerServer(txHash common.Hash, rpcErr string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct{ ID json.RawMessage }
+		json.NewDecoder(r.Body).Decode(&req)
+
+		resp := map[string]interface{}{"jsonrpc": "2.0", "id": req.ID}
+		if len(rpcErr) > 0 {
+			resp["error"] = map[string]interface{}{"code": -32000, "message": rpcErr}
+		} else {
+			resp["result"] = txHash
+		}
+
+		json.NewEncoder(w).Encode(resp)
+	}))
+}
+
+func TestSequencerRouter(t *testing.T) {
+	assert.False(t, (*SequencerRouter)(nil).Enabled())
+	assert.False(t, MustNewSequencerRouter(&SequencerConfig{}).Enabled())
+
+	accepted := newSequencerServer(common.HexToHash("0x01"), "")
+	defer accepted.Close()
+
+	backup := newSequencerServer(common.HexToHash("0x02"), "")
+	defer backup.Close()
+
+	rejected := newSequencerServer(common.Hash{}, "nonce too low")
+	defer rejected.Close()
+
+	unavailable := newSequencerServer(common.Hash{}, "")
+	unavailable.Close()
+
+	tests := []struct {
+		urls   []string
+		sends  int    // number of transactions sent
+		txHash string // empty means failed
+		url    string
+		err    string
+	}{
+		{[]string{accepted.URL, backup.URL}, 1, "0x01", accepted.URL, ""},
+		// failover to the next sequencer on non RPC error
+		{[]string{unavailable.URL, backup.URL}, 1, "0x02", backup.URL, ""},
+		// recently failed sequencer is deprioritized
+		{[]string{unavailable.URL, backup.URL}, 2, "0x02", backup.URL, ""},
+		// RPC error is returned directly without failover
+		{[]string{rejected.URL, backup.URL}, 1, "", rejected.URL, "nonce too low"},
+		// all sequencers unavailable
+		{[]string{unavailable.URL}, 1, "", "", "all sequencers unavailable"},
+	}
+
+	for _, tt := range tests {
+		router := MustNewSequencerRouter(&SequencerConfig{Urls: tt.urls, FailoverCooldown: time.Minute})
+		assert.True(t, router.Enabled())
+
+		var txHash common.Hash
+		var url string
+		var err error
+
+		for i := 0; i < tt.sends; i++ {
+			txHash, url, err = router.SendRawTransaction(hexutil.Bytes{0x01})
+		}
+
+		assert.Equal(t, tt.url, url)
+
+		if len(tt.err) > 0 {
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), tt.err)
+		} else {
+			assert.NoError(t, err)
+			assert.Equal(t, common.HexToHash(tt.txHash), txHash)
+		}
+
+		// the failed sequencer is tried as last resort
+		if tt.url == backup.URL {
+			assert.Equal(t, backup.URL, router.candidates()[0].url)
+		}
+	}
+}
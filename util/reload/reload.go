@@ -0,0 +1,144 @@
+package reload
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	viperutil "github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+	"go.uber.org/multierr"
+)
+
+// Handler is used to apply the latest configurations at runtime after config reloaded.
+type Handler func() error
+
+type namedHandler struct {
+	name    string
+	handler Handler
+}
+
+var (
+	handlers []namedHandler
+	mu       sync.Mutex // to guard handlers registration
+
+	reloadMu sync.Mutex // to serialize reloads, since viper is not goroutine-safe
+)
+
+// Register registers a reload handler with friendly name, which will be fired in
+// registration order once the configurations reloaded.
+func Register(name string, handler Handler) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	handlers = append(handlers, namedHandler{name, handler})
+
+	logrus.WithField("name", name).Debug("Config reload handler registered")
+}
+
+// Reload re-reads the config file and then fires all the registered reload handlers.
+// Note, handlers are fired even if any other handler failed, and without registration
+// lock held so that handlers could register others.
+func Reload() error {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+
+	if len(viper.ConfigFileUsed()) > 0 {
+		if err := viper.ReadInConfig(); err != nil {
+			return errors.WithMessage(err, "failed to read config file")
+		}
+	}
+
+	mu.Lock()
+	registered := make([]namedHandler, len(handlers))
+	copy(registered, handlers)
+	mu.Unlock()
+
+	var errs error
+	for _, h := range registered {
+		if err := h.handler(); err != nil {
+			errs = multierr.Append(errs, errors.WithMessagef(err, "failed to reload %v", h.name))
+			continue
+		}
+
+		logrus.WithField("name", h.name).Debug("Config reloaded")
+	}
+
+	return errs
+}
+
+// WatchFromViper watches configurations changes with settings loaded from viper.
+func WatchFromViper(ctx context.Context) {
+	var config struct {
+		// interval to check config file changes, with 0 means never
+		Interval time.Duration `default:"10s"`
+	}
+	viperutil.MustUnmarshalKey("reload", &config)
+
+	Watch(ctx, config.Interval)
+}
+
+// Watch reloads configurations once SIGHUP signal captured or the config file changed,
+// and blocks until the specified context done. File change is detected by polling the
+// modification time every `interval`, with 0 means never.
+func Watch(ctx context.Context, interval time.Duration) {
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGHUP)
+	defer signal.Stop(sigChan)
+
+	var tickChan <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		tickChan = ticker.C
+	}
+
+	lastModTime := configModTime()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-sigChan:
+			logrus.Info("SIGHUP received, reloading configurations")
+			reloadAndLog()
+		case <-tickChan:
+			if modTime := configModTime(); modTime.After(lastModTime) {
+				logrus.WithField("modTime", modTime).Info("Config file changed, reloading configurations")
+				lastModTime = modTime
+				reloadAndLog()
+			}
+		}
+	}
+}
+
+func reloadAndLog() {
+	if err := Reload(); err != nil {
+		logrus.WithError(err).Error("Failed to reload configurations")
+		return
+	}
+
+	logrus.Info("Configurations reloaded")
+}
+
+// configModTime returns the modification time of the config file in use if any.
+func configModTime() time.Time {
+	file := viper.ConfigFileUsed()
+	if len(file) == 0 {
+		return time.Time{}
+	}
+
+	info, err := os.Stat(file)
+	if err != nil {
+		logrus.WithError(err).WithField("file", file).Debug("Failed to stat config file")
+		return time.Time{}
+	}
+
+	return info.ModTime()
+}
@@ -0,0 +1,26 @@
+package reload
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReloadRegisterInHandler(t *testing.T) {
+	var fired []string
+
+	Register("outer", func() error {
+		fired = append(fired, "outer")
+
+		// registered without deadlock, and fired on the next reload
+		Register("inner", func() error {
+			fired = append(fired, "inner")
+			return nil
+		})
+
+		return nil
+	})
+
+	assert.NoError(t, Reload())
+	assert.Equal(t, []string{"outer"}, fired)
+}
@@ -0,0 +1,178 @@
+package rollup
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/openweb3/web3go"
+	"github.com/openweb3/web3go/types"
+	"github.com/pkg/errors"
+	"github.com/scroll-tech/rpc-gateway/util/rpc"
+)
+
+// BridgeConfig configurations of Scroll bridge to query status of messages between L1 and L2.
+type BridgeConfig struct {
+	Enabled bool
+	// L1 RPC URL
+	L1URL string
+	// addresses of `L1ScrollMessenger` and `L2ScrollMessenger` contracts
+	L1Messenger string
+	L2Messenger string
+}
+
+var (
+	// SentMessage(address indexed sender, address indexed target, uint256 value, uint256 messageNonce,
+	// uint256 gasLimit, bytes message)
+	sentMessageTopic = crypto.Keccak256Hash([]byte("SentMessage(address,address,uint256,uint256,uint256,bytes)"))
+
+	isL1MessageExecutedSelector = crypto.Keccak256([]byte("isL1MessageExecuted(bytes32)"))[:4]
+	isL2MessageExecutedSelector = crypto.Keccak256([]byte("isL2MessageExecuted(bytes32)"))[:4]
+	relayMessageSelector        = crypto.Keccak256([]byte("relayMessage(address,address,uint256,uint256,bytes)"))[:4]
+
+	sentMessageArgs  = mustNewArguments("uint256", "uint256", "uint256", "bytes")
+	relayMessageArgs = mustNewArguments("address", "address", "uint256", "uint256", "bytes")
+)
+
+func mustNewArguments(typeNames ...string) abi.Arguments {
+	var args abi.Arguments
+
+	for _, t := range typeNames {
+		typ, err := abi.NewType(t, "", nil)
+		if err != nil {
+			panic(err)
+		}
+
+		args = append(args, abi.Argument{Type: typ})
+	}
+
+	return args
+}
+
+// Message is the cross layer message sent by messenger contract.
+type Message struct {
+	Hash    common.Hash    `json:"hash"`
+	Sender  common.Address `json:"sender"`
+	Target  common.Address `json:"target"`
+	Value   *hexutil.Big   `json:"value"`
+	Nonce   *hexutil.Big   `json:"nonce"`
+	Message hexutil.Bytes  `json:"message"`
+}
+
+// ParseSentMessages parses messages from `SentMessage` event logs of messenger contract.
+func ParseSentMessages(logs []*types.Log, messenger common.Address) ([]*Message, error) {
+	var messages []*Message
+
+	for _, log := range logs {
+		if log == nil || log.Address != messenger || len(log.Topics) != 3 || log.Topics[0] != sentMessageTopic {
+			continue
+		}
+
+		values, err := sentMessageArgs.Unpack(log.Data)
+		if err != nil {
+			return nil, errors.WithMessage(err, "failed to unpack SentMessage event")
+		}
+
+		msg := Message{
+			Sender:  common.BytesToAddress(log.Topics[1].Bytes()),
+			Target:  common.BytesToAddress(log.Topics[2].Bytes()),
+			Value:   (*hexutil.Big)(values[0].(*big.Int)),
+			Nonce:   (*hexutil.Big)(values[1].(*big.Int)),
+			Message: values[3].([]byte),
+		}
+
+		if msg.Hash, err = messageHash(&msg); err != nil {
+			return nil, err
+		}
+
+		messages = append(messages, &msg)
+	}
+
+	return messages, nil
+}
+
+// messageHash computes the message hash as messenger contract does, which is the hash of
+// `relayMessage` calldata.
+func messageHash(msg *Message) (common.Hash, error) {
+	data, err := relayMessageArgs.Pack(
+		msg.Sender, msg.Target, msg.Value.ToInt(), msg.Nonce.ToInt(), []byte(msg.Message),
+	)
+	if err != nil {
+		return common.Hash{}, errors.WithMessage(err, "failed to pack relayMessage")
+	}
+
+	return crypto.Keccak256Hash(relayMessageSelector, data), nil
+}
+
+// Bridge queries status of messages between L1 and L2.
+type Bridge struct {
+	l1          *web3go.Client
+	l1Messenger common.Address
+	l2Messenger common.Address
+}
+
+func NewBridge(conf BridgeConfig) (*Bridge, error) {
+	if !common.IsHexAddress(conf.L1Messenger) || !common.IsHexAddress(conf.L2Messenger) {
+		return nil, errors.New("invalid messenger address")
+	}
+
+	l1, err := rpc.NewEthClient(conf.L1URL)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to create L1 client")
+	}
+
+	return &Bridge{
+		l1:          l1,
+		l1Messenger: common.HexToAddress(conf.L1Messenger),
+		l2Messenger: common.HexToAddress(conf.L2Messenger),
+	}, nil
+}
+
+// L2Messenger returns the address of `L2ScrollMessenger` contract.
+func (b *Bridge) L2Messenger() common.Address {
+	return b.l2Messenger
+}
+
+// L1Messages returns messages sent from L1 by transaction, or false if transaction not mined.
+func (b *Bridge) L1Messages(txHash common.Hash) ([]*Message, bool, error) {
+	receipt, err := b.l1.Eth.TransactionReceipt(txHash)
+	if err != nil {
+		return nil, false, errors.WithMessage(err, "failed to get L1 transaction receipt")
+	}
+
+	if receipt == nil {
+		return nil, false, nil
+	}
+
+	messages, err := ParseSentMessages(receipt.Logs, b.l1Messenger)
+
+	return messages, true, err
+}
+
+// IsL2MessageExecuted checks if L2 message is claimed on L1.
+func (b *Bridge) IsL2MessageExecuted(msgHash common.Hash) (bool, error) {
+	return isMessageExecuted(b.l1, b.l1Messenger, isL2MessageExecutedSelector, msgHash)
+}
+
+// IsL1MessageExecuted checks if L1 message is relayed on L2 by the specified L2 client.
+func (b *Bridge) IsL1MessageExecuted(l2 *web3go.Client, msgHash common.Hash) (bool, error) {
+	return isMessageExecuted(l2, b.l2Messenger, isL1MessageExecutedSelector, msgHash)
+}
+
+func isMessageExecuted(
+	client *web3go.Client, messenger common.Address, selector []byte, msgHash common.Hash,
+) (bool, error) {
+	request := types.CallRequest{
+		To:   &messenger,
+		Data: append(append([]byte{}, selector...), msgHash.Bytes()...),
+	}
+
+	result, err := client.Eth.Call(request, nil)
+	if err != nil {
+		return false, err
+	}
+
+	return new(big.Int).SetBytes(result).Sign() > 0, nil
+}
@@ -0,0 +1,45 @@
+package rollup
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/openweb3/web3go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSentMessages(t *testing.T) {
+	messenger := common.HexToAddress("0x781e90f1c8Fc4611c9b7497C3B47F99Ef6969CbC")
+	sender := common.HexToAddress("0x1")
+	target := common.HexToAddress("0x2")
+
+	data, err := sentMessageArgs.Pack(big.NewInt(100), big.NewInt(7), big.NewInt(200000), []byte{0xab})
+	assert.Nil(t, err)
+
+	topics := []common.Hash{sentMessageTopic, common.BytesToHash(sender.Bytes()), common.BytesToHash(target.Bytes())}
+
+	logs := []*types.Log{
+		{Address: messenger, Topics: topics, Data: data},
+		// emitted by other contract
+		{Address: common.HexToAddress("0x3"), Topics: topics, Data: data},
+	}
+
+	messages, err := ParseSentMessages(logs, messenger)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(messages))
+
+	msg := messages[0]
+	assert.Equal(t, sender, msg.Sender)
+	assert.Equal(t, target, msg.Target)
+	assert.Equal(t, int64(100), msg.Value.ToInt().Int64())
+	assert.Equal(t, int64(7), msg.Nonce.ToInt().Int64())
+	assert.Equal(t, []byte{0xab}, []byte(msg.Message))
+	assert.NotEqual(t, common.Hash{}, msg.Hash)
+
+	// message hash depends on nonce
+	msg.Nonce.ToInt().SetInt64(8)
+	hash, err := messageHash(msg)
+	assert.Nil(t, err)
+	assert.NotEqual(t, msg.Hash, hash)
+}
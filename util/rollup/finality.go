@@ -0,0 +1,144 @@
+// Package rollup provides Scroll rollup specific data, e.g. L1 batch commitment and
+// finalization.
+package rollup
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// FinalityConfig configurations to track L1 batch finalization from rollup API (e.g. Scroll
+// rollupscan), so that `safe` and `finalized` block tags could be resolved by gateway.
+type FinalityConfig struct {
+	Enabled bool
+	// rollup API base URL, e.g. https://mainnet-api-re.scroll.io
+	URL string
+	// interval to poll the latest committed and finalized batches
+	Interval time.Duration `default:"10s"`
+	Timeout  time.Duration `default:"5s"`
+}
+
+// Finality is the L2 block heights of the latest batches committed and finalized on L1.
+type Finality struct {
+	// end block of the latest batch committed on L1
+	Safe uint64
+	// end block of the latest batch finalized on L1
+	Finalized uint64
+	// updated time
+	UpdatedAt time.Time
+}
+
+// FinalityTracker polls rollup API to track L1 batch finalization.
+type FinalityTracker struct {
+	conf     FinalityConfig
+	client   *http.Client
+	finality atomic.Value // Finality
+}
+
+func NewFinalityTracker(conf FinalityConfig) *FinalityTracker {
+	return &FinalityTracker{
+		conf:   conf,
+		client: &http.Client{Timeout: conf.Timeout},
+	}
+}
+
+// Finality returns the latest finality if any.
+func (t *FinalityTracker) Finality() (Finality, bool) {
+	v, ok := t.finality.Load().(Finality)
+	return v, ok
+}
+
+// Run polls rollup API until context done.
+func (t *FinalityTracker) Run(ctx context.Context) {
+	ticker := time.NewTicker(t.conf.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := t.update(); err != nil {
+			logrus.WithError(err).Info("Failed to update L1 batch finality")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (t *FinalityTracker) update() error {
+	var indexes struct {
+		Committed uint64 `json:"committed_index"`
+		Finalized uint64 `json:"finalized_index"`
+	}
+
+	if err := t.get("/api/last_batch_indexes", &indexes); err != nil {
+		return errors.WithMessage(err, "failed to get the latest batch indexes")
+	}
+
+	prev, _ := t.Finality()
+	finality := Finality{UpdatedAt: time.Now()}
+
+	var err error
+	if finality.Safe, err = t.batchEndBlock(indexes.Committed); err != nil {
+		return err
+	}
+
+	if finality.Finalized, err = t.batchEndBlock(indexes.Finalized); err != nil {
+		return err
+	}
+
+	// never go backwards, e.g. rollup API behind a load balancer
+	if finality.Safe < prev.Safe || finality.Finalized < prev.Finalized {
+		return errors.Errorf("finality went backwards from %+v to %+v", prev, finality)
+	}
+
+	t.finality.Store(finality)
+
+	return nil
+}
+
+// batchEndBlock returns the end block number of batch.
+func (t *FinalityTracker) batchEndBlock(index uint64) (uint64, error) {
+	if index == 0 {
+		return 0, nil
+	}
+
+	var result struct {
+		Batch *struct {
+			EndBlockNumber uint64 `json:"end_block_number"`
+		} `json:"batch"`
+	}
+
+	if err := t.get(fmt.Sprintf("/api/batch?index=%v", index), &result); err != nil {
+		return 0, errors.WithMessagef(err, "failed to get batch %v", index)
+	}
+
+	if result.Batch == nil {
+		return 0, errors.Errorf("batch %v not found", index)
+	}
+
+	return result.Batch.EndBlockNumber, nil
+}
+
+func (t *FinalityTracker) get(path string, result interface{}) error {
+	resp, err := t.client.Get(strings.TrimSuffix(t.conf.URL, "/") + path)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("unexpected status code %v", resp.StatusCode)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(result)
+}
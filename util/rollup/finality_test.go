@@ -0,0 +1,45 @@
+package rollup
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFinalityTrackerUpdate(t *testing.T) {
+	finalized := 8
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/last_batch_indexes":
+			fmt.Fprintf(w, `{"all_index":12,"committed_index":10,"finalized_index":%v}`, finalized)
+		case "/api/batch":
+			fmt.Fprintf(w, `{"batch":{"index":%v,"end_block_number":%v0}}`, r.URL.Query().Get("index"), r.URL.Query().Get("index"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	tracker := NewFinalityTracker(FinalityConfig{URL: server.URL + "/"})
+
+	_, ok := tracker.Finality()
+	assert.False(t, ok)
+
+	assert.Nil(t, tracker.update())
+
+	finality, ok := tracker.Finality()
+	assert.True(t, ok)
+	assert.Equal(t, uint64(100), finality.Safe)
+	assert.Equal(t, uint64(80), finality.Finalized)
+
+	// never go backwards
+	finalized = 7
+	assert.NotNil(t, tracker.update())
+
+	finality, _ = tracker.Finality()
+	assert.Equal(t, uint64(80), finality.Finalized)
+}
@@ -0,0 +1,349 @@
+package rollup
+
+import (
+	"context"
+	"encoding/binary"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/openweb3/web3go"
+	"github.com/openweb3/web3go/types"
+	"github.com/pkg/errors"
+	"github.com/scroll-tech/rpc-gateway/util/rpc"
+	"github.com/sirupsen/logrus"
+)
+
+// status of L2 blocks in batches
+const (
+	BatchStatusPending   = "pending"   // not committed on L1 yet
+	BatchStatusCommitted = "committed" // batch committed on L1
+	BatchStatusFinalized = "finalized" // batch finalized on L1
+)
+
+// L1WatcherConfig configurations of L1 watcher, which watches batch events of Scroll rollup
+// contract on L1.
+type L1WatcherConfig struct {
+	Enabled bool
+	// L1 RPC URL
+	L1URL string
+	// address of `ScrollChain` contract
+	RollupContract string
+	// L1 block to start watching from, which should be before the earliest batch of interest
+	StartBlock uint64
+	// number of L1 blocks behind the latest one to watch, so as to avoid L1 reorg
+	Confirmations uint64 `default:"6"`
+	// interval to poll L1 events
+	Interval time.Duration `default:"12s"`
+	// max number of L1 blocks to query events at a time
+	RangeSize uint64 `default:"1000"`
+	// max number of batches in memory, beyond which the oldest ones are pruned
+	MaxBatches int `default:"100000"`
+}
+
+var (
+	// CommitBatch(uint256 indexed batchIndex, bytes32 indexed batchHash)
+	commitBatchTopic = crypto.Keccak256Hash([]byte("CommitBatch(uint256,bytes32)"))
+	// FinalizeBatch(uint256 indexed batchIndex, bytes32 indexed batchHash, bytes32 stateRoot, bytes32 withdrawRoot)
+	finalizeBatchTopic = crypto.Keccak256Hash([]byte("FinalizeBatch(uint256,bytes32,bytes32,bytes32)"))
+	// RevertBatch(uint256 indexed batchIndex, bytes32 indexed batchHash)
+	revertBatchTopic = crypto.Keccak256Hash([]byte("RevertBatch(uint256,bytes32)"))
+
+	// commit methods of which L2 blocks are available in chunks of calldata
+	commitBatchSelectors = map[string]bool{
+		string(crypto.Keccak256([]byte("commitBatch(uint8,bytes,bytes[],bytes)"))[:4]):                    true,
+		string(crypto.Keccak256([]byte("commitBatchWithBlobProof(uint8,bytes,bytes[],bytes,bytes)"))[:4]): true,
+	}
+
+	commitBatchArgs = mustNewArguments("uint8", "bytes", "bytes[]", "bytes")
+)
+
+// blockContextSize is the size of block context in chunk, which starts with 8 bytes block number.
+const blockContextSize = 60
+
+// Batch is the batch of L2 blocks committed on L1.
+type Batch struct {
+	Index hexutil.Uint64 `json:"index"`
+	Hash  common.Hash    `json:"hash"`
+	// L2 block range, which is unavailable if failed to decode from commit transaction
+	StartBlock *hexutil.Uint64 `json:"startBlock,omitempty"`
+	EndBlock   *hexutil.Uint64 `json:"endBlock,omitempty"`
+	// L1 transactions to commit and finalize batch
+	CommitTx   common.Hash  `json:"commitTx"`
+	FinalizeTx *common.Hash `json:"finalizeTx,omitempty"`
+	Status     string       `json:"status"`
+}
+
+// L1Watcher watches batch events of Scroll rollup contract on L1, so as to query the status of
+// batch that contains L2 block.
+type L1Watcher struct {
+	conf     L1WatcherConfig
+	client   *web3go.Client
+	contract common.Address
+
+	mu             sync.RWMutex
+	batches        []*Batch // in ascending order of batch index
+	finalizedIndex uint64   // index of the latest finalized batch
+	scanned        uint64   // the latest L1 block scanned
+}
+
+func NewL1Watcher(conf L1WatcherConfig) (*L1Watcher, error) {
+	if !common.IsHexAddress(conf.RollupContract) {
+		return nil, errors.New("invalid rollup contract address")
+	}
+
+	client, err := rpc.NewEthClient(conf.L1URL)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to create L1 client")
+	}
+
+	var scanned uint64
+	if conf.StartBlock > 0 {
+		scanned = conf.StartBlock - 1
+	}
+
+	return &L1Watcher{
+		conf:     conf,
+		client:   client,
+		contract: common.HexToAddress(conf.RollupContract),
+		scanned:  scanned,
+	}, nil
+}
+
+// Run polls L1 events until context done.
+func (w *L1Watcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.conf.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := w.poll(ctx); err != nil {
+			logrus.WithError(err).Info("L1 watcher failed to poll batch events")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll scans batch events until the latest confirmed L1 block.
+func (w *L1Watcher) poll(ctx context.Context) error {
+	latest, err := w.client.Eth.BlockNumber()
+	if err != nil {
+		return errors.WithMessage(err, "failed to get L1 block number")
+	}
+
+	if latest.Uint64() < w.conf.Confirmations {
+		return nil
+	}
+
+	confirmed := latest.Uint64() - w.conf.Confirmations
+
+	for w.scanned < confirmed && ctx.Err() == nil {
+		from, to := w.scanned+1, w.scanned+w.conf.RangeSize
+		if to > confirmed {
+			to = confirmed
+		}
+
+		if err := w.scan(ctx, from, to); err != nil {
+			return err
+		}
+
+		w.scanned = to
+	}
+
+	return nil
+}
+
+// scan handles batch events within the L1 block range.
+func (w *L1Watcher) scan(ctx context.Context, from, to uint64) error {
+	fromBlock, toBlock := types.BlockNumber(from), types.BlockNumber(to)
+
+	logs, err := w.client.Eth.Logs(types.FilterQuery{
+		FromBlock: &fromBlock,
+		ToBlock:   &toBlock,
+		Addresses: []common.Address{w.contract},
+		Topics:    [][]common.Hash{{commitBatchTopic, finalizeBatchTopic, revertBatchTopic}},
+	})
+	if err != nil {
+		return errors.WithMessagef(err, "failed to get L1 logs from %v to %v", from, to)
+	}
+
+	for i := range logs {
+		log := &logs[i]
+		if len(log.Topics) < 3 {
+			continue
+		}
+
+		index := log.Topics[1].Big().Uint64()
+
+		switch log.Topics[0] {
+		case commitBatchTopic:
+			batch := Batch{
+				Index:    hexutil.Uint64(index),
+				Hash:     log.Topics[2],
+				CommitTx: log.TxHash,
+				Status:   BatchStatusCommitted,
+			}
+
+			if err := w.decodeBlockRange(ctx, &batch); err != nil {
+				logrus.WithError(err).WithField("batch", index).Debug("L1 watcher failed to decode L2 blocks of batch")
+			}
+
+			w.commit(&batch)
+		case finalizeBatchTopic:
+			w.finalize(index, log.TxHash)
+		case revertBatchTopic:
+			w.revert(index)
+		}
+	}
+
+	return nil
+}
+
+// decodeBlockRange decodes the L2 block range from chunks in calldata of commit transaction.
+func (w *L1Watcher) decodeBlockRange(ctx context.Context, batch *Batch) error {
+	var tx struct {
+		Input hexutil.Bytes `json:"input"`
+	}
+
+	if err := w.client.Provider().CallContext(ctx, &tx, "eth_getTransactionByHash", batch.CommitTx); err != nil {
+		return err
+	}
+
+	start, end, err := decodeChunksBlockRange(tx.Input)
+	if err != nil {
+		return err
+	}
+
+	batch.StartBlock, batch.EndBlock = &start, &end
+
+	return nil
+}
+
+// decodeChunksBlockRange decodes the L2 block range from calldata of commit method.
+func decodeChunksBlockRange(input []byte) (hexutil.Uint64, hexutil.Uint64, error) {
+	if len(input) < 4 || !commitBatchSelectors[string(input[:4])] {
+		return 0, 0, errors.New("L2 blocks unavailable in calldata")
+	}
+
+	// chunks are the 3rd argument of all commit methods
+	values, err := commitBatchArgs.UnpackValues(input[4:])
+	if err != nil {
+		return 0, 0, errors.WithMessage(err, "failed to unpack calldata")
+	}
+
+	chunks := values[2].([][]byte)
+	if len(chunks) == 0 {
+		return 0, 0, errors.New("no chunk in batch")
+	}
+
+	first, last := chunks[0], chunks[len(chunks)-1]
+	if len(first) < 1+blockContextSize || len(last) == 0 || last[0] == 0 || len(last) < 1+int(last[0])*blockContextSize {
+		return 0, 0, errors.New("invalid chunk")
+	}
+
+	start := binary.BigEndian.Uint64(first[1:9])
+	offset := 1 + (int(last[0])-1)*blockContextSize
+	end := binary.BigEndian.Uint64(last[offset : offset+8])
+
+	return hexutil.Uint64(start), hexutil.Uint64(end), nil
+}
+
+func (w *L1Watcher) commit(batch *Batch) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if uint64(batch.Index) <= w.finalizedIndex {
+		batch.Status = BatchStatusFinalized
+	}
+
+	// batches are committed in order, except recommitted after reverted
+	n := len(w.batches)
+	if n > 0 && w.batches[n-1].Index >= batch.Index {
+		w.truncate(uint64(batch.Index))
+	}
+
+	w.batches = append(w.batches, batch)
+
+	if len(w.batches) > w.conf.MaxBatches {
+		w.batches = w.batches[len(w.batches)-w.conf.MaxBatches:]
+	}
+}
+
+// finalize finalizes batches up to the index, since multiple batches could be finalized at a time.
+func (w *L1Watcher) finalize(index uint64, txHash common.Hash) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if index > w.finalizedIndex {
+		w.finalizedIndex = index
+	}
+
+	for i := len(w.batches) - 1; i >= 0; i-- {
+		batch := w.batches[i]
+		if uint64(batch.Index) > index {
+			continue
+		}
+
+		if batch.Status == BatchStatusFinalized {
+			break
+		}
+
+		batch.Status = BatchStatusFinalized
+		batch.FinalizeTx = &txHash
+	}
+}
+
+// revert removes reverted batches from the index.
+func (w *L1Watcher) revert(index uint64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.truncate(index)
+}
+
+// truncate removes batches from the index, which requires lock held.
+func (w *L1Watcher) truncate(index uint64) {
+	i := sort.Search(len(w.batches), func(i int) bool {
+		return uint64(w.batches[i].Index) >= index
+	})
+
+	w.batches = w.batches[:i]
+}
+
+// BatchOf returns the status of batch that contains the L2 block. Returns nil batch if block
+// not committed yet, or unknown if block not within watched batches.
+func (w *L1Watcher) BatchOf(bn uint64) (*Batch, string, bool) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	// batches of newer versions may have no block range
+	for i := len(w.batches) - 1; i >= 0; i-- {
+		batch := w.batches[i]
+		if batch.StartBlock == nil || batch.EndBlock == nil {
+			continue
+		}
+
+		if bn > uint64(*batch.EndBlock) {
+			// not committed yet if beyond the latest batch
+			if i == len(w.batches)-1 {
+				return nil, BatchStatusPending, true
+			}
+
+			return nil, "", false
+		}
+
+		if bn >= uint64(*batch.StartBlock) {
+			result := *batch
+			return &result, batch.Status, true
+		}
+	}
+
+	return nil, "", false
+}
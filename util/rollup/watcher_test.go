@@ -0,0 +1,73 @@
+package rollup
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestChunk(blocks ...uint64) []byte {
+	chunk := make([]byte, 1+len(blocks)*blockContextSize)
+	chunk[0] = byte(len(blocks))
+
+	for i, bn := range blocks {
+		binary.BigEndian.PutUint64(chunk[1+i*blockContextSize:], bn)
+	}
+
+	return chunk
+}
+
+func TestDecodeChunksBlockRange(t *testing.T) {
+	data, err := commitBatchArgs.Pack(
+		uint8(1), []byte{}, [][]byte{newTestChunk(10, 11), newTestChunk(12, 13, 14)}, []byte{},
+	)
+	assert.Nil(t, err)
+
+	selector := crypto.Keccak256([]byte("commitBatch(uint8,bytes,bytes[],bytes)"))[:4]
+
+	start, end, err := decodeChunksBlockRange(append(selector, data...))
+	assert.Nil(t, err)
+	assert.Equal(t, hexutil.Uint64(10), start)
+	assert.Equal(t, hexutil.Uint64(14), end)
+
+	// unknown commit method
+	_, _, err = decodeChunksBlockRange(append([]byte{1, 2, 3, 4}, data...))
+	assert.NotNil(t, err)
+}
+
+func TestL1WatcherBatchOf(t *testing.T) {
+	w := L1Watcher{conf: L1WatcherConfig{MaxBatches: 10}}
+
+	newBatch := func(index, start, end uint64) *Batch {
+		s, e := hexutil.Uint64(start), hexutil.Uint64(end)
+		return &Batch{Index: hexutil.Uint64(index), StartBlock: &s, EndBlock: &e, Status: BatchStatusCommitted}
+	}
+
+	w.commit(newBatch(1, 1, 10))
+	w.commit(newBatch(2, 11, 20))
+	w.commit(newBatch(3, 21, 30))
+	w.finalize(2, common.HexToHash("0x1"))
+
+	batch, status, ok := w.BatchOf(15)
+	assert.True(t, ok)
+	assert.Equal(t, BatchStatusFinalized, status)
+	assert.Equal(t, hexutil.Uint64(2), batch.Index)
+
+	_, status, _ = w.BatchOf(25)
+	assert.Equal(t, BatchStatusCommitted, status)
+
+	_, status, ok = w.BatchOf(31)
+	assert.True(t, ok)
+	assert.Equal(t, BatchStatusPending, status)
+
+	// recommitted after reverted
+	w.revert(3)
+	w.commit(newBatch(3, 21, 25))
+
+	_, status, _ = w.BatchOf(28)
+	assert.Equal(t, BatchStatusPending, status)
+}
@@ -0,0 +1,23 @@
+package rpc
+
+import "sync/atomic"
+
+// canaryNodes is the set of canary node names, which receive only a percentage of traffic
+// and collect error rates separately.
+var canaryNodes atomic.Value // map[string]bool
+
+// SetCanaryNodes sets URLs of canary nodes.
+func SetCanaryNodes(urls []string) {
+	nodes := make(map[string]bool, len(urls))
+	for _, url := range urls {
+		nodes[Url2NodeName(url)] = true
+	}
+
+	canaryNodes.Store(nodes)
+}
+
+// IsCanaryNode checks if the specified node is canary.
+func IsCanaryNode(nodeName string) bool {
+	nodes, _ := canaryNodes.Load().(map[string]bool)
+	return nodes[nodeName]
+}
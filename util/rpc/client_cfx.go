@@ -59,7 +59,7 @@ func NewCfxClient(url string, options ...ClientOption) (*sdk.Client, error) {
 		o(opt)
 	}
 
-	cfx, err := sdk.NewClient(url, *opt.ClientOption)
+	cfx, err := sdk.NewClient(dialUrl(url), *opt.ClientOption)
 	if err == nil && opt.hookMetrics {
 		HookMiddlewares(cfx.Provider(), url, "cfx")
 	}
@@ -1,10 +1,13 @@
 package rpc
 
 import (
+	"net/http"
 	"time"
 
 	providers "github.com/openweb3/go-rpc-provider/provider_wrapper"
 	"github.com/openweb3/web3go"
+	"github.com/pkg/errors"
+	"github.com/scroll-tech/rpc-gateway/util/rpc/handlers"
 	"github.com/sirupsen/logrus"
 )
 
@@ -58,10 +61,75 @@ func NewEthClient(url string, options ...ClientOption) (*web3go.Client, error) {
 		o(&opt)
 	}
 
-	eth, err := web3go.NewClientWithOption(url, opt.ClientOption)
+	var eth *web3go.Client
+	var err error
+
+	if isHttpUrl(url) {
+		// JWT authentication resolved per request over the normal transport, so that it could
+		// be enabled at runtime for clients created before
+		eth, err = newHttpEthClient(url, &opt, newJwtTransport(url, newHttpTransport(url, &opt)))
+	} else if _, ok := jwtAuths.get(Url2NodeName(url)); ok {
+		err = errors.Errorf("JWT authentication only supported over HTTP: %v", url)
+	} else {
+		// WS and IPC connections are dialed by the underlying client
+		eth, err = web3go.NewClientWithOption(dialUrl(url), opt.ClientOption)
+	}
+
 	if err == nil && opt.hookMetrics {
 		HookMiddlewares(eth.Provider(), url, "eth")
 	}
 
 	return eth, err
 }
+
+// newHttpTransport creates HTTP transport with connection pool tuned, TLS configured and TLS
+// certificates pinned if configured.
+func newHttpTransport(url string, opt *ethClientOption) *http.Transport {
+	var transport *http.Transport
+	if ethClientCfg.HttpPool.Enabled {
+		transport = ethClientCfg.HttpPool.newTransport(url, opt.MaxConnectionPerHost)
+	} else {
+		transport = http.DefaultTransport.(*http.Transport).Clone()
+		transport.MaxConnsPerHost = opt.MaxConnectionPerHost
+	}
+
+	if tlsConf, ok := upstreamTls.get(Url2NodeName(url)); ok {
+		transport.TLSClientConfig = tlsConf
+	}
+
+	if pins, ok := ethTlsPins[Url2NodeName(url)]; ok {
+		if transport.TLSClientConfig == nil {
+			transport.TLSClientConfig = pins.tlsConfig()
+		} else {
+			transport.TLSClientConfig.VerifyConnection = pins.verify
+		}
+	}
+
+	return transport
+}
+
+// newHttpEthClient creates evm space client over HTTP with the specified transport.
+func newHttpEthClient(url string, opt *ethClientOption, transport http.RoundTripper) (*web3go.Client, error) {
+	if isResponseLimited() {
+		transport = &limitTransport{base: transport}
+	}
+
+	if handlers.EnvelopeEnabled() {
+		transport = &envelopeTransport{base: transport}
+	}
+
+	if opt.RetryCount > 0 {
+		transport = &retryTransport{
+			base:          transport,
+			retryCount:    opt.RetryCount,
+			retryInterval: opt.RetryInterval,
+		}
+	}
+
+	httpClient := &http.Client{
+		Timeout:   opt.RequestTimeout,
+		Transport: transport,
+	}
+
+	return web3go.NewClientWithProvider(newHttpProvider(url, httpClient)), nil
+}
@@ -17,6 +17,8 @@ func Url2NodeName(url string) string {
 	nodeName = strings.TrimPrefix(nodeName, "https://")
 	nodeName = strings.TrimPrefix(nodeName, "ws://")
 	nodeName = strings.TrimPrefix(nodeName, "wss://")
+	nodeName = strings.TrimPrefix(nodeName, "unix://")
+	nodeName = strings.TrimPrefix(nodeName, "ipc://")
 	return strings.TrimPrefix(nodeName, "/")
 }
 
@@ -42,6 +44,9 @@ func middlewareMetrics(fullnode, space string) providers.CallContextMiddleware {
 			metrics.Registry.RPC.FullnodeNonRpcErrorRate().Mark(nonRpcErr)
 			metrics.Registry.RPC.FullnodeNonRpcErrorRate(fullnode).Mark(nonRpcErr)
 
+			// error rate of canary nodes against the others
+			metrics.Registry.RPC.FullnodeCanaryErrorRate(IsCanaryNode(fullnode)).Mark(err != nil)
+
 			return err
 		}
 	}
@@ -0,0 +1,52 @@
+package rpc
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// protocols of upstream node endpoints
+const (
+	NodeProtocolHttp = "http"
+	NodeProtocolWs   = "ws"
+	NodeProtocolIpc  = "ipc"
+)
+
+// prefixes of unix IPC endpoints besides the plain socket file path
+var ipcUrlPrefixes = []string{"unix://", "ipc://"}
+
+// NodeProtocol returns the protocol of upstream node url, which could be HTTP(S), WS(S) or
+// unix IPC, e.g. `/data/geth.ipc` or `unix:///data/geth.ipc`.
+func NodeProtocol(url string) (string, error) {
+	lower := strings.ToLower(url)
+
+	switch {
+	case strings.HasPrefix(lower, "http://"), strings.HasPrefix(lower, "https://"):
+		return NodeProtocolHttp, nil
+	case strings.HasPrefix(lower, "ws://"), strings.HasPrefix(lower, "wss://"):
+		return NodeProtocolWs, nil
+	case strings.HasPrefix(lower, "/"), strings.HasPrefix(lower, "unix://"), strings.HasPrefix(lower, "ipc://"):
+		return NodeProtocolIpc, nil
+	default:
+		return "", errors.Errorf("unsupported protocol of node url %v", url)
+	}
+}
+
+// IsPersistentProtocol checks if the protocol is over persistent connection, which supports
+// subscriptions.
+func IsPersistentProtocol(protocol string) bool {
+	return protocol == NodeProtocolWs || protocol == NodeProtocolIpc
+}
+
+// dialUrl returns the url to dial upstream node with, which trims the scheme of unix IPC
+// endpoint, since only plain socket file path is recognized by the underlying client.
+func dialUrl(url string) string {
+	for _, prefix := range ipcUrlPrefixes {
+		if len(url) > len(prefix) && strings.EqualFold(url[:len(prefix)], prefix) {
+			return url[len(prefix):]
+		}
+	}
+
+	return url
+}
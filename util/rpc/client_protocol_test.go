@@ -0,0 +1,36 @@
+package rpc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNodeProtocol(t *testing.T) {
+	for url, expected := range map[string]string{
+		"http://127.0.0.1:8545":  NodeProtocolHttp,
+		"HTTPS://rpc.scroll.io":  NodeProtocolHttp,
+		"ws://127.0.0.1:8546":    NodeProtocolWs,
+		"wss://rpc.scroll.io/ws": NodeProtocolWs,
+		"/data/geth.ipc":         NodeProtocolIpc,
+		"unix:///data/geth.ipc":  NodeProtocolIpc,
+		"ipc:///data/l2geth.ipc": NodeProtocolIpc,
+	} {
+		protocol, err := NodeProtocol(url)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, protocol, url)
+	}
+
+	_, err := NodeProtocol("127.0.0.1:8545")
+	assert.Error(t, err)
+}
+
+func TestDialUrl(t *testing.T) {
+	assert.Equal(t, "/data/geth.ipc", dialUrl("unix:///data/geth.ipc"))
+	assert.Equal(t, "/data/geth.ipc", dialUrl("IPC:///data/geth.ipc"))
+	assert.Equal(t, "/data/geth.ipc", dialUrl("/data/geth.ipc"))
+	assert.Equal(t, "ws://127.0.0.1:8546", dialUrl("ws://127.0.0.1:8546"))
+
+	// node name remains the same regardless of IPC scheme
+	assert.Equal(t, Url2NodeName("/data/geth.ipc"), Url2NodeName("unix:///data/geth.ipc"))
+}
@@ -4,11 +4,17 @@ import (
 	"time"
 
 	"github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/scroll-tech/rpc-gateway/util/reload"
+	"github.com/scroll-tech/rpc-gateway/util/rpc/handlers"
+	"github.com/sirupsen/logrus"
 )
 
 var (
 	cfxClientCfg clientConfig
 	ethClientCfg clientConfig
+
+	// pinned TLS certificates of evm space upstream nodes keyed by node name
+	ethTlsPins map[string]*tlsPins
 )
 
 type clientConfig struct {
@@ -18,6 +24,12 @@ type clientConfig struct {
 	RetryInterval   time.Duration `default:"1s"`
 	RequestTimeout  time.Duration `default:"3s"`
 	MaxConnsPerHost int           `default:"1024"`
+	// JWT authentications for upstream nodes over HTTP, only available for evm space
+	JwtAuth []JwtAuthConfig
+	// HTTP connection pool for upstream nodes, only available for evm space
+	HttpPool HttpPoolConfig
+	// TLS certificate pinning for upstream nodes over HTTPS, only available for evm space
+	TlsPins []TlsPinConfig
 }
 
 type ClientOptioner interface {
@@ -71,4 +83,43 @@ func WithClientHookMetrics(hook bool) ClientOption {
 func init() {
 	viper.MustUnmarshalKey("cfx", &cfxClientCfg)
 	viper.MustUnmarshalKey("eth", &ethClientCfg)
+
+	if err := jwtAuths.reset(ethClientCfg.JwtAuth); err != nil {
+		logrus.WithError(err).Fatal("Failed to init JWT authentications for upstream nodes")
+	}
+
+	var err error
+	if ethTlsPins, err = loadTlsPins(ethClientCfg.TlsPins); err != nil {
+		logrus.WithError(err).Fatal("Failed to init TLS pinning for upstream nodes")
+	}
+
+	// JWT secrets could be rotated at runtime
+	reload.Register("jwt_auth", func() error {
+		var conf clientConfig
+		if err := viper.UnmarshalKey("eth", &conf); err != nil {
+			return err
+		}
+
+		return jwtAuths.reset(conf.JwtAuth)
+	})
+
+	var envelope handlers.EnvelopeConfig
+	viper.MustUnmarshalKey("rpc.envelope", &envelope)
+	handlers.SetEnvelopeConfig(envelope)
+
+	if err := resetIPFilter(); err != nil {
+		logrus.WithError(err).Fatal("Failed to init IP filter")
+	}
+
+	// IP filter could be changed at runtime
+	reload.Register("ip_filter", resetIPFilter)
+}
+
+func resetIPFilter() error {
+	var conf handlers.IPFilterConfig
+	if err := viper.UnmarshalKey("rpc.ipFilter", &conf); err != nil {
+		return err
+	}
+
+	return handlers.DefaultIPFilter.Reset(conf)
 }
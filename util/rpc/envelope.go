@@ -0,0 +1,27 @@
+package rpc
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/scroll-tech/rpc-gateway/util/rpc/handlers"
+)
+
+// envelopeTransport adds gateway envelope to the HTTP header for each request, so that
+// upstream gateway instances could detect loops and duplicated hops.
+//
+// Note, envelope received from the previous gateway instance is forwarded only if the
+// request context is passed along to the upstream call.
+type envelopeTransport struct {
+	base http.RoundTripper
+}
+
+func (t *envelopeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	e, _ := handlers.EnvelopeFromContext(req.Context())
+
+	// clone request as required by the http.RoundTripper contract
+	req = req.Clone(req.Context())
+	req.Header.Set(handlers.EnvelopeHeader, e.Forward(time.Now()).Encode())
+
+	return t.base.RoundTrip(req)
+}
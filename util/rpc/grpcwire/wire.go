@@ -0,0 +1,162 @@
+// Package grpcwire provides gRPC codec for protobuf messages encoded in wire format manually,
+// which avoids code generation for a handful of simple messages.
+package grpcwire
+
+import (
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Marshaler is the message that could be encoded in wire format.
+type Marshaler interface {
+	MarshalWire(b []byte) []byte
+}
+
+// Unmarshaler is the message that could be decoded from wire format.
+type Unmarshaler interface {
+	UnmarshalWire(b []byte) error
+}
+
+// Codec implements gRPC codec for the manually encoded protobuf messages.
+type Codec struct{}
+
+func (Codec) Name() string {
+	return "proto"
+}
+
+func (Codec) Marshal(v interface{}) ([]byte, error) {
+	m, ok := v.(Marshaler)
+	if !ok {
+		return nil, errors.Errorf("unsupported message type %T", v)
+	}
+
+	return m.MarshalWire(nil), nil
+}
+
+func (Codec) Unmarshal(data []byte, v interface{}) error {
+	m, ok := v.(Unmarshaler)
+	if !ok {
+		return errors.Errorf("unsupported message type %T", v)
+	}
+
+	return m.UnmarshalWire(data)
+}
+
+// ConsumeFields iterates all fields of the encoded message, and unknown fields, for which fn
+// consumes nothing, are skipped.
+func ConsumeFields(b []byte, fn func(num protowire.Number, typ protowire.Type, b []byte) (int, error)) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+
+		n, err := fn(num, typ, b)
+		if err != nil {
+			return err
+		}
+
+		if n == 0 { // unknown field
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+	}
+
+	return nil
+}
+
+func ConsumeBytes(typ protowire.Type, b []byte, v *[]byte) (int, error) {
+	if typ != protowire.BytesType {
+		return 0, errors.New("invalid wire type for bytes field")
+	}
+
+	data, n := protowire.ConsumeBytes(b)
+	if n < 0 {
+		return 0, protowire.ParseError(n)
+	}
+
+	*v = append([]byte(nil), data...)
+	return n, nil
+}
+
+func ConsumeString(typ protowire.Type, b []byte, v *string) (int, error) {
+	if typ != protowire.BytesType {
+		return 0, errors.New("invalid wire type for string field")
+	}
+
+	s, n := protowire.ConsumeString(b)
+	if n < 0 {
+		return 0, protowire.ParseError(n)
+	}
+
+	*v = s
+	return n, nil
+}
+
+func ConsumeBool(typ protowire.Type, b []byte, v *bool) (int, error) {
+	if typ != protowire.VarintType {
+		return 0, errors.New("invalid wire type for bool field")
+	}
+
+	x, n := protowire.ConsumeVarint(b)
+	if n < 0 {
+		return 0, protowire.ParseError(n)
+	}
+
+	*v = protowire.DecodeBool(x)
+	return n, nil
+}
+
+func AppendBytes(b []byte, num protowire.Number, v []byte) []byte {
+	if len(v) == 0 {
+		return b
+	}
+
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, v)
+}
+
+func AppendString(b []byte, num protowire.Number, v string) []byte {
+	if len(v) == 0 {
+		return b
+	}
+
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, v)
+}
+
+func AppendVarint(b []byte, num protowire.Number, v uint64) []byte {
+	if v == 0 {
+		return b
+	}
+
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, v)
+}
+
+func AppendBool(b []byte, num protowire.Number, v bool) []byte {
+	return AppendVarint(b, num, protowire.EncodeBool(v))
+}
+
+func AppendMessage(b []byte, num protowire.Number, m Marshaler) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, m.MarshalWire(nil))
+}
+
+// Empty is the empty message.
+type Empty struct{}
+
+func (m *Empty) MarshalWire(b []byte) []byte {
+	return b
+}
+
+func (m *Empty) UnmarshalWire(b []byte) error {
+	return ConsumeFields(b, func(protowire.Number, protowire.Type, []byte) (int, error) {
+		return 0, nil
+	})
+}
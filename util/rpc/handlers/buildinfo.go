@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync/atomic"
+)
+
+// BuildInfoConfig configurations of build info endpoint to audit fleet drift programmatically.
+type BuildInfoConfig struct {
+	Enabled bool   `default:"true"`
+	Path    string `default:"/buildinfo"`
+}
+
+// buildInfoProvider provides the build info to serve, which is set by RPC server.
+var buildInfoProvider atomic.Value // func() interface{}
+
+// SetBuildInfoProvider sets the provider of build info to serve.
+func SetBuildInfoProvider(provider func() interface{}) {
+	buildInfoProvider.Store(provider)
+}
+
+// BuildInfo serves build info endpoint, and delegates other requests to the next handler.
+func BuildInfo(conf *BuildInfoConfig) Middleware {
+	return func(next http.Handler) http.Handler {
+		if !conf.Enabled {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			provider, ok := buildInfoProvider.Load().(func() interface{})
+			if !ok || r.Method != http.MethodGet || r.URL.Path != conf.Path {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Cache-Control", "no-store")
+			json.NewEncoder(w).Encode(provider())
+		})
+	}
+}
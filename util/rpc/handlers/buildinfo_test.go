@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildInfo(t *testing.T) {
+	SetBuildInfoProvider(func() interface{} {
+		return map[string]string{"version": "v1.0.0"}
+	})
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+
+	tests := []struct {
+		enabled bool
+		method  string
+		path    string
+		code    int
+		body    string
+	}{
+		{true, http.MethodGet, "/buildinfo", http.StatusOK, `{"version":"v1.0.0"}`},
+		// delegated to the next handler
+		{true, http.MethodPost, "/buildinfo", http.StatusTeapot, ""},
+		{true, http.MethodGet, "/", http.StatusTeapot, ""},
+		{false, http.MethodGet, "/buildinfo", http.StatusTeapot, ""},
+	}
+
+	for _, tt := range tests {
+		handler := BuildInfo(&BuildInfoConfig{Enabled: tt.enabled, Path: "/buildinfo"})(next)
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+
+		assert.Equal(t, tt.code, w.Code)
+
+		if len(tt.body) > 0 {
+			assert.JSONEq(t, tt.body, w.Body.String())
+			assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+			assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+		}
+	}
+}
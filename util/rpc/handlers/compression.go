@@ -0,0 +1,293 @@
+package handlers
+
+import (
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+)
+
+// CompressionConfig configurations of negotiated response compression, which mainly reduces
+// egress bandwidth of large responses, e.g. `eth_getLogs` and traces.
+type CompressionConfig struct {
+	Enabled bool
+	// min size in bytes of response body to compress
+	MinSize int `default:"1024"`
+	// compression level of gzip in range [1, 9]
+	GzipLevel int `default:"6"`
+	// compression level of brotli in range [0, 11]
+	BrotliLevel int `default:"4"`
+	// supported encodings in order of preference when client accepts multiple
+	Encodings []string `default:"[br,gzip]"`
+}
+
+// compressor is the pool of compression writers for a content encoding.
+type compressor struct {
+	encoding string
+	pool     sync.Pool
+}
+
+func newCompressor(encoding string, conf *CompressionConfig) (*compressor, bool) {
+	c := compressor{encoding: encoding}
+
+	switch encoding {
+	case "gzip":
+		level := conf.GzipLevel
+		if level < gzip.BestSpeed || level > gzip.BestCompression {
+			level = gzip.DefaultCompression
+		}
+
+		c.pool.New = func() interface{} {
+			zw, _ := gzip.NewWriterLevel(ioutil.Discard, level)
+			return zw
+		}
+	case "br":
+		level := conf.BrotliLevel
+		if level < brotli.BestSpeed || level > brotli.BestCompression {
+			level = brotli.DefaultCompression
+		}
+
+		c.pool.New = func() interface{} {
+			return brotli.NewWriterLevel(ioutil.Discard, level)
+		}
+	default:
+		return nil, false
+	}
+
+	return &c, true
+}
+
+type resetWriteCloser interface {
+	io.WriteCloser
+	Reset(w io.Writer)
+}
+
+func (c *compressor) get(w io.Writer) resetWriteCloser {
+	zw := c.pool.Get().(resetWriteCloser)
+	zw.Reset(w)
+	return zw
+}
+
+func (c *compressor) put(zw resetWriteCloser) {
+	zw.Reset(ioutil.Discard)
+	c.pool.Put(zw)
+}
+
+// Compression returns middleware to compress responses with encoding negotiated by the
+// `Accept-Encoding` request header, and responses smaller than the min size are not
+// compressed. Note, it replaces the built-in gzip compression of RPC server.
+func Compression(conf *CompressionConfig) Middleware {
+	var compressors []*compressor
+	for _, encoding := range conf.Encodings {
+		if c, ok := newCompressor(strings.ToLower(encoding), conf); ok {
+			compressors = append(compressors, c)
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		if !conf.Enabled {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if len(r.Header.Get("Upgrade")) > 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			accepted := parseAcceptEncoding(r.Header.Get("Accept-Encoding"))
+
+			// disable the built-in compression of RPC server
+			r.Header.Del("Accept-Encoding")
+			w.Header().Add("Vary", "Accept-Encoding")
+
+			c := negotiateCompressor(compressors, accepted)
+			if c == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			cw := compressResponseWriter{
+				ResponseWriter: w,
+				compressor:     c,
+				minSize:        conf.MinSize,
+			}
+			defer cw.close()
+
+			next.ServeHTTP(&cw, r)
+		})
+	}
+}
+
+// parseAcceptEncoding parses the `Accept-Encoding` header into encodings with quality value,
+// e.g. `gzip, br;q=0.5`.
+func parseAcceptEncoding(header string) map[string]float64 {
+	accepted := make(map[string]float64)
+
+	for _, part := range strings.Split(header, ",") {
+		encoding, params := part, ""
+		if idx := strings.Index(part, ";"); idx >= 0 {
+			encoding, params = part[:idx], part[idx+1:]
+		}
+
+		encoding = strings.ToLower(strings.TrimSpace(encoding))
+		if len(encoding) == 0 {
+			continue
+		}
+
+		q := 1.0
+		if params = strings.TrimSpace(params); strings.HasPrefix(params, "q=") {
+			if v, err := strconv.ParseFloat(params[2:], 64); err == nil {
+				q = v
+			}
+		}
+
+		accepted[encoding] = q
+	}
+
+	return accepted
+}
+
+// negotiateCompressor returns the compressor with the highest quality value accepted by
+// client, and falls back to the configured preference order in case of tie.
+func negotiateCompressor(compressors []*compressor, accepted map[string]float64) *compressor {
+	var (
+		selected *compressor
+		maxQ     float64
+	)
+
+	for _, c := range compressors {
+		q, ok := accepted[c.encoding]
+		if !ok {
+			q, ok = accepted["*"]
+		}
+
+		if ok && q > maxQ {
+			selected, maxQ = c, q
+		}
+	}
+
+	return selected
+}
+
+// compressResponseWriter buffers response body until the min size reached, and then compresses
+// the rest in streaming way.
+type compressResponseWriter struct {
+	http.ResponseWriter
+
+	compressor *compressor
+	minSize    int
+
+	status      int
+	buf         []byte
+	writer      resetWriteCloser // compression writer once started
+	passthrough bool             // response written without compression
+}
+
+func (w *compressResponseWriter) WriteHeader(status int) {
+	// deferred until compression decided
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *compressResponseWriter) Write(b []byte) (int, error) {
+	if w.writer != nil {
+		return w.writer.Write(b)
+	}
+
+	if w.passthrough {
+		return w.ResponseWriter.Write(b)
+	}
+
+	w.buf = append(w.buf, b...)
+	if len(w.buf) < w.minSize {
+		return len(b), nil
+	}
+
+	if err := w.start(); err != nil {
+		return 0, err
+	}
+
+	return len(b), nil
+}
+
+// start writes the buffered response body, which is compressed unless already encoded.
+func (w *compressResponseWriter) start() error {
+	header := w.Header()
+	buf := w.buf
+	w.buf = nil
+
+	if len(header.Get("Content-Encoding")) > 0 || !bodyAllowedForStatus(w.status) {
+		w.passthrough = true
+		w.writeHeader()
+		_, err := w.ResponseWriter.Write(buf)
+		return err
+	}
+
+	header.Del("Content-Length")
+	header.Set("Content-Encoding", w.compressor.encoding)
+	w.writeHeader()
+
+	w.writer = w.compressor.get(w.ResponseWriter)
+	_, err := w.writer.Write(buf)
+	return err
+}
+
+func (w *compressResponseWriter) writeHeader() {
+	if w.status != 0 {
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+}
+
+// Flush compresses the buffered response body regardless of the min size.
+func (w *compressResponseWriter) Flush() {
+	if w.writer == nil && !w.passthrough {
+		w.start()
+	}
+
+	if zw, ok := w.writer.(interface{ Flush() error }); ok {
+		zw.Flush()
+	}
+
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// close writes the response body smaller than the min size without compression, or finishes
+// the compression stream.
+func (w *compressResponseWriter) close() {
+	if w.writer != nil {
+		w.writer.Close()
+		w.compressor.put(w.writer)
+		w.writer = nil
+		return
+	}
+
+	if !w.passthrough {
+		w.passthrough = true
+		w.writeHeader()
+
+		if len(w.buf) > 0 {
+			w.ResponseWriter.Write(w.buf)
+			w.buf = nil
+		}
+	}
+}
+
+func bodyAllowedForStatus(status int) bool {
+	switch {
+	case status >= 100 && status < 200:
+		return false
+	case status == http.StatusNoContent, status == http.StatusNotModified:
+		return false
+	}
+
+	return true
+}
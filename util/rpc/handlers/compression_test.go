@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNegotiateCompressor(t *testing.T) {
+	conf := CompressionConfig{GzipLevel: 6, BrotliLevel: 4}
+
+	gz, _ := newCompressor("gzip", &conf)
+	br, _ := newCompressor("br", &conf)
+	compressors := []*compressor{br, gz}
+
+	assert.Equal(t, br, negotiateCompressor(compressors, parseAcceptEncoding("gzip, deflate, br")))
+	assert.Equal(t, gz, negotiateCompressor(compressors, parseAcceptEncoding("gzip, br;q=0.5")))
+	assert.Equal(t, gz, negotiateCompressor(compressors, parseAcceptEncoding("gzip, br;q=0")))
+	assert.Equal(t, br, negotiateCompressor(compressors, parseAcceptEncoding("*")))
+	assert.Nil(t, negotiateCompressor(compressors, parseAcceptEncoding("identity")))
+	assert.Nil(t, negotiateCompressor(compressors, parseAcceptEncoding("")))
+}
+
+func TestCompression(t *testing.T) {
+	conf := CompressionConfig{Enabled: true, MinSize: 16, GzipLevel: 6, Encodings: []string{"gzip"}}
+
+	handler := Compression(&conf)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Empty(t, r.Header.Get("Accept-Encoding"))
+		w.Write([]byte(r.URL.Query().Get("body")))
+	}))
+
+	serve := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/?body="+body, nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	// small response not compressed
+	w := serve("small")
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+	assert.Equal(t, "small", w.Body.String())
+
+	// large response compressed
+	large := strings.Repeat("0x", 64)
+	w = serve(large)
+	assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+	assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+
+	zr, err := gzip.NewReader(w.Body)
+	assert.NoError(t, err)
+
+	data, err := ioutil.ReadAll(zr)
+	assert.NoError(t, err)
+	assert.Equal(t, large, string(data))
+}
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CorsConfig is the CORS configurations for browser dApps hitting the gateway directly.
+type CorsConfig struct {
+	// allowed origins, which supports `*` for all and wildcard subdomain, e.g. `https://*.example.com`
+	AllowedOrigins []string `default:"[*]"`
+	// allowed request headers for preflight, which supports `*` for all
+	AllowedHeaders []string `default:"[*]"`
+	AllowedMethods []string `default:"[GET,POST]"`
+	// max age to cache preflight results
+	MaxAge time.Duration `default:"10m"`
+	// allowed origins for websocket handshake, and empty means the same as `AllowedOrigins`
+	WsOrigins []string
+}
+
+// WebsocketOrigins returns the allowed origins for websocket handshake.
+func (conf *CorsConfig) WebsocketOrigins() []string {
+	if len(conf.WsOrigins) > 0 {
+		return conf.WsOrigins
+	}
+
+	return conf.AllowedOrigins
+}
+
+// allowsOrigin checks if the specified origin is allowed.
+func (conf *CorsConfig) allowsOrigin(origin string) bool {
+	origin = strings.ToLower(origin)
+
+	for _, allowed := range conf.AllowedOrigins {
+		allowed = strings.ToLower(allowed)
+
+		if allowed == "*" || allowed == origin {
+			return true
+		}
+
+		// wildcard subdomain, e.g. https://*.example.com
+		if idx := strings.Index(allowed, "*"); idx >= 0 {
+			prefix, suffix := allowed[:idx], allowed[idx+1:]
+			if len(origin) > len(prefix)+len(suffix) &&
+				strings.HasPrefix(origin, prefix) && strings.HasSuffix(origin, suffix) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Cors returns middleware to handle CORS requests and preflights. Note, requests from
+// disallowed origins are served without CORS headers, so that browsers will block them.
+func Cors(conf *CorsConfig) Middleware {
+	methods := strings.ToUpper(strings.Join(conf.AllowedMethods, ", "))
+	headers := strings.Join(conf.AllowedHeaders, ", ")
+	maxAge := strconv.Itoa(int(conf.MaxAge.Seconds()))
+
+	allowAllHeaders := false
+	for _, h := range conf.AllowedHeaders {
+		if h == "*" {
+			allowAllHeaders = true
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			if len(origin) == 0 { // not a CORS request
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			header := w.Header()
+			header.Add("Vary", "Origin")
+
+			preflight := r.Method == http.MethodOptions && len(r.Header.Get("Access-Control-Request-Method")) > 0
+
+			if !conf.allowsOrigin(origin) {
+				if preflight {
+					w.WriteHeader(http.StatusNoContent)
+					return
+				}
+
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			header.Set("Access-Control-Allow-Origin", origin)
+
+			if !preflight {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			header.Add("Vary", "Access-Control-Request-Method")
+			header.Add("Vary", "Access-Control-Request-Headers")
+			header.Set("Access-Control-Allow-Methods", methods)
+
+			if allowAllHeaders {
+				// reflect the requested headers
+				if reqHeaders := r.Header.Get("Access-Control-Request-Headers"); len(reqHeaders) > 0 {
+					header.Set("Access-Control-Allow-Headers", reqHeaders)
+				}
+			} else if len(headers) > 0 {
+				header.Set("Access-Control-Allow-Headers", headers)
+			}
+
+			if conf.MaxAge > 0 {
+				header.Set("Access-Control-Max-Age", maxAge)
+			}
+
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCorsAllowsOrigin(t *testing.T) {
+	conf := CorsConfig{AllowedOrigins: []string{"https://app.scroll.io", "https://*.example.com"}}
+
+	assert.True(t, conf.allowsOrigin("https://app.scroll.io"))
+	assert.True(t, conf.allowsOrigin("https://dapp.example.com"))
+	assert.False(t, conf.allowsOrigin("https://example.com"))
+	assert.False(t, conf.allowsOrigin("http://dapp.example.com"))
+	assert.False(t, conf.allowsOrigin("https://evil.io"))
+
+	assert.Equal(t, conf.AllowedOrigins, conf.WebsocketOrigins())
+}
+
+func TestCorsPreflight(t *testing.T) {
+	conf := CorsConfig{
+		AllowedOrigins: []string{"https://app.scroll.io"},
+		AllowedHeaders: []string{"Content-Type"},
+		AllowedMethods: []string{"POST"},
+		MaxAge:         time.Minute,
+	}
+
+	handler := Cors(&conf)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest(http.MethodOptions, "/", nil)
+	req.Header.Set("Origin", "https://app.scroll.io")
+	req.Header.Set("Access-Control-Request-Method", "POST")
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "https://app.scroll.io", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "POST", w.Header().Get("Access-Control-Allow-Methods"))
+	assert.Equal(t, "Content-Type", w.Header().Get("Access-Control-Allow-Headers"))
+	assert.Equal(t, "60", w.Header().Get("Access-Control-Max-Age"))
+
+	// disallowed origin
+	req.Header.Set("Origin", "https://evil.io")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+}
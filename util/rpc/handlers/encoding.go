@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+const (
+	// JSON-RPC error code for invalid request
+	errCodeInvalidRequest = -32600
+)
+
+// acceptedContentTypes is the list of content types accepted for JSON-RPC over HTTP.
+var acceptedContentTypes = []string{"application/json", "application/json-rpc", "application/jsonrequest"}
+
+// GzipRequest decompresses gzip encoded request body, which is usually sent by SDKs
+// to reduce bandwidth for large batch requests. Note, the decompressed size is limited
+// to prevent from decompression bomb.
+func GzipRequest(maxDecompressedSize int64) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			switch encoding := strings.ToLower(strings.TrimSpace(r.Header.Get("Content-Encoding"))); encoding {
+			case "", "identity":
+				next.ServeHTTP(w, r)
+				return
+			case "gzip":
+			default:
+				msg := fmt.Sprintf("unsupported content encoding %v, only gzip is supported", encoding)
+				WriteJsonRpcError(w, http.StatusUnsupportedMediaType, errCodeInvalidRequest, msg)
+				return
+			}
+
+			zr, err := gzip.NewReader(r.Body)
+			if err != nil {
+				WriteJsonRpcError(w, http.StatusBadRequest, errCodeInvalidRequest, "invalid gzip request body")
+				return
+			}
+			defer zr.Close()
+
+			data, err := ioutil.ReadAll(io.LimitReader(zr, maxDecompressedSize+1))
+			if err != nil {
+				WriteJsonRpcError(w, http.StatusBadRequest, errCodeInvalidRequest, "invalid gzip request body")
+				return
+			}
+
+			if int64(len(data)) > maxDecompressedSize {
+				msg := fmt.Sprintf("decompressed request body too large, max %v bytes allowed", maxDecompressedSize)
+				WriteJsonRpcError(w, http.StatusRequestEntityTooLarge, errCodeInvalidRequest, msg)
+				return
+			}
+
+			r.Body = ioutil.NopCloser(bytes.NewReader(data))
+			r.ContentLength = int64(len(data))
+			r.Header.Del("Content-Encoding")
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// StrictContentType rejects HTTP requests without valid JSON content type or UTF-8
+// charset, and responds with clear JSON-RPC error.
+func StrictContentType(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		contentType := r.Header.Get("Content-Type")
+		if len(contentType) == 0 {
+			msg := "missing content type, only application/json is supported"
+			WriteJsonRpcError(w, http.StatusUnsupportedMediaType, errCodeInvalidRequest, msg)
+			return
+		}
+
+		mediaType, params, err := mime.ParseMediaType(contentType)
+		if err != nil || !isAcceptedContentType(mediaType) {
+			msg := fmt.Sprintf("invalid content type %v, only application/json is supported", contentType)
+			WriteJsonRpcError(w, http.StatusUnsupportedMediaType, errCodeInvalidRequest, msg)
+			return
+		}
+
+		if charset, ok := params["charset"]; ok && !strings.EqualFold(charset, "utf-8") {
+			msg := fmt.Sprintf("invalid charset %v, only utf-8 is supported", charset)
+			WriteJsonRpcError(w, http.StatusUnsupportedMediaType, errCodeInvalidRequest, msg)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func isAcceptedContentType(mediaType string) bool {
+	for _, v := range acceptedContentTypes {
+		if strings.EqualFold(v, mediaType) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// WriteJsonRpcError writes JSON-RPC error response with null ID, which is used when
+// request rejected before parsed by RPC server.
+func WriteJsonRpcError(w http.ResponseWriter, status int, code int, message string) {
+	resp := struct {
+		Version string      `json:"jsonrpc"`
+		ID      interface{} `json:"id"`
+		Error   struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}{Version: "2.0"}
+
+	resp.Error.Code = code
+	resp.Error.Message = message
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(&resp)
+}
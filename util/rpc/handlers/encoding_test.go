@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStrictContentType(t *testing.T) {
+	handler := StrictContentType(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	serve := func(method, contentType string) int {
+		r := httptest.NewRequest(method, "/", strings.NewReader(`{}`))
+		if len(contentType) > 0 {
+			r.Header.Set("Content-Type", contentType)
+		}
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, serve(http.MethodPost, "application/json"))
+	assert.Equal(t, http.StatusOK, serve(http.MethodPost, "application/json; charset=UTF-8"))
+	assert.Equal(t, http.StatusOK, serve(http.MethodPost, "application/json-rpc"))
+	assert.Equal(t, http.StatusOK, serve(http.MethodGet, ""))
+
+	assert.Equal(t, http.StatusUnsupportedMediaType, serve(http.MethodPost, ""))
+	assert.Equal(t, http.StatusUnsupportedMediaType, serve(http.MethodPost, "text/plain"))
+	assert.Equal(t, http.StatusUnsupportedMediaType, serve(http.MethodPost, "application/json; charset=latin1"))
+}
+
+func TestGzipRequest(t *testing.T) {
+	var received string
+
+	handler := GzipRequest(16)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := ioutil.ReadAll(r.Body)
+		received = string(data)
+	}))
+
+	serve := func(encoding string, body []byte) int {
+		r := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+		r.Header.Set("Content-Encoding", encoding)
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		return w.Code
+	}
+
+	compress := func(data string) []byte {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		zw.Write([]byte(data))
+		zw.Close()
+		return buf.Bytes()
+	}
+
+	// decompressed
+	assert.Equal(t, http.StatusOK, serve("gzip", compress(`{"id":1}`)))
+	assert.Equal(t, `{"id":1}`, received)
+
+	// not compressed
+	assert.Equal(t, http.StatusOK, serve("", []byte(`{"id":2}`)))
+	assert.Equal(t, `{"id":2}`, received)
+
+	// decompression bomb
+	assert.Equal(t, http.StatusRequestEntityTooLarge, serve("gzip", compress(strings.Repeat("x", 17))))
+
+	// invalid body or encoding
+	assert.Equal(t, http.StatusBadRequest, serve("gzip", []byte(`{"id":3}`)))
+	assert.Equal(t, http.StatusUnsupportedMediaType, serve("br", []byte(`{"id":4}`)))
+}
@@ -0,0 +1,208 @@
+package handlers
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/scroll-tech/rpc-gateway/util/metrics"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// EnvelopeHeader is the HTTP header to carry envelope across gateway instances.
+	EnvelopeHeader = "X-Gateway-Envelope"
+
+	CtxKeyEnvelope = CtxKey("Infura-Envelope")
+
+	errCodeEnvelope = -32600
+)
+
+var (
+	errEnvelopeMalformed = errors.New("malformed gateway envelope")
+	errEnvelopeTampered  = errors.New("gateway envelope checksum mismatch")
+	errEnvelopeLoop      = errors.New("request loop detected across gateway instances")
+	errEnvelopeDuplicate = errors.New("duplicated hop detected in gateway envelope")
+	errEnvelopeMaxHops   = errors.New("too many hops across gateway instances")
+	errEnvelopeExpired   = errors.New("gateway envelope expired")
+)
+
+// EnvelopeConfig configurations of tamper-evident envelope carried when request traverses
+// multiple gateway instances (federation), so that loops and duplicated hops are detected.
+type EnvelopeConfig struct {
+	Enabled bool
+	// unique ID of this gateway instance, and empty means hostname
+	InstanceID string
+	// secret shared by all gateway instances to checksum envelope
+	Secret string
+	// max number of hops allowed
+	MaxHops int `default:"5"`
+	// max age of the first hop
+	MaxAge time.Duration `default:"30s"`
+}
+
+// Hop is a gateway instance traversed by request.
+type Hop struct {
+	ID string `json:"id"`
+	// unix timestamp in milliseconds
+	Timestamp int64 `json:"ts"`
+	// chained checksum of all hops so far
+	Checksum string `json:"sum"`
+}
+
+// Envelope carries the hops traversed by request.
+type Envelope struct {
+	Hops []Hop `json:"hops"`
+}
+
+var envelopeConf EnvelopeConfig
+
+// SetEnvelopeConfig sets the envelope configurations, which should be called at startup.
+func SetEnvelopeConfig(conf EnvelopeConfig) {
+	if len(conf.InstanceID) == 0 {
+		conf.InstanceID, _ = os.Hostname()
+	}
+
+	envelopeConf = conf
+}
+
+// EnvelopeEnabled checks if envelope is enabled.
+func EnvelopeEnabled() bool {
+	return envelopeConf.Enabled
+}
+
+// hopChecksum computes the checksum of hop chained with the previous checksum.
+func hopChecksum(secret, prev, id string, ts int64) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(prev))
+	mac.Write([]byte(id))
+
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], uint64(ts))
+	mac.Write(buf[:])
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// verify verifies the checksums, loops and hops of envelope received by this instance.
+func (e *Envelope) verify(conf *EnvelopeConfig, now time.Time) error {
+	if len(e.Hops) == 0 {
+		return errEnvelopeMalformed
+	}
+
+	seen := make(map[string]bool)
+	var prev string
+
+	for _, hop := range e.Hops {
+		expected := hopChecksum(conf.Secret, prev, hop.ID, hop.Timestamp)
+		if !hmac.Equal([]byte(expected), []byte(hop.Checksum)) {
+			return errEnvelopeTampered
+		}
+
+		if seen[hop.ID] {
+			return errEnvelopeDuplicate
+		}
+
+		seen[hop.ID] = true
+		prev = hop.Checksum
+	}
+
+	if seen[conf.InstanceID] {
+		return errEnvelopeLoop
+	}
+
+	if len(e.Hops) >= conf.MaxHops {
+		return errEnvelopeMaxHops
+	}
+
+	if conf.MaxAge > 0 && now.Sub(time.Unix(0, e.Hops[0].Timestamp*int64(time.Millisecond))) > conf.MaxAge {
+		return errEnvelopeExpired
+	}
+
+	return nil
+}
+
+// Forward returns a new envelope with this instance appended as the last hop.
+func (e *Envelope) Forward(now time.Time) *Envelope {
+	var hops []Hop
+	var prev string
+
+	if e != nil {
+		hops = append(hops, e.Hops...)
+		if len(hops) > 0 {
+			prev = hops[len(hops)-1].Checksum
+		}
+	}
+
+	ts := now.UnixNano() / int64(time.Millisecond)
+	hops = append(hops, Hop{
+		ID:        envelopeConf.InstanceID,
+		Timestamp: ts,
+		Checksum:  hopChecksum(envelopeConf.Secret, prev, envelopeConf.InstanceID, ts),
+	})
+
+	return &Envelope{Hops: hops}
+}
+
+// Encode encodes envelope as HTTP header value.
+func (e *Envelope) Encode() string {
+	data, _ := json.Marshal(e)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeEnvelope(value string) (*Envelope, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, errEnvelopeMalformed
+	}
+
+	var e Envelope
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, errEnvelopeMalformed
+	}
+
+	return &e, nil
+}
+
+// EnvelopeFromContext returns the envelope received from the previous gateway instance if any.
+func EnvelopeFromContext(ctx context.Context) (*Envelope, bool) {
+	e, ok := ctx.Value(CtxKeyEnvelope).(*Envelope)
+	return e, ok
+}
+
+// VerifyEnvelope verifies the envelope from the previous gateway instance if any, and rejects
+// requests with tampered envelope, loops or duplicated hops.
+func VerifyEnvelope(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		value := r.Header.Get(EnvelopeHeader)
+		if !envelopeConf.Enabled || len(value) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		e, err := decodeEnvelope(value)
+		if err == nil {
+			err = e.verify(&envelopeConf, time.Now())
+		}
+
+		if err != nil {
+			metrics.Registry.RPC.EnvelopeRejected(err.Error()).Mark(1)
+			logrus.WithError(err).WithField("envelope", e).Warn("Request rejected due to invalid gateway envelope")
+			WriteJsonRpcError(w, http.StatusLoopDetected, errCodeEnvelope, err.Error())
+			return
+		}
+
+		metrics.Registry.RPC.EnvelopeHops().Update(int64(len(e.Hops)))
+
+		ctx := context.WithValue(r.Context(), CtxKeyEnvelope, e)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnvelopeVerify(t *testing.T) {
+	now := time.Now()
+	conf := EnvelopeConfig{Enabled: true, Secret: "secret", MaxHops: 3, MaxAge: time.Second}
+
+	SetEnvelopeConfig(EnvelopeConfig{InstanceID: "gw-a", Secret: conf.Secret})
+	e := (*Envelope)(nil).Forward(now)
+	SetEnvelopeConfig(EnvelopeConfig{InstanceID: "gw-b", Secret: conf.Secret})
+	e = e.Forward(now)
+
+	decoded, err := decodeEnvelope(e.Encode())
+	assert.Nil(t, err)
+	assert.Equal(t, e, decoded)
+
+	conf.InstanceID = "gw-c"
+	assert.Nil(t, e.verify(&conf, now))
+	assert.Equal(t, errEnvelopeExpired, e.verify(&conf, now.Add(2*time.Second)))
+
+	conf.InstanceID = "gw-a"
+	assert.Equal(t, errEnvelopeLoop, e.verify(&conf, now))
+
+	conf.InstanceID = "gw-c"
+	conf.MaxHops = 2
+	assert.Equal(t, errEnvelopeMaxHops, e.verify(&conf, now))
+
+	conf.MaxHops = 3
+	tampered := &Envelope{Hops: append([]Hop{}, e.Hops...)}
+	tampered.Hops[0].ID = "gw-x"
+	assert.Equal(t, errEnvelopeTampered, tampered.verify(&conf, now))
+
+	conf.Secret = "other"
+	assert.Equal(t, errEnvelopeTampered, e.verify(&conf, now))
+}
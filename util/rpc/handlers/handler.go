@@ -12,4 +12,5 @@ const (
 	CtxKeyRealIP       = CtxKey("Infura-Real-IP")
 	CtxKeyRateRegistry = CtxKey("Infura-Rate-Limit-Registry")
 	CtxAccessToken     = CtxKey("Infura-Access-Token")
+	CtxKeyChain        = CtxKey("Infura-Chain")
 )
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// HardeningConfig is the request hardening profile for public-facing deployments.
+type HardeningConfig struct {
+	Enabled bool
+	// max age of strict transport security, and 0 to disable the header
+	HstsMaxAge time.Duration `default:"8760h"`
+	// whether strict transport security applies to subdomains as well
+	HstsIncludeSubdomains bool
+	// whether to allow responses to be rendered in frames
+	AllowFraming bool
+	// allowed HTTP methods, where GET is required by websocket handshake
+	AllowedMethods []string `default:"[GET,POST,OPTIONS]"`
+	// max size in bytes of request headers
+	MaxHeaderBytes int `default:"16384"`
+	// max length of request URL
+	MaxUrlLength int `default:"2048"`
+}
+
+// Hardening returns middleware bundle of security response headers, HTTP method verb
+// restrictions and request URL length limits. Note, max header size should be applied
+// on the HTTP server.
+func Hardening(conf *HardeningConfig) Middleware {
+	allowedMethods := make(map[string]bool, len(conf.AllowedMethods))
+	for _, m := range conf.AllowedMethods {
+		allowedMethods[strings.ToUpper(m)] = true
+	}
+
+	allow := strings.ToUpper(strings.Join(conf.AllowedMethods, ", "))
+
+	var hsts string
+	if conf.HstsMaxAge > 0 {
+		hsts = fmt.Sprintf("max-age=%d", int64(conf.HstsMaxAge.Seconds()))
+		if conf.HstsIncludeSubdomains {
+			hsts += "; includeSubDomains"
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		if !conf.Enabled {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			header := w.Header()
+			header.Set("X-Content-Type-Options", "nosniff")
+			header.Set("Referrer-Policy", "no-referrer")
+
+			if len(hsts) > 0 {
+				header.Set("Strict-Transport-Security", hsts)
+			}
+
+			if !conf.AllowFraming {
+				header.Set("X-Frame-Options", "DENY")
+				header.Set("Content-Security-Policy", "frame-ancestors 'none'")
+			}
+
+			if !allowedMethods[r.Method] {
+				header.Set("Allow", allow)
+				msg := fmt.Sprintf("method %v not allowed", r.Method)
+				WriteJsonRpcError(w, http.StatusMethodNotAllowed, errCodeInvalidRequest, msg)
+				return
+			}
+
+			if conf.MaxUrlLength > 0 && len(r.RequestURI) > conf.MaxUrlLength {
+				msg := fmt.Sprintf("request URL too long, max %v bytes allowed", conf.MaxUrlLength)
+				WriteJsonRpcError(w, http.StatusRequestURITooLong, errCodeInvalidRequest, msg)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHardening(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	enabled := HardeningConfig{
+		Enabled:        true,
+		HstsMaxAge:     time.Hour,
+		AllowedMethods: []string{"get", "POST"},
+		MaxUrlLength:   32,
+	}
+
+	subdomains := enabled
+	subdomains.HstsIncludeSubdomains = true
+	subdomains.AllowFraming = true
+
+	noHsts := enabled
+	noHsts.HstsMaxAge = 0
+
+	disabled := enabled
+	disabled.Enabled = false
+
+	longUrl := "/" + strings.Repeat("a", 32)
+
+	tests := []struct {
+		conf    HardeningConfig
+		method  string
+		url     string
+		code    int
+		hsts    string
+		framing bool // whether framing allowed
+	}{
+		{enabled, http.MethodPost, "/", http.StatusOK, "max-age=3600", false},
+		{enabled, http.MethodGet, "/", http.StatusOK, "max-age=3600", false},
+		{subdomains, http.MethodPost, "/", http.StatusOK, "max-age=3600; includeSubDomains", true},
+		{noHsts, http.MethodPost, "/", http.StatusOK, "", false},
+		// rejected but still with security headers
+		{enabled, http.MethodPut, "/", http.StatusMethodNotAllowed, "max-age=3600", false},
+		{enabled, http.MethodGet, longUrl, http.StatusRequestURITooLong, "max-age=3600", false},
+		// disabled
+		{disabled, http.MethodPut, longUrl, http.StatusOK, "", true},
+	}
+
+	for _, tt := range tests {
+		conf := tt.conf
+
+		w := httptest.NewRecorder()
+		Hardening(&conf)(next).ServeHTTP(w, httptest.NewRequest(tt.method, tt.url, nil))
+
+		assert.Equal(t, tt.code, w.Code)
+		assert.Equal(t, tt.hsts, w.Header().Get("Strict-Transport-Security"))
+		assert.Equal(t, tt.framing, len(w.Header().Get("X-Frame-Options")) == 0)
+
+		if conf.Enabled {
+			assert.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+		} else {
+			assert.Empty(t, w.Header().Get("X-Content-Type-Options"))
+		}
+
+		if tt.code == http.StatusMethodNotAllowed {
+			assert.Equal(t, "GET, POST", w.Header().Get("Allow"))
+		}
+	}
+}
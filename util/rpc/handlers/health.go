@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/scroll-tech/rpc-gateway/util/lifecycle"
+)
+
+// HealthConfig configurations of health and readiness endpoints for orchestration, e.g.
+// Kubernetes liveness and readiness probes.
+type HealthConfig struct {
+	Enabled bool `default:"true"`
+	// liveness endpoint, which reflects the process only
+	LivenessPath string `default:"/healthz"`
+	// readiness endpoint, which reflects serving node groups and dependencies
+	ReadinessPath string `default:"/readyz"`
+	// timeout to check readiness
+	Timeout time.Duration `default:"3s"`
+}
+
+type healthStatus struct {
+	Status     string                      `json:"status"`
+	Error      string                      `json:"error,omitempty"`
+	Subsystems map[string]lifecycle.Status `json:"subsystems,omitempty"`
+}
+
+// Health serves liveness and readiness endpoints, and delegates other requests to the next
+// handler. Readiness requires the default lifecycle manager ready to serve traffic.
+func Health(conf *HealthConfig) Middleware {
+	return func(next http.Handler) http.Handler {
+		if !conf.Enabled {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet && r.Method != http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			switch r.URL.Path {
+			case conf.LivenessPath:
+				writeHealthStatus(w, http.StatusOK, &healthStatus{Status: "ok"})
+			case conf.ReadinessPath:
+				ctx, cancel := context.WithTimeout(r.Context(), conf.Timeout)
+				defer cancel()
+
+				status := healthStatus{Status: "ok", Subsystems: lifecycle.Default.Status()}
+				if err := lifecycle.Default.Ready(ctx); err != nil {
+					status.Status = "unavailable"
+					status.Error = err.Error()
+					writeHealthStatus(w, http.StatusServiceUnavailable, &status)
+				} else {
+					writeHealthStatus(w, http.StatusOK, &status)
+				}
+			default:
+				next.ServeHTTP(w, r)
+			}
+		})
+	}
+}
+
+func writeHealthStatus(w http.ResponseWriter, code int, status *healthStatus) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(status)
+}
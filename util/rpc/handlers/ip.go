@@ -54,9 +54,13 @@ var privateRanges = []ipRange{
 	},
 }
 
+// privateIPv6Nets - IPv6 unique local addresses, which are not routable in public
+var privateIPv6Nets = []*net.IPNet{
+	{IP: net.ParseIP("fc00::"), Mask: net.CIDRMask(7, 128)},
+}
+
 // isPrivateSubnet - check to see if this ip is in a private subnet
 func isPrivateSubnet(ipAddress net.IP) bool {
-	// my use case is only concerned with ipv4 atm
 	if ipCheck := ipAddress.To4(); ipCheck != nil {
 		// iterate over all our ranges
 		for _, r := range privateRanges {
@@ -65,7 +69,16 @@ func isPrivateSubnet(ipAddress net.IP) bool {
 				return true
 			}
 		}
+
+		return false
+	}
+
+	for _, n := range privateIPv6Nets {
+		if n.Contains(ipAddress) {
+			return true
+		}
 	}
+
 	return false
 }
 
@@ -87,11 +100,36 @@ func GetIPAddress(r *http.Request) string {
 		}
 	}
 
-	if idx := strings.Index(r.RemoteAddr, ":"); idx != -1 {
-		return r.RemoteAddr[:idx]
+	// IPv6 remote address is in form of [host]:port
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+
+	return strings.Trim(r.RemoteAddr, "[]")
+}
+
+// ipv6PrefixLength is the prefix length to aggregate IPv6 addresses as a single client, since
+// a single IPv6 subnet (usually /64) is assigned to an end site.
+var ipv6PrefixLength = 64
+
+// SetIPv6PrefixLength sets the prefix length to aggregate IPv6 addresses as a single client.
+func SetIPv6PrefixLength(length int) {
+	if length > 0 && length <= 128 {
+		ipv6PrefixLength = length
+	}
+}
+
+// ClientIPKey returns the key to identify client by IP address for abuse detection, e.g. rate
+// limit, so that a single IPv6 subnet can't trivially bypass per IP limits. IPv4 address is
+// returned as it is, while IPv6 address is aggregated by prefix, e.g. 2001:db8:1:2::/64.
+func ClientIPKey(ip string) string {
+	parsed := net.ParseIP(ip)
+	if parsed == nil || parsed.To4() != nil {
+		return ip
 	}
 
-	return r.RemoteAddr
+	mask := net.CIDRMask(ipv6PrefixLength, 128)
+	return (&net.IPNet{IP: parsed.Mask(mask), Mask: mask}).String()
 }
 
 func RealIP(next http.Handler) http.Handler {
@@ -0,0 +1,276 @@
+package handlers
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// IPFilterConfig configurations of connection-level IP filtering, which is enforced before
+// the RPC handler chain.
+type IPFilterConfig struct {
+	Enabled bool
+	// CIDRs or IPs allowed to connect, and empty means all
+	Allow []string
+	// CIDRs or IPs denied to connect, which take precedence over allow list
+	Deny []string
+	// API key => CIDRs or IPs bound to the key
+	KeyBindings map[string][]string
+	// CIDRs or IPs of trusted reverse proxies, of which forwarded headers, e.g. `X-Forwarded-For`,
+	// are honored to filter clients, otherwise the peer address is filtered
+	TrustedProxies []string
+	// temporary bans for clients exceeding abuse threshold, e.g. rate limited too many times
+	Ban struct {
+		Enabled bool
+		// number of abuses within the window to ban the client
+		Threshold int           `default:"100"`
+		Window    time.Duration `default:"1m"`
+		Duration  time.Duration `default:"10m"`
+	}
+}
+
+type ipFilterPolicy struct {
+	conf        IPFilterConfig
+	allow       []*net.IPNet
+	deny        []*net.IPNet
+	keyBindings map[string][]*net.IPNet
+
+	trustedProxies []*net.IPNet
+}
+
+func newIPFilterPolicy(conf IPFilterConfig) (*ipFilterPolicy, error) {
+	policy := ipFilterPolicy{conf: conf, keyBindings: make(map[string][]*net.IPNet)}
+
+	var err error
+	if policy.allow, err = parseCIDRs(conf.Allow); err != nil {
+		return nil, errors.WithMessage(err, "invalid allow list")
+	}
+
+	if policy.deny, err = parseCIDRs(conf.Deny); err != nil {
+		return nil, errors.WithMessage(err, "invalid deny list")
+	}
+
+	if policy.trustedProxies, err = parseCIDRs(conf.TrustedProxies); err != nil {
+		return nil, errors.WithMessage(err, "invalid trusted proxies")
+	}
+
+	for key, cidrs := range conf.KeyBindings {
+		if policy.keyBindings[key], err = parseCIDRs(cidrs); err != nil {
+			return nil, errors.WithMessagef(err, "invalid IP binding of key %v", key)
+		}
+	}
+
+	return &policy, nil
+}
+
+// parseCIDRs parses CIDRs, where single IP is regarded as a host CIDR.
+func parseCIDRs(cidrs []string) ([]*net.IPNet, error) {
+	var nets []*net.IPNet
+
+	for _, cidr := range cidrs {
+		if !strings.Contains(cidr, "/") {
+			ip := net.ParseIP(cidr)
+			if ip == nil {
+				return nil, errors.Errorf("invalid IP %v", cidr)
+			}
+
+			bits := 128
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, err
+		}
+
+		nets = append(nets, n)
+	}
+
+	return nets, nil
+}
+
+func containsIP(nets []*net.IPNet, ip net.IP) bool {
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// allows checks if the client IP with the specified API key is allowed to connect.
+func (p *ipFilterPolicy) allows(ip net.IP, key string) bool {
+	if containsIP(p.deny, ip) {
+		return false
+	}
+
+	if len(p.allow) > 0 && !containsIP(p.allow, ip) {
+		return false
+	}
+
+	if bindings, ok := p.keyBindings[key]; ok && !containsIP(bindings, ip) {
+		return false
+	}
+
+	return true
+}
+
+// clientIP returns the client IP to filter, which is the peer address unless the peer is a
+// trusted proxy, so that forwarded headers could not be spoofed by clients.
+func (p *ipFilterPolicy) clientIP(r *http.Request) string {
+	peer := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		peer = host
+	}
+
+	if ip := net.ParseIP(peer); ip != nil && containsIP(p.trustedProxies, ip) {
+		return GetIPAddress(r)
+	}
+
+	return peer
+}
+
+// abuseRecord records abuses of client within the window.
+type abuseRecord struct {
+	count       int
+	windowStart time.Time
+	bannedUntil time.Time
+}
+
+// IPFilter filters connections by allow/deny CIDR lists, API key IP bindings and temporary
+// bans, of which policy could be changed at runtime.
+type IPFilter struct {
+	policy atomic.Value // *ipFilterPolicy
+
+	mu      sync.Mutex
+	records map[string]*abuseRecord // client IP key => abuse record
+}
+
+func NewIPFilter() *IPFilter {
+	f := IPFilter{records: make(map[string]*abuseRecord)}
+	f.policy.Store(&ipFilterPolicy{})
+
+	return &f
+}
+
+// DefaultIPFilter is the IP filter applied to all RPC servers.
+var DefaultIPFilter = NewIPFilter()
+
+// Reset resets the IP filter policy with the specified configurations.
+func (f *IPFilter) Reset(conf IPFilterConfig) error {
+	policy, err := newIPFilterPolicy(conf)
+	if err != nil {
+		return err
+	}
+
+	f.policy.Store(policy)
+
+	return nil
+}
+
+// ReportAbuse reports abuse of the client IP, e.g. rate limited, which leads to temporary ban
+// once abuse threshold exceeded.
+func (f *IPFilter) ReportAbuse(ip string) {
+	policy := f.policy.Load().(*ipFilterPolicy)
+	if !policy.conf.Enabled || !policy.conf.Ban.Enabled || len(ip) == 0 {
+		return
+	}
+
+	ban := policy.conf.Ban
+	key := ClientIPKey(ip)
+	now := time.Now()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	record, ok := f.records[key]
+	if !ok || now.Sub(record.windowStart) > ban.Window {
+		record = &abuseRecord{windowStart: now, bannedUntil: record.bannedUntilOrZero()}
+		f.records[key] = record
+	}
+
+	record.count++
+
+	if record.count >= ban.Threshold && now.After(record.bannedUntil) {
+		record.bannedUntil = now.Add(ban.Duration)
+		logrus.WithFields(logrus.Fields{
+			"client": key, "abuses": record.count, "until": record.bannedUntil,
+		}).Warn("Client banned temporarily due to abuse")
+	}
+
+	f.gc(now, ban.Window)
+}
+
+func (r *abuseRecord) bannedUntilOrZero() time.Time {
+	if r == nil {
+		return time.Time{}
+	}
+
+	return r.bannedUntil
+}
+
+// gc removes stale abuse records once too many, which should be called with lock held.
+func (f *IPFilter) gc(now time.Time, window time.Duration) {
+	if len(f.records) < 10000 {
+		return
+	}
+
+	for key, record := range f.records {
+		if now.Sub(record.windowStart) > window && now.After(record.bannedUntil) {
+			delete(f.records, key)
+		}
+	}
+}
+
+// bannedFor returns the remaining ban duration of the client IP if banned.
+func (f *IPFilter) bannedFor(ip string) (time.Duration, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	record, ok := f.records[ClientIPKey(ip)]
+	if !ok {
+		return 0, false
+	}
+
+	remaining := time.Until(record.bannedUntil)
+	return remaining, remaining > 0
+}
+
+// Middleware rejects connections from clients not allowed or banned temporarily.
+func (f *IPFilter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		policy := f.policy.Load().(*ipFilterPolicy)
+		if !policy.conf.Enabled {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ip := policy.clientIP(r)
+
+		if remaining, ok := f.bannedFor(ip); ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(remaining.Seconds())+1))
+			http.Error(w, "client banned temporarily due to abuse", http.StatusForbidden)
+			return
+		}
+
+		if parsed := net.ParseIP(ip); parsed == nil || !policy.allows(parsed, GetAccessToken(r)) {
+			http.Error(w, "client IP not allowed", http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
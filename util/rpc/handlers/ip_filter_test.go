@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"net"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIPFilterPolicy(t *testing.T) {
+	policy, err := newIPFilterPolicy(IPFilterConfig{
+		Allow:       []string{"10.0.0.0/8", "2001:db8::/32"},
+		Deny:        []string{"10.0.0.1"},
+		KeyBindings: map[string][]string{"key": {"10.1.0.0/16"}},
+	})
+	assert.Nil(t, err)
+
+	assert.True(t, policy.allows(net.ParseIP("10.0.0.2"), ""))
+	assert.True(t, policy.allows(net.ParseIP("2001:db8::1"), ""))
+	assert.False(t, policy.allows(net.ParseIP("10.0.0.1"), ""))
+	assert.False(t, policy.allows(net.ParseIP("192.168.0.1"), ""))
+
+	// API key IP binding
+	assert.True(t, policy.allows(net.ParseIP("10.1.2.3"), "key"))
+	assert.False(t, policy.allows(net.ParseIP("10.2.2.3"), "key"))
+
+	_, err = newIPFilterPolicy(IPFilterConfig{Deny: []string{"invalid"}})
+	assert.NotNil(t, err)
+}
+
+func TestIPFilterBan(t *testing.T) {
+	conf := IPFilterConfig{Enabled: true}
+	conf.Ban.Enabled = true
+	conf.Ban.Threshold = 2
+	conf.Ban.Window = time.Minute
+	conf.Ban.Duration = time.Minute
+
+	f := NewIPFilter()
+	assert.Nil(t, f.Reset(conf))
+
+	f.ReportAbuse("1.2.3.4")
+	_, banned := f.bannedFor("1.2.3.4")
+	assert.False(t, banned)
+
+	f.ReportAbuse("1.2.3.4")
+	_, banned = f.bannedFor("1.2.3.4")
+	assert.True(t, banned)
+
+	_, banned = f.bannedFor("1.2.3.5")
+	assert.False(t, banned)
+}
+
+func TestIPFilterTrustedProxies(t *testing.T) {
+	policy, err := newIPFilterPolicy(IPFilterConfig{TrustedProxies: []string{"10.0.0.0/8"}})
+	assert.Nil(t, err)
+
+	// forwarded header from trusted proxy honored
+	r := httptest.NewRequest("POST", "/", nil)
+	r.RemoteAddr = "10.0.0.1:12345"
+	r.Header.Set("X-Forwarded-For", "1.2.3.4")
+	assert.Equal(t, "1.2.3.4", policy.clientIP(r))
+
+	// forwarded header spoofed by client ignored
+	r.RemoteAddr = "5.6.7.8:12345"
+	assert.Equal(t, "5.6.7.8", policy.clientIP(r))
+}
@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetIPAddressDualStack(t *testing.T) {
+	r := &http.Request{RemoteAddr: "[2001:db8::1]:8545", Header: http.Header{}}
+	assert.Equal(t, "2001:db8::1", GetIPAddress(r))
+
+	r = &http.Request{RemoteAddr: "1.2.3.4:8545", Header: http.Header{}}
+	assert.Equal(t, "1.2.3.4", GetIPAddress(r))
+
+	// private IPv6 address in header skipped
+	r.Header.Set("X-Forwarded-For", "2001:db8::2, fd00::1")
+	assert.Equal(t, "2001:db8::2", GetIPAddress(r))
+}
+
+func TestClientIPKey(t *testing.T) {
+	assert.Equal(t, "1.2.3.4", ClientIPKey("1.2.3.4"))
+	assert.Equal(t, "2001:db8:1:2::/64", ClientIPKey("2001:db8:1:2:aaaa:bbbb:cccc:dddd"))
+	assert.Equal(t, ClientIPKey("2001:db8:1:2::1"), ClientIPKey("2001:db8:1:2::2"))
+	assert.Equal(t, "invalid", ClientIPKey("invalid"))
+}
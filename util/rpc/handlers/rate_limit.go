@@ -3,10 +3,15 @@ package handlers
 import (
 	"context"
 	"net/http"
+	"strconv"
+	"sync"
 
 	"github.com/scroll-tech/rpc-gateway/util/rate"
 )
 
+// ctxKeyRateLimitFeedback is the context key of rate limit feedback for HTTP response headers.
+const ctxKeyRateLimitFeedback = CtxKey("Infura-Rate-Limit-Feedback")
+
 func RateLimit(registry *rate.Registry) Middleware {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -17,27 +22,137 @@ func RateLimit(registry *rate.Registry) Middleware {
 }
 
 func RateLimitAllow(ctx context.Context, name string, n int) bool {
-	registry, ok := ctx.Value(CtxKeyRateRegistry).(*rate.Registry)
+	limiter, vc, ok := rateLimiter(ctx, name)
 	if !ok {
 		return true
 	}
 
+	allowed := limiter.Allow(vc, n)
+
+	if feedback, ok := ctx.Value(ctxKeyRateLimitFeedback).(*rateLimitFeedback); ok {
+		feedback.update(limiter.Status(vc), !allowed)
+	}
+
+	return allowed
+}
+
+// RateLimitStatus returns the rate limit budget of the visitor for the specified resource
+// without consuming any token.
+func RateLimitStatus(ctx context.Context, name string) (rate.Status, bool) {
+	limiter, vc, ok := rateLimiter(ctx, name)
+	if !ok {
+		return rate.Status{}, false
+	}
+
+	return limiter.Status(vc), true
+}
+
+func rateLimiter(ctx context.Context, name string) (rate.Limiter, *rate.VisitContext, bool) {
+	registry, ok := ctx.Value(CtxKeyRateRegistry).(*rate.Registry)
+	if !ok {
+		return nil, nil, false
+	}
+
 	ip, ok := GetIPAddressFromContext(ctx)
 	if !ok { // ip is mandatory
-		return true
+		return nil, nil, false
 	}
 
 	// access token is optional
 	token, _ := GetAccessTokenFromContext(ctx)
 
+	// IPv6 addresses aggregated by prefix
 	vc := &rate.VisitContext{
-		Ip: ip, Resource: name, Key: token,
+		Ip: ClientIPKey(ip), Resource: name, Key: token,
 	}
 
 	limiter, ok := registry.Get(vc)
+	return limiter, vc, ok
+}
+
+// rateLimitFeedback collects the most restrictive rate limit status of HTTP request, which may
+// contain a batch of RPC calls.
+type rateLimitFeedback struct {
+	mu      sync.Mutex
+	status  *rate.Status
+	limited bool
+}
+
+func (f *rateLimitFeedback) update(status rate.Status, limited bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.status == nil || status.Remaining < f.status.Remaining {
+		f.status = &status
+	}
+
+	f.limited = f.limited || limited
+}
+
+// RateLimited checks if any rate limit exceeded for the HTTP request.
+func RateLimited(ctx context.Context) bool {
+	feedback, ok := ctx.Value(ctxKeyRateLimitFeedback).(*rateLimitFeedback)
 	if !ok {
-		return true
+		return false
 	}
 
-	return limiter.Allow(vc, n)
+	feedback.mu.Lock()
+	defer feedback.mu.Unlock()
+
+	return feedback.limited
+}
+
+// writeHeaders writes the standard rate limit headers if any limiter applied.
+func (f *rateLimitFeedback) writeHeaders(header http.Header) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.status == nil {
+		return
+	}
+
+	header.Set("X-RateLimit-Limit", strconv.Itoa(f.status.Limit))
+	header.Set("X-RateLimit-Remaining", strconv.Itoa(f.status.Remaining))
+	header.Set("X-RateLimit-Reset", strconv.FormatInt(f.status.ResetSeconds(), 10))
+
+	if f.limited {
+		header.Set("Retry-After", strconv.FormatInt(f.status.ResetSeconds(), 10))
+	}
+}
+
+// rateLimitResponseWriter writes rate limit headers before the response written.
+type rateLimitResponseWriter struct {
+	http.ResponseWriter
+	feedback    *rateLimitFeedback
+	wroteHeader bool
+}
+
+func (w *rateLimitResponseWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		w.feedback.writeHeaders(w.ResponseWriter.Header())
+	}
+
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *rateLimitResponseWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	return w.ResponseWriter.Write(b)
+}
+
+// WithRateLimitFeedback enables rate limit headers in HTTP response. Note, websocket upgrade
+// requests are not affected, since the response writer must be hijackable.
+func WithRateLimitFeedback(ctx context.Context, w http.ResponseWriter, r *http.Request) (context.Context, http.ResponseWriter) {
+	if len(r.Header.Get("Upgrade")) > 0 {
+		return ctx, w
+	}
+
+	feedback := &rateLimitFeedback{}
+	ctx = context.WithValue(ctx, ctxKeyRateLimitFeedback, feedback)
+
+	return ctx, &rateLimitResponseWriter{ResponseWriter: w, feedback: feedback}
 }
@@ -0,0 +1,117 @@
+package rpc
+
+import (
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// HttpPoolConfig connection pool configurations of HTTP transport for upstream nodes,
+// which is used instead of the default transport to avoid connection churn under load.
+type HttpPoolConfig struct {
+	Enabled bool
+	// max idle connections across all nodes
+	MaxIdleConns int `default:"1024"`
+	// max idle connections to keep per node
+	MaxIdleConnsPerHost int `default:"256"`
+	// max duration an idle connection remains in pool
+	IdleConnTimeout time.Duration `default:"90s"`
+	// keep-alive period of TCP connections
+	KeepAlive time.Duration `default:"30s"`
+	// whether to disable HTTP/2 negotiation over TLS
+	DisableHttp2 bool
+	// per node overrides
+	Nodes []NodeHttpPoolConfig
+}
+
+// NodeHttpPoolConfig overrides connection pool configurations for a specific node,
+// and zero value means not overridden.
+type NodeHttpPoolConfig struct {
+	URL                 string
+	MaxIdleConnsPerHost int
+	MaxConnsPerHost     int
+	IdleConnTimeout     time.Duration
+}
+
+// newTransport creates HTTP transport for the specified upstream node url.
+func (c *HttpPoolConfig) newTransport(url string, maxConnsPerHost int) *http.Transport {
+	maxIdleConnsPerHost, idleConnTimeout := c.MaxIdleConnsPerHost, c.IdleConnTimeout
+
+	if node, ok := c.node(url); ok {
+		if node.MaxIdleConnsPerHost > 0 {
+			maxIdleConnsPerHost = node.MaxIdleConnsPerHost
+		}
+
+		if node.MaxConnsPerHost > 0 {
+			maxConnsPerHost = node.MaxConnsPerHost
+		}
+
+		if node.IdleConnTimeout > 0 {
+			idleConnTimeout = node.IdleConnTimeout
+		}
+	}
+
+	return &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: c.KeepAlive,
+		}).DialContext,
+		ForceAttemptHTTP2:     !c.DisableHttp2,
+		MaxIdleConns:          c.MaxIdleConns,
+		MaxIdleConnsPerHost:   maxIdleConnsPerHost,
+		MaxConnsPerHost:       maxConnsPerHost,
+		IdleConnTimeout:       idleConnTimeout,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+}
+
+func (c *HttpPoolConfig) node(url string) (*NodeHttpPoolConfig, bool) {
+	nodeName := Url2NodeName(url)
+
+	for i := range c.Nodes {
+		if Url2NodeName(c.Nodes[i].URL) == nodeName {
+			return &c.Nodes[i], true
+		}
+	}
+
+	return nil, false
+}
+
+// isHttpUrl checks if the url is over HTTP(S).
+func isHttpUrl(url string) bool {
+	return strings.HasPrefix(url, "http://") || strings.HasPrefix(url, "https://")
+}
+
+// retryTransport retries on network errors, since the retry option of client is not
+// applicable for customized HTTP transport.
+type retryTransport struct {
+	base          http.RoundTripper
+	retryCount    int
+	retryInterval time.Duration
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (resp *http.Response, err error) {
+	for i := 0; ; i++ {
+		if resp, err = t.base.RoundTrip(req); err == nil || i >= t.retryCount || req.GetBody == nil {
+			return resp, err
+		}
+
+		select {
+		case <-req.Context().Done():
+			return nil, err
+		case <-time.After(t.retryInterval):
+		}
+
+		// request body should be reset before retry
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+
+		req = req.Clone(req.Context())
+		req.Body = body
+	}
+}
@@ -0,0 +1,30 @@
+package rpc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHttpPoolNodeOverrides(t *testing.T) {
+	conf := HttpPoolConfig{
+		Enabled:             true,
+		MaxIdleConns:        1024,
+		MaxIdleConnsPerHost: 256,
+		IdleConnTimeout:     90 * time.Second,
+		Nodes: []NodeHttpPoolConfig{
+			{URL: "http://127.0.0.1:8545", MaxIdleConnsPerHost: 512, MaxConnsPerHost: 2048},
+		},
+	}
+
+	transport := conf.newTransport("http://127.0.0.1:8545", 1024)
+	assert.Equal(t, 512, transport.MaxIdleConnsPerHost)
+	assert.Equal(t, 2048, transport.MaxConnsPerHost)
+	assert.Equal(t, 90*time.Second, transport.IdleConnTimeout)
+	assert.True(t, transport.ForceAttemptHTTP2)
+
+	transport = conf.newTransport("http://127.0.0.1:8546", 1024)
+	assert.Equal(t, 256, transport.MaxIdleConnsPerHost)
+	assert.Equal(t, 1024, transport.MaxConnsPerHost)
+}
@@ -0,0 +1,146 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/pkg/errors"
+)
+
+// httpMessage is the JSON-RPC message over HTTP.
+type httpMessage struct {
+	Version string          `json:"jsonrpc"`
+	ID      uint64          `json:"id"`
+	Method  string          `json:"method,omitempty"`
+	Params  []interface{}   `json:"params,omitempty"`
+	Error   *rpc.JsonError  `json:"error,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+}
+
+// httpProvider requests upstream node over the standard HTTP client, so that customized HTTP
+// transports, e.g. TLS, JWT authentication or retry, could be applied, which is not supported
+// by the underlying fasthttp client of go-rpc-provider.
+type httpProvider struct {
+	url    string
+	client *http.Client
+	nextID uint64
+}
+
+func newHttpProvider(url string, client *http.Client) *httpProvider {
+	return &httpProvider{url: url, client: client}
+}
+
+func (p *httpProvider) CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	req := httpMessage{
+		Version: "2.0",
+		ID:      atomic.AddUint64(&p.nextID, 1),
+		Method:  method,
+		Params:  args,
+	}
+
+	var resp httpMessage
+	if err := p.post(ctx, &req, &resp); err != nil {
+		return err
+	}
+
+	if resp.Error != nil {
+		return resp.Error
+	}
+
+	if result == nil || len(resp.Result) == 0 {
+		return nil
+	}
+
+	return json.Unmarshal(resp.Result, result)
+}
+
+func (p *httpProvider) BatchCallContext(ctx context.Context, b []rpc.BatchElem) error {
+	reqs := make([]httpMessage, len(b))
+	id2Index := make(map[uint64]int, len(b))
+
+	for i := range b {
+		reqs[i] = httpMessage{
+			Version: "2.0",
+			ID:      atomic.AddUint64(&p.nextID, 1),
+			Method:  b[i].Method,
+			Params:  b[i].Args,
+		}
+
+		id2Index[reqs[i].ID] = i
+	}
+
+	var resps []httpMessage
+	if err := p.post(ctx, reqs, &resps); err != nil {
+		return err
+	}
+
+	for _, resp := range resps {
+		i, ok := id2Index[resp.ID]
+		if !ok {
+			continue
+		}
+
+		switch {
+		case resp.Error != nil:
+			b[i].Error = resp.Error
+		case b[i].Result != nil && len(resp.Result) > 0:
+			b[i].Error = json.Unmarshal(resp.Result, b[i].Result)
+		}
+	}
+
+	return nil
+}
+
+func (p *httpProvider) Subscribe(
+	ctx context.Context, namespace string, channel interface{}, args ...interface{},
+) (*rpc.ClientSubscription, error) {
+	return nil, rpc.ErrNotificationsUnsupported
+}
+
+func (p *httpProvider) Close() {
+	p.client.CloseIdleConnections()
+}
+
+// post sends JSON-RPC request, and decodes the JSON-RPC response.
+func (p *httpProvider) post(ctx context.Context, msg, result interface{}) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	// conforms to go-rpc-provider
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%v", resp.StatusCode)
+	}
+
+	if err = json.Unmarshal(data, result); err != nil {
+		return errors.WithMessage(err, "invalid JSON-RPC response")
+	}
+
+	return nil
+}
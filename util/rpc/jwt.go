@@ -0,0 +1,179 @@
+package rpc
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// jwtHeader is the base64 encoded JOSE header for HS256 algorithm.
+var jwtHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// JwtSecretConfig shared secret to authenticate with upstream node.
+type JwtSecretConfig struct {
+	// hex encoded secret with or without `0x` prefix
+	Secret string
+	// RFC3339 time since when the secret takes effect, which is used for secret rotation
+	NotBefore string
+}
+
+// JwtAuthConfig JWT authentication configurations for upstream node.
+type JwtAuthConfig struct {
+	// upstream node URL
+	URL string
+	// shared secrets, the latest effective one will be used to sign token
+	Secrets []JwtSecretConfig
+	// token expiration duration, with 0 means no `exp` claim
+	Expiry time.Duration
+}
+
+type jwtSecret struct {
+	key       []byte
+	notBefore time.Time
+}
+
+// jwtAuth generates short-lived HS256 JWT tokens to authenticate with upstream node
+// in manner of execution engine authentication.
+type jwtAuth struct {
+	secrets []jwtSecret
+	expiry  time.Duration
+}
+
+func newJwtAuth(conf *JwtAuthConfig) (*jwtAuth, error) {
+	if len(conf.Secrets) == 0 {
+		return nil, errors.New("no secret configured")
+	}
+
+	auth := jwtAuth{expiry: conf.Expiry}
+
+	for _, s := range conf.Secrets {
+		key, err := hex.DecodeString(strings.TrimPrefix(s.Secret, "0x"))
+		if err != nil {
+			return nil, errors.WithMessage(err, "invalid hex secret")
+		}
+
+		if len(key) == 0 {
+			return nil, errors.New("empty secret")
+		}
+
+		var notBefore time.Time
+		if len(s.NotBefore) > 0 {
+			if notBefore, err = time.Parse(time.RFC3339, s.NotBefore); err != nil {
+				return nil, errors.WithMessage(err, "invalid secret effective time")
+			}
+		}
+
+		auth.secrets = append(auth.secrets, jwtSecret{key, notBefore})
+	}
+
+	return &auth, nil
+}
+
+// secret returns the latest secret that takes effect at the specified time, or the
+// first configured secret if none takes effect yet.
+func (auth *jwtAuth) secret(now time.Time) []byte {
+	var latest *jwtSecret
+
+	for i := range auth.secrets {
+		s := &auth.secrets[i]
+		if s.notBefore.After(now) {
+			continue
+		}
+
+		if latest == nil || s.notBefore.After(latest.notBefore) {
+			latest = s
+		}
+	}
+
+	if latest == nil {
+		return auth.secrets[0].key
+	}
+
+	return latest.key
+}
+
+// token generates a new signed token issued at the specified time.
+func (auth *jwtAuth) token(now time.Time) string {
+	claims := fmt.Sprintf(`{"iat":%d}`, now.Unix())
+	if auth.expiry > 0 {
+		claims = fmt.Sprintf(`{"iat":%d,"exp":%d}`, now.Unix(), now.Add(auth.expiry).Unix())
+	}
+
+	signingStr := jwtHeader + "." + base64.RawURLEncoding.EncodeToString([]byte(claims))
+
+	mac := hmac.New(sha256.New, auth.secret(now))
+	mac.Write([]byte(signingStr))
+
+	return signingStr + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// jwtAuthRegistry holds JWT authentications for upstream nodes, which could be
+// updated at runtime for secret rotation.
+type jwtAuthRegistry struct {
+	node2Auths map[string]*jwtAuth // node name => JWT auth
+	mu         sync.RWMutex
+}
+
+var jwtAuths = &jwtAuthRegistry{node2Auths: make(map[string]*jwtAuth)}
+
+func (r *jwtAuthRegistry) get(nodeName string) (*jwtAuth, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	auth, ok := r.node2Auths[nodeName]
+	return auth, ok
+}
+
+// reset replaces all JWT authentications atomically.
+func (r *jwtAuthRegistry) reset(confs []JwtAuthConfig) error {
+	node2Auths := make(map[string]*jwtAuth)
+
+	for i := range confs {
+		auth, err := newJwtAuth(&confs[i])
+		if err != nil {
+			return errors.WithMessagef(err, "bad JWT auth config for node %v", confs[i].URL)
+		}
+
+		node2Auths[Url2NodeName(confs[i].URL)] = auth
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.node2Auths = node2Auths
+
+	return nil
+}
+
+// jwtTransport adds fresh JWT token to the HTTP header for each request if JWT authentication
+// configured for the upstream node, which is resolved per request so that it could be enabled,
+// disabled or rotated at runtime.
+type jwtTransport struct {
+	nodeName string
+	base     http.RoundTripper
+}
+
+func newJwtTransport(url string, base http.RoundTripper) *jwtTransport {
+	return &jwtTransport{nodeName: Url2NodeName(url), base: base}
+}
+
+func (t *jwtTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	auth, ok := jwtAuths.get(t.nodeName)
+	if !ok { // JWT auth not configured
+		return t.base.RoundTrip(req)
+	}
+
+	// clone request as required by the http.RoundTripper contract
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+auth.token(time.Now()))
+
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,107 @@
+package rpc
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJwtAuthToken(t *testing.T) {
+	auth, err := newJwtAuth(&JwtAuthConfig{
+		Secrets: []JwtSecretConfig{{Secret: "0x" + strings.Repeat("ab", 32)}},
+	})
+	assert.Nil(t, err)
+
+	token := auth.token(time.Unix(1700000000, 0))
+	parts := strings.Split(token, ".")
+	assert.Equal(t, 3, len(parts))
+
+	claims, err := base64.RawURLEncoding.DecodeString(parts[1])
+	assert.Nil(t, err)
+	assert.Equal(t, `{"iat":1700000000}`, string(claims))
+
+	mac := hmac.New(sha256.New, auth.secrets[0].key)
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	assert.Equal(t, base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), parts[2])
+}
+
+func TestJwtAuthSecretRotation(t *testing.T) {
+	auth, err := newJwtAuth(&JwtAuthConfig{
+		Secrets: []JwtSecretConfig{
+			{Secret: strings.Repeat("01", 32)},
+			{Secret: strings.Repeat("02", 32), NotBefore: "2030-01-01T00:00:00Z"},
+		},
+	})
+	assert.Nil(t, err)
+
+	// rotated secret not effective yet
+	assert.Equal(t, byte(0x01), auth.secret(time.Date(2029, 1, 1, 0, 0, 0, 0, time.UTC))[0])
+
+	// rotated secret takes effect
+	assert.Equal(t, byte(0x02), auth.secret(time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC))[0])
+}
+
+func TestJwtAuthBadConfig(t *testing.T) {
+	_, err := newJwtAuth(&JwtAuthConfig{})
+	assert.NotNil(t, err)
+
+	_, err = newJwtAuth(&JwtAuthConfig{Secrets: []JwtSecretConfig{{Secret: "0xzz"}}})
+	assert.NotNil(t, err)
+}
+
+func TestJwtAuthClient(t *testing.T) {
+	defer jwtAuths.reset(nil)
+
+	var requests int32
+	var authorization atomic.Value
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// connection closed for the first request to test retry
+		if atomic.AddInt32(&requests, 1) == 1 {
+			conn, _, _ := w.(http.Hijacker).Hijack()
+			conn.Close()
+			return
+		}
+
+		authorization.Store(r.Header.Get("Authorization"))
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":"0x10"}`))
+	}))
+	defer server.Close()
+
+	client, err := NewEthClient(server.URL, WithClientRetryCount(1), WithClientRetryInterval(time.Millisecond))
+	assert.Nil(t, err)
+
+	// JWT authentication not configured yet, and retried on network error
+	_, err = client.Eth.BlockNumber()
+	assert.Nil(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+	assert.Equal(t, "", authorization.Load())
+
+	// JWT authentication enabled at runtime
+	err = jwtAuths.reset([]JwtAuthConfig{
+		{URL: server.URL, Secrets: []JwtSecretConfig{{Secret: strings.Repeat("ab", 32)}}},
+	})
+	assert.Nil(t, err)
+
+	_, err = client.Eth.BlockNumber()
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(authorization.Load().(string), "Bearer "))
+
+	// JWT authentication is not supported over websocket
+	err = jwtAuths.reset([]JwtAuthConfig{
+		{URL: "ws://127.0.0.1:8546", Secrets: []JwtSecretConfig{{Secret: strings.Repeat("ab", 32)}}},
+	})
+	assert.Nil(t, err)
+
+	_, err = NewEthClient("ws://127.0.0.1:8546")
+	assert.NotNil(t, err)
+}
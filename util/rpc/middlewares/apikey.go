@@ -0,0 +1,99 @@
+package middlewares
+
+import (
+	"context"
+	"sync/atomic"
+
+	web3pay "github.com/Conflux-Chain/web3pay-service/client"
+	"github.com/openweb3/go-rpc-provider"
+	"github.com/pkg/errors"
+	"github.com/scroll-tech/rpc-gateway/util/apikey"
+	"github.com/scroll-tech/rpc-gateway/util/rpc/handlers"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	errApiKeyRequired    = errors.New("api key required")
+	errApiKeyInvalid     = errors.New("invalid api key")
+	errApiKeyUnavailable = errors.New("api key service unavailable, please try again later")
+	errApiKeyOutdated    = errors.New("api key exceeds max age, please rotate it")
+)
+
+// apiKeyRequired indicates requests without valid API key should be rejected.
+var apiKeyRequired int32
+
+// SetApiKeyRequired sets whether requests without valid API key should be rejected.
+func SetApiKeyRequired(required bool) {
+	var v int32
+	if required {
+		v = 1
+	}
+
+	atomic.StoreInt32(&apiKeyRequired, v)
+}
+
+// ApiKeyReady checks if API key store is available when API key required.
+func ApiKeyReady() error {
+	if _, ok := apikey.Default(); !ok && atomic.LoadInt32(&apiKeyRequired) == 1 {
+		return errApiKeyUnavailable
+	}
+
+	return nil
+}
+
+// ApiKeyAuth validates API key against the built-in API key store with cached lookup, once
+// API key subsystem initialized. Requests already billed by Web3Pay are served directly.
+func ApiKeyAuth(next rpc.HandleCallMsgFunc) rpc.HandleCallMsgFunc {
+	return func(ctx context.Context, msg *rpc.JsonRpcMessage) *rpc.JsonRpcMessage {
+		if bs, ok := web3pay.BillingStatusFromContext(ctx); ok && bs.Success() {
+			return next(ctx, msg)
+		}
+
+		if err := ValidateApiKey(ctx); err != nil {
+			return msg.ErrorResponse(err)
+		}
+
+		return next(ctx, msg)
+	}
+}
+
+// ValidateApiKey validates the API key in context, if any, against the built-in API key store
+// once API key subsystem initialized.
+func ValidateApiKey(ctx context.Context) error {
+	required := atomic.LoadInt32(&apiKeyRequired) == 1
+
+	manager, ok := apikey.Default()
+	if !ok {
+		if required {
+			return errApiKeyUnavailable
+		}
+
+		return nil
+	}
+
+	token, ok := handlers.GetAccessTokenFromContext(ctx)
+	if !ok {
+		if required {
+			return errApiKeyRequired
+		}
+
+		// rate limited by client IP
+		return nil
+	}
+
+	if _, err := manager.Validate(token); err != nil {
+		if errors.Is(err, apikey.ErrKeyRotationRequired) {
+			return errApiKeyOutdated
+		}
+
+		if errors.Is(err, apikey.ErrKeyNotFound) || errors.Is(err, apikey.ErrKeyRevoked) ||
+			errors.Is(err, apikey.ErrKeyExpired) {
+			return errApiKeyInvalid
+		}
+
+		logrus.WithError(err).Error("Failed to validate api key")
+		return errApiKeyUnavailable
+	}
+
+	return nil
+}
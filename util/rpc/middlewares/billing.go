@@ -1,14 +1,37 @@
 package middlewares
 
 import (
+	"context"
+	"sync/atomic"
+
 	"github.com/Conflux-Chain/go-conflux-util/viper"
 	web3pay "github.com/Conflux-Chain/web3pay-service/client"
 	"github.com/openweb3/go-rpc-provider"
+	"github.com/pkg/errors"
 	"github.com/scroll-tech/rpc-gateway/util/rpc/handlers"
 	"github.com/sirupsen/logrus"
 )
 
+var errBillingUnavailable = errors.New("billing service unavailable, please try again later")
+
+// billing is the Web3Pay billing middleware, which is set once billing subsystem initialized.
+var billing atomic.Value
+
+// billingRequired indicates billing is enabled, so that requests are rejected until billing
+// subsystem initialized.
+var billingRequired int32
+
 func MustNewWeb3PayClient() (*web3pay.Client, bool) {
+	client, ok, err := NewWeb3PayClient()
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to new Web3Pay client")
+	}
+
+	return client, ok
+}
+
+// NewWeb3PayClient creates Web3Pay client if billing enabled.
+func NewWeb3PayClient() (*web3pay.Client, bool, error) {
 	var config struct {
 		web3pay.ClientConfig `mapstructure:",squash"`
 		Enabled              bool
@@ -16,15 +39,17 @@ func MustNewWeb3PayClient() (*web3pay.Client, bool) {
 	viper.MustUnmarshalKey("web3pay", &config)
 
 	if !config.Enabled {
-		return nil, false
+		return nil, false, nil
 	}
 
+	atomic.StoreInt32(&billingRequired, 1)
+
 	client, err := web3pay.NewClient(config.ClientConfig)
 	if err != nil {
-		logrus.WithError(err).Fatal("Failed to new Web3Pay client")
+		return nil, true, err
 	}
 
-	return client, true
+	return client, true, nil
 }
 
 func Billing(client *web3pay.Client) rpc.HandleCallMsgMiddleware {
@@ -32,3 +57,38 @@ func Billing(client *web3pay.Client) rpc.HandleCallMsgMiddleware {
 	mwoption.ApiKeyProvider = handlers.GetAccessTokenFromContext
 	return web3pay.Openweb3BillingMiddleware(mwoption)
 }
+
+// SetBillingClient enables billing middleware with the specified Web3Pay client.
+func SetBillingClient(client *web3pay.Client) {
+	billing.Store(Billing(client))
+}
+
+// GatedBilling is a static billing middleware, which delegates to Web3Pay billing middleware
+// once billing subsystem initialized. Requests are rejected if billing enabled but unavailable,
+// e.g. failed to initialize Web3Pay client.
+func GatedBilling(next rpc.HandleCallMsgFunc) rpc.HandleCallMsgFunc {
+	return func(ctx context.Context, msg *rpc.JsonRpcMessage) *rpc.JsonRpcMessage {
+		if mw, ok := billing.Load().(rpc.HandleCallMsgMiddleware); ok {
+			return mw(next)(ctx, msg)
+		}
+
+		if atomic.LoadInt32(&billingRequired) == 1 {
+			return msg.ErrorResponse(errBillingUnavailable)
+		}
+
+		return next(ctx, msg)
+	}
+}
+
+// BillingReady checks if billing is available when enabled.
+func BillingReady() error {
+	if _, ok := billing.Load().(rpc.HandleCallMsgMiddleware); ok {
+		return nil
+	}
+
+	if atomic.LoadInt32(&billingRequired) == 1 {
+		return errBillingUnavailable
+	}
+
+	return nil
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/openweb3/go-rpc-provider"
 	"github.com/scroll-tech/rpc-gateway/util/metrics"
+	"github.com/scroll-tech/rpc-gateway/util/rpc/handlers"
 )
 
 func MetricsBatch(next rpc.HandleBatchFunc) rpc.HandleBatchFunc {
@@ -24,7 +25,17 @@ func Metrics(next rpc.HandleCallMsgFunc) rpc.HandleCallMsgFunc {
 	return func(ctx context.Context, msg *rpc.JsonRpcMessage) *rpc.JsonRpcMessage {
 		start := time.Now()
 		resp := next(ctx, msg)
-		metrics.Registry.RPC.UpdateDuration(msg.Method, resp.Error, start)
+		metrics.Registry.RPC.UpdateDuration(metricMethod(ctx, msg.Method), resp.Error, start)
 		return resp
 	}
 }
+
+// metricMethod qualifies RPC method with chain name if any, so that metrics of different
+// chains served by the same process are collected separately.
+func metricMethod(ctx context.Context, method string) string {
+	if chain, ok := ctx.Value(handlers.CtxKeyChain).(string); ok && len(chain) > 0 {
+		return chain + "/" + method
+	}
+
+	return method
+}
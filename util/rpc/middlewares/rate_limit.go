@@ -3,9 +3,11 @@ package middlewares
 import (
 	"context"
 	"errors"
+	"time"
 
 	web3pay "github.com/Conflux-Chain/web3pay-service/client"
 	"github.com/openweb3/go-rpc-provider"
+	"github.com/scroll-tech/rpc-gateway/util/rate"
 	"github.com/scroll-tech/rpc-gateway/util/rpc/handlers"
 )
 
@@ -13,15 +15,47 @@ var (
 	errRateLimit = errors.New("too many requests")
 )
 
+// rateLimitError is returned when throttled, which carries the rate limit budget in error data
+// so that clients could implement proper backoff.
+type rateLimitError struct {
+	status rate.Status
+}
+
+func newRateLimitError(ctx context.Context, name string) error {
+	status, ok := handlers.RateLimitStatus(ctx, name)
+	if !ok {
+		return errRateLimit
+	}
+
+	return &rateLimitError{status}
+}
+
+func (e *rateLimitError) Error() string {
+	return errRateLimit.Error()
+}
+
+func (e *rateLimitError) ErrorData() interface{} {
+	return map[string]interface{}{
+		"limit":      e.status.Limit,
+		"remaining":  e.status.Remaining,
+		"reset":      e.status.ResetSeconds(),
+		"retryAfter": (time.Duration(e.status.ResetSeconds()) * time.Second).String(),
+	}
+}
+
 func RateLimitBatch(next rpc.HandleBatchFunc) rpc.HandleBatchFunc {
 	return func(ctx context.Context, msgs []*rpc.JsonRpcMessage) []*rpc.JsonRpcMessage {
 		if handlers.RateLimitAllow(ctx, "rpc_batch", len(msgs)) {
 			return next(ctx, msgs)
 		}
 
+		reportAbuse(ctx)
+
+		err := newRateLimitError(ctx, "rpc_batch")
+
 		var responses []*rpc.JsonRpcMessage
 		for _, v := range msgs {
-			responses = append(responses, v.ErrorResponse(errRateLimit))
+			responses = append(responses, v.ErrorResponse(err))
 		}
 
 		return responses
@@ -36,16 +70,35 @@ func RateLimit(next rpc.HandleCallMsgFunc) rpc.HandleCallMsgFunc {
 			return next(ctx, msg)
 		}
 
-		// overall rate limit
-		if !handlers.RateLimitAllow(ctx, "rpc_all", 1) {
-			return msg.ErrorResponse(errRateLimit)
-		}
-
-		// single method rate limit
-		if !handlers.RateLimitAllow(ctx, msg.Method, 1) {
-			return msg.ErrorResponse(errRateLimit)
+		if err := CheckRateLimit(ctx, msg.Method); err != nil {
+			return msg.ErrorResponse(err)
 		}
 
 		return next(ctx, msg)
 	}
 }
+
+// CheckRateLimit checks both the overall and the single resource rate limits, e.g. RPC method,
+// and returns error if throttled.
+func CheckRateLimit(ctx context.Context, name string) error {
+	// overall rate limit
+	if !handlers.RateLimitAllow(ctx, "rpc_all", 1) {
+		reportAbuse(ctx)
+		return newRateLimitError(ctx, "rpc_all")
+	}
+
+	// single resource rate limit
+	if !handlers.RateLimitAllow(ctx, name, 1) {
+		reportAbuse(ctx)
+		return newRateLimitError(ctx, name)
+	}
+
+	return nil
+}
+
+// reportAbuse reports rate limited client to IP filter for temporary ban.
+func reportAbuse(ctx context.Context) {
+	if ip, ok := handlers.GetIPAddressFromContext(ctx); ok {
+		handlers.DefaultIPFilter.ReportAbuse(ip)
+	}
+}
@@ -0,0 +1,113 @@
+package rpc
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/pkg/errors"
+)
+
+// ErrResponseTooLarge is returned when upstream response exceeds the size limit of request.
+var ErrResponseTooLarge = errors.New("upstream response too large")
+
+type ctxKeyResponseLimit struct{}
+
+// responseLimited indicates whether upstream response size is limited, which requires
+// customized HTTP transport.
+var responseLimited int32
+
+// SetResponseLimited enables or disables to limit upstream response size by request context,
+// which takes effect for HTTP clients created afterwards only.
+func SetResponseLimited(enabled bool) {
+	if enabled {
+		atomic.StoreInt32(&responseLimited, 1)
+	} else {
+		atomic.StoreInt32(&responseLimited, 0)
+	}
+}
+
+func isResponseLimited() bool {
+	return atomic.LoadInt32(&responseLimited) == 1
+}
+
+// ResponseLimit limits the size of upstream HTTP response read for a request, so that oversized
+// responses are aborted while reading rather than fully buffered.
+type ResponseLimit struct {
+	maxSize int64
+	size    int64 // size of the oversized response read or declared, 0 if not exceeded
+}
+
+// WithResponseLimit returns a copy of context with the upstream response size limit in bytes.
+func WithResponseLimit(ctx context.Context, maxSize int) (context.Context, *ResponseLimit) {
+	limit := &ResponseLimit{maxSize: int64(maxSize)}
+	return context.WithValue(ctx, ctxKeyResponseLimit{}, limit), limit
+}
+
+// Exceeded returns the size of the oversized upstream response read so far if any.
+func (l *ResponseLimit) Exceeded() (int, bool) {
+	size := atomic.LoadInt64(&l.size)
+	return int(size), size > 0
+}
+
+func (l *ResponseLimit) exceed(size int64) {
+	atomic.StoreInt64(&l.size, size)
+}
+
+// limitTransport aborts reading upstream response once it exceeds the size limit in request
+// context, which applies to the whole JSON-RPC response over HTTP.
+type limitTransport struct {
+	base http.RoundTripper
+}
+
+func (t *limitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	limit, ok := req.Context().Value(ctxKeyResponseLimit{}).(*ResponseLimit)
+	if !ok {
+		return resp, nil
+	}
+
+	// abort early if oversized by content length
+	if resp.ContentLength > limit.maxSize {
+		resp.Body.Close()
+		limit.exceed(resp.ContentLength)
+		return nil, ErrResponseTooLarge
+	}
+
+	resp.Body = &limitedBody{ReadCloser: resp.Body, limit: limit}
+
+	return resp, nil
+}
+
+// limitedBody fails to read once the response body exceeds the size limit.
+type limitedBody struct {
+	io.ReadCloser
+	limit *ResponseLimit
+	read  int64
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.read > b.limit.maxSize {
+		return 0, ErrResponseTooLarge
+	}
+
+	// read at most one byte beyond the limit to detect oversized body
+	if remaining := b.limit.maxSize - b.read + 1; int64(len(p)) > remaining {
+		p = p[:remaining]
+	}
+
+	n, err := b.ReadCloser.Read(p)
+	b.read += int64(n)
+
+	if b.read > b.limit.maxSize {
+		b.limit.exceed(b.read)
+		return n, ErrResponseTooLarge
+	}
+
+	return n, err
+}
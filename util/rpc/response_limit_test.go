@@ -0,0 +1,62 @@
+package rpc
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimitTransport(t *testing.T) {
+	body := strings.Repeat("x", 100)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// chunked response without content length
+		w.Write([]byte(body[:50]))
+		w.(http.Flusher).Flush()
+		w.Write([]byte(body[50:]))
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: &limitTransport{base: http.DefaultTransport}}
+
+	get := func(ctx context.Context) (string, error) {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+
+		data, err := ioutil.ReadAll(resp.Body)
+		return string(data), err
+	}
+
+	// unlimited
+	data, err := get(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, body, data)
+
+	// within limit
+	ctx, limit := WithResponseLimit(context.Background(), 100)
+	data, err = get(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, body, data)
+
+	_, exceeded := limit.Exceeded()
+	assert.False(t, exceeded)
+
+	// aborted while reading
+	ctx, limit = WithResponseLimit(context.Background(), 60)
+	_, err = get(ctx)
+	assert.Equal(t, ErrResponseTooLarge, err)
+
+	size, exceeded := limit.Exceeded()
+	assert.True(t, exceeded)
+	assert.Equal(t, 61, size)
+}
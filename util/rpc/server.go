@@ -2,11 +2,13 @@ package rpc
 
 import (
 	"context"
+	"crypto/tls"
 	"net"
 	"net/http"
 	"sync"
 	"time"
 
+	viperutil "github.com/Conflux-Chain/go-conflux-util/viper"
 	"github.com/ethereum/go-ethereum/node"
 	"github.com/openweb3/go-rpc-provider"
 	"github.com/scroll-tech/rpc-gateway/util/rpc/handlers"
@@ -27,12 +29,22 @@ var (
 
 	// defaultWsPingInterval the default websocket ping/pong heartbeating interval.
 	defaultWsPingInterval = 10 * time.Second
+
+	// defaultStrictContentType whether to reject HTTP requests with invalid content type
+	// or charset by default.
+	defaultStrictContentType = false
+
+	// defaultMaxGzipBodySize the default max size of decompressed gzip request body, which
+	// conforms to the max request content length of RPC server.
+	defaultMaxGzipBodySize = 5 * 1024 * 1024
 )
 
 // Server serves JSON RPC services.
 type Server struct {
 	name    string
 	servers map[Protocol]*http.Server
+
+	tlsConfig *tls.Config // served over TLS if not nil
 }
 
 // MustNewServer creates an instance of Server with specified RPC services.
@@ -52,13 +64,36 @@ func MustNewServer(name string, rpcs map[string]interface{}, middlewares ...hand
 		"name": name,
 	}).Info("RPC server APIs registered")
 
+	// CORS is handled by the configurable middleware rather than the built-in one
+	httpHandler := node.NewHTTPHandlerStack(handler, nil, []string{"*"})
+
+	viper.SetDefault("rpc.strictContentType", defaultStrictContentType)
+	if viper.GetBool("rpc.strictContentType") {
+		httpHandler = handlers.StrictContentType(httpHandler)
+	}
+
+	viper.SetDefault("rpc.maxGzipBodySize", defaultMaxGzipBodySize)
+	httpHandler = handlers.GzipRequest(viper.GetInt64("rpc.maxGzipBodySize"))(httpHandler)
+
+	httpHandler = handlers.VerifyEnvelope(httpHandler)
+
+	// negotiated response compression rather than the built-in gzip compression
+	var compression handlers.CompressionConfig
+	viperutil.MustUnmarshalKey("rpc.compression", &compression)
+	httpHandler = handlers.Compression(&compression)(httpHandler)
+
+	// CORS headers are required for error responses as well
+	var cors handlers.CorsConfig
+	viperutil.MustUnmarshalKey("rpc.cors", &cors)
+	httpHandler = handlers.Cors(&cors)(httpHandler)
+
 	httpServer := http.Server{
-		Handler: node.NewHTTPHandlerStack(handler, []string{"*"}, []string{"*"}),
+		Handler: httpHandler,
 	}
 
 	viper.SetDefault("rpc.wsPingInterval", defaultWsPingInterval)
 	wsServer := http.Server{
-		Handler: handler.WebsocketHandler([]string{"*"}, rpc.WebsocketOption{
+		Handler: handler.WebsocketHandler(cors.WebsocketOrigins(), rpc.WebsocketOption{
 			WsPingInterval: viper.GetDuration("rpc.wsPingInterval"),
 		}),
 	}
@@ -68,6 +103,32 @@ func MustNewServer(name string, rpcs map[string]interface{}, middlewares ...hand
 		wsServer.Handler = middlewares[i](wsServer.Handler)
 	}
 
+	// hardening profile for public-facing deployments
+	var hardening handlers.HardeningConfig
+	viperutil.MustUnmarshalKey("rpc.hardening", &hardening)
+
+	if hardening.Enabled {
+		for _, server := range []*http.Server{&httpServer, &wsServer} {
+			server.Handler = handlers.Hardening(&hardening)(server.Handler)
+			server.MaxHeaderBytes = hardening.MaxHeaderBytes
+		}
+	}
+
+	// build info endpoint, which is still subject to IP filtering
+	var buildInfo handlers.BuildInfoConfig
+	viperutil.MustUnmarshalKey("rpc.buildInfo", &buildInfo)
+	httpServer.Handler = handlers.BuildInfo(&buildInfo)(httpServer.Handler)
+
+	// connection-level IP filtering before the RPC handler chain
+	for _, server := range []*http.Server{&httpServer, &wsServer} {
+		server.Handler = handlers.DefaultIPFilter.Middleware(server.Handler)
+	}
+
+	// health endpoints for orchestration, which bypass IP filtering for probes
+	var health handlers.HealthConfig
+	viperutil.MustUnmarshalKey("rpc.health", &health)
+	httpServer.Handler = handlers.Health(&health)(httpServer.Handler)
+
 	return &Server{
 		name: name,
 		servers: map[Protocol]*http.Server{
@@ -95,6 +156,11 @@ func (s *Server) MustServe(endpoint string, protocol Protocol) {
 		logger.WithError(err).Fatal("Failed to listen to endpoint")
 	}
 
+	if s.tlsConfig != nil {
+		listener = tls.NewListener(listener, s.tlsConfig)
+		logger = logger.WithField("tls", true)
+	}
+
 	logger.Info("JSON RPC server started")
 
 	server.Serve(listener)
@@ -130,4 +196,10 @@ func (s *Server) shutdown(protocol Protocol) {
 	}
 }
 
+// HttpHandler returns the HTTP handler with all middlewares, e.g. to invoke JSON-RPC in process
+// from other transports.
+func (s *Server) HttpHandler() http.Handler {
+	return s.servers[ProtocolHttp].Handler
+}
+
 func (s *Server) String() string { return s.name }
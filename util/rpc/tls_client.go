@@ -0,0 +1,114 @@
+package rpc
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// TlsClientConfig TLS configurations to connect upstream nodes over HTTPS, e.g. private CA
+// bundle and client certificate for mTLS, which is only available for evm space.
+type TlsClientConfig struct {
+	// PEM encoded CA bundle to verify upstream certificates, empty means system roots
+	CAFile string
+	// PEM encoded client certificate and private key for mTLS
+	CertFile string
+	KeyFile  string
+	// server name to verify upstream certificates, empty means the host of node URL
+	ServerName string
+}
+
+// newTlsConfig loads certificates and creates TLS configurations.
+func (c *TlsClientConfig) newTlsConfig() (*tls.Config, error) {
+	tlsConf := tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: c.ServerName,
+	}
+
+	if len(c.CAFile) > 0 {
+		pool, err := loadCertPool(c.CAFile)
+		if err != nil {
+			return nil, err
+		}
+
+		tlsConf.RootCAs = pool
+	}
+
+	if len(c.CertFile) > 0 || len(c.KeyFile) > 0 {
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, errors.WithMessage(err, "failed to load client certificate")
+		}
+
+		tlsConf.Certificates = []tls.Certificate{cert}
+	}
+
+	return &tlsConf, nil
+}
+
+// loadCertPool loads PEM encoded CA bundle from file.
+func loadCertPool(file string) (*x509.CertPool, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to read CA bundle")
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, errors.Errorf("no certificate found in CA bundle %v", file)
+	}
+
+	return pool, nil
+}
+
+// upstreamTlsRegistry holds TLS configurations of upstream nodes, which could be updated
+// at runtime along with node groups and applies to clients created afterwards.
+type upstreamTlsRegistry struct {
+	node2Confs map[string]*tls.Config // node name => TLS config
+	mu         sync.RWMutex
+}
+
+var upstreamTls = &upstreamTlsRegistry{node2Confs: make(map[string]*tls.Config)}
+
+// get returns a copy of TLS configurations for the specified node if any.
+func (r *upstreamTlsRegistry) get(nodeName string) (*tls.Config, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	conf, ok := r.node2Confs[nodeName]
+	if !ok {
+		return nil, false
+	}
+
+	return conf.Clone(), true
+}
+
+// SetUpstreamTls replaces TLS configurations of all upstream nodes atomically, which are
+// keyed by node URL.
+func SetUpstreamTls(confs map[string]*TlsClientConfig) error {
+	node2Confs := make(map[string]*tls.Config, len(confs))
+
+	for url, conf := range confs {
+		if !strings.HasPrefix(url, "https://") {
+			return errors.Errorf("upstream TLS only supported over HTTPS: %v", url)
+		}
+
+		tlsConf, err := conf.newTlsConfig()
+		if err != nil {
+			return errors.WithMessagef(err, "invalid upstream TLS config for node %v", url)
+		}
+
+		node2Confs[Url2NodeName(url)] = tlsConf
+	}
+
+	upstreamTls.mu.Lock()
+	defer upstreamTls.mu.Unlock()
+
+	upstreamTls.node2Confs = node2Confs
+
+	return nil
+}
@@ -0,0 +1,29 @@
+package rpc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetUpstreamTls(t *testing.T) {
+	defer SetUpstreamTls(nil)
+
+	err := SetUpstreamTls(map[string]*TlsClientConfig{"ws://127.0.0.1:8546": {}})
+	assert.Error(t, err)
+
+	err = SetUpstreamTls(map[string]*TlsClientConfig{"https://evm.example.com": {CAFile: "not_exists.pem"}})
+	assert.Error(t, err)
+
+	err = SetUpstreamTls(map[string]*TlsClientConfig{"https://evm.example.com": {ServerName: "evm.internal"}})
+	assert.Nil(t, err)
+
+	conf, ok := upstreamTls.get(Url2NodeName("https://evm.example.com"))
+	assert.True(t, ok)
+	assert.Equal(t, "evm.internal", conf.ServerName)
+
+	// copy returned to avoid changes by transport
+	conf.ServerName = "changed"
+	conf, _ = upstreamTls.get(Url2NodeName("https://evm.example.com"))
+	assert.Equal(t, "evm.internal", conf.ServerName)
+}
@@ -0,0 +1,113 @@
+package rpc
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var errTlsPinMismatch = errors.New("upstream TLS certificate does not match any pin")
+
+// TlsPinConfig pins TLS certificates of upstream node, so that a compromised DNS entry or
+// CA can't silently redirect traffic to a rogue upstream.
+type TlsPinConfig struct {
+	// upstream node URL over HTTPS
+	URL string
+	// base64 encoded SHA-256 hashes of SubjectPublicKeyInfo, which survive certificate renewal
+	// with the same key pair
+	Spki []string
+	// hex encoded SHA-256 fingerprints of DER encoded certificates
+	Certs []string
+}
+
+// tlsPins is the pinned SHA-256 hashes of some upstream node.
+type tlsPins struct {
+	spki  map[[sha256.Size]byte]bool
+	certs map[[sha256.Size]byte]bool
+}
+
+func newTlsPins(conf *TlsPinConfig) (*tlsPins, error) {
+	if len(conf.Spki) == 0 && len(conf.Certs) == 0 {
+		return nil, errors.New("no pin configured")
+	}
+
+	pins := tlsPins{
+		spki:  make(map[[sha256.Size]byte]bool),
+		certs: make(map[[sha256.Size]byte]bool),
+	}
+
+	for _, v := range conf.Spki {
+		hash, err := base64.StdEncoding.DecodeString(v)
+		if err != nil || len(hash) != sha256.Size {
+			return nil, errors.Errorf("invalid base64 encoded SPKI SHA-256 hash %v", v)
+		}
+
+		var key [sha256.Size]byte
+		copy(key[:], hash)
+		pins.spki[key] = true
+	}
+
+	for _, v := range conf.Certs {
+		hash, err := hex.DecodeString(strings.ReplaceAll(strings.TrimPrefix(v, "0x"), ":", ""))
+		if err != nil || len(hash) != sha256.Size {
+			return nil, errors.Errorf("invalid hex encoded certificate SHA-256 fingerprint %v", v)
+		}
+
+		var key [sha256.Size]byte
+		copy(key[:], hash)
+		pins.certs[key] = true
+	}
+
+	return &pins, nil
+}
+
+// verify checks if any certificate in the verified chains matches the pins, in addition
+// to the standard certificate verification.
+func (p *tlsPins) verify(state tls.ConnectionState) error {
+	for _, chain := range state.VerifiedChains {
+		for _, cert := range chain {
+			if p.match(cert) {
+				return nil
+			}
+		}
+	}
+
+	return errTlsPinMismatch
+}
+
+func (p *tlsPins) match(cert *x509.Certificate) bool {
+	return p.spki[sha256.Sum256(cert.RawSubjectPublicKeyInfo)] || p.certs[sha256.Sum256(cert.Raw)]
+}
+
+// tlsConfig returns TLS configurations to verify pinned certificates.
+func (p *tlsPins) tlsConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion:       tls.VersionTLS12,
+		VerifyConnection: p.verify,
+	}
+}
+
+// loadTlsPins loads TLS pins of upstream nodes keyed by node name.
+func loadTlsPins(confs []TlsPinConfig) (map[string]*tlsPins, error) {
+	pins := make(map[string]*tlsPins, len(confs))
+
+	for i := range confs {
+		if !strings.HasPrefix(confs[i].URL, "https://") {
+			return nil, errors.Errorf("TLS pinning only supported over HTTPS: %v", confs[i].URL)
+		}
+
+		p, err := newTlsPins(&confs[i])
+		if err != nil {
+			return nil, errors.WithMessagef(err, "invalid TLS pins for %v", confs[i].URL)
+		}
+
+		pins[Url2NodeName(confs[i].URL)] = p
+	}
+
+	return pins, nil
+}
@@ -0,0 +1,35 @@
+package rpc
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTlsPins(t *testing.T) {
+	cert := &x509.Certificate{Raw: []byte("cert"), RawSubjectPublicKeyInfo: []byte("spki")}
+	spkiHash := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	certHash := sha256.Sum256(cert.Raw)
+
+	pins, err := newTlsPins(&TlsPinConfig{Spki: []string{base64.StdEncoding.EncodeToString(spkiHash[:])}})
+	assert.Nil(t, err)
+	assert.Nil(t, pins.verify(tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}))
+
+	pins, err = newTlsPins(&TlsPinConfig{Certs: []string{hex.EncodeToString(certHash[:])}})
+	assert.Nil(t, err)
+	assert.True(t, pins.match(cert))
+
+	other := &x509.Certificate{Raw: []byte("other"), RawSubjectPublicKeyInfo: []byte("other")}
+	assert.Equal(t, errTlsPinMismatch, pins.verify(tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{other}}}))
+
+	_, err = newTlsPins(&TlsPinConfig{Spki: []string{"invalid"}})
+	assert.Error(t, err)
+
+	_, err = loadTlsPins([]TlsPinConfig{{URL: "http://127.0.0.1:8545", Certs: []string{hex.EncodeToString(certHash[:])}}})
+	assert.Error(t, err)
+}
@@ -0,0 +1,86 @@
+package rpc
+
+import (
+	"crypto/tls"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// TlsServerConfig TLS configurations of RPC server, which optionally verifies client
+// certificates (mTLS) for deployments exposed across networks.
+type TlsServerConfig struct {
+	Enabled bool
+	// PEM encoded server certificate and private key
+	CertFile string
+	KeyFile  string
+	// PEM encoded CA bundle to verify client certificates, empty means mTLS disabled
+	ClientCAFile string
+	// whether to accept clients without certificate when mTLS enabled
+	ClientCertOptional bool
+	// allowed common names of client certificates, empty means any verified client
+	AllowedClients []string
+}
+
+// newTlsConfig loads certificates and creates TLS configurations.
+func (c *TlsServerConfig) newTlsConfig() (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to load server certificate")
+	}
+
+	tlsConf := tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+	}
+
+	if len(c.ClientCAFile) == 0 {
+		return &tlsConf, nil
+	}
+
+	if tlsConf.ClientCAs, err = loadCertPool(c.ClientCAFile); err != nil {
+		return nil, err
+	}
+
+	tlsConf.ClientAuth = tls.RequireAndVerifyClientCert
+	if c.ClientCertOptional {
+		tlsConf.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+
+	if len(c.AllowedClients) > 0 {
+		allowed := make(map[string]bool, len(c.AllowedClients))
+		for _, name := range c.AllowedClients {
+			allowed[name] = true
+		}
+
+		tlsConf.VerifyConnection = func(state tls.ConnectionState) error {
+			// client certificate is optional
+			if len(state.PeerCertificates) == 0 {
+				return nil
+			}
+
+			if cn := state.PeerCertificates[0].Subject.CommonName; !allowed[cn] {
+				return errors.Errorf("client certificate %q not allowed", cn)
+			}
+
+			return nil
+		}
+	}
+
+	return &tlsConf, nil
+}
+
+// MustEnableTls serves RPC server over TLS if enabled, or panics if failed to load
+// certificates.
+func (s *Server) MustEnableTls(conf *TlsServerConfig) {
+	if !conf.Enabled {
+		return
+	}
+
+	tlsConf, err := conf.newTlsConfig()
+	if err != nil {
+		logrus.WithError(err).WithField("name", s.name).Fatal("Failed to enable TLS for RPC server")
+	}
+
+	s.tlsConfig = tlsConf
+}
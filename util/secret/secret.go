@@ -0,0 +1,197 @@
+package secret
+
+import (
+	"context"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"time"
+
+	viperutil "github.com/Conflux-Chain/go-conflux-util/viper"
+	"github.com/pkg/errors"
+	"github.com/scroll-tech/rpc-gateway/util/reload"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// FileSecret loads the value of config key from secret file, e.g. injected by orchestrator
+// or Vault agent.
+type FileSecret struct {
+	// config key, e.g. `web3pay.billingKey`
+	Key  string
+	File string
+}
+
+// Config configurations to load sensitive configurations (e.g. Web3Pay keys, DB passwords,
+// Redis credentials) from secret files or Vault, instead of the config file.
+type Config struct {
+	Files []FileSecret
+	Vault VaultConfig
+	// interval to check secrets rotation, with 0 means never
+	RefreshInterval time.Duration `default:"1m"`
+}
+
+// manager loads secrets and merges them into viper configurations.
+type manager struct {
+	conf  *Config
+	vault *vaultClient
+
+	mu     sync.Mutex
+	values map[string]string // config key => secret value
+}
+
+func newManager(conf *Config) *manager {
+	m := manager{conf: conf}
+
+	if len(conf.Vault.Address) > 0 {
+		m.vault = newVaultClient(&conf.Vault)
+	}
+
+	return &m
+}
+
+// load loads secrets from all sources, of which Vault secrets take precedence.
+func (m *manager) load() (map[string]string, error) {
+	values := make(map[string]string)
+
+	for _, s := range m.conf.Files {
+		data, err := ioutil.ReadFile(s.File)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "failed to read secret file for %v", s.Key)
+		}
+
+		values[s.Key] = strings.TrimSpace(string(data))
+	}
+
+	if m.vault != nil {
+		vaultValues, err := m.vault.load()
+		if err != nil {
+			return nil, errors.WithMessage(err, "failed to load secrets from vault")
+		}
+
+		for k, v := range vaultValues {
+			values[k] = v
+		}
+	}
+
+	return values, nil
+}
+
+// apply loads secrets and merges them into viper configurations, which should be fired
+// whenever the config file is re-read.
+func (m *manager) apply() error {
+	values, err := m.load()
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	m.values = values
+	m.mu.Unlock()
+
+	return viper.MergeConfigMap(nestedMap(values))
+}
+
+// changed checks if any secret rotated since the last time applied.
+func (m *manager) changed() (bool, error) {
+	values, err := m.load()
+	if err != nil {
+		return false, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(values) != len(m.values) {
+		return true, nil
+	}
+
+	for k, v := range values {
+		if m.values[k] != v {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// watch reloads configurations once any secret rotated until the specified context done.
+func (m *manager) watch(ctx context.Context) {
+	ticker := time.NewTicker(m.conf.RefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			changed, err := m.changed()
+			if err != nil {
+				logrus.WithError(err).Warn("Failed to check secrets rotation")
+				continue
+			}
+
+			if !changed {
+				continue
+			}
+
+			logrus.Info("Secrets rotated, reloading configurations")
+
+			if err := reload.Reload(); err != nil {
+				logrus.WithError(err).Error("Failed to reload configurations after secrets rotated")
+			}
+		}
+	}
+}
+
+// nestedMap converts dotted config keys into nested map, e.g. `a.b: v` => `a: {b: v}`.
+func nestedMap(values map[string]string) map[string]interface{} {
+	result := make(map[string]interface{})
+
+	for key, value := range values {
+		path := strings.Split(strings.ToLower(key), ".")
+
+		m := result
+		for _, k := range path[:len(path)-1] {
+			sub, ok := m[k].(map[string]interface{})
+			if !ok {
+				sub = make(map[string]interface{})
+				m[k] = sub
+			}
+
+			m = sub
+		}
+
+		m[path[len(path)-1]] = value
+	}
+
+	return result
+}
+
+// MustInit loads secrets into viper configurations, and keeps them merged once config file
+// reloaded. Note, it should be called right after viper initialized, so that secrets are
+// available to all the subsequent components and its reload handler fires first.
+//
+// Rotated secrets apply to reloadable configurations at runtime, and others take effect
+// after restart.
+func MustInit() {
+	var conf Config
+	viperutil.MustUnmarshalKey("secrets", &conf)
+
+	for _, s := range conf.Files {
+		if len(s.Key) == 0 || len(s.File) == 0 {
+			logrus.WithField("secret", s).Fatal("Invalid secret file configuration")
+		}
+	}
+
+	m := newManager(&conf)
+	if err := m.apply(); err != nil {
+		logrus.WithError(err).Fatal("Failed to load secrets")
+	}
+
+	reload.Register("secrets", m.apply)
+
+	if conf.RefreshInterval > 0 && (len(conf.Files) > 0 || m.vault != nil) {
+		go m.watch(context.Background())
+	}
+}
@@ -0,0 +1,48 @@
+package secret
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNestedMap(t *testing.T) {
+	m := nestedMap(map[string]string{
+		"web3pay.billingKey":   "key",
+		"store.mysql.password": "pass",
+		"store.mysql.username": "user",
+	})
+
+	assert.Equal(t, map[string]interface{}{
+		"web3pay": map[string]interface{}{"billingkey": "key"},
+		"store": map[string]interface{}{
+			"mysql": map[string]interface{}{"password": "pass", "username": "user"},
+		},
+	}, m)
+}
+
+func TestFileSecretRotation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "secret")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "password")
+	assert.Nil(t, ioutil.WriteFile(file, []byte("pass1\n"), 0600))
+
+	m := newManager(&Config{Files: []FileSecret{{Key: "store.mysql.password", File: file}}})
+	assert.Nil(t, m.apply())
+	assert.Equal(t, map[string]string{"store.mysql.password": "pass1"}, m.values)
+
+	changed, err := m.changed()
+	assert.Nil(t, err)
+	assert.False(t, changed)
+
+	assert.Nil(t, ioutil.WriteFile(file, []byte("pass2\n"), 0600))
+
+	changed, err = m.changed()
+	assert.Nil(t, err)
+	assert.True(t, changed)
+}
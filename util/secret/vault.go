@@ -0,0 +1,114 @@
+package secret
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// VaultSecret loads the value of config key from field of Vault secret.
+type VaultSecret struct {
+	// config key, e.g. `store.mysql.password`
+	Key   string
+	Field string
+}
+
+// VaultConfig configurations to load secrets from Vault KV (version 2) secrets engine.
+type VaultConfig struct {
+	// Vault server address, empty means disabled
+	Address string
+	// Vault token, or file of token renewed by Vault agent
+	Token     string
+	TokenFile string
+	// mount path of KV secrets engine
+	Mount string `default:"secret"`
+	// secret path under the mount
+	Path    string
+	Secrets []VaultSecret
+	Timeout time.Duration `default:"5s"`
+}
+
+// vaultClient reads secrets from Vault over HTTP API.
+type vaultClient struct {
+	conf   *VaultConfig
+	client *http.Client
+}
+
+func newVaultClient(conf *VaultConfig) *vaultClient {
+	return &vaultClient{
+		conf:   conf,
+		client: &http.Client{Timeout: conf.Timeout},
+	}
+}
+
+// token returns the Vault token, which is read from token file each time to support rotation.
+func (c *vaultClient) token() (string, error) {
+	if len(c.conf.TokenFile) == 0 {
+		return c.conf.Token, nil
+	}
+
+	data, err := ioutil.ReadFile(c.conf.TokenFile)
+	if err != nil {
+		return "", errors.WithMessage(err, "failed to read token file")
+	}
+
+	return strings.TrimSpace(string(data)), nil
+}
+
+// load reads the configured secret, and returns config key => secret value.
+func (c *vaultClient) load() (map[string]string, error) {
+	token, err := c.token()
+	if err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf(
+		"%v/v1/%v/data/%v",
+		strings.TrimSuffix(c.conf.Address, "/"), strings.Trim(c.conf.Mount, "/"), strings.Trim(c.conf.Path, "/"),
+	)
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("X-Vault-Token", token)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("unexpected status %v", resp.Status)
+	}
+
+	var result struct {
+		Data struct {
+			Data map[string]interface{} `json:"data"`
+		} `json:"data"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, errors.WithMessage(err, "invalid response")
+	}
+
+	values := make(map[string]string, len(c.conf.Secrets))
+
+	for _, s := range c.conf.Secrets {
+		v, ok := result.Data.Data[s.Field]
+		if !ok {
+			return nil, errors.Errorf("field %v not found in secret %v", s.Field, c.conf.Path)
+		}
+
+		values[s.Key] = fmt.Sprint(v)
+	}
+
+	return values, nil
+}
@@ -0,0 +1,245 @@
+// Package usage aggregates per API key call counts, method breakdowns, errors and data
+// transferred into daily rollups, so that users could query their own usage, e.g. to debug
+// billing disputes.
+package usage
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// DayLayout is the layout of day in daily rollups, which is in UTC.
+const DayLayout = "2006-01-02"
+
+// Config configurations of per API key usage analytics.
+type Config struct {
+	Enabled bool
+	// interval to flush aggregated usages into store
+	FlushInterval time.Duration `default:"1m"`
+	// max number of pending rollups in memory, and more are dropped if store unavailable
+	MaxPending int `default:"100000"`
+	// max number of days to query at once
+	MaxQueryDays int `default:"90"`
+}
+
+// Record is the daily usage rollup of an API key for a RPC method.
+type Record struct {
+	Day    string `json:"day"`
+	Key    string `json:"-"`
+	Method string `json:"method"`
+	Calls  uint64 `json:"calls"`
+	Errors uint64 `json:"errors"`
+	Bytes  uint64 `json:"bytes"` // response data transferred
+}
+
+// Store persists daily usage rollups.
+type Store interface {
+	// AddUsages increases daily usage rollups.
+	AddUsages(records []*Record) error
+	// GetUsages returns the daily usage rollups of API key within the day range inclusively.
+	GetUsages(key string, dayFrom, dayTo string) ([]*Record, error)
+}
+
+type recordKey struct {
+	day, key, method string
+}
+
+// Aggregator aggregates usages in memory, and flushes into store periodically.
+type Aggregator struct {
+	conf  Config
+	store Store
+
+	mu      sync.Mutex
+	pending map[recordKey]*Record
+}
+
+func NewAggregator(conf Config, store Store) *Aggregator {
+	return &Aggregator{
+		conf:    conf,
+		store:   store,
+		pending: make(map[recordKey]*Record),
+	}
+}
+
+// Add aggregates the usage of a RPC call.
+func (a *Aggregator) Add(key, method string, failed bool, bytes int) {
+	rk := recordKey{time.Now().UTC().Format(DayLayout), key, method}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	record, ok := a.pending[rk]
+	if !ok {
+		if len(a.pending) >= a.conf.MaxPending {
+			logrus.WithField("key", key).Debug("Usage record dropped due to too many pending")
+			return
+		}
+
+		record = &Record{Day: rk.day, Key: key, Method: method}
+		a.pending[rk] = record
+	}
+
+	record.Calls++
+	record.Bytes += uint64(bytes)
+
+	if failed {
+		record.Errors++
+	}
+}
+
+// Run flushes aggregated usages into store periodically until context done.
+func (a *Aggregator) Run(ctx context.Context) {
+	ticker := time.NewTicker(a.conf.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			if err := a.Flush(); err != nil {
+				logrus.WithError(err).Warn("Failed to flush usages on shutdown")
+			}
+			return
+		case <-ticker.C:
+			if err := a.Flush(); err != nil {
+				logrus.WithError(err).Warn("Failed to flush usages")
+			}
+		}
+	}
+}
+
+// Flush flushes aggregated usages into store, which are merged back to retry on failure.
+func (a *Aggregator) Flush() error {
+	a.mu.Lock()
+	pending := a.pending
+	a.pending = make(map[recordKey]*Record)
+	a.mu.Unlock()
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	records := make([]*Record, 0, len(pending))
+	for _, r := range pending {
+		records = append(records, r)
+	}
+
+	err := a.store.AddUsages(records)
+	if err == nil {
+		return nil
+	}
+
+	// merge back to retry later
+	a.mu.Lock()
+	for rk, r := range pending {
+		if merged, ok := a.pending[rk]; ok {
+			merged.Calls += r.Calls
+			merged.Errors += r.Errors
+			merged.Bytes += r.Bytes
+		} else if len(a.pending) < a.conf.MaxPending {
+			a.pending[rk] = r
+		}
+	}
+	a.mu.Unlock()
+
+	return errors.WithMessage(err, "failed to add usages")
+}
+
+// DailyUsage is the usage of API key in a day with method breakdowns.
+type DailyUsage struct {
+	Day       string    `json:"day"`
+	Calls     uint64    `json:"calls"`
+	Errors    uint64    `json:"errors"`
+	ErrorRate float64   `json:"errorRate"`
+	Bytes     uint64    `json:"bytes"`
+	Methods   []*Record `json:"methods"`
+}
+
+// Query returns the daily usages of API key within the day range inclusively, which defaults
+// to today if not specified.
+func (a *Aggregator) Query(key string, dayFrom, dayTo string) ([]*DailyUsage, error) {
+	today := time.Now().UTC().Format(DayLayout)
+	if len(dayTo) == 0 {
+		dayTo = today
+	}
+
+	if len(dayFrom) == 0 {
+		dayFrom = dayTo
+	}
+
+	from, err := time.Parse(DayLayout, dayFrom)
+	if err != nil {
+		return nil, errors.Errorf("invalid day %v, expected format %v", dayFrom, DayLayout)
+	}
+
+	to, err := time.Parse(DayLayout, dayTo)
+	if err != nil {
+		return nil, errors.Errorf("invalid day %v, expected format %v", dayTo, DayLayout)
+	}
+
+	if to.Before(from) || int(to.Sub(from).Hours()/24) >= a.conf.MaxQueryDays {
+		return nil, errors.Errorf("invalid day range, max %v days allowed", a.conf.MaxQueryDays)
+	}
+
+	records, err := a.store.GetUsages(key, dayFrom, dayTo)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to get usages")
+	}
+
+	return rollup(records), nil
+}
+
+// rollup groups records by day in ascending order.
+func rollup(records []*Record) []*DailyUsage {
+	days := make(map[string]*DailyUsage)
+
+	for _, r := range records {
+		du, ok := days[r.Day]
+		if !ok {
+			du = &DailyUsage{Day: r.Day}
+			days[r.Day] = du
+		}
+
+		du.Calls += r.Calls
+		du.Errors += r.Errors
+		du.Bytes += r.Bytes
+		du.Methods = append(du.Methods, r)
+	}
+
+	result := make([]*DailyUsage, 0, len(days))
+	for _, du := range days {
+		if du.Calls > 0 {
+			du.ErrorRate = float64(du.Errors) / float64(du.Calls)
+		}
+
+		sort.Slice(du.Methods, func(i, j int) bool {
+			return du.Methods[i].Calls > du.Methods[j].Calls
+		})
+
+		result = append(result, du)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Day < result[j].Day
+	})
+
+	return result
+}
+
+var defaultAggregator atomic.Value // *Aggregator
+
+// SetDefault sets the default usage aggregator, which enables usage analytics.
+func SetDefault(a *Aggregator) {
+	defaultAggregator.Store(a)
+}
+
+// Default returns the default usage aggregator if usage analytics enabled.
+func Default() (*Aggregator, bool) {
+	a, ok := defaultAggregator.Load().(*Aggregator)
+	return a, ok
+}
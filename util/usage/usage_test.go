@@ -0,0 +1,28 @@
+package usage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRollup(t *testing.T) {
+	result := rollup([]*Record{
+		{Day: "2023-06-02", Method: "eth_call", Calls: 4, Errors: 1, Bytes: 100},
+		{Day: "2023-06-01", Method: "eth_call", Calls: 2, Bytes: 50},
+		{Day: "2023-06-02", Method: "eth_getLogs", Calls: 6, Errors: 1, Bytes: 900},
+	})
+
+	assert.Equal(t, 2, len(result))
+
+	assert.Equal(t, "2023-06-01", result[0].Day)
+	assert.Equal(t, uint64(2), result[0].Calls)
+	assert.Equal(t, float64(0), result[0].ErrorRate)
+
+	assert.Equal(t, "2023-06-02", result[1].Day)
+	assert.Equal(t, uint64(10), result[1].Calls)
+	assert.Equal(t, uint64(2), result[1].Errors)
+	assert.Equal(t, uint64(1000), result[1].Bytes)
+	assert.Equal(t, 0.2, result[1].ErrorRate)
+	assert.Equal(t, "eth_getLogs", result[1].Methods[0].Method)
+}